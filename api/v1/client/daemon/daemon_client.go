@@ -24,6 +24,39 @@ type Client struct {
 	formats   strfmt.Registry
 }
 
+/*
+DeleteConntrack purges entries of the connection tracking table
+
+Removes the connection tracking entries, and the NAT entries
+associated with them, which match all of the given criteria. At
+least one criterion must be given.
+
+*/
+func (a *Client) DeleteConntrack(params *DeleteConntrackParams) (*DeleteConntrackOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewDeleteConntrackParams()
+	}
+
+	result, err := a.transport.Submit(&runtime.ClientOperation{
+		ID:                 "DeleteConntrack",
+		Method:             "DELETE",
+		PathPattern:        "/conntrack",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &DeleteConntrackReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*DeleteConntrackOK), nil
+
+}
+
 /*
 GetClusterNodes gets nodes information stored in the cilium agent
 */
@@ -83,6 +116,72 @@ func (a *Client) GetConfig(params *GetConfigParams) (*GetConfigOK, error) {
 
 }
 
+/*
+GetConntrack retrieves entries of the connection tracking table
+
+Retrieves the entries of the connection tracking table, optionally
+filtered by endpoint, source and destination CIDR, port, protocol
+and flags.
+
+*/
+func (a *Client) GetConntrack(params *GetConntrackParams) (*GetConntrackOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetConntrackParams()
+	}
+
+	result, err := a.transport.Submit(&runtime.ClientOperation{
+		ID:                 "GetConntrack",
+		Method:             "GET",
+		PathPattern:        "/conntrack",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetConntrackReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*GetConntrackOK), nil
+
+}
+
+/*
+GetConntrackStatistics retrieves statistics of the connection tracking table
+
+Returns the number of connection tracking entries per endpoint,
+protocol and state, the fill ratio of each connection tracking map
+and the status of the garbage collector.
+
+*/
+func (a *Client) GetConntrackStatistics(params *GetConntrackStatisticsParams) (*GetConntrackStatisticsOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetConntrackStatisticsParams()
+	}
+
+	result, err := a.transport.Submit(&runtime.ClientOperation{
+		ID:                 "GetConntrackStatistics",
+		Method:             "GET",
+		PathPattern:        "/conntrack/statistics",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &GetConntrackStatisticsReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*GetConntrackStatisticsOK), nil
+
+}
+
 /*
 GetDebuginfo retrieves information about the agent and evironment for debugging
 */
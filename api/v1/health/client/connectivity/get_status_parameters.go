@@ -13,6 +13,7 @@ import (
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/runtime"
 	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/swag"
 
 	strfmt "github.com/go-openapi/strfmt"
 )
@@ -20,7 +21,7 @@ import (
 // NewGetStatusParams creates a new GetStatusParams object
 // with the default values initialized.
 func NewGetStatusParams() *GetStatusParams {
-
+	var ()
 	return &GetStatusParams{
 
 		timeout: cr.DefaultTimeout,
@@ -30,7 +31,7 @@ func NewGetStatusParams() *GetStatusParams {
 // NewGetStatusParamsWithTimeout creates a new GetStatusParams object
 // with the default values initialized, and the ability to set a timeout on a request
 func NewGetStatusParamsWithTimeout(timeout time.Duration) *GetStatusParams {
-
+	var ()
 	return &GetStatusParams{
 
 		timeout: timeout,
@@ -40,7 +41,7 @@ func NewGetStatusParamsWithTimeout(timeout time.Duration) *GetStatusParams {
 // NewGetStatusParamsWithContext creates a new GetStatusParams object
 // with the default values initialized, and the ability to set a context for a request
 func NewGetStatusParamsWithContext(ctx context.Context) *GetStatusParams {
-
+	var ()
 	return &GetStatusParams{
 
 		Context: ctx,
@@ -50,7 +51,7 @@ func NewGetStatusParamsWithContext(ctx context.Context) *GetStatusParams {
 // NewGetStatusParamsWithHTTPClient creates a new GetStatusParams object
 // with the default values initialized, and the ability to set a custom HTTPClient for a request
 func NewGetStatusParamsWithHTTPClient(client *http.Client) *GetStatusParams {
-
+	var ()
 	return &GetStatusParams{
 		HTTPClient: client,
 	}
@@ -60,6 +61,15 @@ func NewGetStatusParamsWithHTTPClient(client *http.Client) *GetStatusParams {
 for the get status operation typically these are written to a http.Request
 */
 type GetStatusParams struct {
+
+	/*History
+	  Include the rolling history of probes of each path in the
+	connectivity status
+
+
+	*/
+	History *bool
+
 	timeout    time.Duration
 	Context    context.Context
 	HTTPClient *http.Client
@@ -98,6 +108,17 @@ func (o *GetStatusParams) SetHTTPClient(client *http.Client) {
 	o.HTTPClient = client
 }
 
+// WithHistory adds the history to the get status params
+func (o *GetStatusParams) WithHistory(history *bool) *GetStatusParams {
+	o.SetHistory(history)
+	return o
+}
+
+// SetHistory adds the history to the get status params
+func (o *GetStatusParams) SetHistory(history *bool) {
+	o.History = history
+}
+
 // WriteToRequest writes these params to a swagger request
 func (o *GetStatusParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
 
@@ -106,6 +127,22 @@ func (o *GetStatusParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Reg
 	}
 	var res []error
 
+	if o.History != nil {
+
+		// query param history
+		var qrHistory bool
+		if o.History != nil {
+			qrHistory = *o.History
+		}
+		qHistory := swag.FormatBool(qrHistory)
+		if qHistory != "" {
+			if err := r.SetQueryParam("history", qHistory); err != nil {
+				return err
+			}
+		}
+
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -8,6 +8,7 @@ package models
 import (
 	strfmt "github.com/go-openapi/strfmt"
 
+	"github.com/go-openapi/errors"
 	"github.com/go-openapi/swag"
 )
 
@@ -15,6 +16,9 @@ import (
 // swagger:model ConnectivityStatus
 type ConnectivityStatus struct {
 
+	// Rolling history of probes of the path
+	History *ConnectivityHistory `json:"history,omitempty"`
+
 	// Round trip time to node in nanoseconds
 	Latency int64 `json:"latency,omitempty"`
 
@@ -24,6 +28,33 @@ type ConnectivityStatus struct {
 
 // Validate validates this connectivity status
 func (m *ConnectivityStatus) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateHistory(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *ConnectivityStatus) validateHistory(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.History) { // not required
+		return nil
+	}
+
+	if m.History != nil {
+		if err := m.History.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("history")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -60,6 +60,14 @@ func init() {
           "connectivity"
         ],
         "summary": "Get connectivity status of the Cilium cluster",
+        "parameters": [
+          {
+            "type": "boolean",
+            "description": "Include the rolling history of probes of each path in the\nconnectivity status\n",
+            "name": "history",
+            "in": "query"
+          }
+        ],
         "responses": {
           "200": {
             "description": "Success",
@@ -96,10 +104,80 @@ func init() {
     }
   },
   "definitions": {
+    "ConnectivityHistory": {
+      "description": "Rolling history of probes of a path over a time window\n",
+      "type": "object",
+      "properties": {
+        "latency-avg": {
+          "description": "Average round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "latency-buckets": {
+          "description": "Histogram of the round trip times of successful probes",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/LatencyBucket"
+          }
+        },
+        "latency-max": {
+          "description": "Maximum round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "latency-min": {
+          "description": "Minimum round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "lost": {
+          "description": "Number of probes lost within the window",
+          "type": "integer"
+        },
+        "packet-loss": {
+          "description": "Percentage of probes lost within the window",
+          "type": "number"
+        },
+        "samples": {
+          "description": "Results of the probes within the window, oldest first",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/ConnectivitySample"
+          }
+        },
+        "sent": {
+          "description": "Number of probes sent within the window",
+          "type": "integer"
+        },
+        "window": {
+          "description": "Time window covered by the history in seconds",
+          "type": "integer"
+        }
+      }
+    },
+    "ConnectivitySample": {
+      "description": "Result of a single probe",
+      "type": "object",
+      "properties": {
+        "latency": {
+          "description": "Round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "lost": {
+          "description": "Probe did not receive a response",
+          "type": "boolean"
+        },
+        "timestamp": {
+          "description": "Time at which the probe was sent in RFC3339 format",
+          "type": "string"
+        }
+      }
+    },
     "ConnectivityStatus": {
       "description": "Connectivity status of a path",
       "type": "object",
       "properties": {
+        "history": {
+          "description": "Rolling history of probes of the path",
+          "$ref": "#/definitions/ConnectivityHistory"
+        },
         "latency": {
           "description": "Round trip time to node in nanoseconds",
           "type": "integer"
@@ -162,6 +240,20 @@ func init() {
         }
       }
     },
+    "LatencyBucket": {
+      "description": "Bucket of a round trip time histogram",
+      "type": "object",
+      "properties": {
+        "count": {
+          "description": "Number of probes in the bucket",
+          "type": "integer"
+        },
+        "le": {
+          "description": "Upper bound of the bucket in nanoseconds, 0 if the bucket is\nunbounded\n",
+          "type": "integer"
+        }
+      }
+    },
     "LoadResponse": {
       "description": "System load on node",
       "type": "object",
@@ -274,6 +366,14 @@ func init() {
           "connectivity"
         ],
         "summary": "Get connectivity status of the Cilium cluster",
+        "parameters": [
+          {
+            "type": "boolean",
+            "description": "Include the rolling history of probes of each path in the\nconnectivity status\n",
+            "name": "history",
+            "in": "query"
+          }
+        ],
         "responses": {
           "200": {
             "description": "Success",
@@ -310,10 +410,80 @@ func init() {
     }
   },
   "definitions": {
+    "ConnectivityHistory": {
+      "description": "Rolling history of probes of a path over a time window\n",
+      "type": "object",
+      "properties": {
+        "latency-avg": {
+          "description": "Average round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "latency-buckets": {
+          "description": "Histogram of the round trip times of successful probes",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/LatencyBucket"
+          }
+        },
+        "latency-max": {
+          "description": "Maximum round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "latency-min": {
+          "description": "Minimum round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "lost": {
+          "description": "Number of probes lost within the window",
+          "type": "integer"
+        },
+        "packet-loss": {
+          "description": "Percentage of probes lost within the window",
+          "type": "number"
+        },
+        "samples": {
+          "description": "Results of the probes within the window, oldest first",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/ConnectivitySample"
+          }
+        },
+        "sent": {
+          "description": "Number of probes sent within the window",
+          "type": "integer"
+        },
+        "window": {
+          "description": "Time window covered by the history in seconds",
+          "type": "integer"
+        }
+      }
+    },
+    "ConnectivitySample": {
+      "description": "Result of a single probe",
+      "type": "object",
+      "properties": {
+        "latency": {
+          "description": "Round trip time in nanoseconds",
+          "type": "integer"
+        },
+        "lost": {
+          "description": "Probe did not receive a response",
+          "type": "boolean"
+        },
+        "timestamp": {
+          "description": "Time at which the probe was sent in RFC3339 format",
+          "type": "string"
+        }
+      }
+    },
     "ConnectivityStatus": {
       "description": "Connectivity status of a path",
       "type": "object",
       "properties": {
+        "history": {
+          "description": "Rolling history of probes of the path",
+          "$ref": "#/definitions/ConnectivityHistory"
+        },
         "latency": {
           "description": "Round trip time to node in nanoseconds",
           "type": "integer"
@@ -375,6 +545,20 @@ func init() {
         }
       }
     },
+    "LatencyBucket": {
+      "description": "Bucket of a round trip time histogram",
+      "type": "object",
+      "properties": {
+        "count": {
+          "description": "Number of probes in the bucket",
+          "type": "integer"
+        },
+        "le": {
+          "description": "Upper bound of the bucket in nanoseconds, 0 if the bucket is\nunbounded\n",
+          "type": "integer"
+        }
+      }
+    },
     "LoadResponse": {
       "description": "System load on node",
       "type": "object",
@@ -9,11 +9,17 @@ import (
 	"errors"
 	"net/url"
 	golangswaggerpaths "path"
+
+	"github.com/go-openapi/swag"
 )
 
 // GetStatusURL generates an URL for the get status operation
 type GetStatusURL struct {
+	History *bool
+
 	_basePath string
+	// avoid unkeyed usage
+	_ struct{}
 }
 
 // WithBasePath sets the base path for this url builder, only required when it's different from the
@@ -43,6 +49,18 @@ func (o *GetStatusURL) Build() (*url.URL, error) {
 	}
 	_result.Path = golangswaggerpaths.Join(_basePath, _path)
 
+	qs := make(url.Values)
+
+	var history string
+	if o.History != nil {
+		history = swag.FormatBool(*o.History)
+	}
+	if history != "" {
+		qs.Set("history", history)
+	}
+
+	_result.RawQuery = qs.Encode()
+
 	return &_result, nil
 }
 
@@ -6,6 +6,7 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"encoding/json"
 	"strconv"
 
 	strfmt "github.com/go-openapi/strfmt"
@@ -143,10 +144,132 @@ type ServiceSpecFlags struct {
 
 	// Perform direct server return
 	DirectServerReturn bool `json:"direct-server-return,omitempty"`
+
+	// Affinity of a global service to the clusters providing its backends
+	// Enum: [none local-preferred remote-only failover]
+	GlobalServiceAffinity string `json:"global-service-affinity,omitempty"`
+
+	// Clusters of which the backends are currently selected for a global service
+	GlobalServiceClusters []string `json:"global-service-clusters,omitempty"`
+
+	// Algorithm used to select a backend for new connections
+	// Enum: [random maglev]
+	LbAlgorithm string `json:"lb-algorithm,omitempty"`
+
+	// Send all connections of a client to the same backend (ClientIP session affinity)
+	SessionAffinity bool `json:"session-affinity,omitempty"`
+
+	// Time in seconds after which an idle session affinity expires
+	SessionAffinityTimeout int64 `json:"session-affinity-timeout,omitempty"`
 }
 
 // Validate validates this service spec flags
 func (m *ServiceSpecFlags) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateGlobalServiceAffinity(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateLbAlgorithm(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var serviceSpecFlagsTypeGlobalServiceAffinityPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["none","local-preferred","remote-only","failover"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		serviceSpecFlagsTypeGlobalServiceAffinityPropEnum = append(serviceSpecFlagsTypeGlobalServiceAffinityPropEnum, v)
+	}
+}
+
+const (
+
+	// ServiceSpecFlagsGlobalServiceAffinityNone captures enum value "none"
+	ServiceSpecFlagsGlobalServiceAffinityNone string = "none"
+
+	// ServiceSpecFlagsGlobalServiceAffinityLocalPreferred captures enum value "local-preferred"
+	ServiceSpecFlagsGlobalServiceAffinityLocalPreferred string = "local-preferred"
+
+	// ServiceSpecFlagsGlobalServiceAffinityRemoteOnly captures enum value "remote-only"
+	ServiceSpecFlagsGlobalServiceAffinityRemoteOnly string = "remote-only"
+
+	// ServiceSpecFlagsGlobalServiceAffinityFailover captures enum value "failover"
+	ServiceSpecFlagsGlobalServiceAffinityFailover string = "failover"
+)
+
+// prop value enum
+func (m *ServiceSpecFlags) validateGlobalServiceAffinityEnum(path, location string, value string) error {
+	if err := validate.Enum(path, location, value, serviceSpecFlagsTypeGlobalServiceAffinityPropEnum); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *ServiceSpecFlags) validateGlobalServiceAffinity(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.GlobalServiceAffinity) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateGlobalServiceAffinityEnum("flags"+"."+"global-service-affinity", "body", m.GlobalServiceAffinity); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var serviceSpecFlagsTypeLbAlgorithmPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["random","maglev"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		serviceSpecFlagsTypeLbAlgorithmPropEnum = append(serviceSpecFlagsTypeLbAlgorithmPropEnum, v)
+	}
+}
+
+const (
+
+	// ServiceSpecFlagsLbAlgorithmRandom captures enum value "random"
+	ServiceSpecFlagsLbAlgorithmRandom string = "random"
+
+	// ServiceSpecFlagsLbAlgorithmMaglev captures enum value "maglev"
+	ServiceSpecFlagsLbAlgorithmMaglev string = "maglev"
+)
+
+// prop value enum
+func (m *ServiceSpecFlags) validateLbAlgorithmEnum(path, location string, value string) error {
+	if err := validate.Enum(path, location, value, serviceSpecFlagsTypeLbAlgorithmPropEnum); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *ServiceSpecFlags) validateLbAlgorithm(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.LbAlgorithm) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateLbAlgorithmEnum("flags"+"."+"lb-algorithm", "body", m.LbAlgorithm); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -36,6 +36,9 @@ type StatusResponse struct {
 	// Status of all endpoint controllers
 	Controllers ControllerStatuses `json:"controllers,omitempty"`
 
+	// Status of transparent encryption
+	Encryption *EncryptionStatus `json:"encryption,omitempty"`
+
 	// Status of IP address management
 	IPAM *IPAMStatus `json:"ipam,omitempty"`
 
@@ -75,6 +78,10 @@ func (m *StatusResponse) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateEncryption(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateIPAM(formats); err != nil {
 		res = append(res, err)
 	}
@@ -175,6 +182,24 @@ func (m *StatusResponse) validateControllers(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *StatusResponse) validateEncryption(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Encryption) { // not required
+		return nil
+	}
+
+	if m.Encryption != nil {
+		if err := m.Encryption.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("encryption")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *StatusResponse) validateIPAM(formats strfmt.Registry) error {
 
 	if swag.IsZero(m.IPAM) { // not required
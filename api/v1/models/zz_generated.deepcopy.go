@@ -99,6 +99,27 @@ func (in *ControllerStatusStatus) DeepCopy() *ControllerStatusStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionStatus) DeepCopyInto(out *EncryptionStatus) {
+	*out = *in
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(IPSecKeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionStatus.
+func (in *EncryptionStatus) DeepCopy() *EncryptionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAMStatus) DeepCopyInto(out *IPAMStatus) {
 	*out = *in
@@ -132,6 +153,27 @@ func (in *IPAMStatus) DeepCopy() *IPAMStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPSecKeyRotationStatus) DeepCopyInto(out *IPSecKeyRotationStatus) {
+	*out = *in
+	if in.PendingNodes != nil {
+		in, out := &in.PendingNodes, &out.PendingNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPSecKeyRotationStatus.
+func (in *IPSecKeyRotationStatus) DeepCopy() *IPSecKeyRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IPSecKeyRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K8sStatus) DeepCopyInto(out *K8sStatus) {
 	*out = *in
@@ -292,6 +334,11 @@ func (in *StatusResponse) DeepCopyInto(out *StatusResponse) {
 			}
 		}
 	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(EncryptionStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.IPAM != nil {
 		in, out := &in.IPAM, &out.IPAM
 		*out = new(IPAMStatus)
@@ -108,6 +108,187 @@ func init() {
         }
       }
     },
+    "/conntrack": {
+      "get": {
+        "description": "Retrieves the entries of the connection tracking table, optionally\nfiltered by endpoint, source and destination CIDR, port, protocol\nand flags.\n",
+        "tags": [
+          "daemon"
+        ],
+        "summary": "Retrieve entries of the connection tracking table",
+        "parameters": [
+          {
+            "type": "integer",
+            "description": "ID of the endpoint whose connections to retrieve\n",
+            "name": "endpoint",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the source address",
+            "name": "source-cidr",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the destination address",
+            "name": "destination-cidr",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Source or destination port",
+            "name": "port",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "L4 protocol, e.g. TCP or UDP",
+            "name": "protocol",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "Comma separated list of flags which must all be set on the entry,\ne.g. RxClosing,SeenNonSyn\n",
+            "name": "flags",
+            "in": "query"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "type": "array",
+              "items": {
+                "$ref": "#/definitions/ConntrackEntry"
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid filter",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Invalid"
+          },
+          "404": {
+            "description": "Endpoint not found"
+          },
+          "500": {
+            "description": "Conntrack table could not be read",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      },
+      "delete": {
+        "description": "Removes the connection tracking entries, and the NAT entries\nassociated with them, which match all of the given criteria. At\nleast one criterion must be given.\n",
+        "tags": [
+          "daemon"
+        ],
+        "summary": "Purge entries of the connection tracking table",
+        "parameters": [
+          {
+            "type": "integer",
+            "description": "ID of the endpoint whose connections to purge\n",
+            "name": "endpoint",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the source address",
+            "name": "source-cidr",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the destination address",
+            "name": "destination-cidr",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Source or destination port, or first port of the range if\nend-port is given\n",
+            "name": "port",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Last port of the port range",
+            "name": "end-port",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "L4 protocol, e.g. TCP or UDP",
+            "name": "protocol",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Security identity of the source of the connection",
+            "name": "identity",
+            "in": "query"
+          },
+          {
+            "type": "boolean",
+            "description": "Only count the matching entries without removing them",
+            "name": "dry-run",
+            "in": "query"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/ConntrackPurgeResponse"
+            }
+          },
+          "400": {
+            "description": "Invalid filter",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Invalid"
+          },
+          "404": {
+            "description": "Endpoint not found"
+          },
+          "500": {
+            "description": "Conntrack table could not be purged",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
+    "/conntrack/statistics": {
+      "get": {
+        "description": "Returns the number of connection tracking entries per endpoint,\nprotocol and state, the fill ratio of each connection tracking map\nand the status of the garbage collector.\n",
+        "tags": [
+          "daemon"
+        ],
+        "summary": "Retrieve statistics of the connection tracking table",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/ConntrackStatistics"
+            }
+          },
+          "500": {
+            "description": "Conntrack table could not be read",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
     "/debuginfo": {
       "get": {
         "tags": [
@@ -1402,6 +1583,176 @@ func init() {
         "type": "string"
       }
     },
+    "ConntrackEntry": {
+      "description": "Entry of the connection tracking table",
+      "type": "object",
+      "properties": {
+        "destination-ip": {
+          "description": "Destination address of the connection",
+          "type": "string"
+        },
+        "destination-port": {
+          "description": "Destination port of the connection",
+          "type": "integer"
+        },
+        "direction": {
+          "description": "Direction of the connection, IN or OUT",
+          "type": "string"
+        },
+        "endpoint-id": {
+          "description": "ID of the endpoint the connection belongs to, 0 if unknown\n",
+          "type": "integer"
+        },
+        "flags": {
+          "description": "Flags set on the entry",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "lifetime": {
+          "description": "Number of seconds until the entry expires",
+          "type": "integer"
+        },
+        "map": {
+          "description": "Name of the conntrack map containing the entry",
+          "type": "string"
+        },
+        "protocol": {
+          "description": "L4 protocol of the connection",
+          "type": "string"
+        },
+        "related": {
+          "description": "Entry tracks a related connection, e.g. ICMP errors",
+          "type": "boolean"
+        },
+        "rev-nat": {
+          "description": "Reverse NAT index of the service",
+          "type": "integer"
+        },
+        "rx-bytes": {
+          "type": "integer"
+        },
+        "rx-packets": {
+          "type": "integer"
+        },
+        "service": {
+          "description": "Entry tracks a connection to a service",
+          "type": "boolean"
+        },
+        "source-ip": {
+          "description": "Source address of the connection",
+          "type": "string"
+        },
+        "source-port": {
+          "description": "Source port of the connection",
+          "type": "integer"
+        },
+        "source-security-id": {
+          "description": "Security identity of the source",
+          "type": "integer"
+        },
+        "state": {
+          "description": "State of the connection: opening, established, closing, closed,\nactive or expired\n",
+          "type": "string"
+        },
+        "tx-bytes": {
+          "type": "integer"
+        },
+        "tx-packets": {
+          "type": "integer"
+        }
+      }
+    },
+    "ConntrackMapStatistics": {
+      "description": "Number of entries of a connection tracking map",
+      "type": "object",
+      "properties": {
+        "entries": {
+          "description": "Number of entries",
+          "type": "integer"
+        },
+        "fill-ratio": {
+          "description": "Ratio of entries to the maximum number of entries",
+          "type": "number"
+        },
+        "max-entries": {
+          "description": "Maximum number of entries",
+          "type": "integer"
+        },
+        "name": {
+          "description": "Name of the map",
+          "type": "string"
+        }
+      }
+    },
+    "ConntrackPurgeResponse": {
+      "description": "Result of purging entries of the connection tracking table",
+      "type": "object",
+      "properties": {
+        "dry-run": {
+          "description": "True if the entries were only counted and not removed\n",
+          "type": "boolean"
+        },
+        "per-map": {
+          "description": "Number of purged entries per connection tracking and NAT map",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "total-entries": {
+          "description": "Total number of purged connection tracking and NAT entries",
+          "type": "integer"
+        }
+      }
+    },
+    "ConntrackStatistics": {
+      "description": "Aggregate statistics of the connection tracking table",
+      "type": "object",
+      "properties": {
+        "gc-delete-ratio": {
+          "description": "Maximum ratio of deleted entries to map size of the last garbage\ncollection run, which the garbage collector interval is derived\nfrom\n",
+          "type": "number"
+        },
+        "gc-interval": {
+          "description": "Interval of the garbage collector in seconds",
+          "type": "integer"
+        },
+        "maps": {
+          "description": "Statistics of each connection tracking map",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/ConntrackMapStatistics"
+          }
+        },
+        "per-endpoint": {
+          "description": "Number of entries per endpoint ID",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "per-protocol": {
+          "description": "Number of entries per L4 protocol",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "per-state": {
+          "description": "Number of entries per connection state",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "total-entries": {
+          "description": "Total number of entries",
+          "type": "integer"
+        }
+      }
+    },
     "ControllerStatus": {
       "description": "Status of a controller",
       "type": "object",
@@ -1652,6 +2003,29 @@ func init() {
         }
       }
     },
+    "EncryptionStatus": {
+      "description": "Status of transparent encryption",
+      "type": "object",
+      "properties": {
+        "key-rotation": {
+          "description": "Progress of the IPsec key rotation, if one is in progress",
+          "$ref": "#/definitions/IPSecKeyRotationStatus"
+        },
+        "mode": {
+          "description": "Encryption mode",
+          "type": "string",
+          "enum": [
+            "Disabled",
+            "IPsec",
+            "Wireguard"
+          ]
+        },
+        "msg": {
+          "description": "Human readable status/error/warning message",
+          "type": "string"
+        }
+      }
+    },
     "Endpoint": {
       "description": "An endpoint is a namespaced network interface to which cilium applies policies",
       "type": "object",
@@ -2171,6 +2545,31 @@ func init() {
         }
       }
     },
+    "IPSecKeyRotationStatus": {
+      "description": "Progress of an IPsec key rotation",
+      "type": "object",
+      "properties": {
+        "current-spi": {
+          "description": "SPI of the key being rotated in",
+          "type": "integer"
+        },
+        "migrated-nodes": {
+          "description": "Number of nodes advertising the current key",
+          "type": "integer"
+        },
+        "pending-nodes": {
+          "description": "Names of the nodes still advertising a previous key",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "previous-spi": {
+          "description": "SPI of the key being rotated out",
+          "type": "integer"
+        }
+      }
+    },
     "Identity": {
       "description": "Security identity",
       "type": "object",
@@ -2684,6 +3083,39 @@ func init() {
             "direct-server-return": {
               "description": "Perform direct server return",
               "type": "boolean"
+            },
+            "global-service-affinity": {
+              "description": "Affinity of a global service to the clusters providing its backends",
+              "type": "string",
+              "enum": [
+                "none",
+                "local-preferred",
+                "remote-only",
+                "failover"
+              ]
+            },
+            "global-service-clusters": {
+              "description": "Clusters of which the backends are currently selected for a global service",
+              "type": "array",
+              "items": {
+                "type": "string"
+              }
+            },
+            "lb-algorithm": {
+              "description": "Algorithm used to select a backend for new connections",
+              "type": "string",
+              "enum": [
+                "random",
+                "maglev"
+              ]
+            },
+            "session-affinity": {
+              "description": "Send all connections of a client to the same backend (ClientIP session affinity)",
+              "type": "boolean"
+            },
+            "session-affinity-timeout": {
+              "description": "Time in seconds after which an idle session affinity expires",
+              "type": "integer"
             }
           }
         },
@@ -2750,6 +3182,10 @@ func init() {
           "description": "Status of all endpoint controllers",
           "$ref": "#/definitions/ControllerStatuses"
         },
+        "encryption": {
+          "description": "Status of transparent encryption",
+          "$ref": "#/definitions/EncryptionStatus"
+        },
         "ipam": {
           "description": "Status of IP address management",
           "$ref": "#/definitions/IPAMStatus"
@@ -2977,66 +3413,247 @@ func init() {
         "summary": "Get nodes information stored in the cilium-agent",
         "parameters": [
           {
-            "type": "integer",
-            "description": "Client UUID should be used when the client wants to request\na diff of nodes added and / or removed since the last time\nthat client has made a request.\n",
-            "name": "client-id",
-            "in": "header"
+            "type": "integer",
+            "description": "Client UUID should be used when the client wants to request\na diff of nodes added and / or removed since the last time\nthat client has made a request.\n",
+            "name": "client-id",
+            "in": "header"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/ClusterNodeStatus"
+            }
+          }
+        }
+      }
+    },
+    "/config": {
+      "get": {
+        "description": "Returns the configuration of the Cilium daemon.\n",
+        "tags": [
+          "daemon"
+        ],
+        "summary": "Get configuration of Cilium daemon",
+        "responses": {
+          "200": {
+            "description": "Success",
+            "schema": {
+              "$ref": "#/definitions/DaemonConfiguration"
+            }
+          }
+        }
+      },
+      "patch": {
+        "description": "Updates the daemon configuration by applying the provided\nConfigurationMap and regenerates \u0026 recompiles all required datapath\ncomponents.\n",
+        "tags": [
+          "daemon"
+        ],
+        "summary": "Modify daemon configuration",
+        "parameters": [
+          {
+            "name": "configuration",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/DaemonConfigurationSpec"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Success"
+          },
+          "400": {
+            "description": "Bad configuration parameters",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            }
+          },
+          "500": {
+            "description": "Recompilation failed",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
+          }
+        }
+      }
+    },
+    "/conntrack": {
+      "get": {
+        "description": "Retrieves the entries of the connection tracking table, optionally\nfiltered by endpoint, source and destination CIDR, port, protocol\nand flags.\n",
+        "tags": [
+          "daemon"
+        ],
+        "summary": "Retrieve entries of the connection tracking table",
+        "parameters": [
+          {
+            "type": "integer",
+            "description": "ID of the endpoint whose connections to retrieve\n",
+            "name": "endpoint",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the source address",
+            "name": "source-cidr",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the destination address",
+            "name": "destination-cidr",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Source or destination port",
+            "name": "port",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "L4 protocol, e.g. TCP or UDP",
+            "name": "protocol",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "Comma separated list of flags which must all be set on the entry,\ne.g. RxClosing,SeenNonSyn\n",
+            "name": "flags",
+            "in": "query"
           }
         ],
         "responses": {
           "200": {
             "description": "Success",
             "schema": {
-              "$ref": "#/definitions/ClusterNodeStatus"
+              "type": "array",
+              "items": {
+                "$ref": "#/definitions/ConntrackEntry"
+              }
             }
+          },
+          "400": {
+            "description": "Invalid filter",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Invalid"
+          },
+          "404": {
+            "description": "Endpoint not found"
+          },
+          "500": {
+            "description": "Conntrack table could not be read",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
           }
         }
-      }
-    },
-    "/config": {
-      "get": {
-        "description": "Returns the configuration of the Cilium daemon.\n",
+      },
+      "delete": {
+        "description": "Removes the connection tracking entries, and the NAT entries\nassociated with them, which match all of the given criteria. At\nleast one criterion must be given.\n",
         "tags": [
           "daemon"
         ],
-        "summary": "Get configuration of Cilium daemon",
+        "summary": "Purge entries of the connection tracking table",
+        "parameters": [
+          {
+            "type": "integer",
+            "description": "ID of the endpoint whose connections to purge\n",
+            "name": "endpoint",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the source address",
+            "name": "source-cidr",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "CIDR range of the destination address",
+            "name": "destination-cidr",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Source or destination port, or first port of the range if\nend-port is given\n",
+            "name": "port",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Last port of the port range",
+            "name": "end-port",
+            "in": "query"
+          },
+          {
+            "type": "string",
+            "description": "L4 protocol, e.g. TCP or UDP",
+            "name": "protocol",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "Security identity of the source of the connection",
+            "name": "identity",
+            "in": "query"
+          },
+          {
+            "type": "boolean",
+            "description": "Only count the matching entries without removing them",
+            "name": "dry-run",
+            "in": "query"
+          }
+        ],
         "responses": {
           "200": {
             "description": "Success",
             "schema": {
-              "$ref": "#/definitions/DaemonConfiguration"
+              "$ref": "#/definitions/ConntrackPurgeResponse"
             }
+          },
+          "400": {
+            "description": "Invalid filter",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Invalid"
+          },
+          "404": {
+            "description": "Endpoint not found"
+          },
+          "500": {
+            "description": "Conntrack table could not be purged",
+            "schema": {
+              "$ref": "#/definitions/Error"
+            },
+            "x-go-name": "Failure"
           }
         }
-      },
-      "patch": {
-        "description": "Updates the daemon configuration by applying the provided\nConfigurationMap and regenerates \u0026 recompiles all required datapath\ncomponents.\n",
+      }
+    },
+    "/conntrack/statistics": {
+      "get": {
+        "description": "Returns the number of connection tracking entries per endpoint,\nprotocol and state, the fill ratio of each connection tracking map\nand the status of the garbage collector.\n",
         "tags": [
           "daemon"
         ],
-        "summary": "Modify daemon configuration",
-        "parameters": [
-          {
-            "name": "configuration",
-            "in": "body",
-            "required": true,
-            "schema": {
-              "$ref": "#/definitions/DaemonConfigurationSpec"
-            }
-          }
-        ],
+        "summary": "Retrieve statistics of the connection tracking table",
         "responses": {
           "200": {
-            "description": "Success"
-          },
-          "400": {
-            "description": "Bad configuration parameters",
+            "description": "Success",
             "schema": {
-              "$ref": "#/definitions/Error"
+              "$ref": "#/definitions/ConntrackStatistics"
             }
           },
           "500": {
-            "description": "Recompilation failed",
+            "description": "Conntrack table could not be read",
             "schema": {
               "$ref": "#/definitions/Error"
             },
@@ -4479,6 +5096,176 @@ func init() {
         "type": "string"
       }
     },
+    "ConntrackEntry": {
+      "description": "Entry of the connection tracking table",
+      "type": "object",
+      "properties": {
+        "destination-ip": {
+          "description": "Destination address of the connection",
+          "type": "string"
+        },
+        "destination-port": {
+          "description": "Destination port of the connection",
+          "type": "integer"
+        },
+        "direction": {
+          "description": "Direction of the connection, IN or OUT",
+          "type": "string"
+        },
+        "endpoint-id": {
+          "description": "ID of the endpoint the connection belongs to, 0 if unknown\n",
+          "type": "integer"
+        },
+        "flags": {
+          "description": "Flags set on the entry",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "lifetime": {
+          "description": "Number of seconds until the entry expires",
+          "type": "integer"
+        },
+        "map": {
+          "description": "Name of the conntrack map containing the entry",
+          "type": "string"
+        },
+        "protocol": {
+          "description": "L4 protocol of the connection",
+          "type": "string"
+        },
+        "related": {
+          "description": "Entry tracks a related connection, e.g. ICMP errors",
+          "type": "boolean"
+        },
+        "rev-nat": {
+          "description": "Reverse NAT index of the service",
+          "type": "integer"
+        },
+        "rx-bytes": {
+          "type": "integer"
+        },
+        "rx-packets": {
+          "type": "integer"
+        },
+        "service": {
+          "description": "Entry tracks a connection to a service",
+          "type": "boolean"
+        },
+        "source-ip": {
+          "description": "Source address of the connection",
+          "type": "string"
+        },
+        "source-port": {
+          "description": "Source port of the connection",
+          "type": "integer"
+        },
+        "source-security-id": {
+          "description": "Security identity of the source",
+          "type": "integer"
+        },
+        "state": {
+          "description": "State of the connection: opening, established, closing, closed,\nactive or expired\n",
+          "type": "string"
+        },
+        "tx-bytes": {
+          "type": "integer"
+        },
+        "tx-packets": {
+          "type": "integer"
+        }
+      }
+    },
+    "ConntrackMapStatistics": {
+      "description": "Number of entries of a connection tracking map",
+      "type": "object",
+      "properties": {
+        "entries": {
+          "description": "Number of entries",
+          "type": "integer"
+        },
+        "fill-ratio": {
+          "description": "Ratio of entries to the maximum number of entries",
+          "type": "number"
+        },
+        "max-entries": {
+          "description": "Maximum number of entries",
+          "type": "integer"
+        },
+        "name": {
+          "description": "Name of the map",
+          "type": "string"
+        }
+      }
+    },
+    "ConntrackPurgeResponse": {
+      "description": "Result of purging entries of the connection tracking table",
+      "type": "object",
+      "properties": {
+        "dry-run": {
+          "description": "True if the entries were only counted and not removed\n",
+          "type": "boolean"
+        },
+        "per-map": {
+          "description": "Number of purged entries per connection tracking and NAT map",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "total-entries": {
+          "description": "Total number of purged connection tracking and NAT entries",
+          "type": "integer"
+        }
+      }
+    },
+    "ConntrackStatistics": {
+      "description": "Aggregate statistics of the connection tracking table",
+      "type": "object",
+      "properties": {
+        "gc-delete-ratio": {
+          "description": "Maximum ratio of deleted entries to map size of the last garbage\ncollection run, which the garbage collector interval is derived\nfrom\n",
+          "type": "number"
+        },
+        "gc-interval": {
+          "description": "Interval of the garbage collector in seconds",
+          "type": "integer"
+        },
+        "maps": {
+          "description": "Statistics of each connection tracking map",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/ConntrackMapStatistics"
+          }
+        },
+        "per-endpoint": {
+          "description": "Number of entries per endpoint ID",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "per-protocol": {
+          "description": "Number of entries per L4 protocol",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "per-state": {
+          "description": "Number of entries per connection state",
+          "type": "object",
+          "additionalProperties": {
+            "type": "integer"
+          }
+        },
+        "total-entries": {
+          "description": "Total number of entries",
+          "type": "integer"
+        }
+      }
+    },
     "ControllerStatus": {
       "description": "Status of a controller",
       "type": "object",
@@ -4729,6 +5516,29 @@ func init() {
         }
       }
     },
+    "EncryptionStatus": {
+      "description": "Status of transparent encryption",
+      "type": "object",
+      "properties": {
+        "key-rotation": {
+          "description": "Progress of the IPsec key rotation, if one is in progress",
+          "$ref": "#/definitions/IPSecKeyRotationStatus"
+        },
+        "mode": {
+          "description": "Encryption mode",
+          "type": "string",
+          "enum": [
+            "Disabled",
+            "IPsec",
+            "Wireguard"
+          ]
+        },
+        "msg": {
+          "description": "Human readable status/error/warning message",
+          "type": "string"
+        }
+      }
+    },
     "Endpoint": {
       "description": "An endpoint is a namespaced network interface to which cilium applies policies",
       "type": "object",
@@ -5248,6 +6058,31 @@ func init() {
         }
       }
     },
+    "IPSecKeyRotationStatus": {
+      "description": "Progress of an IPsec key rotation",
+      "type": "object",
+      "properties": {
+        "current-spi": {
+          "description": "SPI of the key being rotated in",
+          "type": "integer"
+        },
+        "migrated-nodes": {
+          "description": "Number of nodes advertising the current key",
+          "type": "integer"
+        },
+        "pending-nodes": {
+          "description": "Names of the nodes still advertising a previous key",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "previous-spi": {
+          "description": "SPI of the key being rotated out",
+          "type": "integer"
+        }
+      }
+    },
     "Identity": {
       "description": "Security identity",
       "type": "object",
@@ -5761,6 +6596,39 @@ func init() {
             "direct-server-return": {
               "description": "Perform direct server return",
               "type": "boolean"
+            },
+            "global-service-affinity": {
+              "description": "Affinity of a global service to the clusters providing its backends",
+              "type": "string",
+              "enum": [
+                "none",
+                "local-preferred",
+                "remote-only",
+                "failover"
+              ]
+            },
+            "global-service-clusters": {
+              "description": "Clusters of which the backends are currently selected for a global service",
+              "type": "array",
+              "items": {
+                "type": "string"
+              }
+            },
+            "lb-algorithm": {
+              "description": "Algorithm used to select a backend for new connections",
+              "type": "string",
+              "enum": [
+                "random",
+                "maglev"
+              ]
+            },
+            "session-affinity": {
+              "description": "Send all connections of a client to the same backend (ClientIP session affinity)",
+              "type": "boolean"
+            },
+            "session-affinity-timeout": {
+              "description": "Time in seconds after which an idle session affinity expires",
+              "type": "integer"
             }
           }
         },
@@ -5827,6 +6695,10 @@ func init() {
           "description": "Status of all endpoint controllers",
           "$ref": "#/definitions/ControllerStatuses"
         },
+        "encryption": {
+          "description": "Status of transparent encryption",
+          "$ref": "#/definitions/EncryptionStatus"
+        },
         "ipam": {
           "description": "Status of IP address management",
           "$ref": "#/definitions/IPAMStatus"
@@ -45,6 +45,9 @@ func NewCiliumAPI(spec *loads.Document) *CiliumAPI {
 		BearerAuthenticator: security.BearerAuth,
 		JSONConsumer:        runtime.JSONConsumer(),
 		JSONProducer:        runtime.JSONProducer(),
+		DaemonDeleteConntrackHandler: daemon.DeleteConntrackHandlerFunc(func(params daemon.DeleteConntrackParams) middleware.Responder {
+			return middleware.NotImplemented("operation DaemonDeleteConntrack has not yet been implemented")
+		}),
 		EndpointDeleteEndpointIDHandler: endpoint.DeleteEndpointIDHandlerFunc(func(params endpoint.DeleteEndpointIDParams) middleware.Responder {
 			return middleware.NotImplemented("operation EndpointDeleteEndpointID has not yet been implemented")
 		}),
@@ -66,6 +69,12 @@ func NewCiliumAPI(spec *loads.Document) *CiliumAPI {
 		DaemonGetConfigHandler: daemon.GetConfigHandlerFunc(func(params daemon.GetConfigParams) middleware.Responder {
 			return middleware.NotImplemented("operation DaemonGetConfig has not yet been implemented")
 		}),
+		DaemonGetConntrackHandler: daemon.GetConntrackHandlerFunc(func(params daemon.GetConntrackParams) middleware.Responder {
+			return middleware.NotImplemented("operation DaemonGetConntrack has not yet been implemented")
+		}),
+		DaemonGetConntrackStatisticsHandler: daemon.GetConntrackStatisticsHandlerFunc(func(params daemon.GetConntrackStatisticsParams) middleware.Responder {
+			return middleware.NotImplemented("operation DaemonGetConntrackStatistics has not yet been implemented")
+		}),
 		DaemonGetDebuginfoHandler: daemon.GetDebuginfoHandlerFunc(func(params daemon.GetDebuginfoParams) middleware.Responder {
 			return middleware.NotImplemented("operation DaemonGetDebuginfo has not yet been implemented")
 		}),
@@ -193,6 +202,8 @@ type CiliumAPI struct {
 	// JSONProducer registers a producer for a "application/json" mime type
 	JSONProducer runtime.Producer
 
+	// DaemonDeleteConntrackHandler sets the operation handler for the delete conntrack operation
+	DaemonDeleteConntrackHandler daemon.DeleteConntrackHandler
 	// EndpointDeleteEndpointIDHandler sets the operation handler for the delete endpoint ID operation
 	EndpointDeleteEndpointIDHandler endpoint.DeleteEndpointIDHandler
 	// PolicyDeleteFqdnCacheHandler sets the operation handler for the delete fqdn cache operation
@@ -207,6 +218,10 @@ type CiliumAPI struct {
 	DaemonGetClusterNodesHandler daemon.GetClusterNodesHandler
 	// DaemonGetConfigHandler sets the operation handler for the get config operation
 	DaemonGetConfigHandler daemon.GetConfigHandler
+	// DaemonGetConntrackHandler sets the operation handler for the get conntrack operation
+	DaemonGetConntrackHandler daemon.GetConntrackHandler
+	// DaemonGetConntrackStatisticsHandler sets the operation handler for the get conntrack statistics operation
+	DaemonGetConntrackStatisticsHandler daemon.GetConntrackStatisticsHandler
 	// DaemonGetDebuginfoHandler sets the operation handler for the get debuginfo operation
 	DaemonGetDebuginfoHandler daemon.GetDebuginfoHandler
 	// EndpointGetEndpointHandler sets the operation handler for the get endpoint operation
@@ -334,6 +349,10 @@ func (o *CiliumAPI) Validate() error {
 		unregistered = append(unregistered, "JSONProducer")
 	}
 
+	if o.DaemonDeleteConntrackHandler == nil {
+		unregistered = append(unregistered, "daemon.DeleteConntrackHandler")
+	}
+
 	if o.EndpointDeleteEndpointIDHandler == nil {
 		unregistered = append(unregistered, "endpoint.DeleteEndpointIDHandler")
 	}
@@ -362,6 +381,14 @@ func (o *CiliumAPI) Validate() error {
 		unregistered = append(unregistered, "daemon.GetConfigHandler")
 	}
 
+	if o.DaemonGetConntrackHandler == nil {
+		unregistered = append(unregistered, "daemon.GetConntrackHandler")
+	}
+
+	if o.DaemonGetConntrackStatisticsHandler == nil {
+		unregistered = append(unregistered, "daemon.GetConntrackStatisticsHandler")
+	}
+
 	if o.DaemonGetDebuginfoHandler == nil {
 		unregistered = append(unregistered, "daemon.GetDebuginfoHandler")
 	}
@@ -588,6 +615,11 @@ func (o *CiliumAPI) initHandlerCache() {
 		o.handlers = make(map[string]map[string]http.Handler)
 	}
 
+	if o.handlers["DELETE"] == nil {
+		o.handlers["DELETE"] = make(map[string]http.Handler)
+	}
+	o.handlers["DELETE"]["/conntrack"] = daemon.NewDeleteConntrack(o.context, o.DaemonDeleteConntrackHandler)
+
 	if o.handlers["DELETE"] == nil {
 		o.handlers["DELETE"] = make(map[string]http.Handler)
 	}
@@ -623,6 +655,16 @@ func (o *CiliumAPI) initHandlerCache() {
 	}
 	o.handlers["GET"]["/config"] = daemon.NewGetConfig(o.context, o.DaemonGetConfigHandler)
 
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/conntrack"] = daemon.NewGetConntrack(o.context, o.DaemonGetConntrackHandler)
+
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/conntrack/statistics"] = daemon.NewGetConntrackStatistics(o.context, o.DaemonGetConntrackStatisticsHandler)
+
 	if o.handlers["GET"] == nil {
 		o.handlers["GET"] = make(map[string]http.Handler)
 	}
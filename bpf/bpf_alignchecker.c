@@ -53,6 +53,10 @@ int main() {
     DECLARE_STRUCT(lb6_key_v2, iter);
     DECLARE_STRUCT(lb6_service_v2, iter);
     DECLARE_STRUCT(lb6_backend, iter);
+    DECLARE_STRUCT(lb_maglev, iter);
+    DECLARE_STRUCT(lb4_affinity_key, iter);
+    DECLARE_STRUCT(lb6_affinity_key, iter);
+    DECLARE_STRUCT(lb_affinity_val, iter);
     DECLARE_STRUCT(endpoint_key, iter);
     DECLARE_STRUCT(endpoint_info, iter);
     DECLARE_STRUCT(metrics_key, iter);
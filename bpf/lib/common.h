@@ -218,7 +218,11 @@ struct policy_key {
 
 struct policy_entry {
 	__be16		proxy_port;
-	__u16		pad[3];
+	__u8		deny:1,
+			pad:7;
+	__u8		pad0;
+	__u16		pad1;
+	__u16		pad2;
 	__u64		packets;
 	__u64		bytes;
 };
@@ -313,6 +317,7 @@ enum {
 #define DROP_ENCAP_PROHIBITED	-170
 #define DROP_INVALID_IDENTITY	-171
 #define DROP_UNKNOWN_SENDER	-172
+#define DROP_POLICY_DENY	-173
 
 /* Cilium metrics reasons for forwarding packets and other stats.
  * If reason is larger than below then this is a drop reason and
@@ -553,7 +558,7 @@ struct lb6_service_v2 {
 	__u16 count;
 	__u16 rev_nat_index;
 	__u16 weight;
-	__u16 pad;
+	__u16 flags;
 };
 
 /* See lb4_backend comments */
@@ -585,7 +590,15 @@ struct lb4_service_v2 {
 	__u16 count;
 	__u16 rev_nat_index;	/* Reverse NAT ID in lb4_reverse_nat */
 	__u16 weight;		/* Currently not used */
-	__u16 pad;
+	__u16 flags;		/* SVC_FLAG_*, only set for the master service */
+};
+
+enum {
+	SVC_FLAG_MAGLEV = (1 << 0),	/* Select backends via lb_maglev */
+	SVC_FLAG_AFFINITY = (1 << 1),	/* ClientIP session affinity, the
+					 * backend_id of the master service
+					 * holds the timeout in seconds
+					 */
 };
 
 struct lb4_backend {
@@ -606,6 +619,31 @@ struct lb_sequence {
 	__u16 idx[LB_RR_MAX_SEQ];
 };
 
+/* Maglev lookup table of a service, indexed by the packet hash.
+ * LB_MAGLEV_LUT_SIZE generated by daemon in node_config.h
+ */
+struct lb_maglev {
+	__u16 backend_ids[LB_MAGLEV_LUT_SIZE];
+};
+
+/* Session affinity of a client to the backend of a service */
+struct lb4_affinity_key {
+	__be32 client_ip;
+	__u16 rev_nat_id;
+	__u16 pad;
+};
+
+struct lb6_affinity_key {
+	union v6addr client_ip;
+	__u16 rev_nat_id;
+	__u16 pad;
+};
+
+struct lb_affinity_val {
+	__u32 last_used;	/* bpf_ktime_get_sec() of the last new connection */
+	__u32 backend_id;
+};
+
 struct ct_state {
 	__u16 rev_nat_index;
 	__u16 loopback:1,
@@ -35,6 +35,12 @@
 
 #define CILIUM_LB_MAP_MAX_FE		256
 
+#ifdef HAVE_LRU_MAP_TYPE
+#define LB_AFFINITY_MAP_TYPE BPF_MAP_TYPE_LRU_HASH
+#else
+#define LB_AFFINITY_MAP_TYPE BPF_MAP_TYPE_HASH
+#endif
+
 #ifdef ENABLE_IPV6
 struct bpf_elf_map __section_maps LB6_REVERSE_NAT_MAP = {
 	.type		= BPF_MAP_TYPE_HASH,
@@ -63,6 +69,26 @@ struct bpf_elf_map __section_maps LB6_RR_SEQ_MAP_V2 = {
 	.flags		= CONDITIONAL_PREALLOC,
 };
 
+struct bpf_elf_map __section_maps LB6_MAGLEV_MAP = {
+	.type           = BPF_MAP_TYPE_HASH,
+	.size_key       = sizeof(struct lb6_key_v2),
+	.size_value     = sizeof(struct lb_maglev),
+	.pinning        = PIN_GLOBAL_NS,
+	.max_elem       = CILIUM_LB_MAGLEV_MAP_MAX_ENTRIES,
+	.flags		= CONDITIONAL_PREALLOC,
+};
+
+struct bpf_elf_map __section_maps LB6_AFFINITY_MAP = {
+	.type           = LB_AFFINITY_MAP_TYPE,
+	.size_key       = sizeof(struct lb6_affinity_key),
+	.size_value     = sizeof(struct lb_affinity_val),
+	.pinning        = PIN_GLOBAL_NS,
+	.max_elem       = CILIUM_LB_AFFINITY_MAP_MAX_ENTRIES,
+#ifndef HAVE_LRU_MAP_TYPE
+	.flags		= CONDITIONAL_PREALLOC,
+#endif
+};
+
 struct bpf_elf_map __section_maps LB6_BACKEND_MAP = {
 	.type           = BPF_MAP_TYPE_HASH,
 	.size_key       = sizeof(__u16),
@@ -102,6 +128,26 @@ struct bpf_elf_map __section_maps LB4_RR_SEQ_MAP_V2 = {
 	.flags		= CONDITIONAL_PREALLOC,
 };
 
+struct bpf_elf_map __section_maps LB4_MAGLEV_MAP = {
+	.type           = BPF_MAP_TYPE_HASH,
+	.size_key       = sizeof(struct lb4_key_v2),
+	.size_value     = sizeof(struct lb_maglev),
+	.pinning        = PIN_GLOBAL_NS,
+	.max_elem       = CILIUM_LB_MAGLEV_MAP_MAX_ENTRIES,
+	.flags		= CONDITIONAL_PREALLOC,
+};
+
+struct bpf_elf_map __section_maps LB4_AFFINITY_MAP = {
+	.type           = LB_AFFINITY_MAP_TYPE,
+	.size_key       = sizeof(struct lb4_affinity_key),
+	.size_value     = sizeof(struct lb_affinity_val),
+	.pinning        = PIN_GLOBAL_NS,
+	.max_elem       = CILIUM_LB_AFFINITY_MAP_MAX_ENTRIES,
+#ifndef HAVE_LRU_MAP_TYPE
+	.flags		= CONDITIONAL_PREALLOC,
+#endif
+};
+
 struct bpf_elf_map __section_maps LB4_BACKEND_MAP = {
 	.type           = BPF_MAP_TYPE_HASH,
 	.size_key       = sizeof(__u16),
@@ -471,6 +517,81 @@ struct lb6_service_v2 *lb6_lookup_slave_v2(struct __sk_buff *skb,
 	return NULL;
 }
 
+/* Returns the ID of the backend selected for a new connection to the master
+ * service svc, or 0 if no backend could be selected. Services with
+ * SVC_FLAG_MAGLEV select the backend from their Maglev lookup table so that
+ * changes to the set of backends only affect a small fraction of the
+ * connections.
+ */
+static inline __u16 __lb6_select_backend_id(struct __sk_buff *skb,
+					     struct lb6_key_v2 *key,
+					     struct lb6_service_v2 *svc)
+{
+	struct lb6_service_v2 *slave_svc;
+	int slave;
+
+#ifdef HAVE_MAP_VAL_ADJ
+	if (svc->flags & SVC_FLAG_MAGLEV) {
+		struct lb_maglev *lut;
+
+		key->slave = 0;
+		lut = map_lookup_elem(&LB6_MAGLEV_MAP, key);
+		if (lut) {
+			__u32 index = lb_enforce_rehash(skb) % LB_MAGLEV_LUT_SIZE;
+
+			return lut->backend_ids[index];
+		}
+	}
+#endif
+
+	slave = lb6_select_slave(skb, svc->count, svc->weight);
+	if ((slave_svc = lb6_lookup_slave_v2(skb, key, slave)) == NULL)
+		return 0;
+
+	return slave_svc->backend_id;
+}
+
+/* Like __lb6_select_backend_id but services with SVC_FLAG_AFFINITY send all
+ * new connections of the client to the backend selected for the previous
+ * connection of the client, unless the client has been idle for longer than
+ * the affinity timeout of the service or the backend has been removed. The
+ * agent deletes the affinity entries of backends removed from the service, so
+ * backends still in use by other services are not selected either.
+ */
+static inline __u16 lb6_select_backend_id(struct __sk_buff *skb,
+					   struct lb6_key_v2 *key,
+					   union v6addr *client,
+					   struct lb6_service_v2 *svc)
+{
+	struct lb6_affinity_key affinity_key = {
+		.rev_nat_id = svc->rev_nat_index,
+	};
+	struct lb_affinity_val *affinity, new_affinity = {};
+	__u32 now;
+	__u16 backend_id;
+
+	if (!(svc->flags & SVC_FLAG_AFFINITY))
+		return __lb6_select_backend_id(skb, key, svc);
+
+	ipv6_addr_copy(&affinity_key.client_ip, client);
+	now = bpf_ktime_get_sec();
+	affinity = map_lookup_elem(&LB6_AFFINITY_MAP, &affinity_key);
+	if (affinity && now - affinity->last_used <= svc->backend_id &&
+	    __lb6_lookup_backend(affinity->backend_id)) {
+		affinity->last_used = now;
+		return affinity->backend_id;
+	}
+
+	backend_id = __lb6_select_backend_id(skb, key, svc);
+	if (backend_id) {
+		new_affinity.last_used = now;
+		new_affinity.backend_id = backend_id;
+		map_update_elem(&LB6_AFFINITY_MAP, &affinity_key, &new_affinity, 0);
+	}
+
+	return backend_id;
+}
+
 static inline int __inline__ lb6_xlate_v2(struct __sk_buff *skb,
 					  union v6addr *new_dst, __u8 nexthdr,
 				          int l3_off, int l4_off,
@@ -515,8 +636,6 @@ static inline int __inline__ lb6_local(void *map, struct __sk_buff *skb,
 	union v6addr *addr;
 	__u8 flags = tuple->flags;
 	struct lb6_backend *backend;
-	struct lb6_service_v2 *slave_svc;
-	int slave;
 	int ret;
 
 	/* See lb4_local comments re svc endpoint lookup process */
@@ -524,15 +643,11 @@ static inline int __inline__ lb6_local(void *map, struct __sk_buff *skb,
 	ret = ct_lookup6(map, tuple, skb, l4_off, CT_SERVICE, state, &monitor);
 	switch(ret) {
 	case CT_NEW:
-		slave = lb6_select_slave(skb, svc_v2->count, svc_v2->weight);
-		if ((slave_svc = lb6_lookup_slave_v2(skb, key, slave)) == NULL) {
-			goto drop_no_service;
-		}
-		backend = lb6_lookup_backend(skb, slave_svc->backend_id);
+		state->backend_id = lb6_select_backend_id(skb, key, &tuple->saddr, svc_v2);
+		backend = lb6_lookup_backend(skb, state->backend_id);
 		if (backend == NULL) {
 			goto drop_no_service;
 		}
-		state->backend_id = slave_svc->backend_id;
 		state->rev_nat_index = svc_v2->rev_nat_index;
 		ret = ct_create6(map, tuple, skb, CT_SERVICE, state, false);
 		/* Fail closed, if the conntrack entry create fails drop
@@ -559,11 +674,10 @@ static inline int __inline__ lb6_local(void *map, struct __sk_buff *skb,
 	if (state->rev_nat_index != svc_v2->rev_nat_index) {
 		cilium_dbg_lb(skb, DBG_LB_STALE_CT, svc_v2->rev_nat_index,
 			      state->rev_nat_index);
-		slave = lb6_select_slave(skb, svc_v2->count, svc_v2->weight);
-		if (!(slave_svc = lb6_lookup_slave_v2(skb, key, slave))) {
+		state->backend_id = lb6_select_backend_id(skb, key, &tuple->saddr, svc_v2);
+		if (state->backend_id == 0) {
 			goto drop_no_service;
 		}
-		state->backend_id = slave_svc->backend_id;
 		ct_update6_backend_id(map, tuple, state);
 		state->rev_nat_index = svc_v2->rev_nat_index;
 		ct_update6_rev_nat_index(map, tuple, state);
@@ -577,15 +691,11 @@ static inline int __inline__ lb6_local(void *map, struct __sk_buff *skb,
 		if (!(svc_v2 = lb6_lookup_service_v2(skb, key))) {
 			goto drop_no_service;
 		}
-		slave = lb6_select_slave(skb, svc_v2->count, svc_v2->weight);
-		if (!(slave_svc = lb6_lookup_slave_v2(skb, key, slave))) {
-			goto drop_no_service;
-		}
-		backend = lb6_lookup_backend(skb, slave_svc->backend_id);
+		state->backend_id = lb6_select_backend_id(skb, key, &tuple->saddr, svc_v2);
+		backend = lb6_lookup_backend(skb, state->backend_id);
 		if (backend == NULL) {
 			goto drop_no_service;
 		}
-		state->backend_id = slave_svc->backend_id;
 		ct_update6_backend_id(map, tuple, state);
 	}
 
@@ -819,6 +929,81 @@ struct lb4_service_v2 *lb4_lookup_slave_v2(struct __sk_buff *skb,
 	return NULL;
 }
 
+/* Returns the ID of the backend selected for a new connection to the master
+ * service svc, or 0 if no backend could be selected. Services with
+ * SVC_FLAG_MAGLEV select the backend from their Maglev lookup table so that
+ * changes to the set of backends only affect a small fraction of the
+ * connections.
+ */
+static inline __u16 __lb4_select_backend_id(struct __sk_buff *skb,
+					     struct lb4_key_v2 *key,
+					     struct lb4_service_v2 *svc)
+{
+	struct lb4_service_v2 *slave_svc;
+	int slave;
+
+#ifdef HAVE_MAP_VAL_ADJ
+	if (svc->flags & SVC_FLAG_MAGLEV) {
+		struct lb_maglev *lut;
+
+		key->slave = 0;
+		lut = map_lookup_elem(&LB4_MAGLEV_MAP, key);
+		if (lut) {
+			__u32 index = lb_enforce_rehash(skb) % LB_MAGLEV_LUT_SIZE;
+
+			return lut->backend_ids[index];
+		}
+	}
+#endif
+
+	slave = lb4_select_slave(skb, svc->count, svc->weight);
+	if ((slave_svc = lb4_lookup_slave_v2(skb, key, slave)) == NULL)
+		return 0;
+
+	return slave_svc->backend_id;
+}
+
+/* Like __lb4_select_backend_id but services with SVC_FLAG_AFFINITY send all
+ * new connections of the client to the backend selected for the previous
+ * connection of the client, unless the client has been idle for longer than
+ * the affinity timeout of the service or the backend has been removed. The
+ * agent deletes the affinity entries of backends removed from the service, so
+ * backends still in use by other services are not selected either.
+ */
+static inline __u16 lb4_select_backend_id(struct __sk_buff *skb,
+					   struct lb4_key_v2 *key,
+					   __be32 client,
+					   struct lb4_service_v2 *svc)
+{
+	struct lb4_affinity_key affinity_key = {
+		.client_ip = client,
+		.rev_nat_id = svc->rev_nat_index,
+	};
+	struct lb_affinity_val *affinity, new_affinity = {};
+	__u32 now;
+	__u16 backend_id;
+
+	if (!(svc->flags & SVC_FLAG_AFFINITY))
+		return __lb4_select_backend_id(skb, key, svc);
+
+	now = bpf_ktime_get_sec();
+	affinity = map_lookup_elem(&LB4_AFFINITY_MAP, &affinity_key);
+	if (affinity && now - affinity->last_used <= svc->backend_id &&
+	    __lb4_lookup_backend(affinity->backend_id)) {
+		affinity->last_used = now;
+		return affinity->backend_id;
+	}
+
+	backend_id = __lb4_select_backend_id(skb, key, svc);
+	if (backend_id) {
+		new_affinity.last_used = now;
+		new_affinity.backend_id = backend_id;
+		map_update_elem(&LB4_AFFINITY_MAP, &affinity_key, &new_affinity, 0);
+	}
+
+	return backend_id;
+}
+
 static inline int __inline__
 lb4_xlate_v2(struct __sk_buff *skb, __be32 *new_daddr, __be32 *new_saddr,
 	     __be32 *old_saddr, __u8 nexthdr, int l3_off, int l4_off,
@@ -877,23 +1062,17 @@ static inline int __inline__ lb4_local(void *map, struct __sk_buff *skb,
 	__be32 new_saddr = 0, new_daddr;
 	__u8 flags = tuple->flags;
 	struct lb4_backend *backend;
-	struct lb4_service_v2 *slave_svc;
-	int slave;
 	int ret;
 
 	ret = ct_lookup4(map, tuple, skb, l4_off, CT_SERVICE, state, &monitor);
 	switch(ret) {
 	case CT_NEW:
 		/* No CT entry has been found, so select a svc endpoint */
-		slave = lb4_select_slave(skb, svc_v2->count, svc_v2->weight);
-		if ((slave_svc = lb4_lookup_slave_v2(skb, key, slave)) == NULL) {
-			goto drop_no_service;
-		}
-		backend = lb4_lookup_backend(skb, slave_svc->backend_id);
+		state->backend_id = lb4_select_backend_id(skb, key, saddr, svc_v2);
+		backend = lb4_lookup_backend(skb, state->backend_id);
 		if (backend == NULL) {
 			goto drop_no_service;
 		}
-		state->backend_id = slave_svc->backend_id;
 		state->rev_nat_index = svc_v2->rev_nat_index;
 		ret = ct_create4(map, tuple, skb, CT_SERVICE, state, false);
 		/* Fail closed, if the conntrack entry create fails drop
@@ -929,11 +1108,10 @@ static inline int __inline__ lb4_local(void *map, struct __sk_buff *skb,
 	if (state->rev_nat_index != svc_v2->rev_nat_index) {
 		cilium_dbg_lb(skb, DBG_LB_STALE_CT, svc_v2->rev_nat_index,
 			      state->rev_nat_index);
-		slave = lb4_select_slave(skb, svc_v2->count, svc_v2->weight);
-		if (!(slave_svc = lb4_lookup_slave_v2(skb, key, slave))) {
+		state->backend_id = lb4_select_backend_id(skb, key, saddr, svc_v2);
+		if (state->backend_id == 0) {
 			goto drop_no_service;
 		}
-		state->backend_id = slave_svc->backend_id;
 		ct_update4_backend_id(map, tuple, state);
 		state->rev_nat_index = svc_v2->rev_nat_index;
 		ct_update4_rev_nat_index(map, tuple, state);
@@ -947,15 +1125,11 @@ static inline int __inline__ lb4_local(void *map, struct __sk_buff *skb,
 		if (!(svc_v2 = lb4_lookup_service_v2(skb, key))) {
 			goto drop_no_service;
 		}
-		slave = lb4_select_slave(skb, svc_v2->count, svc_v2->weight);
-		if (!(slave_svc = lb4_lookup_slave_v2(skb, key, slave))) {
-			goto drop_no_service;
-		}
-		backend = lb4_lookup_backend(skb, slave_svc->backend_id);
+		state->backend_id = lb4_select_backend_id(skb, key, saddr, svc_v2);
+		backend = lb4_lookup_backend(skb, state->backend_id);
 		if (backend == NULL) {
 			goto drop_no_service;
 		}
-		state->backend_id = slave_svc->backend_id;
 		ct_update4_backend_id(map, tuple, state);
 	}
 
@@ -102,6 +102,8 @@ policy_sk_egress(__u32 identity, __u32 ip,  __u16 dport)
 	if (likely(policy)) {
 		/* FIXME: Need byte counter */
 		__sync_fetch_and_add(&policy->packets, 1);
+		if (unlikely(policy->deny))
+			return DROP_POLICY_DENY;
 		goto get_proxy_port;
 	}
 
@@ -112,6 +114,8 @@ policy_sk_egress(__u32 identity, __u32 ip,  __u16 dport)
 	if (likely(policy)) {
 		/* FIXME: Need byte counter */
 		__sync_fetch_and_add(&policy->packets, 1);
+		if (unlikely(policy->deny))
+			return DROP_POLICY_DENY;
 		return TC_ACT_OK;
 	}
 
@@ -122,6 +126,8 @@ policy_sk_egress(__u32 identity, __u32 ip,  __u16 dport)
 	if (likely(policy)) {
 		/* FIXME: Use per cpu counters */
 		__sync_fetch_and_add(&policy->packets, 1);
+		if (unlikely(policy->deny))
+			return DROP_POLICY_DENY;
 		goto get_proxy_port;
 	}
 	return DROP_POLICY;
@@ -155,6 +161,9 @@ __policy_can_access(void *map, struct __sk_buff *skb, __u32 identity,
 		.pad = 0,
 	};
 
+	/* Deny entries are inserted by the agent such that the first
+	 * matching entry in the lookup order below is always the one that
+	 * takes precedence. */
 	if (!is_fragment) {
 		policy = map_lookup_elem(map, &key);
 		if (likely(policy)) {
@@ -162,6 +171,8 @@ __policy_can_access(void *map, struct __sk_buff *skb, __u32 identity,
 				    dport << 16 | proto);
 
 			account(skb, policy);
+			if (unlikely(policy->deny))
+				return DROP_POLICY_DENY;
 			goto get_proxy_port;
 		}
 	}
@@ -172,6 +183,8 @@ __policy_can_access(void *map, struct __sk_buff *skb, __u32 identity,
 	policy = map_lookup_elem(map, &key);
 	if (likely(policy)) {
 		account(skb, policy);
+		if (unlikely(policy->deny))
+			return DROP_POLICY_DENY;
 		return TC_ACT_OK;
 	}
 
@@ -182,6 +195,8 @@ __policy_can_access(void *map, struct __sk_buff *skb, __u32 identity,
 		policy = map_lookup_elem(map, &key);
 		if (likely(policy)) {
 			account(skb, policy);
+			if (unlikely(policy->deny))
+				return DROP_POLICY_DENY;
 			goto get_proxy_port;
 		}
 		key.dport = 0;
@@ -193,6 +208,8 @@ __policy_can_access(void *map, struct __sk_buff *skb, __u32 identity,
 	policy = map_lookup_elem(map, &key);
 	if (policy) {
 		account(skb, policy);
+		if (unlikely(policy->deny))
+			return DROP_POLICY_DENY;
 		return TC_ACT_OK;
 	}
 
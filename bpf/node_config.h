@@ -91,15 +91,22 @@ DEFINE_IPV6(SNAT_IPV6_EXTERNAL, 0xbe, 0xef, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0
 #define LB6_REVERSE_NAT_MAP test_cilium_lb6_reverse_nat
 #define LB6_SERVICES_MAP_V2 test_cilium_lb6_services_v2
 #define LB6_RR_SEQ_MAP_V2 test_cilium_lb6_rr_seq_v2
+#define LB6_MAGLEV_MAP test_cilium_lb6_maglev
+#define LB6_AFFINITY_MAP test_cilium_lb6_affinity
 #define LB6_BACKEND_MAP test_cilium_lb6_backends
 #define LB6_REVERSE_NAT_SK_MAP cilium_lb6_reverse_sk
 #define LB4_REVERSE_NAT_MAP test_cilium_lb4_reverse_nat
 #define LB4_SERVICES_MAP_V2 test_cilium_lb4_services_v2
 #define LB4_RR_SEQ_MAP_V2 test_cilium_lb4_rr_seq_v2
+#define LB4_MAGLEV_MAP test_cilium_lb4_maglev
+#define LB4_AFFINITY_MAP test_cilium_lb4_affinity
 #define LB4_BACKEND_MAP test_cilium_lb4_backends
 #define LB4_REVERSE_NAT_SK_MAP cilium_lb4_reverse_sk
 #define ENABLE_ARP_RESPONDER
 #define LB_RR_MAX_SEQ 31
+#define LB_MAGLEV_LUT_SIZE 1021
+#define CILIUM_LB_MAGLEV_MAP_MAX_ENTRIES 4096
+#define CILIUM_LB_AFFINITY_MAP_MAX_ENTRIES 65536
 #define TUNNEL_ENDPOINT_MAP_SIZE 65536
 #define ENDPOINTS_MAP_SIZE 65536
 #define METRICS_MAP_SIZE 65536
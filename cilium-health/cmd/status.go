@@ -18,6 +18,7 @@ import (
 	"os"
 	"text/tabwriter"
 
+	"github.com/cilium/cilium/api/v1/health/client/connectivity"
 	"github.com/cilium/cilium/api/v1/health/models"
 	"github.com/cilium/cilium/pkg/command"
 	clientPkg "github.com/cilium/cilium/pkg/health/client"
@@ -29,6 +30,7 @@ var (
 	probe    bool
 	succinct bool
 	verbose  bool
+	history  bool
 )
 
 // statusGetCmd represents the status command
@@ -43,6 +45,10 @@ var statusGetCmd = &cobra.Command{
 			Fatalf("Invalid combination of arguments")
 		}
 
+		if probe && history {
+			Fatalf("--history cannot be combined with --probe")
+		}
+
 		if probe {
 			result, err := client.Connectivity.PutStatusProbe(nil)
 			if err != nil {
@@ -50,7 +56,8 @@ var statusGetCmd = &cobra.Command{
 			}
 			sr = result.Payload
 		} else {
-			result, err := client.Connectivity.GetStatus(nil)
+			params := connectivity.NewGetStatusParams().WithHistory(&history)
+			result, err := client.Connectivity.GetStatus(params)
 			if err != nil {
 				Fatalf("Cannot get status: %s\n", err)
 			}
@@ -77,5 +84,7 @@ func init() {
 		"Print the result succinctly (one node per line)")
 	statusGetCmd.Flags().BoolVarP(&verbose, "verbose", "", false,
 		"Print more information in results")
+	statusGetCmd.Flags().BoolVarP(&history, "history", "", false,
+		"Print the history of probes of each path over the health history window")
 	command.AddJSONOutput(statusGetCmd)
 }
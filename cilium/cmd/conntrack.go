@@ -12,35 +12,18 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !privileged_tests
-
-package eni
+package cmd
 
 import (
-	"gopkg.in/check.v1"
+	"github.com/spf13/cobra"
 )
 
-type testDef struct {
-	available   int
-	used        int
-	preallocate int
-	minallocate int
-	result      int
-}
-
-var def = []testDef{
-	{0, 0, 0, 16, 16},
-	{0, 0, 8, 16, 16},
-	{0, 0, 16, 8, 16},
-	{0, 0, 16, 0, 16},
-	{8, 0, 0, 16, 8},
-	{8, 4, 8, 0, 4},
-	{8, 4, 8, 8, 4},
+// conntrackCmd represents the conntrack command
+var conntrackCmd = &cobra.Command{
+	Use:   "conntrack",
+	Short: "Inspect the connection tracking table",
 }
 
-func (e *ENISuite) TestCalculateNeededIPs(c *check.C) {
-	for _, d := range def {
-		result := calculateNeededIPs(d.available, d.used, d.preallocate, d.minallocate)
-		c.Assert(result, check.Equals, d.result)
-	}
+func init() {
+	rootCmd.AddCommand(conntrackCmd)
 }
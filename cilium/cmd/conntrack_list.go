@@ -0,0 +1,126 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cilium/cilium/api/v1/client/daemon"
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/command"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ctListEndpoint int64
+	ctListSrcCIDR  string
+	ctListDstCIDR  string
+	ctListPort     int64
+	ctListProtocol string
+	ctListFlags    string
+)
+
+// conntrackListCmd represents the conntrack list command
+var conntrackListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List connection tracking entries",
+	Long: `List the entries of the connection tracking table of the agent. Entries
+can be filtered by endpoint, source and destination CIDR, port, protocol and
+flags.`,
+	Example: `  cilium conntrack list --endpoint 1234 --protocol TCP
+  cilium conntrack list --dst-cidr 10.0.0.0/8 --port 53 --flags SeenNonSyn`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listConntrack(cmd)
+	},
+}
+
+func init() {
+	conntrackCmd.AddCommand(conntrackListCmd)
+	conntrackListCmd.Flags().Int64VarP(&ctListEndpoint, "endpoint", "e", 0, "Only list connections of the endpoint with this ID")
+	conntrackListCmd.Flags().StringVar(&ctListSrcCIDR, "src-cidr", "", "Only list connections with a source address in this CIDR")
+	conntrackListCmd.Flags().StringVar(&ctListDstCIDR, "dst-cidr", "", "Only list connections with a destination address in this CIDR")
+	conntrackListCmd.Flags().Int64VarP(&ctListPort, "port", "p", 0, "Only list connections with this source or destination port")
+	conntrackListCmd.Flags().StringVar(&ctListProtocol, "protocol", "", "Only list connections of this L4 protocol")
+	conntrackListCmd.Flags().StringVar(&ctListFlags, "flags", "", "Only list entries with all of these comma separated flags set, e.g. RxClosing,SeenNonSyn")
+	command.AddJSONOutput(conntrackListCmd)
+}
+
+func listConntrack(cmd *cobra.Command) {
+	params := daemon.NewGetConntrackParams()
+	if cmd.Flags().Changed("endpoint") {
+		params.SetEndpoint(&ctListEndpoint)
+	}
+	if ctListSrcCIDR != "" {
+		params.SetSourceCidr(&ctListSrcCIDR)
+	}
+	if ctListDstCIDR != "" {
+		params.SetDestinationCidr(&ctListDstCIDR)
+	}
+	if cmd.Flags().Changed("port") {
+		params.SetPort(&ctListPort)
+	}
+	if ctListProtocol != "" {
+		params.SetProtocol(&ctListProtocol)
+	}
+	if ctListFlags != "" {
+		params.SetFlags(&ctListFlags)
+	}
+
+	resp, err := client.Daemon.GetConntrack(params)
+	if err != nil {
+		switch err.(type) {
+		case *daemon.GetConntrackNotFound:
+			Fatalf("Endpoint %d not found", ctListEndpoint)
+		default:
+			Fatalf("Cannot get conntrack entries: %s", err)
+		}
+	}
+
+	if command.OutputJSON() {
+		if err := command.PrintOutput(resp.Payload); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	printConntrackEntries(resp.Payload)
+}
+
+func printConntrackEntries(entries []*models.ConntrackEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tPROTO\tDIR\tSOURCE\tDESTINATION\tSTATE\tFLAGS\tEXPIRES\tRX PKTS\tTX PKTS\tSRC IDENTITY")
+	for _, e := range entries {
+		endpoint := "-"
+		if e.EndpointID != 0 {
+			endpoint = strconv.FormatInt(e.EndpointID, 10)
+		}
+		flags := strings.Join(e.Flags, ",")
+		if flags == "" {
+			flags = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%ds\t%d\t%d\t%d\n",
+			endpoint, e.Protocol, e.Direction,
+			net.JoinHostPort(e.SourceIP, strconv.FormatInt(e.SourcePort, 10)),
+			net.JoinHostPort(e.DestinationIP, strconv.FormatInt(e.DestinationPort, 10)),
+			e.State, flags, e.Lifetime, e.RxPackets, e.TxPackets, e.SourceSecurityID)
+	}
+	w.Flush()
+}
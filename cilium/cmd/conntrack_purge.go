@@ -0,0 +1,155 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cilium/cilium/api/v1/client/daemon"
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/command"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ctPurgeEndpoint int64
+	ctPurgeSrcCIDR  string
+	ctPurgeDstCIDR  string
+	ctPurgePorts    string
+	ctPurgeProtocol string
+	ctPurgeIdentity int64
+	ctPurgeDryRun   bool
+)
+
+// conntrackPurgeCmd represents the conntrack purge command
+var conntrackPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Purge connection tracking entries",
+	Long: `Remove the connection tracking entries, and the NAT entries associated
+with them, which match all of the given criteria. This terminates the
+affected connections, e.g. after access to a backend has been revoked. At
+least one criterion must be given, use "cilium bpf ct flush" to remove all
+entries.`,
+	Example: `  cilium conntrack purge --endpoint 1234 --dst-cidr 10.0.0.0/8
+  cilium conntrack purge --identity 12345 --port 8000-8080 --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		purgeConntrack(cmd)
+	},
+}
+
+func init() {
+	conntrackCmd.AddCommand(conntrackPurgeCmd)
+	conntrackPurgeCmd.Flags().Int64VarP(&ctPurgeEndpoint, "endpoint", "e", 0, "Only purge connections of the endpoint with this ID")
+	conntrackPurgeCmd.Flags().StringVar(&ctPurgeSrcCIDR, "src-cidr", "", "Only purge connections with a source address in this CIDR")
+	conntrackPurgeCmd.Flags().StringVar(&ctPurgeDstCIDR, "dst-cidr", "", "Only purge connections with a destination address in this CIDR")
+	conntrackPurgeCmd.Flags().StringVarP(&ctPurgePorts, "port", "p", "", "Only purge connections with a source or destination port in this port or port range, e.g. 80 or 8000-8080")
+	conntrackPurgeCmd.Flags().StringVar(&ctPurgeProtocol, "protocol", "", "Only purge connections of this L4 protocol")
+	conntrackPurgeCmd.Flags().Int64Var(&ctPurgeIdentity, "identity", 0, "Only purge connections from this security identity")
+	conntrackPurgeCmd.Flags().BoolVar(&ctPurgeDryRun, "dry-run", false, "Only count the matching entries without removing them")
+	command.AddJSONOutput(conntrackPurgeCmd)
+}
+
+// parsePortRange parses a port or a port range of the form <start>-<end>.
+// end is 0 if s is a single port.
+func parsePortRange(s string) (start, end int64, err error) {
+	parts := strings.SplitN(s, "-", 2)
+	if start, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", parts[0])
+	}
+	if len(parts) == 2 {
+		if end, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid port %q", parts[1])
+		}
+	}
+	return start, end, nil
+}
+
+func purgeConntrack(cmd *cobra.Command) {
+	params := daemon.NewDeleteConntrackParams()
+	if cmd.Flags().Changed("endpoint") {
+		params.SetEndpoint(&ctPurgeEndpoint)
+	}
+	if ctPurgeSrcCIDR != "" {
+		params.SetSourceCidr(&ctPurgeSrcCIDR)
+	}
+	if ctPurgeDstCIDR != "" {
+		params.SetDestinationCidr(&ctPurgeDstCIDR)
+	}
+	if ctPurgePorts != "" {
+		start, end, err := parsePortRange(ctPurgePorts)
+		if err != nil {
+			Fatalf("%s", err)
+		}
+		params.SetPort(&start)
+		if end != 0 {
+			params.SetEndPort(&end)
+		}
+	}
+	if ctPurgeProtocol != "" {
+		params.SetProtocol(&ctPurgeProtocol)
+	}
+	if cmd.Flags().Changed("identity") {
+		params.SetIdentity(&ctPurgeIdentity)
+	}
+	params.SetDryRun(&ctPurgeDryRun)
+
+	resp, err := client.Daemon.DeleteConntrack(params)
+	if err != nil {
+		switch e := err.(type) {
+		case *daemon.DeleteConntrackNotFound:
+			Fatalf("Endpoint %d not found", ctPurgeEndpoint)
+		case *daemon.DeleteConntrackInvalid:
+			Fatalf("Invalid filter: %s", e.Payload)
+		default:
+			Fatalf("Cannot purge conntrack entries: %s", err)
+		}
+	}
+
+	if command.OutputJSON() {
+		if err := command.PrintOutput(resp.Payload); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	printConntrackPurge(resp.Payload)
+}
+
+func printConntrackPurge(result *models.ConntrackPurgeResponse) {
+	names := make([]string, 0, len(result.PerMap))
+	for name := range result.PerMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "MAP\tENTRIES")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%d\n", name, result.PerMap[name])
+	}
+	w.Flush()
+
+	if result.DryRun {
+		fmt.Printf("\n%d entries would be purged (dry run)\n", result.TotalEntries)
+	} else {
+		fmt.Printf("\nPurged %d entries\n", result.TotalEntries)
+	}
+}
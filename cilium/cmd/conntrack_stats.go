@@ -0,0 +1,99 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/command"
+
+	"github.com/spf13/cobra"
+)
+
+// conntrackStatsCmd represents the conntrack stats command
+var conntrackStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show connection tracking statistics",
+	Long: `Show the number of connection tracking entries per endpoint, protocol and
+state, the fill ratio of each connection tracking map and the status of the
+garbage collector.`,
+	Example: "cilium conntrack stats",
+	Run: func(cmd *cobra.Command, args []string) {
+		resp, err := client.Daemon.GetConntrackStatistics(nil)
+		if err != nil {
+			Fatalf("Cannot get conntrack statistics: %s", err)
+		}
+
+		if command.OutputJSON() {
+			if err := command.PrintOutput(resp.Payload); err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+
+		printConntrackStatistics(resp.Payload)
+	},
+}
+
+func init() {
+	conntrackCmd.AddCommand(conntrackStatsCmd)
+	command.AddJSONOutput(conntrackStatsCmd)
+}
+
+// printCounts prints the counts sorted by key with the given heading. Keys
+// are sorted numerically if numeric is true.
+func printCounts(w *tabwriter.Writer, heading string, counts map[string]int64, numeric bool) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(w, "\n%s\tENTRIES\n", heading)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%d\n", k, counts[k])
+	}
+}
+
+func printConntrackStatistics(stats *models.ConntrackStatistics) {
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+
+	fmt.Fprintf(w, "Total entries:\t%d\n", stats.TotalEntries)
+	fmt.Fprintf(w, "GC interval:\t%s\n", time.Duration(stats.GcInterval)*time.Second)
+	fmt.Fprintf(w, "GC delete ratio:\t%.2f%%\n", stats.GcDeleteRatio*100)
+
+	fmt.Fprintln(w, "\nMAP\tENTRIES\tMAX ENTRIES\tFILL RATIO")
+	for _, m := range stats.Maps {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f%%\n", m.Name, m.Entries, m.MaxEntries, m.FillRatio*100)
+	}
+
+	printCounts(w, "ENDPOINT", stats.PerEndpoint, true)
+	printCounts(w, "PROTOCOL", stats.PerProtocol, false)
+	printCounts(w, "STATE", stats.PerState, false)
+	w.Flush()
+}
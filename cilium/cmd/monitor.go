@@ -30,6 +30,7 @@ import (
 	"github.com/cilium/cilium/pkg/monitor/agent/listener"
 	"github.com/cilium/cilium/pkg/monitor/format"
 	"github.com/cilium/cilium/pkg/monitor/payload"
+	"github.com/cilium/cilium/pkg/monitor/pcapng"
 
 	"github.com/spf13/cobra"
 )
@@ -53,6 +54,9 @@ programs attached to endpoints and devices. This includes:
 		},
 	}
 	printer = format.NewMonitorFormatter(format.INFO)
+
+	monitorOutput string
+	pcapFile      string
 )
 
 func init() {
@@ -64,9 +68,19 @@ func init() {
 	monitorCmd.Flags().Var(&printer.Related, "related-to", "Filter by either source or destination endpoint id")
 	monitorCmd.Flags().BoolVarP(&printer.Verbose, "verbose", "v", false, "Enable verbose output")
 	monitorCmd.Flags().BoolVarP(&printer.JSONOutput, "json", "j", false, "Enable json output. Shadows -v flag")
+	monitorCmd.Flags().StringVarP(&monitorOutput, "output", "o", "", "Output format, one of: json. Shadows -v flag")
+	monitorCmd.Flags().StringVar(&pcapFile, "write-pcap", "", "Write captured packets of drop and trace notifications to a pcapng file")
 }
 
 func setVerbosity() {
+	switch monitorOutput {
+	case "":
+	case "json":
+		printer.JSONOutput = true
+	default:
+		Fatalf("Unsupported output format %q, supported formats: json", monitorOutput)
+	}
+
 	if printer.JSONOutput {
 		printer.Verbosity = format.JSON
 	} else if printer.Verbose {
@@ -81,12 +95,39 @@ func setupSigHandler() {
 	signal.Notify(signalChan, os.Interrupt)
 	go func() {
 		for range signalChan {
-			fmt.Printf("\nReceived an interrupt, disconnecting from monitor...\n\n")
+			fmt.Fprintf(infoWriter(), "\nReceived an interrupt, disconnecting from monitor...\n\n")
 			os.Exit(0)
 		}
 	}()
 }
 
+// infoWriter returns the writer for informational messages. With json
+// output they are written to stderr, so that stdout only contains events.
+func infoWriter() io.Writer {
+	if printer.Verbosity == format.JSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// setupPcapWriter creates the pcapng file events are written to, if
+// requested.
+func setupPcapWriter() {
+	if pcapFile == "" {
+		return
+	}
+
+	f, err := os.Create(pcapFile)
+	if err != nil {
+		Fatalf("Unable to create pcap file: %s", err)
+	}
+
+	printer.PcapWriter, err = pcapng.NewWriter(f)
+	if err != nil {
+		Fatalf("Unable to write pcap file header: %s", err)
+	}
+}
+
 // openMonitorSock attempts to open a version specific monitor socket It
 // returns a connection, with a version, or an error.
 func openMonitorSock() (conn net.Conn, version listener.Version, err error) {
@@ -244,14 +285,18 @@ func runMonitor(args []string) {
 
 	validateEndpointsFilters()
 	setVerbosity()
+	setupPcapWriter()
 	setupSigHandler()
 	if resp, err := client.Daemon.GetHealthz(nil); err == nil {
 		if nm := resp.Payload.NodeMonitor; nm != nil {
-			fmt.Printf("Listening for events on %d CPUs with %dx%d of shared memory\n",
+			fmt.Fprintf(infoWriter(), "Listening for events on %d CPUs with %dx%d of shared memory\n",
 				nm.Cpus, nm.Npages, nm.Pagesize)
 		}
 	}
-	fmt.Printf("Press Ctrl-C to quit\n")
+	if pcapFile != "" {
+		fmt.Fprintf(infoWriter(), "Writing captured packets to %s\n", pcapFile)
+	}
+	fmt.Fprintf(infoWriter(), "Press Ctrl-C to quit\n")
 
 	// On EOF, retry
 	// On other errors, exit
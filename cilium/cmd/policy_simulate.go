@@ -0,0 +1,160 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cilium/cilium/pkg/command"
+	"github.com/cilium/cilium/pkg/defaults"
+	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/policy"
+	"github.com/cilium/cilium/pkg/policy/simulate"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateFiles           []string
+	simulateFlowsFile       string
+	simulateClusterName     string
+	simulateEnforcementMode string
+)
+
+// policySimulateCmd represents the policy_simulate command
+var policySimulateCmd = &cobra.Command{
+	Use:   "simulate -f <manifest file>... --flows <flows file>",
+	Short: "Simulate policy decisions offline",
+	Long: `Evaluates a list of flows against CiliumNetworkPolicies and NetworkPolicies
+without a running agent. The policies, pods, namespaces and services are loaded
+from YAML or JSON manifest files, multiple resources in a file are separated by
+"---". The flows file is a YAML list of flows:
+
+  - name: frontend-to-backend
+    src: shop/frontend          # source pod, [namespace/]name
+    dst: shop/backend           # destination pod, or
+    dstService: shop/backend    # destination service backends, or
+    dstCIDR: 192.0.2.0/24       # destination outside of the cluster
+    port: 80
+    protocol: TCP               # TCP (default) or UDP
+    http:                       # optional HTTP request
+      method: GET
+      path: /api/items
+    dns: www.example.com        # optional DNS lookup
+    expect: allowed             # optional expected verdict
+
+The command exits with a non-zero status if the verdict of any flow differs
+from its expected verdict.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(simulateFiles) == 0 {
+			Usagef(cmd, "Missing manifest files")
+		}
+		if simulateFlowsFile == "" {
+			Usagef(cmd, "Missing flows file")
+		}
+		switch simulateEnforcementMode {
+		case option.DefaultEnforcement, option.AlwaysEnforce, option.NeverEnforce:
+		default:
+			Usagef(cmd, "Invalid policy enforcement mode %q", simulateEnforcementMode)
+		}
+
+		manifests := simulate.NewManifests()
+		for _, file := range simulateFiles {
+			if err := manifests.LoadFile(file); err != nil {
+				Fatalf("Unable to load manifests: %s", err)
+			}
+		}
+
+		flows, err := simulate.ParseFlowsFile(simulateFlowsFile)
+		if err != nil {
+			Fatalf("Unable to parse flows: %s", err)
+		}
+
+		sim := simulate.NewSimulator(manifests, simulateClusterName)
+		sim.EnforcementMode = simulateEnforcementMode
+		if verbose {
+			sim.Trace = policy.TRACE_VERBOSE
+		}
+
+		var results []*simulate.FlowResult
+		for _, flow := range flows {
+			res, err := sim.Simulate(flow)
+			if err != nil {
+				Fatalf("Unable to simulate flow %q: %s", flow.Name, err)
+			}
+			results = append(results, res...)
+		}
+
+		if command.OutputJSON() {
+			if err := command.PrintOutput(results); err != nil {
+				os.Exit(1)
+			}
+		} else {
+			printSimulateResults(results)
+		}
+
+		for _, r := range results {
+			if r.Unexpected() {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	policyCmd.AddCommand(policySimulateCmd)
+	policySimulateCmd.Flags().StringSliceVarP(&simulateFiles, "file", "f", []string{}, "Manifest file with policies, pods, namespaces and services")
+	policySimulateCmd.Flags().StringVar(&simulateFlowsFile, "flows", "", "YAML file with the flows to simulate")
+	policySimulateCmd.Flags().StringVar(&simulateClusterName, "cluster-name", defaults.ClusterName, "Name of the cluster the pods run in")
+	policySimulateCmd.Flags().StringVar(&simulateEnforcementMode, "policy-enforcement", option.DefaultEnforcement, "Policy enforcement mode (default, always, never)")
+	policySimulateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print the policy trace of each flow")
+	command.AddJSONOutput(policySimulateCmd)
+}
+
+func formatDirectionVerdict(v *simulate.DirectionVerdict) string {
+	if v == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("%s (%s)", v.Verdict, v.Reason)
+	if len(v.Rules) > 0 {
+		s += ": " + strings.Join(v.Rules, ", ")
+	}
+	return s
+}
+
+func printSimulateResults(results []*simulate.FlowResult) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "FLOW\tSOURCE\tDESTINATION\tPORT\tVERDICT\tEGRESS\tINGRESS\n")
+	for _, r := range results {
+		verdict := strings.ToUpper(r.Verdict.String())
+		if r.Unexpected() {
+			verdict += fmt.Sprintf(" (expected %s)", r.Expect)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%s\t%s\t%s\t%s\n", r.Name, r.Src, r.Dst,
+			r.Port, r.Protocol, verdict,
+			formatDirectionVerdict(r.Egress), formatDirectionVerdict(r.Ingress))
+	}
+	w.Flush()
+
+	for _, r := range results {
+		if r.Trace != "" {
+			fmt.Println("----------------------------------------------------------------")
+			fmt.Printf("%s: %s -> %s\n%s", r.Name, r.Src, r.Dst, r.Trace)
+		}
+	}
+}
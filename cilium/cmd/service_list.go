@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/cilium/cilium/api/v1/models"
@@ -60,11 +61,13 @@ func listServices(cmd *cobra.Command, args []string) {
 }
 
 func printServiceList(w *tabwriter.Writer, list []*models.Service) {
-	fmt.Fprintln(w, "ID\tFrontend\tBackend\t")
+	fmt.Fprintln(w, "ID\tFrontend\tAlgorithm\tClusters\tBackend\t")
 
 	type ServiceOutput struct {
 		ID               int64
 		FrontendAddress  string
+		Algorithm        string
+		Clusters         string
 		BackendAddresses []string
 	}
 	svcs := []ServiceOutput{}
@@ -97,9 +100,16 @@ func printServiceList(w *tabwriter.Writer, list []*models.Service) {
 			backendAddresses = append(backendAddresses, str)
 		}
 
+		algorithm := string(loadbalancer.LBAlgorithmRandom)
+		if flags := svc.Status.Realized.Flags; flags != nil && flags.LbAlgorithm != "" {
+			algorithm = flags.LbAlgorithm
+		}
+
 		SvcOutput := ServiceOutput{
 			ID:               svc.Status.Realized.ID,
 			FrontendAddress:  feA.String(),
+			Algorithm:        algorithm,
+			Clusters:         globalServiceClusters(svc.Status.Realized.Flags),
 			BackendAddresses: backendAddresses,
 		}
 		svcs = append(svcs, SvcOutput)
@@ -113,22 +123,40 @@ func printServiceList(w *tabwriter.Writer, list []*models.Service) {
 		var str string
 
 		if len(service.BackendAddresses) == 0 {
-			str = fmt.Sprintf("%d\t%s\t\t",
-				service.ID, service.FrontendAddress)
+			str = fmt.Sprintf("%d\t%s\t%s\t%s\t\t",
+				service.ID, service.FrontendAddress, service.Algorithm, service.Clusters)
 			fmt.Fprintln(w, str)
 			continue
 		}
 
-		str = fmt.Sprintf("%d\t%s\t%s\t",
-			service.ID, service.FrontendAddress,
-			service.BackendAddresses[0])
+		str = fmt.Sprintf("%d\t%s\t%s\t%s\t%s\t",
+			service.ID, service.FrontendAddress, service.Algorithm,
+			service.Clusters, service.BackendAddresses[0])
 		fmt.Fprintln(w, str)
 
 		for _, bkaddr := range service.BackendAddresses[1:] {
-			str := fmt.Sprintf("\t\t%s\t", bkaddr)
+			str := fmt.Sprintf("\t\t\t\t%s\t", bkaddr)
 			fmt.Fprintln(w, str)
 		}
 	}
 
 	w.Flush()
 }
+
+// globalServiceClusters returns the clusters selected by a global service
+// followed by its cluster affinity, or an empty string if the service is not
+// a global service
+func globalServiceClusters(flags *models.ServiceSpecFlags) string {
+	if flags == nil || flags.GlobalServiceAffinity == "" {
+		return ""
+	}
+
+	clusters := "-"
+	if len(flags.GlobalServiceClusters) > 0 {
+		clusters = strings.Join(flags.GlobalServiceClusters, ",")
+	}
+	if flags.GlobalServiceAffinity == string(loadbalancer.ClusterAffinityNone) {
+		return clusters
+	}
+	return fmt.Sprintf("%s (%s)", clusters, flags.GlobalServiceAffinity)
+}
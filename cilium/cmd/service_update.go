@@ -23,16 +23,21 @@ import (
 	"strings"
 
 	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/defaults"
 	"github.com/cilium/cilium/pkg/loadbalancer"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	addRev   bool
-	idU      uint64
-	frontend string
-	backends []string
+	addRev      bool
+	idU         uint64
+	frontend    string
+	backends    []string
+	lbAlgorithm string
+
+	sessionAffinity           bool
+	sessionAffinityTimeoutSec uint32
 )
 
 // serviceUpdateCmd represents the service_update command
@@ -50,6 +55,9 @@ func init() {
 	serviceUpdateCmd.Flags().Uint64VarP(&idU, "id", "", 0, "Identifier")
 	serviceUpdateCmd.Flags().StringVarP(&frontend, "frontend", "", "", "Frontend address")
 	serviceUpdateCmd.Flags().StringSliceVarP(&backends, "backends", "", []string{}, "Backend address or addresses followed by optional weight (<IP:Port>[/weight])")
+	serviceUpdateCmd.Flags().StringVarP(&lbAlgorithm, "lb-algorithm", "", "", "Algorithm to select the backend of new connections (random, maglev), defaults to the agent setting")
+	serviceUpdateCmd.Flags().BoolVarP(&sessionAffinity, "session-affinity", "", false, "Send all connections of a client to the same backend")
+	serviceUpdateCmd.Flags().Uint32VarP(&sessionAffinityTimeoutSec, "session-affinity-timeout", "", defaults.SessionAffinityTimeoutSec, "Time in seconds after which an idle session affinity expires")
 }
 
 func parseFrontendAddress(address string) (*models.FrontendAddress, net.IP) {
@@ -94,6 +102,26 @@ func updateService(cmd *cobra.Command, args []string) {
 	spec.FrontendAddress = fa
 	spec.Flags.DirectServerReturn = addRev
 
+	if lbAlgorithm != "" {
+		algorithm, err := loadbalancer.ParseLBAlgorithm(lbAlgorithm)
+		if err != nil {
+			Fatalf("Invalid load-balancing algorithm: %s", err)
+		}
+		spec.Flags.LbAlgorithm = string(algorithm)
+	}
+
+	// Keep the session affinity of an existing service unless it is
+	// explicitly changed
+	if cmd.Flags().Changed("session-affinity") {
+		spec.Flags.SessionAffinity = sessionAffinity
+		spec.Flags.SessionAffinityTimeout = 0
+		if sessionAffinity {
+			spec.Flags.SessionAffinityTimeout = int64(sessionAffinityTimeoutSec)
+		}
+	} else if cmd.Flags().Changed("session-affinity-timeout") && spec.Flags.SessionAffinity {
+		spec.Flags.SessionAffinityTimeout = int64(sessionAffinityTimeoutSec)
+	}
+
 	if len(backends) == 0 {
 		fmt.Printf("Reading backend list from stdin...\n")
 
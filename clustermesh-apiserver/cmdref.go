@@ -0,0 +1,34 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func linkHandler(s string) string {
+	// The generated files have a 'See also' section but the URL's are
+	// hardcoded to use Markdown but we only want / have them in HTML
+	// later.
+	return strings.Replace(s, ".md", ".html", 1)
+}
+
+func filePrepend(s string) string {
+	// Prepend a HTML comment that this file is autogenerated. So that
+	// users are warned before fixing issues in the Markdown files.  Should
+	// never show up on the web.
+	return fmt.Sprintf("%s\n\n", "<!-- This file was autogenerated via clustermesh-apiserver --cmdref, do not edit manually-->")
+}
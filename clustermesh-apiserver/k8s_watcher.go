@@ -0,0 +1,152 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/cilium/cilium/pkg/clustermesh/mirror"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/k8s/informer"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// convertToObject passes the full custom resource to the event handlers as
+// the mirror requires the complete status of the resources
+func convertToObject(obj interface{}) interface{} {
+	return obj
+}
+
+// mirrorEventHandler returns the event handlers calling upsert for all added
+// and updated objects and remove for all deleted objects, including the
+// ones of which the deletion was not observed by the watcher
+func mirrorEventHandler(kind string, upsert, remove func(obj interface{}) error) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if err := upsert(obj); err != nil {
+				log.WithError(err).Warningf("Unable to mirror %s", kind)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if err := upsert(newObj); err != nil {
+				log.WithError(err).Warningf("Unable to mirror %s", kind)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if deletedObj, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				// Delete was not observed by the watcher but is
+				// removed from kube-apiserver. This is the last
+				// known state and the object no longer exists.
+				obj = deletedObj.Obj
+			}
+			if err := remove(obj); err != nil {
+				log.WithError(err).Warningf("Unable to remove mirrored %s", kind)
+			}
+		},
+	}
+}
+
+// startSynchronizingCRD starts an informer for the custom resources of the
+// given type and returns its sync function
+func startSynchronizingCRD(resource string, objType runtime.Object, handler cache.ResourceEventHandler) cache.InformerSynced {
+	_, controller := informer.NewInformer(
+		cache.NewListWatchFromClient(ciliumK8sClient.CiliumV2().RESTClient(),
+			resource, v1.NamespaceAll, fields.Everything()),
+		objType,
+		0,
+		handler,
+		convertToObject,
+	)
+
+	go controller.Run(wait.NeverStop)
+
+	return controller.HasSynced
+}
+
+func unknownObject(kind string, obj interface{}) {
+	log.Warningf("Unknown %s object type %s received: %+v", kind, reflect.TypeOf(obj), obj)
+}
+
+func startSynchronizingIdentities(m *mirror.Mirror) cache.InformerSynced {
+	log.Info("Starting to synchronize CiliumIdentity custom resources...")
+
+	handler := mirrorEventHandler("CiliumIdentity",
+		func(obj interface{}) error {
+			if id, ok := obj.(*v2.CiliumIdentity); ok {
+				return m.UpsertIdentity(context.TODO(), id)
+			}
+			unknownObject("CiliumIdentity", obj)
+			return nil
+		},
+		func(obj interface{}) error {
+			if id, ok := obj.(*v2.CiliumIdentity); ok {
+				return m.DeleteIdentity(id)
+			}
+			unknownObject("CiliumIdentity", obj)
+			return nil
+		})
+
+	return startSynchronizingCRD("ciliumidentities", &v2.CiliumIdentity{}, handler)
+}
+
+func startSynchronizingEndpoints(m *mirror.Mirror) cache.InformerSynced {
+	log.Info("Starting to synchronize CiliumEndpoint custom resources...")
+
+	handler := mirrorEventHandler("CiliumEndpoint",
+		func(obj interface{}) error {
+			if ep, ok := obj.(*v2.CiliumEndpoint); ok {
+				return m.UpsertEndpoint(context.TODO(), ep)
+			}
+			unknownObject("CiliumEndpoint", obj)
+			return nil
+		},
+		func(obj interface{}) error {
+			if ep, ok := obj.(*v2.CiliumEndpoint); ok {
+				return m.DeleteEndpoint(ep)
+			}
+			unknownObject("CiliumEndpoint", obj)
+			return nil
+		})
+
+	return startSynchronizingCRD("ciliumendpoints", &v2.CiliumEndpoint{}, handler)
+}
+
+func startSynchronizingNodes(m *mirror.Mirror) cache.InformerSynced {
+	log.Info("Starting to synchronize CiliumNode custom resources...")
+
+	handler := mirrorEventHandler("CiliumNode",
+		func(obj interface{}) error {
+			if node, ok := obj.(*v2.CiliumNode); ok {
+				return m.UpsertNode(context.TODO(), node)
+			}
+			unknownObject("CiliumNode", obj)
+			return nil
+		},
+		func(obj interface{}) error {
+			if node, ok := obj.(*v2.CiliumNode); ok {
+				return m.DeleteNode(node)
+			}
+			unknownObject("CiliumNode", obj)
+			return nil
+		})
+
+	return startSynchronizingCRD("ciliumnodes", &v2.CiliumNode{}, handler)
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cilium/cilium/pkg/clustermesh/mirror"
+	"github.com/cilium/cilium/pkg/controller"
+	"github.com/cilium/cilium/pkg/defaults"
+	"github.com/cilium/cilium/pkg/k8s"
+	clientset "github.com/cilium/cilium/pkg/k8s/client/clientset/versioned"
+	k8sversion "github.com/cilium/cilium/pkg/k8s/version"
+	"github.com/cilium/cilium/pkg/kvstore"
+	"github.com/cilium/cilium/pkg/logging"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/version"
+
+	gops "github.com/google/gops/agent"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+var (
+	log = logging.DefaultLogger.WithField(logfields.LogSubsys, "clustermesh-apiserver")
+
+	rootCmd = &cobra.Command{
+		Use:   "clustermesh-apiserver",
+		Short: "Run the clustermesh-apiserver",
+		Long: `Run the clustermesh-apiserver
+
+The clustermesh-apiserver mirrors the CiliumIdentity, CiliumEndpoint and
+CiliumNode custom resources as well as the global services of a cluster into
+a kvstore, so that agents of remote clusters can connect to the cluster even
+if it runs with identity-allocation-mode=crd.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			runApiserver(cmd)
+		},
+	}
+
+	k8sAPIServer      string
+	k8sKubeConfigPath string
+	kvStore           string
+	kvStoreOpts       = make(map[string]string)
+	resyncInterval    time.Duration
+	shutdownSignal    = make(chan struct{})
+
+	ciliumK8sClient clientset.Interface
+
+	cmdRefDir string
+)
+
+func main() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		close(shutdownSignal)
+	}()
+
+	// Open socket for using gops to get stacktraces of the apiserver.
+	if err := gops.Listen(gops.Options{}); err != nil {
+		errorString := fmt.Sprintf("unable to start gops: %s", err)
+		fmt.Println(errorString)
+		os.Exit(-1)
+	}
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+func init() {
+	cobra.OnInitialize(initConfig)
+
+	flags := rootCmd.Flags()
+	flags.Bool("version", false, "Print version information")
+	flags.Int(option.ClusterIDName, 0, "Unique identifier of the cluster")
+	option.BindEnv(option.ClusterIDName)
+	flags.String(option.ClusterName, defaults.ClusterName, "Name of the cluster")
+	option.BindEnv(option.ClusterName)
+	flags.BoolP("debug", "D", false, "Enable debugging mode")
+	flags.StringVar(&k8sAPIServer, "k8s-api-server", "", "Kubernetes api address server (for https use --k8s-kubeconfig-path instead)")
+	flags.StringVar(&k8sKubeConfigPath, "k8s-kubeconfig-path", "", "Absolute path of the kubernetes kubeconfig file")
+	flags.String(option.KVStore, "", "Key-value store type")
+	option.BindEnv(option.KVStore)
+	flags.Var(option.NewNamedMapOptions(option.KVStoreOpt, &kvStoreOpts, nil), option.KVStoreOpt, "Key-value store options")
+	option.BindEnv(option.KVStoreOpt)
+	flags.DurationVar(&resyncInterval, "kvstore-resync-interval", defaults.KVstorePeriodicSync, "Interval in which all mirrored keys are re-created in the kvstore")
+
+	flags.Float32(option.K8sClientQPSLimit, defaults.K8sClientQPSLimit, "Queries per second limit for the K8s client")
+	flags.Int(option.K8sClientBurst, defaults.K8sClientBurst, "Burst value allowed for the K8s client")
+
+	flags.StringVar(&cmdRefDir, "cmdref", "", "Path to cmdref output directory")
+	flags.MarkHidden("cmdref")
+	viper.BindPFlags(flags)
+
+	// Make sure that klog logging variables are initialized so that we can
+	// update them from this file.
+	klog.InitFlags(nil)
+
+	// Make sure klog (used by the client-go dependency) logs to stderr, as it
+	// will try to log to directories that may not exist in the container.
+	flag.Set("logtostderr", "true")
+}
+
+// initConfig reads in config file and ENV variables if set.
+func initConfig() {
+	if viper.GetBool("version") {
+		fmt.Printf("Cilium %s\n", version.Version)
+		os.Exit(0)
+	}
+
+	option.Config.ClusterName = viper.GetString(option.ClusterName)
+	option.Config.ClusterID = viper.GetInt(option.ClusterIDName)
+
+	viper.SetEnvPrefix("cilium")
+	viper.SetConfigName("clustermesh-apiserver")
+}
+
+func runApiserver(cmd *cobra.Command) {
+	logging.SetupLogging([]string{}, map[string]string{}, "clustermesh-apiserver", viper.GetBool("debug"))
+
+	if cmdRefDir != "" {
+		// Remove the line 'Auto generated by spf13/cobra on ...'
+		cmd.DisableAutoGenTag = true
+		if err := doc.GenMarkdownTreeCustom(cmd, cmdRefDir, filePrepend, linkHandler); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
+	log.Infof("Cilium ClusterMesh apiserver %s", version.Version)
+
+	k8sClientQPSLimit := viper.GetFloat64(option.K8sClientQPSLimit)
+	k8sClientBurst := viper.GetInt(option.K8sClientBurst)
+	kvStore = viper.GetString(option.KVStore)
+	kvStoreOpts = viper.GetStringMapString(option.KVStoreOpt)
+
+	if kvStore == "" {
+		log.Fatalf("A kvstore must be configured with --%s", option.KVStore)
+	}
+
+	k8s.Configure(k8sAPIServer, k8sKubeConfigPath, float32(k8sClientQPSLimit), k8sClientBurst)
+	if err := k8s.Init(); err != nil {
+		log.WithError(err).Fatal("Unable to connect to Kubernetes apiserver")
+	}
+
+	ciliumK8sClient = k8s.CiliumClient()
+	k8sversion.Update(k8s.Client())
+	if !k8sversion.Capabilities().MinimalVersionMet {
+		log.Fatalf("Minimal kubernetes version not met: %s < %s",
+			k8sversion.Version(), k8sversion.MinimalVersionConstraint)
+	}
+
+	scopedLog := log.WithFields(logrus.Fields{
+		"kvstore": kvStore,
+		"address": kvStoreOpts[fmt.Sprintf("%s.address", kvStore)],
+	})
+	scopedLog.Info("Connecting to kvstore...")
+	if err := kvstore.Setup(kvStore, kvStoreOpts, nil); err != nil {
+		scopedLog.WithError(err).Fatal("Unable to setup kvstore")
+	}
+
+	m := mirror.NewMirror(kvstore.Client(), option.Config.ClusterName, option.Config.ClusterID)
+
+	synced := []cache.InformerSynced{
+		startSynchronizingIdentities(m),
+		startSynchronizingEndpoints(m),
+		startSynchronizingNodes(m),
+	}
+	synced = append(synced, startSynchronizingServices(m)...)
+
+	log.Info("Waiting for all resources to be synced with kubernetes")
+	if !cache.WaitForCacheSync(shutdownSignal, synced...) {
+		log.Info("Received termination signal. Shutting down")
+		return
+	}
+
+	// The initial resynchronization removes all keys left behind by a
+	// previous instance for resources which have been deleted since.
+	controller.NewManager().UpdateController("clustermesh-mirror-resync",
+		controller.ControllerParams{
+			RunInterval: resyncInterval,
+			DoFunc: func(ctx context.Context) error {
+				return m.Resync(ctx)
+			},
+		})
+
+	log.Info("Initialization complete")
+
+	<-shutdownSignal
+	// graceful exit
+	log.Info("Received termination signal. Shutting down")
+}
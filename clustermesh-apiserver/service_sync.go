@@ -0,0 +1,151 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/cilium/cilium/pkg/clustermesh/mirror"
+	"github.com/cilium/cilium/pkg/k8s"
+	"github.com/cilium/cilium/pkg/k8s/informer"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// k8sServiceHandler mirrors all global services of the service cache
+func k8sServiceHandler(m *mirror.Mirror, svcCache *k8s.ServiceCache) {
+	for {
+		event, ok := <-svcCache.Events
+		if !ok {
+			return
+		}
+
+		svc := k8s.NewClusterService(event.ID, event.Service, event.Endpoints)
+
+		scopedLog := log.WithFields(logrus.Fields{
+			logfields.K8sSvcName:   event.ID.Name,
+			logfields.K8sNamespace: event.ID.Namespace,
+			"action":               event.Action.String(),
+			"shared":               event.Service.Shared,
+		})
+		scopedLog.Debug("Kubernetes service definition changed")
+
+		var err error
+		switch {
+		case !event.Service.Shared:
+			// The annotation may have been removed, delete an eventual
+			// existing service
+			err = m.DeleteService(&svc)
+		case event.Action == k8s.UpdateService || event.Action == k8s.UpdateIngress:
+			err = m.UpsertService(context.TODO(), &svc)
+		case event.Action == k8s.DeleteService || event.Action == k8s.DeleteIngress:
+			err = m.DeleteService(&svc)
+		}
+		if err != nil {
+			scopedLog.WithError(err).Warning("Unable to mirror global service")
+		}
+	}
+}
+
+func startSynchronizingServices(m *mirror.Mirror) []cache.InformerSynced {
+	log.Info("Starting to synchronize k8s services...")
+
+	svcCache := k8s.NewServiceCache()
+
+	_, svcController := informer.NewInformer(
+		cache.NewListWatchFromClient(k8s.Client().CoreV1().RESTClient(),
+			"services", v1.NamespaceAll, fields.Everything()),
+		&v1.Service{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if k8sSvc := k8s.CopyObjToV1Services(obj); k8sSvc != nil {
+					svcCache.UpdateService(k8sSvc)
+				}
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if oldk8sSvc := k8s.CopyObjToV1Services(oldObj); oldk8sSvc != nil {
+					if newk8sSvc := k8s.CopyObjToV1Services(newObj); newk8sSvc != nil {
+						if k8s.EqualV1Services(oldk8sSvc, newk8sSvc) {
+							return
+						}
+						svcCache.UpdateService(newk8sSvc)
+					}
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				if deletedObj, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = deletedObj.Obj
+				}
+				if k8sSvc := k8s.CopyObjToV1Services(obj); k8sSvc != nil {
+					svcCache.DeleteService(k8sSvc)
+				}
+			},
+		},
+		k8s.ConvertToK8sService,
+	)
+
+	go svcController.Run(wait.NeverStop)
+
+	_, endpointController := informer.NewInformer(
+		cache.NewListWatchFromClient(k8s.Client().CoreV1().RESTClient(),
+			"endpoints", v1.NamespaceAll,
+			// Don't get any events from kubernetes endpoints.
+			fields.ParseSelectorOrDie("metadata.name!=kube-scheduler,metadata.name!=kube-controller-manager"),
+		),
+		&v1.Endpoints{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if k8sEP := k8s.CopyObjToV1Endpoints(obj); k8sEP != nil {
+					svcCache.UpdateEndpoints(k8sEP)
+				}
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if oldk8sEP := k8s.CopyObjToV1Endpoints(oldObj); oldk8sEP != nil {
+					if newk8sEP := k8s.CopyObjToV1Endpoints(newObj); newk8sEP != nil {
+						if k8s.EqualV1Endpoints(oldk8sEP, newk8sEP) {
+							return
+						}
+						svcCache.UpdateEndpoints(newk8sEP)
+					}
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				if deletedObj, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = deletedObj.Obj
+				}
+				if k8sEP := k8s.CopyObjToV1Endpoints(obj); k8sEP != nil {
+					svcCache.DeleteEndpoints(k8sEP)
+				}
+			},
+		},
+		k8s.ConvertToK8sEndpoints,
+	)
+
+	go endpointController.Run(wait.NeverStop)
+
+	go k8sServiceHandler(m, &svcCache)
+
+	return []cache.InformerSynced{
+		svcController.HasSynced,
+		endpointController.HasSynced,
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/cilium/cilium/api/v1/models"
+	restapi "github.com/cilium/cilium/api/v1/server/restapi/daemon"
+	"github.com/cilium/cilium/pkg/api"
+	"github.com/cilium/cilium/pkg/byteorder"
+	"github.com/cilium/cilium/pkg/endpoint"
+	"github.com/cilium/cilium/pkg/endpointmanager"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/maps/ctmap"
+	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/u8proto"
+
+	"github.com/go-openapi/runtime/middleware"
+)
+
+// ctMapOwner is a conntrack map along with the ID of the endpoint owning it,
+// 0 for the global maps
+type ctMapOwner struct {
+	m          *ctmap.Map
+	endpointID uint16
+}
+
+// conntrackMaps returns the conntrack maps holding the connections of ep, or
+// all conntrack maps if ep is nil
+func conntrackMaps(ep *endpoint.Endpoint) []ctMapOwner {
+	var result []ctMapOwner
+
+	add := func(maps []*ctmap.Map, endpointID uint16) {
+		for _, m := range maps {
+			result = append(result, ctMapOwner{m: m, endpointID: endpointID})
+		}
+	}
+
+	ipv4, ipv6 := option.Config.EnableIPv4, option.Config.EnableIPv6
+	if ep != nil {
+		if ep.ConntrackLocal() {
+			add(ctmap.LocalMaps(ep, ipv4, ipv6), ep.ID)
+		} else {
+			add(ctmap.GlobalMaps(ipv4, ipv6), 0)
+		}
+		return result
+	}
+
+	add(ctmap.GlobalMaps(ipv4, ipv6), 0)
+	for _, e := range endpointmanager.GetEndpoints() {
+		if e.ConntrackLocal() {
+			add(ctmap.LocalMaps(e, ipv4, ipv6), e.ID)
+		}
+	}
+	return result
+}
+
+// endpointIPs maps the IPs of all local endpoints to the endpoint IDs
+func endpointIPs() map[string]uint16 {
+	ips := map[string]uint16{}
+	for _, e := range endpointmanager.GetEndpoints() {
+		for _, ip := range e.IPs() {
+			ips[ip.String()] = e.ID
+		}
+	}
+	return ips
+}
+
+// entryOwner returns a function returning the ID of the endpoint owning a
+// conntrack entry found in the map of owner, 0 if unknown
+func entryOwner(owner ctMapOwner, ips map[string]uint16) func(*ctmap.Entry) uint16 {
+	return func(e *ctmap.Entry) uint16 {
+		if owner.endpointID != 0 {
+			return owner.endpointID
+		}
+		if id, ok := ips[e.SrcIP.String()]; ok {
+			return id
+		}
+		return ips[e.DstIP.String()]
+	}
+}
+
+// forEachConntrackMap opens each of the conntrack maps and calls fn with
+// it. Maps which do not exist are skipped.
+func forEachConntrackMap(maps []ctMapOwner, fn func(owner ctMapOwner) error) error {
+	for _, owner := range maps {
+		if err := owner.m.Open(); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("unable to open %s: %s", owner.m.Name(), err)
+		}
+		err := fn(owner)
+		owner.m.Close()
+		if err != nil {
+			return fmt.Errorf("unable to dump %s: %s", owner.m.Name(), err)
+		}
+	}
+	return nil
+}
+
+// newConntrackFilter returns a conntrack query filter for the given source
+// and destination CIDR, port and protocol. Nil values are not filtered on.
+func newConntrackFilter(srcCIDR, dstCIDR *string, port *int64, protocol *string) (*ctmap.QueryFilter, error) {
+	filter := &ctmap.QueryFilter{}
+
+	if srcCIDR != nil {
+		_, cidr, err := net.ParseCIDR(*srcCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source CIDR: %s", err)
+		}
+		filter.SrcCIDR = cidr
+	}
+
+	if dstCIDR != nil {
+		_, cidr, err := net.ParseCIDR(*dstCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination CIDR: %s", err)
+		}
+		filter.DstCIDR = cidr
+	}
+
+	if port != nil {
+		p, err := parseConntrackPort(*port)
+		if err != nil {
+			return nil, err
+		}
+		filter.Port = p
+	}
+
+	if protocol != nil {
+		proto, err := u8proto.ParseProtocol(*protocol)
+		if err != nil {
+			return nil, err
+		}
+		filter.Protocol = proto
+	}
+
+	return filter, nil
+}
+
+func parseConntrackPort(port int64) (uint16, error) {
+	if port < 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %d", port)
+	}
+	return uint16(port), nil
+}
+
+func parseConntrackFilter(params restapi.GetConntrackParams) (*ctmap.QueryFilter, error) {
+	filter, err := newConntrackFilter(params.SourceCidr, params.DestinationCidr, params.Port, params.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Flags != nil {
+		flags, err := ctmap.ParseFlags(*params.Flags)
+		if err != nil {
+			return nil, err
+		}
+		filter.Flags = flags
+	}
+
+	return filter, nil
+}
+
+func parseConntrackPurgeFilter(params restapi.DeleteConntrackParams) (*ctmap.QueryFilter, error) {
+	if params.Endpoint == nil && params.SourceCidr == nil && params.DestinationCidr == nil &&
+		params.Port == nil && params.Protocol == nil && params.Identity == nil {
+		return nil, fmt.Errorf("at least one of endpoint, CIDR, port, protocol or identity must be given")
+	}
+
+	filter, err := newConntrackFilter(params.SourceCidr, params.DestinationCidr, params.Port, params.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.EndPort != nil {
+		if params.Port == nil {
+			return nil, fmt.Errorf("end port requires a port")
+		}
+		endPort, err := parseConntrackPort(*params.EndPort)
+		if err != nil {
+			return nil, err
+		}
+		if endPort < filter.Port {
+			return nil, fmt.Errorf("invalid port range %d-%d", filter.Port, endPort)
+		}
+		filter.EndPort = endPort
+	}
+
+	if params.Identity != nil {
+		if *params.Identity <= 0 || *params.Identity > 0xffffffff {
+			return nil, fmt.Errorf("invalid identity %d", *params.Identity)
+		}
+		filter.SecurityID = uint32(*params.Identity)
+	}
+
+	return filter, nil
+}
+
+// lookupConntrackEndpoint returns the endpoint with the given ID. If the
+// endpoint does not have its own conntrack maps, filter is restricted to
+// the IPs of the endpoint.
+func lookupConntrackEndpoint(id int64, filter *ctmap.QueryFilter) (*endpoint.Endpoint, error) {
+	if id <= 0 || id > 0xffff {
+		return nil, fmt.Errorf("invalid endpoint ID %d", id)
+	}
+
+	ep := endpointmanager.LookupCiliumID(uint16(id))
+	if ep != nil && !ep.ConntrackLocal() {
+		filter.MatchIPs = map[string]struct{}{}
+		for _, ip := range ep.IPs() {
+			filter.MatchIPs[ip.String()] = struct{}{}
+		}
+	}
+	return ep, nil
+}
+
+func conntrackEntryModel(e *ctmap.Entry, now uint32, endpointID uint16) *models.ConntrackEntry {
+	direction := "OUT"
+	if e.TupleFlags&ctmap.TUPLE_F_IN != 0 {
+		direction = "IN"
+	}
+
+	return &models.ConntrackEntry{
+		Map:              e.MapName,
+		EndpointID:       int64(endpointID),
+		Protocol:         e.Protocol.String(),
+		SourceIP:         e.SrcIP.String(),
+		SourcePort:       int64(e.SrcPort),
+		DestinationIP:    e.DstIP.String(),
+		DestinationPort:  int64(e.DstPort),
+		Direction:        direction,
+		Related:          e.TupleFlags&ctmap.TUPLE_F_RELATED != 0,
+		Service:          e.TupleFlags&ctmap.TUPLE_F_SERVICE != 0,
+		Flags:            e.FlagNames(),
+		State:            e.State(now),
+		Lifetime:         int64(e.Remaining(now)),
+		RxPackets:        int64(e.RxPackets),
+		RxBytes:          int64(e.RxBytes),
+		TxPackets:        int64(e.TxPackets),
+		TxBytes:          int64(e.TxBytes),
+		SourceSecurityID: int64(e.SourceSecurityID),
+		RevNat:           int64(byteorder.NetworkToHost(e.RevNAT).(uint16)),
+	}
+}
+
+type getConntrack struct {
+	daemon *Daemon
+}
+
+func NewGetConntrackHandler(d *Daemon) restapi.GetConntrackHandler {
+	return &getConntrack{daemon: d}
+}
+
+func (h *getConntrack) Handle(params restapi.GetConntrackParams) middleware.Responder {
+	filter, err := parseConntrackFilter(params)
+	if err != nil {
+		return api.Error(restapi.GetConntrackInvalidCode, err)
+	}
+
+	var ep *endpoint.Endpoint
+	if params.Endpoint != nil {
+		if ep, err = lookupConntrackEndpoint(*params.Endpoint, filter); err != nil {
+			return api.Error(restapi.GetConntrackInvalidCode, err)
+		} else if ep == nil {
+			return restapi.NewGetConntrackNotFound()
+		}
+	}
+
+	now, err := ctmap.Now()
+	if err != nil {
+		return api.Error(restapi.GetConntrackFailureCode, err)
+	}
+
+	ips := endpointIPs()
+	entries := []*models.ConntrackEntry{}
+	err = forEachConntrackMap(conntrackMaps(ep), func(owner ctMapOwner) error {
+		endpointID := entryOwner(owner, ips)
+		return owner.m.Query(filter, func(e *ctmap.Entry) {
+			entries = append(entries, conntrackEntryModel(e, now, endpointID(e)))
+		})
+	})
+	if err != nil {
+		return api.Error(restapi.GetConntrackFailureCode, err)
+	}
+
+	return restapi.NewGetConntrackOK().WithPayload(entries)
+}
+
+type deleteConntrack struct {
+	daemon *Daemon
+}
+
+func NewDeleteConntrackHandler(d *Daemon) restapi.DeleteConntrackHandler {
+	return &deleteConntrack{daemon: d}
+}
+
+func (h *deleteConntrack) Handle(params restapi.DeleteConntrackParams) middleware.Responder {
+	log.WithField(logfields.Params, logfields.Repr(params)).Debug("DELETE /conntrack request")
+
+	filter, err := parseConntrackPurgeFilter(params)
+	if err != nil {
+		return api.Error(restapi.DeleteConntrackInvalidCode, err)
+	}
+
+	var ep *endpoint.Endpoint
+	if params.Endpoint != nil {
+		if ep, err = lookupConntrackEndpoint(*params.Endpoint, filter); err != nil {
+			return api.Error(restapi.DeleteConntrackInvalidCode, err)
+		} else if ep == nil {
+			return restapi.NewDeleteConntrackNotFound()
+		}
+	}
+
+	dryRun := params.DryRun != nil && *params.DryRun
+	result := &models.ConntrackPurgeResponse{
+		DryRun: dryRun,
+		PerMap: map[string]int64{},
+	}
+	err = forEachConntrackMap(conntrackMaps(ep), func(owner ctMapOwner) error {
+		purged := ctmap.Purge(owner.m, &ctmap.GCFilter{Match: filter, DryRun: dryRun})
+		result.PerMap[owner.m.Name()] = int64(purged.Deleted)
+		result.TotalEntries += int64(purged.Deleted)
+		if purged.NatMap != "" {
+			result.PerMap[purged.NatMap] += int64(purged.NatDeleted)
+			result.TotalEntries += int64(purged.NatDeleted)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.Error(restapi.DeleteConntrackFailureCode, err)
+	}
+
+	// NAT is only performed for connections tracked in the global maps
+	if ep == nil || !ep.ConntrackLocal() {
+		orphans, err := ctmap.PurgeOrphanNatEntries(filter, option.Config.EnableIPv4, option.Config.EnableIPv6, dryRun)
+		if err != nil {
+			return api.Error(restapi.DeleteConntrackFailureCode, err)
+		}
+		for name, deleted := range orphans {
+			result.PerMap[name] += int64(deleted)
+			result.TotalEntries += int64(deleted)
+		}
+	}
+
+	return restapi.NewDeleteConntrackOK().WithPayload(result)
+}
+
+type getConntrackStatistics struct {
+	daemon *Daemon
+}
+
+func NewGetConntrackStatisticsHandler(d *Daemon) restapi.GetConntrackStatisticsHandler {
+	return &getConntrackStatistics{daemon: d}
+}
+
+func (h *getConntrackStatistics) Handle(params restapi.GetConntrackStatisticsParams) middleware.Responder {
+	now, err := ctmap.Now()
+	if err != nil {
+		return api.Error(restapi.GetConntrackStatisticsFailureCode, err)
+	}
+
+	ips := endpointIPs()
+	stats := ctmap.NewStatistics()
+	err = forEachConntrackMap(conntrackMaps(nil), func(owner ctMapOwner) error {
+		return stats.Collect(owner.m, now, entryOwner(owner, ips))
+	})
+	if err != nil {
+		return api.Error(restapi.GetConntrackStatisticsFailureCode, err)
+	}
+
+	interval, deleteRatio := ctmap.GCStatus()
+	result := &models.ConntrackStatistics{
+		TotalEntries:  int64(stats.Entries),
+		PerEndpoint:   map[string]int64{},
+		PerProtocol:   map[string]int64{},
+		PerState:      map[string]int64{},
+		GcInterval:    int64(interval.Seconds()),
+		GcDeleteRatio: deleteRatio,
+	}
+	for id, n := range stats.PerEndpoint {
+		result.PerEndpoint[strconv.Itoa(int(id))] = int64(n)
+	}
+	for proto, n := range stats.PerProtocol {
+		result.PerProtocol[proto] = int64(n)
+	}
+	for state, n := range stats.PerState {
+		result.PerState[state] = int64(n)
+	}
+	for i := range stats.Maps {
+		m := &stats.Maps[i]
+		result.Maps = append(result.Maps, &models.ConntrackMapStatistics{
+			Name:       m.Name,
+			Entries:    int64(m.Entries),
+			MaxEntries: int64(m.MaxEntries),
+			FillRatio:  m.FillRatio(),
+		})
+	}
+
+	return restapi.NewGetConntrackStatisticsOK().WithPayload(result)
+}
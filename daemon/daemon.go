@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
@@ -33,6 +34,7 @@ import (
 	"github.com/cilium/cilium/pkg/datapath"
 	bpfIPCache "github.com/cilium/cilium/pkg/datapath/ipcache"
 	"github.com/cilium/cilium/pkg/datapath/linux/ipsec"
+	"github.com/cilium/cilium/pkg/datapath/linux/wireguard"
 	"github.com/cilium/cilium/pkg/datapath/loader"
 	"github.com/cilium/cilium/pkg/datapath/prefilter"
 	"github.com/cilium/cilium/pkg/debug"
@@ -163,6 +165,10 @@ type Daemon struct {
 	// resource name maps to is closed.
 	k8sResourceSynced map[string]chan struct{}
 
+	// ciliumNodesSynced is closed once the node manager has processed
+	// the initial list of CiliumNode resources
+	ciliumNodesSynced chan struct{}
+
 	// k8sSvcCache is a cache of all Kubernetes services and endpoints
 	k8sSvcCache k8s.ServiceCache
 
@@ -448,6 +454,19 @@ func (d *Daemon) initMaps() error {
 			RunInterval: 5 * time.Second,
 		})
 
+	// Start the controller for periodic garbage collection of expired
+	// session affinity entries.
+	controller.NewManager().UpdateController("lb-affinity-gc",
+		controller.ControllerParams{
+			DoFunc: func(ctx context.Context) error {
+				if deleted := lbmap.GCAffinityMaps(); deleted > 0 {
+					log.WithField("deleted", deleted).Debug("Removed expired session affinity entries")
+				}
+				return nil
+			},
+			RunInterval: defaults.SessionAffinityGCInterval,
+		})
+
 	// Clean all lb entries
 	if !option.Config.RestoreState {
 		log.Debug("cleaning up all BPF LB maps")
@@ -462,6 +481,12 @@ func (d *Daemon) initMaps() error {
 			if err := lbmap.RRSeq6MapV2.DeleteAll(); err != nil {
 				return err
 			}
+			if err := lbmap.Maglev6Map.DeleteAll(); err != nil {
+				return err
+			}
+			if err := lbmap.Affinity6Map.DeleteAll(); err != nil {
+				return err
+			}
 			if err := lbmap.Backend6Map.DeleteAll(); err != nil {
 				return err
 			}
@@ -477,6 +502,12 @@ func (d *Daemon) initMaps() error {
 			if err := lbmap.RRSeq4MapV2.DeleteAll(); err != nil {
 				return err
 			}
+			if err := lbmap.Maglev4Map.DeleteAll(); err != nil {
+				return err
+			}
+			if err := lbmap.Affinity4Map.DeleteAll(); err != nil {
+				return err
+			}
 			if err := lbmap.Backend4Map.DeleteAll(); err != nil {
 				return err
 			}
@@ -730,7 +761,8 @@ func NewDaemon(dp datapath.Datapath, iptablesManager rulesManager) (*Daemon, *en
 		return nil, nil, fmt.Errorf("unable to setup encryption: %s", err)
 	}
 
-	mtuConfig := mtu.NewConfiguration(authKeySize, option.Config.EnableIPSec, option.Config.Tunnel != option.TunnelDisabled, configuredMTU)
+	mtuConfig := mtu.NewConfiguration(authKeySize, option.Config.EnableIPSec, option.Config.Tunnel != option.TunnelDisabled,
+		option.Config.EnableWireguard, configuredMTU)
 
 	nodeMngr, err := nodemanager.NewManager("all", dp.Node())
 	if err != nil {
@@ -748,6 +780,7 @@ func NewDaemon(dp datapath.Datapath, iptablesManager rulesManager) (*Daemon, *en
 		uniqueID:          map[uint64]context.CancelFunc{},
 		prefixLengths:     createPrefixLengthCounter(),
 		k8sResourceSynced: map[string]chan struct{}{},
+		ciliumNodesSynced: make(chan struct{}),
 		buildEndpointSem:  semaphore.NewWeighted(int64(numWorkerThreads())),
 		compilationMutex:  new(lock.RWMutex),
 		netConf:           netConf,
@@ -762,6 +795,7 @@ func NewDaemon(dp datapath.Datapath, iptablesManager rulesManager) (*Daemon, *en
 		if err != nil {
 			return nil, nil, err
 		}
+		monitorAgent.SetFlowResolvers(flowResolver{}, flowResolver{})
 		d.monitorAgent = monitorAgent
 	}
 	bootstrapStats.daemonInit.End(true)
@@ -961,6 +995,40 @@ func setupIPSec() (int, error) {
 	return authKeySize, nil
 }
 
+// waitForNodesProcessed blocks until the node manager has processed the
+// initial list of remote nodes of the active node discovery source, i.e. the
+// key-value store if configured, the CiliumNode resources otherwise.
+func (d *Daemon) waitForNodesProcessed() {
+	if option.Config.KVStore != "" {
+		// The initial list of the node store is processed before
+		// the local node is registered
+		<-d.nodeDiscovery.Registered
+		return
+	}
+	<-d.ciliumNodesSynced
+}
+
+// rotateIPSecKey is called when the IPsec key file has changed to the keys
+// with ID spi. The states of the new keys are installed for all nodes before
+// the new key ID is advertised, so remote nodes only start to use the new
+// keys once they can be decrypted by the local node.
+func (d *Daemon) rotateIPSecKey(spi uint8) {
+	node.SetIPsecKeyIdentity(spi)
+	d.nodeDiscovery.Manager.ValidateNodes()
+	d.nodeDiscovery.UpdateEncryptionKey(spi)
+}
+
+func setupWireguard() (*wireguard.Agent, error) {
+	privateKeyFile := filepath.Join(option.Config.StateDir, wireguard.PrivateKeyFile)
+	agent, err := wireguard.NewAgent(wireguard.NewDevice(), privateKeyFile, option.Config.WireguardListenPort)
+	if err != nil {
+		return nil, err
+	}
+	node.SetWireguardPublicKey(agent.PublicKey().String())
+
+	return agent, nil
+}
+
 func (d *Daemon) bootstrapClusterMesh(nodeMngr *nodemanager.Manager) {
 	bootstrapStats.clusterMeshInit.Start()
 	if path := option.Config.ClusterMeshConfig; path != "" {
@@ -39,6 +39,7 @@ import (
 	"github.com/cilium/cilium/pkg/controller"
 	"github.com/cilium/cilium/pkg/datapath/iptables"
 	linuxdatapath "github.com/cilium/cilium/pkg/datapath/linux"
+	"github.com/cilium/cilium/pkg/datapath/linux/ipsec"
 	"github.com/cilium/cilium/pkg/datapath/loader"
 	"github.com/cilium/cilium/pkg/datapath/maps"
 	"github.com/cilium/cilium/pkg/defaults"
@@ -453,6 +454,9 @@ func init() {
 	flags.Bool(option.EnableHealthChecking, defaults.EnableHealthChecking, "Enable connectivity health checking")
 	option.BindEnv(option.EnableHealthChecking)
 
+	flags.Duration(option.HealthHistoryWindow, defaults.HealthHistoryWindow, "Time window over which the history of connectivity health probes is kept")
+	option.BindEnv(option.HealthHistoryWindow)
+
 	flags.Bool(option.EnableIPv4Name, defaults.EnableIPv4, "Enable IPv4 support")
 	option.BindEnv(option.EnableIPv4Name)
 
@@ -517,6 +521,12 @@ func init() {
 	flags.String(option.IPSecKeyFileName, "", "Path to IPSec key file")
 	option.BindEnv(option.IPSecKeyFileName)
 
+	flags.Bool(option.EnableWireguardName, defaults.EnableWireguard, "Enable WireGuard encryption of traffic between nodes")
+	option.BindEnv(option.EnableWireguardName)
+
+	flags.Int(option.WireguardListenPortName, defaults.WireguardListenPort, "UDP port WireGuard listens on")
+	option.BindEnv(option.WireguardListenPortName)
+
 	flags.Bool(option.ForceLocalPolicyEvalAtSource, defaults.ForceLocalPolicyEvalAtSource, "Force policy evaluation of all local communication at the source endpoint")
 	option.BindEnv(option.ForceLocalPolicyEvalAtSource)
 
@@ -653,6 +663,9 @@ func init() {
 	flags.StringSlice(option.NodePortRange, []string{fmt.Sprintf("%d", option.NodePortMinDefault), fmt.Sprintf("%d", option.NodePortMaxDefault)}, fmt.Sprintf("Set the min/max NodePort port range"))
 	option.BindEnv(option.NodePortRange)
 
+	flags.String(option.LBAlgorithm, defaults.LBAlgorithm, "Default algorithm to select the backend of new connections to services (random, maglev)")
+	option.BindEnv(option.LBAlgorithm)
+
 	flags.String(option.LibDir, defaults.LibraryPath, "Directory path to store runtime build environment")
 	option.BindEnv(option.LibDir)
 
@@ -794,6 +807,18 @@ func init() {
 	flags.Int(option.ToFQDNsProxyPort, 0, "Global port on which the in-agent DNS proxy should listen. Default 0 is a OS-assigned port.")
 	option.BindEnv(option.ToFQDNsProxyPort)
 
+	flags.Float64(option.ToFQDNsProxyRateLimit, 0, "Maximum number of DNS requests per second the in-agent DNS proxy forwards for each endpoint. Default 0 disables rate limiting.")
+	option.BindEnv(option.ToFQDNsProxyRateLimit)
+
+	flags.Int(option.ToFQDNsProxyRateLimitBurst, defaults.ToFQDNsProxyRateLimitBurst, "Maximum number of DNS requests of an endpoint the in-agent DNS proxy forwards in a burst")
+	option.BindEnv(option.ToFQDNsProxyRateLimitBurst)
+
+	flags.StringVar(&option.Config.FQDNRateLimitResponse, option.FQDNRateLimitResponseCode, option.FQDNProxyRateLimitWithRefused, fmt.Sprintf("DNS response code for DNS requests exceeding the rate limit, available options are '%v'", option.FQDNRateLimitOptions))
+	option.BindEnv(option.FQDNRateLimitResponseCode)
+
+	flags.Int(option.ToFQDNsProxyCacheSize, 0, "Maximum number of DNS responses cached by the in-agent DNS proxy. Responses are cached for their lowest TTL. Default 0 disables caching.")
+	option.BindEnv(option.ToFQDNsProxyCacheSize)
+
 	flags.Bool(option.ToFQDNsEnablePoller, false, "Enable proactive polling of DNS names in toFQDNs.matchName rules.")
 	option.BindEnv(option.ToFQDNsEnablePoller)
 
@@ -1171,6 +1196,17 @@ func initEnv(cmd *cobra.Command) {
 		option.Config.EncryptInterface = link
 	}
 
+	if option.Config.EnableWireguard {
+		if option.Config.EnableIPSec {
+			log.Fatalf("WireGuard (--%s) cannot be used together with IPsec (--%s)",
+				option.EnableWireguardName, option.EnableIPSecName)
+		}
+		if option.Config.Tunnel != option.TunnelDisabled {
+			log.Fatalf("WireGuard (--%s) requires tunneling to be disabled (--%s=%s)",
+				option.EnableWireguardName, option.TunnelName, option.TunnelDisabled)
+		}
+	}
+
 	// BPF masquerade specified, rejecting unsupported options for this mode.
 	if !option.Config.InstallIptRules && option.Config.Masquerade {
 		if option.Config.DatapathMode != option.DatapathModeIpvlan {
@@ -1350,6 +1386,14 @@ func runDaemon() {
 		EncryptInterface: option.Config.EncryptInterface,
 	}
 
+	if option.Config.EnableWireguard {
+		agent, err := setupWireguard()
+		if err != nil {
+			log.WithError(err).Fatal("Unable to setup WireGuard")
+		}
+		datapathConfig.WireguardAgent = agent
+	}
+
 	log.Info("Initializing daemon")
 
 	// Since flannel doesn't create the cni0 interface until the first container
@@ -1416,6 +1460,28 @@ func runDaemon() {
 	// (Check Daemon.initK8sSubsystem() for more info)
 	<-k8sCachesSynced
 	bootstrapStats.k8sInit.End(true)
+
+	// Stale peers are only removed once the remote nodes are known
+	if agent := datapathConfig.WireguardAgent; agent != nil {
+		controller.NewManager().UpdateController("wireguard-peer-gc",
+			controller.ControllerParams{
+				DoFunc:      agent.Reconcile,
+				RunInterval: defaults.WireguardPeerGCInterval,
+			})
+	}
+
+	// Previous IPsec keys are only removed once the keys of all remote
+	// nodes are known
+	if option.Config.EnableIPSec {
+		go func() {
+			d.waitForNodesProcessed()
+			ipsec.SetNodeKeysSynced()
+		}()
+		if err := ipsec.StartKeyfileWatcher(option.Config.IPSecKeyFile, d.rotateIPSecKey); err != nil {
+			log.WithError(err).Warning("Unable to watch IPsec key file, keys are only rotated on restart")
+		}
+	}
+
 	restoreComplete := d.initRestore(restoredEndpoints)
 
 	if option.Config.IsFlannelMasterDeviceSet() {
@@ -1607,6 +1673,11 @@ func (d *Daemon) instantiateAPI() *restapi.CiliumAPI {
 	api.DaemonGetMapHandler = NewGetMapHandler(d)
 	api.DaemonGetMapNameHandler = NewGetMapNameHandler(d)
 
+	// /conntrack
+	api.DaemonDeleteConntrackHandler = NewDeleteConntrackHandler(d)
+	api.DaemonGetConntrackHandler = NewGetConntrackHandler(d)
+	api.DaemonGetConntrackStatisticsHandler = NewGetConntrackStatisticsHandler(d)
+
 	// metrics
 	api.MetricsGetMetricsHandler = NewGetMetricsHandler(d)
 
@@ -240,6 +240,9 @@ func (d *Daemon) bootstrapFQDN(restoredEndpoints *endpointRestoreState, preCache
 		err = d.l7Proxy.SetProxyPort(listenerName, proxy.DefaultDNSProxy.BindPort)
 
 		proxy.DefaultDNSProxy.SetRejectReply(option.Config.FQDNRejectResponse)
+		proxy.DefaultDNSProxy.SetRateLimit(option.Config.ToFQDNsProxyRateLimit, option.Config.ToFQDNsProxyRateLimitBurst)
+		proxy.DefaultDNSProxy.SetRateLimitReply(option.Config.FQDNRateLimitResponse)
+		proxy.DefaultDNSProxy.SetCacheSize(option.Config.ToFQDNsProxyCacheSize)
 	}
 	return err // filled by StartDNSProxy
 }
@@ -407,6 +410,22 @@ func (d *Daemon) notifyOnDNSMsg(lookupTime time.Time, ep *endpoint.Endpoint, epI
 		log.WithError(err).Error("cannot extract DNS message details")
 	}
 
+	dnsRecord := &accesslog.LogRecordDNS{
+		Query:             qname,
+		IPs:               responseIPs,
+		TTL:               TTL,
+		CNAMEs:            CNAMEs,
+		ObservationSource: accesslog.DNSSourceProxy,
+		RCode:             rcode,
+		QTypes:            qTypes,
+		AnswerTypes:       recordTypes,
+	}
+	if stat.EDNS != nil {
+		dnsRecord.EDNSUDPSize = stat.EDNS.UDPSize
+		dnsRecord.EDNSClientSubnet = stat.EDNS.ClientSubnet
+		dnsRecord.EDNSOptions = stat.EDNS.Options
+	}
+
 	ep.UpdateProxyStatistics(strings.ToUpper(protocol), uint16(serverPort), false, !msg.Response, verdict)
 	record := logger.NewLogRecord(proxy.DefaultEndpointInfoRegistry, ep, flowType, false,
 		func(lr *logger.LogRecord) { lr.LogRecord.TransportProtocol = accesslog.TransportProtocol(protoID) },
@@ -449,16 +468,7 @@ func (d *Daemon) notifyOnDNSMsg(lookupTime time.Time, ep *endpoint.Endpoint, epI
 				}
 			}
 		},
-		logger.LogTags.DNS(&accesslog.LogRecordDNS{
-			Query:             qname,
-			IPs:               responseIPs,
-			TTL:               TTL,
-			CNAMEs:            CNAMEs,
-			ObservationSource: accesslog.DNSSourceProxy,
-			RCode:             rcode,
-			QTypes:            qTypes,
-			AnswerTypes:       recordTypes,
-		}),
+		logger.LogTags.DNS(dnsRecord),
 	)
 	record.Log()
 
@@ -761,7 +761,7 @@ func (d *Daemon) EnableK8sWatcher(queueSize uint) error {
 	// CiliumNode objects are used for node discovery until the key-value
 	// store is connected
 	go func() {
-		var once sync.Once
+		var once, processedOnce sync.Once
 		for {
 			_, ciliumNodeInformer := informer.NewInformer(
 				cache.NewListWatchFromClient(ciliumNPClient.CiliumV2().RESTClient(),
@@ -841,6 +841,18 @@ func (d *Daemon) EnableK8sWatcher(queueSize uint) error {
 			d.k8sAPIGroups.addAPI(k8sAPIGroupCiliumNodeV2)
 			go ciliumNodeInformer.Run(isConnected)
 
+			go func() {
+				if cache.WaitForCacheSync(isConnected, ciliumNodeInformer.HasSynced) {
+					// The events of the initial list have been
+					// enqueued before, so they are processed once
+					// this function runs
+					serNodes.Enqueue(func() error {
+						processedOnce.Do(func() { close(d.ciliumNodesSynced) })
+						return nil
+					}, serializer.NoRetry)
+				}
+			}()
+
 			<-kvstore.Client().Connected()
 			close(isConnected)
 
@@ -1324,6 +1336,17 @@ func (d *Daemon) addK8sSVCs(svcID k8s.ServiceID, svc *k8s.Service, endpoints *k8
 		logfields.K8sNamespace: svcID.Namespace,
 	})
 
+	var global *loadbalancer.ClusterSelection
+	if svc.IncludeExternal {
+		global = &loadbalancer.ClusterSelection{
+			Affinity: svc.ClusterAffinity,
+			Clusters: endpoints.Clusters,
+		}
+		if global.Affinity == "" {
+			global.Affinity = loadbalancer.ClusterAffinityNone
+		}
+	}
+
 	uniqPorts := svc.UniquePorts()
 
 	for fePortName, fePort := range svc.Ports {
@@ -1386,7 +1409,7 @@ func (d *Daemon) addK8sSVCs(svcID k8s.ServiceID, svc *k8s.Service, endpoints *k8
 		}
 
 		for _, fe := range frontends {
-			if _, err := d.svcAdd(*fe, besValues, true); err != nil {
+			if _, err := d.svcAdd(*fe, besValues, svc.LBAlgorithm, svc.SessionAffinityTimeoutSec, global, true); err != nil {
 				scopedLog.WithError(err).Error("Error while inserting service in LB map")
 			}
 		}
@@ -16,11 +16,13 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/cilium/cilium/api/v1/models"
 	. "github.com/cilium/cilium/api/v1/server/restapi/service"
 	"github.com/cilium/cilium/pkg/api"
+	"github.com/cilium/cilium/pkg/defaults"
 	"github.com/cilium/cilium/pkg/loadbalancer"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 	"github.com/cilium/cilium/pkg/maps/lbmap"
@@ -36,13 +38,13 @@ import (
 func (d *Daemon) addSVC2BPFMap(feCilium loadbalancer.L3n4AddrID, feBPF lbmap.ServiceKey,
 	besBPF []lbmap.ServiceValue,
 	svcKeyV2 lbmap.ServiceKeyV2, svcValuesV2 []lbmap.ServiceValueV2, backendsV2 []lbmap.Backend,
-	addRevNAT bool) error {
+	algorithm loadbalancer.LBAlgorithm, sessionAffinityTimeoutSec uint32, addRevNAT bool) error {
 	log.WithField(logfields.ServiceName, feCilium.String()).Debug("adding service to BPF maps")
 
 	revNATID := int(feCilium.ID)
 
-	if err := lbmap.UpdateService(feBPF, besBPF, addRevNAT, revNATID,
-		service.AcquireBackendID, service.DeleteBackendID); err != nil {
+	if err := lbmap.UpdateService(feBPF, besBPF, addRevNAT, revNATID, algorithm,
+		sessionAffinityTimeoutSec, service.AcquireBackendID, service.DeleteBackendID); err != nil {
 		if addRevNAT {
 			delete(d.loadBalancer.RevNATMap, loadbalancer.ServiceID(feCilium.ID))
 		}
@@ -58,10 +60,12 @@ func (d *Daemon) addSVC2BPFMap(feCilium loadbalancer.L3n4AddrID, feBPF lbmap.Ser
 
 // SVCAdd is the public method to add services. We assume the ID provided is not in
 // sync with the KVStore. If that's the, case the service won't be used and an error is
-// returned to the caller.
+// returned to the caller. If algorithm is empty, the default load-balancing algorithm is
+// used. If sessionAffinityTimeoutSec is 0, session affinity is disabled.
 //
 // Returns true if service was created.
-func (d *Daemon) SVCAdd(feL3n4Addr loadbalancer.L3n4AddrID, be []loadbalancer.LBBackEnd, addRevNAT bool) (bool, error) {
+func (d *Daemon) SVCAdd(feL3n4Addr loadbalancer.L3n4AddrID, be []loadbalancer.LBBackEnd, algorithm loadbalancer.LBAlgorithm,
+	sessionAffinityTimeoutSec uint32, addRevNAT bool) (bool, error) {
 	log.WithField(logfields.ServiceID, feL3n4Addr.String()).Debug("adding service")
 	if feL3n4Addr.ID == 0 {
 		return false, fmt.Errorf("invalid service ID 0")
@@ -89,7 +93,19 @@ func (d *Daemon) SVCAdd(feL3n4Addr loadbalancer.L3n4AddrID, be []loadbalancer.LB
 		return false, fmt.Errorf("service ID %d is already registered to L3n4Addr %s, please choose a different ID", feL3n4Addr.ID, feAddr.String())
 	}
 
-	return d.svcAdd(feL3n4Addr, be, addRevNAT)
+	return d.svcAdd(feL3n4Addr, be, algorithm, sessionAffinityTimeoutSec, nil, addRevNAT)
+}
+
+// getLBAlgorithm returns the given load-balancing algorithm or the default
+// algorithm if it is empty.
+func getLBAlgorithm(algorithm loadbalancer.LBAlgorithm) loadbalancer.LBAlgorithm {
+	if algorithm != "" {
+		return algorithm
+	}
+	if defaultAlgorithm, err := loadbalancer.ParseLBAlgorithm(option.Config.LBAlgorithm); err == nil {
+		return defaultAlgorithm
+	}
+	return loadbalancer.LBAlgorithmRandom
 }
 
 // svcAdd adds a service from the given feL3n4Addr (frontend) and LBBackEnd (backends).
@@ -99,7 +115,12 @@ func (d *Daemon) SVCAdd(feL3n4Addr loadbalancer.L3n4AddrID, be []loadbalancer.LB
 // entry fails while updating the LB map, the frontend won't be inserted in the LB map
 // therefore there won't be any traffic going to the given backends.
 // All of the backends added will be DeepCopied to the internal load balancer map.
-func (d *Daemon) svcAdd(feL3n4Addr loadbalancer.L3n4AddrID, bes []loadbalancer.LBBackEnd, addRevNAT bool) (bool, error) {
+// If algorithm is empty, the default load-balancing algorithm is used. If
+// sessionAffinityTimeoutSec is 0, session affinity is disabled. global is the
+// selection of the clusters providing the backends of a global service, nil
+// for all other services.
+func (d *Daemon) svcAdd(feL3n4Addr loadbalancer.L3n4AddrID, bes []loadbalancer.LBBackEnd, algorithm loadbalancer.LBAlgorithm,
+	sessionAffinityTimeoutSec uint32, global *loadbalancer.ClusterSelection, addRevNAT bool) (bool, error) {
 	scopedLog := log.WithFields(logrus.Fields{
 		logfields.ServiceID: feL3n4Addr.String(),
 		logfields.Object:    logfields.Repr(bes),
@@ -114,9 +135,13 @@ func (d *Daemon) svcAdd(feL3n4Addr loadbalancer.L3n4AddrID, bes []loadbalancer.L
 	}
 
 	svc := loadbalancer.LBSVC{
-		FE:     feL3n4Addr,
-		BES:    beCpy,
-		Sha256: feL3n4Addr.L3n4Addr.SHA256Sum(),
+		FE:        feL3n4Addr,
+		BES:       beCpy,
+		Sha256:    feL3n4Addr.L3n4Addr.SHA256Sum(),
+		Algorithm: getLBAlgorithm(algorithm),
+
+		SessionAffinityTimeoutSec: sessionAffinityTimeoutSec,
+		Global:                    global,
 	}
 
 	fe, besValues, err := lbmap.LBSVC2ServiceKeynValue(svc)
@@ -132,7 +157,8 @@ func (d *Daemon) svcAdd(feL3n4Addr loadbalancer.L3n4AddrID, bes []loadbalancer.L
 	d.loadBalancer.BPFMapMU.Lock()
 	defer d.loadBalancer.BPFMapMU.Unlock()
 
-	err = d.addSVC2BPFMap(feL3n4Addr, fe, besValues, svcKeyV2, svcValuesV2, backendsV2, addRevNAT)
+	err = d.addSVC2BPFMap(feL3n4Addr, fe, besValues, svcKeyV2, svcValuesV2, backendsV2, svc.Algorithm,
+		svc.SessionAffinityTimeoutSec, addRevNAT)
 	if err != nil {
 		return false, err
 	}
@@ -184,15 +210,36 @@ func (h *putServiceID) Handle(params PutServiceIDParams) middleware.Responder {
 	}
 
 	revnat := false
+	var (
+		algorithm                 loadbalancer.LBAlgorithm
+		sessionAffinityTimeoutSec uint32
+	)
 	if params.Config.Flags != nil {
 		revnat = params.Config.Flags.DirectServerReturn
+		if params.Config.Flags.LbAlgorithm != "" {
+			algorithm, err = loadbalancer.ParseLBAlgorithm(params.Config.Flags.LbAlgorithm)
+			if err != nil {
+				return api.Error(PutServiceIDFailureCode, err)
+			}
+		}
+		if params.Config.Flags.SessionAffinity {
+			timeout := params.Config.Flags.SessionAffinityTimeout
+			if timeout == 0 {
+				timeout = defaults.SessionAffinityTimeoutSec
+			}
+			if timeout < 0 || timeout > math.MaxUint32 {
+				return api.Error(PutServiceIDFailureCode,
+					fmt.Errorf("invalid session affinity timeout %d", timeout))
+			}
+			sessionAffinityTimeoutSec = uint32(timeout)
+		}
 	}
 
 	// FIXME
 	// Add flag to indicate whether service should be registered in
 	// global key value store
 
-	if created, err := h.d.SVCAdd(frontend, backends, revnat); err != nil {
+	if created, err := h.d.SVCAdd(frontend, backends, algorithm, sessionAffinityTimeoutSec, revnat); err != nil {
 		return api.Error(PutServiceIDFailureCode, err)
 	} else if created {
 		return NewPutServiceIDCreated()
@@ -435,6 +482,12 @@ func openServiceMaps() error {
 		if _, err := lbmap.RRSeq6MapV2.OpenOrCreate(); err != nil {
 			return err
 		}
+		if _, err := lbmap.Maglev6Map.OpenOrCreate(); err != nil {
+			return err
+		}
+		if _, err := lbmap.Affinity6Map.OpenOrCreate(); err != nil {
+			return err
+		}
 	}
 
 	if option.Config.EnableIPv4 {
@@ -450,6 +503,12 @@ func openServiceMaps() error {
 		if _, err := lbmap.RRSeq4MapV2.OpenOrCreate(); err != nil {
 			return err
 		}
+		if _, err := lbmap.Maglev4Map.OpenOrCreate(); err != nil {
+			return err
+		}
+		if _, err := lbmap.Affinity4Map.OpenOrCreate(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -523,7 +582,8 @@ func (d *Daemon) SyncLBMap() error {
 				" This entry will be removed from the bpf's LB map.", svc.FE.String(), svc.BES, err)
 		}
 
-		err = d.addSVC2BPFMap(svc.FE, fe, besValues, svcKeyV2, svcValuesV2, backendsV2, false)
+		err = d.addSVC2BPFMap(svc.FE, fe, besValues, svcKeyV2, svcValuesV2, backendsV2, getLBAlgorithm(svc.Algorithm),
+			svc.SessionAffinityTimeoutSec, false)
 		if err != nil {
 			return fmt.Errorf("Unable to add service FE: %s: %s."+
 				" This entry will be removed from the bpf's LB map.", svc.FE.String(), err)
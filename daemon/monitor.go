@@ -0,0 +1,45 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"math"
+
+	"github.com/cilium/cilium/pkg/endpointmanager"
+	"github.com/cilium/cilium/pkg/identity"
+	"github.com/cilium/cilium/pkg/identity/cache"
+)
+
+// flowResolver resolves identities and endpoints for the flows exported by
+// the monitor agent
+type flowResolver struct{}
+
+// LookupIdentityByID returns the identity with the given numeric ID
+func (flowResolver) LookupIdentityByID(id identity.NumericIdentity) *identity.Identity {
+	return cache.LookupIdentityByID(id)
+}
+
+// GetEndpointPodInfo returns the Kubernetes namespace and pod name of the
+// local endpoint with the given ID
+func (flowResolver) GetEndpointPodInfo(id uint64) (namespace, podName string, ok bool) {
+	if id > math.MaxUint16 {
+		return "", "", false
+	}
+	ep := endpointmanager.LookupCiliumID(uint16(id))
+	if ep == nil {
+		return "", "", false
+	}
+	return ep.GetK8sNamespace(), ep.GetK8sPodName(), true
+}
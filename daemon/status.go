@@ -25,6 +25,7 @@ import (
 	"github.com/cilium/cilium/pkg/backoff"
 	"github.com/cilium/cilium/pkg/controller"
 	"github.com/cilium/cilium/pkg/datapath"
+	"github.com/cilium/cilium/pkg/datapath/linux/ipsec"
 	"github.com/cilium/cilium/pkg/k8s"
 	k8smetrics "github.com/cilium/cilium/pkg/k8s/metrics"
 	"github.com/cilium/cilium/pkg/kvstore"
@@ -288,6 +289,28 @@ func (h *getNodes) Handle(params GetClusterNodesParams) middleware.Responder {
 	return NewGetClusterNodesOK().WithPayload(cns)
 }
 
+// getEncryptionStatus returns the status of transparent encryption including
+// the progress of an IPsec key rotation
+func (d *Daemon) getEncryptionStatus() *models.EncryptionStatus {
+	switch {
+	case option.Config.EnableIPSec:
+		return &models.EncryptionStatus{
+			Mode:        models.EncryptionStatusModeIPsec,
+			Msg:         fmt.Sprintf("Key ID %d", node.GetIPsecKeyIdentity()),
+			KeyRotation: ipsec.GetKeyRotationStatusModel(),
+		}
+	case option.Config.EnableWireguard:
+		return &models.EncryptionStatus{
+			Mode: models.EncryptionStatusModeWireguard,
+			Msg:  fmt.Sprintf("Public key %s", node.GetWireguardPublicKey()),
+		}
+	default:
+		return &models.EncryptionStatus{
+			Mode: models.EncryptionStatusModeDisabled,
+		}
+	}
+}
+
 // getStatus returns the daemon status. If brief is provided a minimal version
 // of the StatusResponse is provided.
 func (d *Daemon) getStatus(brief bool) models.StatusResponse {
@@ -594,6 +617,23 @@ func (d *Daemon) startStatusCollector() {
 				}
 			},
 		},
+		{
+			Name: "encryption",
+			Probe: func(ctx context.Context) (interface{}, error) {
+				return d.getEncryptionStatus(), nil
+			},
+			OnStatusUpdate: func(status status.Status) {
+				d.statusCollectMutex.Lock()
+				defer d.statusCollectMutex.Unlock()
+
+				// EncryptionStatus has no way to report errors
+				if status.Err == nil {
+					if s, ok := status.Data.(*models.EncryptionStatus); ok {
+						d.statusResponse.Encryption = s
+					}
+				}
+			},
+		},
 		{
 			Name: "controllers",
 			Probe: func(ctx context.Context) (interface{}, error) {
@@ -0,0 +1,105 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	azureapi "github.com/cilium/cilium/pkg/azure/api"
+	azureipam "github.com/cilium/cilium/pkg/azure/ipam"
+	azuremetadata "github.com/cilium/cilium/pkg/azure/metadata"
+	"github.com/cilium/cilium/pkg/controller"
+	"github.com/cilium/cilium/pkg/ipam/nodepool"
+
+	"github.com/sirupsen/logrus"
+)
+
+// azureConfig is the configuration of the Azure allocator
+type azureConfig struct {
+	subscriptionID string
+	resourceGroup  string
+	tenantID       string
+	clientID       string
+	clientSecret   string
+	qpsLimit       float64
+	burst          int
+}
+
+// startAzureAllocator kicks off Azure IP allocation. The subscription and
+// resource group default to the ones of the virtual machine the operator is
+// running on. The initial synchronization with the Azure API is done in a
+// blocking manner, given that is successful, a controller is started to
+// manage allocation based on CiliumNode custom resources
+func startAzureAllocator(cfg azureConfig) error {
+	log.Info("Starting Azure IP allocator...")
+
+	if cfg.subscriptionID == "" || cfg.resourceGroup == "" {
+		log.Info("Retrieving own metadata from Azure instance metadata service...")
+		_, subscriptionID, resourceGroup, err := azuremetadata.GetInstanceMetadata()
+		if err != nil {
+			return fmt.Errorf("unable to retrieve instance metadata: %s", err)
+		}
+
+		if cfg.subscriptionID == "" {
+			cfg.subscriptionID = subscriptionID
+		}
+		if cfg.resourceGroup == "" {
+			cfg.resourceGroup = resourceGroup
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"subscriptionID": cfg.subscriptionID,
+		"resourceGroup":  cfg.resourceGroup,
+	}).Info("Connecting to Azure API")
+
+	azureClient, err := azureapi.NewClient(cfg.subscriptionID, cfg.resourceGroup,
+		cfg.tenantID, cfg.clientID, cfg.clientSecret, cfg.qpsLimit, cfg.burst)
+	if err != nil {
+		return fmt.Errorf("unable to create Azure client: %s", err)
+	}
+
+	instances := azureipam.NewInstancesManager(azureClient)
+	nodeManager, err = nodepool.NewNodeManager(instances, &k8sAPI{}, nil, azureParallelWorkers, releaseExcessIPs)
+	if err != nil {
+		return fmt.Errorf("unable to initialize Azure node manager: %s", err)
+	}
+
+	// Initial blocking synchronization of all interfaces and subnets
+	if instances.Resync(context.TODO()).IsZero() {
+		return fmt.Errorf("initial synchronization with Azure API failed")
+	}
+
+	// Start an interval based background resync for safety, it will
+	// synchronize the state regularly and resolve eventual deficit if the
+	// event driven trigger fails
+	go func() {
+		time.Sleep(time.Minute)
+		mngr := controller.NewManager()
+		mngr.UpdateController("azure-refresh",
+			controller.ControllerParams{
+				RunInterval: time.Minute,
+				DoFunc: func(ctx context.Context) error {
+					syncTime := instances.Resync(ctx)
+					nodeManager.Resync(ctx, syncTime)
+					return nil
+				},
+			})
+	}()
+
+	return nil
+}
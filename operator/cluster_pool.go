@@ -0,0 +1,92 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cilium/cilium/pkg/controller"
+	"github.com/cilium/cilium/pkg/ipam/clusterpool"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var clusterPoolManager *clusterpool.NodeManager
+
+// startClusterPoolAllocator starts the allocation of PodCIDRs out of the
+// cluster-pool CIDRs. The allocation state is restored from the existing
+// CiliumNode resources before a controller is started to retry failed
+// allocations.
+func startClusterPoolAllocator(v4CIDRs []string, v4MaskSize int, v6CIDRs []string, v6MaskSize int) error {
+	log.Info("Starting cluster-pool PodCIDR allocator...")
+
+	var v4Pool, v6Pool *clusterpool.CIDRPool
+	var err error
+
+	if len(v4CIDRs) == 0 && len(v6CIDRs) == 0 {
+		return fmt.Errorf("no cluster-pool CIDRs specified")
+	}
+	if len(v4CIDRs) > 0 {
+		v4Pool, err = clusterpool.NewCIDRPool(v4CIDRs, v4MaskSize)
+		if err != nil {
+			return fmt.Errorf("unable to initialize IPv4 cluster-pool: %s", err)
+		}
+		if v4Pool.IsIPv6() {
+			return fmt.Errorf("IPv4 cluster-pool contains IPv6 CIDRs")
+		}
+	}
+	if len(v6CIDRs) > 0 {
+		v6Pool, err = clusterpool.NewCIDRPool(v6CIDRs, v6MaskSize)
+		if err != nil {
+			return fmt.Errorf("unable to initialize IPv6 cluster-pool: %s", err)
+		}
+		if !v6Pool.IsIPv6() {
+			return fmt.Errorf("IPv6 cluster-pool contains IPv4 CIDRs")
+		}
+	}
+
+	manager := clusterpool.NewNodeManager(&k8sAPI{}, v4Pool, v6Pool)
+
+	// Restore the allocation of all existing nodes before any new PodCIDR
+	// is allocated
+	nodes, err := ciliumK8sClient.CiliumV2().CiliumNodes().List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list CiliumNodes: %s", err)
+	}
+	manager.Restore(nodes.Items)
+
+	log.WithFields(logrus.Fields{
+		"ipv4CIDRs": v4CIDRs,
+		"ipv6CIDRs": v6CIDRs,
+		"nodes":     len(nodes.Items),
+	}).Info("Restored cluster-pool PodCIDR allocation")
+
+	clusterPoolManager = manager
+
+	mngr := controller.NewManager()
+	mngr.UpdateController("cluster-pool-resync",
+		controller.ControllerParams{
+			RunInterval: time.Minute,
+			DoFunc: func(_ context.Context) error {
+				manager.Resync()
+				return nil
+			},
+		})
+
+	return nil
+}
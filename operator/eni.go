@@ -24,6 +24,7 @@ import (
 	"github.com/cilium/cilium/pkg/aws/eni"
 	"github.com/cilium/cilium/pkg/aws/eni/metrics"
 	"github.com/cilium/cilium/pkg/controller"
+	"github.com/cilium/cilium/pkg/ipam/nodepool"
 	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
 	k8sversion "github.com/cilium/cilium/pkg/k8s/version"
 	"github.com/cilium/cilium/pkg/trigger"
@@ -35,7 +36,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var nodeManager *eni.NodeManager
+var nodeManager *nodepool.NodeManager
 
 type k8sAPI struct{}
 
@@ -81,12 +82,18 @@ func ciliumNodeUpdated(resource *v2.CiliumNode) {
 	if nodeManager != nil {
 		nodeManager.Update(resource)
 	}
+	if clusterPoolManager != nil {
+		clusterPoolManager.Update(resource)
+	}
 }
 
 func ciliumNodeDeleted(nodeName string) {
 	if nodeManager != nil {
 		nodeManager.Delete(nodeName)
 	}
+	if clusterPoolManager != nil {
+		clusterPoolManager.Delete(nodeName)
+	}
 }
 
 // startENIAllocator kicks of ENI allocation, the initial connection to AWS
@@ -117,6 +124,7 @@ func startENIAllocator(awsClientQPSLimit float64, awsClientBurst int) error {
 	var (
 		ec2Client *ec2shim.Client
 		instances *eni.InstancesManager
+		provider  *eni.Provider
 	)
 
 	if enableMetrics {
@@ -124,7 +132,8 @@ func startENIAllocator(awsClientQPSLimit float64, awsClientBurst int) error {
 		ec2Client = ec2shim.NewClient(ec2.New(cfg), eniMetrics, awsClientQPSLimit, awsClientBurst)
 		log.Info("Connected to EC2 service API")
 		instances = eni.NewInstancesManager(ec2Client, eniMetrics)
-		nodeManager, err = eni.NewNodeManager(instances, ec2Client, &k8sAPI{}, eniMetrics, eniParallelWorkers)
+		provider = eni.NewProvider(instances, ec2Client, eniMetrics)
+		nodeManager, err = nodepool.NewNodeManager(provider, &k8sAPI{}, eniMetrics, eniParallelWorkers, releaseExcessIPs)
 		if err != nil {
 			return fmt.Errorf("unable to initialize ENI node manager: %s", err)
 		}
@@ -135,14 +144,15 @@ func startENIAllocator(awsClientQPSLimit float64, awsClientBurst int) error {
 		ec2Client = ec2shim.NewClient(ec2.New(cfg), noOpMetric, awsClientQPSLimit, awsClientBurst)
 		log.Info("Connected to EC2 service API")
 		instances = eni.NewInstancesManager(ec2Client, noOpMetric)
-		nodeManager, err = eni.NewNodeManager(instances, ec2Client, &k8sAPI{}, noOpMetric, eniParallelWorkers)
+		provider = eni.NewProvider(instances, ec2Client, noOpMetric)
+		nodeManager, err = nodepool.NewNodeManager(provider, &k8sAPI{}, noOpMetric, eniParallelWorkers, releaseExcessIPs)
 		if err != nil {
 			return fmt.Errorf("unable to initialize ENI node manager: %s", err)
 		}
 	}
 
 	// Initial blocking synchronization of all ENIs and subnets
-	instances.Resync()
+	provider.Resync(context.TODO())
 
 	// Start an interval based  background resync for safety, it will
 	// synchronize the state regularly and resolve eventual deficit if the
@@ -153,9 +163,9 @@ func startENIAllocator(awsClientQPSLimit float64, awsClientBurst int) error {
 		mngr.UpdateController("eni-refresh",
 			controller.ControllerParams{
 				RunInterval: time.Minute,
-				DoFunc: func(_ context.Context) error {
-					syncTime := instances.Resync()
-					nodeManager.Resync(syncTime)
+				DoFunc: func(ctx context.Context) error {
+					syncTime := provider.Resync(ctx)
+					nodeManager.Resync(ctx, syncTime)
 					return nil
 				},
 			})
@@ -175,7 +185,7 @@ func (m *noOpMetricsObserver) QueueEvent(reason string)
 
 type noOpMetrics struct{}
 
-// eni metricsAPI interface implementation
+// eni metricsAPI and nodepool.MetricsAPI interface implementation
 func (m *noOpMetrics) IncENIAllocationAttempt(status, subnetID string)                           {}
 func (m *noOpMetrics) AddIPAllocation(subnetID string, allocated int64)                          {}
 func (m *noOpMetrics) SetAllocatedIPs(typ string, allocated int)                                 {}
@@ -183,7 +193,7 @@ func (m *noOpMetrics) SetAvailableENIs(available int)
 func (m *noOpMetrics) SetAvailableIPsPerSubnet(subnetID, availabilityZone string, available int) {}
 func (m *noOpMetrics) SetNodes(category string, nodes int)                                       {}
 func (m *noOpMetrics) IncResyncCount()                                                           {}
-func (m *noOpMetrics) DeficitResolverTrigger() trigger.MetricsObserver {
+func (m *noOpMetrics) PoolMaintainerTrigger() trigger.MetricsObserver {
 	return &noOpMetricsObserver{}
 }
 func (m *noOpMetrics) K8sSyncTrigger() trigger.MetricsObserver {
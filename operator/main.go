@@ -31,6 +31,7 @@ import (
 	"github.com/cilium/cilium/pkg/logging"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/policy/groups/inventory"
 	"github.com/cilium/cilium/pkg/version"
 	"github.com/spf13/cobra/doc"
 
@@ -66,6 +67,11 @@ var (
 	metricsAddress      string
 	eniParallelWorkers  int64
 	enableENI           bool
+	enableClusterPool   bool
+	releaseExcessIPs    bool
+
+	azureParallelWorkers int64
+	enableAzure          bool
 
 	k8sIdentityGCInterval       time.Duration
 	k8sIdentityHeartbeatTimeout time.Duration
@@ -136,6 +142,34 @@ func init() {
 	flags.Int(option.AWSClientBurst, 4, "Burst value allowed for the AWS client used by the AWS ENI IPAM")
 	flags.Float64(option.AWSClientQPSLimit, 20.0, "Queries per second limit for the AWS client used by the AWS ENI IPAM")
 
+	flags.String(option.AzureSubscriptionID, "", "Subscription ID of the Azure resources managed by the Azure IPAM (defaults to the subscription of the operator's virtual machine)")
+	option.BindEnv(option.AzureSubscriptionID)
+	flags.String(option.AzureResourceGroup, "", "Resource group of the network interfaces and virtual networks managed by the Azure IPAM (defaults to the resource group of the operator's virtual machine)")
+	option.BindEnv(option.AzureResourceGroup)
+	flags.String(option.AzureTenantID, "", "Tenant ID of the service principal used by the Azure IPAM")
+	option.BindEnv(option.AzureTenantID)
+	flags.String(option.AzureClientID, "", "Client ID of the service principal or user assigned managed identity used by the Azure IPAM")
+	option.BindEnv(option.AzureClientID)
+	flags.String(option.AzureClientSecret, "", "Client secret of the service principal used by the Azure IPAM, the managed identity of the virtual machine is used if empty")
+	option.BindEnv(option.AzureClientSecret)
+	flags.Int(option.AzureClientBurst, 4, "Burst value allowed for the Azure client used by the Azure IPAM")
+	flags.Float64(option.AzureClientQPSLimit, 10.0, "Queries per second limit for the Azure client used by the Azure IPAM")
+	flags.Int64Var(&azureParallelWorkers, "azure-parallel-workers", 50, "Maximum number of parallel workers used by Azure allocator")
+
+	flags.BoolVar(&releaseExcessIPs, "release-excess-ips", false, "Release IPs above the pre-allocate and max-above-watermark watermarks of nodes in ENI and Azure IPAM mode")
+
+	flags.StringSlice(option.ClusterPoolIPv4CIDR, []string{}, "IPv4 CIDRs to allocate node PodCIDRs from in cluster-pool IPAM mode")
+	option.BindEnv(option.ClusterPoolIPv4CIDR)
+	flags.StringSlice(option.ClusterPoolIPv6CIDR, []string{}, "IPv6 CIDRs to allocate node PodCIDRs from in cluster-pool IPAM mode")
+	option.BindEnv(option.ClusterPoolIPv6CIDR)
+	flags.Int(option.ClusterPoolIPv4MaskSize, defaults.ClusterPoolIPv4MaskSize, "Mask size of the IPv4 PodCIDRs allocated in cluster-pool IPAM mode")
+	option.BindEnv(option.ClusterPoolIPv4MaskSize)
+	flags.Int(option.ClusterPoolIPv6MaskSize, defaults.ClusterPoolIPv6MaskSize, "Mask size of the IPv6 PodCIDRs allocated in cluster-pool IPAM mode, must be at least 96")
+	option.BindEnv(option.ClusterPoolIPv6MaskSize)
+
+	flags.String(option.ToGroupsInventorySource, "", "HTTP(S) URL or file path of a JSON inventory mapping group names to IPs, used by toGroups inventory rules")
+	option.BindEnv(option.ToGroupsInventorySource)
+
 	flags.Float32(option.K8sClientQPSLimit, defaults.K8sClientQPSLimit, "Queries per second limit for the K8s client")
 	flags.Int(option.K8sClientBurst, defaults.K8sClientBurst, "Burst value allowed for the K8s client")
 
@@ -246,7 +280,34 @@ func runOperator(cmd *cobra.Command) {
 		}
 	}
 
-	if enableENI {
+	enableAzure = viper.GetString(option.IPAM) == option.IPAMAzure
+	if enableAzure {
+		if err := startAzureAllocator(azureConfig{
+			subscriptionID: viper.GetString(option.AzureSubscriptionID),
+			resourceGroup:  viper.GetString(option.AzureResourceGroup),
+			tenantID:       viper.GetString(option.AzureTenantID),
+			clientID:       viper.GetString(option.AzureClientID),
+			clientSecret:   viper.GetString(option.AzureClientSecret),
+			qpsLimit:       viper.GetFloat64(option.AzureClientQPSLimit),
+			burst:          viper.GetInt(option.AzureClientBurst),
+		}); err != nil {
+			log.WithError(err).Fatal("Unable to start Azure allocator")
+		}
+	}
+
+	enableClusterPool = viper.GetString(option.IPAM) == option.IPAMClusterPool
+	if enableClusterPool {
+		if err := startClusterPoolAllocator(
+			viper.GetStringSlice(option.ClusterPoolIPv4CIDR),
+			viper.GetInt(option.ClusterPoolIPv4MaskSize),
+			viper.GetStringSlice(option.ClusterPoolIPv6CIDR),
+			viper.GetInt(option.ClusterPoolIPv6MaskSize),
+		); err != nil {
+			log.WithError(err).Fatal("Unable to start cluster-pool allocator")
+		}
+	}
+
+	if enableENI || enableAzure || enableClusterPool {
 		startSynchronizingCiliumNodes()
 	}
 
@@ -327,6 +388,7 @@ func runOperator(cmd *cobra.Command) {
 	if identityGCInterval != time.Duration(0) {
 		startIdentityGC()
 	}
+	inventory.SetSource(viper.GetString(option.ToGroupsInventorySource))
 	err := enableCNPWatcher()
 	if err != nil {
 		log.WithError(err).WithField("subsys", "CNPWatcher").Fatal(
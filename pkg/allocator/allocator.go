@@ -768,16 +768,17 @@ type RemoteCache struct {
 	allocator *Allocator
 }
 
-// WatchRemoteKVStore starts watching an allocator base prefix the kvstore
-// represents by the provided backend. A local cache of all identities of that
-// kvstore will be maintained in the RemoteCache structure returned and will
-// start being reported in the identities returned by the ForeachCache()
-// function.
-func (a *Allocator) WatchRemoteKVStore(backend kvstore.BackendOperations, prefix string) *RemoteCache {
+// WatchRemoteKVStore starts watching the allocations of the provided remote
+// backend, typically backed by the kvstore of another cluster. A local cache
+// of all identities of that backend will be maintained in the RemoteCache
+// structure returned and will start being reported in the identities
+// returned by the ForeachCache() function.
+func (a *Allocator) WatchRemoteKVStore(remote Backend) *RemoteCache {
 	rc := &RemoteCache{
 		cache:     newCache(a),
 		allocator: a,
 	}
+	rc.cache.backend = remote
 
 	a.remoteCachesMutex.Lock()
 	a.remoteCaches[rc] = struct{}{}
@@ -67,11 +67,16 @@ type cache struct {
 	// watcher is started with the conditions marked as done when the
 	// watcher has exited
 	stopWatchWg sync.WaitGroup
+
+	// backend is the backend listed and watched by the cache. This is
+	// the backend of the allocator unless the cache is a remote cache.
+	backend Backend
 }
 
 func newCache(a *Allocator) cache {
 	return cache{
 		allocator: a,
+		backend:   a.backend,
 		cache:     idMap{},
 		keyCache:  keyMap{},
 		stopChan:  make(chan struct{}),
@@ -189,7 +194,7 @@ func (c *cache) start(a *Allocator) waitChan {
 	c.stopWatchWg.Add(1)
 
 	go func() {
-		c.allocator.backend.ListAndWatch(c, c.stopChan)
+		c.backend.ListAndWatch(c, c.stopChan)
 		c.stopWatchWg.Done()
 	}()
 
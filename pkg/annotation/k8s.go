@@ -55,4 +55,15 @@ const (
 	// GlobalService to true allows to expose remote endpoints without
 	// sharing local endpoints.
 	SharedService = Prefix + "shared-service"
+
+	// GlobalServiceAffinity selects the clusters of which the backends are
+	// used by a global service, either "none", "local-preferred",
+	// "remote-only" or "failover". If not set, the backends of all
+	// clusters are used.
+	GlobalServiceAffinity = Prefix + "/global-service-affinity"
+
+	// LBAlgorithm selects the algorithm used to select the backend of new
+	// connections to a service, either "random" or "maglev". If not set,
+	// the default algorithm of the agent is used.
+	LBAlgorithm = Prefix + "/lb-algorithm"
 )
@@ -397,3 +397,18 @@ func (c *Client) AssignPrivateIpAddresses(eniID string, addresses int64) error {
 	c.metricsAPI.ObserveEC2APICall("AssignPrivateIpAddresses", deriveStatus(req.Request, err), sinceStart.Seconds())
 	return err
 }
+
+// UnassignPrivateIpAddresses unassigns the specified secondary IP addresses
+func (c *Client) UnassignPrivateIpAddresses(eniID string, addresses []string) error {
+	request := ec2.UnassignPrivateIpAddressesInput{
+		NetworkInterfaceId: &eniID,
+		PrivateIpAddresses: addresses,
+	}
+
+	c.rateLimit("UnassignPrivateIpAddresses")
+	sinceStart := spanstat.Start()
+	req := c.ec2Client.UnassignPrivateIpAddressesRequest(&request)
+	_, err := req.Send()
+	c.metricsAPI.ObserveEC2APICall("UnassignPrivateIpAddresses", deriveStatus(req.Request, err), sinceStart.Seconds())
+	return err
+}
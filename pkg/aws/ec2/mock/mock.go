@@ -40,6 +40,7 @@ const (
 	AttachNetworkInterface
 	ModifyNetworkInterface
 	AssignPrivateIpAddresses
+	UnassignPrivateIpAddresses
 	MaxOperation
 )
 
@@ -281,6 +282,45 @@ func (e *API) AssignPrivateIpAddresses(eniID string, addresses int64) error {
 	return fmt.Errorf("Unable to find ENI with ID %s", eniID)
 }
 
+func (e *API) UnassignPrivateIpAddresses(eniID string, addresses []string) error {
+	e.rateLimit()
+	e.simulateDelay(UnassignPrivateIpAddresses)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err, ok := e.errors[UnassignPrivateIpAddresses]; ok {
+		return err
+	}
+
+	for _, enis := range e.enis {
+		if eni, ok := enis[eniID]; ok {
+			subnet, ok := e.subnets[eni.Subnet.ID]
+			if !ok {
+				return fmt.Errorf("subnet %s not found", eni.Subnet.ID)
+			}
+
+			released := map[string]struct{}{}
+			for _, ip := range addresses {
+				released[ip] = struct{}{}
+			}
+
+			remaining := []string{}
+			for _, ip := range eni.Addresses {
+				if _, ok := released[ip]; ok {
+					e.allocator.Release(net.ParseIP(ip))
+					subnet.AvailableAddresses++
+					continue
+				}
+				remaining = append(remaining, ip)
+			}
+			eni.Addresses = remaining
+			return nil
+		}
+	}
+	return fmt.Errorf("Unable to find ENI with ID %s", eniID)
+}
+
 func (e *API) GetInstances(vpcs types.VpcMap, subnets types.SubnetMap) (types.InstanceMap, error) {
 	instances := types.InstanceMap{}
 
@@ -130,7 +130,7 @@ func NewPrometheusMetrics(namespace string, registry *prometheus.Registry) *prom
 	return m
 }
 
-func (p *prometheusMetrics) DeficitResolverTrigger() trigger.MetricsObserver {
+func (p *prometheusMetrics) PoolMaintainerTrigger() trigger.MetricsObserver {
 	return p.deficitResolver
 }
 
@@ -150,7 +150,7 @@ func (m *mockMetrics) IncResyncCount() {
 	m.mutex.Unlock()
 }
 
-func (m *mockMetrics) DeficitResolverTrigger() trigger.MetricsObserver {
+func (m *mockMetrics) PoolMaintainerTrigger() trigger.MetricsObserver {
 	return nil
 }
 
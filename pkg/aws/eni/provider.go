@@ -0,0 +1,599 @@
+// Copyright 2019 Authors of Cilium
+// Copyright 2017 Lyft, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eni
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cilium/cilium/pkg/aws/types"
+	"github.com/cilium/cilium/pkg/defaults"
+	"github.com/cilium/cilium/pkg/ipam/nodepool"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/math"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// warningInterval is the interval for warnings which should be done
+	// once and then repeated if the warning persists.
+	warningInterval = time.Hour
+
+	// maxAttachRetries is the maximum number of attachment retries
+	maxAttachRetries = 5
+)
+
+type nodeManagerAPI interface {
+	GetENI(instanceID string, index int) *v2.ENI
+	GetENIs(instanceID string) []*v2.ENI
+	GetSubnet(subnetID string) *types.Subnet
+	GetSubnets() types.SubnetMap
+	FindSubnetByTags(vpcID, availabilityZone string, required types.Tags) *types.Subnet
+	Resync() time.Time
+	UpdateENI(instanceID string, eni *v2.ENI)
+}
+
+type ec2API interface {
+	CreateNetworkInterface(toAllocate int64, subnetID, desc string, groups []string) (string, *v2.ENI, error)
+	DeleteNetworkInterface(eniID string) error
+	AttachNetworkInterface(index int64, instanceID, eniID string) (string, error)
+	ModifyNetworkInterface(eniID, attachmentID string, deleteOnTermination bool) error
+	AssignPrivateIpAddresses(eniID string, addresses int64) error
+	UnassignPrivateIpAddresses(eniID string, addresses []string) error
+}
+
+type metricsAPI interface {
+	IncENIAllocationAttempt(status, subnetID string)
+	AddIPAllocation(subnetID string, allocated int64)
+	SetAvailableENIs(available int)
+	SetAvailableIPsPerSubnet(subnetID string, availabilityZone string, available int)
+	IncResyncCount()
+}
+
+// nodeState is the ENI specific state of a node
+type nodeState struct {
+	// instanceID is the ID of the EC2 instance backing the node
+	instanceID string
+
+	// instanceType is the type of the EC2 instance backing the node
+	instanceType string
+
+	// firstInterfaceIndex is the index of the first ENI to use for IP
+	// allocation
+	firstInterfaceIndex int
+
+	// lastMaxAdapterWarning is the timestamp when the last warning was
+	// printed that this node is out of adapters
+	lastMaxAdapterWarning time.Time
+
+	// instanceNotRunning is true when the EC2 instance backing the node is
+	// not running. This state is detected based on error messages returned
+	// when modifying instance state
+	instanceNotRunning bool
+
+	// notRunningVersion is the resource version of the CiliumNode when the
+	// instance was marked as not running. Any modification to the custom
+	// resource is seen as a sign that the instance is alive.
+	notRunningVersion string
+}
+
+// Provider allocates IPs to nodes by assigning secondary IPs to the ENIs of
+// the EC2 instance backing the node and by creating and attaching additional
+// ENIs. It implements nodepool.Provider.
+type Provider struct {
+	mutex        lock.Mutex
+	instancesAPI nodeManagerAPI
+	ec2API       ec2API
+	metricsAPI   metricsAPI
+
+	// nodes is the ENI specific state of all nodes indexed by node name
+	nodes map[string]*nodeState
+}
+
+// NewProvider returns a new ENI provider
+func NewProvider(instancesAPI nodeManagerAPI, ec2API ec2API, metrics metricsAPI) *Provider {
+	return &Provider{
+		instancesAPI: instancesAPI,
+		ec2API:       ec2API,
+		metricsAPI:   metrics,
+		nodes:        map[string]*nodeState{},
+	}
+}
+
+func logger(resource *v2.CiliumNode) *logrus.Entry {
+	return log.WithFields(logrus.Fields{
+		fieldName:    resource.Name,
+		"instanceID": resource.Spec.ENI.InstanceID,
+	})
+}
+
+// getNodeLocked returns the state of the node and updates it with the
+// instance information found in resource
+func (p *Provider) getNodeLocked(resource *v2.CiliumNode) *nodeState {
+	n, ok := p.nodes[resource.Name]
+	if !ok {
+		n = &nodeState{}
+		p.nodes[resource.Name] = n
+	}
+	n.instanceID = resource.Spec.ENI.InstanceID
+	n.instanceType = resource.Spec.ENI.InstanceType
+	n.firstInterfaceIndex = resource.Spec.ENI.FirstInterfaceIndex
+	return n
+}
+
+// availableInterfaces returns the number of ENIs that can either be created
+// or have not yet exhausted the ENI specific quota of addresses
+func availableInterfaces(limits Limits, enis []*v2.ENI, firstInterfaceIndex int) int {
+	available := limits.Adapters - len(enis)
+	for _, e := range enis {
+		if e.Number >= firstInterfaceIndex && len(e.Addresses) < limits.IPv4 {
+			available++
+		}
+	}
+	return available
+}
+
+// Resync synchronizes the list of EC2 instances and subnets and updates the
+// subnet and ENI metrics. Nodes whose instance is no longer known are
+// forgotten.
+func (p *Provider) Resync(ctx context.Context) time.Time {
+	syncTime := p.instancesAPI.Resync()
+
+	for subnetID, subnet := range p.instancesAPI.GetSubnets() {
+		p.metricsAPI.SetAvailableIPsPerSubnet(subnetID, subnet.AvailabilityZone, subnet.AvailableAddresses)
+	}
+
+	remainingInterfaces := 0
+	p.mutex.Lock()
+	for name, n := range p.nodes {
+		enis := p.instancesAPI.GetENIs(n.instanceID)
+		if len(enis) == 0 {
+			delete(p.nodes, name)
+			continue
+		}
+		if limits, ok := GetLimits(n.instanceType); ok {
+			remainingInterfaces += availableInterfaces(limits, enis, n.firstInterfaceIndex)
+		}
+	}
+	p.mutex.Unlock()
+	p.metricsAPI.SetAvailableENIs(remainingInterfaces)
+
+	return syncTime
+}
+
+// GetPool returns the addresses of all ENIs of the instance starting at
+// FirstInterfaceIndex
+func (p *Provider) GetPool(resource *v2.CiliumNode) map[string]v2.AllocationIP {
+	p.mutex.Lock()
+	p.getNodeLocked(resource)
+	p.mutex.Unlock()
+
+	pool := map[string]v2.AllocationIP{}
+	for _, e := range p.instancesAPI.GetENIs(resource.Spec.ENI.InstanceID) {
+		if e.Number < resource.Spec.ENI.FirstInterfaceIndex {
+			continue
+		}
+
+		for _, ip := range e.Addresses {
+			pool[ip] = v2.AllocationIP{Resource: e.ID}
+		}
+	}
+	return pool
+}
+
+// GetWatermarks returns the watermarks specified in the ENI spec of the node.
+// PreAllocate defaults to defaults.ENIPreAllocation.
+func (p *Provider) GetWatermarks(resource *v2.CiliumNode) nodepool.Watermarks {
+	w := nodepool.Watermarks{
+		PreAllocate:       resource.Spec.ENI.PreAllocate,
+		MinAllocate:       resource.Spec.ENI.MinAllocate,
+		MaxAboveWatermark: resource.Spec.ENI.MaxAboveWatermark,
+	}
+	if w.PreAllocate == 0 {
+		w.PreAllocate = defaults.ENIPreAllocation
+	}
+	return w
+}
+
+// PopulateStatusFields populates Status.ENI with all ENIs attached to the
+// instance
+func (p *Provider) PopulateStatusFields(resource *v2.CiliumNode) {
+	resource.Status.ENI.ENIs = map[string]v2.ENI{}
+	for _, e := range p.instancesAPI.GetENIs(resource.Spec.ENI.InstanceID) {
+		resource.Status.ENI.ENIs[e.ID] = *e
+	}
+}
+
+// ReleaseIPs unassigns the given secondary IPs from the ENIs of the node.
+// ENIs are never detached or deleted.
+func (p *Provider) ReleaseIPs(ctx context.Context, resource *v2.CiliumNode, ips []string) error {
+	instanceID := resource.Spec.ENI.InstanceID
+	enis := map[string]*v2.ENI{}
+	byENI := map[string][]string{}
+
+	for _, e := range p.instancesAPI.GetENIs(instanceID) {
+		enis[e.ID] = e
+	}
+
+	pool := p.GetPool(resource)
+	for _, ip := range ips {
+		allocationIP, ok := pool[ip]
+		if !ok {
+			return fmt.Errorf("IP %s is not an address of instance %s", ip, instanceID)
+		}
+		if e, ok := enis[allocationIP.Resource]; ok && e.IP == ip {
+			return fmt.Errorf("IP %s is the primary address of ENI %s", ip, e.ID)
+		}
+		byENI[allocationIP.Resource] = append(byENI[allocationIP.Resource], ip)
+	}
+
+	eniIDs := make([]string, 0, len(byENI))
+	for eniID := range byENI {
+		eniIDs = append(eniIDs, eniID)
+	}
+	sort.Strings(eniIDs)
+
+	for _, eniID := range eniIDs {
+		toRelease := byENI[eniID]
+		if err := p.ec2API.UnassignPrivateIpAddresses(eniID, toRelease); err != nil {
+			return fmt.Errorf("unable to release %d IPs from ENI %s: %s", len(toRelease), eniID, err)
+		}
+
+		released := map[string]struct{}{}
+		for _, ip := range toRelease {
+			released[ip] = struct{}{}
+		}
+
+		e := enis[eniID]
+		remaining := make([]string, 0, len(e.Addresses))
+		for _, ip := range e.Addresses {
+			if _, ok := released[ip]; !ok {
+				remaining = append(remaining, ip)
+			}
+		}
+		e.Addresses = remaining
+
+		p.instancesAPI.UpdateENI(instanceID, e)
+	}
+
+	return nil
+}
+
+// isRunning returns false if the instance of the node has been marked as not
+// running and the custom resource has not been modified since
+func (p *Provider) isRunning(resource *v2.CiliumNode) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	n := p.getNodeLocked(resource)
+	if n.instanceNotRunning {
+		if n.notRunningVersion == resource.ResourceVersion {
+			return false
+		}
+		logger(resource).Info("Marking node as running")
+		n.instanceNotRunning = false
+	}
+	return true
+}
+
+func (p *Provider) errorInstanceNotRunning(resource *v2.CiliumNode, err error) (notRunning bool) {
+	// This is handling the special case when an instance has been
+	// terminated but the grace period has delayed the Kubernetes node
+	// deletion event to not have been sent out yet. The next ENI resync
+	// will cause the instance to be marked as inactive.
+	notRunning = strings.Contains(err.Error(), "is not 'running'")
+	if notRunning {
+		p.mutex.Lock()
+		n := p.getNodeLocked(resource)
+		n.instanceNotRunning = true
+		n.notRunningVersion = resource.ResourceVersion
+		p.mutex.Unlock()
+		logger(resource).Info("Marking node as not running")
+	}
+	return
+}
+
+func (p *Provider) getSecurityGroups(resource *v2.CiliumNode) (securityGroups []string) {
+	// When no security groups are provided, derive them from eth0
+	securityGroups = resource.Spec.ENI.SecurityGroups
+	if len(securityGroups) == 0 {
+		if eni := p.instancesAPI.GetENI(resource.Spec.ENI.InstanceID, 0); eni != nil {
+			securityGroups = eni.SecurityGroups
+		}
+	}
+	return
+}
+
+func isAttachmentIndexConflict(err error) bool {
+	e, ok := err.(awserr.Error)
+	return ok && e.Code() == "InvalidParameterValue" && strings.Contains(e.Message(), "interface attached at device")
+}
+
+// indexExists returns true if the specified index is occupied by an ENI in the
+// slice of ENIs
+func indexExists(enis []*v2.ENI, index int64) bool {
+	for _, e := range enis {
+		if e.Number == int(index) {
+			return true
+		}
+	}
+	return false
+}
+
+// findNextIndex returns the next available index with the provided index being
+// the first candidate
+func findNextIndex(enis []*v2.ENI, index int64) int64 {
+	for indexExists(enis, index) {
+		index++
+	}
+	return index
+}
+
+// allocateENI creates an additional ENI and attaches it to the instance as
+// specified by the ciliumNode. toAllocate secondary IPs are assigned to the
+// interface up to the maximum number of addresses as allowed by the ENI. It
+// returns the number of IPs allocated.
+func (p *Provider) allocateENI(resource *v2.CiliumNode, s *types.Subnet, a *allocatableResources, toAllocate int) (int, error) {
+	securityGroups := p.getSecurityGroups(resource)
+	instanceID := resource.Spec.ENI.InstanceID
+	desc := "Cilium-CNI (" + instanceID + ")"
+	numAddresses := int64(math.IntMin(toAllocate, a.limits.IPv4))
+	index := findNextIndex(a.enis, int64(resource.Spec.ENI.FirstInterfaceIndex))
+
+	scopedLog := logger(resource).WithFields(logrus.Fields{
+		"securityGroups": securityGroups,
+		"subnetID":       s.ID,
+		"addresses":      numAddresses,
+	})
+	scopedLog.Info("No more IPs available, creating new ENI")
+
+	eniID, eni, err := p.ec2API.CreateNetworkInterface(numAddresses, s.ID, desc, securityGroups)
+	if err != nil {
+		p.metricsAPI.IncENIAllocationAttempt("ENI creation failed", s.ID)
+		return 0, fmt.Errorf("unable to create ENI: %s", err)
+	}
+
+	scopedLog = scopedLog.WithField(fieldEniID, eniID)
+	scopedLog.Info("Created new ENI")
+
+	var attachmentID string
+	for attachRetries := 0; attachRetries < maxAttachRetries; attachRetries++ {
+		attachmentID, err = p.ec2API.AttachNetworkInterface(index, instanceID, eniID)
+
+		// The index is already in use, this can happen if the local
+		// list of ENIs is oudated.  Retry the attachment to avoid
+		// having to delete the ENI
+		if !isAttachmentIndexConflict(err) {
+			break
+		}
+
+		index = findNextIndex(a.enis, index+1)
+	}
+
+	if err != nil {
+		delErr := p.ec2API.DeleteNetworkInterface(eniID)
+		if delErr != nil {
+			scopedLog.WithError(delErr).Warning("Unable to undo ENI creation after failure to attach")
+		}
+
+		if p.errorInstanceNotRunning(resource, err) {
+			return 0, nil
+		}
+
+		p.metricsAPI.IncENIAllocationAttempt("ENI attachment failed", s.ID)
+
+		return 0, fmt.Errorf("unable to attach ENI at index %d: %s", index, err)
+	}
+
+	scopedLog = scopedLog.WithFields(logrus.Fields{
+		"attachmentID": attachmentID,
+		"index":        index,
+	})
+
+	eni.Number = int(index)
+
+	scopedLog.Info("Attached ENI to instance")
+
+	if resource.Spec.ENI.DeleteOnTermination {
+		// We have an attachment ID from the last API, which lets us mark the
+		// interface as delete on termination
+		err = p.ec2API.ModifyNetworkInterface(eniID, attachmentID, resource.Spec.ENI.DeleteOnTermination)
+		if err != nil {
+			delErr := p.ec2API.DeleteNetworkInterface(eniID)
+			if delErr != nil {
+				scopedLog.WithError(delErr).Warning("Unable to undo ENI creation after failure to attach")
+			}
+
+			if p.errorInstanceNotRunning(resource, err) {
+				return 0, nil
+			}
+
+			p.metricsAPI.IncENIAllocationAttempt("ENI modification failed", s.ID)
+			return 0, fmt.Errorf("unable to mark ENI for deletion on termination: %s", err)
+		}
+	}
+
+	// Add the information of the created ENI to the instances manager
+	p.instancesAPI.UpdateENI(instanceID, eni)
+
+	p.metricsAPI.IncENIAllocationAttempt("success", s.ID)
+	p.metricsAPI.AddIPAllocation(s.ID, numAddresses)
+
+	return int(numAddresses), nil
+}
+
+// allocatableResources represents the resources available for allocation for a
+// particular ciliumNode. If an existing ENI has IP allocation capacity left,
+// that capacity is used up first. If not, an available index is found to
+// create a new ENI.
+type allocatableResources struct {
+	enis                []*v2.ENI
+	eni                 *v2.ENI
+	subnet              *types.Subnet
+	availableOnSubnet   int
+	limits              Limits
+	remainingInterfaces int
+}
+
+func (p *Provider) determineAllocationAction(resource *v2.CiliumNode, maxAllocate int) (*allocatableResources, error) {
+	instanceType := resource.Spec.ENI.InstanceType
+	limits, ok := GetLimits(instanceType)
+	if !ok {
+		p.metricsAPI.IncENIAllocationAttempt("limits unavailable", "")
+		return nil, fmt.Errorf("Unable to determine limits of instance type '%s'", instanceType)
+	}
+
+	scopedLog := logger(resource)
+
+	a := &allocatableResources{
+		enis:   p.instancesAPI.GetENIs(resource.Spec.ENI.InstanceID),
+		limits: limits,
+	}
+	for _, e := range a.enis {
+		scopedLog.WithFields(logrus.Fields{
+			fieldEniID:     e.ID,
+			"needIndex":    resource.Spec.ENI.FirstInterfaceIndex,
+			"index":        e.Number,
+			"addressLimit": limits.IPv4,
+			"numAddresses": len(e.Addresses),
+		}).Debug("Considering ENI for allocation")
+
+		if e.Number < resource.Spec.ENI.FirstInterfaceIndex {
+			continue
+		}
+
+		availableOnENI := math.IntMax(limits.IPv4-len(e.Addresses), 0)
+		if availableOnENI <= 0 {
+			continue
+		}
+
+		scopedLog.WithFields(logrus.Fields{
+			fieldEniID:       e.ID,
+			"maxAllocate":    maxAllocate,
+			"availableOnEni": availableOnENI,
+		}).Debug("ENI has IPs available")
+		maxAllocateOnENI := math.IntMin(availableOnENI, maxAllocate)
+
+		if subnet := p.instancesAPI.GetSubnet(e.Subnet.ID); subnet != nil {
+			if subnet.AvailableAddresses > 0 && a.eni == nil {
+				scopedLog.WithFields(logrus.Fields{
+					"subnetID":           e.Subnet.ID,
+					"availableAddresses": subnet.AvailableAddresses,
+				}).Debug("Subnet has IPs available")
+				a.eni = e
+				a.subnet = subnet
+				a.availableOnSubnet = math.IntMin(subnet.AvailableAddresses, maxAllocateOnENI)
+			}
+		}
+	}
+
+	a.remainingInterfaces = availableInterfaces(limits, a.enis, resource.Spec.ENI.FirstInterfaceIndex)
+
+	scopedLog = scopedLog.WithFields(logrus.Fields{
+		"toAlloc":             maxAllocate,
+		"remainingInterfaces": a.remainingInterfaces,
+	})
+
+	if a.eni != nil {
+		scopedLog = scopedLog.WithFields(logrus.Fields{
+			"selectedENI":          a.eni.ID,
+			"selectedSubnet":       a.subnet.ID,
+			"availableIPsOnSubnet": a.subnet.AvailableAddresses,
+		})
+	}
+
+	scopedLog.Info("Resolving IP deficit of node")
+
+	return a, nil
+}
+
+func (p *Provider) prepareENICreation(resource *v2.CiliumNode, a *allocatableResources) (*types.Subnet, error) {
+	if a.remainingInterfaces == 0 {
+		// This is not a failure scenario, warn once per hour but do
+		// not track as ENI allocation failure. There is a separate
+		// metric to track nodes running at capacity.
+		p.mutex.Lock()
+		n := p.getNodeLocked(resource)
+		if time.Since(n.lastMaxAdapterWarning) > warningInterval {
+			logger(resource).WithFields(logrus.Fields{
+				"max":       a.limits.Adapters,
+				"allocated": len(a.enis),
+			}).Warning("Instance is out of ENIs")
+			n.lastMaxAdapterWarning = time.Now()
+		}
+		p.mutex.Unlock()
+		return nil, nil
+	}
+
+	bestSubnet := p.instancesAPI.FindSubnetByTags(resource.Spec.ENI.VpcID, resource.Spec.ENI.AvailabilityZone, resource.Spec.ENI.SubnetTags)
+	if bestSubnet == nil {
+		p.metricsAPI.IncENIAllocationAttempt("no available subnet", "")
+		return nil, fmt.Errorf("No matching subnet available for ENI creation (VPC=%s AZ=%s SubnetTags=%s",
+			resource.Spec.ENI.VpcID, resource.Spec.ENI.AvailabilityZone, resource.Spec.ENI.SubnetTags)
+	}
+
+	return bestSubnet, nil
+}
+
+// AllocateIPs assigns up to toAllocate IPs to an existing ENI of the instance
+// or, if all ENIs are exhausted, creates an additional ENI. No IPs are
+// allocated while the instance is considered not running.
+func (p *Provider) AllocateIPs(ctx context.Context, resource *v2.CiliumNode, toAllocate int) (int, error) {
+	// If the instance is no longer running, don't attempt any deficit
+	// resolution and wait for the custom resource to be updated as a sign
+	// of life.
+	if !p.isRunning(resource) {
+		return 0, nil
+	}
+
+	a, err := p.determineAllocationAction(resource, toAllocate)
+	if err != nil {
+		return 0, err
+	}
+
+	if a.subnet != nil && a.availableOnSubnet > 0 {
+		err := p.ec2API.AssignPrivateIpAddresses(a.eni.ID, int64(a.availableOnSubnet))
+		if err == nil {
+			p.metricsAPI.IncENIAllocationAttempt("success", a.subnet.ID)
+			p.metricsAPI.AddIPAllocation(a.subnet.ID, int64(a.availableOnSubnet))
+			return a.availableOnSubnet, nil
+		}
+
+		p.metricsAPI.IncENIAllocationAttempt("ip assignment failed", a.subnet.ID)
+		logger(resource).WithFields(logrus.Fields{
+			fieldEniID:           a.eni.ID,
+			"requestedAddresses": a.availableOnSubnet,
+		}).WithError(err).Warning("Unable to assign additional private IPs to ENI, will create new ENI")
+	}
+
+	bestSubnet, err := p.prepareENICreation(resource, a)
+	if err != nil {
+		return 0, err
+	}
+
+	// Out of ENIs
+	if bestSubnet == nil {
+		return 0, nil
+	}
+
+	return p.allocateENI(resource, bestSubnet, a, toAllocate)
+}
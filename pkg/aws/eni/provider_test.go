@@ -17,6 +17,7 @@
 package eni
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -24,6 +25,7 @@ import (
 	ec2mock "github.com/cilium/cilium/pkg/aws/ec2/mock"
 	metricsmock "github.com/cilium/cilium/pkg/aws/eni/metrics/mock"
 	"github.com/cilium/cilium/pkg/aws/types"
+	"github.com/cilium/cilium/pkg/ipam/nodepool"
 	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
 	"github.com/cilium/cilium/pkg/testutils"
 
@@ -32,6 +34,9 @@ import (
 )
 
 var (
+	_ nodepool.Provider           = &Provider{}
+	_ nodepool.WatermarksProvider = &Provider{}
+
 	testSubnet = &types.Subnet{
 		ID:                 "s-1",
 		AvailabilityZone:   "us-west-1",
@@ -51,7 +56,7 @@ func (e *ENISuite) TestGetNodeNames(c *check.C) {
 	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -77,7 +82,7 @@ func (e *ENISuite) TestNodeManagerGet(c *check.C) {
 	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -91,6 +96,34 @@ func (e *ENISuite) TestNodeManagerGet(c *check.C) {
 	c.Assert(mngr.Get("node2"), check.IsNil)
 }
 
+type watermarkTestDef struct {
+	available   int
+	used        int
+	preallocate int
+	minallocate int
+	result      int
+}
+
+var watermarkDefs = []watermarkTestDef{
+	{0, 0, 0, 16, 16},
+	{0, 0, 8, 16, 16},
+	{0, 0, 16, 8, 16},
+	{0, 0, 16, 0, 16},
+	{8, 0, 0, 16, 8},
+	{8, 4, 8, 0, 4},
+	{8, 4, 8, 8, 4},
+	{0, 0, 0, 0, 8},
+}
+
+func (e *ENISuite) TestCalculateNeededIPs(c *check.C) {
+	provider := NewProvider(nil, nil, metricsapi)
+	for _, d := range watermarkDefs {
+		w := provider.GetWatermarks(newCiliumNode("node1", "i-1", "m4.large", "us-west-1", "vpc-1", d.preallocate, d.minallocate, 0, 0))
+		result := nodepool.CalculateNeededIPs(d.available, d.used, w.PreAllocate, w.MinAllocate)
+		c.Assert(result, check.Equals, d.result, check.Commentf("%+v", d))
+	}
+}
+
 type k8sMock struct{}
 
 func (k *k8sMock) Update(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
@@ -151,9 +184,9 @@ func updateCiliumNode(cn *v2.CiliumNode, available, used int) *v2.CiliumNode {
 	return cn
 }
 
-func reachedAddressesNeeded(mngr *NodeManager, nodeName string, needed int) (success bool) {
+func reachedAddressesNeeded(mngr *nodepool.NodeManager, nodeName string, needed int) (success bool) {
 	if node := mngr.Get(nodeName); node != nil {
-		success = node.getNeededAddresses() == needed
+		success = node.Stats().NeededIPs == needed
 	}
 	return
 }
@@ -167,7 +200,7 @@ func (e *ENISuite) TestNodeManagerDefaultAllocation(c *check.C) {
 	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -178,8 +211,8 @@ func (e *ENISuite) TestNodeManagerDefaultAllocation(c *check.C) {
 
 	node := mngr.Get("node1")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 8)
-	c.Assert(node.stats.usedIPs, check.Equals, 0)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 8)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 0)
 
 	// Use 7 out of 8 IPs
 	mngr.Update(updateCiliumNode(cn, 8, 7))
@@ -187,8 +220,47 @@ func (e *ENISuite) TestNodeManagerDefaultAllocation(c *check.C) {
 
 	node = mngr.Get("node1")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 15)
-	c.Assert(node.stats.usedIPs, check.Equals, 7)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 15)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 7)
+}
+
+// TestReleaseIPs tests the release of secondary IPs from the ENIs of a node
+func (e *ENISuite) TestReleaseIPs(c *check.C) {
+	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
+	instances := NewInstancesManager(ec2api, metricsapi)
+	c.Assert(instances, check.Not(check.IsNil))
+	provider := NewProvider(instances, ec2api, metricsapi)
+	mngr, err := nodepool.NewNodeManager(provider, k8sapi, metricsapi, 10, false)
+	c.Assert(err, check.IsNil)
+
+	cn := newCiliumNode("node1", "i-0", "m4.large", "us-west-1", "vpc-1", 0, 0, 0, 0)
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node1", 0) }, 5*time.Second), check.IsNil)
+
+	pool := provider.GetPool(cn)
+	c.Assert(pool, check.HasLen, 8)
+
+	// Addresses not assigned to the instance cannot be released
+	c.Assert(provider.ReleaseIPs(context.TODO(), cn, []string{"192.0.2.1"}), check.Not(check.IsNil))
+
+	toRelease := []string{}
+	for ip := range pool {
+		if len(toRelease) < 3 {
+			toRelease = append(toRelease, ip)
+		}
+	}
+	c.Assert(provider.ReleaseIPs(context.TODO(), cn, toRelease), check.IsNil)
+
+	pool = provider.GetPool(cn)
+	c.Assert(pool, check.HasLen, 5)
+	for _, ip := range toRelease {
+		_, ok := pool[ip]
+		c.Assert(ok, check.Equals, false)
+	}
+
+	// The release is persisted in EC2
+	instances.Resync()
+	c.Assert(provider.GetPool(cn), check.HasLen, 5)
 }
 
 // TestNodeManagerMinAllocate20 tests MinAllocate without PreAllocate
@@ -200,7 +272,7 @@ func (e *ENISuite) TestNodeManagerMinAllocate20(c *check.C) {
 	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -211,16 +283,16 @@ func (e *ENISuite) TestNodeManagerMinAllocate20(c *check.C) {
 
 	node := mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 10)
-	c.Assert(node.stats.usedIPs, check.Equals, 0)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 10)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 0)
 
 	mngr.Update(updateCiliumNode(cn, 10, 8))
 	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node2", 0) }, 5*time.Second), check.IsNil)
 
 	node = mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 10)
-	c.Assert(node.stats.usedIPs, check.Equals, 8)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 10)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 8)
 
 	// Change MinAllocate to 20
 	cn = newCiliumNode("node2", "i-1", "m5.4xlarge", "us-west-1", "vpc-1", 0, 20, 10, 8)
@@ -229,8 +301,8 @@ func (e *ENISuite) TestNodeManagerMinAllocate20(c *check.C) {
 
 	node = mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 20)
-	c.Assert(node.stats.usedIPs, check.Equals, 8)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 20)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 8)
 }
 
 // TestNodeManagerMinAllocateAndPreallocate tests MinAllocate in combination with PreAllocate
@@ -242,7 +314,7 @@ func (e *ENISuite) TestNodeManagerMinAllocateAndPreallocate(c *check.C) {
 	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -253,32 +325,32 @@ func (e *ENISuite) TestNodeManagerMinAllocateAndPreallocate(c *check.C) {
 
 	node := mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 10)
-	c.Assert(node.stats.usedIPs, check.Equals, 0)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 10)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 0)
 
 	// Use 9 out of 10 IPs, no additional IPs should be allocated
 	mngr.Update(updateCiliumNode(cn, 10, 9))
 	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node2", 0) }, 5*time.Second), check.IsNil)
 	node = mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 10)
-	c.Assert(node.stats.usedIPs, check.Equals, 9)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 10)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 9)
 
 	// Use 10 out of 10 IPs, PreAllocate 1 must kick in and allocate an additional IP
 	mngr.Update(updateCiliumNode(cn, 10, 10))
 	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node2", 0) }, 5*time.Second), check.IsNil)
 	node = mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 11)
-	c.Assert(node.stats.usedIPs, check.Equals, 10)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 11)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 10)
 
 	// Release some IPs, no additional IPs should be allocated
 	mngr.Update(updateCiliumNode(cn, 10, 8))
 	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node2", 0) }, 5*time.Second), check.IsNil)
 	node = mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 11)
-	c.Assert(node.stats.usedIPs, check.Equals, 8)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 11)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 8)
 }
 
 // TestNodeManagerExceedENICapacity tests exceeding ENI capacity
@@ -290,7 +362,7 @@ func (e *ENISuite) TestNodeManagerExceedENICapacity(c *check.C) {
 	ec2api := ec2mock.NewAPI([]*types.Subnet{testSubnet}, []*types.Vpc{testVpc})
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -301,8 +373,8 @@ func (e *ENISuite) TestNodeManagerExceedENICapacity(c *check.C) {
 
 	node := mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 20)
-	c.Assert(node.stats.usedIPs, check.Equals, 0)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 20)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 0)
 
 	// Use 16 out of 20 IPs, we should reach 4 addresses needed but never 0 addresses needed
 	mngr.Update(updateCiliumNode(cn, 20, 16))
@@ -311,11 +383,11 @@ func (e *ENISuite) TestNodeManagerExceedENICapacity(c *check.C) {
 
 	node = mngr.Get("node2")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 20)
-	c.Assert(node.stats.usedIPs, check.Equals, 16)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 20)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 16)
 }
 
-type nodeState struct {
+type testNodeState struct {
 	cn           *v2.CiliumNode
 	name         string
 	instanceName string
@@ -342,14 +414,14 @@ func (e *ENISuite) TestNodeManagerManyNodes(c *check.C) {
 	metricsapi := metricsmock.NewMockMetrics()
 	instancesManager := NewInstancesManager(ec2api, metricsapi)
 	instancesManager.Resync()
-	mngr, err := NewNodeManager(instancesManager, ec2api, k8sapi, metricsapi, 10)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instancesManager, ec2api, metricsapi), k8sapi, metricsapi, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
-	state := make([]*nodeState, numNodes)
+	state := make([]*testNodeState, numNodes)
 
 	for i := range state {
-		s := &nodeState{name: fmt.Sprintf("node%d", i), instanceName: fmt.Sprintf("i-%d", i)}
+		s := &testNodeState{name: fmt.Sprintf("node%d", i), instanceName: fmt.Sprintf("i-%d", i)}
 		s.cn = newCiliumNode(s.name, s.instanceName, "m4.large", "us-west-1", "vpc-1", 1, minAllocate, 0, 0)
 		state[i] = s
 		mngr.Update(s.cn)
@@ -360,17 +432,17 @@ func (e *ENISuite) TestNodeManagerManyNodes(c *check.C) {
 
 		node := mngr.Get(s.name)
 		c.Assert(node, check.Not(check.IsNil))
-		if node.stats.availableIPs != minAllocate {
-			c.Errorf("Node %s allocation mismatch. expected: %d allocated: %d", s.name, minAllocate, node.stats.availableIPs)
+		if node.Stats().AvailableIPs != minAllocate {
+			c.Errorf("Node %s allocation mismatch. expected: %d allocated: %d", s.name, minAllocate, node.Stats().AvailableIPs)
 			c.Fail()
 		}
-		c.Assert(node.stats.usedIPs, check.Equals, 0)
+		c.Assert(node.Stats().UsedIPs, check.Equals, 0)
 	}
 
 	// The above check returns as soon as the address requirements are met.
 	// The metrics may still be oudated, resync all nodes to update
 	// metrics.
-	mngr.Resync(time.Now())
+	mngr.Resync(context.TODO(), time.Now())
 
 	c.Assert(metricsapi.Nodes("total"), check.Equals, numNodes)
 	c.Assert(metricsapi.Nodes("in-deficit"), check.Equals, 0)
@@ -398,7 +470,8 @@ func (e *ENISuite) TestNodeManagerInstanceNotRunning(c *check.C) {
 	metricsMock := metricsmock.NewMockMetrics()
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsMock, 10)
+	provider := NewProvider(instances, ec2api, metricsMock)
+	mngr, err := nodepool.NewNodeManager(provider, k8sapi, metricsMock, 10, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
@@ -408,7 +481,9 @@ func (e *ENISuite) TestNodeManagerInstanceNotRunning(c *check.C) {
 
 	// Wait for node to be declared notRunning
 	c.Assert(testutils.WaitUntil(func() bool {
-		if n := mngr.Get("node1"); n != nil {
+		provider.mutex.Lock()
+		defer provider.mutex.Unlock()
+		if n, ok := provider.nodes["node1"]; ok {
 			return n.instanceNotRunning
 		}
 		return false
@@ -419,8 +494,8 @@ func (e *ENISuite) TestNodeManagerInstanceNotRunning(c *check.C) {
 
 	node := mngr.Get("node1")
 	c.Assert(node, check.Not(check.IsNil))
-	c.Assert(node.stats.availableIPs, check.Equals, 0)
-	c.Assert(node.stats.usedIPs, check.Equals, 0)
+	c.Assert(node.Stats().AvailableIPs, check.Equals, 0)
+	c.Assert(node.Stats().UsedIPs, check.Equals, 0)
 }
 
 func benchmarkAllocWorker(c *check.C, workers int64, delay time.Duration, rateLimit float64, burst int) {
@@ -433,15 +508,15 @@ func benchmarkAllocWorker(c *check.C, workers int64, delay time.Duration, rateLi
 	ec2api.SetLimiter(rateLimit, burst)
 	instances := NewInstancesManager(ec2api, metricsapi)
 	c.Assert(instances, check.Not(check.IsNil))
-	mngr, err := NewNodeManager(instances, ec2api, k8sapi, metricsapi, workers)
+	mngr, err := nodepool.NewNodeManager(NewProvider(instances, ec2api, metricsapi), k8sapi, metricsapi, workers, false)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr, check.Not(check.IsNil))
 
-	state := make([]*nodeState, c.N)
+	state := make([]*testNodeState, c.N)
 
 	c.ResetTimer()
 	for i := range state {
-		s := &nodeState{name: fmt.Sprintf("node%d", i), instanceName: fmt.Sprintf("i-%d", i)}
+		s := &testNodeState{name: fmt.Sprintf("node%d", i), instanceName: fmt.Sprintf("i-%d", i)}
 		s.cn = newCiliumNode(s.name, s.instanceName, "m4.large", "us-west-1", "vpc-1", 1, 10, 0, 0)
 		state[i] = s
 		mngr.Update(s.cn)
@@ -0,0 +1,474 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api implements a client of the Azure Resource Manager network API
+// as needed by the Azure IPAM provider. It manages the network interfaces and
+// virtual networks of a single resource group.
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cilium/cilium/pkg/azure/types"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// managementURL is the endpoint of the Azure Resource Manager API
+	managementURL = "https://management.azure.com"
+
+	// networkAPIVersion is the version of the Microsoft.Network API
+	networkAPIVersion = "2019-11-01"
+
+	// subnetReservedAddresses is the number of addresses Azure reserves in
+	// every subnet
+	subnetReservedAddresses = 5
+
+	// ipConfigurationPrefix is the name prefix of the IP configurations
+	// created by the client
+	ipConfigurationPrefix = "cilium-"
+
+	// requestTimeout is the timeout of a single API request
+	requestTimeout = 30 * time.Second
+)
+
+// Client is a client of the Azure Resource Manager API
+type Client struct {
+	httpClient     *http.Client
+	tokens         tokenSource
+	baseURL        string
+	subscriptionID string
+	resourceGroup  string
+	limiter        *rate.Limiter
+}
+
+// NewClient returns a new Azure client for the network resources of the
+// given subscription and resource group. If clientSecret is empty, the
+// managed identity of the instance is used to authenticate, clientID then
+// optionally selects a user assigned identity.
+func NewClient(subscriptionID, resourceGroup, tenantID, clientID, clientSecret string, rateLimit float64, burst int) (*Client, error) {
+	if subscriptionID == "" || resourceGroup == "" {
+		return nil, fmt.Errorf("subscription ID and resource group must be specified")
+	}
+
+	httpClient := &http.Client{Timeout: requestTimeout}
+
+	var tokens tokenSource
+	if clientSecret != "" {
+		if tenantID == "" || clientID == "" {
+			return nil, fmt.Errorf("tenant ID and client ID must be specified to authenticate with a client secret")
+		}
+		tokens = newServicePrincipalAuthorizer(httpClient, tenantID, clientID, clientSecret)
+	} else {
+		tokens = newManagedIdentityAuthorizer(httpClient, clientID)
+	}
+
+	return &Client{
+		httpClient:     httpClient,
+		tokens:         tokens,
+		baseURL:        managementURL,
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+		limiter:        rate.NewLimiter(rate.Limit(rateLimit), burst),
+	}, nil
+}
+
+// resourceGroupPath returns the path of the resource group relative to the
+// API endpoint
+func (c *Client) resourceGroupPath() string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", c.subscriptionID, c.resourceGroup)
+}
+
+// do sends a request for the given resource path or the absolute URL of the
+// next page of a list. body is encoded as JSON, the response is decoded into
+// result unless it is nil.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, body, result interface{}) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	url := path
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		url = fmt.Sprintf("%s%s?api-version=%s", c.baseURL, path, networkAPIVersion)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response body: %s", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s failed: %s: %s", method, path, resp.Status, string(respBody))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("unable to parse response of %s %s: %s", method, path, err)
+		}
+	}
+
+	return nil
+}
+
+// subResource is a reference to another Azure resource
+type subResource struct {
+	ID string `json:"id"`
+}
+
+type subnet struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Properties struct {
+		AddressPrefix    string        `json:"addressPrefix"`
+		IPConfigurations []subResource `json:"ipConfigurations"`
+	} `json:"properties"`
+}
+
+type virtualNetwork struct {
+	ID         string            `json:"id"`
+	Tags       map[string]string `json:"tags"`
+	Properties struct {
+		AddressSpace struct {
+			AddressPrefixes []string `json:"addressPrefixes"`
+		} `json:"addressSpace"`
+		Subnets []subnet `json:"subnets"`
+	} `json:"properties"`
+}
+
+type ipConfiguration struct {
+	Name       string `json:"name"`
+	Properties struct {
+		PrivateIPAddress  string       `json:"privateIPAddress"`
+		Primary           bool         `json:"primary"`
+		ProvisioningState string       `json:"provisioningState"`
+		Subnet            *subResource `json:"subnet"`
+	} `json:"properties"`
+}
+
+type networkInterface struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Properties struct {
+		MACAddress           string            `json:"macAddress"`
+		ProvisioningState    string            `json:"provisioningState"`
+		VirtualMachine       *subResource      `json:"virtualMachine"`
+		NetworkSecurityGroup *subResource      `json:"networkSecurityGroup"`
+		IPConfigurations     []ipConfiguration `json:"ipConfigurations"`
+	} `json:"properties"`
+}
+
+// normalizeID returns the canonical form of an Azure resource ID. Resource
+// IDs are case insensitive but the API does not return them in consistent
+// case.
+func normalizeID(id string) string {
+	return strings.ToLower(id)
+}
+
+// list retrieves all pages of the given list resource and passes the raw
+// items of each page to add
+func (c *Client) list(ctx context.Context, path string, add func(json.RawMessage) error) error {
+	for path != "" {
+		var page struct {
+			Value    []json.RawMessage `json:"value"`
+			NextLink string            `json:"nextLink"`
+		}
+		if err := c.do(ctx, http.MethodGet, path, nil, nil, &page); err != nil {
+			return err
+		}
+		for _, item := range page.Value {
+			if err := add(item); err != nil {
+				return err
+			}
+		}
+		path = page.NextLink
+	}
+	return nil
+}
+
+// availableAddresses returns the number of addresses of the subnet with the
+// given prefix which are neither reserved nor in use
+func availableAddresses(prefix string, used int) int {
+	_, cidr, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return 0
+	}
+	ones, bits := cidr.Mask.Size()
+	if bits-ones >= 31 {
+		return 1<<31 - 1
+	}
+	available := 1<<uint(bits-ones) - subnetReservedAddresses - used
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// GetVirtualNetworksAndSubnets returns all virtual networks and subnets of
+// the resource group
+func (c *Client) GetVirtualNetworksAndSubnets(ctx context.Context) (types.VirtualNetworkMap, types.SubnetMap, error) {
+	vnets := types.VirtualNetworkMap{}
+	subnets := types.SubnetMap{}
+
+	err := c.list(ctx, c.resourceGroupPath()+"/providers/Microsoft.Network/virtualNetworks", func(item json.RawMessage) error {
+		var v virtualNetwork
+		if err := json.Unmarshal(item, &v); err != nil {
+			return err
+		}
+
+		vnet := &types.VirtualNetwork{ID: normalizeID(v.ID)}
+		if len(v.Properties.AddressSpace.AddressPrefixes) > 0 {
+			vnet.PrimaryCIDR = v.Properties.AddressSpace.AddressPrefixes[0]
+		}
+		vnets[vnet.ID] = vnet
+
+		for _, s := range v.Properties.Subnets {
+			subnets[normalizeID(s.ID)] = &types.Subnet{
+				ID:                 normalizeID(s.ID),
+				Name:               s.Name,
+				CIDR:               s.Properties.AddressPrefix,
+				VirtualNetworkID:   vnet.ID,
+				AvailableAddresses: availableAddresses(s.Properties.AddressPrefix, len(s.Properties.IPConfigurations)),
+				Tags:               v.Tags,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return vnets, subnets, nil
+}
+
+// parseInterface converts a network interface into its CiliumNode
+// representation
+func parseInterface(n *networkInterface) *v2.AzureInterface {
+	iface := &v2.AzureInterface{
+		ID:    n.ID,
+		Name:  n.Name,
+		MAC:   n.Properties.MACAddress,
+		State: strings.ToLower(n.Properties.ProvisioningState),
+	}
+
+	if n.Properties.NetworkSecurityGroup != nil {
+		iface.SecurityGroup = n.Properties.NetworkSecurityGroup.ID
+	}
+
+	for _, ipConfig := range n.Properties.IPConfigurations {
+		address := v2.AzureAddress{
+			IP:      ipConfig.Properties.PrivateIPAddress,
+			State:   strings.ToLower(ipConfig.Properties.ProvisioningState),
+			Primary: ipConfig.Properties.Primary,
+		}
+		if ipConfig.Properties.Subnet != nil {
+			address.Subnet = normalizeID(ipConfig.Properties.Subnet.ID)
+		}
+		iface.Addresses = append(iface.Addresses, address)
+	}
+
+	return iface
+}
+
+// GetInstances returns the network interfaces of the resource group which
+// are attached to a virtual machine, indexed by the virtual machine ID
+func (c *Client) GetInstances(ctx context.Context, subnets types.SubnetMap) (types.InstanceMap, error) {
+	instances := types.InstanceMap{}
+
+	err := c.list(ctx, c.resourceGroupPath()+"/providers/Microsoft.Network/networkInterfaces", func(item json.RawMessage) error {
+		var n networkInterface
+		if err := json.Unmarshal(item, &n); err != nil {
+			return err
+		}
+
+		if n.Properties.VirtualMachine != nil && n.Properties.VirtualMachine.ID != "" {
+			instances.Add(normalizeID(n.Properties.VirtualMachine.ID), parseInterface(&n))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// updateInterface retrieves the network interface, passes its IP
+// configurations to modify and writes back the interface. The interface is
+// handled as raw JSON to preserve all properties not known to the client.
+// The update is rejected by Azure if the interface has been modified in the
+// meantime. Returns the updated interface.
+func (c *Client) updateInterface(ctx context.Context, interfaceID string, modify func([]map[string]interface{}) ([]map[string]interface{}, error)) (*networkInterface, error) {
+	var raw map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, interfaceID, nil, nil, &raw); err != nil {
+		return nil, err
+	}
+
+	properties, ok := raw["properties"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("interface %s has no properties", interfaceID)
+	}
+
+	ipConfigs := []map[string]interface{}{}
+	if list, ok := properties["ipConfigurations"].([]interface{}); ok {
+		for _, item := range list {
+			if ipConfig, ok := item.(map[string]interface{}); ok {
+				ipConfigs = append(ipConfigs, ipConfig)
+			}
+		}
+	}
+
+	ipConfigs, err := modify(ipConfigs)
+	if err != nil {
+		return nil, err
+	}
+	properties["ipConfigurations"] = ipConfigs
+
+	header := http.Header{}
+	if etag, ok := raw["etag"].(string); ok {
+		header.Set("If-Match", etag)
+	}
+
+	var updated networkInterface
+	if err := c.do(ctx, http.MethodPut, interfaceID, header, raw, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// newIPConfigurationName returns a random name for a new IP configuration
+func newIPConfigurationName() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return ipConfigurationPrefix + hex.EncodeToString(b), nil
+}
+
+// AssignPrivateIpAddresses adds addresses IP configurations with dynamically
+// allocated IPs in the subnet to the interface. Returns the IPs which have
+// been allocated by Azure, IPs still being provisioned are picked up by the
+// next resync.
+func (c *Client) AssignPrivateIpAddresses(ctx context.Context, subnetID, interfaceID string, addresses int) ([]string, error) {
+	names := map[string]struct{}{}
+
+	updated, err := c.updateInterface(ctx, interfaceID, func(ipConfigs []map[string]interface{}) ([]map[string]interface{}, error) {
+		for i := 0; i < addresses; i++ {
+			name, err := newIPConfigurationName()
+			if err != nil {
+				return nil, err
+			}
+			names[name] = struct{}{}
+			ipConfigs = append(ipConfigs, map[string]interface{}{
+				"name": name,
+				"properties": map[string]interface{}{
+					"privateIPAllocationMethod": "Dynamic",
+					"primary":                   false,
+					"subnet":                    map[string]interface{}{"id": subnetID},
+				},
+			})
+		}
+		return ipConfigs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	for _, ipConfig := range updated.Properties.IPConfigurations {
+		if _, ok := names[ipConfig.Name]; ok && ipConfig.Properties.PrivateIPAddress != "" {
+			ips = append(ips, ipConfig.Properties.PrivateIPAddress)
+		}
+	}
+
+	return ips, nil
+}
+
+// ReleasePrivateIpAddresses removes the IP configurations of the given IPs
+// from the interface
+func (c *Client) ReleasePrivateIpAddresses(ctx context.Context, interfaceID string, ips []string) error {
+	toRelease := map[string]struct{}{}
+	for _, ip := range ips {
+		toRelease[ip] = struct{}{}
+	}
+
+	_, err := c.updateInterface(ctx, interfaceID, func(ipConfigs []map[string]interface{}) ([]map[string]interface{}, error) {
+		remaining := make([]map[string]interface{}, 0, len(ipConfigs))
+		released := 0
+		for _, ipConfig := range ipConfigs {
+			properties, _ := ipConfig["properties"].(map[string]interface{})
+			ip, _ := properties["privateIPAddress"].(string)
+			if _, ok := toRelease[ip]; !ok {
+				remaining = append(remaining, ipConfig)
+				continue
+			}
+			if primary, _ := properties["primary"].(bool); primary {
+				return nil, fmt.Errorf("primary IP %s of interface %s cannot be released", ip, interfaceID)
+			}
+			released++
+		}
+		if released != len(toRelease) {
+			return nil, fmt.Errorf("%d IPs are not assigned to interface %s", len(toRelease)-released, interfaceID)
+		}
+		return remaining, nil
+	})
+
+	return err
+}
@@ -0,0 +1,257 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cilium/cilium/pkg/lock"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type APISuite struct{}
+
+var _ = check.Suite(&APISuite{})
+
+const (
+	rgPath    = "/subscriptions/sub/resourceGroups/rg"
+	vnetID    = rgPath + "/providers/Microsoft.Network/virtualNetworks/vnet"
+	subnetID  = vnetID + "/subnets/Nodes"
+	nicID     = rgPath + "/providers/Microsoft.Network/networkInterfaces/vm-1-nic"
+	vmID      = rgPath + "/providers/Microsoft.Compute/virtualMachines/VM-1"
+	testToken = "secret-token"
+)
+
+type staticToken string
+
+func (s staticToken) Token(ctx context.Context) (string, error) { return string(s), nil }
+
+// fakeARM is a minimal fake of the Azure Resource Manager network API
+type fakeARM struct {
+	mutex  lock.Mutex
+	server *httptest.Server
+	nic    map[string]interface{}
+	nextIP int
+	etag   int
+}
+
+func newFakeARM() *fakeARM {
+	f := &fakeARM{nextIP: 10}
+	json.Unmarshal([]byte(`{
+		"id": "`+nicID+`",
+		"name": "vm-1-nic",
+		"location": "westeurope",
+		"properties": {
+			"enableAcceleratedNetworking": true,
+			"macAddress": "00-0D-3A-00-00-01",
+			"provisioningState": "Succeeded",
+			"virtualMachine": {"id": "`+vmID+`"},
+			"ipConfigurations": [{
+				"name": "ipconfig1",
+				"properties": {
+					"privateIPAddress": "10.0.0.4",
+					"primary": true,
+					"provisioningState": "Succeeded",
+					"subnet": {"id": "`+strings.ToUpper(subnetID)+`"}
+				}
+			}]
+		}
+	}`), &f.nic)
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeARM) client() *Client {
+	c, _ := NewClient("sub", "rg", "", "", "", 100, 10)
+	c.tokens = staticToken(testToken)
+	c.baseURL = f.server.URL
+	return c
+}
+
+func (f *fakeARM) serve(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == rgPath+"/providers/Microsoft.Network/virtualNetworks" && r.URL.Query().Get("page") == "":
+		fmt.Fprintf(w, `{"value": [], "nextLink": "%s%s?page=2"}`, f.server.URL, r.URL.Path)
+	case r.URL.Path == rgPath+"/providers/Microsoft.Network/virtualNetworks":
+		fmt.Fprintf(w, `{"value": [{
+			"id": "%s",
+			"tags": {"env": "test"},
+			"properties": {
+				"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]},
+				"subnets": [{"id": "%s", "name": "Nodes", "properties": {
+					"addressPrefix": "10.0.0.0/24",
+					"ipConfigurations": [{"id": "a"}, {"id": "b"}]
+				}}]
+			}
+		}]}`, vnetID, subnetID)
+	case r.URL.Path == rgPath+"/providers/Microsoft.Network/networkInterfaces":
+		json.NewEncoder(w).Encode(map[string]interface{}{"value": []interface{}{f.nic}})
+	case r.URL.Path == nicID && r.Method == http.MethodGet:
+		f.nic["etag"] = fmt.Sprintf("W/\"%d\"", f.etag)
+		json.NewEncoder(w).Encode(f.nic)
+	case r.URL.Path == nicID && r.Method == http.MethodPut:
+		if r.Header.Get("If-Match") != fmt.Sprintf("W/\"%d\"", f.etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		var nic map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&nic); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		properties := nic["properties"].(map[string]interface{})
+		for _, item := range properties["ipConfigurations"].([]interface{}) {
+			ipConfig := item.(map[string]interface{})["properties"].(map[string]interface{})
+			if _, ok := ipConfig["privateIPAddress"]; !ok {
+				ipConfig["privateIPAddress"] = fmt.Sprintf("10.0.0.%d", f.nextIP)
+				ipConfig["provisioningState"] = "Succeeded"
+				f.nextIP++
+			}
+		}
+		f.nic = nic
+		f.etag++
+		json.NewEncoder(w).Encode(f.nic)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (e *APISuite) TestAvailableAddresses(c *check.C) {
+	c.Assert(availableAddresses("10.0.0.0/24", 0), check.Equals, 251)
+	c.Assert(availableAddresses("10.0.0.0/24", 10), check.Equals, 241)
+	c.Assert(availableAddresses("10.0.0.0/30", 10), check.Equals, 0)
+	c.Assert(availableAddresses("invalid", 0), check.Equals, 0)
+}
+
+func (e *APISuite) TestNewClient(c *check.C) {
+	_, err := NewClient("", "rg", "", "", "", 10, 4)
+	c.Assert(err, check.Not(check.IsNil))
+
+	_, err = NewClient("sub", "rg", "", "", "secret", 10, 4)
+	c.Assert(err, check.Not(check.IsNil))
+
+	client, err := NewClient("sub", "rg", "tenant", "client", "secret", 10, 4)
+	c.Assert(err, check.IsNil)
+	c.Assert(client.resourceGroupPath(), check.Equals, rgPath)
+}
+
+func (e *APISuite) TestGetVirtualNetworksAndSubnets(c *check.C) {
+	f := newFakeARM()
+	defer f.server.Close()
+
+	vnets, subnets, err := f.client().GetVirtualNetworksAndSubnets(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(len(vnets), check.Equals, 1)
+	c.Assert(vnets[strings.ToLower(vnetID)].PrimaryCIDR, check.Equals, "10.0.0.0/16")
+	c.Assert(len(subnets), check.Equals, 1)
+
+	subnet := subnets[strings.ToLower(subnetID)]
+	c.Assert(subnet, check.Not(check.IsNil))
+	c.Assert(subnet.CIDR, check.Equals, "10.0.0.0/24")
+	c.Assert(subnet.VirtualNetworkID, check.Equals, strings.ToLower(vnetID))
+	c.Assert(subnet.AvailableAddresses, check.Equals, 249)
+	c.Assert(subnet.Tags["env"], check.Equals, "test")
+}
+
+func (e *APISuite) TestAssignAndReleaseIPs(c *check.C) {
+	f := newFakeARM()
+	defer f.server.Close()
+	client := f.client()
+
+	instances, err := client.GetInstances(context.TODO(), nil)
+	c.Assert(err, check.IsNil)
+	interfaces := instances.Get(strings.ToLower(vmID))
+	c.Assert(len(interfaces), check.Equals, 1)
+	c.Assert(interfaces[0].ID, check.Equals, nicID)
+	c.Assert(interfaces[0].State, check.Equals, "succeeded")
+	c.Assert(len(interfaces[0].Addresses), check.Equals, 1)
+	c.Assert(interfaces[0].Addresses[0].Primary, check.Equals, true)
+	c.Assert(interfaces[0].Addresses[0].Subnet, check.Equals, strings.ToLower(subnetID))
+
+	ips, err := client.AssignPrivateIpAddresses(context.TODO(), subnetID, nicID, 2)
+	c.Assert(err, check.IsNil)
+	c.Assert(ips, check.DeepEquals, []string{"10.0.0.10", "10.0.0.11"})
+
+	// Properties unknown to the client must be preserved
+	c.Assert(f.nic["location"], check.Equals, "westeurope")
+	c.Assert(f.nic["properties"].(map[string]interface{})["enableAcceleratedNetworking"], check.Equals, true)
+
+	err = client.ReleasePrivateIpAddresses(context.TODO(), nicID, []string{"10.0.0.4"})
+	c.Assert(err, check.Not(check.IsNil))
+
+	err = client.ReleasePrivateIpAddresses(context.TODO(), nicID, []string{"10.0.0.99"})
+	c.Assert(err, check.Not(check.IsNil))
+
+	err = client.ReleasePrivateIpAddresses(context.TODO(), nicID, []string{"10.0.0.10"})
+	c.Assert(err, check.IsNil)
+
+	instances, err = client.GetInstances(context.TODO(), nil)
+	c.Assert(err, check.IsNil)
+	interfaces = instances.Get(strings.ToLower(vmID))
+	c.Assert(len(interfaces[0].Addresses), check.Equals, 2)
+	c.Assert(interfaces[0].Addresses[1].IP, check.Equals, "10.0.0.11")
+}
+
+func (e *APISuite) TestTokenCaching(c *check.C) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		c.Assert(r.Header.Get("Metadata"), check.Equals, "true")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": "3599"}`, requests)
+	}))
+	defer server.Close()
+
+	a := newManagedIdentityAuthorizer(server.Client(), "")
+	newRequest := a.newRequest
+	a.newRequest = func(ctx context.Context) (*http.Request, error) {
+		req, err := newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.Scheme = "http"
+		req.URL.Host = strings.TrimPrefix(server.URL, "http://")
+		return req, nil
+	}
+
+	token, err := a.Token(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(token, check.Equals, "token-1")
+
+	token, err = a.Token(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(token, check.Equals, "token-1")
+	c.Assert(requests, check.Equals, 1)
+}
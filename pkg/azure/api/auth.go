@@ -0,0 +1,165 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+const (
+	// managementResource is the resource for which access tokens of the
+	// Azure Resource Manager API are requested
+	managementResource = "https://management.azure.com/"
+
+	// activeDirectoryURL is the endpoint of Azure Active Directory
+	activeDirectoryURL = "https://login.microsoftonline.com"
+
+	// identityURL is the endpoint of the managed identity of the instance
+	// metadata service
+	identityURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	// tokenRefreshMargin is the time before the expiration of an access
+	// token at which it is refreshed
+	tokenRefreshMargin = time.Minute
+)
+
+// tokenSource provides access tokens for the Azure Resource Manager API
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// tokenResponse is the response of both Azure Active Directory and the
+// managed identity endpoint. Both return the expiry as a string.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
+}
+
+// authorizer retrieves access tokens either for a service principal from
+// Azure Active Directory or for the managed identity of the instance and
+// caches them until shortly before they expire
+type authorizer struct {
+	mutex      lock.Mutex
+	httpClient *http.Client
+	newRequest func(ctx context.Context) (*http.Request, error)
+	token      string
+	expiry     time.Time
+}
+
+// newServicePrincipalAuthorizer returns an authorizer for the service
+// principal with the given client ID and secret
+func newServicePrincipalAuthorizer(httpClient *http.Client, tenantID, clientID, clientSecret string) *authorizer {
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/token", activeDirectoryURL, url.PathEscape(tenantID))
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"resource":      {managementResource},
+	}
+
+	return &authorizer{
+		httpClient: httpClient,
+		newRequest: func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return req.WithContext(ctx), nil
+		},
+	}
+}
+
+// newManagedIdentityAuthorizer returns an authorizer for the managed
+// identity of the instance. clientID selects a user assigned identity, if
+// empty the system assigned identity is used.
+func newManagedIdentityAuthorizer(httpClient *http.Client, clientID string) *authorizer {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {managementResource},
+	}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	tokenURL := identityURL + "?" + query.Encode()
+
+	return &authorizer{
+		httpClient: httpClient,
+		newRequest: func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodGet, tokenURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Metadata", "true")
+			return req.WithContext(ctx), nil
+		},
+	}
+}
+
+// Token returns a valid access token, a new token is requested if the
+// cached token is about to expire
+func (a *authorizer) Token(ctx context.Context) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token != "" && time.Now().Add(tokenRefreshMargin).Before(a.expiry) {
+		return a.token, nil
+	}
+
+	req, err := a.newRequest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to request access token: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read access token response: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to request access token: %s: %s", resp.Status, string(body))
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("unable to parse access token response: %s", err)
+	}
+
+	expiresIn, err := strconv.Atoi(token.ExpiresIn)
+	if err != nil {
+		return "", fmt.Errorf("invalid access token expiry %q: %s", token.ExpiresIn, err)
+	}
+
+	a.token = token.AccessToken
+	a.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+
+	return a.token, nil
+}
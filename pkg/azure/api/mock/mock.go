@@ -0,0 +1,331 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mock implements a mock of the Azure API used by the Azure IPAM
+// provider. It maintains the instance, interface and subnet inventory in
+// memory so the provider can be tested without access to Azure.
+package mock
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cilium/cilium/pkg/azure/types"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+
+	"golang.org/x/time/rate"
+	"k8s.io/kubernetes/pkg/registry/core/service/ipallocator"
+)
+
+type interfaceMap map[string]*v2.AzureInterface
+
+// Operation is an Azure API operation that this mock API supports
+type Operation int
+
+const (
+	AllOperations Operation = iota
+	GetInstances
+	GetVirtualNetworksAndSubnets
+	AssignPrivateIpAddresses
+	ReleasePrivateIpAddresses
+	MaxOperation
+)
+
+// API is the mock Azure API
+type API struct {
+	mutex      lock.RWMutex
+	instances  map[string]interfaceMap
+	subnets    map[string]*types.Subnet
+	vnets      map[string]*types.VirtualNetwork
+	allocators map[string]*ipallocator.Range
+	errors     map[Operation]error
+	delays     map[Operation]time.Duration
+	limiter    *rate.Limiter
+}
+
+// NewAPI returns a new mock API with the given subnets and virtual networks.
+// IPs are allocated out of the CIDR of each subnet.
+func NewAPI(subnets []*types.Subnet, vnets []*types.VirtualNetwork) *API {
+	api := &API{
+		instances:  map[string]interfaceMap{},
+		subnets:    map[string]*types.Subnet{},
+		vnets:      map[string]*types.VirtualNetwork{},
+		allocators: map[string]*ipallocator.Range{},
+		errors:     map[Operation]error{},
+		delays:     map[Operation]time.Duration{},
+	}
+
+	for _, s := range subnets {
+		api.subnets[s.ID] = s
+		if _, cidr, err := net.ParseCIDR(s.CIDR); err == nil {
+			api.allocators[s.ID] = ipallocator.NewCIDRRange(cidr)
+		}
+	}
+
+	for _, v := range vnets {
+		api.vnets[v.ID] = v
+	}
+
+	return api
+}
+
+// UpdateInstances replaces the instances and interfaces known to the mock
+// API. All addresses of the interfaces are marked as allocated in their
+// respective subnets.
+func (a *API) UpdateInstances(instances types.InstanceMap) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.instances = map[string]interfaceMap{}
+	for instanceID := range instances {
+		a.instances[instanceID] = interfaceMap{}
+		for _, iface := range instances.Get(instanceID) {
+			a.instances[instanceID][iface.ID] = iface
+			for _, address := range iface.Addresses {
+				if allocator, ok := a.allocators[address.Subnet]; ok {
+					allocator.Allocate(net.ParseIP(address.IP))
+				}
+			}
+		}
+	}
+}
+
+// SetMockError modifies the mock API to return an error for a particular
+// operation. A nil error restores the normal behavior.
+func (a *API) SetMockError(op Operation, err error) {
+	a.mutex.Lock()
+	if err == nil {
+		delete(a.errors, op)
+	} else {
+		a.errors[op] = err
+	}
+	a.mutex.Unlock()
+}
+
+func (a *API) setDelayLocked(op Operation, delay time.Duration) {
+	if delay == time.Duration(0) {
+		delete(a.delays, op)
+	} else {
+		a.delays[op] = delay
+	}
+}
+
+// SetDelay specifies the delay which should be simulated for an individual
+// Azure API operation
+func (a *API) SetDelay(op Operation, delay time.Duration) {
+	a.mutex.Lock()
+	if op == AllOperations {
+		for op := AllOperations + 1; op < MaxOperation; op++ {
+			a.setDelayLocked(op, delay)
+		}
+	} else {
+		a.setDelayLocked(op, delay)
+	}
+	a.mutex.Unlock()
+}
+
+// SetLimiter adds a rate limiter to all simulated API calls
+func (a *API) SetLimiter(limit float64, burst int) {
+	a.limiter = rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+func (a *API) rateLimit() {
+	a.mutex.RLock()
+	if a.limiter == nil {
+		a.mutex.RUnlock()
+		return
+	}
+
+	r := a.limiter.Reserve()
+	a.mutex.RUnlock()
+	if delay := r.Delay(); delay != time.Duration(0) && delay != rate.InfDuration {
+		time.Sleep(delay)
+	}
+}
+
+func (a *API) simulateDelay(op Operation) {
+	a.mutex.RLock()
+	delay, ok := a.delays[op]
+	a.mutex.RUnlock()
+	if ok {
+		time.Sleep(delay)
+	}
+}
+
+// GetInstances returns the list of all instances including their interfaces
+func (a *API) GetInstances(ctx context.Context, subnets types.SubnetMap) (types.InstanceMap, error) {
+	a.rateLimit()
+	a.simulateDelay(GetInstances)
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if err, ok := a.errors[GetInstances]; ok {
+		return nil, err
+	}
+
+	instances := types.InstanceMap{}
+	for instanceID, interfaces := range a.instances {
+		for _, iface := range interfaces {
+			instances.Add(instanceID, iface.DeepCopy())
+		}
+	}
+
+	return instances, nil
+}
+
+// GetVirtualNetworksAndSubnets returns all virtual networks and subnets
+func (a *API) GetVirtualNetworksAndSubnets(ctx context.Context) (types.VirtualNetworkMap, types.SubnetMap, error) {
+	a.rateLimit()
+	a.simulateDelay(GetVirtualNetworksAndSubnets)
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if err, ok := a.errors[GetVirtualNetworksAndSubnets]; ok {
+		return nil, nil, err
+	}
+
+	vnets := types.VirtualNetworkMap{}
+	for _, v := range a.vnets {
+		vnetCopy := *v
+		vnets[v.ID] = &vnetCopy
+	}
+
+	subnets := types.SubnetMap{}
+	for _, s := range a.subnets {
+		subnetCopy := *s
+		subnets[s.ID] = &subnetCopy
+	}
+
+	return vnets, subnets, nil
+}
+
+func (a *API) findInterfaceLocked(interfaceID string) *v2.AzureInterface {
+	for _, interfaces := range a.instances {
+		if iface, ok := interfaces[interfaceID]; ok {
+			return iface
+		}
+	}
+	return nil
+}
+
+// AssignPrivateIpAddresses adds addresses IP configurations in the subnet
+// to the interface and returns the assigned IPs
+func (a *API) AssignPrivateIpAddresses(ctx context.Context, subnetID, interfaceID string, addresses int) ([]string, error) {
+	a.rateLimit()
+	a.simulateDelay(AssignPrivateIpAddresses)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err, ok := a.errors[AssignPrivateIpAddresses]; ok {
+		return nil, err
+	}
+
+	iface := a.findInterfaceLocked(interfaceID)
+	if iface == nil {
+		return nil, fmt.Errorf("interface %s not found", interfaceID)
+	}
+
+	if len(iface.Addresses)+addresses > types.InterfaceAddressLimit {
+		return nil, fmt.Errorf("interface %s cannot hold %d additional IP configurations", interfaceID, addresses)
+	}
+
+	subnet, ok := a.subnets[subnetID]
+	if !ok {
+		return nil, fmt.Errorf("subnet %s not found", subnetID)
+	}
+
+	if addresses > subnet.AvailableAddresses {
+		return nil, fmt.Errorf("subnet %s has not enough addresses available", subnetID)
+	}
+
+	allocator, ok := a.allocators[subnetID]
+	if !ok {
+		return nil, fmt.Errorf("subnet %s has no valid CIDR", subnetID)
+	}
+
+	ips := make([]string, 0, addresses)
+	for i := 0; i < addresses; i++ {
+		ip, err := allocator.AllocateNext()
+		if err != nil {
+			return nil, fmt.Errorf("unable to allocate IP from subnet %s: %s", subnetID, err)
+		}
+		ips = append(ips, ip.String())
+		iface.Addresses = append(iface.Addresses, v2.AzureAddress{
+			IP:     ip.String(),
+			Subnet: subnetID,
+			State:  types.StateSucceeded,
+		})
+	}
+	subnet.AvailableAddresses -= addresses
+
+	return ips, nil
+}
+
+// ReleasePrivateIpAddresses removes the IP configurations of the given IPs
+// from the interface
+func (a *API) ReleasePrivateIpAddresses(ctx context.Context, interfaceID string, ips []string) error {
+	a.rateLimit()
+	a.simulateDelay(ReleasePrivateIpAddresses)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err, ok := a.errors[ReleasePrivateIpAddresses]; ok {
+		return err
+	}
+
+	iface := a.findInterfaceLocked(interfaceID)
+	if iface == nil {
+		return fmt.Errorf("interface %s not found", interfaceID)
+	}
+
+	toRelease := map[string]struct{}{}
+	for _, ip := range ips {
+		toRelease[ip] = struct{}{}
+	}
+
+	var remaining, released []v2.AzureAddress
+	for _, address := range iface.Addresses {
+		if _, ok := toRelease[address.IP]; !ok {
+			remaining = append(remaining, address)
+			continue
+		}
+		if address.Primary {
+			return fmt.Errorf("primary IP %s of interface %s cannot be released", address.IP, interfaceID)
+		}
+		released = append(released, address)
+	}
+
+	if len(released) != len(toRelease) {
+		return fmt.Errorf("%d IPs are not assigned to interface %s", len(toRelease)-len(released), interfaceID)
+	}
+
+	for _, address := range released {
+		if allocator, ok := a.allocators[address.Subnet]; ok {
+			allocator.Release(net.ParseIP(address.IP))
+		}
+		if subnet, ok := a.subnets[address.Subnet]; ok {
+			subnet.AvailableAddresses++
+		}
+	}
+	iface.Addresses = remaining
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/pkg/azure/types"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type MockSuite struct{}
+
+var _ = check.Suite(&MockSuite{})
+
+func (e *MockSuite) TestMock(c *check.C) {
+	api := NewAPI([]*types.Subnet{{ID: "s-1", CIDR: "10.0.0.0/24", AvailableAddresses: 100}}, []*types.VirtualNetwork{{ID: "v-1"}})
+	c.Assert(api, check.Not(check.IsNil))
+
+	instances := types.InstanceMap{}
+	instances.Add("vm-1", &v2.AzureInterface{
+		ID:        "nic-1",
+		Name:      "eth0",
+		Addresses: []v2.AzureAddress{{IP: "10.0.0.1", Subnet: "s-1", State: types.StateSucceeded, Primary: true}},
+	})
+	api.UpdateInstances(instances)
+
+	ips, err := api.AssignPrivateIpAddresses(context.TODO(), "s-1", "nic-1", 2)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(ips), check.Equals, 2)
+	c.Assert(ips[0], check.Not(check.Equals), "10.0.0.1")
+	c.Assert(ips[1], check.Not(check.Equals), "10.0.0.1")
+
+	_, subnets, err := api.GetVirtualNetworksAndSubnets(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(subnets["s-1"].AvailableAddresses, check.Equals, 98)
+
+	instances, err = api.GetInstances(context.TODO(), subnets)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(instances.Get("vm-1")[0].Addresses), check.Equals, 3)
+
+	_, err = api.AssignPrivateIpAddresses(context.TODO(), "s-1", "nic-2", 2)
+	c.Assert(err, check.Not(check.IsNil))
+	_, err = api.AssignPrivateIpAddresses(context.TODO(), "s-1", "nic-1", 200)
+	c.Assert(err, check.Not(check.IsNil))
+
+	// The primary IP and unknown IPs cannot be released
+	c.Assert(api.ReleasePrivateIpAddresses(context.TODO(), "nic-1", []string{"10.0.0.1"}), check.Not(check.IsNil))
+	c.Assert(api.ReleasePrivateIpAddresses(context.TODO(), "nic-1", []string{"10.0.0.9"}), check.Not(check.IsNil))
+
+	err = api.ReleasePrivateIpAddresses(context.TODO(), "nic-1", ips[:1])
+	c.Assert(err, check.IsNil)
+
+	instances, err = api.GetInstances(context.TODO(), subnets)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(instances.Get("vm-1")[0].Addresses), check.Equals, 2)
+
+	_, subnets, err = api.GetVirtualNetworksAndSubnets(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(subnets["s-1"].AvailableAddresses, check.Equals, 99)
+}
+
+func (e *MockSuite) TestSetMockError(c *check.C) {
+	api := NewAPI([]*types.Subnet{}, []*types.VirtualNetwork{})
+	c.Assert(api, check.Not(check.IsNil))
+
+	mockError := errors.New("error")
+
+	api.SetMockError(GetInstances, mockError)
+	_, err := api.GetInstances(context.TODO(), nil)
+	c.Assert(err, check.Equals, mockError)
+
+	api.SetMockError(GetVirtualNetworksAndSubnets, mockError)
+	_, _, err = api.GetVirtualNetworksAndSubnets(context.TODO())
+	c.Assert(err, check.Equals, mockError)
+
+	api.SetMockError(AssignPrivateIpAddresses, mockError)
+	_, err = api.AssignPrivateIpAddresses(context.TODO(), "s-1", "nic-1", 10)
+	c.Assert(err, check.Equals, mockError)
+
+	api.SetMockError(ReleasePrivateIpAddresses, mockError)
+	err = api.ReleasePrivateIpAddresses(context.TODO(), "nic-1", []string{"10.0.0.2"})
+	c.Assert(err, check.Equals, mockError)
+}
+
+func (e *MockSuite) TestSetDelay(c *check.C) {
+	api := NewAPI([]*types.Subnet{}, []*types.VirtualNetwork{})
+	c.Assert(api, check.Not(check.IsNil))
+
+	api.SetDelay(AllOperations, time.Second)
+	c.Assert(api.delays[GetInstances], check.Equals, time.Second)
+	c.Assert(api.delays[GetVirtualNetworksAndSubnets], check.Equals, time.Second)
+	c.Assert(api.delays[AssignPrivateIpAddresses], check.Equals, time.Second)
+	c.Assert(api.delays[ReleasePrivateIpAddresses], check.Equals, time.Second)
+}
+
+func (e *MockSuite) TestSetLimiter(c *check.C) {
+	api := NewAPI([]*types.Subnet{{ID: "s-1", AvailableAddresses: 100}}, []*types.VirtualNetwork{{ID: "v-1"}})
+	c.Assert(api, check.Not(check.IsNil))
+
+	api.SetLimiter(10.0, 2)
+	_, _, err := api.GetVirtualNetworksAndSubnets(context.TODO())
+	c.Assert(err, check.IsNil)
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"time"
+
+	"github.com/cilium/cilium/pkg/azure/types"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+
+	"github.com/sirupsen/logrus"
+)
+
+type azureAPI interface {
+	GetInstances(ctx context.Context, subnets types.SubnetMap) (types.InstanceMap, error)
+	GetVirtualNetworksAndSubnets(ctx context.Context) (types.VirtualNetworkMap, types.SubnetMap, error)
+	AssignPrivateIpAddresses(ctx context.Context, subnetID, interfaceID string, addresses int) ([]string, error)
+	ReleasePrivateIpAddresses(ctx context.Context, interfaceID string, ips []string) error
+}
+
+// InstancesManager maintains the list of instances. It must be kept up to date
+// by calling Resync() regularly. It implements nodepool.Provider, the Azure
+// identity of a node is derived from Spec.Azure of the CiliumNode resource.
+type InstancesManager struct {
+	mutex     lock.RWMutex
+	instances types.InstanceMap
+	subnets   types.SubnetMap
+	vnets     types.VirtualNetworkMap
+	api       azureAPI
+}
+
+// NewInstancesManager returns a new instances manager
+func NewInstancesManager(api azureAPI) *InstancesManager {
+	return &InstancesManager{
+		instances: types.InstanceMap{},
+		subnets:   types.SubnetMap{},
+		vnets:     types.VirtualNetworkMap{},
+		api:       api,
+	}
+}
+
+// GetSubnet returns the subnet by subnet ID
+//
+// The returned subnet is immutable so it can be safely accessed
+func (m *InstancesManager) GetSubnet(subnetID string) *types.Subnet {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.subnets[subnetID]
+}
+
+// GetInterfaces returns the list of interfaces associated with a particular
+// instance sorted by interface name
+func (m *InstancesManager) GetInterfaces(instanceID string) []*v2.AzureInterface {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.instances.Get(instanceID)
+}
+
+// UpdateInterface updates the definition of an interface for a particular
+// instance. If the interface is already known, the definition is updated,
+// otherwise the interface is added to the instance.
+func (m *InstancesManager) UpdateInterface(instanceID string, iface *v2.AzureInterface) {
+	m.mutex.Lock()
+	m.instances.Update(instanceID, iface)
+	m.mutex.Unlock()
+}
+
+// Resync fetches the list of Azure instances, interfaces and subnets and
+// updates the local cache in the instanceManager. It returns the time when
+// the resync has started or time.Time{} if it did not complete.
+func (m *InstancesManager) Resync(ctx context.Context) time.Time {
+	resyncStart := time.Now()
+
+	vnets, subnets, err := m.api.GetVirtualNetworksAndSubnets(ctx)
+	if err != nil {
+		log.WithError(err).Warning("Unable to synchronize Azure virtual network and subnet list")
+		return time.Time{}
+	}
+
+	instances, err := m.api.GetInstances(ctx, subnets)
+	if err != nil {
+		log.WithError(err).Warning("Unable to synchronize Azure instance list")
+		return time.Time{}
+	}
+
+	log.WithFields(logrus.Fields{
+		"numInstances":       len(instances),
+		"numVirtualNetworks": len(vnets),
+		"numSubnets":         len(subnets),
+	}).Info("Synchronized Azure IPAM information")
+
+	m.mutex.Lock()
+	m.instances = instances
+	m.subnets = subnets
+	m.vnets = vnets
+	m.mutex.Unlock()
+
+	return resyncStart
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package ipam
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/pkg/azure/api/mock"
+	"github.com/cilium/cilium/pkg/azure/types"
+	"github.com/cilium/cilium/pkg/ipam/nodepool"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type IPAMSuite struct{}
+
+var _ = check.Suite(&IPAMSuite{})
+
+var _ nodepool.Provider = &InstancesManager{}
+
+var (
+	testSubnets = []*types.Subnet{
+		{ID: "subnet-1", CIDR: "10.0.0.0/16", VirtualNetworkID: "vnet-1", AvailableAddresses: 65534},
+		{ID: "subnet-2", CIDR: "10.1.0.0/29", VirtualNetworkID: "vnet-1", AvailableAddresses: 2},
+	}
+	testVnets = []*types.VirtualNetwork{{ID: "vnet-1", PrimaryCIDR: "10.0.0.0/8"}}
+)
+
+// newTestAPI returns a mock API with instance vm-1 attached to subnet-1 via
+// two interfaces and instance vm-2 attached to subnet-2
+func newTestAPI() *mock.API {
+	subnets := make([]*types.Subnet, 0, len(testSubnets))
+	for _, s := range testSubnets {
+		subnetCopy := *s
+		subnets = append(subnets, &subnetCopy)
+	}
+
+	api := mock.NewAPI(subnets, testVnets)
+
+	instances := types.InstanceMap{}
+	instances.Add("vm-1", &v2.AzureInterface{
+		ID:    "nic-1",
+		Name:  "eth0",
+		State: types.StateSucceeded,
+		Addresses: []v2.AzureAddress{
+			{IP: "10.0.0.4", Subnet: "subnet-1", State: types.StateSucceeded, Primary: true},
+			{IP: "10.0.0.5", Subnet: "subnet-1", State: types.StateSucceeded},
+			{IP: "10.0.0.6", Subnet: "subnet-1", State: "updating"},
+		},
+	})
+	instances.Add("vm-1", &v2.AzureInterface{
+		ID:    "nic-2",
+		Name:  "eth1",
+		State: types.StateSucceeded,
+		Addresses: []v2.AzureAddress{
+			{IP: "10.0.1.4", Subnet: "subnet-1", State: types.StateSucceeded, Primary: true},
+		},
+	})
+	instances.Add("vm-2", &v2.AzureInterface{
+		ID:    "nic-3",
+		Name:  "eth0",
+		State: types.StateSucceeded,
+		Addresses: []v2.AzureAddress{
+			{IP: "10.1.0.1", Subnet: "subnet-2", State: types.StateSucceeded, Primary: true},
+		},
+	})
+	api.UpdateInstances(instances)
+
+	return api
+}
+
+func (e *IPAMSuite) TestResync(c *check.C) {
+	api := newTestAPI()
+	mngr := NewInstancesManager(api)
+	c.Assert(len(mngr.GetInterfaces("vm-1")), check.Equals, 0)
+
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	interfaces := mngr.GetInterfaces("vm-1")
+	c.Assert(len(interfaces), check.Equals, 2)
+	c.Assert(interfaces[0].ID, check.Equals, "nic-1")
+	c.Assert(interfaces[1].ID, check.Equals, "nic-2")
+	c.Assert(len(mngr.GetInterfaces("vm-2")), check.Equals, 1)
+	c.Assert(mngr.GetSubnet("subnet-2").AvailableAddresses, check.Equals, 2)
+	c.Assert(mngr.GetSubnet("subnet-3"), check.IsNil)
+
+	// A failed resync keeps the existing state
+	api.SetMockError(mock.GetInstances, errors.New("error"))
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, true)
+	c.Assert(len(mngr.GetInterfaces("vm-1")), check.Equals, 2)
+
+	api.SetMockError(mock.GetVirtualNetworksAndSubnets, errors.New("error"))
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, true)
+	c.Assert(mngr.GetSubnet("subnet-1"), check.Not(check.IsNil))
+}
+
+func (e *IPAMSuite) TestUpdateInterface(c *check.C) {
+	mngr := NewInstancesManager(newTestAPI())
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	mngr.UpdateInterface("vm-2", &v2.AzureInterface{ID: "nic-4", Name: "eth1"})
+	c.Assert(len(mngr.GetInterfaces("vm-2")), check.Equals, 2)
+
+	mngr.UpdateInterface("vm-3", &v2.AzureInterface{ID: "nic-5", Name: "eth0"})
+	c.Assert(len(mngr.GetInterfaces("vm-3")), check.Equals, 1)
+
+	// Interfaces added locally are replaced on resync
+	c.Assert(mngr.Resync(context.TODO()).After(time.Time{}), check.Equals, true)
+	c.Assert(len(mngr.GetInterfaces("vm-2")), check.Equals, 1)
+	c.Assert(len(mngr.GetInterfaces("vm-3")), check.Equals, 0)
+}
@@ -0,0 +1,216 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cilium/cilium/pkg/azure/types"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+
+	"github.com/sirupsen/logrus"
+)
+
+// selectInterface returns the interface of the instance on which IPs are
+// allocated or nil if the instance has no such interface
+func (m *InstancesManager) selectInterface(resource *v2.CiliumNode) *v2.AzureInterface {
+	for _, iface := range m.GetInterfaces(resource.Spec.Azure.InstanceID) {
+		if resource.Spec.Azure.InterfaceName == "" || iface.Name == resource.Spec.Azure.InterfaceName {
+			return iface
+		}
+	}
+	return nil
+}
+
+// primarySubnet returns the subnet of the primary IP configuration of the
+// interface. Secondary IPs are allocated out of this subnet.
+func primarySubnet(iface *v2.AzureInterface) string {
+	for _, address := range iface.Addresses {
+		if address.Primary {
+			return address.Subnet
+		}
+	}
+	if len(iface.Addresses) > 0 {
+		return iface.Addresses[0].Subnet
+	}
+	return ""
+}
+
+// availableOnInterface returns the number of IPs which can still be
+// allocated on the interface out of the subnet, limited by both the maximum
+// number of IP configurations per interface and the addresses left in the
+// subnet
+func availableOnInterface(iface *v2.AzureInterface, subnet *types.Subnet) int {
+	available := types.InterfaceAddressLimit - len(iface.Addresses)
+	if subnet.AvailableAddresses < available {
+		available = subnet.AvailableAddresses
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// GetPool returns all secondary IPs of the interfaces of the node which have
+// been provisioned successfully. The Resource field refers to the ID of the
+// interface.
+func (m *InstancesManager) GetPool(resource *v2.CiliumNode) map[string]v2.AllocationIP {
+	pool := map[string]v2.AllocationIP{}
+
+	for _, iface := range m.GetInterfaces(resource.Spec.Azure.InstanceID) {
+		if resource.Spec.Azure.InterfaceName != "" && iface.Name != resource.Spec.Azure.InterfaceName {
+			continue
+		}
+
+		for _, address := range iface.Addresses {
+			if address.Primary || address.State != types.StateSucceeded {
+				continue
+			}
+			pool[address.IP] = v2.AllocationIP{Resource: iface.ID}
+		}
+	}
+
+	return pool
+}
+
+// AllocateIPs assigns up to toAllocate secondary IPs to the interface of the
+// node
+func (m *InstancesManager) AllocateIPs(ctx context.Context, resource *v2.CiliumNode, toAllocate int) (int, error) {
+	instanceID := resource.Spec.Azure.InstanceID
+
+	if types.IsScaleSetInstance(instanceID) {
+		return 0, fmt.Errorf("instance %s is a virtual machine scale set instance, scale sets are not supported", instanceID)
+	}
+
+	iface := m.selectInterface(resource)
+	if iface == nil {
+		return 0, fmt.Errorf("no interface found for instance %s", instanceID)
+	}
+
+	subnetID := primarySubnet(iface)
+	subnet := m.GetSubnet(subnetID)
+	if subnet == nil {
+		return 0, fmt.Errorf("subnet %s of interface %s not found", subnetID, iface.ID)
+	}
+
+	scopedLog := log.WithFields(logrus.Fields{
+		fieldInstanceID:  instanceID,
+		fieldInterfaceID: iface.ID,
+		"subnetID":       subnetID,
+	})
+
+	if available := availableOnInterface(iface, subnet); available < toAllocate {
+		scopedLog.WithFields(logrus.Fields{
+			"requested": toAllocate,
+			"available": available,
+		}).Warning("Unable to allocate all requested IPs, interface or subnet capacity exhausted")
+		toAllocate = available
+	}
+
+	if toAllocate == 0 {
+		return 0, nil
+	}
+
+	ips, err := m.api.AssignPrivateIpAddresses(ctx, subnetID, iface.ID, toAllocate)
+	if err != nil {
+		return 0, fmt.Errorf("unable to assign %d IPs to interface %s: %s", toAllocate, iface.ID, err)
+	}
+
+	scopedLog.WithField("ips", ips).Debug("Assigned IPs to interface")
+
+	for _, ip := range ips {
+		iface.Addresses = append(iface.Addresses, v2.AzureAddress{
+			IP:     ip,
+			Subnet: subnetID,
+			State:  types.StateSucceeded,
+		})
+	}
+
+	m.mutex.Lock()
+	m.instances.Update(instanceID, iface)
+	if s, ok := m.subnets[subnetID]; ok {
+		subnetCopy := *s
+		subnetCopy.AvailableAddresses -= len(ips)
+		m.subnets[subnetID] = &subnetCopy
+	}
+	m.mutex.Unlock()
+
+	return len(ips), nil
+}
+
+// ReleaseIPs unassigns the given secondary IPs from the interfaces of the
+// node
+func (m *InstancesManager) ReleaseIPs(ctx context.Context, resource *v2.CiliumNode, ips []string) error {
+	instanceID := resource.Spec.Azure.InstanceID
+	interfaces := map[string]*v2.AzureInterface{}
+	byInterface := map[string][]string{}
+
+	pool := m.GetPool(resource)
+	for _, ip := range ips {
+		allocationIP, ok := pool[ip]
+		if !ok {
+			return fmt.Errorf("IP %s is not a secondary IP of instance %s", ip, instanceID)
+		}
+		byInterface[allocationIP.Resource] = append(byInterface[allocationIP.Resource], ip)
+	}
+
+	for _, iface := range m.GetInterfaces(instanceID) {
+		interfaces[iface.ID] = iface
+	}
+
+	interfaceIDs := make([]string, 0, len(byInterface))
+	for interfaceID := range byInterface {
+		interfaceIDs = append(interfaceIDs, interfaceID)
+	}
+	sort.Strings(interfaceIDs)
+
+	for _, interfaceID := range interfaceIDs {
+		toRelease := byInterface[interfaceID]
+		if err := m.api.ReleasePrivateIpAddresses(ctx, interfaceID, toRelease); err != nil {
+			return fmt.Errorf("unable to release %d IPs from interface %s: %s", len(toRelease), interfaceID, err)
+		}
+
+		released := map[string]struct{}{}
+		for _, ip := range toRelease {
+			released[ip] = struct{}{}
+		}
+
+		iface := interfaces[interfaceID]
+		remaining := make([]v2.AzureAddress, 0, len(iface.Addresses))
+		for _, address := range iface.Addresses {
+			if _, ok := released[address.IP]; !ok {
+				remaining = append(remaining, address)
+			}
+		}
+		iface.Addresses = remaining
+
+		m.UpdateInterface(instanceID, iface)
+	}
+
+	return nil
+}
+
+// PopulateStatusFields populates Status.Azure with the interfaces of the
+// node
+func (m *InstancesManager) PopulateStatusFields(resource *v2.CiliumNode) {
+	interfaces := m.GetInterfaces(resource.Spec.Azure.InstanceID)
+
+	resource.Status.Azure.Interfaces = make([]v2.AzureInterface, 0, len(interfaces))
+	for _, iface := range interfaces {
+		resource.Status.Azure.Interfaces = append(resource.Status.Azure.Interfaces, *iface)
+	}
+}
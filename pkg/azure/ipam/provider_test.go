@@ -0,0 +1,204 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package ipam
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cilium/cilium/pkg/azure/api/mock"
+	"github.com/cilium/cilium/pkg/ipam/nodepool"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/testutils"
+
+	"gopkg.in/check.v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type k8sMock struct {
+	mutex lock.Mutex
+	nodes map[string]*v2.CiliumNode
+}
+
+func newK8sMock() *k8sMock {
+	return &k8sMock{nodes: map[string]*v2.CiliumNode{}}
+}
+
+func (k *k8sMock) Update(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.nodes[node.Name] = node.DeepCopy()
+	return node, nil
+}
+
+func (k *k8sMock) UpdateStatus(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
+	return k.Update(node, origNode)
+}
+
+func (k *k8sMock) Get(name string) (*v2.CiliumNode, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if node, ok := k.nodes[name]; ok {
+		return node.DeepCopy(), nil
+	}
+	return &v2.CiliumNode{}, nil
+}
+
+func (k *k8sMock) get(name string) *v2.CiliumNode {
+	node, _ := k.Get(name)
+	return node
+}
+
+func newCiliumNode(name, instanceID, interfaceName string, preAllocate int) *v2.CiliumNode {
+	return &v2.CiliumNode{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v2.NodeSpec{
+			Azure: v2.AzureSpec{
+				InstanceID:    instanceID,
+				InterfaceName: interfaceName,
+			},
+			IPAM: v2.IPAMSpec{
+				PreAllocate: preAllocate,
+			},
+		},
+	}
+}
+
+func (e *IPAMSuite) TestGetPool(c *check.C) {
+	mngr := NewInstancesManager(newTestAPI())
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	// The primary IP and IPs which are still being provisioned are not
+	// part of the pool
+	pool := mngr.GetPool(newCiliumNode("node1", "vm-1", "", 0))
+	c.Assert(pool, check.DeepEquals, map[string]v2.AllocationIP{
+		"10.0.0.5": {Resource: "nic-1"},
+	})
+
+	c.Assert(len(mngr.GetPool(newCiliumNode("node1", "vm-1", "eth1", 0))), check.Equals, 0)
+	c.Assert(len(mngr.GetPool(newCiliumNode("node3", "vm-3", "", 0))), check.Equals, 0)
+}
+
+func (e *IPAMSuite) TestAllocateIPs(c *check.C) {
+	mngr := NewInstancesManager(newTestAPI())
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	// The first interface is used if no interface name is specified
+	allocated, err := mngr.AllocateIPs(context.TODO(), newCiliumNode("node1", "vm-1", "", 0), 4)
+	c.Assert(err, check.IsNil)
+	c.Assert(allocated, check.Equals, 4)
+	c.Assert(len(mngr.GetPool(newCiliumNode("node1", "vm-1", "eth0", 0))), check.Equals, 5)
+	c.Assert(mngr.GetSubnet("subnet-1").AvailableAddresses, check.Equals, 65530)
+
+	allocated, err = mngr.AllocateIPs(context.TODO(), newCiliumNode("node1", "vm-1", "eth1", 0), 2)
+	c.Assert(err, check.IsNil)
+	c.Assert(allocated, check.Equals, 2)
+	for _, allocationIP := range mngr.GetPool(newCiliumNode("node1", "vm-1", "eth1", 0)) {
+		c.Assert(allocationIP.Resource, check.Equals, "nic-2")
+	}
+
+	// The allocation is limited by the addresses available in the subnet
+	allocated, err = mngr.AllocateIPs(context.TODO(), newCiliumNode("node2", "vm-2", "", 0), 8)
+	c.Assert(err, check.IsNil)
+	c.Assert(allocated, check.Equals, 2)
+	allocated, err = mngr.AllocateIPs(context.TODO(), newCiliumNode("node2", "vm-2", "", 0), 8)
+	c.Assert(err, check.IsNil)
+	c.Assert(allocated, check.Equals, 0)
+
+	_, err = mngr.AllocateIPs(context.TODO(), newCiliumNode("node1", "vm-1", "eth2", 0), 2)
+	c.Assert(err, check.Not(check.IsNil))
+	_, err = mngr.AllocateIPs(context.TODO(), newCiliumNode("node3", "vm-3", "", 0), 2)
+	c.Assert(err, check.Not(check.IsNil))
+
+	// Scale set instances are rejected
+	_, err = mngr.AllocateIPs(context.TODO(), newCiliumNode("node4", "/subscriptions/xxx/resourceGroups/g1/providers/Microsoft.Compute/virtualMachineScaleSets/vmss1/virtualMachines/0", "", 0), 2)
+	c.Assert(err, check.ErrorMatches, ".*scale sets are not supported")
+}
+
+func (e *IPAMSuite) TestAllocateIPsInterfaceLimit(c *check.C) {
+	mngr := NewInstancesManager(newTestAPI())
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	// eth0 of vm-1 has 3 IP configurations
+	allocated, err := mngr.AllocateIPs(context.TODO(), newCiliumNode("node1", "vm-1", "eth0", 0), 300)
+	c.Assert(err, check.IsNil)
+	c.Assert(allocated, check.Equals, 253)
+	c.Assert(len(mngr.GetInterfaces("vm-1")[0].Addresses), check.Equals, 256)
+}
+
+func (e *IPAMSuite) TestReleaseIPs(c *check.C) {
+	api := newTestAPI()
+	mngr := NewInstancesManager(api)
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	cn := newCiliumNode("node1", "vm-1", "", 0)
+	c.Assert(mngr.ReleaseIPs(context.TODO(), cn, []string{"10.0.0.4"}), check.Not(check.IsNil))
+	c.Assert(mngr.ReleaseIPs(context.TODO(), cn, []string{"10.0.0.6"}), check.Not(check.IsNil))
+
+	api.SetMockError(mock.ReleasePrivateIpAddresses, errors.New("error"))
+	c.Assert(mngr.ReleaseIPs(context.TODO(), cn, []string{"10.0.0.5"}), check.Not(check.IsNil))
+	c.Assert(len(mngr.GetPool(cn)), check.Equals, 1)
+
+	api.SetMockError(mock.ReleasePrivateIpAddresses, nil)
+	c.Assert(mngr.ReleaseIPs(context.TODO(), cn, []string{"10.0.0.5"}), check.IsNil)
+	c.Assert(len(mngr.GetPool(cn)), check.Equals, 0)
+
+	// The release is reflected in the API
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+	c.Assert(len(mngr.GetPool(cn)), check.Equals, 0)
+	c.Assert(len(mngr.GetInterfaces("vm-1")[0].Addresses), check.Equals, 2)
+}
+
+func (e *IPAMSuite) TestPopulateStatusFields(c *check.C) {
+	mngr := NewInstancesManager(newTestAPI())
+	c.Assert(mngr.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	cn := newCiliumNode("node1", "vm-1", "", 0)
+	mngr.PopulateStatusFields(cn)
+	c.Assert(len(cn.Status.Azure.Interfaces), check.Equals, 2)
+	c.Assert(cn.Status.Azure.Interfaces[0].ID, check.Equals, "nic-1")
+	c.Assert(len(cn.Status.Azure.Interfaces[0].Addresses), check.Equals, 3)
+	c.Assert(cn.Status.Azure.Interfaces[1].ID, check.Equals, "nic-2")
+}
+
+// TestNodeManager tests the Azure provider in combination with the node pool
+// manager
+func (e *IPAMSuite) TestNodeManager(c *check.C) {
+	k8sapi := newK8sMock()
+	instances := NewInstancesManager(newTestAPI())
+	c.Assert(instances.Resync(context.TODO()).IsZero(), check.Equals, false)
+
+	mngr, err := nodepool.NewNodeManager(instances, k8sapi, nil, 10, false)
+	c.Assert(err, check.IsNil)
+
+	mngr.Update(newCiliumNode("node1", "vm-1", "", 8))
+	c.Assert(testutils.WaitUntil(func() bool {
+		node := mngr.Get("node1")
+		return node != nil && len(node.Pool()) == 8
+	}, 5*time.Second), check.IsNil)
+
+	c.Assert(testutils.WaitUntil(func() bool {
+		cn := k8sapi.get("node1")
+		return len(cn.Spec.IPAM.Pool) == 8 && len(cn.Status.Azure.Interfaces) == 2
+	}, 5*time.Second), check.IsNil)
+
+	for _, allocationIP := range k8sapi.get("node1").Spec.IPAM.Pool {
+		c.Assert(allocationIP.Resource, check.Equals, "nic-1")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metadata retrieves the identity of the Azure virtual machine from
+// the Azure instance metadata service
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const metadataURL = "http://169.254.169.254/metadata/instance?api-version=2019-06-01"
+
+type instanceMetadata struct {
+	Compute struct {
+		ResourceID        string `json:"resourceId"`
+		SubscriptionID    string `json:"subscriptionId"`
+		ResourceGroupName string `json:"resourceGroupName"`
+	} `json:"compute"`
+}
+
+// GetInstanceMetadata returns the resource ID of the virtual machine in
+// lower case, as well as the subscription and resource group it belongs to
+func GetInstanceMetadata() (instanceID, subscriptionID, resourceGroup string, err error) {
+	req, err := http.NewRequest(http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Metadata", "true")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("unable to retrieve instance metadata from metadata service: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unable to retrieve instance metadata from metadata service: %s", resp.Status)
+		return
+	}
+
+	var metadata instanceMetadata
+	if err = json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		err = fmt.Errorf("unable to parse instance metadata: %s", err)
+		return
+	}
+
+	instanceID = strings.ToLower(metadata.Compute.ResourceID)
+	subscriptionID = metadata.Compute.SubscriptionID
+	resourceGroup = metadata.Compute.ResourceGroupName
+	return
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+)
+
+const (
+	// StateSucceeded is the provisioning state of a network interface or
+	// IP configuration which has been successfully provisioned
+	StateSucceeded = "succeeded"
+
+	// InterfaceAddressLimit is the maximum number of IP configurations
+	// per network interface
+	//
+	// https://docs.microsoft.com/en-us/azure/azure-resource-manager/management/azure-subscription-service-limits#networking-limits
+	InterfaceAddressLimit = 256
+)
+
+// IsScaleSetInstance returns true if instanceID is the resource ID of a
+// virtual machine scale set instance. The network interfaces of scale set
+// instances are managed through the scale set and are not supported.
+func IsScaleSetInstance(instanceID string) bool {
+	return strings.Contains(strings.ToLower(instanceID), "/providers/microsoft.compute/virtualmachinescalesets/")
+}
+
+// Tags implements generic key value tags used by Azure
+type Tags map[string]string
+
+// Match returns true if the required tags are all found
+func (t Tags) Match(required Tags) bool {
+	for k, neededvalue := range required {
+		haveValue, ok := t[k]
+		if !ok || (ok && neededvalue != haveValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// Subnet is a representation of an Azure subnet
+type Subnet struct {
+	// ID is the Azure resource ID of the subnet
+	ID string
+
+	// Name is the subnet name
+	Name string
+
+	// CIDR is the CIDR associated with the subnet
+	CIDR string
+
+	// VirtualNetworkID is the ID of the virtual network the subnet is in
+	VirtualNetworkID string
+
+	// AvailableAddresses is the number of addresses available for
+	// allocation
+	AvailableAddresses int
+
+	// Tags is the tags of the subnet
+	Tags Tags
+}
+
+// VirtualNetwork is the representation of an Azure virtual network
+type VirtualNetwork struct {
+	// ID is the Azure resource ID of the virtual network
+	ID string
+
+	// PrimaryCIDR is the primary IPv4 CIDR
+	PrimaryCIDR string
+}
+
+// instance is the minimal representation of an Azure virtual machine as
+// needed by the IPAM provider
+type instance struct {
+	// interfaces is a map of all network interfaces attached to the
+	// instance indexed by the interface ID
+	interfaces map[string]*v2.AzureInterface
+}
+
+// InstanceMap is the list of all instances indexed by instance ID
+type InstanceMap map[string]*instance
+
+// Add adds an interface definition to the instance map. instanceMap may not
+// be subject to concurrent access while add() is used.
+func (m InstanceMap) Add(instanceID string, iface *v2.AzureInterface) {
+	i, ok := m[instanceID]
+	if !ok {
+		i = &instance{}
+		m[instanceID] = i
+	}
+
+	if i.interfaces == nil {
+		i.interfaces = map[string]*v2.AzureInterface{}
+	}
+
+	i.interfaces[iface.ID] = iface
+}
+
+// Update updates the definition of an interface for a particular instance.
+// If the interface is already known, the definition is updated, otherwise
+// the interface is added to the instance.
+func (m InstanceMap) Update(instanceID string, iface *v2.AzureInterface) {
+	if i, ok := m[instanceID]; ok {
+		i.interfaces[iface.ID] = iface
+	} else {
+		m.Add(instanceID, iface)
+	}
+}
+
+// Get returns the list of interfaces for a particular instance ID sorted by
+// interface name
+func (m InstanceMap) Get(instanceID string) (interfaces []*v2.AzureInterface) {
+	if instance, ok := m[instanceID]; ok {
+		for _, iface := range instance.interfaces {
+			interfaces = append(interfaces, iface.DeepCopy())
+		}
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool {
+		return interfaces[i].Name < interfaces[j].Name
+	})
+
+	return
+}
+
+// SubnetMap indexes Azure subnets by subnet ID
+type SubnetMap map[string]*Subnet
+
+// VirtualNetworkMap indexes Azure virtual networks by virtual network ID
+type VirtualNetworkMap map[string]*VirtualNetwork
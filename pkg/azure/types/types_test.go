@@ -0,0 +1,67 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package types
+
+import (
+	"testing"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type TypesSuite struct{}
+
+var _ = check.Suite(&TypesSuite{})
+
+func (b *TypesSuite) TestTagsMatch(c *check.C) {
+	c.Assert(Tags{"1": "1", "2": "2"}.Match(Tags{"1": "1"}), check.Equals, true)
+	c.Assert(Tags{"1": "1", "2": "2"}.Match(Tags{"2": "2"}), check.Equals, true)
+	c.Assert(Tags{"1": "1", "2": "2"}.Match(Tags{"3": "3"}), check.Equals, false)
+}
+
+func (b *TypesSuite) TestIsScaleSetInstance(c *check.C) {
+	c.Assert(IsScaleSetInstance("/subscriptions/xxx/resourceGroups/group1/providers/Microsoft.Compute/virtualMachineScaleSets/vmss1/virtualMachines/0"), check.Equals, true)
+	c.Assert(IsScaleSetInstance("/subscriptions/xxx/resourcegroups/group1/providers/microsoft.compute/virtualmachinescalesets/vmss1/virtualmachines/0"), check.Equals, true)
+	c.Assert(IsScaleSetInstance("/subscriptions/xxx/resourceGroups/group1/providers/Microsoft.Compute/virtualMachines/vm1"), check.Equals, false)
+	c.Assert(IsScaleSetInstance("vm-1"), check.Equals, false)
+}
+
+func (b *TypesSuite) TestInstanceMap(c *check.C) {
+	m := InstanceMap{}
+	m.Add("vm-1", &v2.AzureInterface{ID: "nic-2", Name: "eth1"})
+	m.Add("vm-1", &v2.AzureInterface{ID: "nic-1", Name: "eth0"})
+	m.Update("vm-2", &v2.AzureInterface{ID: "nic-3", Name: "eth0"})
+
+	interfaces := m.Get("vm-1")
+	c.Assert(len(interfaces), check.Equals, 2)
+	c.Assert(interfaces[0].ID, check.Equals, "nic-1")
+	c.Assert(interfaces[1].ID, check.Equals, "nic-2")
+
+	m.Update("vm-1", &v2.AzureInterface{ID: "nic-1", Name: "eth0", MAC: "00:11:22:33:44:55"})
+	c.Assert(m.Get("vm-1")[0].MAC, check.Equals, "00:11:22:33:44:55")
+	c.Assert(len(m.Get("vm-2")), check.Equals, 1)
+	c.Assert(len(m.Get("vm-3")), check.Equals, 0)
+
+	// The returned interfaces are copies
+	m.Get("vm-1")[0].MAC = ""
+	c.Assert(m.Get("vm-1")[0].MAC, check.Equals, "00:11:22:33:44:55")
+}
@@ -280,6 +280,20 @@ func FormatStatusResponse(w io.Writer, sr *models.StatusResponse, allAddresses,
 		}
 	}
 
+	if enc := sr.Encryption; enc != nil {
+		fmt.Fprintf(w, "Encryption:\t%s\t%s\n", enc.Mode, enc.Msg)
+		if r := enc.KeyRotation; r != nil {
+			fmt.Fprintf(w, "IPsec key rotation:\tKey ID %d -> %d, %d/%d nodes migrated\n",
+				r.PreviousSpi, r.CurrentSpi, r.MigratedNodes, r.MigratedNodes+int64(len(r.PendingNodes)))
+			if allNodes && len(r.PendingNodes) > 0 {
+				fmt.Fprintf(w, "Pending nodes:\n")
+				for _, name := range r.PendingNodes {
+					fmt.Fprintf(w, "  %s\n", name)
+				}
+			}
+		}
+	}
+
 	if sr.IPAM != nil {
 		fmt.Fprintf(w, "IPAM:\t%s\n", sr.IPAM.Status)
 		if allAddresses {
@@ -165,7 +165,13 @@ func (cm *ClusterMesh) remove(name string) {
 		cluster.onRemove()
 		delete(cm.clusters, name)
 
-		cm.globalServices.onClusterDelete(name)
+		// Re-evaluate the backend selection of all global services
+		// which had backends in the removed cluster
+		for _, svc := range cm.globalServices.onClusterDelete(name) {
+			if merger := cm.conf.ServiceMerger; merger != nil {
+				merger.MergeExternalServiceDelete(svc)
+			}
+		}
 	}
 	cm.mutex.Unlock()
 
@@ -0,0 +1,22 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mirror mirrors the state of a cluster which is stored in
+// Kubernetes custom resources, i.e. CiliumIdentity, CiliumEndpoint and
+// CiliumNode, as well as its global services into a kvstore. The keys are
+// written in the same layout as used by agents running with a kvstore, so
+// that agents of remote clusters can connect to the kvstore and consume the
+// state through the regular clustermesh code path, even if the mirrored
+// cluster itself runs without a kvstore.
+package mirror
@@ -0,0 +1,341 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+
+	endpointid "github.com/cilium/cilium/pkg/endpoint/id"
+	"github.com/cilium/cilium/pkg/identity"
+	"github.com/cilium/cilium/pkg/identity/cache"
+	"github.com/cilium/cilium/pkg/ipcache"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/kvstore"
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/logging"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/node"
+	nodeStore "github.com/cilium/cilium/pkg/node/store"
+	"github.com/cilium/cilium/pkg/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+var log = logging.DefaultLogger.WithField(logfields.LogSubsys, "clustermesh-mirror")
+
+// Mirror writes the state of a cluster into a kvstore. All keys written are
+// cached so that they can be removed when the corresponding resource is
+// deleted and re-created by Resync() if they disappear from the kvstore.
+type Mirror struct {
+	backend     kvstore.BackendOperations
+	clusterName string
+	clusterID   int
+
+	// mutex protects the following fields
+	mutex lock.Mutex
+
+	// keys maps all keys written to their value
+	keys map[string][]byte
+
+	// endpointKeys maps the namespace/name of each CiliumEndpoint to the
+	// keys written for it
+	endpointKeys map[string][]string
+
+	// ipOwners maps each IP key to the namespace/name of the CiliumEndpoint
+	// which last wrote it. An IP can be reused by a new endpoint before the
+	// endpoint previously using it is deleted, the key is then only removed
+	// on behalf of the new owner.
+	ipOwners map[string]string
+}
+
+// NewMirror returns a mirror writing into backend the state of the cluster
+// with the given name and ID
+func NewMirror(backend kvstore.BackendOperations, clusterName string, clusterID int) *Mirror {
+	return &Mirror{
+		backend:      backend,
+		clusterName:  clusterName,
+		clusterID:    clusterID,
+		keys:         map[string][]byte{},
+		endpointKeys: map[string][]string{},
+		ipOwners:     map[string]string{},
+	}
+}
+
+// prefixes returns the kvstore prefixes owned by the mirror
+func (m *Mirror) prefixes() []string {
+	return []string{
+		path.Join(cache.IdentitiesPath, "id") + "/",
+		path.Join(ipcache.IPIdentitiesPath, ipcache.AddressSpace) + "/",
+		path.Join(nodeStore.NodeStorePrefix, m.clusterName) + "/",
+		path.Join(service.ServiceStorePrefix, m.clusterName) + "/",
+	}
+}
+
+// upsert writes key into the kvstore and the cache. Must be called with
+// m.mutex held.
+func (m *Mirror) upsert(ctx context.Context, key string, value []byte) error {
+	m.keys[key] = value
+	_, err := m.backend.UpdateIfDifferent(ctx, key, value, true)
+	return err
+}
+
+// delete removes key from the kvstore and the cache. Must be called with
+// m.mutex held.
+func (m *Mirror) delete(key string) error {
+	if _, ok := m.keys[key]; !ok {
+		return nil
+	}
+	delete(m.keys, key)
+	return m.backend.Delete(key)
+}
+
+func identityKey(id string) string {
+	return path.Join(cache.IdentitiesPath, "id", id)
+}
+
+// UpsertIdentity mirrors the master key of the identity
+func (m *Mirror) UpsertIdentity(ctx context.Context, id *ciliumv2.CiliumIdentity) error {
+	if _, err := strconv.ParseUint(id.Name, 10, 32); err != nil {
+		return fmt.Errorf("invalid identity %q: %s", id.Name, err)
+	}
+
+	key := cache.GlobalIdentity{}.PutKeyFromMap(id.SecurityLabels)
+	value := []byte(m.backend.Encode([]byte(key.GetKey())))
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.upsert(ctx, identityKey(id.Name), value)
+}
+
+// DeleteIdentity removes the master key of the identity
+func (m *Mirror) DeleteIdentity(id *ciliumv2.CiliumIdentity) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.delete(identityKey(id.Name))
+}
+
+func endpointName(ep *ciliumv2.CiliumEndpoint) string {
+	return path.Join(ep.Namespace, ep.Name)
+}
+
+// endpointIPs returns the IP to identity mappings of the endpoint, nil if the
+// endpoint has no identity or no addresses yet
+func endpointIPs(ep *ciliumv2.CiliumEndpoint) []identity.IPIdentityPair {
+	status := &ep.Status
+	if status.Identity == nil || status.Networking == nil {
+		return nil
+	}
+
+	metadata := strings.Join([]string{endpointid.CiliumGlobalIdPrefix.String(), ipcache.AddressSpace,
+		status.Networking.NodeIP, strconv.FormatInt(status.ID, 10)}, ":")
+
+	var pairs []identity.IPIdentityPair
+	for _, pair := range status.Networking.Addressing {
+		for _, addr := range []string{pair.IPV4, pair.IPV6} {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				continue
+			}
+			pairs = append(pairs, identity.IPIdentityPair{
+				IP:       ip,
+				HostIP:   net.ParseIP(status.Networking.NodeIP),
+				ID:       identity.NumericIdentity(status.Identity.ID),
+				Key:      uint8(status.Encryption.Key),
+				Metadata: metadata,
+			})
+		}
+	}
+	return pairs
+}
+
+// UpsertEndpoint mirrors the IP to identity mappings of the endpoint. Keys
+// of addresses no longer used by the endpoint are removed.
+func (m *Mirror) UpsertEndpoint(ctx context.Context, ep *ciliumv2.CiliumEndpoint) error {
+	name := endpointName(ep)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var keys []string
+	for _, pair := range endpointIPs(ep) {
+		value, err := json.Marshal(pair)
+		if err != nil {
+			return err
+		}
+		key := path.Join(ipcache.IPIdentitiesPath, ipcache.AddressSpace, pair.IP.String())
+		if err := m.upsert(ctx, key, value); err != nil {
+			return err
+		}
+		m.ipOwners[key] = name
+		keys = append(keys, key)
+	}
+
+	var err error
+	for _, old := range m.endpointKeys[name] {
+		if !containsString(keys, old) {
+			if err2 := m.deleteEndpointKey(name, old); err2 != nil {
+				err = err2
+			}
+		}
+	}
+
+	if len(keys) > 0 {
+		m.endpointKeys[name] = keys
+	} else {
+		delete(m.endpointKeys, name)
+	}
+
+	return err
+}
+
+// DeleteEndpoint removes the IP to identity mappings of the endpoint
+func (m *Mirror) DeleteEndpoint(ep *ciliumv2.CiliumEndpoint) error {
+	name := endpointName(ep)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var err error
+	for _, key := range m.endpointKeys[name] {
+		if err2 := m.deleteEndpointKey(name, key); err2 != nil {
+			err = err2
+		}
+	}
+	delete(m.endpointKeys, name)
+	return err
+}
+
+// deleteEndpointKey removes the IP key written for the endpoint with the
+// given namespace/name, unless the IP has since been taken over by another
+// endpoint. Must be called with m.mutex held.
+func (m *Mirror) deleteEndpointKey(name, key string) error {
+	if m.ipOwners[key] != name {
+		return nil
+	}
+	delete(m.ipOwners, key)
+	return m.delete(key)
+}
+
+func (m *Mirror) parseNode(cn *ciliumv2.CiliumNode) node.Node {
+	n := node.ParseCiliumNode(cn)
+	n.Cluster = m.clusterName
+	n.ClusterID = m.clusterID
+	return n
+}
+
+// UpsertNode mirrors the node
+func (m *Mirror) UpsertNode(ctx context.Context, cn *ciliumv2.CiliumNode) error {
+	n := m.parseNode(cn)
+	value, err := n.Marshal()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.upsert(ctx, path.Join(nodeStore.NodeStorePrefix, n.GetKeyName()), value)
+}
+
+// DeleteNode removes the node
+func (m *Mirror) DeleteNode(cn *ciliumv2.CiliumNode) error {
+	key := path.Join(nodeStore.NodeStorePrefix, node.GetKeyNodeName(m.clusterName, cn.Name))
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.delete(key)
+}
+
+// UpsertService mirrors the global service
+func (m *Mirror) UpsertService(ctx context.Context, svc *service.ClusterService) error {
+	svc.Cluster = m.clusterName
+	value, err := svc.Marshal()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.upsert(ctx, path.Join(service.ServiceStorePrefix, svc.GetKeyName()), value)
+}
+
+// DeleteService removes the global service
+func (m *Mirror) DeleteService(svc *service.ClusterService) error {
+	svc.Cluster = m.clusterName
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.delete(path.Join(service.ServiceStorePrefix, svc.GetKeyName()))
+}
+
+// Resync re-creates all mirrored keys which are missing or differ in the
+// kvstore, and removes all keys in the prefixes owned by the mirror which do
+// not correspond to a mirrored resource, e.g. keys left behind by a previous
+// instance. It must only be called once the mirror has been populated with
+// all resources.
+func (m *Mirror) Resync(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var errs []string
+	for key, value := range m.keys {
+		if _, err := m.backend.UpdateIfDifferent(ctx, key, value, true); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	stale := 0
+	for _, prefix := range m.prefixes() {
+		pairs, err := m.backend.ListPrefix(prefix)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		for key := range pairs {
+			if _, ok := m.keys[key]; ok {
+				continue
+			}
+			if err := m.backend.Delete(key); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			stale++
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"keys":  len(m.keys),
+		"stale": stale,
+	}).Debug("Resynchronized kvstore mirror")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to resynchronize kvstore mirror: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/pkg/allocator"
+	"github.com/cilium/cilium/pkg/identity"
+	"github.com/cilium/cilium/pkg/identity/cache"
+	"github.com/cilium/cilium/pkg/idpool"
+	"github.com/cilium/cilium/pkg/ipcache"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/kvstore"
+	kvstoreallocator "github.com/cilium/cilium/pkg/kvstore/allocator"
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/node"
+	"github.com/cilium/cilium/pkg/node/addressing"
+	nodeStore "github.com/cilium/cilium/pkg/node/store"
+	"github.com/cilium/cilium/pkg/service"
+	"github.com/cilium/cilium/pkg/testutils"
+
+	. "gopkg.in/check.v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testCluster = "mirror-test"
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type MirrorSuite struct {
+	mirror *Mirror
+}
+
+var _ = Suite(&MirrorSuite{})
+
+func (s *MirrorSuite) SetUpTest(c *C) {
+	kvstore.SetupDummy("etcd")
+	s.mirror = NewMirror(kvstore.Client(), testCluster, 7)
+	s.cleanup()
+}
+
+func (s *MirrorSuite) TearDownTest(c *C) {
+	s.cleanup()
+	kvstore.Close()
+}
+
+func (s *MirrorSuite) cleanup() {
+	for _, prefix := range s.mirror.prefixes() {
+		kvstore.DeletePrefix(prefix)
+	}
+}
+
+func getJSON(c *C, key string, v interface{}) bool {
+	value, err := kvstore.Client().Get(key)
+	c.Assert(err, IsNil)
+	if value == nil {
+		return false
+	}
+	c.Assert(json.Unmarshal(value, v), IsNil)
+	return true
+}
+
+// identityObserver records the identities reported by an allocator backend
+type identityObserver struct {
+	mutex      lock.Mutex
+	identities map[idpool.ID]allocator.AllocatorKey
+}
+
+func (o *identityObserver) OnListDone() {}
+
+func (o *identityObserver) OnAdd(id idpool.ID, key allocator.AllocatorKey) {
+	o.OnModify(id, key)
+}
+
+func (o *identityObserver) OnModify(id idpool.ID, key allocator.AllocatorKey) {
+	o.mutex.Lock()
+	o.identities[id] = key
+	o.mutex.Unlock()
+}
+
+func (o *identityObserver) OnDelete(id idpool.ID, key allocator.AllocatorKey) {
+	o.mutex.Lock()
+	delete(o.identities, id)
+	o.mutex.Unlock()
+}
+
+func (o *identityObserver) get(id idpool.ID) allocator.AllocatorKey {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.identities[id]
+}
+
+func (s *MirrorSuite) TestIdentity(c *C) {
+	id := &ciliumv2.CiliumIdentity{
+		ObjectMeta:     metav1.ObjectMeta{Name: "12345"},
+		SecurityLabels: map[string]string{"k8s:app": "foo", "k8s:io.kubernetes.pod.namespace": "default"},
+	}
+	c.Assert(s.mirror.UpsertIdentity(context.TODO(), id), IsNil)
+
+	invalid := &ciliumv2.CiliumIdentity{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	c.Assert(s.mirror.UpsertIdentity(context.TODO(), invalid), Not(IsNil))
+
+	// Watch the identities the same way as agents of remote clusters
+	observer := &identityObserver{identities: map[idpool.ID]allocator.AllocatorKey{}}
+	backend := kvstoreallocator.NewRemoteKVStoreBackend(cache.IdentitiesPath, cache.GlobalIdentity{}, kvstore.Client())
+	stop := make(chan struct{})
+	defer close(stop)
+	go backend.ListAndWatch(observer, stop)
+
+	c.Assert(testutils.WaitUntil(func() bool {
+		return observer.get(12345) != nil
+	}, 10*time.Second), IsNil)
+	c.Assert(observer.get(12345).GetAsMap(), DeepEquals, id.SecurityLabels)
+
+	c.Assert(s.mirror.DeleteIdentity(id), IsNil)
+	c.Assert(testutils.WaitUntil(func() bool {
+		return observer.get(12345) == nil
+	}, 10*time.Second), IsNil)
+}
+
+func (s *MirrorSuite) TestEndpoint(c *C) {
+	ep := &ciliumv2.CiliumEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Status: ciliumv2.EndpointStatus{
+			ID:       42,
+			Identity: &ciliumv2.EndpointIdentity{ID: 12345},
+			Networking: &ciliumv2.EndpointNetworking{
+				Addressing: ciliumv2.AddressPairList{{IPV4: "10.0.0.1", IPV6: "f00d::1"}},
+				NodeIP:     "192.168.1.1",
+			},
+			Encryption: ciliumv2.EncryptionSpec{Key: 3},
+		},
+	}
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), ep), IsNil)
+
+	key4 := path.Join(ipcache.IPIdentitiesPath, ipcache.AddressSpace, "10.0.0.1")
+	key6 := path.Join(ipcache.IPIdentitiesPath, ipcache.AddressSpace, "f00d::1")
+
+	var pair identity.IPIdentityPair
+	c.Assert(getJSON(c, key4, &pair), Equals, true)
+	c.Assert(pair.IP.Equal(net.ParseIP("10.0.0.1")), Equals, true)
+	c.Assert(pair.HostIP.Equal(net.ParseIP("192.168.1.1")), Equals, true)
+	c.Assert(pair.ID, Equals, identity.NumericIdentity(12345))
+	c.Assert(pair.Key, Equals, uint8(3))
+	c.Assert(pair.Metadata, Equals, "cilium-global:default:192.168.1.1:42")
+	c.Assert(getJSON(c, key6, &pair), Equals, true)
+
+	// Addresses no longer used by the endpoint are removed
+	ep.Status.Networking.Addressing = ciliumv2.AddressPairList{{IPV4: "10.0.0.2"}}
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), ep), IsNil)
+	c.Assert(getJSON(c, key4, &pair), Equals, false)
+	c.Assert(getJSON(c, key6, &pair), Equals, false)
+
+	key := path.Join(ipcache.IPIdentitiesPath, ipcache.AddressSpace, "10.0.0.2")
+	c.Assert(getJSON(c, key, &pair), Equals, true)
+
+	c.Assert(s.mirror.DeleteEndpoint(ep), IsNil)
+	c.Assert(getJSON(c, key, &pair), Equals, false)
+
+	// Endpoints without identity are not mirrored
+	ep.Status.Identity = nil
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), ep), IsNil)
+	c.Assert(getJSON(c, key, &pair), Equals, false)
+}
+
+func (s *MirrorSuite) TestEndpointReusedIP(c *C) {
+	newEndpoint := func(name string, id int64) *ciliumv2.CiliumEndpoint {
+		return &ciliumv2.CiliumEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: ciliumv2.EndpointStatus{
+				ID:       id,
+				Identity: &ciliumv2.EndpointIdentity{ID: 12345},
+				Networking: &ciliumv2.EndpointNetworking{
+					Addressing: ciliumv2.AddressPairList{{IPV4: "10.0.0.1"}},
+					NodeIP:     "192.168.1.1",
+				},
+			},
+		}
+	}
+	key := path.Join(ipcache.IPIdentitiesPath, ipcache.AddressSpace, "10.0.0.1")
+
+	// The IP of a deleted pod is reused by a new pod before the
+	// CiliumEndpoint of the old pod is deleted
+	oldEP, newEP := newEndpoint("old", 42), newEndpoint("new", 43)
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), oldEP), IsNil)
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), newEP), IsNil)
+	c.Assert(s.mirror.DeleteEndpoint(oldEP), IsNil)
+
+	var pair identity.IPIdentityPair
+	c.Assert(getJSON(c, key, &pair), Equals, true)
+	c.Assert(pair.Metadata, Equals, "cilium-global:default:192.168.1.1:43")
+
+	// The old endpoint releasing the IP leaves the key of the new owner
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), oldEP), IsNil)
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), newEP), IsNil)
+	oldEP.Status.Networking.Addressing = ciliumv2.AddressPairList{{IPV4: "10.0.0.2"}}
+	c.Assert(s.mirror.UpsertEndpoint(context.TODO(), oldEP), IsNil)
+	c.Assert(getJSON(c, key, &pair), Equals, true)
+	c.Assert(pair.Metadata, Equals, "cilium-global:default:192.168.1.1:43")
+
+	c.Assert(s.mirror.DeleteEndpoint(newEP), IsNil)
+	c.Assert(getJSON(c, key, &pair), Equals, false)
+	c.Assert(s.mirror.DeleteEndpoint(oldEP), IsNil)
+}
+
+func (s *MirrorSuite) TestNode(c *C) {
+	cn := &ciliumv2.CiliumNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec: ciliumv2.NodeSpec{
+			Addresses: []ciliumv2.NodeAddress{{Type: addressing.NodeInternalIP, IP: "192.168.1.1"}},
+			IPAM:      ciliumv2.IPAMSpec{PodCIDRs: []string{"10.0.0.0/24"}},
+		},
+	}
+	c.Assert(s.mirror.UpsertNode(context.TODO(), cn), IsNil)
+
+	key := path.Join(nodeStore.NodeStorePrefix, testCluster, "node1")
+	var n node.Node
+	c.Assert(getJSON(c, key, &n), Equals, true)
+	c.Assert(n.Name, Equals, "node1")
+	c.Assert(n.Cluster, Equals, testCluster)
+	c.Assert(n.ClusterID, Equals, 7)
+	c.Assert(n.GetNodeIP(false).String(), Equals, "192.168.1.1")
+	c.Assert(n.IPv4AllocCIDR.String(), Equals, "10.0.0.0/24")
+
+	c.Assert(s.mirror.DeleteNode(cn), IsNil)
+	c.Assert(getJSON(c, key, &n), Equals, false)
+}
+
+func (s *MirrorSuite) TestService(c *C) {
+	svc := service.NewClusterService("foo", "default")
+	svc.Frontends["172.20.0.1"] = service.PortConfiguration{}
+	c.Assert(s.mirror.UpsertService(context.TODO(), &svc), IsNil)
+
+	key := path.Join(service.ServiceStorePrefix, testCluster, "default", "foo")
+	var mirrored service.ClusterService
+	c.Assert(getJSON(c, key, &mirrored), Equals, true)
+	c.Assert(mirrored.Cluster, Equals, testCluster)
+	c.Assert(mirrored.Frontends, HasLen, 1)
+
+	c.Assert(s.mirror.DeleteService(&svc), IsNil)
+	c.Assert(getJSON(c, key, &mirrored), Equals, false)
+}
+
+func (s *MirrorSuite) TestResync(c *C) {
+	cn := &ciliumv2.CiliumNode{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	c.Assert(s.mirror.UpsertNode(context.TODO(), cn), IsNil)
+
+	key := path.Join(nodeStore.NodeStorePrefix, testCluster, "node1")
+	stale := path.Join(nodeStore.NodeStorePrefix, testCluster, "node2")
+	other := path.Join(nodeStore.NodeStorePrefix, testCluster+"-other", "node3")
+	c.Assert(kvstore.Client().Delete(key), IsNil)
+	c.Assert(kvstore.Client().Set(stale, []byte("{}")), IsNil)
+	c.Assert(kvstore.Client().Set(other, []byte("{}")), IsNil)
+	defer kvstore.Client().Delete(other)
+
+	c.Assert(s.mirror.Resync(context.TODO()), IsNil)
+
+	var n node.Node
+	c.Assert(getJSON(c, key, &n), Equals, true)
+	c.Assert(getJSON(c, stale, &n), Equals, false)
+	// Keys of other clusters are left untouched
+	c.Assert(getJSON(c, other, &n), Equals, true)
+}
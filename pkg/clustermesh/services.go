@@ -103,22 +103,32 @@ func (c *globalServiceCache) onDelete(svc *service.ClusterService) {
 
 	c.mutex.Lock()
 	if globalService, ok := c.byName[svc.NamespaceServiceName()]; ok {
-		c.delete(globalService, svc.NamespaceServiceName(), svc.Cluster)
+		c.delete(globalService, svc.Cluster, svc.NamespaceServiceName())
 	} else {
 		scopedLog.Debugf("Ignoring delete request for unknown global service")
 	}
 	c.mutex.Unlock()
 }
 
-func (c *globalServiceCache) onClusterDelete(clusterName string) {
+// onClusterDelete removes all services of the cluster and returns them so
+// that their backends can be removed from the backend selection of the
+// corresponding global services
+func (c *globalServiceCache) onClusterDelete(clusterName string) []*service.ClusterService {
 	scopedLog := log.WithFields(logrus.Fields{logfields.ClusterName: clusterName})
 	scopedLog.Debugf("Cluster deletion event")
 
+	var deleted []*service.ClusterService
+
 	c.mutex.Lock()
 	for serviceName, globalService := range c.byName {
-		c.delete(globalService, serviceName, clusterName)
+		if svc, ok := globalService.clusterServices[clusterName]; ok {
+			deleted = append(deleted, svc)
+		}
+		c.delete(globalService, clusterName, serviceName)
 	}
 	c.mutex.Unlock()
+
+	return deleted
 }
 
 type remoteServiceObserver struct {
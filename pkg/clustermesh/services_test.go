@@ -21,8 +21,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"time"
 
+	"github.com/cilium/cilium/pkg/checker"
 	"github.com/cilium/cilium/pkg/defaults"
 	"github.com/cilium/cilium/pkg/identity"
 	"github.com/cilium/cilium/pkg/identity/cache"
@@ -30,6 +32,7 @@ import (
 	"github.com/cilium/cilium/pkg/k8s/types"
 	"github.com/cilium/cilium/pkg/kvstore"
 	"github.com/cilium/cilium/pkg/loadbalancer"
+	"github.com/cilium/cilium/pkg/service"
 	"github.com/cilium/cilium/pkg/testutils"
 
 	. "gopkg.in/check.v1"
@@ -274,3 +277,32 @@ func (s *ClusterMeshServicesTestSuite) TestClusterMeshServicesNonGlobal(c *C) {
 	default:
 	}
 }
+
+func (s *ClusterMeshTestSuite) TestGlobalServiceCacheClusterDelete(c *C) {
+	cache := newGlobalServiceCache()
+
+	svc1 := &service.ClusterService{Cluster: "cluster1", Namespace: "default", Name: "foo"}
+	svc2 := &service.ClusterService{Cluster: "cluster2", Namespace: "default", Name: "foo"}
+	svc3 := &service.ClusterService{Cluster: "cluster1", Namespace: "default", Name: "bar"}
+	cache.onUpdate(svc1)
+	cache.onUpdate(svc2)
+	cache.onUpdate(svc3)
+
+	names := []string{}
+	for _, svc := range cache.onClusterDelete("cluster1") {
+		c.Assert(svc.Cluster, Equals, "cluster1")
+		names = append(names, svc.Name)
+	}
+	sort.Strings(names)
+	c.Assert(names, checker.DeepEquals, []string{"bar", "foo"})
+
+	// The global service of bar is removed along with its last cluster
+	// service, foo is still provided by cluster2
+	c.Assert(len(cache.byName), Equals, 1)
+	c.Assert(cache.byName["default/foo"].clusterServices, checker.DeepEquals,
+		map[string]*service.ClusterService{"cluster2": svc2})
+
+	cache.onDelete(svc2)
+	c.Assert(len(cache.byName), Equals, 0)
+	c.Assert(cache.onClusterDelete("cluster2"), IsNil)
+}
@@ -57,6 +57,10 @@ func CheckStructAlignments(path string) error {
 		"lb6_key_v2":           {reflect.TypeOf(lbmap.Service6KeyV2{})},
 		"lb6_service_v2":       {reflect.TypeOf(lbmap.Service6ValueV2{})},
 		"lb6_backend":          {reflect.TypeOf(lbmap.Backend6Value{})},
+		"lb_maglev":            {reflect.TypeOf(lbmap.MaglevValue{})},
+		"lb4_affinity_key":     {reflect.TypeOf(lbmap.Affinity4Key{})},
+		"lb6_affinity_key":     {reflect.TypeOf(lbmap.Affinity6Key{})},
+		"lb_affinity_val":      {reflect.TypeOf(lbmap.AffinityValue{})},
 		"endpoint_info":        {reflect.TypeOf(lxcmap.EndpointInfo{})},
 		"metrics_key":          {reflect.TypeOf(metricsmap.Key{})},
 		"metrics_value":        {reflect.TypeOf(metricsmap.Value{})},
@@ -16,6 +16,7 @@ package linux
 
 import (
 	"github.com/cilium/cilium/pkg/datapath"
+	"github.com/cilium/cilium/pkg/datapath/linux/wireguard"
 	"github.com/cilium/cilium/pkg/endpoint/connector"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 )
@@ -27,6 +28,9 @@ type DatapathConfiguration struct {
 	HostDevice string
 	// EncryptInterface is the name of the device to be used for direct ruoting encryption
 	EncryptInterface string
+	// WireguardAgent configures the WireGuard device if WireGuard
+	// encryption is enabled
+	WireguardAgent *wireguard.Agent
 }
 
 type rulesManager interface {
@@ -25,16 +25,19 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/cilium/cilium/api/v1/models"
 	"github.com/cilium/cilium/pkg/datapath/linux/linux_defaults"
 	"github.com/cilium/cilium/pkg/datapath/linux/route"
+	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/maps/encrypt"
 	"github.com/vishvananda/netlink"
 
 	"github.com/sirupsen/logrus"
+	fsnotify "gopkg.in/fsnotify.v1"
 )
 
 type IPSecDir string
@@ -54,11 +57,28 @@ type ipSecKey struct {
 	Aead  *netlink.XfrmStateAlgo
 }
 
-// ipSecKeysGlobal is safe to read unlocked because the only writers are from
-// daemon init time before any readers will be online.
-var ipSecKeysGlobal = make(map[string]*ipSecKey)
+var (
+	// ipSecLock protects ipSecKeysGlobal, ipSecSpi and ipSecKeyLen which
+	// are replaced when the key file changes
+	ipSecLock lock.RWMutex
+
+	ipSecKeysGlobal = make(map[string]*ipSecKey)
+
+	// ipSecSpi is the SPI of the current keys
+	ipSecSpi uint8
+
+	// ipSecKeyLen is the authentication overhead of the current keys
+	ipSecKeyLen int
+
+	// ipSecKeyRotation tracks the keys advertised by the peers to remove
+	// the states of previous keys once all peers have moved over
+	ipSecKeyRotation = newKeyRotation(ipsecRemoveKeys)
+)
 
 func getIPSecKeys(ip net.IP) *ipSecKey {
+	ipSecLock.RLock()
+	defer ipSecLock.RUnlock()
+
 	key, scoped := ipSecKeysGlobal[ip.String()]
 	if scoped == false {
 		key, _ = ipSecKeysGlobal[""]
@@ -193,6 +213,55 @@ func ipsecDeleteXfrmSpi(spi uint8) {
 	}
 }
 
+// ipsecDeleteXfrmPolicySpi deletes all outgoing policies using a key other
+// than spi
+func ipsecDeleteXfrmPolicySpi(spi uint8) {
+	scopedLog := log.WithFields(logrus.Fields{
+		"spi": spi,
+	})
+
+	xfrmPolicyList, err := netlink.XfrmPolicyList(0)
+	if err != nil {
+		scopedLog.WithError(err).Warning("deleting previous SPI, xfrm policy list error")
+		return
+	}
+	for _, p := range xfrmPolicyList {
+		if p.Dir != netlink.XFRM_DIR_OUT || p.Mark == nil ||
+			p.Mark.Value&linux_defaults.RouteMarkMask != linux_defaults.RouteMarkEncrypt {
+			continue
+		}
+		if p.Mark.Value>>12 != uint32(spi) {
+			if err := netlink.XfrmPolicyDel(&p); err != nil {
+				scopedLog.WithError(err).Warning("deleting old xfrm policy failed")
+			}
+		}
+	}
+}
+
+// ipsecStaleSpi returns the SPI of an xfrm state using a key other than spi,
+// e.g. installed before a restart with new keys, or 0 if there is none
+func ipsecStaleSpi(spi uint8) uint8 {
+	xfrmStateList, err := netlink.XfrmStateList(0)
+	if err != nil {
+		log.WithError(err).Warning("looking up previous SPI, xfrm state list error")
+		return 0
+	}
+	for _, s := range xfrmStateList {
+		if s.Spi != int(spi) {
+			return uint8(s.Spi)
+		}
+	}
+	return 0
+}
+
+// ipsecRemoveKeys removes the states and outgoing policies of all keys
+// other than spi. It is called once all peers have moved over to spi.
+func ipsecRemoveKeys(spi uint8) {
+	log.WithField("spi", spi).Info("All nodes use the new encryption keys, reclaiming previous SPIs")
+	ipsecDeleteXfrmSpi(spi)
+	ipsecDeleteXfrmPolicySpi(spi)
+}
+
 func ipsecDeleteXfrmState(ip net.IP) {
 	scopedLog := log.WithFields(logrus.Fields{
 		"remote-ip": ip,
@@ -343,19 +412,16 @@ func LoadIPSecKeysFile(path string) (int, uint8, error) {
 	return loadIPSecKeys(file)
 }
 
-func loadIPSecKeys(r io.Reader) (int, uint8, error) {
+// parseIPSecKeys parses the keys from r. Returns the authentication overhead
+// in bytes, the key ID and the keys indexed by scope.
+func parseIPSecKeys(r io.Reader) (int, uint8, map[string]*ipSecKey, error) {
 	var spi uint8
 	var keyLen int
-	scopedLog := log.WithFields(logrus.Fields{
-		"spi": spi,
-	})
-
-	encrypt.MapCreate()
+	keys := make(map[string]*ipSecKey)
 
 	scanner := bufio.NewScanner(r)
 	scanner.Split(bufio.ScanLines)
 	for scanner.Scan() {
-		var oldSpi uint8
 		var authkey []byte
 		offset := 0
 
@@ -367,7 +433,7 @@ func loadIPSecKeys(r io.Reader) (int, uint8, error) {
 		//    auth-algo auth-key enc-algo enc-key
 		s := strings.Split(scanner.Text(), " ")
 		if len(s) < 2 {
-			return 0, 0, fmt.Errorf("missing IPSec keys or invalid format")
+			return 0, 0, nil, fmt.Errorf("missing IPSec keys or invalid format")
 		}
 
 		spiI, err := strconv.Atoi(s[0])
@@ -378,27 +444,27 @@ func loadIPSecKeys(r io.Reader) (int, uint8, error) {
 			offset = -1
 		}
 		if spiI > linux_defaults.IPsecMaxKeyVersion {
-			return 0, 0, fmt.Errorf("encryption Key space exhausted, id must be nonzero and less than %d. Attempted %q", linux_defaults.IPsecMaxKeyVersion, s[0])
+			return 0, 0, nil, fmt.Errorf("encryption Key space exhausted, id must be nonzero and less than %d. Attempted %q", linux_defaults.IPsecMaxKeyVersion, s[0])
 		}
 		if spiI == 0 {
-			return 0, 0, fmt.Errorf("zero is not a valid key to disable encryption use `--enable-ipsec=false`, id must be nonzero and less than %d. Attempted %q", linux_defaults.IPsecMaxKeyVersion, s[0])
+			return 0, 0, nil, fmt.Errorf("zero is not a valid key to disable encryption use `--enable-ipsec=false`, id must be nonzero and less than %d. Attempted %q", linux_defaults.IPsecMaxKeyVersion, s[0])
 		}
 		spi = uint8(spiI)
 
 		keyLen, authkey, err = decodeIPSecKey(s[2+offset])
 		if err != nil {
-			return 0, 0, fmt.Errorf("unable to decode authkey string %q", s[1+offset])
+			return 0, 0, nil, fmt.Errorf("unable to decode authkey string %q", s[1+offset])
 		}
 		authname := s[1+offset]
 
 		if strings.HasPrefix(authname, "rfc") {
 			icvLen, err := strconv.Atoi(s[3+offset])
 			if err != nil {
-				return 0, 0, fmt.Errorf("ICVLen is invalid or missing")
+				return 0, 0, nil, fmt.Errorf("ICVLen is invalid or missing")
 			}
 
 			if icvLen != 96 && icvLen != 128 && icvLen != 256 {
-				return 0, 0, fmt.Errorf("Unknown ICVLen accepts 96, 128, 256")
+				return 0, 0, nil, fmt.Errorf("Unknown ICVLen accepts 96, 128, 256")
 			}
 
 			ipSecKey.Aead = &netlink.XfrmStateAlgo{
@@ -410,7 +476,7 @@ func loadIPSecKeys(r io.Reader) (int, uint8, error) {
 		} else {
 			_, enckey, err := decodeIPSecKey(s[4+offset])
 			if err != nil {
-				return 0, 0, fmt.Errorf("unable to decode enckey string %q", s[3+offset])
+				return 0, 0, nil, fmt.Errorf("unable to decode enckey string %q", s[3+offset])
 			}
 
 			encname := s[3+offset]
@@ -428,34 +494,147 @@ func loadIPSecKeys(r io.Reader) (int, uint8, error) {
 		ipSecKey.Spi = spi
 
 		if len(s) == 6+offset {
-			if ipSecKeysGlobal[s[5+offset]] != nil {
-				oldSpi = ipSecKeysGlobal[s[5+offset]].Spi
-			}
-			ipSecKeysGlobal[s[5+offset]] = ipSecKey
+			keys[s[5+offset]] = ipSecKey
 		} else {
-			if ipSecKeysGlobal[""] != nil {
-				oldSpi = ipSecKeysGlobal[""].Spi
-			}
-			ipSecKeysGlobal[""] = ipSecKey
+			keys[""] = ipSecKey
 		}
+	}
+	return keyLen, spi, keys, nil
+}
 
-		// Detect a version change and call cleanup routine to remove old
-		// keys after a timeout period. We also want to ensure on restart
-		// we remove any stale keys for example when a restart changes keys.
-		// In the restart case oldSpi will be '0' and cause the delete logic
-		// to run.
-		if oldSpi != ipSecKey.Spi {
-			go func() {
-				time.Sleep(linux_defaults.IPsecKeyDeleteDelay)
-				scopedLog.Info("New encryption keys reclaiming SPI")
-				ipsecDeleteXfrmSpi(ipSecKey.Spi)
-			}()
-		}
+func loadIPSecKeys(r io.Reader) (int, uint8, error) {
+	keyLen, spi, keys, err := parseIPSecKeys(r)
+	if err != nil {
+		return 0, 0, err
 	}
+
+	encrypt.MapCreate()
+
+	ipSecLock.Lock()
+	ipSecKeysGlobal = keys
+	ipSecSpi = spi
+	ipSecKeyLen = keyLen
+	ipSecLock.Unlock()
+
+	// States of other keys may remain from before a restart with new
+	// keys. Peers which have not been restarted yet may still use them,
+	// so they are only removed once all peers advertise the new keys.
+	ipSecKeyRotation.start(ipsecStaleSpi(spi), spi)
+
 	encrypt.MapUpdateContext(0, spi)
 	return keyLen, spi, nil
 }
 
+// reloadIPSecKeysFile replaces the keys with the keys from the file at path
+// if the key ID has changed. The states of the previous keys remain in place
+// until all peers have moved over to the new keys. Returns the key ID and
+// whether it has changed.
+func reloadIPSecKeysFile(path string) (uint8, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+
+	keyLen, spi, keys, err := parseIPSecKeys(file)
+	if err != nil {
+		return 0, false, err
+	}
+
+	ipSecLock.Lock()
+	if spi == ipSecSpi {
+		changed := !reflect.DeepEqual(keys, ipSecKeysGlobal)
+		ipSecLock.Unlock()
+		if changed {
+			return 0, false, fmt.Errorf("keys changed without changing the key ID %d", spi)
+		}
+		return spi, false, nil
+	}
+	if keyLen != ipSecKeyLen {
+		ipSecLock.Unlock()
+		return 0, false, fmt.Errorf("authentication overhead of the new keys differs, restart required")
+	}
+	previous := ipSecSpi
+	ipSecKeysGlobal = keys
+	ipSecSpi = spi
+	ipSecLock.Unlock()
+
+	ipSecKeyRotation.start(previous, spi)
+	return spi, true, nil
+}
+
+// StartKeyfileWatcher watches the key file at path for changes. When the key
+// ID in the file has changed, the new keys are loaded and onUpdate is called
+// with the new key ID. onUpdate must install the states of the new keys and
+// advertise the new key ID to the peers. The datapath starts to use the new
+// keys once onUpdate has returned.
+func StartKeyfileWatcher(path string, onUpdate func(spi uint8)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Kubernetes updates the files of a secret volume by replacing a
+	// symlink in the directory, so the directory is watched
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case event := <-watcher.Events:
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+
+				spi, changed, err := reloadIPSecKeysFile(path)
+				if err != nil {
+					log.WithError(err).WithField("path", path).Warning("Unable to reload IPsec keys")
+					continue
+				}
+				if !changed {
+					continue
+				}
+
+				log.WithField("spi", spi).Info("IPsec keys changed, rotating to new keys")
+				onUpdate(spi)
+				encrypt.MapUpdateContext(0, spi)
+
+			case err := <-watcher.Errors:
+				log.WithError(err).WithField("path", path).Warning("error encountered while watching IPsec key file with fsnotify")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// UpdateNodeKey sets the key ID advertised by the remote node nodeName. The
+// states of previous keys are removed once all remote nodes advertise the
+// current key ID.
+func UpdateNodeKey(nodeName string, spi uint8) {
+	ipSecKeyRotation.upsertPeer(nodeName, spi)
+}
+
+// DeleteNodeKey removes the remote node nodeName
+func DeleteNodeKey(nodeName string) {
+	ipSecKeyRotation.deletePeer(nodeName)
+}
+
+// SetNodeKeysSynced must be called once all remote nodes are known. Until
+// then, the states of previous keys are never removed.
+func SetNodeKeysSynced() {
+	ipSecKeyRotation.setSynced()
+}
+
+// GetKeyRotationStatusModel returns the progress of the key rotation in
+// progress, nil if no rotation is in progress
+func GetKeyRotationStatusModel() *models.IPSecKeyRotationStatus {
+	return ipSecKeyRotation.getStatusModel()
+}
+
 // EnableIPv6Forwarding sets proc file to enable IPv6 forwarding
 func EnableIPv6Forwarding() error {
 	ip6ConfPath := "/proc/sys/net/ipv6/conf/"
@@ -0,0 +1,103 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package ipsec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *IPSecSuite) TestParseKeys(c *C) {
+	keyLen, spi, keys, err := parseIPSecKeys(bytes.NewReader([]byte(
+		"3 hmac(sha256) 0123456789abcdef0123456789abcdef cbc(aes) 0123456789abcdef0123456789abcdef\n" +
+			"3 hmac(sha256) 0123456789abcdef0123456789abcdef cbc(aes) 0123456789abcdef0123456789abcdef 1.2.3.4\n")))
+	c.Assert(err, IsNil)
+	c.Assert(keyLen, Equals, 32)
+	c.Assert(spi, Equals, uint8(3))
+	c.Assert(keys, HasLen, 2)
+	c.Assert(keys[""].Spi, Equals, uint8(3))
+	c.Assert(keys["1.2.3.4"].Crypt.Name, Equals, "cbc(aes)")
+
+	keyLen, spi, keys, err = parseIPSecKeys(bytes.NewReader([]byte("rfc4106(gcm(aes)) 44434241343332312423222114131211f4f3f2f1 128\n")))
+	c.Assert(err, IsNil)
+	c.Assert(keyLen, Equals, 16)
+	c.Assert(spi, Equals, uint8(1))
+	c.Assert(keys[""].Aead.ICVLen, Equals, 128)
+
+	_, _, _, err = parseIPSecKeys(bytes.NewReader([]byte("0 rfc4106(gcm(aes)) 44434241343332312423222114131211f4f3f2f1 128\n")))
+	c.Assert(err, NotNil)
+	_, _, _, err = parseIPSecKeys(bytes.NewReader([]byte("17 rfc4106(gcm(aes)) 44434241343332312423222114131211f4f3f2f1 128\n")))
+	c.Assert(err, NotNil)
+}
+
+func (s *IPSecSuite) TestReloadKeys(c *C) {
+	dir, err := ioutil.TempDir("", "ipsec")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys")
+
+	oldKeys, oldSpi, oldKeyLen, oldRotation := ipSecKeysGlobal, ipSecSpi, ipSecKeyLen, ipSecKeyRotation
+	defer func() {
+		ipSecKeysGlobal, ipSecSpi, ipSecKeyLen, ipSecKeyRotation = oldKeys, oldSpi, oldKeyLen, oldRotation
+	}()
+	ipSecKeyRotation = newKeyRotation(func(spi uint8) {})
+
+	_, spi, keys, err := parseIPSecKeys(bytes.NewReader([]byte("3 rfc4106(gcm(aes)) 44434241343332312423222114131211f4f3f2f1 128\n")))
+	c.Assert(err, IsNil)
+	ipSecKeysGlobal, ipSecSpi, ipSecKeyLen = keys, spi, 16
+
+	// Same key ID and keys
+	err = ioutil.WriteFile(path, []byte("3 rfc4106(gcm(aes)) 44434241343332312423222114131211f4f3f2f1 128\n"), 0600)
+	c.Assert(err, IsNil)
+	spi, changed, err := reloadIPSecKeysFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(changed, Equals, false)
+	c.Assert(spi, Equals, uint8(3))
+
+	// Changing the keys requires changing the key ID
+	err = ioutil.WriteFile(path, []byte("3 rfc4106(gcm(aes)) 54434241343332312423222114131211f4f3f2f1 128\n"), 0600)
+	c.Assert(err, IsNil)
+	_, changed, err = reloadIPSecKeysFile(path)
+	c.Assert(err, NotNil)
+	c.Assert(changed, Equals, false)
+
+	// The authentication overhead must not change
+	err = ioutil.WriteFile(path, []byte("4 rfc4106(gcm(aes)) 54434241343332312423222114131211f4f3f2f1 96\n"), 0600)
+	c.Assert(err, IsNil)
+	_, changed, err = reloadIPSecKeysFile(path)
+	c.Assert(err, NotNil)
+	c.Assert(changed, Equals, false)
+	c.Assert(ipSecSpi, Equals, uint8(3))
+
+	// New key ID
+	err = ioutil.WriteFile(path, []byte("4 rfc4106(gcm(aes)) 54434241343332312423222114131211f4f3f2f1 128\n"), 0600)
+	c.Assert(err, IsNil)
+	spi, changed, err = reloadIPSecKeysFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(changed, Equals, true)
+	c.Assert(spi, Equals, uint8(4))
+	c.Assert(getIPSecKeys(nil).Spi, Equals, uint8(4))
+
+	status := GetKeyRotationStatusModel()
+	c.Assert(status, NotNil)
+	c.Assert(status.PreviousSpi, Equals, int64(3))
+	c.Assert(status.CurrentSpi, Equals, int64(4))
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsec
+
+import (
+	"sort"
+
+	"github.com/cilium/cilium/api/v1/models"
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+// keyRotation tracks the keys advertised by the peers of the local node to
+// decide when the states of the previous keys can be removed. The datapath
+// encrypts the traffic to a peer with the smaller of the local key and the
+// key advertised by the peer, so the states of a previous key must remain
+// in place until all peers have moved over to the current key.
+type keyRotation struct {
+	mutex lock.Mutex
+
+	// current is the SPI of the local key
+	current uint8
+
+	// previous is the SPI of the key being rotated out, 0 if no rotation
+	// is in progress
+	previous uint8
+
+	// synced is true once the keys advertised by all peers are known
+	synced bool
+
+	// peers maps the name of each peer to the SPI advertised by the peer
+	peers map[string]uint8
+
+	// onComplete is called with the current SPI once all peers have moved
+	// over to the current key
+	onComplete func(spi uint8)
+}
+
+func newKeyRotation(onComplete func(spi uint8)) *keyRotation {
+	return &keyRotation{
+		peers:      map[string]uint8{},
+		onComplete: onComplete,
+	}
+}
+
+// start starts the rotation from the previous to the current key. If
+// previous is 0 or equal to current, no previous key is in use.
+func (r *keyRotation) start(previous, current uint8) {
+	r.mutex.Lock()
+	r.current = current
+	if previous != 0 && previous != current {
+		r.previous = previous
+	} else {
+		r.previous = 0
+	}
+	complete := r.completed()
+	r.mutex.Unlock()
+
+	if complete {
+		r.onComplete(current)
+	}
+}
+
+// completed returns true if a rotation was in progress and all peers have
+// moved over to the current key, in which case the rotation is ended. A
+// rotation is never completed while no peer is known, as the peers may not
+// have been learned yet. Must be called with r.mutex held.
+func (r *keyRotation) completed() bool {
+	if r.previous == 0 || !r.synced || len(r.peers) == 0 {
+		return false
+	}
+	for _, spi := range r.peers {
+		if spi != r.current {
+			return false
+		}
+	}
+	r.previous = 0
+	return true
+}
+
+// update runs fn with r.mutex held and ends the rotation if all peers have
+// moved over to the current key afterwards
+func (r *keyRotation) update(fn func()) {
+	r.mutex.Lock()
+	fn()
+	complete := r.completed()
+	current := r.current
+	r.mutex.Unlock()
+
+	if complete {
+		r.onComplete(current)
+	}
+}
+
+// upsertPeer sets the SPI advertised by the peer. Peers not advertising a
+// key do not use encryption and are ignored.
+func (r *keyRotation) upsertPeer(name string, spi uint8) {
+	r.update(func() {
+		if spi == 0 {
+			delete(r.peers, name)
+		} else {
+			r.peers[name] = spi
+		}
+	})
+}
+
+// deletePeer removes the peer
+func (r *keyRotation) deletePeer(name string) {
+	r.update(func() {
+		delete(r.peers, name)
+	})
+}
+
+// setSynced marks the keys advertised by all peers as known. Until then,
+// a rotation is never considered completed.
+func (r *keyRotation) setSynced() {
+	r.update(func() {
+		r.synced = true
+	})
+}
+
+// getStatusModel returns the progress of the rotation in progress, nil if
+// no rotation is in progress
+func (r *keyRotation) getStatusModel() *models.IPSecKeyRotationStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.previous == 0 {
+		return nil
+	}
+
+	status := &models.IPSecKeyRotationStatus{
+		CurrentSpi:   int64(r.current),
+		PreviousSpi:  int64(r.previous),
+		PendingNodes: []string{},
+	}
+	for name, spi := range r.peers {
+		if spi == r.current {
+			status.MigratedNodes++
+		} else {
+			status.PendingNodes = append(status.PendingNodes, name)
+		}
+	}
+	sort.Strings(status.PendingNodes)
+
+	return status
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package ipsec
+
+import (
+	"testing"
+
+	"github.com/cilium/cilium/api/v1/models"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type IPSecSuite struct{}
+
+var _ = Suite(&IPSecSuite{})
+
+func (s *IPSecSuite) TestKeyRotation(c *C) {
+	var reclaimed []uint8
+	r := newKeyRotation(func(spi uint8) {
+		reclaimed = append(reclaimed, spi)
+	})
+
+	r.start(0, 1)
+	c.Assert(r.getStatusModel(), IsNil)
+
+	r.upsertPeer("node1", 1)
+	r.upsertPeer("node2", 1)
+	r.upsertPeer("node3", 0)
+	r.setSynced()
+	c.Assert(reclaimed, IsNil)
+
+	// Rotating to the new key keeps the previous key as long as any peer
+	// advertises it
+	r.start(1, 2)
+	c.Assert(r.getStatusModel(), DeepEquals, &models.IPSecKeyRotationStatus{
+		CurrentSpi:    2,
+		PreviousSpi:   1,
+		MigratedNodes: 0,
+		PendingNodes:  []string{"node1", "node2"},
+	})
+
+	r.upsertPeer("node2", 2)
+	c.Assert(reclaimed, IsNil)
+	c.Assert(r.getStatusModel(), DeepEquals, &models.IPSecKeyRotationStatus{
+		CurrentSpi:    2,
+		PreviousSpi:   1,
+		MigratedNodes: 1,
+		PendingNodes:  []string{"node1"},
+	})
+
+	// Peers not advertising a key do not hold up the rotation
+	r.upsertPeer("node4", 0)
+	r.upsertPeer("node1", 2)
+	c.Assert(reclaimed, DeepEquals, []uint8{2})
+	c.Assert(r.getStatusModel(), IsNil)
+
+	// Further updates do not reclaim the keys again
+	r.upsertPeer("node1", 2)
+	c.Assert(reclaimed, DeepEquals, []uint8{2})
+}
+
+func (s *IPSecSuite) TestKeyRotationDeletePeer(c *C) {
+	var reclaimed []uint8
+	r := newKeyRotation(func(spi uint8) {
+		reclaimed = append(reclaimed, spi)
+	})
+
+	r.upsertPeer("node1", 3)
+	r.upsertPeer("node2", 4)
+	r.start(3, 4)
+	r.setSynced()
+	c.Assert(reclaimed, IsNil)
+	c.Assert(r.getStatusModel().PendingNodes, DeepEquals, []string{"node1"})
+
+	r.deletePeer("node1")
+	c.Assert(reclaimed, DeepEquals, []uint8{4})
+}
+
+func (s *IPSecSuite) TestKeyRotationSynced(c *C) {
+	var reclaimed []uint8
+	r := newKeyRotation(func(spi uint8) {
+		reclaimed = append(reclaimed, spi)
+	})
+
+	// Until all peers are known, the previous key is kept even if all
+	// known peers use the current key
+	r.start(5, 6)
+	r.upsertPeer("node1", 6)
+	c.Assert(reclaimed, IsNil)
+	c.Assert(r.getStatusModel().MigratedNodes, Equals, int64(1))
+
+	r.setSynced()
+	c.Assert(reclaimed, DeepEquals, []uint8{6})
+}
+
+func (s *IPSecSuite) TestKeyRotationNoPeers(c *C) {
+	var reclaimed []uint8
+	r := newKeyRotation(func(spi uint8) {
+		reclaimed = append(reclaimed, spi)
+	})
+
+	// The previous key is kept while no peer is known, e.g. when the
+	// node list was empty when the keys were marked as synced
+	r.setSynced()
+	r.start(7, 8)
+	c.Assert(reclaimed, IsNil)
+	c.Assert(r.getStatusModel().PendingNodes, DeepEquals, []string{})
+
+	r.upsertPeer("node1", 7)
+	c.Assert(reclaimed, IsNil)
+	r.upsertPeer("node1", 8)
+	c.Assert(reclaimed, DeepEquals, []uint8{8})
+}
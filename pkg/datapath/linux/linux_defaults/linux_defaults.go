@@ -14,10 +14,6 @@
 
 package linux_defaults
 
-import (
-	"time"
-)
-
 // Linux specific constants used in Linux datapath
 const (
 	// RouteTableIPSec is the default table ID to use for IPSec routing rules
@@ -48,8 +44,4 @@ const (
 
 	// IPsecMarkMaskIn is the mask required for IPsec to lookup encrypt/decrypt bits
 	IPsecMarkMaskIn = 0x0F00
-
-	// IPsecKeyDeleteDelay is the time to wait before removing old keys when
-	// the IPsec key is changing.
-	IPsecKeyDeleteDelay = 5 * time.Minute
 )
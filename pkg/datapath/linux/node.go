@@ -24,6 +24,7 @@ import (
 	"github.com/cilium/cilium/pkg/datapath/linux/ipsec"
 	"github.com/cilium/cilium/pkg/datapath/linux/linux_defaults"
 	"github.com/cilium/cilium/pkg/datapath/linux/route"
+	"github.com/cilium/cilium/pkg/datapath/linux/wireguard"
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 	"github.com/cilium/cilium/pkg/maps/tunnel"
@@ -47,6 +48,7 @@ type linuxNodeHandler struct {
 	nodeAddressing datapath.NodeAddressing
 	datapathConfig DatapathConfiguration
 	nodes          map[node.Identity]*node.Node
+	wireguard      *wireguard.Agent
 }
 
 // NewNodeHandler returns a new node handler to handle node events and
@@ -56,6 +58,7 @@ func NewNodeHandler(datapathConfig DatapathConfiguration, nodeAddressing datapat
 		nodeAddressing: nodeAddressing,
 		datapathConfig: datapathConfig,
 		nodes:          map[node.Identity]*node.Node{},
+		wireguard:      datapathConfig.WireguardAgent,
 	}
 }
 
@@ -637,6 +640,39 @@ func (n *linuxNodeHandler) subnetEncryption() bool {
 	return len(n.nodeConfig.IPv4PodSubnets) > 0 || len(n.nodeConfig.IPv6PodSubnets) > 0
 }
 
+func (n *linuxNodeHandler) wireguardEnabled() bool {
+	return n.nodeConfig.EnableWireguard && n.wireguard != nil
+}
+
+// updateWireguardPeer configures the WireGuard peer of newNode and routes
+// its pod CIDRs through the WireGuard device
+func (n *linuxNodeHandler) updateWireguardPeer(newNode *node.Node) {
+	var (
+		nodeIP     net.IP
+		allowedIPs []*net.IPNet
+	)
+
+	if n.nodeConfig.EnableIPv4 && newNode.IPv4AllocCIDR != nil {
+		allowedIPs = append(allowedIPs, newNode.IPv4AllocCIDR.IPNet)
+	}
+	if n.nodeConfig.EnableIPv6 && newNode.IPv6AllocCIDR != nil {
+		allowedIPs = append(allowedIPs, newNode.IPv6AllocCIDR.IPNet)
+	}
+
+	if n.nodeConfig.EnableIPv4 {
+		nodeIP = newNode.GetNodeIP(false)
+	}
+	if nodeIP == nil && n.nodeConfig.EnableIPv6 {
+		nodeIP = newNode.GetNodeIP(true)
+	}
+
+	err := n.wireguard.UpsertPeer(newNode.Fullname(), newNode.WireguardPublicKey, nodeIP, allowedIPs)
+	if err != nil {
+		log.WithError(err).WithField(logfields.NodeName, newNode.Fullname()).
+			Warning("Unable to update WireGuard peer")
+	}
+}
+
 func (n *linuxNodeHandler) nodeUpdate(oldNode, newNode *node.Node, firstAddition bool) error {
 	var (
 		oldIP4Cidr, oldIP6Cidr *cidr.CIDR
@@ -672,6 +708,9 @@ func (n *linuxNodeHandler) nodeUpdate(oldNode, newNode *node.Node, firstAddition
 
 	if n.nodeConfig.EnableIPSec {
 		n.encryptNode(newNode)
+		if !newNode.IsLocal() {
+			ipsec.UpdateNodeKey(newNode.Fullname(), newNode.EncryptionKey)
+		}
 	}
 
 	if newNode.IsLocal() {
@@ -685,6 +724,27 @@ func (n *linuxNodeHandler) nodeUpdate(oldNode, newNode *node.Node, firstAddition
 		return nil
 	}
 
+	// With WireGuard, the pod CIDRs of the node are routed through the
+	// WireGuard device in place of direct routes. Nodes which have not
+	// published their public key yet are reached through the regular
+	// routes until they do.
+	if n.wireguardEnabled() {
+		n.updateWireguardPeer(newNode)
+		if newNode.WireguardPublicKey != "" {
+			if oldNode != nil && oldNode.WireguardPublicKey == "" && n.nodeConfig.EnableAutoDirectRouting {
+				n.deleteDirectRoute(oldIP4Cidr, oldIP4)
+				n.deleteDirectRoute(oldIP6Cidr, oldIP6)
+			}
+			return nil
+		}
+		if oldNode != nil && oldNode.WireguardPublicKey != "" {
+			// The routes through the WireGuard device have been
+			// removed along with the peer, the regular routes must
+			// be installed from scratch.
+			oldIP4Cidr, oldIP6Cidr = nil, nil
+		}
+	}
+
 	if n.nodeConfig.EnableAutoDirectRouting {
 		n.updateDirectRoute(oldIP4Cidr, newNode.IPv4AllocCIDR, oldIP4, newIP4, firstAddition, n.nodeConfig.EnableIPv4)
 		n.updateDirectRoute(oldIP6Cidr, newNode.IPv6AllocCIDR, oldIP6, newIP6, firstAddition, n.nodeConfig.EnableIPv6)
@@ -746,6 +806,16 @@ func (n *linuxNodeHandler) nodeDelete(oldNode *node.Node) error {
 	oldIP4 := oldNode.GetNodeIP(false)
 	oldIP6 := oldNode.GetNodeIP(true)
 
+	if n.wireguardEnabled() {
+		if err := n.wireguard.DeletePeer(oldNode.Fullname()); err != nil {
+			log.WithError(err).WithField(logfields.NodeName, oldNode.Fullname()).
+				Warning("Unable to delete WireGuard peer")
+		}
+		if oldNode.WireguardPublicKey != "" {
+			return nil
+		}
+	}
+
 	if n.nodeConfig.EnableAutoDirectRouting {
 		n.deleteDirectRoute(oldNode.IPv4AllocCIDR, oldIP4)
 		n.deleteDirectRoute(oldNode.IPv6AllocCIDR, oldIP6)
@@ -763,6 +833,7 @@ func (n *linuxNodeHandler) nodeDelete(oldNode *node.Node) error {
 
 	if n.nodeConfig.EnableIPSec {
 		n.deleteIPsec(oldNode)
+		ipsec.DeleteNodeKey(oldNode.Fullname())
 	}
 
 	return nil
@@ -1017,6 +1088,12 @@ func (n *linuxNodeHandler) NodeConfigurationChanged(newConfig datapath.LocalNode
 
 	n.updateOrRemoveNodeRoutes(prevConfig.AuxiliaryPrefixes, newConfig.AuxiliaryPrefixes)
 
+	if newConfig.EnableWireguard && n.wireguard != nil {
+		if err := n.wireguard.Init(newConfig.MtuConfig.GetRouteMTU()); err != nil {
+			log.WithError(err).Error("Unable to initialize WireGuard")
+		}
+	}
+
 	if newConfig.EnableIPSec {
 		if err := n.replaceHostRules(); err != nil {
 			log.WithError(err).Warning("Cannot replace Host rules")
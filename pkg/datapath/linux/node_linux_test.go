@@ -17,13 +17,18 @@
 package linux
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cilium/cilium/pkg/cidr"
 	"github.com/cilium/cilium/pkg/datapath"
 	"github.com/cilium/cilium/pkg/datapath/fake"
 	"github.com/cilium/cilium/pkg/datapath/linux/route"
+	"github.com/cilium/cilium/pkg/datapath/linux/wireguard"
+	wgfake "github.com/cilium/cilium/pkg/datapath/linux/wireguard/fake"
 	"github.com/cilium/cilium/pkg/maps/tunnel"
 	"github.com/cilium/cilium/pkg/mtu"
 	"github.com/cilium/cilium/pkg/node"
@@ -69,7 +74,7 @@ const (
 
 func (s *linuxPrivilegedBaseTestSuite) SetUpTest(c *check.C, addressing datapath.NodeAddressing, enableIPv6, enableIPv4 bool) {
 	s.nodeAddressing = addressing
-	s.mtuConfig = mtu.NewConfiguration(0, false, false, 1500)
+	s.mtuConfig = mtu.NewConfiguration(0, false, false, false, 1500)
 	s.enableIPv6 = enableIPv6
 	s.enableIPv4 = enableIPv4
 
@@ -761,6 +766,95 @@ func (s *linuxPrivilegedBaseTestSuite) TestNodeUpdateDirectRouting(c *check.C) {
 	c.Assert(len(foundRoutes), check.Equals, 0) // route should not exist regardless whether ipv4 is enabled or not
 }
 
+func (s *linuxPrivilegedBaseTestSuite) TestNodeUpdateWireguardWithoutKey(c *check.C) {
+	ip4Alloc1 := cidr.MustParseCIDR("5.5.5.0/24")
+	externalNode1IP4v1 := net.ParseIP("4.4.4.4")
+
+	externalNode1Device := "dummy_node1"
+	removeDevice(externalNode1Device)
+	err := setupDummyDevice(externalNode1Device, externalNode1IP4v1, net.ParseIP("face::1"))
+	c.Assert(err, check.IsNil)
+	defer removeDevice(externalNode1Device)
+
+	dir, err := ioutil.TempDir("", "wireguard")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	device := wgfake.NewDevice()
+	agent, err := wireguard.NewAgent(device, filepath.Join(dir, wireguard.PrivateKeyFile), 51871)
+	c.Assert(err, check.IsNil)
+	peerKey, err := wireguard.GeneratePrivateKey()
+	c.Assert(err, check.IsNil)
+
+	dpConfig := DatapathConfiguration{HostDevice: dummyHostDeviceName, WireguardAgent: agent}
+	linuxNodeHandler := NewNodeHandler(dpConfig, s.nodeAddressing).(*linuxNodeHandler)
+	c.Assert(linuxNodeHandler, check.Not(check.IsNil))
+	nodeConfig := datapath.LocalNodeConfiguration{
+		MtuConfig:               s.mtuConfig,
+		EnableIPv4:              s.enableIPv4,
+		EnableIPv6:              s.enableIPv6,
+		EnableAutoDirectRouting: true,
+		EnableWireguard:         true,
+	}
+
+	err = linuxNodeHandler.NodeConfigurationChanged(nodeConfig)
+	c.Assert(err, check.IsNil)
+
+	// nodev1: no public key yet, reached via direct route
+	nodev1 := node.Node{
+		Name: "node1",
+		IPAddresses: []node.Address{
+			{IP: externalNode1IP4v1, Type: nodeaddressing.NodeInternalIP},
+		},
+		IPv4AllocCIDR: ip4Alloc1,
+	}
+	err = linuxNodeHandler.NodeAdd(nodev1)
+	c.Assert(err, check.IsNil)
+
+	foundRoutes, err := linuxNodeHandler.lookupDirectRoute(ip4Alloc1, externalNode1IP4v1)
+	c.Assert(err, check.IsNil)
+	if s.enableIPv4 {
+		c.Assert(len(foundRoutes), check.Equals, 1)
+	} else {
+		c.Assert(len(foundRoutes), check.Equals, 0)
+	}
+	c.Assert(device.NumPeers(), check.Equals, 0)
+
+	// nodev2: public key published, routed through the WireGuard device
+	nodev2 := nodev1
+	nodev2.WireguardPublicKey = peerKey.PublicKey().String()
+	err = linuxNodeHandler.NodeUpdate(nodev1, nodev2)
+	c.Assert(err, check.IsNil)
+
+	foundRoutes, err = linuxNodeHandler.lookupDirectRoute(ip4Alloc1, externalNode1IP4v1)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(foundRoutes), check.Equals, 0)
+	c.Assert(device.HasRoute(ip4Alloc1.String()), check.Equals, s.enableIPv4)
+
+	// nodev3: public key withdrawn, reached via direct route again
+	nodev3 := nodev1
+	err = linuxNodeHandler.NodeUpdate(nodev2, nodev3)
+	c.Assert(err, check.IsNil)
+
+	foundRoutes, err = linuxNodeHandler.lookupDirectRoute(ip4Alloc1, externalNode1IP4v1)
+	c.Assert(err, check.IsNil)
+	if s.enableIPv4 {
+		c.Assert(len(foundRoutes), check.Equals, 1)
+	} else {
+		c.Assert(len(foundRoutes), check.Equals, 0)
+	}
+	c.Assert(device.NumPeers(), check.Equals, 0)
+	c.Assert(device.NumRoutes(), check.Equals, 0)
+
+	// delete nodev3
+	err = linuxNodeHandler.NodeDelete(nodev3)
+	c.Assert(err, check.IsNil)
+
+	foundRoutes, err = linuxNodeHandler.lookupDirectRoute(ip4Alloc1, externalNode1IP4v1)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(foundRoutes), check.Equals, 0)
+}
+
 func (s *linuxPrivilegedBaseTestSuite) TestAgentRestartOptionChanges(c *check.C) {
 	ip4Alloc1 := cidr.MustParseCIDR("5.5.5.0/24")
 	ip6Alloc1 := cidr.MustParseCIDR("2001:aaaa::/96")
@@ -0,0 +1,252 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/cilium/cilium/pkg/lock"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DeviceName is the name of the WireGuard device
+	DeviceName = "cilium_wg0"
+
+	// PrivateKeyFile is the name of the file in the state directory the
+	// private key of the node is stored in
+	PrivateKeyFile = "wireguard.key"
+)
+
+// Device is the kernel programming of the WireGuard device. Peers are
+// identified by their base64 encoded public key.
+type Device interface {
+	// Setup creates the device if it does not exist yet, configures the
+	// private key stored in privateKeyFile, the listen port and the MTU
+	// and brings the device up
+	Setup(privateKeyFile string, listenPort, mtu int) error
+
+	// Peers returns the public keys of all peers configured on the device
+	Peers() ([]string, error)
+
+	// UpsertPeer creates or replaces the peer with the given public key
+	UpsertPeer(publicKey string, endpoint *net.UDPAddr, allowedIPs []*net.IPNet) error
+
+	// DeletePeer removes the peer with the given public key
+	DeletePeer(publicKey string) error
+
+	// ReplaceRoute routes dst through the device
+	ReplaceRoute(dst *net.IPNet) error
+
+	// DeleteRoute removes the route of dst through the device
+	DeleteRoute(dst *net.IPNet) error
+}
+
+// peer is the desired configuration of the peer of a remote node
+type peer struct {
+	publicKey  string
+	endpoint   *net.UDPAddr
+	allowedIPs []*net.IPNet
+}
+
+// Agent configures the WireGuard device and reconciles its peers with the
+// remote nodes of the cluster
+type Agent struct {
+	// mutex protects peers
+	mutex lock.Mutex
+
+	device         Device
+	privateKeyFile string
+	privateKey     Key
+	listenPort     int
+
+	// peers maps the name of remote nodes to their peer
+	peers map[string]*peer
+}
+
+// NewAgent returns a new agent configuring device. The private key is read
+// from privateKeyFile or generated if the file does not exist.
+func NewAgent(device Device, privateKeyFile string, listenPort int) (*Agent, error) {
+	key, err := LoadOrGeneratePrivateKey(privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Agent{
+		device:         device,
+		privateKeyFile: privateKeyFile,
+		privateKey:     key,
+		listenPort:     listenPort,
+		peers:          map[string]*peer{},
+	}, nil
+}
+
+// PublicKey returns the public key of the local node
+func (a *Agent) PublicKey() Key {
+	return a.privateKey.PublicKey()
+}
+
+// Init creates and configures the WireGuard device with the given MTU
+func (a *Agent) Init(mtu int) error {
+	if err := a.device.Setup(a.privateKeyFile, a.listenPort, mtu); err != nil {
+		return fmt.Errorf("unable to setup WireGuard device %s: %s", DeviceName, err)
+	}
+	return nil
+}
+
+// UpsertPeer configures the peer of the remote node nodeName with the given
+// public key and node IP and routes allowedIPs, typically the pod CIDRs of
+// the node, through the device. An empty public key removes the peer as the
+// node does not accept encrypted traffic yet.
+func (a *Agent) UpsertPeer(nodeName, publicKey string, nodeIP net.IP, allowedIPs []*net.IPNet) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	old := a.peers[nodeName]
+
+	if publicKey == "" || nodeIP == nil {
+		return a.deletePeer(nodeName, old)
+	}
+
+	if _, err := ParseKey(publicKey); err != nil {
+		return fmt.Errorf("invalid public key of node %s: %s", nodeName, err)
+	}
+
+	newPeer := &peer{
+		publicKey:  publicKey,
+		endpoint:   &net.UDPAddr{IP: nodeIP, Port: a.listenPort},
+		allowedIPs: allowedIPs,
+	}
+
+	if old != nil && old.publicKey != publicKey {
+		if err := a.device.DeletePeer(old.publicKey); err != nil {
+			return fmt.Errorf("unable to delete peer with previous key of node %s: %s", nodeName, err)
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		fieldPublicKey:  publicKey,
+		fieldEndpoint:   newPeer.endpoint,
+		fieldAllowedIPs: allowedIPs,
+	}).Debug("Updating WireGuard peer")
+
+	if err := a.device.UpsertPeer(publicKey, newPeer.endpoint, allowedIPs); err != nil {
+		return fmt.Errorf("unable to update peer of node %s: %s", nodeName, err)
+	}
+	a.peers[nodeName] = newPeer
+
+	for _, cidr := range allowedIPs {
+		if err := a.device.ReplaceRoute(cidr); err != nil {
+			return fmt.Errorf("unable to route %s through %s: %s", cidr, DeviceName, err)
+		}
+	}
+	if old != nil {
+		for _, cidr := range old.allowedIPs {
+			if !containsCIDR(allowedIPs, cidr) {
+				if err := a.device.DeleteRoute(cidr); err != nil {
+					return fmt.Errorf("unable to delete route %s: %s", cidr, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeletePeer removes the peer of the remote node nodeName and the routes of
+// its allowed IPs
+func (a *Agent) DeletePeer(nodeName string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.deletePeer(nodeName, a.peers[nodeName])
+}
+
+func (a *Agent) deletePeer(nodeName string, p *peer) error {
+	if p == nil {
+		return nil
+	}
+
+	if err := a.device.DeletePeer(p.publicKey); err != nil {
+		return fmt.Errorf("unable to delete peer of node %s: %s", nodeName, err)
+	}
+	delete(a.peers, nodeName)
+
+	for _, cidr := range p.allowedIPs {
+		if err := a.device.DeleteRoute(cidr); err != nil {
+			return fmt.Errorf("unable to delete route %s: %s", cidr, err)
+		}
+	}
+
+	return nil
+}
+
+// Reconcile removes the peers of the device which do not belong to a known
+// remote node, e.g. of nodes deleted while the agent was not running, and
+// restores missing peers of known nodes
+func (a *Agent) Reconcile(ctx context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	keys, err := a.device.Peers()
+	if err != nil {
+		return fmt.Errorf("unable to list peers of %s: %s", DeviceName, err)
+	}
+
+	configured := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		configured[key] = struct{}{}
+	}
+
+	desired := make(map[string]struct{}, len(a.peers))
+	for nodeName, p := range a.peers {
+		desired[p.publicKey] = struct{}{}
+		if _, ok := configured[p.publicKey]; ok {
+			continue
+		}
+
+		if err := a.device.UpsertPeer(p.publicKey, p.endpoint, p.allowedIPs); err != nil {
+			return fmt.Errorf("unable to restore peer of node %s: %s", nodeName, err)
+		}
+		for _, cidr := range p.allowedIPs {
+			if err := a.device.ReplaceRoute(cidr); err != nil {
+				return fmt.Errorf("unable to route %s through %s: %s", cidr, DeviceName, err)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if _, ok := desired[key]; !ok {
+			log.WithField(fieldPublicKey, key).Info("Removing stale WireGuard peer")
+			if err := a.device.DeletePeer(key); err != nil {
+				return fmt.Errorf("unable to delete stale peer: %s", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsCIDR(cidrs []*net.IPNet, cidr *net.IPNet) bool {
+	for _, c := range cidrs {
+		if c.String() == cidr.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/cilium/cilium/pkg/datapath/linux/wireguard/fake"
+
+	"gopkg.in/check.v1"
+)
+
+var _ Device = &fake.Device{}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, cidr, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return cidr
+}
+
+func newPublicKey(c *check.C) string {
+	k, err := GeneratePrivateKey()
+	c.Assert(err, check.IsNil)
+	return k.PublicKey().String()
+}
+
+func newTestAgent(c *check.C) (*Agent, *fake.Device, func()) {
+	dir, err := ioutil.TempDir("", "wireguard")
+	c.Assert(err, check.IsNil)
+
+	device := fake.NewDevice()
+	agent, err := NewAgent(device, filepath.Join(dir, PrivateKeyFile), 51871)
+	c.Assert(err, check.IsNil)
+
+	return agent, device, func() { os.RemoveAll(dir) }
+}
+
+func (s *WireguardSuite) TestInit(c *check.C) {
+	agent, device, cleanup := newTestAgent(c)
+	defer cleanup()
+
+	c.Assert(agent.Init(1420), check.IsNil)
+	c.Assert(device.MTU, check.Equals, 1420)
+	c.Assert(device.ListenPort, check.Equals, 51871)
+	c.Assert(device.PrivateKeyFile, check.Equals, agent.privateKeyFile)
+
+	device.SetError(errors.New("no kernel support"))
+	c.Assert(agent.Init(1420), check.Not(check.IsNil))
+}
+
+func (s *WireguardSuite) TestUpsertPeer(c *check.C) {
+	agent, device, cleanup := newTestAgent(c)
+	defer cleanup()
+
+	key1 := newPublicKey(c)
+	cidr1 := mustParseCIDR("10.1.0.0/24")
+	cidr2 := mustParseCIDR("10.2.0.0/24")
+
+	c.Assert(agent.UpsertPeer("node1", key1, net.ParseIP("192.168.1.1"), []*net.IPNet{cidr1}), check.IsNil)
+	p, ok := device.Peer(key1)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(p.Endpoint.String(), check.Equals, "192.168.1.1:51871")
+	c.Assert(device.HasRoute("10.1.0.0/24"), check.Equals, true)
+
+	// Change of the pod CIDR replaces the route
+	c.Assert(agent.UpsertPeer("node1", key1, net.ParseIP("192.168.1.1"), []*net.IPNet{cidr2}), check.IsNil)
+	c.Assert(device.HasRoute("10.1.0.0/24"), check.Equals, false)
+	c.Assert(device.HasRoute("10.2.0.0/24"), check.Equals, true)
+
+	// Rotation of the key of the node replaces the peer
+	key2 := newPublicKey(c)
+	c.Assert(agent.UpsertPeer("node1", key2, net.ParseIP("192.168.1.2"), []*net.IPNet{cidr2}), check.IsNil)
+	_, ok = device.Peer(key1)
+	c.Assert(ok, check.Equals, false)
+	p, ok = device.Peer(key2)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(p.Endpoint.String(), check.Equals, "192.168.1.2:51871")
+	c.Assert(device.NumRoutes(), check.Equals, 1)
+
+	// A node without public key has no peer
+	c.Assert(agent.UpsertPeer("node1", "", net.ParseIP("192.168.1.2"), []*net.IPNet{cidr2}), check.IsNil)
+	c.Assert(device.NumPeers(), check.Equals, 0)
+	c.Assert(device.NumRoutes(), check.Equals, 0)
+
+	c.Assert(agent.UpsertPeer("node2", "invalid", net.ParseIP("192.168.1.3"), nil), check.Not(check.IsNil))
+	c.Assert(device.NumPeers(), check.Equals, 0)
+}
+
+func (s *WireguardSuite) TestDeletePeer(c *check.C) {
+	agent, device, cleanup := newTestAgent(c)
+	defer cleanup()
+
+	key := newPublicKey(c)
+	cidrs := []*net.IPNet{mustParseCIDR("10.1.0.0/24"), mustParseCIDR("f00d::/96")}
+	c.Assert(agent.UpsertPeer("node1", key, net.ParseIP("192.168.1.1"), cidrs), check.IsNil)
+	c.Assert(device.NumRoutes(), check.Equals, 2)
+
+	c.Assert(agent.DeletePeer("node1"), check.IsNil)
+	c.Assert(device.NumPeers(), check.Equals, 0)
+	c.Assert(device.NumRoutes(), check.Equals, 0)
+
+	// Deletion of an unknown node is a no-op
+	c.Assert(agent.DeletePeer("node2"), check.IsNil)
+}
+
+func (s *WireguardSuite) TestReconcile(c *check.C) {
+	agent, device, cleanup := newTestAgent(c)
+	defer cleanup()
+
+	// Peer of a node deleted while the agent was down
+	stale := newPublicKey(c)
+	c.Assert(device.UpsertPeer(stale, &net.UDPAddr{IP: net.ParseIP("192.168.1.9")}, nil), check.IsNil)
+
+	key := newPublicKey(c)
+	c.Assert(agent.UpsertPeer("node1", key, net.ParseIP("192.168.1.1"),
+		[]*net.IPNet{mustParseCIDR("10.1.0.0/24")}), check.IsNil)
+
+	c.Assert(agent.Reconcile(context.Background()), check.IsNil)
+	_, ok := device.Peer(stale)
+	c.Assert(ok, check.Equals, false)
+	_, ok = device.Peer(key)
+	c.Assert(ok, check.Equals, true)
+
+	// Peers removed behind the agent's back are restored
+	c.Assert(device.DeletePeer(key), check.IsNil)
+	c.Assert(device.DeleteRoute(mustParseCIDR("10.1.0.0/24")), check.IsNil)
+	c.Assert(agent.Reconcile(context.Background()), check.IsNil)
+	_, ok = device.Peer(key)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(device.HasRoute("10.1.0.0/24"), check.Equals, true)
+
+	device.SetError(errors.New("netlink error"))
+	c.Assert(agent.Reconcile(context.Background()), check.Not(check.IsNil))
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	osexec "os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cilium/cilium/pkg/command/exec"
+	"github.com/cilium/cilium/pkg/datapath/linux/route"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	// wgCommand is the WireGuard configuration tool used to configure the
+	// keys and peers of the device
+	wgCommand = "wg"
+
+	// wgTimeout is the timeout of a single invocation of wgCommand
+	wgTimeout = 10 * time.Second
+)
+
+// linuxDevice configures the kernel WireGuard device
+type linuxDevice struct{}
+
+// NewDevice returns the Device configuring the kernel WireGuard device
+// DeviceName via netlink and the wg tool
+func NewDevice() Device {
+	return &linuxDevice{}
+}
+
+func wg(args ...string) ([]byte, error) {
+	return exec.WithTimeout(wgTimeout, wgCommand, args...).CombinedOutput(log, true)
+}
+
+func (d *linuxDevice) Setup(privateKeyFile string, listenPort, mtu int) error {
+	if _, err := osexec.LookPath(wgCommand); err != nil {
+		return fmt.Errorf("unable to find %s tool: %s", wgCommand, err)
+	}
+
+	link, err := netlink.LinkByName(DeviceName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); !ok {
+			return err
+		}
+		link = &netlink.GenericLink{
+			LinkAttrs: netlink.LinkAttrs{Name: DeviceName},
+			LinkType:  "wireguard",
+		}
+		if err := netlink.LinkAdd(link); err != nil {
+			return fmt.Errorf("unable to create device: %s", err)
+		}
+	}
+
+	if err := netlink.LinkSetMTU(link, mtu); err != nil {
+		return fmt.Errorf("unable to set MTU: %s", err)
+	}
+
+	if _, err := wg("set", DeviceName,
+		"private-key", privateKeyFile,
+		"listen-port", strconv.Itoa(listenPort)); err != nil {
+		return err
+	}
+
+	return netlink.LinkSetUp(link)
+}
+
+func (d *linuxDevice) Peers() ([]string, error) {
+	out, err := wg("show", DeviceName, "peers")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+func (d *linuxDevice) UpsertPeer(publicKey string, endpoint *net.UDPAddr, allowedIPs []*net.IPNet) error {
+	ips := make([]string, 0, len(allowedIPs))
+	for _, cidr := range allowedIPs {
+		ips = append(ips, cidr.String())
+	}
+
+	// Replace the allowed IPs of the peer instead of adding to them
+	_, err := wg("set", DeviceName,
+		"peer", publicKey,
+		"endpoint", endpoint.String(),
+		"replace-allowed-ips",
+		"allowed-ips", strings.Join(ips, ","))
+	return err
+}
+
+func (d *linuxDevice) DeletePeer(publicKey string) error {
+	_, err := wg("set", DeviceName, "peer", publicKey, "remove")
+	return err
+}
+
+func (d *linuxDevice) ReplaceRoute(dst *net.IPNet) error {
+	_, err := route.Upsert(route.Route{
+		Prefix: *dst,
+		Device: DeviceName,
+		Scope:  netlink.SCOPE_LINK,
+	}, nil)
+	return err
+}
+
+func (d *linuxDevice) DeleteRoute(dst *net.IPNet) error {
+	return route.Delete(route.Route{
+		Prefix: *dst,
+		Device: DeviceName,
+		Scope:  netlink.SCOPE_LINK,
+	})
+}
@@ -0,0 +1,174 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+// Peer is a peer configured on the fake device
+type Peer struct {
+	Endpoint   *net.UDPAddr
+	AllowedIPs []*net.IPNet
+}
+
+// Device is a fake WireGuard device which keeps its configuration in memory
+type Device struct {
+	mutex lock.Mutex
+
+	// PrivateKeyFile, ListenPort and MTU are the arguments of the last
+	// call to Setup
+	PrivateKeyFile string
+	ListenPort     int
+	MTU            int
+
+	peers  map[string]Peer
+	routes map[string]struct{}
+	err    error
+}
+
+// NewDevice returns a new fake device
+func NewDevice() *Device {
+	return &Device{
+		peers:  map[string]Peer{},
+		routes: map[string]struct{}{},
+	}
+}
+
+// SetError causes all subsequent operations to fail with err until
+// SetError(nil) is called
+func (d *Device) SetError(err error) {
+	d.mutex.Lock()
+	d.err = err
+	d.mutex.Unlock()
+}
+
+// Setup records the device configuration
+func (d *Device) Setup(privateKeyFile string, listenPort, mtu int) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return d.err
+	}
+	d.PrivateKeyFile, d.ListenPort, d.MTU = privateKeyFile, listenPort, mtu
+	return nil
+}
+
+// Peers returns the public keys of all peers
+func (d *Device) Peers() ([]string, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	keys := make([]string, 0, len(d.peers))
+	for key := range d.peers {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// UpsertPeer creates or replaces a peer
+func (d *Device) UpsertPeer(publicKey string, endpoint *net.UDPAddr, allowedIPs []*net.IPNet) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return d.err
+	}
+	d.peers[publicKey] = Peer{Endpoint: endpoint, AllowedIPs: allowedIPs}
+	return nil
+}
+
+// DeletePeer removes a peer
+func (d *Device) DeletePeer(publicKey string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return d.err
+	}
+	if _, ok := d.peers[publicKey]; !ok {
+		return fmt.Errorf("peer %s not found", publicKey)
+	}
+	delete(d.peers, publicKey)
+	return nil
+}
+
+// ReplaceRoute adds a route through the device
+func (d *Device) ReplaceRoute(dst *net.IPNet) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return d.err
+	}
+	d.routes[dst.String()] = struct{}{}
+	return nil
+}
+
+// DeleteRoute removes a route through the device
+func (d *Device) DeleteRoute(dst *net.IPNet) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.err != nil {
+		return d.err
+	}
+	if _, ok := d.routes[dst.String()]; !ok {
+		return fmt.Errorf("route %s not found", dst)
+	}
+	delete(d.routes, dst.String())
+	return nil
+}
+
+// Peer returns the peer with the given public key
+func (d *Device) Peer(publicKey string) (Peer, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	p, ok := d.peers[publicKey]
+	return p, ok
+}
+
+// NumPeers returns the number of peers
+func (d *Device) NumPeers() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.peers)
+}
+
+// HasRoute returns true if dst is routed through the device
+func (d *Device) HasRoute(dst string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	_, ok := d.routes[dst]
+	return ok
+}
+
+// NumRoutes returns the number of routes through the device
+func (d *Device) NumRoutes() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.routes)
+}
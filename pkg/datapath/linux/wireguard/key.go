@@ -0,0 +1,104 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// KeyLen is the length of a WireGuard key in bytes
+const KeyLen = 32
+
+// Key is a Curve25519 private or public key
+type Key [KeyLen]byte
+
+// GeneratePrivateKey returns a new random private key
+func GeneratePrivateKey() (Key, error) {
+	var k Key
+	if _, err := rand.Read(k[:]); err != nil {
+		return Key{}, fmt.Errorf("unable to generate private key: %s", err)
+	}
+
+	// Clamp the key as specified for Curve25519
+	k[0] &= 248
+	k[31] &= 127
+	k[31] |= 64
+
+	return k, nil
+}
+
+// ParseKey parses a base64 encoded key
+func ParseKey(s string) (Key, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid key: %s", err)
+	}
+	if len(b) != KeyLen {
+		return Key{}, fmt.Errorf("invalid key length %d", len(b))
+	}
+
+	var k Key
+	copy(k[:], b)
+	return k, nil
+}
+
+// PublicKey returns the public key of the private key k
+func (k Key) PublicKey() Key {
+	var pub Key
+	curve25519.ScalarBaseMult((*[KeyLen]byte)(&pub), (*[KeyLen]byte)(&k))
+	return pub
+}
+
+// String returns the base64 encoding of the key as used by the wg tool
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// LoadOrGeneratePrivateKey reads the base64 encoded private key from path.
+// If the file does not exist, a new private key is generated and written to
+// path so that the node keeps its identity across restarts.
+func LoadOrGeneratePrivateKey(path string) (Key, error) {
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		return ParseKey(string(b))
+	case !os.IsNotExist(err):
+		return Key{}, fmt.Errorf("unable to read private key: %s", err)
+	}
+
+	k, err := GeneratePrivateKey()
+	if err != nil {
+		return Key{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return Key{}, fmt.Errorf("unable to create directory for private key: %s", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(k.String()+"\n"), 0600); err != nil {
+		return Key{}, fmt.Errorf("unable to write private key: %s", err)
+	}
+
+	log.WithField(fieldPublicKey, k.PublicKey()).Info("Generated new WireGuard private key")
+
+	return k, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package wireguard
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type WireguardSuite struct{}
+
+var _ = check.Suite(&WireguardSuite{})
+
+func (s *WireguardSuite) TestKey(c *check.C) {
+	// Test vector of RFC 7748, section 6.1
+	priv, err := ParseKey("dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo=")
+	c.Assert(err, check.IsNil)
+	c.Assert(priv.PublicKey().String(), check.Equals, "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo=")
+
+	_, err = ParseKey("invalid")
+	c.Assert(err, check.Not(check.IsNil))
+	_, err = ParseKey("AAAA")
+	c.Assert(err, check.Not(check.IsNil))
+
+	k, err := GeneratePrivateKey()
+	c.Assert(err, check.IsNil)
+	c.Assert(k[0]&7, check.Equals, byte(0))
+	c.Assert(k[31]&192, check.Equals, byte(64))
+
+	parsed, err := ParseKey(k.String() + "\n")
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed, check.Equals, k)
+}
+
+func (s *WireguardSuite) TestLoadOrGeneratePrivateKey(c *check.C) {
+	dir, err := ioutil.TempDir("", "wireguard")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state", PrivateKeyFile)
+	k1, err := LoadOrGeneratePrivateKey(path)
+	c.Assert(err, check.IsNil)
+
+	info, err := os.Stat(path)
+	c.Assert(err, check.IsNil)
+	c.Assert(info.Mode().Perm(), check.Equals, os.FileMode(0600))
+
+	// The key is kept across restarts
+	k2, err := LoadOrGeneratePrivateKey(path)
+	c.Assert(err, check.IsNil)
+	c.Assert(k2, check.Equals, k1)
+
+	c.Assert(ioutil.WriteFile(path, []byte("garbage"), 0600), check.IsNil)
+	_, err = LoadOrGeneratePrivateKey(path)
+	c.Assert(err, check.Not(check.IsNil))
+}
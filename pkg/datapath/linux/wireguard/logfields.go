@@ -0,0 +1,33 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"github.com/cilium/cilium/pkg/logging"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+)
+
+var log = logging.DefaultLogger.WithField(logfields.LogSubsys, "wireguard")
+
+const (
+	// fieldPublicKey is the public key of a peer
+	fieldPublicKey = "publicKey"
+
+	// fieldEndpoint is the UDP endpoint of a peer
+	fieldEndpoint = "endpoint"
+
+	// fieldAllowedIPs are the CIDRs routed to a peer
+	fieldAllowedIPs = "allowedIPs"
+)
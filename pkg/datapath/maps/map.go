@@ -172,6 +172,8 @@ func RemoveDisabledMaps() {
 			"cilium_lb6_services",
 			"cilium_lb6_services_v2",
 			"cilium_lb6_rr_seq_v2",
+			"cilium_lb6_maglev",
+			"cilium_lb6_affinity",
 			"cilium_lb6_backends",
 			"cilium_lb6_reverse_sk",
 			"cilium_snat_v6_external",
@@ -187,6 +189,8 @@ func RemoveDisabledMaps() {
 			"cilium_lb4_services",
 			"cilium_lb4_services_v2",
 			"cilium_lb4_rr_seq_v2",
+			"cilium_lb4_maglev",
+			"cilium_lb4_affinity",
 			"cilium_lb4_backends",
 			"cilium_lb4_reverse_sk",
 			"cilium_snat_v4_external",
@@ -97,6 +97,10 @@ type LocalNodeConfiguration struct {
 	// EncryptNode enables encrypting NodeIP traffic requires EnableIPSec
 	EncryptNode bool
 
+	// EnableWireguard enables the encryption of traffic to the pod CIDRs
+	// of remote nodes with WireGuard
+	EnableWireguard bool
+
 	// IPv4PodSubnets is a list of IPv4 subnets that pod IPs are assigned from
 	// these are then used when encryption is enabled to configure the node
 	// for encryption over these subnets at node initialization.
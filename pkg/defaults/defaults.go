@@ -65,6 +65,11 @@ const (
 	// This is the 1.2 protocol version.
 	MonitorSockPath1_2 = RuntimePath + "/monitor1_2.sock"
 
+	// MonitorFlowSockPath1_0 is the path to the UNIX domain socket used to
+	// distribute decoded and filtered flows to listeners.
+	// This is the flow 1.0 protocol version.
+	MonitorFlowSockPath1_0 = RuntimePath + "/monitor_flow1_0.sock"
+
 	// PidFilePath is the path to the pid file for the agent.
 	PidFilePath = RuntimePath + "/cilium.pid"
 
@@ -101,6 +106,11 @@ const (
 	// for each FQDN name in an endpoint's FQDN cache
 	ToFQDNsMaxIPsPerHost = 50
 
+	// ToFQDNsProxyRateLimitBurst is the maximum number of DNS requests of
+	// an endpoint the DNS proxy forwards in a burst when rate limiting is
+	// enabled
+	ToFQDNsProxyRateLimitBurst = 20
+
 	// ToFQDNsPreCache is a path to a file with DNS cache data to insert into the
 	// global cache on startup.
 	// The file is not re-read after agent start.
@@ -140,6 +150,16 @@ const (
 	// which are not part of Cilium manged pods.
 	EncryptNode = false
 
+	// EnableWireguard is the default value for WireGuard enablement
+	EnableWireguard = false
+
+	// WireguardListenPort is the default UDP port WireGuard listens on
+	WireguardListenPort = 51871
+
+	// WireguardPeerGCInterval is the interval in which stale WireGuard
+	// peers are removed
+	WireguardPeerGCInterval = 5 * time.Minute
+
 	// MonitorQueueSizePerCPU is the default value for the monitor queue
 	// size per CPU
 	MonitorQueueSizePerCPU = 1024
@@ -159,12 +179,29 @@ const (
 	// DatapathMode is the default value for the datapath mode.
 	DatapathMode = "veth"
 
+	// LBAlgorithm is the default algorithm used to select the backend of
+	// new connections to services
+	LBAlgorithm = "random"
+
+	// SessionAffinityTimeoutSec is the default time in seconds after which
+	// an idle ClientIP session affinity of a service expires, it matches
+	// the default of Kubernetes
+	SessionAffinityTimeoutSec = 10800
+
+	// SessionAffinityGCInterval is the interval in which expired session
+	// affinity entries are removed from the BPF maps
+	SessionAffinityGCInterval = time.Minute
+
 	// EnableAutoDirectRouting is the default value for EnableAutoDirectRouting
 	EnableAutoDirectRouting = false
 
 	// EnableHealthChecking is the default value for EnableHealthChecking
 	EnableHealthChecking = true
 
+	// HealthHistoryWindow is the default time window over which the
+	// history of connectivity health probes is kept
+	HealthHistoryWindow = time.Hour
+
 	// AlignCheckerName is the BPF object name for the alignchecker.
 	AlignCheckerName = "bpf_alignchecker.o"
 
@@ -274,6 +311,18 @@ const (
 	// CiliumNode.Spec.ENI.PreAllocate if no value is set
 	ENIPreAllocation = 8
 
+	// IPAMPreAllocation is the default value for
+	// CiliumNode.Spec.IPAM.PreAllocate if no value is set
+	IPAMPreAllocation = 8
+
+	// ClusterPoolIPv4MaskSize is the default mask size of the IPv4
+	// PodCIDRs allocated in cluster-pool IPAM mode
+	ClusterPoolIPv4MaskSize = 24
+
+	// ClusterPoolIPv6MaskSize is the default mask size of the IPv6
+	// PodCIDRs allocated in cluster-pool IPAM mode
+	ClusterPoolIPv6MaskSize = IPv6NodePrefixLen
+
 	// AutoCreateCiliumNodeResource enables automatic creation of a
 	// CiliumNode resource for the local node
 	AutoCreateCiliumNodeResource = true
@@ -215,6 +215,10 @@ func (e *Endpoint) addNewRedirectsFromMap(m policy.L4PolicyMap, desiredRedirects
 			keysFromFilter := l4.ToKeys(direction)
 
 			for _, keyFromFilter := range keysFromFilter {
+				// Deny entries take precedence over redirects.
+				if e.desiredPolicy.PolicyMapState.IsDenied(keyFromFilter) {
+					continue
+				}
 				if oldEntry, ok := e.desiredPolicy.PolicyMapState[keyFromFilter]; ok {
 					updatedDesiredMapState[keyFromFilter] = oldEntry
 				} else {
@@ -901,7 +905,12 @@ func (e *Endpoint) addPolicyKey(keyToAdd policy.Key, entry policy.MapStateEntry,
 		TrafficDirection: keyToAdd.TrafficDirection,
 	}
 
-	err := e.policyMap.AllowKey(policymapKey, entry.ProxyPort)
+	var err error
+	if entry.IsDeny {
+		err = e.policyMap.DenyKey(policymapKey)
+	} else {
+		err = e.policyMap.AllowKey(policymapKey, entry.ProxyPort)
+	}
 	if err != nil {
 		e.getLogger().WithError(err).WithFields(logrus.Fields{
 			logfields.BPFMapKey: policymapKey,
@@ -921,6 +930,45 @@ func (e *Endpoint) addPolicyKey(keyToAdd policy.Key, entry policy.MapStateEntry,
 	return true
 }
 
+// applyIncrementalPolicyMapChanges applies the given incremental
+// changes to the desired policy map state, returning the number of
+// failed policy map updates.
+func (e *Endpoint) applyIncrementalPolicyMapChanges(adds, deletes policy.MapState) (errors int) {
+	for keyToAdd, entry := range adds {
+		// Keep the existing proxy port, if any
+		if !entry.IsDeny {
+			entry.ProxyPort = e.realizedRedirects[policy.ProxyIDFromKey(e.ID, keyToAdd)]
+		}
+		// Deny entries may shadow or remove other entries, apply all
+		// the resulting changes.
+		changedAdds, changedDeletes := make(policy.MapState), make(policy.MapState)
+		e.desiredPolicy.PolicyMapState.DenyPreferredInsertWithChanges(keyToAdd, entry, changedAdds, changedDeletes)
+		for k, v := range changedAdds {
+			if !e.addPolicyKey(k, v, true) {
+				errors++
+			}
+		}
+		for k := range changedDeletes {
+			if !e.deletePolicyKey(k, true) {
+				errors++
+			}
+		}
+	}
+
+	for keyToDelete, entry := range deletes {
+		// Removing an allow entry must not remove a deny entry for the
+		// same key, and vice versa.
+		if oldEntry, ok := e.desiredPolicy.PolicyMapState[keyToDelete]; ok && oldEntry.IsDeny != entry.IsDeny {
+			continue
+		}
+		if !e.deletePolicyKey(keyToDelete, true) {
+			errors++
+		}
+	}
+
+	return errors
+}
+
 // applyPolicyMapChanges applies any incremental policy map changes
 // collected on the desired policy.
 func (e *Endpoint) applyPolicyMapChanges() error {
@@ -932,20 +980,23 @@ func (e *Endpoint) applyPolicyMapChanges() error {
 	//  collected on the newly computed desired policy, which is
 	//  not fully realized yet. This is why we get the map changes
 	//  from the desired policy here.
-	adds, deletes := e.desiredPolicy.PolicyMapChanges.ConsumeMapChanges()
+	adds, deletes, recomputed := e.desiredPolicy.ConsumeMapChanges()
 
-	for keyToAdd, entry := range adds {
-		// Keep the existing proxy port, if any
-		entry.ProxyPort = e.realizedRedirects[policy.ProxyIDFromKey(e.ID, keyToAdd)]
-		if !e.addPolicyKey(keyToAdd, entry, true) {
-			errors++
+	if recomputed {
+		// The changes are the complete differences to the
+		// desired policy map state, apply them as-is.
+		for keyToAdd, entry := range adds {
+			if !e.addPolicyKey(keyToAdd, entry, true) {
+				errors++
+			}
 		}
-	}
-
-	for keyToDelete := range deletes {
-		if !e.deletePolicyKey(keyToDelete, true) {
-			errors++
+		for keyToDelete := range deletes {
+			if !e.deletePolicyKey(keyToDelete, true) {
+				errors++
+			}
 		}
+	} else {
+		errors += e.applyIncrementalPolicyMapChanges(adds, deletes)
 	}
 
 	if errors > 0 {
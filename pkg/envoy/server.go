@@ -520,6 +520,46 @@ func getHTTPRule(h *api.PortRuleHTTP) (headers []*envoy_api_v2_route.HeaderMatch
 	return
 }
 
+func getGRPCRule(g *api.PortRuleGRPC) (headers []*envoy_api_v2_route.HeaderMatcher, ruleRef string) {
+	headers = make([]*envoy_api_v2_route.HeaderMatcher, 0, 3+len(g.Metadata))
+
+	// gRPC requests are always HTTP/2 POST requests to "/<service>/<method>"
+	headers = append(headers, &envoy_api_v2_route.HeaderMatcher{Name: ":method",
+		HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: "POST"}})
+	headers = append(headers, &envoy_api_v2_route.HeaderMatcher{Name: "content-type",
+		HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PrefixMatch{PrefixMatch: "application/grpc"}})
+	if g.Method != "" {
+		headers = append(headers, &envoy_api_v2_route.HeaderMatcher{Name: ":path",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: g.Path()}})
+		ruleRef = `GRPCMethod("` + g.Service + `","` + g.Method + `")`
+	} else {
+		headers = append(headers, &envoy_api_v2_route.HeaderMatcher{Name: ":path",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PrefixMatch{PrefixMatch: g.Path()}})
+		ruleRef = `GRPCService("` + g.Service + `")`
+	}
+
+	for _, md := range g.Metadata {
+		strs := strings.SplitN(md, " ", 2)
+		ruleRef += ` && Metadata("`
+		if len(strs) == 2 {
+			// Remove ':' in "x-key: true"
+			key := strings.TrimRight(strs[0], ":")
+			// Metadata presence and matching (literal) value needed.
+			headers = append(headers, &envoy_api_v2_route.HeaderMatcher{Name: key,
+				HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: strs[1]}})
+			ruleRef += key + `","` + strs[1]
+		} else {
+			// Only metadata presence needed
+			headers = append(headers, &envoy_api_v2_route.HeaderMatcher{Name: strs[0],
+				HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PresentMatch{PresentMatch: true}})
+			ruleRef += strs[0]
+		}
+		ruleRef += `")`
+	}
+	SortHeaderMatchers(headers)
+	return
+}
+
 func createBootstrap(filePath string, name, cluster, version string, xdsSock, egressClusterName, ingressClusterName string, adminPath string) {
 	connectTimeout := int64(option.Config.ProxyConnectTimeout) // in seconds
 
@@ -631,12 +671,16 @@ func getPortNetworkPolicyRule(sel policy.CachedSelector, l7Parser policy.L7Parse
 
 	switch l7Parser {
 	case policy.ParserTypeHTTP:
-		if len(l7Rules.HTTP) > 0 { // Just cautious. This should never be false.
-			httpRules := make([]*cilium.HttpNetworkPolicyRule, 0, len(l7Rules.HTTP))
+		if len(l7Rules.HTTP) > 0 || len(l7Rules.GRPC) > 0 { // Just cautious. This should never be false.
+			httpRules := make([]*cilium.HttpNetworkPolicyRule, 0, len(l7Rules.HTTP)+len(l7Rules.GRPC))
 			for _, l7 := range l7Rules.HTTP {
 				headers, _ := getHTTPRule(&l7)
 				httpRules = append(httpRules, &cilium.HttpNetworkPolicyRule{Headers: headers})
 			}
+			for _, l7 := range l7Rules.GRPC {
+				headers, _ := getGRPCRule(&l7)
+				httpRules = append(httpRules, &cilium.HttpNetworkPolicyRule{Headers: headers})
+			}
 			SortHTTPNetworkPolicyRules(httpRules)
 			r.L7 = &cilium.PortNetworkPolicyRule_HttpRules{
 				HttpRules: &cilium.HttpNetworkPolicyRules{
@@ -60,6 +60,16 @@ var PortRuleHTTP3 = &api.PortRuleHTTP{
 	Method: "GET",
 }
 
+var PortRuleGRPC1 = &api.PortRuleGRPC{
+	Service:  "helloworld.Greeter",
+	Method:   "SayHello",
+	Metadata: []string{"x-tenant: blue", "x-trace"},
+}
+
+var PortRuleGRPC2 = &api.PortRuleGRPC{
+	Service: "helloworld.Greeter",
+}
+
 var ExpectedHeaders1 = []*envoy_api_v2_route.HeaderMatcher{
 	{
 		Name:                 ":authority",
@@ -387,6 +397,50 @@ func (s *ServerSuite) TestGetHTTPRule(c *C) {
 	c.Assert(obtained, checker.Equals, ExpectedHeaders1)
 }
 
+func (s *ServerSuite) TestGetGRPCRule(c *C) {
+	obtained, ruleRef := getGRPCRule(PortRuleGRPC1)
+	c.Assert(obtained, checker.Equals, []*envoy_api_v2_route.HeaderMatcher{
+		{
+			Name:                 ":method",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: "POST"},
+		},
+		{
+			Name:                 ":path",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: "/helloworld.Greeter/SayHello"},
+		},
+		{
+			Name:                 "content-type",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PrefixMatch{PrefixMatch: "application/grpc"},
+		},
+		{
+			Name:                 "x-tenant",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: "blue"},
+		},
+		{
+			Name:                 "x-trace",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PresentMatch{PresentMatch: true},
+		},
+	})
+	c.Assert(ruleRef, Equals, `GRPCMethod("helloworld.Greeter","SayHello") && Metadata("x-tenant","blue") && Metadata("x-trace")`)
+
+	// Without a method, all methods of the service are matched
+	obtained, _ = getGRPCRule(PortRuleGRPC2)
+	c.Assert(obtained, checker.Equals, []*envoy_api_v2_route.HeaderMatcher{
+		{
+			Name:                 ":method",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{ExactMatch: "POST"},
+		},
+		{
+			Name:                 ":path",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PrefixMatch{PrefixMatch: "/helloworld.Greeter/"},
+		},
+		{
+			Name:                 "content-type",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_PrefixMatch{PrefixMatch: "application/grpc"},
+		},
+	})
+}
+
 func (s *ServerSuite) TestGetPortNetworkPolicyRule(c *C) {
 	obtained := getPortNetworkPolicyRule(cachedSelector1, policy.ParserTypeHTTP, L7Rules1)
 	c.Assert(obtained, checker.Equals, ExpectedPortNetworkPolicyRule1)
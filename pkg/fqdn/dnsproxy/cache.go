@@ -0,0 +1,186 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproxy
+
+import (
+	"math"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+
+	"github.com/miekg/dns"
+)
+
+// responseCache caches DNS responses of upstream servers for the lowest TTL
+// of their records. It is shared by all endpoints, the policy check is done
+// before the cache is consulted.
+// Entries are keyed by the exact question, including the case of the name,
+// so that cached answers match the case of the query.
+type responseCache struct {
+	maxEntries int
+
+	mutex   lock.Mutex
+	entries map[cacheKey]*cacheEntry
+}
+
+// cacheKey identifies the request a response has been received for. Besides
+// the question, the upstream server and the request flags affecting the
+// content of the response are part of the key.
+type cacheKey struct {
+	question dns.Question
+	server   string
+
+	// edns is true if the request has an OPT record, do if the DNSSEC OK
+	// bit is set in it
+	edns bool
+	do   bool
+
+	// cd is the Checking Disabled flag of the request
+	cd bool
+}
+
+// newCacheKey returns the key of request sent to server. request must have
+// exactly one question.
+func newCacheKey(request *dns.Msg, server string) cacheKey {
+	key := cacheKey{
+		question: request.Question[0],
+		server:   server,
+		cd:       request.CheckingDisabled,
+	}
+	if opt := request.IsEdns0(); opt != nil {
+		key.edns = true
+		key.do = opt.Do()
+	}
+	return key
+}
+
+type cacheEntry struct {
+	response *dns.Msg
+	stored   time.Time
+	expires  time.Time
+}
+
+// newResponseCache returns a cache holding up to maxEntries responses.
+// Returns nil if maxEntries is not positive, a nil cache never holds any
+// responses.
+func newResponseCache(maxEntries int) *responseCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	return &responseCache{
+		maxEntries: maxEntries,
+		entries:    map[cacheKey]*cacheEntry{},
+	}
+}
+
+// cacheTTL returns the number of seconds response may be cached for, 0 if it
+// must not be cached. Only complete, successful responses with at least one
+// answer are cached.
+func cacheTTL(response *dns.Msg) uint32 {
+	if response.Rcode != dns.RcodeSuccess || response.Truncated ||
+		len(response.Question) != 1 || len(response.Answer) == 0 {
+		return 0
+	}
+
+	ttl := uint32(math.MaxUint32)
+	for _, sections := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range sections {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if rr.Header().Ttl < ttl {
+				ttl = rr.Header().Ttl
+			}
+		}
+	}
+	return ttl
+}
+
+// add stores response to request received from server at time now.
+// Responses that must not be cached are ignored.
+func (c *responseCache) add(request *dns.Msg, server string, response *dns.Msg, now time.Time) {
+	if c == nil || len(request.Question) != 1 {
+		return
+	}
+	ttl := cacheTTL(response)
+	if ttl == 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := newCacheKey(request, server)
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.entries[key] = &cacheEntry{
+		response: response.Copy(),
+		stored:   now,
+		expires:  now.Add(time.Duration(ttl) * time.Second),
+	}
+}
+
+// evictLocked removes all expired entries or, if there are none, an
+// arbitrary entry
+func (c *responseCache) evictLocked(now time.Time) {
+	evicted := false
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			evicted = true
+		}
+	}
+	if evicted {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
+
+// lookup returns a copy of the cached response of server to request at time
+// now, with the TTLs of all records reduced by the time the response has been
+// cached for. Returns nil if no unexpired response is cached.
+func (c *responseCache) lookup(request *dns.Msg, server string, now time.Time) *dns.Msg {
+	if c == nil || len(request.Question) != 1 {
+		return nil
+	}
+
+	key := newCacheKey(request, server)
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	if ok && !now.Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	response := entry.response.Copy()
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	for _, sections := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range sections {
+			if rr.Header().Rrtype != dns.TypeOPT {
+				rr.Header().Ttl -= elapsed
+			}
+		}
+	}
+	response.Id = request.Id
+	return response
+}
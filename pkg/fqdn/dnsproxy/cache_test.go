@@ -0,0 +1,172 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package dnsproxy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+	. "gopkg.in/check.v1"
+)
+
+// testServer is the upstream server of the cached responses
+const testServer = "10.0.0.1:53"
+
+type DNSProxyCacheTestSuite struct{}
+
+var _ = Suite(&DNSProxyCacheTestSuite{})
+
+// newTestExchange returns a request for name and a response with an A record
+// for each of the given TTLs
+func newTestExchange(c *C, name string, ttls ...int) (request, response *dns.Msg) {
+	request = new(dns.Msg)
+	request.SetQuestion(name, dns.TypeA)
+	response = new(dns.Msg)
+	response.SetReply(request)
+	for i, ttl := range ttls {
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN A 1.1.1.%d", name, ttl, i+1))
+		c.Assert(err, IsNil)
+		response.Answer = append(response.Answer, rr)
+	}
+	return request, response
+}
+
+func (s *DNSProxyCacheTestSuite) TestDisabled(c *C) {
+	cache := newResponseCache(0)
+	c.Assert(cache, IsNil)
+
+	request, response := newTestExchange(c, "cilium.io.", 60)
+	cache.add(request, testServer, response, time.Now())
+	c.Assert(cache.lookup(request, testServer, time.Now()), IsNil)
+}
+
+func (s *DNSProxyCacheTestSuite) TestTTL(c *C) {
+	cache := newResponseCache(10)
+	now := time.Now()
+
+	request, response := newTestExchange(c, "cilium.io.", 60, 30)
+	cache.add(request, testServer, response, now)
+
+	request.Id = 4242
+	cached := cache.lookup(request, testServer, now.Add(10*time.Second))
+	c.Assert(cached, Not(IsNil))
+	c.Assert(cached.Id, Equals, uint16(4242))
+	c.Assert(len(cached.Answer), Equals, 2)
+	c.Assert(cached.Answer[0].Header().Ttl, Equals, uint32(50))
+	c.Assert(cached.Answer[1].Header().Ttl, Equals, uint32(20))
+
+	// The cached response is not modified by lookups
+	cached = cache.lookup(request, testServer, now.Add(20*time.Second))
+	c.Assert(cached.Answer[1].Header().Ttl, Equals, uint32(10))
+
+	// The response expires with the lowest TTL
+	c.Assert(cache.lookup(request, testServer, now.Add(30*time.Second)), IsNil)
+	c.Assert(len(cache.entries), Equals, 0)
+}
+
+func (s *DNSProxyCacheTestSuite) TestNotCached(c *C) {
+	cache := newResponseCache(10)
+	now := time.Now()
+
+	// No answers
+	request, response := newTestExchange(c, "cilium.io.")
+	cache.add(request, testServer, response, now)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+
+	// TTL of 0
+	request, response = newTestExchange(c, "cilium.io.", 60, 0)
+	cache.add(request, testServer, response, now)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+
+	// Truncated
+	request, response = newTestExchange(c, "cilium.io.", 60)
+	response.Truncated = true
+	cache.add(request, testServer, response, now)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+
+	// Not NOERROR
+	request, response = newTestExchange(c, "cilium.io.", 60)
+	response.Rcode = dns.RcodeServerFailure
+	cache.add(request, testServer, response, now)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+}
+
+func (s *DNSProxyCacheTestSuite) TestQuestionMismatch(c *C) {
+	cache := newResponseCache(10)
+	now := time.Now()
+
+	request, response := newTestExchange(c, "cilium.io.", 60)
+	cache.add(request, testServer, response, now)
+
+	request = new(dns.Msg)
+	request.SetQuestion("cilium.io.", dns.TypeAAAA)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+
+	request.SetQuestion("CILIUM.io.", dns.TypeA)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+}
+
+func (s *DNSProxyCacheTestSuite) TestKey(c *C) {
+	cache := newResponseCache(10)
+	now := time.Now()
+
+	request, response := newTestExchange(c, "cilium.io.", 60)
+	cache.add(request, testServer, response, now)
+	c.Assert(cache.lookup(request, testServer, now), Not(IsNil))
+
+	// Responses of other servers are not shared
+	c.Assert(cache.lookup(request, "10.0.0.2:53", now), IsNil)
+
+	// Nor are responses to requests with different EDNS, DO or CD flags
+	request.CheckingDisabled = true
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+	request.CheckingDisabled = false
+	request.SetEdns0(4096, false)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+	request.IsEdns0().SetDo()
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+
+	cache.add(request, testServer, response, now)
+	c.Assert(cache.lookup(request, testServer, now), Not(IsNil))
+	request.IsEdns0().SetDo(false)
+	c.Assert(cache.lookup(request, testServer, now), IsNil)
+}
+
+func (s *DNSProxyCacheTestSuite) TestEviction(c *C) {
+	cache := newResponseCache(2)
+	now := time.Now()
+
+	request1, response := newTestExchange(c, "one.cilium.io.", 10)
+	cache.add(request1, testServer, response, now)
+	request2, response := newTestExchange(c, "two.cilium.io.", 60)
+	cache.add(request2, testServer, response, now)
+
+	// The expired entry is evicted first
+	now = now.Add(20 * time.Second)
+	request3, response := newTestExchange(c, "three.cilium.io.", 60)
+	cache.add(request3, testServer, response, now)
+	c.Assert(len(cache.entries), Equals, 2)
+	c.Assert(cache.lookup(request1, testServer, now), IsNil)
+	c.Assert(cache.lookup(request2, testServer, now), Not(IsNil))
+	c.Assert(cache.lookup(request3, testServer, now), Not(IsNil))
+
+	// Without expired entries, an arbitrary entry is evicted
+	request4, response := newTestExchange(c, "four.cilium.io.", 60)
+	cache.add(request4, testServer, response, now)
+	c.Assert(len(cache.entries), Equals, 2)
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproxy
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// edns0OptionNames are the names of the EDNS(0) options used in
+// EDNSDetails.Options
+var edns0OptionNames = map[uint16]string{
+	dns.EDNS0LLQ:          "LLQ",
+	dns.EDNS0UL:           "UL",
+	dns.EDNS0NSID:         "NSID",
+	dns.EDNS0DAU:          "DAU",
+	dns.EDNS0DHU:          "DHU",
+	dns.EDNS0N3U:          "N3U",
+	dns.EDNS0SUBNET:       "SUBNET",
+	dns.EDNS0EXPIRE:       "EXPIRE",
+	dns.EDNS0COOKIE:       "COOKIE",
+	dns.EDNS0TCPKEEPALIVE: "TCPKEEPALIVE",
+	dns.EDNS0PADDING:      "PADDING",
+}
+
+// EDNSDetails are the details of the EDNS(0) OPT record of a DNS message
+type EDNSDetails struct {
+	// UDPSize is the UDP payload size announced by the sender
+	UDPSize uint16
+
+	// ClientSubnet is the EDNS client-subnet option in CIDR notation, e.g.
+	// "192.0.2.0/24". It is empty if the message has no client-subnet
+	// option.
+	ClientSubnet string
+
+	// Options are all EDNS(0) options in the form "NAME=value"
+	Options []string
+}
+
+// ExtractEDNSDetails returns the details of the OPT record of msg or nil if
+// msg has no OPT record.
+func ExtractEDNSDetails(msg *dns.Msg) *EDNSDetails {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	details := &EDNSDetails{
+		UDPSize: opt.UDPSize(),
+		Options: make([]string, 0, len(opt.Option)),
+	}
+	for _, o := range opt.Option {
+		name, ok := edns0OptionNames[o.Option()]
+		if !ok {
+			name = fmt.Sprintf("OPT%d", o.Option())
+		}
+		details.Options = append(details.Options, name+"="+o.String())
+
+		if subnet, ok := o.(*dns.EDNS0_SUBNET); ok && subnet.Address != nil {
+			bits := 32
+			if subnet.Family == 2 {
+				bits = 128
+			}
+			mask := net.CIDRMask(int(subnet.SourceNetmask), bits)
+			cidr := net.IPNet{IP: subnet.Address.Mask(mask), Mask: mask}
+			details.ClientSubnet = cidr.String()
+		}
+	}
+	return details
+}
+
+// maxUDPResponseSize returns the size of the largest response the sender of
+// request accepts over UDP
+func maxUDPResponseSize(request *dns.Msg) int {
+	if opt := request.IsEdns0(); opt != nil && int(opt.UDPSize()) > dns.MinMsgSize {
+		return int(opt.UDPSize())
+	}
+	return dns.MinMsgSize
+}
+
+// responseForClient returns response truncated to the size accepted by the
+// sender of request over the given protocol. Responses retried over TCP
+// upstream may not fit into the UDP response to the client. response is not
+// modified, a truncated copy is returned if needed.
+func responseForClient(request, response *dns.Msg, protocol string) *dns.Msg {
+	if protocol != "udp" {
+		return response
+	}
+	size := maxUDPResponseSize(request)
+	if response.Len() <= size {
+		return response
+	}
+	truncated := response.Copy()
+	truncated.Truncate(size)
+	return truncated
+}
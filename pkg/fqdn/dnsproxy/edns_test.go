@@ -0,0 +1,95 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package dnsproxy
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/miekg/dns"
+	. "gopkg.in/check.v1"
+)
+
+type DNSProxyEDNSTestSuite struct{}
+
+var _ = Suite(&DNSProxyEDNSTestSuite{})
+
+func (s *DNSProxyEDNSTestSuite) TestExtractEDNSDetails(c *C) {
+	msg := new(dns.Msg)
+	msg.SetQuestion("cilium.io.", dns.TypeA)
+	c.Assert(ExtractEDNSDetails(msg), IsNil)
+
+	msg.SetEdns0(4096, false)
+	details := ExtractEDNSDetails(msg)
+	c.Assert(details, Not(IsNil))
+	c.Assert(details.UDPSize, Equals, uint16(4096))
+	c.Assert(details.ClientSubnet, Equals, "")
+	c.Assert(details.Options, HasLen, 0)
+
+	opt := msg.IsEdns0()
+	opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: 24,
+		Address:       net.ParseIP("192.0.2.17"),
+	})
+	opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{
+		Code:   dns.EDNS0COOKIE,
+		Cookie: "24a5ac1223341f8f",
+	})
+	details = ExtractEDNSDetails(msg)
+	c.Assert(details.ClientSubnet, Equals, "192.0.2.0/24")
+	c.Assert(details.Options, DeepEquals, []string{
+		"SUBNET=192.0.2.17/24/0",
+		"COOKIE=24a5ac1223341f8f",
+	})
+
+	opt.Option = []dns.EDNS0{&dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        2,
+		SourceNetmask: 56,
+		Address:       net.ParseIP("2001:db8:1:2345::1"),
+	}}
+	details = ExtractEDNSDetails(msg)
+	c.Assert(details.ClientSubnet, Equals, "2001:db8:1:2300::/56")
+}
+
+func (s *DNSProxyEDNSTestSuite) TestResponseForClient(c *C) {
+	request := new(dns.Msg)
+	request.SetQuestion("cilium.io.", dns.TypeA)
+	response := new(dns.Msg)
+	response.SetReply(request)
+	for i := 0; i < 100; i++ {
+		rr, err := dns.NewRR(fmt.Sprintf("cilium.io. 60 IN A 10.0.0.%d", i))
+		c.Assert(err, IsNil)
+		response.Answer = append(response.Answer, rr)
+	}
+
+	// TCP responses are never truncated
+	c.Assert(responseForClient(request, response, "tcp"), Equals, response)
+
+	// UDP responses are truncated to 512 bytes without an OPT record
+	truncated := responseForClient(request, response, "udp")
+	c.Assert(truncated.Truncated, Equals, true)
+	c.Assert(truncated.Len() <= dns.MinMsgSize, Equals, true)
+	c.Assert(response.Truncated, Equals, false)
+	c.Assert(response.Answer, HasLen, 100)
+
+	// The UDP size of the OPT record of the request is honoured
+	request.SetEdns0(4096, false)
+	c.Assert(responseForClient(request, response, "udp"), Equals, response)
+}
@@ -17,9 +17,16 @@
 package dnsproxy
 
 import (
+	"testing"
+
 	. "gopkg.in/check.v1"
 )
 
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
 type DNSProxyHelperTestSuite struct{}
 
 var _ = Suite(&DNSProxyHelperTestSuite{})
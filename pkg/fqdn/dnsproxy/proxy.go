@@ -29,6 +29,7 @@ import (
 	"github.com/cilium/cilium/pkg/fqdn/regexpmap"
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/metrics"
 	"github.com/cilium/cilium/pkg/option"
 	"github.com/cilium/cilium/pkg/spanstat"
 
@@ -114,6 +115,18 @@ type DNSProxy struct {
 	// rejectReply is the OPCode send from the DNS-proxy to the endpoint if the
 	// DNS request is invalid
 	rejectReply int
+
+	// rateLimiter limits the rate of DNS requests of each endpoint. It is
+	// nil when rate limiting is disabled.
+	rateLimiter *endpointRateLimiter
+
+	// rateLimitReply is the OPCode sent from the DNS-proxy to the endpoint
+	// if the endpoint exceeded its DNS request rate limit
+	rateLimitReply int
+
+	// cache holds the responses of upstream DNS servers. It is nil when
+	// caching is disabled.
+	cache *responseCache
 }
 
 // LookupEndpointIDByIPFunc wraps logic to lookup an endpoint with any backend.
@@ -131,6 +144,10 @@ type ProxyRequestContext struct {
 	UpstreamTime spanstat.SpanStat
 	Success      bool
 	Err          error
+
+	// EDNS holds the details of the EDNS(0) OPT record of the DNS message
+	// passed along with this context, nil if the message has none.
+	EDNS *EDNSDetails
 }
 
 // IsTimeout return true if the ProxyRequest timeout
@@ -168,6 +185,7 @@ func StartDNSProxy(address string, port uint16, lookupEPFunc LookupEndpointIDByI
 		lookupTargetDNSServer: lookupTargetDNSServer,
 		allowed:               regexpmap.NewRegexpMap(),
 		rejectReply:           dns.RcodeRefused,
+		rateLimitReply:        dns.RcodeRefused,
 	}
 
 	// Start the DNS listeners on UDP and TCP
@@ -273,12 +291,15 @@ func (p *DNSProxy) CheckAllowed(name, endpointID string) bool {
 //  - Look up the endpoint that sent the request by IP, via LookupEndpointIDByIP.
 //  - Check that the endpoint ID is in the set of values associated with the
 //  DNS query (lowercased). If not, the request is dropped.
+//  - Check that the endpoint did not exceed its DNS request rate limit. If it
+//  did, the request is answered with the rate limit reply.
+//  - Answer the request from the response cache if possible.
 //  - The allowed request is forwarded to the originally intended DNS server IP
 //  - The response is shared via NotifyOnDNSMsg (this will go to a
 //  fqdn/NameManager instance).
 //  - Write the response to the endpoint.
 func (p *DNSProxy) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
-	stat := ProxyRequestContext{}
+	stat := ProxyRequestContext{EDNS: ExtractEDNSDetails(request)}
 	stat.ProcessingTime.Start()
 	requestID := request.Id // keep the original request ID
 	qname := string(request.Question[0].Name)
@@ -335,9 +356,35 @@ func (p *DNSProxy) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
 		return
 	}
 
+	rateLimiter, rateLimitReply, cache := p.getRateLimiterAndCache()
+	if !rateLimiter.allow(uint16(ep.ID), time.Now()) {
+		scopedLog.Debug("Rejecting DNS query from endpoint due to rate limit")
+		metrics.FQDNProxyRateLimitedTotal.Inc()
+		p.sendRcode(scopedLog, w, request, rateLimitReply)
+		stat.Err = ErrRateLimited
+		stat.ProcessingTime.End(true)
+		p.NotifyOnDNSMsg(time.Now(), ep, epIPPort, targetServerAddr, request, protocol, false, stat)
+		return
+	}
+
 	scopedLog.Debug("Forwarding DNS request for a name that is allowed")
 	p.NotifyOnDNSMsg(time.Now(), ep, epIPPort, targetServerAddr, request, protocol, true, stat)
 
+	if response := cache.lookup(request, targetServerAddr, time.Now()); response != nil {
+		scopedLog.WithField(logfields.Response, response).Debug("Responding to DNS query from cache")
+		stat.ProcessingTime.End(true)
+		stat.Success = true
+		stat.EDNS = ExtractEDNSDetails(response)
+		if err := w.WriteMsg(responseForClient(request, response, protocol)); err != nil {
+			scopedLog.WithError(err).Error("Cannot send cached DNS response")
+			stat.Err = fmt.Errorf("Cannot send cached DNS response: %s", err)
+		}
+		// Cached responses are notified like upstream responses so that
+		// their IPs are still learned for the endpoint
+		p.NotifyOnDNSMsg(time.Now(), ep, epIPPort, targetServerAddr, response, protocol, true, stat)
+		return
+	}
+
 	// Keep the same L4 protocol. This handles DNS re-requests over TCP, for
 	// requests that were too large for UDP.
 	var client *dns.Client
@@ -359,6 +406,14 @@ func (p *DNSProxy) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
 
 	request.Id = dns.Id() // force a random new ID for this request
 	response, _, err := client.Exchange(request, targetServerAddr)
+	if err == nil && response.Truncated && client == p.UDPClient {
+		// Retry truncated responses over TCP so that all IPs of the
+		// response are learned, even if the endpoint does not retry
+		// itself. The response to the endpoint is truncated again if it
+		// does not fit.
+		scopedLog.Debug("Retrying truncated DNS response over TCP")
+		response, _, err = p.TCPClient.Exchange(request, targetServerAddr)
+	}
 	stat.UpstreamTime.End(err == nil)
 	if err != nil {
 		stat.Err = err
@@ -375,11 +430,13 @@ func (p *DNSProxy) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
 
 	scopedLog.WithField(logfields.Response, response).Debug("Received DNS response to proxied lookup")
 	stat.Success = true
+	stat.EDNS = ExtractEDNSDetails(response)
+	cache.add(request, targetServerAddr, response, time.Now())
 
 	scopedLog.Debug("Responding to original DNS query")
 	// restore the ID to the one in the initial request so it matches what the requester expects.
 	response.Id = requestID
-	err = w.WriteMsg(response)
+	err = w.WriteMsg(responseForClient(request, response, protocol))
 	if err != nil {
 		scopedLog.WithError(err).Error("Cannot forward proxied DNS response")
 		stat.Err = fmt.Errorf("Cannot forward proxied DNS response: %s", err)
@@ -395,12 +452,20 @@ func (p *DNSProxy) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
 // sendRefused creates and sends a REFUSED response for request to w
 // The returned error is logged with scopedLog and is returned for convenience
 func (p *DNSProxy) sendRefused(scopedLog *logrus.Entry, w dns.ResponseWriter, request *dns.Msg) (err error) {
-	refused := new(dns.Msg)
-	refused.SetRcode(request, p.rejectReply)
+	return p.sendRcode(scopedLog, w, request, p.rejectReply)
+}
 
-	if err = w.WriteMsg(refused); err != nil {
-		scopedLog.WithError(err).Error("Cannot send REFUSED response")
-		err = fmt.Errorf("cannot send REFUSED response: %s", err)
+// sendRcode creates and sends a response with the given rcode for request to
+// w. The returned error is logged with scopedLog and is returned for
+// convenience
+func (p *DNSProxy) sendRcode(scopedLog *logrus.Entry, w dns.ResponseWriter, request *dns.Msg, rcode int) (err error) {
+	reply := new(dns.Msg)
+	reply.SetRcode(request, rcode)
+
+	if err = w.WriteMsg(reply); err != nil {
+		rcodeStr := dns.RcodeToString[rcode]
+		scopedLog.WithError(err).Errorf("Cannot send %s response", rcodeStr)
+		err = fmt.Errorf("cannot send %s response: %s", rcodeStr, err)
 	}
 	return err
 }
@@ -419,6 +484,46 @@ func (p *DNSProxy) SetRejectReply(opt string) {
 	}
 }
 
+// SetRateLimit limits the DNS requests of each endpoint to qps requests per
+// second with bursts of up to burst requests. A qps of 0 disables rate
+// limiting.
+func (p *DNSProxy) SetRateLimit(qps float64, burst int) {
+	p.Lock()
+	defer p.Unlock()
+	p.rateLimiter = newEndpointRateLimiter(qps, burst)
+}
+
+// SetRateLimitReply sets the reply to DNS requests of endpoints that exceeded
+// their rate limit.
+func (p *DNSProxy) SetRateLimitReply(opt string) {
+	p.Lock()
+	defer p.Unlock()
+	switch strings.ToLower(opt) {
+	case strings.ToLower(option.FQDNProxyRateLimitWithRefused):
+		p.rateLimitReply = dns.RcodeRefused
+	case strings.ToLower(option.FQDNProxyRateLimitWithServerFailure):
+		p.rateLimitReply = dns.RcodeServerFailure
+	default:
+		log.Infof("DNS rate limit response '%s' is not valid, available options are '%v'",
+			opt, option.FQDNRateLimitOptions)
+	}
+}
+
+// SetCacheSize enables caching of up to size upstream DNS responses for the
+// lowest TTL of their records. A size of 0 disables caching. Any previously
+// cached responses are dropped.
+func (p *DNSProxy) SetCacheSize(size int) {
+	p.Lock()
+	defer p.Unlock()
+	p.cache = newResponseCache(size)
+}
+
+func (p *DNSProxy) getRateLimiterAndCache() (*endpointRateLimiter, int, *responseCache) {
+	p.Lock()
+	defer p.Unlock()
+	return p.rateLimiter, p.rateLimitReply, p.cache
+}
+
 // ExtractMsgDetails extracts a canonical query name, any IPs in a response,
 // the lowest applicable TTL, rcode, anwer rr types and question types
 // When a CNAME is returned the chain is collapsed down, keeping the lowest TTL,
@@ -299,3 +299,21 @@ func (s *DNSProxyTestSuite) TestSetRejectReplyNoValidData(c *C) {
 	c.Assert(err, IsNil, Commentf("DNS request from test client returned error when it should be rejected"))
 	c.Assert(response.Rcode, Not(Equals), 100, Commentf("DNS request from test client has an invalid response code"))
 }
+
+func (s *DNSProxyTestSuite) TestRateLimit(c *C) {
+	s.proxy.AddAllowed("c[il]{3,3}um[.]io[.]", "123")
+	s.proxy.SetRateLimit(0.001, 1)
+	s.proxy.SetRateLimitReply(option.FQDNProxyRateLimitWithServerFailure)
+	defer s.proxy.SetRateLimitReply(option.FQDNProxyRateLimitWithRefused)
+	defer s.proxy.SetRateLimit(0, 0)
+
+	request := new(dns.Msg)
+	request.SetQuestion("cilium.io.", dns.TypeA)
+	response, _, err := s.dnsTCPClient.Exchange(request, s.proxy.TCPServer.Listener.Addr().String())
+	c.Assert(err, IsNil, Commentf("DNS request from test client failed when it should succeed"))
+	c.Assert(response.Rcode, Equals, dns.RcodeSuccess, Commentf("DNS request within the rate limit was rejected"))
+
+	response, _, err = s.dnsTCPClient.Exchange(request, s.proxy.TCPServer.Listener.Addr().String())
+	c.Assert(err, IsNil, Commentf("DNS request from test client returned error when it should be rejected"))
+	c.Assert(response.Rcode, Equals, dns.RcodeServerFailure, Commentf("DNS request exceeding the rate limit was not rejected with the right response code"))
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproxy
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is the error set in ProxyRequestContext.Err of DNS requests
+// rejected because the endpoint exceeded its DNS request rate limit
+var ErrRateLimited = errors.New("DNS request rate limit exceeded")
+
+// endpointRateLimiter tracks a token bucket per endpoint. Buckets of
+// endpoints that have been idle for long enough to refill the whole bucket
+// are removed, a new bucket behaves exactly the same.
+type endpointRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mutex     lock.Mutex
+	limiters  map[uint16]*endpointLimiter
+	lastPrune time.Time
+}
+
+type endpointLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newEndpointRateLimiter returns a rate limiter allowing each endpoint qps
+// requests per second with bursts of up to burst requests. Returns nil if qps
+// is not positive, a nil limiter allows all requests.
+func newEndpointRateLimiter(qps float64, burst int) *endpointRateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &endpointRateLimiter{
+		limit:    rate.Limit(qps),
+		burst:    burst,
+		limiters: map[uint16]*endpointLimiter{},
+	}
+}
+
+// refillTime is the time after which an unused bucket is full again
+func (l *endpointRateLimiter) refillTime() time.Duration {
+	return time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+}
+
+// allow returns true if the endpoint with the given ID may send a request at
+// time now
+func (l *endpointRateLimiter) allow(epID uint16, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	refill := l.refillTime()
+	if now.Sub(l.lastPrune) > refill {
+		for id, el := range l.limiters {
+			if now.Sub(el.lastSeen) > refill {
+				delete(l.limiters, id)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	el, ok := l.limiters[epID]
+	if !ok {
+		el = &endpointLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[epID] = el
+	}
+	el.lastSeen = now
+	return el.limiter.AllowN(now, 1)
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package dnsproxy
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type DNSProxyRateLimitTestSuite struct{}
+
+var _ = Suite(&DNSProxyRateLimitTestSuite{})
+
+func (s *DNSProxyRateLimitTestSuite) TestDisabled(c *C) {
+	l := newEndpointRateLimiter(0, 10)
+	c.Assert(l, IsNil)
+	for i := 0; i < 100; i++ {
+		c.Assert(l.allow(1, time.Now()), Equals, true)
+	}
+}
+
+func (s *DNSProxyRateLimitTestSuite) TestBurstAndRefill(c *C) {
+	l := newEndpointRateLimiter(10, 5)
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		c.Assert(l.allow(1, now), Equals, true)
+	}
+	c.Assert(l.allow(1, now), Equals, false)
+
+	// Other endpoints have their own bucket
+	c.Assert(l.allow(2, now), Equals, true)
+
+	// One token is added every 100ms
+	now = now.Add(100 * time.Millisecond)
+	c.Assert(l.allow(1, now), Equals, true)
+	c.Assert(l.allow(1, now), Equals, false)
+}
+
+func (s *DNSProxyRateLimitTestSuite) TestPrune(c *C) {
+	l := newEndpointRateLimiter(10, 5)
+	now := time.Now()
+
+	c.Assert(l.allow(1, now), Equals, true)
+	c.Assert(l.allow(2, now), Equals, true)
+	c.Assert(len(l.limiters), Equals, 2)
+
+	// Both buckets refilled completely and are removed, only endpoint 2
+	// gets a new bucket
+	now = now.Add(time.Second)
+	c.Assert(l.allow(2, now), Equals, true)
+	c.Assert(len(l.limiters), Equals, 1)
+	_, ok := l.limiters[2]
+	c.Assert(ok, Equals, true)
+}
@@ -35,6 +35,18 @@ import (
 
 const (
 	ipUnavailable = "Unavailable"
+
+	// maxTrendSamples is the maximum number of most recent samples
+	// shown in the trend of a connectivity history
+	maxTrendSamples = 60
+)
+
+// trendLevels are the characters used to plot the round trip time of
+// successful probes in the trend of a connectivity history, lost probes are
+// plotted as trendLost
+var (
+	trendLevels = []rune("▁▂▃▄▅▆▇█")
+	trendLost   = 'x'
 )
 
 // Client is a client for cilium health
@@ -122,6 +134,78 @@ func formatConnectivityStatus(w io.Writer, cs *models.ConnectivityStatus, path,
 		status = fmt.Sprintf("OK, RTT=%s", latency)
 	}
 	fmt.Fprintf(w, "%s%s:\t%s\n", indent, path, status)
+
+	if cs.History != nil {
+		formatConnectivityHistory(w, cs.History, indent+"  ")
+	}
+}
+
+// formatTrend plots the round trip times of the most recent samples of the
+// history relative to the maximum round trip time, oldest sample first.
+func formatTrend(h *models.ConnectivityHistory) string {
+	samples := h.Samples
+	if len(samples) > maxTrendSamples {
+		samples = samples[len(samples)-maxTrendSamples:]
+	}
+
+	trend := make([]rune, 0, len(samples))
+	for _, sample := range samples {
+		if sample == nil {
+			continue
+		}
+		if sample.Lost {
+			trend = append(trend, trendLost)
+			continue
+		}
+		level := 0
+		if h.LatencyMax > 0 {
+			level = int(sample.Latency * int64(len(trendLevels)-1) / h.LatencyMax)
+		}
+		trend = append(trend, trendLevels[level])
+	}
+	return string(trend)
+}
+
+// formatHistogram returns the non-empty buckets of the round trip time
+// histogram of the history.
+func formatHistogram(h *models.ConnectivityHistory) string {
+	var (
+		buckets []string
+		le      int64
+	)
+	for _, bucket := range h.LatencyBuckets {
+		if bucket == nil {
+			continue
+		}
+		// The unbounded bucket follows the bucket with the largest bound
+		bound := ">" + time.Duration(le).String()
+		if bucket.Le != 0 {
+			bound = "<=" + time.Duration(bucket.Le).String()
+			le = bucket.Le
+		}
+		if bucket.Count > 0 {
+			buckets = append(buckets, fmt.Sprintf("%s: %d", bound, bucket.Count))
+		}
+	}
+	if len(buckets) == 0 {
+		return "-"
+	}
+	return strings.Join(buckets, ", ")
+}
+
+func formatConnectivityHistory(w io.Writer, h *models.ConnectivityHistory, indent string) {
+	window := time.Duration(h.Window) * time.Second
+	fmt.Fprintf(w, "%sHistory:\t%d sent, %d lost (%.1f%%) in the last %s\n",
+		indent, h.Sent, h.Lost, h.PacketLoss, window)
+	if h.Sent == 0 {
+		return
+	}
+	if h.Sent > h.Lost {
+		fmt.Fprintf(w, "%sRTT min/avg/max:\t%s/%s/%s\n", indent,
+			time.Duration(h.LatencyMin), time.Duration(h.LatencyAvg), time.Duration(h.LatencyMax))
+		fmt.Fprintf(w, "%sRTT histogram:\t%s\n", indent, formatHistogram(h))
+	}
+	fmt.Fprintf(w, "%sTrend:\t%s\n", indent, formatTrend(h))
 }
 
 func formatPathStatus(w io.Writer, name string, cp *models.PathStatus, indent string, verbose bool) {
@@ -17,8 +17,10 @@
 package client
 
 import (
+	"bytes"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/cilium/cilium/api/v1/health/models"
 
@@ -171,3 +173,54 @@ func (s *ClientTestSuite) TestGetPrimaryAddressIP(c *C) {
 	pathStatus = getPrimaryAddressIP(primaryAddressNS)
 	c.Assert(pathStatus, Equals, "")
 }
+
+func (s *ClientTestSuite) TestFormatConnectivityHistory(c *C) {
+	history := &models.ConnectivityHistory{
+		Window:     3600,
+		Sent:       4,
+		Lost:       1,
+		PacketLoss: 25,
+		LatencyMin: time.Millisecond.Nanoseconds(),
+		LatencyAvg: (3 * time.Millisecond).Nanoseconds(),
+		LatencyMax: (8 * time.Millisecond).Nanoseconds(),
+		LatencyBuckets: []*models.LatencyBucket{
+			{Le: time.Millisecond.Nanoseconds(), Count: 2},
+			{Le: (5 * time.Millisecond).Nanoseconds(), Count: 0},
+			{Count: 1},
+		},
+		Samples: []*models.ConnectivitySample{
+			{Latency: time.Millisecond.Nanoseconds()},
+			{Lost: true},
+			{Latency: time.Millisecond.Nanoseconds()},
+			{Latency: (8 * time.Millisecond).Nanoseconds()},
+		},
+	}
+
+	c.Assert(formatTrend(history), Equals, "▁x▁█")
+	c.Assert(formatHistogram(history), Equals, "<=1ms: 2, >5ms: 1")
+
+	var b bytes.Buffer
+	formatConnectivityHistory(&b, history, "")
+	c.Assert(b.String(), Equals,
+		"History:\t4 sent, 1 lost (25.0%) in the last 1h0m0s\n"+
+			"RTT min/avg/max:\t1ms/3ms/8ms\n"+
+			"RTT histogram:\t<=1ms: 2, >5ms: 1\n"+
+			"Trend:\t▁x▁█\n")
+
+	// Probes which were all lost have no round trip times
+	lost := &models.ConnectivityHistory{
+		Window:     60,
+		Sent:       2,
+		Lost:       2,
+		PacketLoss: 100,
+		Samples: []*models.ConnectivitySample{
+			{Lost: true},
+			{Lost: true},
+		},
+	}
+	b.Reset()
+	formatConnectivityHistory(&b, lost, "")
+	c.Assert(b.String(), Equals,
+		"History:\t2 sent, 2 lost (100.0%) in the last 1m0s\n"+
+			"Trend:\txx\n")
+}
@@ -0,0 +1,227 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/cilium/cilium/api/v1/health/models"
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+const (
+	protocolICMP = "icmp"
+	protocolHTTP = "http"
+
+	pathTypeNode     = "node"
+	pathTypeEndpoint = "endpoint"
+)
+
+// latencyBuckets are the upper bounds of the buckets of the round trip time
+// histograms. They match the buckets of the node connectivity latency metric.
+var latencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// probeSample is the result of a single probe.
+type probeSample struct {
+	timestamp time.Time
+	latency   time.Duration
+	lost      bool
+}
+
+// probeHistory is the rolling history of the probes of a path over a
+// single protocol, oldest sample first.
+type probeHistory struct {
+	samples []probeSample
+}
+
+// add appends 'sample' to the history and expires all samples which are
+// older than 'window' relative to 'sample'.
+func (h *probeHistory) add(sample probeSample, window time.Duration) {
+	h.samples = append(h.samples, sample)
+
+	cutoff := sample.timestamp.Add(-window)
+	expired := 0
+	for expired < len(h.samples) && h.samples[expired].timestamp.Before(cutoff) {
+		expired++
+	}
+	h.samples = h.samples[expired:]
+}
+
+// packetLoss returns the percentage of lost probes in the history.
+func (h *probeHistory) packetLoss() float64 {
+	if len(h.samples) == 0 {
+		return 0
+	}
+
+	lost := 0
+	for _, sample := range h.samples {
+		if sample.lost {
+			lost++
+		}
+	}
+	return float64(lost) * 100 / float64(len(h.samples))
+}
+
+// getModel returns the history as an API model.
+func (h *probeHistory) getModel(window time.Duration) *models.ConnectivityHistory {
+	result := &models.ConnectivityHistory{
+		Window:         int64(window.Seconds()),
+		Sent:           int64(len(h.samples)),
+		PacketLoss:     h.packetLoss(),
+		LatencyBuckets: make([]*models.LatencyBucket, 0, len(latencyBuckets)+1),
+		Samples:        make([]*models.ConnectivitySample, 0, len(h.samples)),
+	}
+
+	for _, le := range latencyBuckets {
+		result.LatencyBuckets = append(result.LatencyBuckets, &models.LatencyBucket{
+			Le: le.Nanoseconds(),
+		})
+	}
+	// Bucket for round trip times exceeding the largest bound
+	result.LatencyBuckets = append(result.LatencyBuckets, &models.LatencyBucket{})
+
+	var total time.Duration
+	for _, sample := range h.samples {
+		result.Samples = append(result.Samples, &models.ConnectivitySample{
+			Timestamp: sample.timestamp.Format(time.RFC3339),
+			Latency:   sample.latency.Nanoseconds(),
+			Lost:      sample.lost,
+		})
+
+		if sample.lost {
+			result.Lost++
+			continue
+		}
+
+		latency := sample.latency.Nanoseconds()
+		if result.LatencyMin == 0 || latency < result.LatencyMin {
+			result.LatencyMin = latency
+		}
+		if latency > result.LatencyMax {
+			result.LatencyMax = latency
+		}
+		total += sample.latency
+
+		bucket := len(latencyBuckets)
+		for i, le := range latencyBuckets {
+			if sample.latency <= le {
+				bucket = i
+				break
+			}
+		}
+		result.LatencyBuckets[bucket].Count++
+	}
+
+	if received := result.Sent - result.Lost; received > 0 {
+		result.LatencyAvg = total.Nanoseconds() / received
+	}
+
+	return result
+}
+
+// pathHistory is the rolling history of the probes of a single IP address.
+type pathHistory struct {
+	icmp probeHistory
+	http probeHistory
+}
+
+// get returns the history of the probes over 'protocol'.
+func (p *pathHistory) get(protocol string) *probeHistory {
+	if protocol == protocolICMP {
+		return &p.icmp
+	}
+	return &p.http
+}
+
+// connectivityHistory keeps the rolling history of the probes of all paths
+// over a time window. It outlives the probers so that the history is kept
+// across probe cycles.
+type connectivityHistory struct {
+	lock.RWMutex
+
+	// window is the time window over which probes are kept. If zero, no
+	// history is kept.
+	window time.Duration
+	paths  map[ipString]*pathHistory
+}
+
+func newConnectivityHistory(window time.Duration) *connectivityHistory {
+	return &connectivityHistory{
+		window: window,
+		paths:  make(map[ipString]*pathHistory),
+	}
+}
+
+// record adds the result of a probe of 'ip' over 'protocol' to the history
+// and returns the percentage of probes lost within the window.
+func (c *connectivityHistory) record(ip ipString, protocol string, sample probeSample) float64 {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.window <= 0 {
+		if sample.lost {
+			return 100
+		}
+		return 0
+	}
+
+	path, ok := c.paths[ip]
+	if !ok {
+		path = &pathHistory{}
+		c.paths[ip] = path
+	}
+
+	history := path.get(protocol)
+	history.add(sample, c.window)
+	return history.packetLoss()
+}
+
+// remove removes the history of all probes of 'ip'.
+func (c *connectivityHistory) remove(ip ipString) {
+	c.Lock()
+	delete(c.paths, ip)
+	c.Unlock()
+}
+
+// getModel returns the history of the probes of 'ip' over 'protocol' as an
+// API model, or nil if no history is available.
+func (c *connectivityHistory) getModel(ip ipString, protocol string) *models.ConnectivityHistory {
+	c.RLock()
+	defer c.RUnlock()
+
+	path, ok := c.paths[ip]
+	if !ok {
+		return nil
+	}
+	history := path.get(protocol)
+	if len(history.samples) == 0 {
+		return nil
+	}
+	return history.getModel(c.window)
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/api/v1/health/models"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type HistoryTestSuite struct{}
+
+var _ = Suite(&HistoryTestSuite{})
+
+func (s *HistoryTestSuite) TestProbeHistoryExpire(c *C) {
+	var h probeHistory
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		h.add(probeSample{
+			timestamp: start.Add(time.Duration(i) * time.Minute),
+			latency:   time.Millisecond,
+			lost:      i%2 == 0,
+		}, 5*time.Minute)
+	}
+
+	// Samples older than 5 minutes relative to the last sample at
+	// minute 9 are expired, minute 4 is kept.
+	c.Assert(len(h.samples), Equals, 6)
+	c.Assert(h.samples[0].timestamp, Equals, start.Add(4*time.Minute))
+	c.Assert(h.packetLoss(), Equals, float64(50))
+}
+
+func (s *HistoryTestSuite) TestProbeHistoryModel(c *C) {
+	var h probeHistory
+	start := time.Now()
+
+	latencies := []time.Duration{
+		200 * time.Microsecond,
+		3 * time.Millisecond,
+		0,
+		4 * time.Millisecond,
+		2 * time.Second,
+	}
+	for i, latency := range latencies {
+		h.add(probeSample{
+			timestamp: start.Add(time.Duration(i) * time.Second),
+			latency:   latency,
+			lost:      latency == 0,
+		}, time.Hour)
+	}
+
+	model := h.getModel(time.Hour)
+	c.Assert(model.Window, Equals, int64(3600))
+	c.Assert(model.Sent, Equals, int64(5))
+	c.Assert(model.Lost, Equals, int64(1))
+	c.Assert(model.PacketLoss, Equals, float64(20))
+	c.Assert(model.LatencyMin, Equals, (200 * time.Microsecond).Nanoseconds())
+	c.Assert(model.LatencyMax, Equals, (2 * time.Second).Nanoseconds())
+	c.Assert(model.LatencyAvg, Equals, (2007200*time.Microsecond).Nanoseconds()/4)
+	c.Assert(len(model.Samples), Equals, 5)
+	c.Assert(model.Samples[2].Lost, Equals, true)
+
+	counts := map[int64]int64{}
+	for _, bucket := range model.LatencyBuckets {
+		counts[bucket.Le] = bucket.Count
+	}
+	c.Assert(len(model.LatencyBuckets), Equals, len(latencyBuckets)+1)
+	c.Assert(counts, DeepEquals, map[int64]int64{
+		(100 * time.Microsecond).Nanoseconds():  0,
+		(250 * time.Microsecond).Nanoseconds():  1,
+		(500 * time.Microsecond).Nanoseconds():  0,
+		time.Millisecond.Nanoseconds():          0,
+		(2500 * time.Microsecond).Nanoseconds(): 0,
+		(5 * time.Millisecond).Nanoseconds():    2,
+		(10 * time.Millisecond).Nanoseconds():   0,
+		(25 * time.Millisecond).Nanoseconds():   0,
+		(50 * time.Millisecond).Nanoseconds():   0,
+		(100 * time.Millisecond).Nanoseconds():  0,
+		(250 * time.Millisecond).Nanoseconds():  0,
+		(500 * time.Millisecond).Nanoseconds():  0,
+		time.Second.Nanoseconds():               0,
+		0:                                       1,
+	})
+}
+
+func (s *HistoryTestSuite) TestConnectivityHistory(c *C) {
+	history := newConnectivityHistory(time.Hour)
+	now := time.Now()
+
+	loss := history.record("10.0.0.1", protocolICMP, probeSample{timestamp: now, latency: time.Millisecond})
+	c.Assert(loss, Equals, float64(0))
+	loss = history.record("10.0.0.1", protocolICMP, probeSample{timestamp: now, lost: true})
+	c.Assert(loss, Equals, float64(50))
+	loss = history.record("10.0.0.1", protocolHTTP, probeSample{timestamp: now, lost: true})
+	c.Assert(loss, Equals, float64(100))
+
+	c.Assert(history.getModel("10.0.0.1", protocolICMP).Sent, Equals, int64(2))
+	c.Assert(history.getModel("10.0.0.1", protocolHTTP).Sent, Equals, int64(1))
+	c.Assert(history.getModel("10.0.0.2", protocolICMP), IsNil)
+
+	history.remove("10.0.0.1")
+	c.Assert(history.getModel("10.0.0.1", protocolICMP), IsNil)
+
+	// No history is kept without a window
+	history = newConnectivityHistory(0)
+	loss = history.record("10.0.0.1", protocolICMP, probeSample{timestamp: now, lost: true})
+	c.Assert(loss, Equals, float64(100))
+	c.Assert(history.getModel("10.0.0.1", protocolICMP), IsNil)
+}
+
+func (s *HistoryTestSuite) TestWithHistory(c *C) {
+	srv := &Server{history: newConnectivityHistory(time.Hour)}
+	srv.history.record("10.0.0.1", protocolICMP, probeSample{timestamp: time.Now(), latency: time.Millisecond})
+
+	path := &models.PathStatus{
+		IP:   "10.0.0.1",
+		Icmp: &models.ConnectivityStatus{Latency: time.Millisecond.Nanoseconds()},
+		HTTP: &models.ConnectivityStatus{Status: "Connection timed out"},
+	}
+	result := srv.withHistory(path)
+	c.Assert(result.Icmp.History, Not(IsNil))
+	c.Assert(result.Icmp.History.Sent, Equals, int64(1))
+	c.Assert(result.HTTP.History, IsNil)
+	c.Assert(result.HTTP.Status, Equals, "Connection timed out")
+
+	// The cached path status is not modified
+	c.Assert(path.Icmp.History, IsNil)
+	c.Assert(srv.withHistory(nil), IsNil)
+}
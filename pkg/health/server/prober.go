@@ -26,6 +26,7 @@ import (
 	"github.com/cilium/cilium/pkg/health/probe"
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/metrics"
 
 	"github.com/servak/go-fastping"
 	"github.com/sirupsen/logrus"
@@ -57,6 +58,10 @@ type prober struct {
 	start   time.Time
 	results map[ipString]*models.PathStatus
 	nodes   nodeMap
+
+	// icmpReplied is the set of IPs which replied to the ICMP probes of
+	// the current ICMP probe cycle.
+	icmpReplied map[ipString]bool
 }
 
 // copyResultRLocked makes a copy of the path status for the specified IP.
@@ -175,6 +180,79 @@ func (p *prober) markIPsLocked(deletedNodes nodeMap) {
 	}
 }
 
+// pathType returns the type of the path to 'ip' of 'node' as exported in
+// metrics, or an empty string for secondary addresses.
+func pathType(node *healthNode, ip string) string {
+	switch ip {
+	case node.PrimaryIP():
+		return pathTypeNode
+	case node.HealthIP():
+		return pathTypeEndpoint
+	}
+	return ""
+}
+
+// recordProbeLocked records the result of a probe of 'ip' of 'node' over
+// 'protocol' in the connectivity history of the server and updates the
+// node connectivity metrics.
+func (p *prober) recordProbeLocked(node *healthNode, ip string, protocol string, sample probeSample) {
+	loss := p.server.history.record(ipString(ip), protocol, sample)
+
+	source := p.server.localNodeName()
+	typ := pathType(node, ip)
+	if source == "" || typ == "" {
+		return
+	}
+
+	if !sample.lost {
+		metrics.NodeConnectivityLatency.WithLabelValues(source, node.Name, typ, protocol).Observe(sample.latency.Seconds())
+	}
+	metrics.NodeConnectivityPacketLoss.WithLabelValues(source, node.Name, typ, protocol).Set(loss)
+}
+
+// forgetPathLocked removes the connectivity history and the packet loss
+// metrics of the path to 'ip' of 'node'.
+func (p *prober) forgetPathLocked(node *healthNode, ip string) {
+	p.server.history.remove(ipString(ip))
+
+	source := p.server.localNodeName()
+	typ := pathType(node, ip)
+	if source == "" || typ == "" {
+		return
+	}
+
+	for _, protocol := range []string{protocolICMP, protocolHTTP} {
+		metrics.NodeConnectivityPacketLoss.DeleteLabelValues(source, node.Name, typ, protocol)
+	}
+}
+
+// completeICMPRound records all IPs which did not reply to the ICMP probes
+// of the current probe cycle as lost, and resets the set of replies for the
+// next cycle. It is called by the pinger when the ICMP probe deadline has
+// passed.
+func (p *prober) completeICMPRound() {
+	p.Lock()
+	defer p.Unlock()
+
+	// The probes of this cycle were sent when the deadline started
+	sent := time.Now().Add(-p.MaxRTT)
+	for ip, node := range p.nodes {
+		if p.icmpReplied[ip] {
+			continue
+		}
+		if result, ok := p.results[ip]; ok {
+			result.Icmp = &models.ConnectivityStatus{
+				Status: "Connection timed out",
+			}
+		}
+		p.recordProbeLocked(&node, string(ip), protocolICMP, probeSample{
+			timestamp: sent,
+			lost:      true,
+		})
+	}
+	p.icmpReplied = make(map[ipString]bool)
+}
+
 // sweepIPsLocked iterates through nodes in the prober and removes nodes which
 // are marked for deletion.
 func (p *prober) sweepIPsLocked() {
@@ -187,6 +265,7 @@ func (p *prober) sweepIPsLocked() {
 			for elem := range node.Addresses() {
 				delete(p.results, ipString(elem.IP))
 				p.RemoveIP(elem.IP) // ICMP pinger
+				p.forgetPathLocked(&node, elem.IP)
 			}
 			delete(p.nodes, ip) // TCP prober
 		}
@@ -303,6 +382,7 @@ func (p *prober) runHTTPProbe() {
 			ports := map[int]**models.ConnectivityStatus{
 				defaults.HTTPPathPort: &status.HTTP,
 			}
+			sent := time.Now()
 			for port, result := range ports {
 				*result = p.httpProbe(name, ip.String(), port)
 				if status.HTTP.Status != "" {
@@ -316,6 +396,13 @@ func (p *prober) runHTTPProbe() {
 			p.Lock()
 			if _, ok := p.results[peer]; ok {
 				p.results[peer].HTTP = status.HTTP
+				if node, ok := p.nodes[peer]; ok {
+					p.recordProbeLocked(&node, ip.String(), protocolHTTP, probeSample{
+						timestamp: sent,
+						latency:   time.Duration(status.HTTP.Latency),
+						lost:      status.HTTP.Status != "",
+					})
+				}
 			} else {
 				// While we weren't holding the lock, the
 				// pinger's OnIdle() callback fired and updated
@@ -387,8 +474,10 @@ func newProber(s *Server, nodes nodeMap) *prober {
 		stop:         make(chan bool),
 		results:      make(map[ipString]*models.PathStatus),
 		nodes:        make(nodeMap),
+		icmpReplied:  make(map[ipString]bool),
 	}
 	prober.MaxRTT = s.ProbeDeadline
+	prober.OnIdle = prober.completeICMPRound
 
 	prober.setNodes(nodes, nil)
 	prober.OnRecv = func(addr *net.IPAddr, rtt time.Duration) {
@@ -409,6 +498,11 @@ func newProber(s *Server, nodes nodeMap) *prober {
 			Latency: rtt.Nanoseconds(),
 			Status:  "",
 		}
+		prober.icmpReplied[ipString(addr.String())] = true
+		prober.recordProbeLocked(&node, addr.String(), protocolICMP, probeSample{
+			timestamp: time.Now().Add(-rtt),
+			latency:   rtt,
+		})
 		scopedLog.WithFields(logrus.Fields{
 			logfields.NodeName: node.Name,
 		}).Debugf("Probe successful")
@@ -50,6 +50,7 @@ type Config struct {
 	CiliumURI     string
 	ProbeInterval time.Duration
 	ProbeDeadline time.Duration
+	HistoryWindow time.Duration
 }
 
 // ipString is an IP address used as a more descriptive type name in maps.
@@ -79,6 +80,9 @@ type Server struct {
 	lock.RWMutex
 	connectivity *healthReport
 	localStatus  *healthModels.SelfStatus
+
+	// history is the rolling history of the probes of all paths
+	history *connectivityHistory
 }
 
 // DumpUptime returns the time that this server has been running.
@@ -86,6 +90,18 @@ func (s *Server) DumpUptime() string {
 	return time.Since(s.startTime).String()
 }
 
+// localNodeName returns the name of the local node, or an empty string if
+// it is not known yet.
+func (s *Server) localNodeName() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.localStatus == nil {
+		return ""
+	}
+	return s.localStatus.Name
+}
+
 // getNodes fetches the nodes added and removed from the last time the server
 // made a request to the daemon.
 func (s *Server) getNodes() (nodeMap, nodeMap, error) {
@@ -210,6 +226,55 @@ func (s *Server) GetStatusResponse() *healthModels.HealthStatusResponse {
 	}
 }
 
+// GetStatusHistoryResponse returns the most recent cluster connectivity
+// status along with the rolling history of the probes of each path.
+func (s *Server) GetStatusHistoryResponse() *healthModels.HealthStatusResponse {
+	sr := s.GetStatusResponse()
+
+	nodes := make([]*healthModels.NodeStatus, 0, len(sr.Nodes))
+	for _, node := range sr.Nodes {
+		status := &healthModels.NodeStatus{
+			Name:     node.Name,
+			Endpoint: s.withHistory(node.Endpoint),
+		}
+		if node.Host != nil {
+			status.Host = &healthModels.HostStatus{
+				PrimaryAddress: s.withHistory(node.Host.PrimaryAddress),
+			}
+			for _, addr := range node.Host.SecondaryAddresses {
+				status.Host.SecondaryAddresses = append(status.Host.SecondaryAddresses, s.withHistory(addr))
+			}
+		}
+		nodes = append(nodes, status)
+	}
+	sr.Nodes = nodes
+
+	return sr
+}
+
+// withHistory returns a copy of the path status with the rolling history of
+// the probes attached to each protocol.
+func (s *Server) withHistory(path *healthModels.PathStatus) *healthModels.PathStatus {
+	if path == nil {
+		return nil
+	}
+
+	result := &healthModels.PathStatus{
+		IP: path.IP,
+	}
+	if path.Icmp != nil {
+		icmp := *path.Icmp
+		icmp.History = s.history.getModel(ipString(path.IP), protocolICMP)
+		result.Icmp = &icmp
+	}
+	if path.HTTP != nil {
+		http := *path.HTTP
+		http.History = s.history.getModel(ipString(path.IP), protocolHTTP)
+		result.HTTP = &http
+	}
+	return result
+}
+
 // FetchStatusResponse updates the cluster with the latest set of nodes,
 // runs a synchronous probe across the cluster, updates the connectivity cache
 // and returns the results.
@@ -244,6 +309,8 @@ func (s *Server) runActiveServices() error {
 	prober := newProber(s, nodesAdded)
 	prober.MaxRTT = s.ProbeInterval
 	prober.OnIdle = func() {
+		prober.completeICMPRound()
+
 		// Fetch results and update set of nodes to probe every
 		// ProbeInterval
 		s.updateCluster(prober.getResults())
@@ -320,6 +387,7 @@ func NewServer(config Config) (*Server, error) {
 		Config:       config,
 		tcpServers:   []*responder.Server{},
 		connectivity: &healthReport{},
+		history:      newConnectivityHistory(config.HistoryWindow),
 	}
 
 	swaggerSpec, err := loads.Analyzed(healthApi.SwaggerJSON, "")
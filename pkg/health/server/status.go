@@ -43,6 +43,9 @@ func NewPutStatusProbeHandler(s *Server) PutStatusProbeHandler {
 func (h *getStatusCache) Handle(params GetStatusParams) middleware.Responder {
 	log.Debug("Handling request for /status")
 
+	if params.History != nil && *params.History {
+		return NewGetStatusOK().WithPayload(h.GetStatusHistoryResponse())
+	}
 	return NewGetStatusOK().WithPayload(h.GetStatusResponse())
 }
 
@@ -391,5 +391,5 @@ func ReleaseSlice(ctx context.Context, owner IdentityAllocatorOwner, identities
 // syncs all identities to the local identity cache.
 func WatchRemoteIdentities(backend kvstore.BackendOperations) *allocator.RemoteCache {
 	<-GlobalIdentityAllocatorInitialized
-	return IdentityAllocator.WatchRemoteKVStore(backend, IdentitiesPath)
+	return IdentityAllocator.WatchRemoteKVStore(kvstoreallocator.NewRemoteKVStoreBackend(IdentitiesPath, GlobalIdentity{}, backend))
 }
@@ -0,0 +1,240 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterpool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+const (
+	// maxSubnetBits is the maximum difference between the mask size of
+	// the allocated PodCIDRs and the prefix length of a cluster CIDR
+	maxSubnetBits = 32
+
+	// MaxPodCIDRHostBits is the maximum number of host bits of a PodCIDR.
+	// It limits a PodCIDR to 2^32 IPs so that the number of IPs can be
+	// counted in an int, IPv6 PodCIDRs must thus be at least a /96.
+	MaxPodCIDRHostBits = 32
+)
+
+var (
+	// ErrCIDRPoolExhausted is returned by AllocateNext when all PodCIDRs
+	// of all cluster CIDRs are allocated
+	ErrCIDRPoolExhausted = errors.New("all PodCIDRs of the cluster-pool are allocated")
+)
+
+// cidrSet is the set of PodCIDRs of a single cluster CIDR
+type cidrSet struct {
+	cidr *net.IPNet
+
+	// base is the first address of cidr
+	base *big.Int
+
+	// subnetBits is the difference between the PodCIDR mask size and the
+	// prefix length of cidr
+	subnetBits uint
+
+	// size is the number of PodCIDRs in the set
+	size uint64
+
+	// allocated is the set of allocated PodCIDRs indexed by their
+	// position within cidr
+	allocated map[uint64]struct{}
+
+	// next is the index at which the search for a free PodCIDR starts
+	next uint64
+}
+
+// CIDRPool allocates PodCIDRs of a fixed mask size out of a list of cluster
+// CIDRs of the same address family
+type CIDRPool struct {
+	mutex    lock.Mutex
+	maskSize int
+	bits     int
+	sets     []*cidrSet
+}
+
+// NewCIDRPool returns a pool allocating PodCIDRs with the given mask size out
+// of cidrs. All cidrs must be of the same address family.
+func NewCIDRPool(cidrs []string, maskSize int) (*CIDRPool, error) {
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("no cluster CIDRs specified")
+	}
+
+	p := &CIDRPool{maskSize: maskSize}
+	for _, c := range cidrs {
+		_, cidr, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster CIDR %q: %s", c, err)
+		}
+
+		ones, bits := cidr.Mask.Size()
+		switch {
+		case p.bits == 0:
+			p.bits = bits
+		case p.bits != bits:
+			return nil, fmt.Errorf("cluster CIDR %s is not of the same address family as %s", c, cidrs[0])
+		}
+
+		if maskSize < ones || maskSize > bits {
+			return nil, fmt.Errorf("mask size %d is not within the prefix length %d and %d of cluster CIDR %s",
+				maskSize, ones, bits, c)
+		}
+		if bits-maskSize > MaxPodCIDRHostBits {
+			return nil, fmt.Errorf("mask size %d exceeds the maximum PodCIDR size of 2^%d IPs, the mask size must be at least %d",
+				maskSize, MaxPodCIDRHostBits, bits-MaxPodCIDRHostBits)
+		}
+		if maskSize-ones > maxSubnetBits {
+			return nil, fmt.Errorf("cluster CIDR %s contains more than 2^%d PodCIDRs of mask size %d",
+				c, maxSubnetBits, maskSize)
+		}
+
+		subnetBits := uint(maskSize - ones)
+		p.sets = append(p.sets, &cidrSet{
+			cidr:       cidr,
+			base:       new(big.Int).SetBytes(cidr.IP),
+			subnetBits: subnetBits,
+			size:       uint64(1) << subnetBits,
+			allocated:  map[uint64]struct{}{},
+		})
+	}
+
+	return p, nil
+}
+
+// subnet returns the PodCIDR at index
+func (s *cidrSet) subnet(index uint64, maskSize, bits int) *net.IPNet {
+	offset := new(big.Int).SetUint64(index)
+	offset.Lsh(offset, uint(bits-maskSize))
+	ip := new(big.Int).Add(s.base, offset).Bytes()
+
+	// Pad to the length of the address family
+	addr := make(net.IP, bits/8)
+	copy(addr[len(addr)-len(ip):], ip)
+
+	return &net.IPNet{IP: addr, Mask: net.CIDRMask(maskSize, bits)}
+}
+
+// MaskSize returns the mask size of the PodCIDRs allocated by the pool
+func (p *CIDRPool) MaskSize() int {
+	return p.maskSize
+}
+
+// IsIPv6 returns true if the pool allocates IPv6 PodCIDRs
+func (p *CIDRPool) IsIPv6() bool {
+	return p.bits == net.IPv6len*8
+}
+
+// AllocateNext allocates the next free PodCIDR. ErrCIDRPoolExhausted is
+// returned if all PodCIDRs are allocated.
+func (p *CIDRPool) AllocateNext() (*net.IPNet, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, s := range p.sets {
+		if uint64(len(s.allocated)) >= s.size {
+			continue
+		}
+		for i := uint64(0); i < s.size; i++ {
+			index := (s.next + i) % s.size
+			if _, ok := s.allocated[index]; !ok {
+				s.allocated[index] = struct{}{}
+				s.next = (index + 1) % s.size
+				return s.subnet(index, p.maskSize, p.bits), nil
+			}
+		}
+	}
+
+	return nil, ErrCIDRPoolExhausted
+}
+
+// lookup returns the set containing the PodCIDR cidr and its index within
+// the set
+func (p *CIDRPool) lookup(cidr *net.IPNet) (*cidrSet, uint64, error) {
+	ones, bits := cidr.Mask.Size()
+	if ones != p.maskSize || bits != p.bits {
+		return nil, 0, fmt.Errorf("CIDR %s does not match the PodCIDR mask size /%d", cidr, p.maskSize)
+	}
+
+	ip := cidr.IP.To16()
+	if bits == net.IPv4len*8 {
+		ip = cidr.IP.To4()
+	}
+	if ip == nil {
+		return nil, 0, fmt.Errorf("invalid CIDR %s", cidr)
+	}
+	ip = ip.Mask(cidr.Mask)
+
+	for _, s := range p.sets {
+		if !s.cidr.Contains(ip) {
+			continue
+		}
+		offset := new(big.Int).Sub(new(big.Int).SetBytes(ip), s.base)
+		offset.Rsh(offset, uint(bits-ones))
+		return s, offset.Uint64(), nil
+	}
+
+	return nil, 0, fmt.Errorf("CIDR %s is not part of the cluster-pool", cidr)
+}
+
+// Occupy marks the PodCIDR cidr as allocated, e.g. to restore the allocation
+// state from existing CiliumNode resources
+func (p *CIDRPool) Occupy(cidr *net.IPNet) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s, index, err := p.lookup(cidr)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.allocated[index]; ok {
+		return fmt.Errorf("CIDR %s is already allocated", cidr)
+	}
+	s.allocated[index] = struct{}{}
+	return nil
+}
+
+// Release releases the PodCIDR cidr
+func (p *CIDRPool) Release(cidr *net.IPNet) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s, index, err := p.lookup(cidr)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.allocated[index]; !ok {
+		return fmt.Errorf("CIDR %s is not allocated", cidr)
+	}
+	delete(s.allocated, index)
+	return nil
+}
+
+// Stats returns the number of allocated and total PodCIDRs of the pool
+func (p *CIDRPool) Stats() (allocated, total uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, s := range p.sets {
+		allocated += uint64(len(s.allocated))
+		total += s.size
+	}
+	return
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package clusterpool
+
+import (
+	"net"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type ClusterPoolSuite struct{}
+
+var _ = check.Suite(&ClusterPoolSuite{})
+
+func mustParseCIDR(c *check.C, s string) *net.IPNet {
+	_, cidr, err := net.ParseCIDR(s)
+	c.Assert(err, check.IsNil)
+	return cidr
+}
+
+func (e *ClusterPoolSuite) TestNewCIDRPool(c *check.C) {
+	_, err := NewCIDRPool(nil, 24)
+	c.Assert(err, check.Not(check.IsNil))
+
+	_, err = NewCIDRPool([]string{"10.0.0.0"}, 24)
+	c.Assert(err, check.Not(check.IsNil))
+
+	// Mixed address families
+	_, err = NewCIDRPool([]string{"10.0.0.0/16", "f00d::/64"}, 24)
+	c.Assert(err, check.Not(check.IsNil))
+
+	// Mask size smaller than the prefix length
+	_, err = NewCIDRPool([]string{"10.0.0.0/16"}, 8)
+	c.Assert(err, check.Not(check.IsNil))
+
+	// PodCIDRs larger than 2^32 IPs
+	_, err = NewCIDRPool([]string{"f00d::/48"}, 64)
+	c.Assert(err, check.Not(check.IsNil))
+	_, err = NewCIDRPool([]string{"f00d::/64"}, 95)
+	c.Assert(err, check.Not(check.IsNil))
+
+	// Too many PodCIDRs
+	_, err = NewCIDRPool([]string{"f00d::/48"}, 96)
+	c.Assert(err, check.Not(check.IsNil))
+
+	p, err := NewCIDRPool([]string{"10.0.0.0/16"}, 24)
+	c.Assert(err, check.IsNil)
+	c.Assert(p.IsIPv6(), check.Equals, false)
+	c.Assert(p.MaskSize(), check.Equals, 24)
+
+	p, err = NewCIDRPool([]string{"f00d::/64"}, 96)
+	c.Assert(err, check.IsNil)
+	c.Assert(p.IsIPv6(), check.Equals, true)
+}
+
+func (e *ClusterPoolSuite) TestAllocateNext(c *check.C) {
+	p, err := NewCIDRPool([]string{"10.0.0.0/23", "10.1.0.0/24"}, 24)
+	c.Assert(err, check.IsNil)
+
+	for _, expected := range []string{"10.0.0.0/24", "10.0.1.0/24", "10.1.0.0/24"} {
+		cidr, err := p.AllocateNext()
+		c.Assert(err, check.IsNil)
+		c.Assert(cidr.String(), check.Equals, expected)
+	}
+
+	_, err = p.AllocateNext()
+	c.Assert(err, check.Equals, ErrCIDRPoolExhausted)
+
+	allocated, total := p.Stats()
+	c.Assert(allocated, check.Equals, uint64(3))
+	c.Assert(total, check.Equals, uint64(3))
+
+	// Released PodCIDRs are allocated again
+	c.Assert(p.Release(mustParseCIDR(c, "10.0.0.0/24")), check.IsNil)
+	c.Assert(p.Release(mustParseCIDR(c, "10.0.0.0/24")), check.Not(check.IsNil))
+	cidr, err := p.AllocateNext()
+	c.Assert(err, check.IsNil)
+	c.Assert(cidr.String(), check.Equals, "10.0.0.0/24")
+}
+
+func (e *ClusterPoolSuite) TestAllocateNextIPv6(c *check.C) {
+	p, err := NewCIDRPool([]string{"f00d::/95"}, 96)
+	c.Assert(err, check.IsNil)
+
+	for _, expected := range []string{"f00d::/96", "f00d::1:0:0/96"} {
+		cidr, err := p.AllocateNext()
+		c.Assert(err, check.IsNil)
+		c.Assert(cidr.String(), check.Equals, expected)
+	}
+
+	_, err = p.AllocateNext()
+	c.Assert(err, check.Equals, ErrCIDRPoolExhausted)
+}
+
+func (e *ClusterPoolSuite) TestOccupy(c *check.C) {
+	p, err := NewCIDRPool([]string{"10.0.0.0/22"}, 24)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(p.Occupy(mustParseCIDR(c, "10.0.1.0/24")), check.IsNil)
+	c.Assert(p.Occupy(mustParseCIDR(c, "10.0.1.0/24")), check.Not(check.IsNil))
+
+	// Not part of the pool or wrong mask size
+	c.Assert(p.Occupy(mustParseCIDR(c, "10.1.0.0/24")), check.Not(check.IsNil))
+	c.Assert(p.Occupy(mustParseCIDR(c, "10.0.2.0/25")), check.Not(check.IsNil))
+	c.Assert(p.Occupy(mustParseCIDR(c, "f00d::/24")), check.Not(check.IsNil))
+
+	// Occupied PodCIDRs are skipped
+	for _, expected := range []string{"10.0.0.0/24", "10.0.2.0/24", "10.0.3.0/24"} {
+		cidr, err := p.AllocateNext()
+		c.Assert(err, check.IsNil)
+		c.Assert(cidr.String(), check.Equals, expected)
+	}
+}
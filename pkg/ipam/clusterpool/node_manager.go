@@ -0,0 +1,283 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterpool
+
+import (
+	"net"
+	"reflect"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+
+	"github.com/sirupsen/logrus"
+)
+
+type k8sAPI interface {
+	Update(origResource, newResource *v2.CiliumNode) (*v2.CiliumNode, error)
+	UpdateStatus(origResource, newResource *v2.CiliumNode) (*v2.CiliumNode, error)
+	Get(name string) (*v2.CiliumNode, error)
+}
+
+// nodeCIDRs is the PodCIDR allocation of a node
+type nodeCIDRs struct {
+	v4 *net.IPNet
+	v6 *net.IPNet
+
+	// err is the error of the last allocation attempt
+	err string
+
+	// synced is true when the allocation has been written to the
+	// CiliumNode resource
+	synced bool
+}
+
+// podCIDRs returns the allocated PodCIDRs in string form, IPv4 first
+func (n *nodeCIDRs) podCIDRs() []string {
+	cidrs := []string{}
+	if n.v4 != nil {
+		cidrs = append(cidrs, n.v4.String())
+	}
+	if n.v6 != nil {
+		cidrs = append(cidrs, n.v6.String())
+	}
+	return cidrs
+}
+
+// NodeManager allocates PodCIDRs to nodes out of the cluster-pool and keeps
+// the CiliumNode resources in sync with the allocation
+type NodeManager struct {
+	mutex  lock.Mutex
+	k8sAPI k8sAPI
+	v4Pool *CIDRPool
+	v6Pool *CIDRPool
+	nodes  map[string]*nodeCIDRs
+}
+
+// NewNodeManager returns a new NodeManager allocating PodCIDRs out of v4Pool
+// and v6Pool. A nil pool disables allocation for the address family.
+func NewNodeManager(k8sAPI k8sAPI, v4Pool, v6Pool *CIDRPool) *NodeManager {
+	return &NodeManager{
+		k8sAPI: k8sAPI,
+		v4Pool: v4Pool,
+		v6Pool: v6Pool,
+		nodes:  map[string]*nodeCIDRs{},
+	}
+}
+
+// restoreLocked occupies the PodCIDRs in the spec of resource which are part
+// of the cluster-pool. n.mutex must be held.
+func (n *NodeManager) restoreLocked(resource *v2.CiliumNode) *nodeCIDRs {
+	node := &nodeCIDRs{}
+	for _, c := range resource.Spec.IPAM.PodCIDRs {
+		scopedLog := log.WithFields(logrus.Fields{
+			fieldName: resource.Name,
+			"podCIDR": c,
+		})
+
+		_, cidr, err := net.ParseCIDR(c)
+		if err != nil {
+			scopedLog.WithError(err).Warning("Ignoring invalid PodCIDR of node")
+			continue
+		}
+
+		pool, allocated := n.v4Pool, &node.v4
+		if cidr.IP.To4() == nil {
+			pool, allocated = n.v6Pool, &node.v6
+		}
+		if pool == nil || *allocated != nil {
+			continue
+		}
+
+		if err := pool.Occupy(cidr); err != nil {
+			scopedLog.WithError(err).Warning("Unable to restore PodCIDR of node, allocating a new PodCIDR")
+			continue
+		}
+		*allocated = cidr
+	}
+
+	n.nodes[resource.Name] = node
+	return node
+}
+
+// Restore restores the allocation state from existing CiliumNode resources.
+// It must be called before the first call to Update to ensure that PodCIDRs
+// which are already in use are not handed out to other nodes.
+func (n *NodeManager) Restore(resources []v2.CiliumNode) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for i := range resources {
+		if _, ok := n.nodes[resources[i].Name]; !ok {
+			n.restoreLocked(&resources[i])
+		}
+	}
+}
+
+// allocateLocked allocates the PodCIDRs missing in node. n.mutex must be
+// held.
+func (n *NodeManager) allocateLocked(name string, node *nodeCIDRs) {
+	node.err = ""
+
+	for _, family := range []struct {
+		pool      *CIDRPool
+		allocated **net.IPNet
+	}{
+		{n.v4Pool, &node.v4},
+		{n.v6Pool, &node.v6},
+	} {
+		if family.pool == nil || *family.allocated != nil {
+			continue
+		}
+
+		cidr, err := family.pool.AllocateNext()
+		if err != nil {
+			log.WithError(err).WithField(fieldName, name).Warning("Unable to allocate PodCIDR for node")
+			node.err = err.Error()
+			continue
+		}
+
+		log.WithFields(logrus.Fields{
+			fieldName: name,
+			"podCIDR": cidr.String(),
+		}).Info("Allocated PodCIDR for node")
+		*family.allocated = cidr
+		node.synced = false
+	}
+}
+
+// Update is called whenever a CiliumNode resource has been created or
+// updated. Missing PodCIDRs are allocated and the resource is updated to
+// reflect the allocation.
+func (n *NodeManager) Update(resource *v2.CiliumNode) bool {
+	n.mutex.Lock()
+	node, ok := n.nodes[resource.Name]
+	if !ok {
+		node = n.restoreLocked(resource)
+	}
+	n.allocateLocked(resource.Name, node)
+
+	podCIDRs := node.podCIDRs()
+	status := v2.IPAMOperatorStatus{PodCIDRs: podCIDRs, Error: node.err}
+	n.mutex.Unlock()
+
+	err := n.syncToAPIServer(resource, podCIDRs, status)
+
+	n.mutex.Lock()
+	// The node may have been deleted in the meantime
+	if current, ok := n.nodes[resource.Name]; ok && current == node {
+		node.synced = err == nil
+	}
+	n.mutex.Unlock()
+
+	return err == nil
+}
+
+// syncToAPIServer writes podCIDRs into the spec and status into the status
+// of resource
+func (n *NodeManager) syncToAPIServer(resource *v2.CiliumNode, podCIDRs []string, status v2.IPAMOperatorStatus) error {
+	scopedLog := log.WithField(fieldName, resource.Name)
+
+	node := resource.DeepCopy()
+	origNode := resource.DeepCopy()
+
+	if !reflect.DeepEqual(node.Spec.IPAM.PodCIDRs, podCIDRs) {
+		node.Spec.IPAM.PodCIDRs = podCIDRs
+		scopedLog.WithField("podCIDRs", podCIDRs).Debug("Updating node in apiserver")
+
+		updatedNode, err := n.k8sAPI.Update(node, origNode)
+		if err != nil {
+			scopedLog.WithError(err).Warning("Unable to update CiliumNode spec")
+			return err
+		}
+		if updatedNode != nil && updatedNode.Name != "" {
+			node = updatedNode.DeepCopy()
+			origNode = updatedNode.DeepCopy()
+		}
+	}
+
+	node.Status.IPAM.OperatorStatus = status
+	if _, err := n.k8sAPI.UpdateStatus(node, origNode); err != nil {
+		scopedLog.WithError(err).Warning("Unable to update CiliumNode status")
+		return err
+	}
+
+	return nil
+}
+
+// Delete is called after a CiliumNode resource has been deleted. The
+// PodCIDRs of the node are released. Nodes which failed to allocate a
+// PodCIDR are retried on the next resync.
+func (n *NodeManager) Delete(name string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	node, ok := n.nodes[name]
+	if !ok {
+		return
+	}
+	delete(n.nodes, name)
+
+	released := false
+	for _, family := range []struct {
+		pool *CIDRPool
+		cidr *net.IPNet
+	}{
+		{n.v4Pool, node.v4},
+		{n.v6Pool, node.v6},
+	} {
+		if family.pool == nil || family.cidr == nil {
+			continue
+		}
+		if err := family.pool.Release(family.cidr); err != nil {
+			log.WithError(err).WithField(fieldName, name).Warning("Unable to release PodCIDR of node")
+			continue
+		}
+		log.WithFields(logrus.Fields{
+			fieldName: name,
+			"podCIDR": family.cidr.String(),
+		}).Info("Released PodCIDR of deleted node")
+		released = true
+	}
+
+	if released {
+		for _, other := range n.nodes {
+			if other.err != "" {
+				other.synced = false
+			}
+		}
+	}
+}
+
+// Resync retries the allocation and synchronization of all nodes which have
+// not been synchronized successfully yet
+func (n *NodeManager) Resync() {
+	n.mutex.Lock()
+	names := []string{}
+	for name, node := range n.nodes {
+		if !node.synced {
+			names = append(names, name)
+		}
+	}
+	n.mutex.Unlock()
+
+	for _, name := range names {
+		resource, err := n.k8sAPI.Get(name)
+		if err != nil {
+			log.WithError(err).WithField(fieldName, name).Warning("Unable to retrieve CiliumNode for resync")
+			continue
+		}
+		n.Update(resource)
+	}
+}
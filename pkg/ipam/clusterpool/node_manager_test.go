@@ -0,0 +1,161 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package clusterpool
+
+import (
+	"fmt"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+
+	"gopkg.in/check.v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// k8sMock stores CiliumNode resources in memory
+type k8sMock struct {
+	mutex lock.Mutex
+	nodes map[string]*v2.CiliumNode
+	fail  bool
+}
+
+func newK8sMock(nodes ...*v2.CiliumNode) *k8sMock {
+	k := &k8sMock{nodes: map[string]*v2.CiliumNode{}}
+	for _, node := range nodes {
+		k.nodes[node.Name] = node.DeepCopy()
+	}
+	return k
+}
+
+func (k *k8sMock) Update(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if k.fail {
+		return nil, fmt.Errorf("update failed")
+	}
+	k.nodes[node.Name] = node.DeepCopy()
+	return node.DeepCopy(), nil
+}
+
+func (k *k8sMock) UpdateStatus(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
+	return k.Update(node, origNode)
+}
+
+func (k *k8sMock) Get(name string) (*v2.CiliumNode, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	node, ok := k.nodes[name]
+	if !ok {
+		return nil, fmt.Errorf("node %s not found", name)
+	}
+	return node.DeepCopy(), nil
+}
+
+func newCiliumNode(name string, podCIDRs ...string) *v2.CiliumNode {
+	return &v2.CiliumNode{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v2.NodeSpec{
+			IPAM: v2.IPAMSpec{PodCIDRs: podCIDRs},
+		},
+	}
+}
+
+func (e *ClusterPoolSuite) TestNodeManager(c *check.C) {
+	v4Pool, err := NewCIDRPool([]string{"10.0.0.0/23"}, 24)
+	c.Assert(err, check.IsNil)
+	v6Pool, err := NewCIDRPool([]string{"f00d::/64"}, 96)
+	c.Assert(err, check.IsNil)
+
+	k8sapi := newK8sMock(newCiliumNode("node1"))
+	mngr := NewNodeManager(k8sapi, v4Pool, v6Pool)
+
+	c.Assert(mngr.Update(newCiliumNode("node1")), check.Equals, true)
+	node, err := k8sapi.Get("node1")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.0.0/24", "f00d::/96"})
+	c.Assert(node.Status.IPAM.OperatorStatus.PodCIDRs, check.DeepEquals, []string{"10.0.0.0/24", "f00d::/96"})
+	c.Assert(node.Status.IPAM.OperatorStatus.Error, check.Equals, "")
+
+	// Updates of the node do not change the allocation
+	c.Assert(mngr.Update(node), check.Equals, true)
+	node, err = k8sapi.Get("node1")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.0.0/24", "f00d::/96"})
+
+	c.Assert(mngr.Update(newCiliumNode("node2")), check.Equals, true)
+	node, err = k8sapi.Get("node2")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.1.0/24", "f00d::1:0:0/96"})
+
+	// The IPv4 pool is exhausted, the error is reported in the status
+	c.Assert(mngr.Update(newCiliumNode("node3")), check.Equals, true)
+	node, err = k8sapi.Get("node3")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"f00d::2:0:0/96"})
+	c.Assert(node.Status.IPAM.OperatorStatus.Error, check.Equals, ErrCIDRPoolExhausted.Error())
+
+	// Deleting a node releases its PodCIDRs, the resync allocates them
+	// to the node which failed to allocate
+	mngr.Delete("node1")
+	mngr.Resync()
+	node, err = k8sapi.Get("node3")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.0.0/24", "f00d::2:0:0/96"})
+	c.Assert(node.Status.IPAM.OperatorStatus.Error, check.Equals, "")
+}
+
+func (e *ClusterPoolSuite) TestNodeManagerRestore(c *check.C) {
+	v4Pool, err := NewCIDRPool([]string{"10.0.0.0/22"}, 24)
+	c.Assert(err, check.IsNil)
+
+	k8sapi := newK8sMock()
+	mngr := NewNodeManager(k8sapi, v4Pool, nil)
+	mngr.Restore([]v2.CiliumNode{
+		*newCiliumNode("node1", "10.0.0.0/24"),
+		// PodCIDRs outside of the pool and of disabled address
+		// families are ignored
+		*newCiliumNode("node2", "192.168.0.0/24", "f00d::/96"),
+	})
+
+	c.Assert(mngr.Update(newCiliumNode("node3")), check.Equals, true)
+	node, err := k8sapi.Get("node3")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.1.0/24"})
+
+	c.Assert(mngr.Update(newCiliumNode("node2", "192.168.0.0/24", "f00d::/96")), check.Equals, true)
+	node, err = k8sapi.Get("node2")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.2.0/24"})
+}
+
+func (e *ClusterPoolSuite) TestNodeManagerResync(c *check.C) {
+	v4Pool, err := NewCIDRPool([]string{"10.0.0.0/22"}, 24)
+	c.Assert(err, check.IsNil)
+
+	k8sapi := newK8sMock(newCiliumNode("node1"))
+	k8sapi.fail = true
+	mngr := NewNodeManager(k8sapi, v4Pool, nil)
+
+	c.Assert(mngr.Update(newCiliumNode("node1")), check.Equals, false)
+
+	// The allocation is kept and written on resync
+	k8sapi.fail = false
+	mngr.Resync()
+	node, err := k8sapi.Get("node1")
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Spec.IPAM.PodCIDRs, check.DeepEquals, []string{"10.0.0.0/24"})
+}
@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/cilium/cilium/pkg/cidr"
+	"github.com/cilium/cilium/pkg/ip"
+	"github.com/cilium/cilium/pkg/ipam/clusterpool"
 	"github.com/cilium/cilium/pkg/k8s"
 	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
 	"github.com/cilium/cilium/pkg/k8s/informer"
@@ -170,6 +172,10 @@ func newNodeStore(nodeName string, owner Owner) *nodeStore {
 		time.Sleep(5 * time.Second)
 	}
 
+	if option.Config.IPAM == option.IPAMClusterPool {
+		store.setPodCIDRAllocRanges()
+	}
+
 	store.refreshTrigger.TriggerWithReason("initial sync")
 
 	return store
@@ -201,11 +207,19 @@ func (n *nodeStore) hasMinimumIPsInPool() (minimumReached bool, required, numAva
 		return
 	}
 
+	if option.Config.IPAM == option.IPAMClusterPool {
+		return n.hasMinimumIPsInPodCIDRs()
+	}
+
 	switch {
 	case n.ownNode.Spec.ENI.MinAllocate != 0:
 		required = n.ownNode.Spec.ENI.MinAllocate
 	case n.ownNode.Spec.ENI.PreAllocate != 0:
 		required = n.ownNode.Spec.ENI.PreAllocate
+	case n.ownNode.Spec.IPAM.MinAllocate != 0:
+		required = n.ownNode.Spec.IPAM.MinAllocate
+	case n.ownNode.Spec.IPAM.PreAllocate != 0:
+		required = n.ownNode.Spec.IPAM.PreAllocate
 	case option.Config.EnableHealthChecking:
 		required = 2
 	default:
@@ -230,6 +244,88 @@ func (n *nodeStore) hasMinimumIPsInPool() (minimumReached bool, required, numAva
 	return
 }
 
+// parsePodCIDRs returns the valid PodCIDRs in the spec of node. PodCIDRs
+// larger than 2^clusterpool.MaxPodCIDRHostBits IPs are ignored.
+func parsePodCIDRs(node *ciliumv2.CiliumNode) []*net.IPNet {
+	parsed, invalid := ip.ParseCIDRs(node.Spec.IPAM.PodCIDRs)
+	for _, c := range invalid {
+		log.WithFields(logrus.Fields{
+			fieldName: node.Name,
+			"podCIDR": c,
+		}).Warning("Unable to parse PodCIDR in CiliumNode custom resource")
+	}
+
+	podCIDRs := make([]*net.IPNet, 0, len(parsed))
+	for _, podCIDR := range parsed {
+		if ones, bits := podCIDR.Mask.Size(); bits-ones > clusterpool.MaxPodCIDRHostBits {
+			log.WithFields(logrus.Fields{
+				fieldName: node.Name,
+				"podCIDR": podCIDR.String(),
+			}).Warningf("Ignoring PodCIDR in CiliumNode custom resource larger than 2^%d IPs", clusterpool.MaxPodCIDRHostBits)
+			continue
+		}
+		podCIDRs = append(podCIDRs, podCIDR)
+	}
+	return podCIDRs
+}
+
+// podCIDRSize returns the number of allocatable IPs in podCIDR. The network
+// and broadcast addresses are excluded. podCIDR must not exceed
+// 2^clusterpool.MaxPodCIDRHostBits IPs.
+func podCIDRSize(podCIDR *net.IPNet) int {
+	if size := ip.CountIPsInCIDR(podCIDR) - 1; size > 0 {
+		return size
+	}
+	return 0
+}
+
+// hasMinimumIPsInPodCIDRs is the cluster-pool variant of
+// hasMinimumIPsInPool. A PodCIDR must have been allocated by cilium-operator
+// for each enabled address family. n.mutex must be held.
+func (n *nodeStore) hasMinimumIPsInPodCIDRs() (minimumReached bool, required, numAvailable int) {
+	required = 1
+	if option.Config.EnableHealthChecking {
+		required = 2
+	}
+
+	var hasIPv4, hasIPv6 bool
+	for _, podCIDR := range parsePodCIDRs(n.ownNode) {
+		numAvailable += podCIDRSize(podCIDR)
+
+		if podCIDR.IP.To4() != nil {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+
+	minimumReached = numAvailable >= required &&
+		(hasIPv4 || !option.Config.EnableIPv4) &&
+		(hasIPv6 || !option.Config.EnableIPv6)
+
+	return
+}
+
+// setPodCIDRAllocRanges sets the node allocation ranges to the PodCIDRs
+// allocated by cilium-operator
+func (n *nodeStore) setPodCIDRAllocRanges() {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	if n.ownNode == nil {
+		return
+	}
+
+	for _, podCIDR := range parsePodCIDRs(n.ownNode) {
+		if podCIDR.IP.To4() != nil {
+			node.SetIPv4AllocRange(cidr.NewCIDR(podCIDR))
+		} else if err := node.SetIPv6NodeRange(podCIDR); err != nil {
+			log.WithError(err).WithField("podCIDR", podCIDR.String()).
+				Warning("Unable to use PodCIDR as IPv6 node allocation range")
+		}
+	}
+}
+
 // deleteLocalNodeResource is called when the CiliumNode resource representing
 // the local node has been deleted.
 func (n *nodeStore) deleteLocalNodeResource() {
@@ -248,7 +344,11 @@ func (n *nodeStore) updateLocalNodeResource(node *ciliumv2.CiliumNode) {
 	n.ownNode = node
 	n.allocationPoolSize[IPv4] = 0
 	n.allocationPoolSize[IPv6] = 0
-	if node.Spec.IPAM.Pool != nil {
+	if option.Config.IPAM == option.IPAMClusterPool {
+		for _, podCIDR := range parsePodCIDRs(node) {
+			n.allocationPoolSize[DeriveFamily(podCIDR.IP)] += podCIDRSize(podCIDR)
+		}
+	} else if node.Spec.IPAM.Pool != nil {
 		for ipString := range node.Spec.IPAM.Pool {
 			if ip := net.ParseIP(ipString); ip != nil {
 				if ip.To4() != nil {
@@ -327,6 +427,15 @@ func (n *nodeStore) allocate(ip net.IP) (*ciliumv2.AllocationIP, error) {
 		return nil, fmt.Errorf("CiliumNode for own node is not available")
 	}
 
+	if option.Config.IPAM == option.IPAMClusterPool {
+		for _, podCIDR := range parsePodCIDRs(n.ownNode) {
+			if podCIDR.Contains(ip) && isAllocatableInPodCIDR(podCIDR, ip) {
+				return &ciliumv2.AllocationIP{Resource: podCIDR.String()}, nil
+			}
+		}
+		return nil, fmt.Errorf("IP %s is not part of the PodCIDRs of the node", ip.String())
+	}
+
 	if n.ownNode.Spec.IPAM.Pool == nil {
 		return nil, fmt.Errorf("No IPs available")
 	}
@@ -348,6 +457,10 @@ func (n *nodeStore) allocateNext(allocated map[string]ciliumv2.AllocationIP, fam
 		return nil, nil, fmt.Errorf("CiliumNode for own node is not available")
 	}
 
+	if option.Config.IPAM == option.IPAMClusterPool {
+		return n.allocateNextInPodCIDRs(allocated, family)
+	}
+
 	// FIXME: This is currently using a brute-force method that can be
 	// optimized
 	for ip, ipInfo := range n.ownNode.Spec.IPAM.Pool {
@@ -372,6 +485,43 @@ func (n *nodeStore) allocateNext(allocated map[string]ciliumv2.AllocationIP, fam
 	return nil, nil, fmt.Errorf("No more IPs available")
 }
 
+// isAllocatableInPodCIDR returns false for the network and broadcast
+// addresses of podCIDR
+func isAllocatableInPodCIDR(podCIDR *net.IPNet, addr net.IP) bool {
+	if addr.Equal(podCIDR.IP) {
+		return false
+	}
+	broadcast := make(net.IP, len(podCIDR.IP))
+	for i := range podCIDR.IP {
+		broadcast[i] = podCIDR.IP[i] | ^podCIDR.Mask[i]
+	}
+	return !addr.Equal(broadcast)
+}
+
+// allocateNextInPodCIDRs is the cluster-pool variant of allocateNext. The
+// next IP not in allocated is returned from the PodCIDRs of the node.
+// n.mutex must be held.
+func (n *nodeStore) allocateNextInPodCIDRs(allocated map[string]ciliumv2.AllocationIP, family Family) (net.IP, *ciliumv2.AllocationIP, error) {
+	for _, podCIDR := range parsePodCIDRs(n.ownNode) {
+		if DeriveFamily(podCIDR.IP) != family {
+			continue
+		}
+
+		// FIXME: This is currently using a brute-force method that can
+		// be optimized
+		for addr := ip.GetNextIP(podCIDR.IP); podCIDR.Contains(addr); addr = ip.GetNextIP(addr) {
+			if !isAllocatableInPodCIDR(podCIDR, addr) {
+				break
+			}
+			if _, ok := allocated[addr.String()]; !ok {
+				return addr, &ciliumv2.AllocationIP{Resource: podCIDR.String()}, nil
+			}
+		}
+	}
+
+	return nil, nil, fmt.Errorf("No more IPs available")
+}
+
 // crdAllocator implements the CRD-backed IP allocator
 type crdAllocator struct {
 	// store is the node store backing the custom resource
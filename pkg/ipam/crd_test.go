@@ -0,0 +1,70 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package ipam
+
+import (
+	"net"
+
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/option"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *IPAMSuite) TestClusterPoolNodeStore(c *C) {
+	oldIPAM := option.Config.IPAM
+	option.Config.IPAM = option.IPAMClusterPool
+	defer func() { option.Config.IPAM = oldIPAM }()
+
+	store := &nodeStore{allocationPoolSize: map[Family]int{}}
+	store.updateLocalNodeResource(&ciliumv2.CiliumNode{
+		Spec: ciliumv2.NodeSpec{
+			IPAM: ciliumv2.IPAMSpec{
+				PodCIDRs: []string{"10.0.0.0/30", "f00d::/126", "invalid", "beef::/64"},
+			},
+		},
+	})
+	// The /64 exceeds the maximum PodCIDR size and is ignored
+	c.Assert(store.allocationPoolSize[IPv4], Equals, 2)
+	c.Assert(store.allocationPoolSize[IPv6], Equals, 2)
+
+	ipInfo, err := store.allocate(net.ParseIP("10.0.0.1"))
+	c.Assert(err, IsNil)
+	c.Assert(ipInfo.Resource, Equals, "10.0.0.0/30")
+
+	// Network and broadcast addresses and IPs outside of the PodCIDRs
+	// cannot be allocated
+	for _, ip := range []string{"10.0.0.0", "10.0.0.3", "10.0.1.1"} {
+		_, err = store.allocate(net.ParseIP(ip))
+		c.Assert(err, Not(IsNil))
+	}
+
+	allocated := map[string]ciliumv2.AllocationIP{}
+	for _, expected := range []string{"10.0.0.1", "10.0.0.2"} {
+		ip, ipInfo, err := store.allocateNext(allocated, IPv4)
+		c.Assert(err, IsNil)
+		c.Assert(ip.String(), Equals, expected)
+		allocated[ip.String()] = *ipInfo
+	}
+	_, _, err = store.allocateNext(allocated, IPv4)
+	c.Assert(err, Not(IsNil))
+
+	ip, ipInfo, err := store.allocateNext(allocated, IPv6)
+	c.Assert(err, IsNil)
+	c.Assert(ip.String(), Equals, "f00d::1")
+	c.Assert(ipInfo.Resource, Equals, "f00d::/126")
+}
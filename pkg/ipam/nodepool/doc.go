@@ -0,0 +1,20 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodepool implements a provider-neutral manager of the IP allocation
+// pools of nodes. It maintains the pool of each node between the MinAllocate,
+// PreAllocate and MaxAboveWatermark watermarks of the CiliumNode resource.
+// Deficits are resolved and excess IPs are released via a cloud specific
+// Provider, e.g. the ENI provider in pkg/aws/eni.
+package nodepool
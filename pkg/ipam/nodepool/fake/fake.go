@@ -0,0 +1,152 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake implements a fake IPAM provider for the node pool manager. It
+// can be used to test the node pool manager and backends built on top of it
+// without access to a cloud provider.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+// Provider is a fake IPAM provider assigning IPs out of 10.0.0.0/8. It
+// implements nodepool.Provider.
+type Provider struct {
+	mutex lock.Mutex
+
+	// capacity is the maximum number of IPs per node, 0 means unlimited
+	capacity int
+
+	// nodes maps node names to the IPs assigned to the node
+	nodes map[string]map[string]v2.AllocationIP
+
+	// lastIP is the index of the last IP handed out
+	lastIP uint32
+
+	allocateError error
+	releaseError  error
+}
+
+// NewProvider returns a new fake provider assigning up to capacity IPs to
+// each node. A capacity of 0 means unlimited.
+func NewProvider(capacity int) *Provider {
+	return &Provider{
+		capacity: capacity,
+		nodes:    map[string]map[string]v2.AllocationIP{},
+	}
+}
+
+// SetAllocateError sets the error returned by all subsequent calls to
+// AllocateIPs. A nil error restores the normal behavior.
+func (p *Provider) SetAllocateError(err error) {
+	p.mutex.Lock()
+	p.allocateError = err
+	p.mutex.Unlock()
+}
+
+// SetReleaseError sets the error returned by all subsequent calls to
+// ReleaseIPs. A nil error restores the normal behavior.
+func (p *Provider) SetReleaseError(err error) {
+	p.mutex.Lock()
+	p.releaseError = err
+	p.mutex.Unlock()
+}
+
+// NumIPs returns the number of IPs assigned to the node
+func (p *Provider) NumIPs(nodeName string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.nodes[nodeName])
+}
+
+// Resync implements nodepool.Provider. The fake provider has no remote
+// state, the current time is returned.
+func (p *Provider) Resync(ctx context.Context) time.Time {
+	return time.Now()
+}
+
+// GetPool implements nodepool.Provider
+func (p *Provider) GetPool(resource *v2.CiliumNode) map[string]v2.AllocationIP {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pool := map[string]v2.AllocationIP{}
+	for ip, allocationIP := range p.nodes[resource.Name] {
+		pool[ip] = allocationIP
+	}
+	return pool
+}
+
+// AllocateIPs implements nodepool.Provider
+func (p *Provider) AllocateIPs(ctx context.Context, resource *v2.CiliumNode, toAllocate int) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.allocateError != nil {
+		return 0, p.allocateError
+	}
+
+	ips, ok := p.nodes[resource.Name]
+	if !ok {
+		ips = map[string]v2.AllocationIP{}
+		p.nodes[resource.Name] = ips
+	}
+
+	if p.capacity > 0 && len(ips)+toAllocate > p.capacity {
+		toAllocate = p.capacity - len(ips)
+	}
+
+	for i := 0; i < toAllocate; i++ {
+		p.lastIP++
+		if p.lastIP >= 1<<24 {
+			return i, fmt.Errorf("fake provider is out of IPs")
+		}
+		ip := net.IPv4(10, byte(p.lastIP>>16), byte(p.lastIP>>8), byte(p.lastIP))
+		ips[ip.String()] = v2.AllocationIP{Resource: "fake-" + resource.Name}
+	}
+
+	return toAllocate, nil
+}
+
+// ReleaseIPs implements nodepool.Provider
+func (p *Provider) ReleaseIPs(ctx context.Context, resource *v2.CiliumNode, ips []string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.releaseError != nil {
+		return p.releaseError
+	}
+
+	for _, ip := range ips {
+		if _, ok := p.nodes[resource.Name][ip]; !ok {
+			return fmt.Errorf("IP %s is not assigned to node %s", ip, resource.Name)
+		}
+	}
+	for _, ip := range ips {
+		delete(p.nodes[resource.Name], ip)
+	}
+
+	return nil
+}
+
+// PopulateStatusFields implements nodepool.Provider. The fake provider has no
+// provider specific status.
+func (p *Provider) PopulateStatusFields(resource *v2.CiliumNode) {}
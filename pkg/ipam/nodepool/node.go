@@ -0,0 +1,433 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/trigger"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// excessIPReleaseDelay is the time an excess IP is withheld from the
+	// pool of the node before it is released. The delay must exceed the
+	// interval in which the agent reports used IPs so that an IP which
+	// the agent allocated before observing its removal from the pool is
+	// returned to the pool instead of being released.
+	excessIPReleaseDelay = time.Minute
+)
+
+// Node represents a Kubernetes node running Cilium with an associated
+// CiliumNode custom resource
+type Node struct {
+	// mutex protects all members of this structure
+	mutex lock.RWMutex
+
+	// name is the name of the node
+	name string
+
+	// resource is the link to the CiliumNode custom resource
+	resource *v2.CiliumNode
+
+	// stats provides accounting for various per node statistics
+	stats Statistics
+
+	// capacityExhausted is true when the provider allocated fewer IPs
+	// than requested during the last allocation
+	capacityExhausted bool
+
+	// available is the pool of IPs assigned to the node by the provider
+	available map[string]v2.AllocationIP
+
+	// releasing is the set of excess IPs which have been removed from the
+	// pool and are pending release, indexed by the IP and pointing to the
+	// time of removal
+	releasing map[string]time.Time
+
+	// waitingForMaintenance is true when the pool of the node is subject
+	// to a maintenance which must be performed before another maintenance
+	// can be attempted
+	waitingForMaintenance bool
+
+	// resyncNeeded is set to the current time when a resync with the
+	// provider is required. The timestamp is required to ensure that this
+	// is only reset if the resync started after the time stored in
+	// resyncNeeded. This is needed because resyncs and allocations happen
+	// in parallel.
+	resyncNeeded time.Time
+
+	manager *NodeManager
+
+	// poolMaintainer is the trigger used to resolve a deficit or release
+	// excess IPs of this node. It ensures that multiple requests are
+	// batched together if a maintenance is still ongoing.
+	poolMaintainer *trigger.Trigger
+
+	// k8sSync is the trigger used to synchronize node information with the
+	// K8s apiserver. The trigger is used to batch multiple updates
+	// together if the apiserver is slow to respond or subject to rate
+	// limiting.
+	k8sSync *trigger.Trigger
+}
+
+// Statistics represents the IP allocation statistics of a node
+type Statistics struct {
+	// UsedIPs is the number of IPs currently in use
+	UsedIPs int
+
+	// AvailableIPs is the number of IPs currently available for
+	// allocation by the node
+	AvailableIPs int
+
+	// NeededIPs is the number of IPs needed to reach the PreAllocate
+	// watermwark
+	NeededIPs int
+
+	// ExcessIPs is the number of IPs above the PreAllocate watermark plus
+	// MaxAboveWatermark which can be released
+	ExcessIPs int
+}
+
+func (n *Node) logger() *logrus.Entry {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	return n.loggerLocked()
+}
+
+func (n *Node) loggerLocked() *logrus.Entry {
+	return log.WithField(fieldName, n.name)
+}
+
+func (n *Node) getNeededAddresses() int {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	return n.stats.NeededIPs
+}
+
+// Stats returns the IP allocation statistics of the node
+func (n *Node) Stats() Statistics {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	return n.stats
+}
+
+func (n *Node) updatedResource(resource *v2.CiliumNode) bool {
+	n.mutex.Lock()
+	n.resource = resource
+	n.recalculateLocked()
+	maintenanceNeeded := n.maintenanceNeeded()
+	if maintenanceNeeded {
+		n.waitingForMaintenance = true
+		n.poolMaintainer.Trigger()
+	}
+	n.mutex.Unlock()
+
+	return maintenanceNeeded
+}
+
+func (n *Node) recalculateLocked() {
+	n.available = n.manager.provider.GetPool(n.resource)
+
+	for ip := range n.releasing {
+		// IPs which are no longer assigned to the node have been
+		// released
+		if _, ok := n.available[ip]; !ok {
+			delete(n.releasing, ip)
+			continue
+		}
+
+		// The agent allocated the IP before it observed the removal
+		// from the pool
+		if _, ok := n.resource.Status.IPAM.Used[ip]; ok {
+			n.loggerLocked().WithField("ip", ip).Warning("Excess IP is in use, returning it to pool")
+			delete(n.releasing, ip)
+		}
+	}
+
+	watermarks := n.manager.getWatermarks(n.resource)
+
+	n.stats.UsedIPs = len(n.resource.Status.IPAM.Used)
+	n.stats.AvailableIPs = len(n.available) - len(n.releasing)
+	n.stats.NeededIPs = CalculateNeededIPs(n.stats.AvailableIPs, n.stats.UsedIPs, watermarks.PreAllocate, watermarks.MinAllocate)
+
+	// Resolve a deficit with IPs pending release before allocating new IPs
+	for ip := range n.releasing {
+		if n.stats.NeededIPs == 0 {
+			break
+		}
+		delete(n.releasing, ip)
+		n.stats.AvailableIPs++
+		n.stats.NeededIPs--
+	}
+
+	n.stats.ExcessIPs = 0
+	if n.manager.releaseExcessIPs {
+		n.stats.ExcessIPs = CalculateExcessIPs(n.stats.AvailableIPs, n.stats.UsedIPs, watermarks.PreAllocate, watermarks.MinAllocate, watermarks.MaxAboveWatermark)
+	}
+
+	n.loggerLocked().WithFields(logrus.Fields{
+		"available":             n.stats.AvailableIPs,
+		"used":                  n.stats.UsedIPs,
+		"toAlloc":               n.stats.NeededIPs,
+		"toRelease":             n.stats.ExcessIPs,
+		"releasing":             len(n.releasing),
+		"waitingForMaintenance": n.waitingForMaintenance,
+		"resyncNeeded":          n.resyncNeeded,
+	}).Debug("Recalculated needed addresses")
+}
+
+// maintenanceNeeded returns true if this node requires IPs to be allocated
+// or released
+func (n *Node) maintenanceNeeded() bool {
+	return !n.waitingForMaintenance && n.resyncNeeded.IsZero() &&
+		(n.stats.NeededIPs > 0 || n.stats.ExcessIPs > 0 || len(n.releasing) > 0)
+}
+
+// Pool returns the IP allocation pool available to the node. IPs pending
+// release are excluded.
+func (n *Node) Pool() (pool map[string]v2.AllocationIP) {
+	pool = map[string]v2.AllocationIP{}
+	n.mutex.RLock()
+	for k, allocationIP := range n.available {
+		if _, ok := n.releasing[k]; !ok {
+			pool[k] = allocationIP
+		}
+	}
+	n.mutex.RUnlock()
+	return
+}
+
+// ResourceCopy returns a deep copy of the CiliumNode custom resource
+// associated with the node
+func (n *Node) ResourceCopy() *v2.CiliumNode {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.resource.DeepCopy()
+}
+
+// markExcessIPs removes up to excessIPs unused IPs from the pool and marks
+// them for release
+func (n *Node) markExcessIPs(excessIPs int) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	candidates := make([]string, 0, len(n.available))
+	for ip := range n.available {
+		if _, ok := n.resource.Status.IPAM.Used[ip]; ok {
+			continue
+		}
+		if _, ok := n.releasing[ip]; ok {
+			continue
+		}
+		candidates = append(candidates, ip)
+	}
+	sort.Strings(candidates)
+	if len(candidates) > excessIPs {
+		candidates = candidates[:excessIPs]
+	}
+
+	if n.releasing == nil {
+		n.releasing = map[string]time.Time{}
+	}
+	now := time.Now()
+	for _, ip := range candidates {
+		n.releasing[ip] = now
+	}
+
+	n.loggerLocked().WithField("ips", candidates).Info("Removed excess IPs from pool, releasing after delay")
+}
+
+// releaseExcessIPs releases all IPs which have been pending release for
+// longer than excessIPReleaseDelay
+func (n *Node) releaseExcessIPs(ctx context.Context) error {
+	n.mutex.Lock()
+	resource := n.resource.DeepCopy()
+	toRelease := []string{}
+	for ip, since := range n.releasing {
+		if time.Since(since) >= excessIPReleaseDelay {
+			toRelease = append(toRelease, ip)
+		}
+	}
+	n.mutex.Unlock()
+
+	if len(toRelease) == 0 {
+		return nil
+	}
+	sort.Strings(toRelease)
+
+	if err := n.manager.provider.ReleaseIPs(ctx, resource, toRelease); err != nil {
+		return fmt.Errorf("unable to release %d excess IPs: %s", len(toRelease), err)
+	}
+
+	n.logger().WithField("ips", toRelease).Info("Released excess IPs")
+
+	n.mutex.Lock()
+	for _, ip := range toRelease {
+		delete(n.releasing, ip)
+		delete(n.available, ip)
+	}
+	n.mutex.Unlock()
+
+	return nil
+}
+
+// maintainPool allocates IPs to resolve a deficit or releases excess IPs. It
+// returns true if IPs have been allocated.
+func (n *Node) maintainPool(ctx context.Context) (allocated bool, err error) {
+	n.mutex.RLock()
+	resource := n.resource.DeepCopy()
+	neededIPs := n.stats.NeededIPs
+	excessIPs := n.stats.ExcessIPs
+	n.mutex.RUnlock()
+
+	if neededIPs > 0 {
+		toAllocate := neededIPs + n.manager.getWatermarks(resource).MaxAboveWatermark
+		numAllocated, err := n.manager.provider.AllocateIPs(ctx, resource, toAllocate)
+		if err != nil {
+			return false, fmt.Errorf("unable to allocate %d IPs: %s", toAllocate, err)
+		}
+
+		n.mutex.Lock()
+		n.capacityExhausted = numAllocated < toAllocate
+		n.mutex.Unlock()
+
+		n.logger().WithFields(logrus.Fields{
+			"requested": toAllocate,
+			"allocated": numAllocated,
+		}).Info("Resolved IP deficit of node")
+
+		return numAllocated > 0, nil
+	}
+
+	if excessIPs > 0 {
+		n.markExcessIPs(excessIPs)
+	}
+
+	return false, n.releaseExcessIPs(ctx)
+}
+
+// MaintainIPPool attempts to allocate all IPs required to reach the
+// watermarks of the node, or to release all IPs in excess of them.
+func (n *Node) MaintainIPPool(ctx context.Context) error {
+	allocated, err := n.maintainPool(ctx)
+
+	n.mutex.Lock()
+	if allocated {
+		n.loggerLocked().Debug("Setting resync needed")
+		n.resyncNeeded = time.Now()
+	}
+	n.recalculateLocked()
+	n.waitingForMaintenance = false
+	n.mutex.Unlock()
+
+	// A failed allocation may be caused by outdated provider state, e.g.
+	// an unknown subnet, resync before retrying
+	if allocated || err != nil {
+		n.manager.resyncTrigger.Trigger()
+	}
+	n.k8sSync.Trigger()
+
+	return err
+}
+
+// SyncToAPIServer is called to synchronize the node content with the custom
+// resource in the apiserver
+func (n *Node) SyncToAPIServer() (err error) {
+	var updatedNode *v2.CiliumNode
+
+	scopedLog := n.logger()
+	scopedLog.Debug("Refreshing node")
+
+	node := n.ResourceCopy()
+	origNode := node.DeepCopy()
+
+	// Always update the status first to ensure that the provider specific
+	// information is synced for all addresses that are marked as
+	// available.
+	//
+	// Two attempts are made in case the local resource is outdated. If the
+	// second attempt fails as well we are likely under heavy contention,
+	// fall back to the controller based background interval to retry.
+	for retry := 0; retry < 2; retry++ {
+		if node.Status.IPAM.Used == nil {
+			node.Status.IPAM.Used = map[string]v2.AllocationIP{}
+		}
+
+		n.manager.provider.PopulateStatusFields(node)
+
+		scopedLog.WithField("allocatedIPs", len(node.Status.IPAM.Used)).Debug("Updating status of node in apiserver")
+
+		updatedNode, err = n.manager.k8sAPI.UpdateStatus(node, origNode)
+		if updatedNode != nil && updatedNode.Name != "" {
+			node = updatedNode.DeepCopy()
+			if err == nil {
+				break
+			}
+		} else if err != nil {
+			node, err = n.manager.k8sAPI.Get(node.Name)
+			if err != nil {
+				break
+			}
+			node = node.DeepCopy()
+			origNode = node.DeepCopy()
+		} else {
+			break
+		}
+	}
+
+	if err != nil {
+		scopedLog.WithError(err).Warning("Unable to update CiliumNode status")
+		return err
+	}
+
+	for retry := 0; retry < 2; retry++ {
+		node.Spec.IPAM.Pool = n.Pool()
+
+		scopedLog.WithField("poolSize", len(node.Spec.IPAM.Pool)).Debug("Updating node in apiserver")
+
+		updatedNode, err = n.manager.k8sAPI.Update(node, origNode)
+		if updatedNode != nil && updatedNode.Name != "" {
+			node = updatedNode.DeepCopy()
+			if err == nil {
+				break
+			}
+		} else if err != nil {
+			node, err = n.manager.k8sAPI.Get(node.Name)
+			if err != nil {
+				break
+			}
+			node = node.DeepCopy()
+			origNode = node.DeepCopy()
+		} else {
+			break
+		}
+	}
+
+	if err != nil {
+		scopedLog.WithError(err).Warning("Unable to update CiliumNode spec")
+	}
+
+	return err
+}
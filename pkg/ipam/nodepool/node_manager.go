@@ -1,5 +1,4 @@
 // Copyright 2019 Authors of Cilium
-// Copyright 2017 Lyft, Inc.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -13,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package eni
+package nodepool
 
 import (
 	"context"
@@ -21,7 +20,6 @@ import (
 	"sort"
 	"time"
 
-	"github.com/cilium/cilium/pkg/aws/types"
 	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/trigger"
@@ -29,80 +27,49 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-type k8sAPI interface {
-	Update(origResource, newResource *v2.CiliumNode) (*v2.CiliumNode, error)
-	UpdateStatus(origResource, newResource *v2.CiliumNode) (*v2.CiliumNode, error)
-	Get(name string) (*v2.CiliumNode, error)
-}
-
-type nodeManagerAPI interface {
-	GetENI(instanceID string, index int) *v2.ENI
-	GetENIs(instanceID string) []*v2.ENI
-	GetSubnet(subnetID string) *types.Subnet
-	GetSubnets() types.SubnetMap
-	FindSubnetByTags(vpcID, availabilityZone string, required types.Tags) *types.Subnet
-	Resync() time.Time
-	UpdateENI(instanceID string, eni *v2.ENI)
-}
-
-type ec2API interface {
-	CreateNetworkInterface(toAllocate int64, subnetID, desc string, groups []string) (string, *v2.ENI, error)
-	DeleteNetworkInterface(eniID string) error
-	AttachNetworkInterface(index int64, instanceID, eniID string) (string, error)
-	ModifyNetworkInterface(eniID, attachmentID string, deleteOnTermination bool) error
-	AssignPrivateIpAddresses(eniID string, addresses int64) error
-}
-
-type metricsAPI interface {
-	IncENIAllocationAttempt(status, subnetID string)
-	AddIPAllocation(subnetID string, allocated int64)
-	SetAllocatedIPs(typ string, allocated int)
-	SetAvailableENIs(available int)
-	SetAvailableIPsPerSubnet(subnetID string, availabilityZone string, available int)
-	SetNodes(category string, nodes int)
-	IncResyncCount()
-	DeficitResolverTrigger() trigger.MetricsObserver
-	K8sSyncTrigger() trigger.MetricsObserver
-	ResyncTrigger() trigger.MetricsObserver
-}
-
-// nodeMap is a mapping of node names to ENI nodes
+// nodeMap is a mapping of node names to nodes
 type nodeMap map[string]*Node
 
-// NodeManager manages all nodes with ENIs
+// NodeManager manages the IP allocation pools of all nodes
 type NodeManager struct {
-	mutex           lock.RWMutex
-	nodes           nodeMap
-	instancesAPI    nodeManagerAPI
-	ec2API          ec2API
-	k8sAPI          k8sAPI
-	metricsAPI      metricsAPI
-	resyncTrigger   *trigger.Trigger
-	parallelWorkers int64
+	mutex            lock.RWMutex
+	nodes            nodeMap
+	provider         Provider
+	k8sAPI           k8sAPI
+	metricsAPI       MetricsAPI
+	resyncTrigger    *trigger.Trigger
+	parallelWorkers  int64
+	releaseExcessIPs bool
 }
 
-// NewNodeManager returns a new NodeManager
-func NewNodeManager(instancesAPI nodeManagerAPI, ec2API ec2API, k8sAPI k8sAPI, metrics metricsAPI, parallelWorkers int64) (*NodeManager, error) {
+// NewNodeManager returns a new NodeManager maintaining the pools of all nodes
+// via provider. If releaseExcessIPs is true, IPs above the PreAllocate plus
+// MaxAboveWatermark watermark are released. metrics may be nil.
+func NewNodeManager(provider Provider, k8sAPI k8sAPI, metrics MetricsAPI, parallelWorkers int64, releaseExcessIPs bool) (*NodeManager, error) {
 	if parallelWorkers < 1 {
 		parallelWorkers = 1
 	}
 
+	if metrics == nil {
+		metrics = noOpMetrics{}
+	}
+
 	mngr := &NodeManager{
-		nodes:           nodeMap{},
-		instancesAPI:    instancesAPI,
-		ec2API:          ec2API,
-		k8sAPI:          k8sAPI,
-		metricsAPI:      metrics,
-		parallelWorkers: parallelWorkers,
+		nodes:            nodeMap{},
+		provider:         provider,
+		k8sAPI:           k8sAPI,
+		metricsAPI:       metrics,
+		parallelWorkers:  parallelWorkers,
+		releaseExcessIPs: releaseExcessIPs,
 	}
 
 	resyncTrigger, err := trigger.NewTrigger(trigger.Parameters{
-		Name:            "eni-node-manager-resync",
+		Name:            "ipam-node-manager-resync",
 		MinInterval:     10 * time.Millisecond,
 		MetricsObserver: metrics.ResyncTrigger(),
 		TriggerFunc: func(reasons []string) {
-			syncTime := instancesAPI.Resync()
-			mngr.Resync(syncTime)
+			syncTime := provider.Resync(context.TODO())
+			mngr.Resync(context.TODO(), syncTime)
 		},
 	})
 	if err != nil {
@@ -114,6 +81,16 @@ func NewNodeManager(instancesAPI nodeManagerAPI, ec2API ec2API, k8sAPI k8sAPI, m
 	return mngr, nil
 }
 
+// getWatermarks returns the watermarks of the node as specified by the
+// provider or, if the provider does not implement WatermarksProvider, by the
+// IPAM spec of the node
+func (n *NodeManager) getWatermarks(resource *v2.CiliumNode) Watermarks {
+	if p, ok := n.provider.(WatermarksProvider); ok {
+		return p.GetWatermarks(resource)
+	}
+	return IPAMWatermarks(resource)
+}
+
 // GetNames returns the list of all node names
 func (n *NodeManager) GetNames() (allNodeNames []string) {
 	n.mutex.RLock()
@@ -135,27 +112,29 @@ func (n *NodeManager) Update(resource *v2.CiliumNode) bool {
 	node, ok := n.nodes[resource.Name]
 	if !ok {
 		node = &Node{
-			name:    resource.Name,
-			manager: n,
+			name:      resource.Name,
+			manager:   n,
+			releasing: map[string]time.Time{},
 		}
 
-		deficitResolver, err := trigger.NewTrigger(trigger.Parameters{
-			Name:            fmt.Sprintf("eni-deficit-resolver-%s", resource.Name),
+		poolMaintainer, err := trigger.NewTrigger(trigger.Parameters{
+			Name:            fmt.Sprintf("ipam-pool-maintainer-%s", resource.Name),
 			MinInterval:     10 * time.Millisecond,
-			MetricsObserver: n.metricsAPI.DeficitResolverTrigger(),
+			MetricsObserver: n.metricsAPI.PoolMaintainerTrigger(),
 			TriggerFunc: func(reasons []string) {
-				if err := node.ResolveIPDeficit(); err != nil {
-					node.logger().WithError(err).Warning("Unable to resolve IP deficit of node")
+				if err := node.MaintainIPPool(context.TODO()); err != nil {
+					node.logger().WithError(err).Warning("Unable to maintain IP pool of node")
 				}
 			},
 		})
 		if err != nil {
-			node.logger().WithError(err).Error("Unable to create deficit-resolver trigger")
+			n.mutex.Unlock()
+			node.logger().WithError(err).Error("Unable to create pool-maintainer trigger")
 			return false
 		}
 
 		k8sSync, err := trigger.NewTrigger(trigger.Parameters{
-			Name:            fmt.Sprintf("eni-node-k8s-sync-%s", resource.Name),
+			Name:            fmt.Sprintf("ipam-node-k8s-sync-%s", resource.Name),
 			MinInterval:     10 * time.Millisecond,
 			MetricsObserver: n.metricsAPI.K8sSyncTrigger(),
 			TriggerFunc: func(reasons []string) {
@@ -163,12 +142,13 @@ func (n *NodeManager) Update(resource *v2.CiliumNode) bool {
 			},
 		})
 		if err != nil {
-			deficitResolver.Shutdown()
+			poolMaintainer.Shutdown()
+			n.mutex.Unlock()
 			node.logger().WithError(err).Error("Unable to create k8s-sync trigger")
 			return false
 		}
 
-		node.deficitResolver = deficitResolver
+		node.poolMaintainer = poolMaintainer
 		node.k8sSync = k8sSync
 		n.nodes[node.name] = node
 
@@ -184,8 +164,8 @@ func (n *NodeManager) Update(resource *v2.CiliumNode) bool {
 func (n *NodeManager) Delete(nodeName string) {
 	n.mutex.Lock()
 	if node, ok := n.nodes[nodeName]; ok {
-		if node.deficitResolver != nil {
-			node.deficitResolver.Shutdown()
+		if node.poolMaintainer != nil {
+			node.poolMaintainer.Shutdown()
 		}
 		if node.k8sSync != nil {
 			node.k8sSync.Shutdown()
@@ -224,14 +204,13 @@ func (n *NodeManager) GetNodesByNeededAddresses() []*Node {
 }
 
 type resyncStats struct {
-	mutex               lock.Mutex
-	totalUsed           int
-	totalAvailable      int
-	totalNeeded         int
-	remainingInterfaces int
-	nodes               int
-	nodesAtCapacity     int
-	nodesInDeficit      int
+	mutex           lock.Mutex
+	totalUsed       int
+	totalAvailable  int
+	totalNeeded     int
+	nodes           int
+	nodesAtCapacity int
+	nodesInDeficit  int
 }
 
 func (n *NodeManager) resyncNode(node *Node, stats *resyncStats, syncTime time.Time) {
@@ -243,48 +222,44 @@ func (n *NodeManager) resyncNode(node *Node, stats *resyncStats, syncTime time.T
 	}
 
 	node.recalculateLocked()
-	allocationNeeded := node.allocationNeeded()
-	if allocationNeeded {
-		node.waitingForAllocation = true
-		node.deficitResolver.Trigger()
+	if node.maintenanceNeeded() {
+		node.waitingForMaintenance = true
+		node.poolMaintainer.Trigger()
 	}
 
 	stats.mutex.Lock()
-	stats.totalUsed += node.stats.usedIPs
-	availableOnNode := node.stats.availableIPs - node.stats.usedIPs
+	stats.totalUsed += node.stats.UsedIPs
+	availableOnNode := node.stats.AvailableIPs - node.stats.UsedIPs
 	stats.totalAvailable += availableOnNode
-	stats.totalNeeded += node.stats.neededIPs
-	stats.remainingInterfaces += node.stats.remainingInterfaces
+	stats.totalNeeded += node.stats.NeededIPs
 	stats.nodes++
 
-	if allocationNeeded {
+	if node.stats.NeededIPs > 0 {
 		stats.nodesInDeficit++
 	}
 
-	if node.stats.remainingInterfaces == 0 && availableOnNode == 0 {
+	if node.capacityExhausted && availableOnNode <= 0 {
 		stats.nodesAtCapacity++
 	}
-
-	for subnetID, subnet := range n.instancesAPI.GetSubnets() {
-		n.metricsAPI.SetAvailableIPsPerSubnet(subnetID, subnet.AvailabilityZone, subnet.AvailableAddresses)
-	}
-
 	stats.mutex.Unlock()
+
 	node.mutex.Unlock()
 
 	node.k8sSync.Trigger()
 }
 
-// Resync will attend all nodes and resolves IP deficits. The order of
-// attendance is defined by the number of IPs needed to reach the configured
-// watermarks. Any updates to the node resource are synchronized to the
-// Kubernetes apiserver.
-func (n *NodeManager) Resync(syncTime time.Time) {
+// Resync will attend all nodes and resolves IP deficits and excess IPs. The
+// order of attendance is defined by the number of IPs needed to reach the
+// configured watermarks. Any updates to the node resource are synchronized
+// to the Kubernetes apiserver.
+func (n *NodeManager) Resync(ctx context.Context, syncTime time.Time) {
 	stats := resyncStats{}
 	sem := semaphore.NewWeighted(n.parallelWorkers)
 
 	for _, node := range n.GetNodesByNeededAddresses() {
-		sem.Acquire(context.TODO(), 1)
+		if err := sem.Acquire(ctx, 1); err != nil {
+			continue
+		}
 		go func(node *Node, stats *resyncStats) {
 			n.resyncNode(node, stats, syncTime)
 			sem.Release(1)
@@ -293,12 +268,13 @@ func (n *NodeManager) Resync(syncTime time.Time) {
 
 	// Acquire the full semaphore, this requires all go routines to
 	// complete and thus blocks until all nodes are synced
-	sem.Acquire(context.TODO(), n.parallelWorkers)
+	if err := sem.Acquire(ctx, n.parallelWorkers); err != nil {
+		return
+	}
 
 	n.metricsAPI.SetAllocatedIPs("used", stats.totalUsed)
 	n.metricsAPI.SetAllocatedIPs("available", stats.totalAvailable)
 	n.metricsAPI.SetAllocatedIPs("needed", stats.totalNeeded)
-	n.metricsAPI.SetAvailableENIs(stats.remainingInterfaces)
 	n.metricsAPI.SetNodes("total", stats.nodes)
 	n.metricsAPI.SetNodes("in-deficit", stats.nodesInDeficit)
 	n.metricsAPI.SetNodes("at-capacity", stats.nodesAtCapacity)
@@ -0,0 +1,309 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !privileged_tests
+// +build !privileged_tests
+
+package nodepool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/pkg/ipam/nodepool/fake"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/testutils"
+	"github.com/cilium/cilium/pkg/trigger"
+
+	"gopkg.in/check.v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type NodePoolSuite struct{}
+
+var _ = check.Suite(&NodePoolSuite{})
+
+var (
+	_ Provider = &fake.Provider{}
+
+	k8sapi = &k8sMock{}
+)
+
+type k8sMock struct{}
+
+func (k *k8sMock) Update(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
+	return nil, nil
+}
+
+func (k *k8sMock) UpdateStatus(node, origNode *v2.CiliumNode) (*v2.CiliumNode, error) {
+	return nil, nil
+}
+
+func (k *k8sMock) Get(node string) (*v2.CiliumNode, error) {
+	return &v2.CiliumNode{}, nil
+}
+
+type metricsMock struct {
+	mutex        lock.Mutex
+	allocatedIPs map[string]int
+	nodes        map[string]int
+}
+
+func newMetricsMock() *metricsMock {
+	return &metricsMock{allocatedIPs: map[string]int{}, nodes: map[string]int{}}
+}
+
+func (m *metricsMock) SetAllocatedIPs(typ string, allocated int) {
+	m.mutex.Lock()
+	m.allocatedIPs[typ] = allocated
+	m.mutex.Unlock()
+}
+
+func (m *metricsMock) SetNodes(category string, nodes int) {
+	m.mutex.Lock()
+	m.nodes[category] = nodes
+	m.mutex.Unlock()
+}
+
+func (m *metricsMock) PoolMaintainerTrigger() trigger.MetricsObserver { return nil }
+func (m *metricsMock) K8sSyncTrigger() trigger.MetricsObserver        { return nil }
+func (m *metricsMock) ResyncTrigger() trigger.MetricsObserver         { return nil }
+
+func newCiliumNode(node string, preAllocate, minAllocate, maxAboveWatermark int) *v2.CiliumNode {
+	return &v2.CiliumNode{
+		ObjectMeta: metav1.ObjectMeta{Name: node},
+		Spec: v2.NodeSpec{
+			IPAM: v2.IPAMSpec{
+				PreAllocate:       preAllocate,
+				MinAllocate:       minAllocate,
+				MaxAboveWatermark: maxAboveWatermark,
+			},
+		},
+	}
+}
+
+// useIPs marks the first used IPs of the pool of the node as used
+func useIPs(mngr *NodeManager, cn *v2.CiliumNode, used int) *v2.CiliumNode {
+	ips := []string{}
+	for ip := range mngr.Get(cn.Name).Pool() {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	cn = cn.DeepCopy()
+	cn.Status.IPAM.Used = map[string]v2.AllocationIP{}
+	for _, ip := range ips[:used] {
+		cn.Status.IPAM.Used[ip] = v2.AllocationIP{Owner: "pod"}
+	}
+	return cn
+}
+
+func reachedAddressesNeeded(mngr *NodeManager, nodeName string, needed int) (success bool) {
+	if node := mngr.Get(nodeName); node != nil {
+		success = node.getNeededAddresses() == needed
+	}
+	return
+}
+
+func reachedPoolSize(mngr *NodeManager, nodeName string, size int) (success bool) {
+	if node := mngr.Get(nodeName); node != nil {
+		success = len(node.Pool()) == size
+	}
+	return
+}
+
+func (e *NodePoolSuite) TestGetNodeNames(c *check.C) {
+	mngr, err := NewNodeManager(fake.NewProvider(0), k8sapi, nil, 10, false)
+	c.Assert(err, check.IsNil)
+
+	mngr.Update(newCiliumNode("node1", 0, 0, 0))
+	mngr.Update(newCiliumNode("node2", 0, 0, 0))
+	c.Assert(len(mngr.GetNames()), check.Equals, 2)
+
+	mngr.Delete("node1")
+	names := mngr.GetNames()
+	c.Assert(len(names), check.Equals, 1)
+	c.Assert(names[0], check.Equals, "node2")
+	c.Assert(mngr.Get("node1"), check.IsNil)
+}
+
+// TestDefaultAllocation tests allocation with default parameters
+func (e *NodePoolSuite) TestDefaultAllocation(c *check.C) {
+	provider := fake.NewProvider(0)
+	mngr, err := NewNodeManager(provider, k8sapi, nil, 10, false)
+	c.Assert(err, check.IsNil)
+
+	cn := newCiliumNode("node1", 0, 0, 0)
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node1", 0) }, 5*time.Second), check.IsNil)
+	c.Assert(len(mngr.Get("node1").Pool()), check.Equals, 8)
+	c.Assert(provider.NumIPs("node1"), check.Equals, 8)
+
+	// Use 7 out of 8 IPs, 7 more IPs are needed to reach PreAllocate
+	mngr.Update(useIPs(mngr, cn, 7))
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 15) }, 5*time.Second), check.IsNil)
+	c.Assert(mngr.Get("node1").getNeededAddresses(), check.Equals, 0)
+}
+
+// TestMinAllocateAndMaxAboveWatermark tests MinAllocate in combination with
+// MaxAboveWatermark
+func (e *NodePoolSuite) TestMinAllocateAndMaxAboveWatermark(c *check.C) {
+	mngr, err := NewNodeManager(fake.NewProvider(0), k8sapi, nil, 10, false)
+	c.Assert(err, check.IsNil)
+
+	mngr.Update(newCiliumNode("node1", 1, 10, 2))
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 12) }, 5*time.Second), check.IsNil)
+	c.Assert(mngr.Get("node1").getNeededAddresses(), check.Equals, 0)
+}
+
+// TestCapacityExceeded tests a provider unable to fulfill the deficit
+func (e *NodePoolSuite) TestCapacityExceeded(c *check.C) {
+	provider := fake.NewProvider(5)
+	mngr, err := NewNodeManager(provider, k8sapi, nil, 10, false)
+	c.Assert(err, check.IsNil)
+
+	mngr.Update(newCiliumNode("node1", 8, 0, 0))
+	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node1", 3) }, 5*time.Second), check.IsNil)
+	c.Assert(provider.NumIPs("node1"), check.Equals, 5)
+}
+
+// TestMetrics tests the metrics reported on resync
+func (e *NodePoolSuite) TestMetrics(c *check.C) {
+	metrics := newMetricsMock()
+	mngr, err := NewNodeManager(fake.NewProvider(5), k8sapi, metrics, 10, false)
+	c.Assert(err, check.IsNil)
+
+	mngr.Update(newCiliumNode("node1", 8, 0, 0))
+	mngr.Update(newCiliumNode("node2", 4, 0, 0))
+	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node1", 3) }, 5*time.Second), check.IsNil)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node2", 0) }, 5*time.Second), check.IsNil)
+
+	// node1 is out of capacity with all 5 IPs unused, it is in deficit
+	// but not at capacity
+	mngr.Resync(context.TODO(), time.Now())
+	metrics.mutex.Lock()
+	c.Assert(metrics.nodes, check.DeepEquals, map[string]int{"total": 2, "in-deficit": 1, "at-capacity": 0})
+	c.Assert(metrics.allocatedIPs, check.DeepEquals, map[string]int{"used": 0, "available": 9, "needed": 3})
+	metrics.mutex.Unlock()
+
+	// Once all IPs of node1 are in use, it is at capacity
+	mngr.Update(useIPs(mngr, newCiliumNode("node1", 8, 0, 0), 5))
+	c.Assert(testutils.WaitUntil(func() bool { return reachedAddressesNeeded(mngr, "node1", 8) }, 5*time.Second), check.IsNil)
+	mngr.Resync(context.TODO(), time.Now())
+	metrics.mutex.Lock()
+	c.Assert(metrics.nodes, check.DeepEquals, map[string]int{"total": 2, "in-deficit": 1, "at-capacity": 1})
+	c.Assert(metrics.allocatedIPs, check.DeepEquals, map[string]int{"used": 5, "available": 4, "needed": 8})
+	metrics.mutex.Unlock()
+}
+
+// TestAllocationError tests that a failed allocation is retried on resync
+func (e *NodePoolSuite) TestAllocationError(c *check.C) {
+	provider := fake.NewProvider(0)
+	provider.SetAllocateError(errors.New("allocation failed"))
+	mngr, err := NewNodeManager(provider, k8sapi, nil, 10, false)
+	c.Assert(err, check.IsNil)
+
+	mngr.Update(newCiliumNode("node1", 4, 0, 0))
+	c.Assert(testutils.WaitUntil(func() bool {
+		node := mngr.Get("node1")
+		node.mutex.RLock()
+		defer node.mutex.RUnlock()
+		return !node.waitingForMaintenance
+	}, 5*time.Second), check.IsNil)
+	c.Assert(provider.NumIPs("node1"), check.Equals, 0)
+
+	provider.SetAllocateError(nil)
+	mngr.Resync(context.TODO(), time.Now())
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 4) }, 5*time.Second), check.IsNil)
+}
+
+// TestReleaseExcessIPs tests the release of IPs above the watermarks
+func (e *NodePoolSuite) TestReleaseExcessIPs(c *check.C) {
+	oldDelay := excessIPReleaseDelay
+	excessIPReleaseDelay = 0
+	defer func() { excessIPReleaseDelay = oldDelay }()
+
+	provider := fake.NewProvider(0)
+	mngr, err := NewNodeManager(provider, k8sapi, nil, 10, true)
+	c.Assert(err, check.IsNil)
+
+	cn := newCiliumNode("node1", 10, 0, 0)
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 10) }, 5*time.Second), check.IsNil)
+
+	// Lower PreAllocate to 2 with 1 IP in use, 7 IPs are in excess
+	cn = useIPs(mngr, cn, 1)
+	cn.Spec.IPAM.PreAllocate = 2
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool { return provider.NumIPs("node1") == 3 }, 5*time.Second), check.IsNil)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 3) }, 5*time.Second), check.IsNil)
+
+	// The used IP has not been released
+	for ip := range cn.Status.IPAM.Used {
+		_, ok := mngr.Get("node1").Pool()[ip]
+		c.Assert(ok, check.Equals, true)
+	}
+}
+
+// TestReleaseExcessIPsInUse tests that an excess IP which is used by the agent
+// before the release delay expired is returned to the pool
+func (e *NodePoolSuite) TestReleaseExcessIPsInUse(c *check.C) {
+	oldDelay := excessIPReleaseDelay
+	excessIPReleaseDelay = time.Hour
+	defer func() { excessIPReleaseDelay = oldDelay }()
+
+	provider := fake.NewProvider(0)
+	mngr, err := NewNodeManager(provider, k8sapi, nil, 10, true)
+	c.Assert(err, check.IsNil)
+
+	cn := newCiliumNode("node1", 10, 0, 0)
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 10) }, 5*time.Second), check.IsNil)
+	pool := mngr.Get("node1").Pool()
+
+	// 6 IPs are removed from the pool but not yet released
+	cn = cn.DeepCopy()
+	cn.Spec.IPAM.PreAllocate = 4
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool { return reachedPoolSize(mngr, "node1", 4) }, 5*time.Second), check.IsNil)
+	c.Assert(provider.NumIPs("node1"), check.Equals, 10)
+
+	// The agent uses one of the IPs pending release
+	node := mngr.Get("node1")
+	node.mutex.RLock()
+	var releasingIP string
+	for ip := range node.releasing {
+		releasingIP = ip
+		break
+	}
+	node.mutex.RUnlock()
+	c.Assert(pool[releasingIP], check.Not(check.Equals), v2.AllocationIP{})
+
+	cn = cn.DeepCopy()
+	cn.Status.IPAM.Used = map[string]v2.AllocationIP{releasingIP: {Owner: "pod"}}
+	mngr.Update(cn)
+	c.Assert(testutils.WaitUntil(func() bool {
+		_, ok := mngr.Get("node1").Pool()[releasingIP]
+		return ok
+	}, 5*time.Second), check.IsNil)
+	c.Assert(provider.NumIPs("node1"), check.Equals, 10)
+}
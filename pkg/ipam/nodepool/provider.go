@@ -0,0 +1,85 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodepool
+
+import (
+	"context"
+	"time"
+
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/trigger"
+)
+
+// Provider is the interface a cloud specific IPAM backend implements to plug
+// into the NodeManager. Nodes are identified by their CiliumNode resource,
+// the provider is expected to derive the cloud specific identity of the
+// node, e.g. the instance ID, from the spec of the resource.
+type Provider interface {
+	// Resync synchronizes the local state of the provider with the cloud
+	// provider. It returns the time at which the synchronization started.
+	// Allocations performed before this time are reflected in the state.
+	Resync(ctx context.Context) time.Time
+
+	// GetPool returns all IPs currently assigned to the node by the
+	// provider, indexed by the IP in string form. The Resource field of
+	// each entry refers to the cloud resource the IP is associated with,
+	// e.g. the network interface.
+	GetPool(resource *v2.CiliumNode) map[string]v2.AllocationIP
+
+	// AllocateIPs assigns up to toAllocate additional IPs to the node. It
+	// returns the number of IPs allocated, which may be less than
+	// toAllocate if the capacity of the node is exhausted.
+	AllocateIPs(ctx context.Context, resource *v2.CiliumNode, toAllocate int) (int, error)
+
+	// ReleaseIPs unassigns the given IPs from the node
+	ReleaseIPs(ctx context.Context, resource *v2.CiliumNode, ips []string) error
+
+	// PopulateStatusFields populates the provider specific fields of the
+	// status of resource before it is written to the apiserver
+	PopulateStatusFields(resource *v2.CiliumNode)
+}
+
+// WatermarksProvider is implemented by providers which read the watermarks of
+// a node from a provider specific section of the CiliumNode spec. The
+// watermarks of the IPAM spec are used for all other providers.
+type WatermarksProvider interface {
+	// GetWatermarks returns the watermarks between which the pool of the
+	// node is maintained
+	GetWatermarks(resource *v2.CiliumNode) Watermarks
+}
+
+// MetricsAPI is the interface the NodeManager uses to report metrics
+type MetricsAPI interface {
+	SetAllocatedIPs(typ string, allocated int)
+	SetNodes(category string, nodes int)
+	PoolMaintainerTrigger() trigger.MetricsObserver
+	K8sSyncTrigger() trigger.MetricsObserver
+	ResyncTrigger() trigger.MetricsObserver
+}
+
+// noOpMetrics is used if no MetricsAPI is passed to NewNodeManager
+type noOpMetrics struct{}
+
+func (m noOpMetrics) SetAllocatedIPs(typ string, allocated int)      {}
+func (m noOpMetrics) SetNodes(category string, nodes int)            {}
+func (m noOpMetrics) PoolMaintainerTrigger() trigger.MetricsObserver { return nil }
+func (m noOpMetrics) K8sSyncTrigger() trigger.MetricsObserver        { return nil }
+func (m noOpMetrics) ResyncTrigger() trigger.MetricsObserver         { return nil }
+
+type k8sAPI interface {
+	Update(origResource, newResource *v2.CiliumNode) (*v2.CiliumNode, error)
+	UpdateStatus(origResource, newResource *v2.CiliumNode) (*v2.CiliumNode, error)
+	Get(name string) (*v2.CiliumNode, error)
+}
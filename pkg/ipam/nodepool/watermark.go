@@ -0,0 +1,84 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodepool
+
+import (
+	"github.com/cilium/cilium/pkg/defaults"
+	"github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/math"
+)
+
+// Watermarks are the watermarks between which the pool of a node is
+// maintained
+type Watermarks struct {
+	// PreAllocate is the number of unused IPs which must be available for
+	// allocation at all times
+	PreAllocate int
+
+	// MinAllocate is the minimum number of IPs which must be available
+	// for allocation
+	MinAllocate int
+
+	// MaxAboveWatermark is the maximum number of IPs allocated beyond the
+	// PreAllocate watermark
+	MaxAboveWatermark int
+}
+
+// IPAMWatermarks returns the watermarks specified in the IPAM spec of the
+// node. PreAllocate defaults to defaults.IPAMPreAllocation.
+func IPAMWatermarks(resource *v2.CiliumNode) Watermarks {
+	w := Watermarks{
+		PreAllocate:       resource.Spec.IPAM.PreAllocate,
+		MinAllocate:       resource.Spec.IPAM.MinAllocate,
+		MaxAboveWatermark: resource.Spec.IPAM.MaxAboveWatermark,
+	}
+	if w.PreAllocate == 0 {
+		w.PreAllocate = defaults.IPAMPreAllocation
+	}
+	return w
+}
+
+// CalculateNeededIPs returns the number of IPs that must be allocated to
+// reach the preAllocate watermark of available and unused IPs, and the
+// minAllocate watermark of available IPs.
+func CalculateNeededIPs(availableIPs, usedIPs, preAllocate, minAllocate int) (neededIPs int) {
+	neededIPs = preAllocate - (availableIPs - usedIPs)
+	if neededIPs < 0 {
+		neededIPs = 0
+	}
+
+	if minAllocate > 0 {
+		neededIPs = math.IntMax(neededIPs, minAllocate-availableIPs)
+	}
+
+	return
+}
+
+// CalculateExcessIPs returns the number of IPs that can be released without
+// falling below the preAllocate watermark plus maxAboveWatermark of unused
+// IPs, or below the minAllocate watermark of available IPs.
+func CalculateExcessIPs(availableIPs, usedIPs, preAllocate, minAllocate, maxAboveWatermark int) (excessIPs int) {
+	excessIPs = availableIPs - usedIPs - preAllocate - maxAboveWatermark
+
+	if minAllocate > 0 {
+		excessIPs = math.IntMin(excessIPs, availableIPs-minAllocate)
+	}
+
+	if excessIPs < 0 {
+		excessIPs = 0
+	}
+
+	return
+}
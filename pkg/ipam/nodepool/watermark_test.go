@@ -0,0 +1,55 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !privileged_tests
+// +build !privileged_tests
+
+package nodepool
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type watermarkTestDef struct {
+	available         int
+	used              int
+	preallocate       int
+	minallocate       int
+	maxabovewatermark int
+	needed            int
+	excess            int
+}
+
+var watermarkDefs = []watermarkTestDef{
+	{0, 0, 0, 16, 0, 16, 0},
+	{0, 0, 8, 16, 0, 16, 0},
+	{0, 0, 16, 8, 0, 16, 0},
+	{0, 0, 16, 0, 0, 16, 0},
+	{8, 0, 0, 16, 0, 8, 0},
+	{8, 4, 8, 0, 0, 4, 0},
+	{8, 4, 8, 8, 0, 4, 0},
+	{16, 4, 8, 0, 0, 0, 4},
+	{16, 4, 8, 0, 2, 0, 2},
+	{16, 4, 8, 14, 0, 0, 2},
+	{16, 16, 8, 0, 0, 8, 0},
+}
+
+func (e *NodePoolSuite) TestCalculateWatermarks(c *check.C) {
+	for _, d := range watermarkDefs {
+		c.Assert(CalculateNeededIPs(d.available, d.used, d.preallocate, d.minallocate), check.Equals, d.needed,
+			check.Commentf("%+v", d))
+		c.Assert(CalculateExcessIPs(d.available, d.used, d.preallocate, d.minallocate, d.maxabovewatermark), check.Equals, d.excess,
+			check.Commentf("%+v", d))
+	}
+}
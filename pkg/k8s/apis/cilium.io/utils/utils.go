@@ -131,6 +131,50 @@ func parseToCiliumIngressRule(namespace string, inRule, retRule *api.Rule) {
 	}
 }
 
+func parseToCiliumIngressDenyRule(namespace string, inRule, retRule *api.Rule) {
+	matchesInit := retRule.EndpointSelector.HasKey(podInitLbl)
+
+	if inRule.IngressDeny != nil {
+		retRule.IngressDeny = make([]api.IngressDenyRule, len(inRule.IngressDeny))
+		for i, ing := range inRule.IngressDeny {
+			if ing.FromEndpoints != nil {
+				retRule.IngressDeny[i].FromEndpoints = make([]api.EndpointSelector, len(ing.FromEndpoints))
+				for j, ep := range ing.FromEndpoints {
+					retRule.IngressDeny[i].FromEndpoints[j] = getEndpointSelector(namespace, ep.LabelSelector, true, matchesInit)
+				}
+			}
+
+			if ing.ToPorts != nil {
+				retRule.IngressDeny[i].ToPorts = make([]api.PortDenyRule, len(ing.ToPorts))
+				copy(retRule.IngressDeny[i].ToPorts, ing.ToPorts)
+			}
+			if ing.FromCIDR != nil {
+				retRule.IngressDeny[i].FromCIDR = make([]api.CIDR, len(ing.FromCIDR))
+				copy(retRule.IngressDeny[i].FromCIDR, ing.FromCIDR)
+			}
+
+			if ing.FromCIDRSet != nil {
+				retRule.IngressDeny[i].FromCIDRSet = make([]api.CIDRRule, len(ing.FromCIDRSet))
+				copy(retRule.IngressDeny[i].FromCIDRSet, ing.FromCIDRSet)
+			}
+
+			if ing.FromRequires != nil {
+				retRule.IngressDeny[i].FromRequires = make([]api.EndpointSelector, len(ing.FromRequires))
+				for j, ep := range ing.FromRequires {
+					retRule.IngressDeny[i].FromRequires[j] = getEndpointSelector(namespace, ep.LabelSelector, false, matchesInit)
+				}
+			}
+
+			if ing.FromEntities != nil {
+				retRule.IngressDeny[i].FromEntities = make([]api.Entity, len(ing.FromEntities))
+				copy(retRule.IngressDeny[i].FromEntities, ing.FromEntities)
+			}
+
+			retRule.IngressDeny[i].SetAggregatedSelectors()
+		}
+	}
+}
+
 func parseToCiliumEgressRule(namespace string, inRule, retRule *api.Rule) {
 	matchesInit := retRule.EndpointSelector.HasKey(podInitLbl)
 
@@ -191,6 +235,50 @@ func parseToCiliumEgressRule(namespace string, inRule, retRule *api.Rule) {
 	}
 }
 
+func parseToCiliumEgressDenyRule(namespace string, inRule, retRule *api.Rule) {
+	matchesInit := retRule.EndpointSelector.HasKey(podInitLbl)
+
+	if inRule.EgressDeny != nil {
+		retRule.EgressDeny = make([]api.EgressDenyRule, len(inRule.EgressDeny))
+		for i, egr := range inRule.EgressDeny {
+			if egr.ToEndpoints != nil {
+				retRule.EgressDeny[i].ToEndpoints = make([]api.EndpointSelector, len(egr.ToEndpoints))
+				for j, ep := range egr.ToEndpoints {
+					retRule.EgressDeny[i].ToEndpoints[j] = getEndpointSelector(namespace, ep.LabelSelector, true, matchesInit)
+				}
+			}
+
+			if egr.ToPorts != nil {
+				retRule.EgressDeny[i].ToPorts = make([]api.PortDenyRule, len(egr.ToPorts))
+				copy(retRule.EgressDeny[i].ToPorts, egr.ToPorts)
+			}
+			if egr.ToCIDR != nil {
+				retRule.EgressDeny[i].ToCIDR = make([]api.CIDR, len(egr.ToCIDR))
+				copy(retRule.EgressDeny[i].ToCIDR, egr.ToCIDR)
+			}
+
+			if egr.ToCIDRSet != nil {
+				retRule.EgressDeny[i].ToCIDRSet = make(api.CIDRRuleSlice, len(egr.ToCIDRSet))
+				copy(retRule.EgressDeny[i].ToCIDRSet, egr.ToCIDRSet)
+			}
+
+			if egr.ToRequires != nil {
+				retRule.EgressDeny[i].ToRequires = make([]api.EndpointSelector, len(egr.ToRequires))
+				for j, ep := range egr.ToRequires {
+					retRule.EgressDeny[i].ToRequires[j] = getEndpointSelector(namespace, ep.LabelSelector, false, matchesInit)
+				}
+			}
+
+			if egr.ToEntities != nil {
+				retRule.EgressDeny[i].ToEntities = make([]api.Entity, len(egr.ToEntities))
+				copy(retRule.EgressDeny[i].ToEntities, egr.ToEntities)
+			}
+
+			retRule.EgressDeny[i].SetAggregatedSelectors()
+		}
+	}
+}
+
 // namespacesAreValid checks the set of namespaces from a rule returns true if
 // they are not specified, or if they are specified and match the namespace
 // where the rule is being inserted.
@@ -229,6 +317,8 @@ func ParseToCiliumRule(namespace, name string, uid types.UID, r *api.Rule) *api.
 
 	parseToCiliumIngressRule(namespace, r, retRule)
 	parseToCiliumEgressRule(namespace, r, retRule)
+	parseToCiliumIngressDenyRule(namespace, r, retRule)
+	parseToCiliumEgressDenyRule(namespace, r, retRule)
 
 	retRule.Labels = ParseToCiliumLabels(namespace, name, uid, r.Labels)
 
@@ -286,6 +286,91 @@ func Test_ParseToCiliumRule(t *testing.T) {
 				},
 			),
 		},
+		{
+			// Deny rules are namespaced like allow rules.
+			name: "parse-deny-rules",
+			args: args{
+				namespace: metav1.NamespaceDefault,
+				uid:       uuid,
+				rule: &api.Rule{
+					EndpointSelector: api.NewESFromMatchRequirements(
+						map[string]string{
+							role: "backend",
+						},
+						nil,
+					),
+					IngressDeny: []api.IngressDenyRule{
+						{
+							FromEndpoints: []api.EndpointSelector{
+								api.NewESFromMatchRequirements(
+									map[string]string{
+										role: "frontend",
+									},
+									nil,
+								),
+							},
+						},
+					},
+					EgressDeny: []api.EgressDenyRule{
+						{
+							ToCIDR: []api.CIDR{"10.0.0.0/8"},
+						},
+					},
+				},
+			},
+			want: api.NewRule().WithEndpointSelector(
+				api.NewESFromMatchRequirements(
+					map[string]string{
+						role:      "backend",
+						namespace: "default",
+					},
+					nil,
+				),
+			).WithIngressDenyRules(
+				[]api.IngressDenyRule{
+					{
+						FromEndpoints: []api.EndpointSelector{
+							api.NewESFromMatchRequirements(
+								map[string]string{
+									role:      "frontend",
+									namespace: "default",
+								},
+								nil,
+							),
+						},
+					},
+				},
+			).WithEgressDenyRules(
+				[]api.EgressDenyRule{
+					{
+						ToCIDR: []api.CIDR{"10.0.0.0/8"},
+					},
+				},
+			).WithLabels(
+				labels.LabelArray{
+					{
+						Key:    "io.cilium.k8s.policy.derived-from",
+						Value:  "CiliumNetworkPolicy",
+						Source: labels.LabelSourceK8s,
+					},
+					{
+						Key:    "io.cilium.k8s.policy.name",
+						Value:  "parse-deny-rules",
+						Source: labels.LabelSourceK8s,
+					},
+					{
+						Key:    "io.cilium.k8s.policy.namespace",
+						Value:  "default",
+						Source: labels.LabelSourceK8s,
+					},
+					{
+						Key:    "io.cilium.k8s.policy.uid",
+						Value:  string(uuid),
+						Source: labels.LabelSourceK8s,
+					},
+				},
+			),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
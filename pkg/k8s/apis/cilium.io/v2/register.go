@@ -42,7 +42,7 @@ const (
 
 	// CustomResourceDefinitionSchemaVersion is semver-conformant version of CRD schema
 	// Used to determine if CRD needs to be updated in cluster
-	CustomResourceDefinitionSchemaVersion = "1.14"
+	CustomResourceDefinitionSchemaVersion = "1.18"
 
 	// CustomResourceDefinitionSchemaVersionKey is key to label which holds the CRD schema version
 	CustomResourceDefinitionSchemaVersionKey = "io.cilium.k8s.crd.schema.version"
@@ -473,8 +473,10 @@ var (
 	properties = map[string]apiextensionsv1beta1.JSONSchemaProps{
 		"CIDR":                     CIDR,
 		"CIDRRule":                 CIDRRule,
+		"EgressDenyRule":           EgressDenyRule,
 		"EgressRule":               EgressRule,
 		"EndpointSelector":         EndpointSelector,
+		"IngressDenyRule":          IngressDenyRule,
 		"IngressRule":              IngressRule,
 		"K8sServiceNamespace":      K8sServiceNamespace,
 		"L7Rules":                  L7Rules,
@@ -482,7 +484,9 @@ var (
 		"LabelSelector":            LabelSelector,
 		"LabelSelectorRequirement": LabelSelectorRequirement,
 		"PortProtocol":             PortProtocol,
+		"PortDenyRule":             PortDenyRule,
 		"PortRule":                 PortRule,
+		"PortRuleGRPC":             PortRuleGRPC,
 		"PortRuleHTTP":             PortRuleHTTP,
 		"PortRuleKafka":            PortRuleKafka,
 		"PortRuleL7":               PortRuleL7,
@@ -641,7 +645,8 @@ var (
 				gather data from third-party providers and create a new
 				derived policy.`,
 				Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
-					"AWS": AWSGroup,
+					"AWS":       AWSGroup,
+					"inventory": InventoryGroup,
 				},
 			},
 			"toFQDNs": {
@@ -655,6 +660,69 @@ var (
 		},
 	}
 
+	EgressDenyRule = apiextensionsv1beta1.JSONSchemaProps{
+		Description: "EgressDenyRule contains all rule types which can be applied at egress, " +
+			"i.e. network traffic that originates inside the endpoint and exits the endpoint " +
+			"selected by the endpointSelector, and which must be denied regardless of any " +
+			"EgressRule that would allow it.",
+		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+			"toCIDR": {
+				Description: "ToCIDR is a list of IP blocks to which the endpoint subject to " +
+					"the rule is not allowed to initiate connections.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &CIDR,
+				},
+			},
+			"toCIDRSet": {
+				Description: "ToCIDRSet is a list of IP blocks to which the endpoint subject " +
+					"to the rule is not allowed to initiate connections, along with a list of " +
+					"subnets contained within their corresponding IP block which are not " +
+					"subject to the deny.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &CIDRRule,
+				},
+			},
+			"toEntities": {
+				Description: "ToEntities is a list of special entities to which the endpoint " +
+					"subject to the rule is not allowed to initiate connections.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1beta1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+			"toPorts": {
+				Description: "ToPorts is a list of destination ports identified by port number " +
+					"and protocol which the endpoint subject to the rule is not allowed to " +
+					"connect to.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &PortDenyRule,
+				},
+			},
+			"toEndpoints": {
+				Description: "ToEndpoints is a list of endpoints identified by an " +
+					"EndpointSelector to which the endpoint subject to the rule is not " +
+					"allowed to communicate.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &EndpointSelector,
+				},
+			},
+			"toRequires": {
+				Description: "ToRequires is a list of additional constraints which must be " +
+					"met in order for the selected endpoints to be denied.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &EndpointSelector,
+				},
+			},
+		},
+	}
+
 	FQDNRule = apiextensionsv1beta1.JSONSchemaProps{
 		Description: `FQDNRule is a rule that specifies an fully qualified domain name to which outside communication is allowed`,
 		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
@@ -695,6 +763,22 @@ var (
 			},
 		},
 	}
+
+	InventoryGroup = apiextensionsv1beta1.JSONSchemaProps{
+		Description: "InventoryGroup selects the IPs of groups listed in the " +
+			"inventory configured in the operator",
+		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+			"groups": {
+				Description: "Groups are the names of the inventory groups whose IPs are selected",
+				Type:        "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1beta1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+		},
+	}
 	EndpointSelector = *LabelSelector.DeepCopy()
 
 	IngressRule = apiextensionsv1beta1.JSONSchemaProps{
@@ -788,6 +872,69 @@ var (
 		},
 	}
 
+	IngressDenyRule = apiextensionsv1beta1.JSONSchemaProps{
+		Description: "IngressDenyRule contains all rule types which can be applied at " +
+			"ingress, i.e. network traffic that originates outside of the endpoint and is " +
+			"entering the endpoint selected by the endpointSelector, and which must be " +
+			"denied regardless of any IngressRule that would allow it.",
+		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+			"fromCIDR": {
+				Description: "FromCIDR is a list of IP blocks from which the endpoint " +
+					"subject to the rule is not allowed to receive connections.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &CIDR,
+				},
+			},
+			"fromCIDRSet": {
+				Description: "FromCIDRSet is a list of IP blocks from which the endpoint " +
+					"subject to the rule is not allowed to receive connections, along with a " +
+					"list of subnets contained within their corresponding IP block which are " +
+					"not subject to the deny.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &CIDRRule,
+				},
+			},
+			"fromEndpoints": {
+				Description: "FromEndpoints is a list of endpoints identified by an " +
+					"EndpointSelector which are not allowed to communicate with the endpoint " +
+					"subject to the rule.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &EndpointSelector,
+				},
+			},
+			"fromEntities": {
+				Description: "FromEntities is a list of special entities from which the " +
+					"endpoint subject to the rule is not allowed to receive connections.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1beta1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+			"fromRequires": {
+				Description: "FromRequires is a list of additional constraints which must be " +
+					"met in order for the selected endpoints to be denied.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &EndpointSelector,
+				},
+			},
+			"toPorts": {
+				Description: "ToPorts is a list of destination ports identified by port number " +
+					"and protocol on which the endpoint subject to the rule is not allowed to " +
+					"receive connections.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &PortDenyRule,
+				},
+			},
+		},
+	}
+
 	K8sServiceNamespace = apiextensionsv1beta1.JSONSchemaProps{
 		Description: "K8sServiceNamespace is an abstraction for the k8s service + namespace " +
 			"types.",
@@ -838,6 +985,13 @@ var (
 					Schema: &PortRuleDNS,
 				},
 			},
+			"grpc": {
+				Description: "gRPC-specific rules.",
+				Type:        "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &PortRuleGRPC,
+				},
+			},
 		},
 	}
 
@@ -995,6 +1149,57 @@ var (
 		},
 	}
 
+	PortDenyRule = apiextensionsv1beta1.JSONSchemaProps{
+		Description: "PortDenyRule is a list of ports/protocol combinations which must be " +
+			"denied.",
+		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+			"ports": {
+				Description: "Ports is a list of L4 port/protocol",
+				Type:        "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &PortProtocol,
+				},
+			},
+		},
+	}
+
+	PortRuleGRPC = apiextensionsv1beta1.JSONSchemaProps{
+		Description: "PortRuleGRPC is a list of gRPC protocol constraints. gRPC requests are " +
+			"HTTP/2 POST requests to the path \"/<service>/<method>\", so these rules are " +
+			"enforced by the HTTP proxy.",
+		Required: []string{
+			"service",
+		},
+		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+			"metadata": {
+				Description: "Metadata is a list of gRPC metadata headers which must be " +
+					"present in the request. Each entry is either a header name, in which " +
+					"case the header must be present, or a \"name: value\" pair, in which " +
+					"case the header must be present with the given value. If omitted or " +
+					"empty, requests are allowed regardless of the metadata present.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1beta1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+			"method": {
+				Description: "Method is the name of a method of the service, e.g. " +
+					"\"SayHello\".\n\nIf omitted or empty, all methods of the service are " +
+					"allowed.",
+				Type:    "string",
+				Pattern: `^[A-Za-z_][A-Za-z0-9_]*$`,
+			},
+			"service": {
+				Description: "Service is the fully qualified name of the gRPC service, " +
+					"including the protobuf package name, e.g. \"helloworld.Greeter\".",
+				Type:    "string",
+				Pattern: `^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`,
+			},
+		},
+	}
+
 	PortRuleHTTP = apiextensionsv1beta1.JSONSchemaProps{
 		Description: "PortRuleHTTP is a list of HTTP protocol constraints. All fields are " +
 			"optional, if all fields are empty or missing, the rule does not have any effect." +
@@ -1102,6 +1307,23 @@ var (
 				Type:      "string",
 				MaxLength: getInt64(255),
 			},
+			"principal": {
+				Description: "Principal is the SASL principal authenticated on the connection, " +
+					"i.e. the authorization identity, or if none was provided the " +
+					"authentication identity, of the PLAIN and SCRAM mechanisms.\n\nThis " +
+					"constraint is ignored for the requests which are part of the " +
+					"authentication exchange. All other requests are rejected until a " +
+					"principal has been authenticated.\n\nIf omitted or empty, all " +
+					"principals are allowed, including unauthenticated connections.",
+				Type: "string",
+			},
+			"consumerGroup": {
+				Description: "ConsumerGroup is the consumer group contained in the message.\n\n" +
+					"This constraint is ignored if the matched request message type doesn't " +
+					"contain a consumer group.\n\nIf omitted or empty, all consumer groups " +
+					"are allowed.",
+				Type: "string",
+			},
 		},
 	}
 
@@ -1152,6 +1374,15 @@ var (
 					Schema: &EgressRule,
 				},
 			},
+			"egressDeny": {
+				Description: "EgressDeny is a list of EgressDenyRule which are enforced at " +
+					"egress. Any traffic matching an EgressDenyRule is dropped, even if it is " +
+					"also allowed by an EgressRule.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &EgressDenyRule,
+				},
+			},
 			"endpointSelector": EndpointSelector,
 			"ingress": {
 				Description: "Ingress is a list of IngressRule which are enforced at ingress. " +
@@ -1161,6 +1392,15 @@ var (
 					Schema: &IngressRule,
 				},
 			},
+			"ingressDeny": {
+				Description: "IngressDeny is a list of IngressDenyRule which are enforced at " +
+					"ingress. Any traffic matching an IngressDenyRule is dropped, even if it " +
+					"is also allowed by an IngressRule.",
+				Type: "array",
+				Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{
+					Schema: &IngressDenyRule,
+				},
+			},
 			"labels": {
 				Description: "Labels is a list of optional strings which can be used to " +
 					"re-identify the rule or to store metadata. It is possible to lookup or " +
@@ -657,11 +657,21 @@ type NodeSpec struct {
 	// +optional
 	Encryption EncryptionSpec `json:"encryption,omitempty"`
 
+	// Wireguard is the WireGuard configuration of the node
+	//
+	// +optional
+	Wireguard WireguardSpec `json:"wireguard,omitempty"`
+
 	// ENI is the AWS ENI specific configuration
 	//
 	// +optional
 	ENI ENISpec `json:"eni,omitempty"`
 
+	// Azure is the Azure network interface specific configuration
+	//
+	// +optional
+	Azure AzureSpec `json:"azure,omitempty"`
+
 	// IPAM is the address management specification. This section can be
 	// populated by a user or it can be automatically populated by an IPAM
 	// operator
@@ -687,12 +697,23 @@ type HealthAddressingSpec struct {
 // EncryptionSpec defines the encryption relevant configuration of a node
 type EncryptionSpec struct {
 	// Key is the index to the key to use for encryption or 0 if encryption
-	// is disabled
+	// is disabled. The key is updated when the node rotates to new keys,
+	// other nodes keep the states of the previous key until all nodes
+	// advertise the new key.
 	//
 	// +optional
 	Key int `json:"key,omitempty"`
 }
 
+// WireguardSpec defines the WireGuard relevant configuration of a node
+type WireguardSpec struct {
+	// PublicKey is the base64 encoded WireGuard public key of the node or
+	// empty if WireGuard is disabled
+	//
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
 // ENISpec is the ENI specification of a node. This specification is considered
 // by the cilium-operator to act as an IPAM operator and makes ENI IPs available
 // via the IPAMSpec section.
@@ -784,6 +805,34 @@ type IPAMSpec struct {
 	//
 	// +optional
 	PodCIDRs []string `json:"podCIDRs,omitempty"`
+
+	// MinAllocate is the minimum number of IPs that must be allocated when
+	// the node is first bootstrapped. It defines the minimum base socket
+	// of addresses that must be available. After reaching this watermark,
+	// the PreAllocate and MaxAboveWatermark logic takes over to continue
+	// allocating IPs.
+	//
+	// This field is used by IPAM backends built on the provider-neutral
+	// node pool manager. The ENI backend uses Spec.ENI.MinAllocate.
+	//
+	// +optional
+	MinAllocate int `json:"min-allocate,omitempty"`
+
+	// PreAllocate defines the number of IP addresses that must be
+	// available for allocation in the IPAMspec. It defines the buffer of
+	// addresses available immediately without requiring cilium-operator to
+	// get involved.
+	//
+	// +optional
+	PreAllocate int `json:"pre-allocate,omitempty"`
+
+	// MaxAboveWatermark is the maximum number of addresses to allocate
+	// beyond the addresses needed to reach the PreAllocate watermark.
+	// Addresses above the watermark are released again if cilium-operator
+	// is configured to release excess IPs.
+	//
+	// +optional
+	MaxAboveWatermark int `json:"max-above-watermark,omitempty"`
 }
 
 // NodeStatus is the status of a node
@@ -793,6 +842,11 @@ type NodeStatus struct {
 	// +optional
 	ENI ENIStatus `json:"eni,omitempty"`
 
+	// Azure is the Azure specific status of the node
+	//
+	// +optional
+	Azure AzureStatus `json:"azure,omitempty"`
+
 	// IPAM is the IPAM status of the node
 	//
 	// +optional
@@ -806,6 +860,27 @@ type IPAMStatus struct {
 	//
 	// +optional
 	Used map[string]AllocationIP `json:"used,omitempty"`
+
+	// OperatorStatus describes the status of the PodCIDR allocation of
+	// cilium-operator in cluster-pool IPAM mode
+	//
+	// +optional
+	OperatorStatus IPAMOperatorStatus `json:"operator-status,omitempty"`
+}
+
+// IPAMOperatorStatus is the status of the PodCIDR allocation of
+// cilium-operator for a node
+type IPAMOperatorStatus struct {
+	// PodCIDRs is the list of PodCIDRs allocated to the node
+	//
+	// +optional
+	PodCIDRs []string `json:"pod-cidrs,omitempty"`
+
+	// Error is the error which occurred during the last allocation
+	// attempt, e.g. the exhaustion of the cluster-pool CIDRs
+	//
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 // AllocationIP is an IP which is available for allocation, or already
@@ -914,6 +989,95 @@ type AwsVPC struct {
 	CIDRs []string `json:"cidrs,omitempty"`
 }
 
+// AzureSpec is the Azure specification of a node. This specification is
+// considered by the cilium-operator to act as an IPAM operator and makes
+// secondary IPs of Azure network interfaces available via the IPAMSpec
+// section.
+type AzureSpec struct {
+	// InstanceID is the Azure resource ID of the virtual machine the node
+	// is running on
+	//
+	// +optional
+	InstanceID string `json:"instance-id,omitempty"`
+
+	// InterfaceName is the name of the network interface to allocate IPs
+	// on. If empty, the first interface of the instance sorted by name is
+	// used.
+	//
+	// +optional
+	InterfaceName string `json:"interface-name,omitempty"`
+}
+
+// AzureStatus is the status of Azure addressing of the node
+type AzureStatus struct {
+	// Interfaces is the list of interfaces on the node
+	//
+	// +optional
+	Interfaces []AzureInterface `json:"interfaces,omitempty"`
+}
+
+// AzureInterface represents an Azure network interface
+//
+// More details:
+// https://docs.microsoft.com/en-us/azure/virtual-network/virtual-network-network-interface
+type AzureInterface struct {
+	// ID is the Azure resource ID of the interface
+	//
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// Name is the name of the interface
+	//
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// MAC is the mac address of the interface
+	//
+	// +optional
+	MAC string `json:"mac,omitempty"`
+
+	// State is the provisioning state of the interface
+	//
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Addresses is the list of all IP configurations of the interface,
+	// including the primary IP configuration
+	//
+	// +optional
+	Addresses []AzureAddress `json:"addresses,omitempty"`
+
+	// SecurityGroup is the network security group associated with the
+	// interface
+	//
+	// +optional
+	SecurityGroup string `json:"security-group,omitempty"`
+}
+
+// AzureAddress is an IP configuration of an Azure network interface
+type AzureAddress struct {
+	// IP is the private IP address of the IP configuration
+	//
+	// +optional
+	IP string `json:"ip,omitempty"`
+
+	// Subnet is the Azure resource ID of the subnet the IP belongs to
+	//
+	// +optional
+	Subnet string `json:"subnet,omitempty"`
+
+	// State is the provisioning state of the IP configuration
+	//
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Primary is true for the primary IP configuration of the interface.
+	// The primary IP is never handed out for allocation.
+	//
+	// +optional
+	Primary bool `json:"primary,omitempty"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 //
 // CiliumNodeList is a list of CiliumNode objects
@@ -78,6 +78,82 @@ func (in *AwsVPC) DeepCopy() *AwsVPC {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureAddress) DeepCopyInto(out *AzureAddress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureAddress.
+func (in *AzureAddress) DeepCopy() *AzureAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureInterface) DeepCopyInto(out *AzureInterface) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]AzureAddress, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureInterface.
+func (in *AzureInterface) DeepCopy() *AzureInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureSpec) DeepCopyInto(out *AzureSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureSpec.
+func (in *AzureSpec) DeepCopy() *AzureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureStatus) DeepCopyInto(out *AzureStatus) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]AzureInterface, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureStatus.
+func (in *AzureStatus) DeepCopy() *AzureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CiliumEndpoint) DeepCopyInto(out *CiliumEndpoint) {
 	*out = *in
@@ -560,6 +636,27 @@ func (in *HealthAddressingSpec) DeepCopy() *HealthAddressingSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAMOperatorStatus) DeepCopyInto(out *IPAMOperatorStatus) {
+	*out = *in
+	if in.PodCIDRs != nil {
+		in, out := &in.PodCIDRs, &out.PodCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAMOperatorStatus.
+func (in *IPAMOperatorStatus) DeepCopy() *IPAMOperatorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAMOperatorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAMSpec) DeepCopyInto(out *IPAMSpec) {
 	*out = *in
@@ -598,6 +695,7 @@ func (in *IPAMStatus) DeepCopyInto(out *IPAMStatus) {
 			(*out)[key] = val
 		}
 	}
+	in.OperatorStatus.DeepCopyInto(&out.OperatorStatus)
 	return
 }
 
@@ -660,7 +758,9 @@ func (in *NodeSpec) DeepCopyInto(out *NodeSpec) {
 	}
 	out.HealthAddressing = in.HealthAddressing
 	out.Encryption = in.Encryption
+	out.Wireguard = in.Wireguard
 	in.ENI.DeepCopyInto(&out.ENI)
+	out.Azure = in.Azure
 	in.IPAM.DeepCopyInto(&out.IPAM)
 	return
 }
@@ -679,6 +779,7 @@ func (in *NodeSpec) DeepCopy() *NodeSpec {
 func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
 	*out = *in
 	in.ENI.DeepCopyInto(&out.ENI)
+	in.Azure.DeepCopyInto(&out.Azure)
 	in.IPAM.DeepCopyInto(&out.IPAM)
 	return
 }
@@ -702,3 +803,19 @@ func (in *Timestamp) DeepCopy() *Timestamp {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardSpec) DeepCopyInto(out *WireguardSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardSpec.
+func (in *WireguardSpec) DeepCopy() *WireguardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
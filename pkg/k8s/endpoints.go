@@ -36,6 +36,10 @@ type Endpoints struct {
 	// the map is the backend IP in string form. The value defines the list
 	// of ports for that backend IP in the form of a PortConfiguration.
 	Backends map[string]service.PortConfiguration
+
+	// Clusters are the names of the clusters of which the backends are
+	// included, sorted by name. It is only set for global services.
+	Clusters []string
 }
 
 // String returns the string representation of an endpoints resource, with
@@ -48,6 +48,59 @@ func getAnnotationShared(svc *types.Service) bool {
 	return getAnnotationIncludeExternal(svc)
 }
 
+// getAnnotationLBAlgorithm returns the load-balancing algorithm selected by
+// the service or an empty algorithm if the service does not select one.
+func getAnnotationLBAlgorithm(svc *types.Service) loadbalancer.LBAlgorithm {
+	value, ok := svc.ObjectMeta.Annotations[annotation.LBAlgorithm]
+	if !ok {
+		return ""
+	}
+
+	algorithm, err := loadbalancer.ParseLBAlgorithm(value)
+	if err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			logfields.K8sSvcName:   svc.ObjectMeta.Name,
+			logfields.K8sNamespace: svc.ObjectMeta.Namespace,
+		}).Warningf("Ignoring annotation %s", annotation.LBAlgorithm)
+		return ""
+	}
+	return algorithm
+}
+
+// getAnnotationClusterAffinity returns the cluster affinity selected by the
+// service or an empty affinity if the service does not select one.
+func getAnnotationClusterAffinity(svc *types.Service) loadbalancer.ClusterAffinity {
+	value, ok := svc.ObjectMeta.Annotations[annotation.GlobalServiceAffinity]
+	if !ok {
+		return ""
+	}
+
+	affinity, err := loadbalancer.ParseClusterAffinity(value)
+	if err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			logfields.K8sSvcName:   svc.ObjectMeta.Name,
+			logfields.K8sNamespace: svc.ObjectMeta.Namespace,
+		}).Warningf("Ignoring annotation %s", annotation.GlobalServiceAffinity)
+		return ""
+	}
+	return affinity
+}
+
+// getSessionAffinityTimeoutSec returns the ClientIP session affinity timeout
+// of the service in seconds, or 0 if session affinity is disabled.
+func getSessionAffinityTimeoutSec(svc *types.Service) uint32 {
+	if svc.Spec.SessionAffinity != v1.ServiceAffinityClientIP {
+		return 0
+	}
+
+	timeout := v1.DefaultClientIPServiceAffinitySeconds
+	if cfg := svc.Spec.SessionAffinityConfig; cfg != nil && cfg.ClientIP != nil &&
+		cfg.ClientIP.TimeoutSeconds != nil && *cfg.ClientIP.TimeoutSeconds > 0 {
+		timeout = *cfg.ClientIP.TimeoutSeconds
+	}
+	return uint32(timeout)
+}
+
 // ParseServiceID parses a Kubernetes service and returns the ServiceID
 func ParseServiceID(svc *types.Service) ServiceID {
 	return ServiceID{
@@ -93,6 +146,9 @@ func ParseService(svc *types.Service) (ServiceID, *Service) {
 	svcInfo := NewService(clusterIP, headless, svc.Labels, svc.Spec.Selector)
 	svcInfo.IncludeExternal = getAnnotationIncludeExternal(svc)
 	svcInfo.Shared = getAnnotationShared(svc)
+	svcInfo.ClusterAffinity = getAnnotationClusterAffinity(svc)
+	svcInfo.LBAlgorithm = getAnnotationLBAlgorithm(svc)
+	svcInfo.SessionAffinityTimeoutSec = getSessionAffinityTimeoutSec(svc)
 
 	if len(svc.Spec.ExternalIPs) != 0 {
 		// Accordingly with k8s docs: Traffic that ingresses into the cluster
@@ -217,6 +273,19 @@ type Service struct {
 	// Shared is true when the service should be exposed/shared to other clusters
 	Shared bool
 
+	// ClusterAffinity selects the clusters of which the backends are used
+	// if IncludeExternal is true. If empty, the backends of all clusters
+	// are used.
+	ClusterAffinity loadbalancer.ClusterAffinity
+
+	// LBAlgorithm is the load-balancing algorithm selected by the service.
+	// If empty, the default algorithm of the agent is used.
+	LBAlgorithm loadbalancer.LBAlgorithm
+
+	// SessionAffinityTimeoutSec is the ClientIP session affinity timeout
+	// of the service in seconds. If 0, session affinity is disabled.
+	SessionAffinityTimeoutSec uint32
+
 	Ports map[loadbalancer.FEPortName]*loadbalancer.FEPort
 	// NodePorts stores mapping for port name => NodePort frontend addr string =>
 	// NodePort fronted addr. The string addr => addr indirection is to avoid
@@ -266,6 +335,9 @@ func (s *Service) DeepEquals(o *Service) bool {
 	}
 
 	if s.IsHeadless == o.IsHeadless &&
+		s.LBAlgorithm == o.LBAlgorithm &&
+		s.ClusterAffinity == o.ClusterAffinity &&
+		s.SessionAffinityTimeoutSec == o.SessionAffinityTimeoutSec &&
 		s.FrontendIP.Equal(o.FrontendIP) &&
 		comparator.MapStringEquals(s.Labels, o.Labels) &&
 		comparator.MapStringEquals(s.Selector, o.Selector) {
@@ -447,9 +447,10 @@ func (s *ServiceCache) UniqueServiceFrontends() FrontendList {
 }
 
 // correlateEndpoints builds a combined Endpoints of the local endpoints and
-// all external endpoints if the service is marked as a global service. Also
-// returns a boolean that indicates whether the service is ready to be plumbed,
-// this is true if:
+// all external endpoints if the service is marked as a global service. The
+// clusters of which the backends are included are selected by the cluster
+// affinity of the service. Also returns a boolean that indicates whether the
+// service is ready to be plumbed, this is true if:
 // IF If ta local endpoints resource is present. Regardless whether the
 //    endpoints resource contains actual backends or not.
 // OR Remote endpoints exist which correlate to the service.
@@ -457,33 +458,55 @@ func (s *ServiceCache) correlateEndpoints(id ServiceID) (*Endpoints, bool) {
 	endpoints := newEndpoints()
 
 	localEndpoints, hasLocalEndpoints := s.endpoints[id]
+
+	svc, hasExternalService := s.services[id]
+	if !hasExternalService || !svc.IncludeExternal {
+		if hasLocalEndpoints {
+			for ip, e := range localEndpoints.Backends {
+				endpoints.Backends[ip] = e
+			}
+		}
+		return endpoints, hasLocalEndpoints
+	}
+
+	externalEndpoints := s.externalEndpoints[id]
+
+	backends := map[string]int{}
 	if hasLocalEndpoints {
-		for ip, e := range localEndpoints.Backends {
-			endpoints.Backends[ip] = e
+		backends[option.Config.ClusterName] = len(localEndpoints.Backends)
+	}
+	for clusterName, remoteClusterEndpoints := range externalEndpoints.endpoints {
+		if clusterName != option.Config.ClusterName {
+			backends[clusterName] = len(remoteClusterEndpoints.Backends)
 		}
 	}
+	endpoints.Clusters = svc.ClusterAffinity.SelectClusters(option.Config.ClusterName, backends)
 
-	svc, hasExternalService := s.services[id]
-	if hasExternalService && svc.IncludeExternal {
-		externalEndpoints, hasExternalEndpoints := s.externalEndpoints[id]
-		if hasExternalEndpoints {
-			for clusterName, remoteClusterEndpoints := range externalEndpoints.endpoints {
-				if clusterName == option.Config.ClusterName {
-					continue
-				}
-
-				for ip, e := range remoteClusterEndpoints.Backends {
-					if _, ok := endpoints.Backends[ip]; ok {
-						log.WithFields(logrus.Fields{
-							logfields.K8sSvcName:   id.Name,
-							logfields.K8sNamespace: id.Namespace,
-							logfields.IPAddr:       ip,
-							"cluster":              clusterName,
-						}).Warning("Conflicting service backend IP")
-					} else {
-						endpoints.Backends[ip] = e
-					}
-				}
+	// Local backends are added first so that they take precedence over
+	// conflicting remote backends
+	for _, clusterName := range endpoints.Clusters {
+		if clusterName == option.Config.ClusterName {
+			for ip, e := range localEndpoints.Backends {
+				endpoints.Backends[ip] = e
+			}
+		}
+	}
+
+	for _, clusterName := range endpoints.Clusters {
+		if clusterName == option.Config.ClusterName {
+			continue
+		}
+
+		for ip, e := range externalEndpoints.endpoints[clusterName].Backends {
+			if _, ok := endpoints.Backends[ip]; ok {
+				log.WithFields(logrus.Fields{
+					logfields.K8sSvcName:   id.Name,
+					logfields.K8sNamespace: id.Namespace,
+					logfields.IPAddr:       ip,
+					"cluster":              clusterName,
+				}).Warning("Conflicting service backend IP")
+			} else {
+				endpoints.Backends[ip] = e
 			}
 		}
 	}
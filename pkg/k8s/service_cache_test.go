@@ -937,6 +937,105 @@ func (s *K8sSuite) TestServiceMerging(c *check.C) {
 	c.Assert(addresses, checker.DeepEquals, loadbalancer.NewL3n4Addr(loadbalancer.TCP, net.ParseIP("127.0.0.1"), 80))
 }
 
+func (s *K8sSuite) TestServiceMergingClusterAffinity(c *check.C) {
+	svcCache := NewServiceCache()
+
+	k8sSvc := &types.Service{
+		Service: &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "bar",
+				Annotations: map[string]string{
+					"io.cilium/global-service":          "true",
+					"io.cilium/global-service-affinity": "failover",
+				},
+			},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "127.0.0.1",
+				Type:      v1.ServiceTypeClusterIP,
+			},
+		},
+	}
+
+	k8sEndpoints := &types.Endpoints{
+		Endpoints: &v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "bar",
+			},
+			Subsets: []v1.EndpointSubset{
+				{
+					Addresses: []v1.EndpointAddress{{IP: "2.2.2.2"}},
+					Ports: []v1.EndpointPort{
+						{
+							Name:     "port",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	svcID := svcCache.UpdateService(k8sSvc)
+	svcCache.UpdateEndpoints(k8sEndpoints)
+
+	c.Assert(testutils.WaitUntil(func() bool {
+		event := <-svcCache.Events
+		c.Assert(event.Action, check.Equals, UpdateService)
+		c.Assert(event.ID, check.Equals, svcID)
+		c.Assert(event.Service.ClusterAffinity, check.Equals, loadbalancer.ClusterAffinityFailover)
+		return true
+	}, 2*time.Second), check.IsNil)
+
+	cluster1svc := &service.ClusterService{
+		Cluster:   "cluster1",
+		Namespace: "bar",
+		Name:      "foo",
+		Backends: map[string]service.PortConfiguration{
+			"3.3.3.3": map[string]*loadbalancer.L4Addr{
+				"port": {Protocol: loadbalancer.TCP, Port: 80},
+			},
+		},
+	}
+
+	// The local cluster has backends, the remote backends are not used
+	svcCache.MergeExternalServiceUpdate(cluster1svc)
+	c.Assert(testutils.WaitUntil(func() bool {
+		event := <-svcCache.Events
+		c.Assert(event.Action, check.Equals, UpdateService)
+		c.Assert(event.Endpoints.Clusters, checker.DeepEquals, []string{option.Config.ClusterName})
+		c.Assert(event.Endpoints.Backends["2.2.2.2"], check.Not(check.IsNil))
+		c.Assert(event.Endpoints.Backends["3.3.3.3"], check.IsNil)
+		return true
+	}, 2*time.Second), check.IsNil)
+
+	// Once the last local backend is gone, the service fails over to
+	// the remote cluster
+	k8sEndpoints.Subsets = nil
+	svcCache.UpdateEndpoints(k8sEndpoints)
+	c.Assert(testutils.WaitUntil(func() bool {
+		event := <-svcCache.Events
+		c.Assert(event.Action, check.Equals, UpdateService)
+		c.Assert(event.Endpoints.Clusters, checker.DeepEquals, []string{"cluster1"})
+		c.Assert(event.Endpoints.Backends["3.3.3.3"], checker.DeepEquals, service.PortConfiguration{
+			"port": {Protocol: loadbalancer.TCP, Port: 80},
+		})
+		return true
+	}, 2*time.Second), check.IsNil)
+
+	// Removing the remote cluster leaves the service without backends
+	svcCache.MergeExternalServiceDelete(cluster1svc)
+	c.Assert(testutils.WaitUntil(func() bool {
+		event := <-svcCache.Events
+		c.Assert(event.Action, check.Equals, UpdateService)
+		c.Assert(event.Endpoints.Clusters, check.IsNil)
+		c.Assert(len(event.Endpoints.Backends), check.Equals, 0)
+		return true
+	}, 2*time.Second), check.IsNil)
+}
+
 func (s *K8sSuite) TestNonSharedServie(c *check.C) {
 	svcCache := NewServiceCache()
 
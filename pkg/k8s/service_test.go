@@ -53,6 +53,45 @@ func (s *K8sSuite) TestGetAnnotationIncludeExternal(c *check.C) {
 	c.Assert(getAnnotationIncludeExternal(svc), check.Equals, false)
 }
 
+func (s *K8sSuite) TestGetAnnotationLBAlgorithm(c *check.C) {
+	svc := &types.Service{Service: &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name: "foo",
+	}}}
+	c.Assert(getAnnotationLBAlgorithm(svc), check.Equals, loadbalancer.LBAlgorithm(""))
+
+	svc = &types.Service{Service: &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"io.cilium/lb-algorithm": "maglev"},
+	}}}
+	c.Assert(getAnnotationLBAlgorithm(svc), check.Equals, loadbalancer.LBAlgorithmMaglev)
+
+	svc = &types.Service{Service: &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"io.cilium/lb-algorithm": "Random"},
+	}}}
+	c.Assert(getAnnotationLBAlgorithm(svc), check.Equals, loadbalancer.LBAlgorithmRandom)
+
+	svc = &types.Service{Service: &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"io.cilium/lb-algorithm": "unknown"},
+	}}}
+	c.Assert(getAnnotationLBAlgorithm(svc), check.Equals, loadbalancer.LBAlgorithm(""))
+}
+
+func (s *K8sSuite) TestGetSessionAffinityTimeoutSec(c *check.C) {
+	svc := &types.Service{Service: &v1.Service{}}
+	c.Assert(getSessionAffinityTimeoutSec(svc), check.Equals, uint32(0))
+
+	svc.Spec.SessionAffinity = v1.ServiceAffinityNone
+	c.Assert(getSessionAffinityTimeoutSec(svc), check.Equals, uint32(0))
+
+	svc.Spec.SessionAffinity = v1.ServiceAffinityClientIP
+	c.Assert(getSessionAffinityTimeoutSec(svc), check.Equals, uint32(v1.DefaultClientIPServiceAffinitySeconds))
+
+	timeout := int32(60)
+	svc.Spec.SessionAffinityConfig = &v1.SessionAffinityConfig{
+		ClientIP: &v1.ClientIPConfig{TimeoutSeconds: &timeout},
+	}
+	c.Assert(getSessionAffinityTimeoutSec(svc), check.Equals, uint32(60))
+}
+
 func (s *K8sSuite) TestParseServiceID(c *check.C) {
 	svc := &types.Service{
 		Service: &v1.Service{
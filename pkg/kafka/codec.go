@@ -0,0 +1,237 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	errTruncated     = errors.New("unexpected end of message")
+	errInvalidLength = errors.New("invalid length")
+	errInvalidVarint = errors.New("invalid varint")
+)
+
+// decoder decodes the primitive types of the Kafka protocol. Strings, byte
+// arrays and arrays use the compact encoding of flexible versions if
+// flexible is set. After the first error, all subsequent reads return zero
+// values and the error is returned by Err().
+type decoder struct {
+	b        []byte
+	flexible bool
+	err      error
+}
+
+// Err returns the first error encountered while decoding
+func (d *decoder) Err() error {
+	return d.err
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errTruncated
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) skip(n int) {
+	d.next(n)
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = errInvalidVarint
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// length decodes the length of a string (int16), byte array (int32) or
+// array (int32), or the compact length of flexible versions. -1 is
+// returned for null values.
+func (d *decoder) length(classic func() int) int {
+	if !d.flexible {
+		return classic()
+	}
+	n := d.uvarint()
+	if n > uint64(len(d.b))+1 {
+		if d.err == nil {
+			d.err = errInvalidLength
+		}
+		return -1
+	}
+	return int(n) - 1
+}
+
+// nullableString returns the string and false if the string is null
+func (d *decoder) nullableString() (string, bool) {
+	n := d.length(func() int { return int(d.int16()) })
+	if n < 0 {
+		return "", false
+	}
+	return string(d.next(n)), d.err == nil
+}
+
+func (d *decoder) string() string {
+	s, _ := d.nullableString()
+	return s
+}
+
+// nullableBytes returns the byte array, nil if null
+func (d *decoder) nullableBytes() []byte {
+	n := d.length(func() int { return int(d.int32()) })
+	if n < 0 {
+		return nil
+	}
+	return d.next(n)
+}
+
+// arrayLen returns the number of elements of an array, -1 if null
+func (d *decoder) arrayLen() int {
+	n := d.length(func() int { return int(d.int32()) })
+	if d.err == nil && n > len(d.b) {
+		// Each element is at least one byte long
+		d.err = errInvalidLength
+		return -1
+	}
+	return n
+}
+
+// taggedFields skips the tagged fields of flexible versions
+func (d *decoder) taggedFields() {
+	if !d.flexible {
+		return
+	}
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		d.uvarint()
+		size := d.uvarint()
+		if size > uint64(len(d.b)) {
+			d.err = errTruncated
+			return
+		}
+		d.skip(int(size))
+	}
+}
+
+// encoder encodes the primitive types of the Kafka protocol. Strings, byte
+// arrays and arrays use the compact encoding of flexible versions if
+// flexible is set.
+type encoder struct {
+	b        []byte
+	flexible bool
+}
+
+func (e *encoder) int8(v int8) {
+	e.b = append(e.b, byte(v))
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.int8(1)
+	} else {
+		e.int8(0)
+	}
+}
+
+func (e *encoder) int16(v int16) {
+	e.b = append(e.b, byte(v>>8), byte(v))
+}
+
+func (e *encoder) int32(v int32) {
+	e.b = append(e.b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (e *encoder) int64(v int64) {
+	e.int32(int32(v >> 32))
+	e.int32(int32(v))
+}
+
+func (e *encoder) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	e.b = append(e.b, buf[:n]...)
+}
+
+// length encodes the length of a string, byte array or array, -1 for null
+func (e *encoder) length(n int, classic func(int)) {
+	if e.flexible {
+		e.uvarint(uint64(n + 1))
+	} else {
+		classic(n)
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.length(len(s), func(n int) { e.int16(int16(n)) })
+	e.b = append(e.b, s...)
+}
+
+func (e *encoder) nullString() {
+	e.length(-1, func(n int) { e.int16(int16(n)) })
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.length(len(b), func(n int) { e.int32(int32(n)) })
+	e.b = append(e.b, b...)
+}
+
+func (e *encoder) nullBytes() {
+	e.length(-1, func(n int) { e.int32(int32(n)) })
+}
+
+// arrayLen encodes the number of elements of an array, -1 for null
+func (e *encoder) arrayLen(n int) {
+	e.length(n, func(n int) { e.int32(int32(n)) })
+}
+
+// taggedFields encodes empty tagged fields in flexible versions
+func (e *encoder) taggedFields() {
+	if e.flexible {
+		e.uvarint(0)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/policy/api"
 )
 
 var (
@@ -49,6 +50,11 @@ type correlationEntry struct {
 	// request. It will be used to restore the correlation ID in the
 	// response heading back to the client.
 	origCorrelationID CorrelationID
+
+	// principal is the SASL principal requested by a SaslAuthenticate
+	// request. It becomes the principal of the connection once the
+	// broker has accepted the authentication.
+	principal string
 }
 
 // CorrelationCache is a cache used to correlate requests with responses
@@ -91,6 +97,13 @@ type CorrelationCache struct {
 
 	// stopGc is closed when the garbage collector must exit
 	stopGc chan struct{}
+
+	// mechanism is the SASL mechanism selected by the last SaslHandshake
+	// request
+	mechanism string
+
+	// principal is the SASL principal authenticated on the connection
+	principal string
 }
 
 // NewCorrelationCache returns a new correlation cache
@@ -138,12 +151,30 @@ func (cc *CorrelationCache) HandleRequest(req *RequestMessage, finishFunc Finish
 		log.Warning("BUG: Overwriting Kafka request message in correlation cache")
 	}
 
-	cc.cache[newCorrelationID] = &correlationEntry{
+	entry := &correlationEntry{
 		request:           req,
 		created:           time.Now(),
 		origCorrelationID: origCorrelationID,
 		finishFunc:        finishFunc,
 	}
+
+	switch req.kind {
+	case api.SaslHandshakeKey:
+		cc.mechanism = req.saslMechanism
+	case api.SaslAuthenticateKey:
+		entry.principal = saslPrincipal(cc.mechanism, req.saslAuthBytes)
+	}
+
+	cc.cache[newCorrelationID] = entry
+}
+
+// Principal returns the SASL principal authenticated on the connection, or
+// an empty string if no principal has been authenticated
+func (cc *CorrelationCache) Principal() string {
+	cc.mutex.RLock()
+	defer cc.mutex.RUnlock()
+
+	return cc.principal
 }
 
 // correlate returns the request message with the matching correlation ID
@@ -166,6 +197,11 @@ func (cc *CorrelationCache) CorrelateResponse(res *ResponseMessage) *RequestMess
 	if entry := cc.cache[correlationID]; entry != nil {
 		res.SetCorrelationID(entry.origCorrelationID)
 
+		if entry.request.kind == api.SaslAuthenticateKey && entry.principal != "" &&
+			saslAuthenticateSucceeded(entry.request, res) {
+			cc.principal = entry.principal
+		}
+
 		if entry.finishFunc != nil {
 			entry.finishFunc(entry.request)
 		}
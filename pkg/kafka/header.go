@@ -0,0 +1,118 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"github.com/cilium/cilium/pkg/policy/api"
+)
+
+// Reference: https://kafka.apache.org/protocol#protocol_messages
+
+const (
+	// requestHeaderLen is the length of the request size, API key, API
+	// version and correlation ID which precede the client ID in all
+	// request headers
+	requestHeaderLen = 12
+
+	// responseHeaderLen is the length of the response size and correlation
+	// ID which precede the tagged fields of flexible response headers
+	responseHeaderLen = 8
+)
+
+// flexibleVersions maps API keys to the first version using the flexible
+// encoding of KIP-482, i.e. compact strings and arrays, tagged fields and
+// version 2 request and version 1 response headers. API keys not listed
+// here have no flexible versions.
+var flexibleVersions = map[int16]int16{
+	0:  9,  /* Produce */
+	1:  12, /* Fetch */
+	2:  6,  /* Offsets */
+	3:  9,  /* Metadata */
+	4:  4,  /* LeaderAndIsr */
+	5:  2,  /* StopReplica */
+	6:  6,  /* UpdateMetadata */
+	7:  3,  /* ControlledShutdown */
+	8:  8,  /* OffsetCommit */
+	9:  6,  /* OffsetFetch */
+	10: 3,  /* FindCoordinator */
+	11: 6,  /* JoinGroup */
+	12: 4,  /* Heartbeat */
+	13: 4,  /* LeaveGroup */
+	14: 4,  /* SyncGroup */
+	15: 5,  /* DescribeGroups */
+	16: 3,  /* ListGroups */
+	18: 3,  /* ApiVersions */
+	19: 5,  /* CreateTopics */
+	20: 4,  /* DeleteTopics */
+	21: 2,  /* DeleteRecords */
+	22: 2,  /* InitProducerId */
+	23: 4,  /* OffsetForLeaderEpoch */
+	24: 3,  /* AddPartitionsToTxn */
+	25: 3,  /* AddOffsetsToTxn */
+	26: 3,  /* EndTxn */
+	27: 1,  /* WriteTxnMarkers */
+	28: 3,  /* TxnOffsetCommit */
+	29: 2,  /* DescribeAcls */
+	30: 2,  /* CreateAcls */
+	31: 2,  /* DeleteAcls */
+	32: 4,  /* DescribeConfigs */
+	33: 2,  /* AlterConfigs */
+	34: 2,  /* AlterReplicaLogDirs */
+	35: 2,  /* DescribeLogDirs */
+	36: 2,  /* SaslAuthenticate */
+	37: 2,  /* CreatePartitions */
+	38: 2,  /* CreateDelegationToken */
+	39: 2,  /* RenewDelegationToken */
+	40: 2,  /* ExpireDelegationToken */
+	41: 2,  /* DescribeDelegationToken */
+	42: 2,  /* DeleteGroups */
+	43: 2,  /* ElectLeaders */
+	44: 1,  /* IncrementalAlterConfigs */
+	45: 0,  /* AlterPartitionReassignments */
+	46: 0,  /* ListPartitionReassignments */
+	48: 1,  /* DescribeClientQuotas */
+	49: 1,  /* AlterClientQuotas */
+	50: 0,  /* DescribeUserScramCredentials */
+	51: 0,  /* AlterUserScramCredentials */
+}
+
+// isFlexibleVersion returns true if version of the API key kind uses the
+// flexible encoding
+func isFlexibleVersion(kind, version int16) bool {
+	first, ok := flexibleVersions[kind]
+	return ok && version >= first
+}
+
+// hasFlexibleResponseHeader returns true if the response to version of the
+// API key kind has a version 1 response header. ApiVersions responses
+// always use the version 0 header so that clients can parse them before
+// the supported versions are known.
+func hasFlexibleResponseHeader(kind, version int16) bool {
+	return kind != api.APIVersionsKey && isFlexibleVersion(kind, version)
+}
+
+// parseHeader parses the client ID of the request header and returns a
+// decoder positioned at the start of the request body
+func (req *RequestMessage) parseHeader() (*decoder, error) {
+	d := &decoder{b: req.rawMsg[requestHeaderLen:]}
+
+	// The client ID is a nullable string in all header versions
+	req.clientID, _ = d.nullableString()
+
+	d.flexible = isFlexibleVersion(req.kind, req.version)
+	d.taggedFields()
+
+	return d, d.Err()
+}
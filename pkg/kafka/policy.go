@@ -51,6 +51,56 @@ func isTopicAPIKey(kind int16) bool {
 	return false
 }
 
+// isGroupAPIKey returns true if kind is apiKey message type which contains a
+// consumer group in its request.
+func isGroupAPIKey(kind int16) bool {
+	switch kind {
+	case api.OffsetCommitKey,
+		api.OffsetFetchKey,
+		api.JoinGroupKey,
+		api.HeartbeatKey,
+		api.LeaveGroupKey,
+		api.SyncgroupKey,
+		api.TxnOffsetCommitKey:
+
+		return true
+	}
+	return false
+}
+
+// isSaslAPIKey returns true if kind is apiKey message type which is
+// exchanged before the principal of the connection is authenticated.
+func isSaslAPIKey(kind int16) bool {
+	switch kind {
+	case api.APIVersionsKey,
+		api.SaslHandshakeKey,
+		api.SaslAuthenticateKey:
+
+		return true
+	}
+	return false
+}
+
+func matchPrincipal(req *RequestMessage, rule api.PortRuleKafka) bool {
+	if rule.Principal == "" || isSaslAPIKey(req.kind) {
+		return true
+	}
+	return rule.Principal == req.principal
+}
+
+func matchConsumerGroup(req *RequestMessage, rule api.PortRuleKafka) bool {
+	if rule.ConsumerGroup == "" || !isGroupAPIKey(req.kind) {
+		return true
+	}
+	return req.hasGroup && rule.ConsumerGroup == req.groupID
+}
+
+// isAllTopicsAPIKey returns true if a request of kind without topics refers
+// to all topics
+func isAllTopicsAPIKey(kind int16) bool {
+	return kind == api.MetadataKey || kind == api.OffsetFetchKey
+}
+
 func matchNonTopicRequests(req *RequestMessage, rule api.PortRuleKafka) bool {
 	// matchNonTopicRequests() is called when
 	// the kafka parser was not able to parse beyond the generic header.
@@ -59,13 +109,17 @@ func matchNonTopicRequests(req *RequestMessage, rule api.PortRuleKafka) bool {
 	// 2. The parser could not parse further even if there was a topic present.
 	// For scenario 2, if topic is present, we need to return
 	// false since topic can never be associated with this request kind.
+	// Requests decoded by decodeVersioned() have been matched against the
+	// topic already in MatchesRule(), unless they have no topics. Metadata
+	// and OffsetFetch requests without topics refer to all topics.
 	if rule.Topic != "" && isTopicAPIKey(req.kind) {
+		if !req.versioned || (len(req.topics) == 0 && isAllTopicsAPIKey(req.kind)) {
+			return false
+		}
+	}
+	if rule.ClientID != "" && rule.ClientID != req.GetClientID() {
 		return false
 	}
-	// TODO add functionality for parsing clientID GH-3097
-	//if rule.ClientID != "" && rule.ClientID != req.GetClientID() {
-	//	return false
-	//}
 	return true
 }
 
@@ -160,6 +214,10 @@ func (req *RequestMessage) ruleMatches(rule api.PortRuleKafka) bool {
 		return false
 	}
 
+	if !matchPrincipal(req, rule) || !matchConsumerGroup(req, rule) {
+		return false
+	}
+
 	// If the rule contains no additional conditionals, it is not required
 	// to match into the request specific fields.
 	if rule.Topic == "" && rule.ClientID == "" {
@@ -184,8 +242,8 @@ func (req *RequestMessage) ruleMatches(rule api.PortRuleKafka) bool {
 	case nil:
 		// This is the case when requests like
 		// heartbeat,findcordinator, et al
-		// are specified. They are not
-		// associated with a topic, but we should
+		// are specified, or requests decoded by decodeVersioned().
+		// They are not associated with a topic, but we should
 		// still check for ClientID present in request header.
 		return matchNonTopicRequests(req, rule)
 	default:
@@ -127,3 +127,41 @@ func (k *kafkaTestSuite) TestUnknownRequest(c *C) {
 	reqMsg = RequestMessage{kind: 19}
 	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{rule1, rule2}), Equals, false)
 }
+
+func (k *kafkaTestSuite) TestPrincipalAndConsumerGroup(c *C) {
+	reqMsg := RequestMessage{
+		kind:      api.JoinGroupKey,
+		versioned: true,
+		groupID:   "group1",
+		hasGroup:  true,
+		clientID:  "client1",
+	}
+
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{ConsumerGroup: "group1"}}), Equals, true)
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{ConsumerGroup: "group2"}}), Equals, false)
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{ClientID: "client2"}}), Equals, false)
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{ClientID: "client1"}}), Equals, true)
+
+	// No principal has been authenticated
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Principal: "alice"}}), Equals, false)
+	reqMsg.SetPrincipal("alice")
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Principal: "alice", ConsumerGroup: "group1"}}), Equals, true)
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Principal: "bob"}}), Equals, false)
+
+	// The consumer group does not restrict requests without a group
+	reqMsg = RequestMessage{kind: api.MetadataKey, versioned: true, principal: "alice"}
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Principal: "alice", ConsumerGroup: "group1"}}), Equals, true)
+
+	// The SASL exchange is allowed before the principal is known
+	reqMsg = RequestMessage{kind: api.SaslAuthenticateKey, versioned: true}
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Principal: "alice"}}), Equals, true)
+
+	// Topics of versioned requests are matched
+	reqMsg = RequestMessage{
+		kind:      api.ProduceKey,
+		versioned: true,
+		topics:    []topicPartitions{{name: "foo"}, {name: "bar"}},
+	}
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Topic: "foo"}}), Equals, false)
+	c.Assert(reqMsg.MatchesRule([]api.PortRuleKafka{{Topic: "foo"}, {Topic: "bar"}}), Equals, true)
+}
@@ -32,6 +32,27 @@ type RequestMessage struct {
 	version int16
 	rawMsg  []byte
 	request interface{}
+
+	// clientID is the client ID of the request header
+	clientID string
+
+	// versioned is true if the request body has been decoded by
+	// decodeVersioned() instead of the vendored decoder. topics then
+	// contains the topics of the request.
+	versioned bool
+	topics    []topicPartitions
+
+	// groupID is the consumer group of the request if hasGroup is true
+	groupID  string
+	hasGroup bool
+
+	// saslMechanism and saslAuthBytes are the payload of SaslHandshake
+	// and SaslAuthenticate requests
+	saslMechanism string
+	saslAuthBytes []byte
+
+	// principal is the SASL principal authenticated on the connection
+	principal string
 }
 
 // CorrelationID represents the correlation id as defined in the Kafka protocol
@@ -69,6 +90,23 @@ func (req *RequestMessage) SetCorrelationID(id CorrelationID) {
 	}
 }
 
+// GetClientID returns the client ID of the Kafka request header
+func (req *RequestMessage) GetClientID() string {
+	return req.clientID
+}
+
+// GetConsumerGroup returns the consumer group of the Kafka request and
+// whether the request contains a consumer group
+func (req *RequestMessage) GetConsumerGroup() (string, bool) {
+	return req.groupID, req.hasGroup
+}
+
+// SetPrincipal sets the SASL principal authenticated on the connection the
+// Kafka request was received on
+func (req *RequestMessage) SetPrincipal(principal string) {
+	req.principal = principal
+}
+
 func (req *RequestMessage) extractVersion() int16 {
 	return int16(binary.BigEndian.Uint16(req.rawMsg[6:8]))
 }
@@ -87,7 +125,14 @@ func (req *RequestMessage) String() string {
 // GetTopics returns the Kafka request list of topics
 func (req *RequestMessage) GetTopics() []string {
 	if req.request == nil {
-		return nil
+		if len(req.topics) == 0 {
+			return nil
+		}
+		topics := make([]string, len(req.topics))
+		for k, topic := range req.topics {
+			topics[k] = topic.name
+		}
+		return topics
 	}
 
 	switch val := req.request.(type) {
@@ -172,6 +217,9 @@ func (req *RequestMessage) CreateResponse(err error) (*ResponseMessage, error) {
 	case *proto.OffsetFetchReq:
 		return createOffsetFetchResponse(val, err)
 	case nil:
+		if req.versioned {
+			return req.createVersionedResponse(err)
+		}
 		return nil, fmt.Errorf("unsupported request API key %d", req.kind)
 	default:
 		// The switch cases above must correspond exactly to the switch cases
@@ -198,6 +246,31 @@ func ReadRequest(reader io.Reader) (*RequestMessage, error) {
 	}
 	req.version = req.extractVersion()
 
+	d, err := req.parseHeader()
+	if err != nil {
+		if isVendoredVersion(req.kind, req.version) || isVersionedVersion(req.kind, req.version) {
+			flowdebug.Log(log.WithField(fieldRequest, req.String()).WithError(err),
+				"Ignoring Kafka message due to header parse error")
+			return nil, err
+		}
+		// The body of the request is not inspected
+		err = nil
+	}
+
+	if !isVendoredVersion(req.kind, req.version) {
+		if isVersionedVersion(req.kind, req.version) {
+			err = req.decodeVersioned(d)
+		} else {
+			log.WithField(fieldRequest, req.String()).Debugf("Unknown Kafka request API key: %d", req.kind)
+		}
+		if err != nil {
+			flowdebug.Log(log.WithField(fieldRequest, req.String()).WithError(err),
+				"Ignoring Kafka message due to parse error")
+			return nil, err
+		}
+		return req, nil
+	}
+
 	var nilSlice []byte
 	buf := bytes.NewBuffer(append(nilSlice, req.rawMsg...))
 
@@ -213,11 +286,17 @@ func ReadRequest(reader io.Reader) (*RequestMessage, error) {
 	case proto.ConsumerMetadataReqKind:
 		req.request, err = proto.ReadConsumerMetadataReq(buf)
 	case proto.OffsetCommitReqKind:
-		req.request, err = proto.ReadOffsetCommitReq(buf)
+		var commit *proto.OffsetCommitReq
+		if commit, err = proto.ReadOffsetCommitReq(buf); err == nil {
+			req.request = commit
+			req.groupID, req.hasGroup = commit.ConsumerGroup, true
+		}
 	case proto.OffsetFetchReqKind:
-		req.request, err = proto.ReadOffsetFetchReq(buf)
-	default:
-		log.WithField(fieldRequest, req.String()).Debugf("Unknown Kafka request API key: %d", req.kind)
+		var fetch *proto.OffsetFetchReq
+		if fetch, err = proto.ReadOffsetFetchReq(buf); err == nil {
+			req.request = fetch
+			req.groupID, req.hasGroup = fetch.ConsumerGroup, true
+		}
 	}
 
 	if err != nil {
@@ -0,0 +1,71 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"bytes"
+	"strings"
+)
+
+// SASL mechanisms from which the principal can be extracted
+const (
+	saslPlain       = "PLAIN"
+	saslScramPrefix = "SCRAM-SHA-"
+)
+
+// saslPrincipal returns the principal requested by the client in the first
+// SASL authentication message of mechanism, or an empty string if the
+// mechanism does not carry the principal in clear text.
+func saslPrincipal(mechanism string, authBytes []byte) string {
+	switch {
+	case mechanism == saslPlain:
+		// RFC 4616: [authzid] NUL authcid NUL passwd
+		parts := bytes.SplitN(authBytes, []byte{0}, 3)
+		if len(parts) != 3 {
+			return ""
+		}
+		if len(parts[0]) > 0 {
+			return string(parts[0])
+		}
+		return string(parts[1])
+
+	case strings.HasPrefix(mechanism, saslScramPrefix):
+		// RFC 5802: gs2-header client-first-message-bare, where the
+		// bare message starts with n=<saslname>
+		msg := string(authBytes)
+		for _, attr := range strings.Split(msg, ",") {
+			if strings.HasPrefix(attr, "n=") {
+				name := strings.Replace(attr[2:], "=2C", ",", -1)
+				return strings.Replace(name, "=3D", "=", -1)
+			}
+		}
+	}
+	return ""
+}
+
+// saslAuthenticateSucceeded returns true if res is a successful response to
+// the SaslAuthenticate request req
+func saslAuthenticateSucceeded(req *RequestMessage, res *ResponseMessage) bool {
+	if len(res.rawMsg) < responseHeaderLen {
+		return false
+	}
+	d := &decoder{b: res.rawMsg[responseHeaderLen:]}
+	if hasFlexibleResponseHeader(req.kind, req.version) {
+		d.flexible = true
+		d.taggedFields()
+	}
+	code := d.int16()
+	return d.Err() == nil && code == 0
+}
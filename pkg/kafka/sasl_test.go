@@ -0,0 +1,72 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package kafka
+
+import (
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	. "gopkg.in/check.v1"
+)
+
+func (k *kafkaTestSuite) TestSaslPrincipal(c *C) {
+	c.Assert(saslPrincipal("PLAIN", []byte("\x00alice\x00secret")), Equals, "alice")
+	c.Assert(saslPrincipal("PLAIN", []byte("admin\x00alice\x00secret")), Equals, "admin")
+	c.Assert(saslPrincipal("PLAIN", []byte("alice")), Equals, "")
+	c.Assert(saslPrincipal("SCRAM-SHA-256", []byte("n,,n=alice,r=abc")), Equals, "alice")
+	c.Assert(saslPrincipal("SCRAM-SHA-512", []byte("n,a=x,n=a=2Cb=3Dc,r=abc")), Equals, "a,b=c")
+	c.Assert(saslPrincipal("GSSAPI", []byte("token")), Equals, "")
+}
+
+// saslResponse returns a raw response to req with error code code
+func saslResponse(req *RequestMessage, code int16) *ResponseMessage {
+	e := &encoder{}
+	e.int32(0)
+	e.int32(int32(req.GetCorrelationID()))
+	e.flexible = hasFlexibleResponseHeader(req.kind, req.version)
+	e.taggedFields()
+	e.int16(code)
+	return &ResponseMessage{rawMsg: e.b}
+}
+
+func (k *kafkaTestSuite) TestCorrelationPrincipal(c *C) {
+	cc := NewCorrelationCache()
+	defer cc.DeleteCache()
+
+	handshake := readRequest(c, encodeRequest(api.SaslHandshakeKey, 1, "client", func(e *encoder) {
+		e.string("PLAIN")
+	}))
+	c.Assert(handshake.saslMechanism, Equals, "PLAIN")
+	cc.HandleRequest(handshake, nil)
+	c.Assert(cc.CorrelateResponse(saslResponse(handshake, 0)), Equals, handshake)
+
+	authenticate := func(version int16, user string) *RequestMessage {
+		return readRequest(c, encodeRequest(api.SaslAuthenticateKey, version, "client", func(e *encoder) {
+			e.bytes([]byte("\x00" + user + "\x00secret"))
+		}))
+	}
+
+	// Failed authentication does not set the principal
+	req := authenticate(1, "mallory")
+	cc.HandleRequest(req, nil)
+	cc.CorrelateResponse(saslResponse(req, 58))
+	c.Assert(cc.Principal(), Equals, "")
+
+	req = authenticate(2, "alice")
+	cc.HandleRequest(req, nil)
+	cc.CorrelateResponse(saslResponse(req, 0))
+	c.Assert(cc.Principal(), Equals, "alice")
+}
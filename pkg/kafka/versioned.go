@@ -0,0 +1,436 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	"github.com/optiopay/kafka/proto"
+)
+
+// vendoredMaxVersions maps the API keys supported by the vendored decoder to
+// the highest version it can decode and create responses for. Newer
+// versions are decoded by the proxy itself.
+var vendoredMaxVersions = map[int16]int16{
+	proto.ProduceReqKind:          4,
+	proto.FetchReqKind:            6,
+	proto.OffsetReqKind:           3,
+	proto.MetadataReqKind:         4,
+	proto.OffsetCommitReqKind:     4,
+	proto.OffsetFetchReqKind:      4,
+	proto.ConsumerMetadataReqKind: 2,
+}
+
+// versionedMaxVersions maps the API keys decoded by the proxy to the highest
+// version supported
+var versionedMaxVersions = map[int16]int16{
+	api.ProduceKey:          9,
+	api.FetchKey:            12,
+	api.OffsetsKey:          7,
+	api.MetadataKey:         12,
+	api.OffsetCommitKey:     8,
+	api.OffsetFetchKey:      7,
+	api.JoinGroupKey:        9,
+	api.HeartbeatKey:        4,
+	api.LeaveGroupKey:       5,
+	api.SyncgroupKey:        5,
+	api.TxnOffsetCommitKey:  3,
+	api.SaslHandshakeKey:    1,
+	api.SaslAuthenticateKey: 2,
+}
+
+// isVendoredVersion returns true if the vendored decoder supports version
+// of the API key kind
+func isVendoredVersion(kind, version int16) bool {
+	max, ok := vendoredMaxVersions[kind]
+	return ok && version <= max
+}
+
+// isVersionedVersion returns true if the proxy decodes version of the API
+// key kind itself
+func isVersionedVersion(kind, version int16) bool {
+	max, ok := versionedMaxVersions[kind]
+	return ok && version <= max && !isVendoredVersion(kind, version)
+}
+
+// topicPartitions is a topic and its partitions contained in a request
+type topicPartitions struct {
+	name       string
+	partitions []int32
+}
+
+// decodeTopics decodes an array of topics. partitions is called to decode
+// each partition and returns the partition index.
+func decodeTopics(d *decoder, partition func(d *decoder) int32) []topicPartitions {
+	n := d.arrayLen()
+	if n < 0 {
+		return nil
+	}
+	topics := make([]topicPartitions, 0, n)
+	for i := 0; i < n && d.Err() == nil; i++ {
+		topic := topicPartitions{name: d.string()}
+		m := d.arrayLen()
+		for j := 0; j < m && d.Err() == nil; j++ {
+			topic.partitions = append(topic.partitions, partition(d))
+			d.taggedFields()
+		}
+		d.taggedFields()
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// decodeVersioned decodes the body of requests not supported by the vendored
+// decoder. Only the fields used in policy enforcement and in the creation of
+// responses are decoded.
+func (req *RequestMessage) decodeVersioned(d *decoder) error {
+	v := req.version
+
+	switch req.kind {
+	case api.ProduceKey:
+		// transactional_id, acks, timeout_ms
+		d.nullableString()
+		d.skip(2 + 4)
+		req.topics = decodeTopics(d, func(d *decoder) int32 {
+			index := d.int32()
+			d.nullableBytes()
+			return index
+		})
+
+	case api.FetchKey:
+		// replica_id, max_wait_ms, min_bytes, max_bytes, isolation_level,
+		// session_id, session_epoch
+		d.skip(4 + 4 + 4 + 4 + 1 + 4 + 4)
+		req.topics = decodeTopics(d, func(d *decoder) int32 {
+			index := d.int32()
+			if v >= 9 {
+				// current_leader_epoch
+				d.skip(4)
+			}
+			// fetch_offset
+			d.skip(8)
+			if v >= 12 {
+				// last_fetched_epoch
+				d.skip(4)
+			}
+			// log_start_offset, partition_max_bytes
+			d.skip(8 + 4)
+			return index
+		})
+
+	case api.OffsetsKey:
+		// replica_id, isolation_level
+		d.skip(4 + 1)
+		req.topics = decodeTopics(d, func(d *decoder) int32 {
+			index := d.int32()
+			// current_leader_epoch, timestamp
+			d.skip(4 + 8)
+			return index
+		})
+
+	case api.MetadataKey:
+		n := d.arrayLen()
+		if n >= 0 {
+			req.topics = make([]topicPartitions, 0, n)
+		}
+		for i := 0; i < n && d.Err() == nil; i++ {
+			if v >= 10 {
+				// topic_id
+				d.skip(16)
+			}
+			req.topics = append(req.topics, topicPartitions{name: d.string()})
+			d.taggedFields()
+		}
+
+	case api.OffsetCommitKey:
+		req.groupID = d.string()
+		req.hasGroup = true
+		// generation_id, member_id
+		d.skip(4)
+		d.string()
+		if v >= 7 {
+			// group_instance_id
+			d.nullableString()
+		}
+		req.topics = decodeTopics(d, func(d *decoder) int32 {
+			index := d.int32()
+			// committed_offset
+			d.skip(8)
+			if v >= 6 {
+				// committed_leader_epoch
+				d.skip(4)
+			}
+			// committed_metadata
+			d.nullableString()
+			return index
+		})
+
+	case api.OffsetFetchKey:
+		req.groupID = d.string()
+		req.hasGroup = true
+		n := d.arrayLen()
+		if n >= 0 {
+			req.topics = make([]topicPartitions, 0, n)
+		}
+		for i := 0; i < n && d.Err() == nil; i++ {
+			topic := topicPartitions{name: d.string()}
+			m := d.arrayLen()
+			for j := 0; j < m && d.Err() == nil; j++ {
+				topic.partitions = append(topic.partitions, d.int32())
+			}
+			d.taggedFields()
+			req.topics = append(req.topics, topic)
+		}
+
+	case api.JoinGroupKey, api.HeartbeatKey, api.LeaveGroupKey, api.SyncgroupKey:
+		req.groupID = d.string()
+		req.hasGroup = true
+
+	case api.TxnOffsetCommitKey:
+		// transactional_id
+		d.string()
+		req.groupID = d.string()
+		req.hasGroup = true
+
+	case api.SaslHandshakeKey:
+		req.saslMechanism = d.string()
+
+	case api.SaslAuthenticateKey:
+		req.saslAuthBytes = d.nullableBytes()
+	}
+
+	if err := d.Err(); err != nil {
+		return fmt.Errorf("unable to decode %s request version %d: %s",
+			apiKeyName(req.kind), req.version, err)
+	}
+	req.versioned = true
+	return nil
+}
+
+func apiKeyName(kind int16) string {
+	if name, ok := api.KafkaReverseAPIKeyMap[kind]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", kind)
+}
+
+// errorCode returns the Kafka error code of err
+func errorCode(err error) int16 {
+	if kerr, ok := err.(*proto.KafkaError); ok {
+		return int16(kerr.Errno())
+	}
+	return int16(ErrUnknown)
+}
+
+// createVersionedResponse creates a response to a request decoded by the
+// proxy with the error code of err set in the response and in all topics and
+// partitions. Requests which contain a group but no topics are rejected with
+// GROUP_AUTHORIZATION_FAILED in place of TOPIC_AUTHORIZATION_FAILED.
+func (req *RequestMessage) createVersionedResponse(err error) (*ResponseMessage, error) {
+	v := req.version
+	code := errorCode(err)
+
+	e := &encoder{flexible: isFlexibleVersion(req.kind, v)}
+	// Size placeholder and correlation ID
+	e.int32(0)
+	e.int32(int32(req.GetCorrelationID()))
+	if hasFlexibleResponseHeader(req.kind, v) {
+		e.taggedFields()
+	}
+
+	groupCode := code
+	if err == proto.ErrTopicAuthorizationFailed {
+		groupCode = int16(ErrGroupAuthorizationFailed)
+	}
+
+	encodeTopics := func(partition func(index int32)) {
+		e.arrayLen(len(req.topics))
+		for _, topic := range req.topics {
+			e.string(topic.name)
+			e.arrayLen(len(topic.partitions))
+			for _, index := range topic.partitions {
+				e.int32(index)
+				partition(index)
+				e.taggedFields()
+			}
+			e.taggedFields()
+		}
+	}
+
+	switch req.kind {
+	case api.ProduceKey:
+		encodeTopics(func(index int32) {
+			// error_code, base_offset, log_append_time_ms,
+			// log_start_offset
+			e.int16(code)
+			e.int64(-1)
+			e.int64(-1)
+			e.int64(-1)
+			if v >= 8 {
+				// record_errors, error_message
+				e.arrayLen(0)
+				e.nullString()
+			}
+		})
+		// throttle_time_ms
+		e.int32(0)
+
+	case api.FetchKey:
+		// throttle_time_ms, error_code, session_id
+		e.int32(0)
+		e.int16(0)
+		e.int32(0)
+		encodeTopics(func(index int32) {
+			// error_code, high_watermark, last_stable_offset,
+			// log_start_offset, aborted_transactions
+			e.int16(code)
+			e.int64(-1)
+			e.int64(-1)
+			e.int64(-1)
+			e.arrayLen(-1)
+			if v >= 11 {
+				// preferred_read_replica
+				e.int32(-1)
+			}
+			// records
+			e.nullBytes()
+		})
+
+	case api.OffsetsKey:
+		// throttle_time_ms
+		e.int32(0)
+		encodeTopics(func(index int32) {
+			// error_code, timestamp, offset, leader_epoch
+			e.int16(code)
+			e.int64(-1)
+			e.int64(-1)
+			e.int32(-1)
+		})
+
+	case api.MetadataKey:
+		// throttle_time_ms, brokers, cluster_id, controller_id
+		e.int32(0)
+		e.arrayLen(0)
+		e.nullString()
+		e.int32(-1)
+		e.arrayLen(len(req.topics))
+		for _, topic := range req.topics {
+			e.int16(code)
+			e.string(topic.name)
+			if v >= 10 {
+				// topic_id
+				e.b = append(e.b, make([]byte, 16)...)
+			}
+			// is_internal, partitions
+			e.bool(false)
+			e.arrayLen(0)
+			if v >= 8 {
+				// topic_authorized_operations
+				e.int32(-2147483648)
+			}
+			e.taggedFields()
+		}
+		if v >= 8 && v <= 10 {
+			// cluster_authorized_operations
+			e.int32(-2147483648)
+		}
+
+	case api.OffsetCommitKey:
+		// throttle_time_ms
+		e.int32(0)
+		encodeTopics(func(index int32) {
+			e.int16(code)
+		})
+
+	case api.OffsetFetchKey:
+		// throttle_time_ms
+		e.int32(0)
+		encodeTopics(func(index int32) {
+			// committed_offset, committed_leader_epoch, metadata,
+			// error_code
+			e.int64(-1)
+			e.int32(-1)
+			e.string("")
+			e.int16(code)
+		})
+		e.int16(code)
+
+	case api.JoinGroupKey:
+		if v >= 2 {
+			// throttle_time_ms
+			e.int32(0)
+		}
+		// error_code, generation_id
+		e.int16(groupCode)
+		e.int32(-1)
+		if v >= 7 {
+			// protocol_type
+			e.nullString()
+		}
+		// protocol_name, leader
+		e.string("")
+		e.string("")
+		if v >= 9 {
+			// skip_assignment
+			e.bool(false)
+		}
+		// member_id, members
+		e.string("")
+		e.arrayLen(0)
+
+	case api.HeartbeatKey:
+		if v >= 1 {
+			// throttle_time_ms
+			e.int32(0)
+		}
+		e.int16(groupCode)
+
+	case api.LeaveGroupKey:
+		if v >= 1 {
+			// throttle_time_ms
+			e.int32(0)
+		}
+		e.int16(groupCode)
+		if v >= 3 {
+			// members
+			e.arrayLen(0)
+		}
+
+	case api.SyncgroupKey:
+		if v >= 1 {
+			// throttle_time_ms
+			e.int32(0)
+		}
+		e.int16(groupCode)
+		if v >= 5 {
+			// protocol_type, protocol_name
+			e.nullString()
+			e.nullString()
+		}
+		// assignment
+		e.bytes(nil)
+
+	default:
+		return nil, fmt.Errorf("unsupported response for %s request version %d",
+			apiKeyName(req.kind), req.version)
+	}
+	e.taggedFields()
+
+	binary.BigEndian.PutUint32(e.b, uint32(len(e.b)-4))
+	return &ResponseMessage{rawMsg: e.b}, nil
+}
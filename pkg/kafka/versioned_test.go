@@ -0,0 +1,301 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	"github.com/optiopay/kafka/proto"
+	. "gopkg.in/check.v1"
+)
+
+// encodeRequest returns a raw request with the header and the body encoded
+// by body
+func encodeRequest(kind, version int16, clientID string, body func(e *encoder)) []byte {
+	e := &encoder{}
+	e.int32(0)
+	e.int16(kind)
+	e.int16(version)
+	e.int32(42)
+	// The client ID is never a compact string
+	e.string(clientID)
+	e.flexible = isFlexibleVersion(kind, version)
+	e.taggedFields()
+	body(e)
+	e.taggedFields()
+	binary.BigEndian.PutUint32(e.b, uint32(len(e.b)-4))
+	return e.b
+}
+
+func readRequest(c *C, raw []byte) *RequestMessage {
+	req, err := ReadRequest(bytes.NewReader(raw))
+	c.Assert(err, IsNil)
+	return req
+}
+
+func encodeProduce(e *encoder) {
+	e.nullString()
+	e.int16(-1)
+	e.int32(1000)
+	e.arrayLen(2)
+	for _, topic := range []string{"foo", "bar"} {
+		e.string(topic)
+		e.arrayLen(1)
+		e.int32(3)
+		e.bytes([]byte("records"))
+		e.taggedFields()
+		e.taggedFields()
+	}
+}
+
+func (k *kafkaTestSuite) TestFlexibleVersions(c *C) {
+	c.Assert(isFlexibleVersion(api.ProduceKey, 8), Equals, false)
+	c.Assert(isFlexibleVersion(api.ProduceKey, 9), Equals, true)
+	c.Assert(isFlexibleVersion(api.SaslHandshakeKey, 1), Equals, false)
+	c.Assert(hasFlexibleResponseHeader(api.APIVersionsKey, 3), Equals, false)
+	c.Assert(hasFlexibleResponseHeader(api.MetadataKey, 9), Equals, true)
+}
+
+func (k *kafkaTestSuite) TestCodec(c *C) {
+	for _, flexible := range []bool{false, true} {
+		e := &encoder{flexible: flexible}
+		e.string("foo")
+		e.nullString()
+		e.bytes([]byte{1, 2})
+		e.arrayLen(-1)
+		e.taggedFields()
+		e.int32(7)
+
+		d := &decoder{b: e.b, flexible: flexible}
+		c.Assert(d.string(), Equals, "foo")
+		_, ok := d.nullableString()
+		c.Assert(ok, Equals, false)
+		c.Assert(d.nullableBytes(), DeepEquals, []byte{1, 2})
+		c.Assert(d.arrayLen(), Equals, -1)
+		d.taggedFields()
+		c.Assert(d.int32(), Equals, int32(7))
+		c.Assert(d.Err(), IsNil)
+
+		// Reading beyond the end fails and keeps failing
+		c.Assert(d.int16(), Equals, int16(0))
+		c.Assert(d.Err(), Equals, errTruncated)
+		c.Assert(d.string(), Equals, "")
+	}
+
+	// Tagged fields are skipped
+	d := &decoder{b: []byte{2, 0, 1, 0xff, 5, 2, 0xff, 0xff, 0x12}, flexible: true}
+	d.taggedFields()
+	c.Assert(d.int8(), Equals, int8(0x12))
+	c.Assert(d.Err(), IsNil)
+
+	// Array lengths larger than the remaining data are rejected
+	d = &decoder{b: []byte{0, 0, 0, 10, 0}}
+	c.Assert(d.arrayLen(), Equals, -1)
+	c.Assert(d.Err(), Equals, errInvalidLength)
+}
+
+func (k *kafkaTestSuite) TestVersionedProduce(c *C) {
+	for _, version := range []int16{5, 8, 9} {
+		req := readRequest(c, encodeRequest(api.ProduceKey, version, "client1", encodeProduce))
+		c.Assert(req.versioned, Equals, true)
+		c.Assert(req.GetClientID(), Equals, "client1")
+		c.Assert(req.GetTopics(), DeepEquals, []string{"foo", "bar"})
+
+		res, err := req.CreateResponse(proto.ErrTopicAuthorizationFailed)
+		c.Assert(err, IsNil)
+		c.Assert(res.GetCorrelationID(), Equals, CorrelationID(42))
+		c.Assert(int(binary.BigEndian.Uint32(res.rawMsg)), Equals, len(res.rawMsg)-4)
+
+		d := &decoder{b: res.rawMsg[responseHeaderLen:], flexible: version >= 9}
+		d.taggedFields()
+		c.Assert(d.arrayLen(), Equals, 2)
+		c.Assert(d.string(), Equals, "foo")
+		c.Assert(d.arrayLen(), Equals, 1)
+		c.Assert(d.int32(), Equals, int32(3))
+		c.Assert(d.int16(), Equals, int16(ErrTopicAuthorizationFailed))
+		c.Assert(d.Err(), IsNil)
+	}
+
+	// Versions supported by the vendored decoder are not affected
+	req := readRequest(c, encodeRequest(api.ProduceKey, 3, "client1", encodeProduce))
+	c.Assert(req.versioned, Equals, false)
+	c.Assert(req.GetTopics(), DeepEquals, []string{"foo", "bar"})
+
+	// Truncated requests are rejected
+	raw := encodeRequest(api.ProduceKey, 9, "client1", encodeProduce)
+	raw = raw[:len(raw)-12]
+	binary.BigEndian.PutUint32(raw, uint32(len(raw)-4))
+	_, err := ReadRequest(bytes.NewReader(raw))
+	c.Assert(err, Not(IsNil))
+}
+
+func (k *kafkaTestSuite) TestVersionedFetch(c *C) {
+	raw := encodeRequest(api.FetchKey, 12, "consumer", func(e *encoder) {
+		e.int32(-1)
+		e.int32(500)
+		e.int32(1)
+		e.int32(1 << 20)
+		e.int8(0)
+		e.int32(0)
+		e.int32(-1)
+		e.arrayLen(1)
+		e.string("foo")
+		e.arrayLen(2)
+		for _, partition := range []int32{0, 1} {
+			e.int32(partition)
+			e.int32(-1)
+			e.int64(100)
+			e.int32(-1)
+			e.int64(0)
+			e.int32(1 << 20)
+			e.taggedFields()
+		}
+		e.taggedFields()
+		// forgotten_topics_data, rack_id
+		e.arrayLen(0)
+		e.string("")
+	})
+	req := readRequest(c, raw)
+	c.Assert(req.versioned, Equals, true)
+	c.Assert(req.topics, DeepEquals, []topicPartitions{{name: "foo", partitions: []int32{0, 1}}})
+
+	res, err := req.CreateResponse(proto.ErrTopicAuthorizationFailed)
+	c.Assert(err, IsNil)
+	d := &decoder{b: res.rawMsg[responseHeaderLen:], flexible: true}
+	d.taggedFields()
+	// throttle_time_ms, error_code, session_id
+	d.skip(4 + 2 + 4)
+	c.Assert(d.arrayLen(), Equals, 1)
+	c.Assert(d.string(), Equals, "foo")
+	c.Assert(d.arrayLen(), Equals, 2)
+	c.Assert(d.int32(), Equals, int32(0))
+	c.Assert(d.int16(), Equals, int16(ErrTopicAuthorizationFailed))
+	c.Assert(d.Err(), IsNil)
+}
+
+func (k *kafkaTestSuite) TestVersionedGroupRequests(c *C) {
+	raw := encodeRequest(api.OffsetCommitKey, 8, "consumer", func(e *encoder) {
+		e.string("group1")
+		e.int32(1)
+		e.string("member1")
+		e.nullString()
+		e.arrayLen(1)
+		e.string("foo")
+		e.arrayLen(1)
+		e.int32(0)
+		e.int64(100)
+		e.int32(-1)
+		e.nullString()
+		e.taggedFields()
+		e.taggedFields()
+	})
+	req := readRequest(c, raw)
+	group, ok := req.GetConsumerGroup()
+	c.Assert(ok, Equals, true)
+	c.Assert(group, Equals, "group1")
+	c.Assert(req.GetTopics(), DeepEquals, []string{"foo"})
+
+	raw = encodeRequest(api.JoinGroupKey, 6, "consumer", func(e *encoder) {
+		e.string("group2")
+		e.int32(10000)
+		e.int32(30000)
+		e.string("")
+		e.nullString()
+		e.string("consumer")
+		e.arrayLen(0)
+	})
+	req = readRequest(c, raw)
+	group, ok = req.GetConsumerGroup()
+	c.Assert(ok, Equals, true)
+	c.Assert(group, Equals, "group2")
+
+	res, err := req.CreateResponse(proto.ErrTopicAuthorizationFailed)
+	c.Assert(err, IsNil)
+	d := &decoder{b: res.rawMsg[responseHeaderLen:], flexible: true}
+	d.taggedFields()
+	d.skip(4)
+	c.Assert(d.int16(), Equals, int16(ErrGroupAuthorizationFailed))
+	c.Assert(d.Err(), IsNil)
+
+	// Group is also extracted by the vendored decoder
+	offsetFetch := &proto.OffsetFetchReq{
+		CorrelationID: 1,
+		ClientID:      "consumer",
+		ConsumerGroup: "group3",
+		Topics:        []proto.OffsetFetchReqTopic{{Name: "foo", Partitions: []int32{0}}},
+	}
+	b, err := offsetFetch.Bytes(1)
+	c.Assert(err, IsNil)
+	req = readRequest(c, b)
+	group, ok = req.GetConsumerGroup()
+	c.Assert(ok, Equals, true)
+	c.Assert(group, Equals, "group3")
+}
+
+func (k *kafkaTestSuite) TestVersionedAllTopics(c *C) {
+	rules := []api.PortRuleKafka{{Topic: "foo"}}
+
+	for _, n := range []int{-1, 0} {
+		// Metadata requests with null or empty topics return all topics
+		req := readRequest(c, encodeRequest(api.MetadataKey, 9, "client1", func(e *encoder) {
+			e.arrayLen(n)
+			e.bool(false)
+			e.bool(false)
+			e.bool(false)
+		}))
+		c.Assert(req.versioned, Equals, true)
+		c.Assert(req.GetTopics(), HasLen, 0)
+		c.Assert(req.MatchesRule(rules), Equals, false)
+		c.Assert(req.MatchesRule([]api.PortRuleKafka{{APIKey: "metadata"}}), Equals, true)
+
+		// OffsetFetch requests with null or empty topics fetch the
+		// offsets of all topics
+		req = readRequest(c, encodeRequest(api.OffsetFetchKey, 7, "consumer", func(e *encoder) {
+			e.string("group1")
+			e.arrayLen(n)
+			e.bool(false)
+		}))
+		c.Assert(req.versioned, Equals, true)
+		c.Assert(req.MatchesRule(rules), Equals, false)
+	}
+
+	req := readRequest(c, encodeRequest(api.MetadataKey, 9, "client1", func(e *encoder) {
+		e.arrayLen(1)
+		e.string("foo")
+		e.taggedFields()
+		e.bool(false)
+		e.bool(false)
+		e.bool(false)
+	}))
+	c.Assert(req.MatchesRule(rules), Equals, true)
+}
+
+func (k *kafkaTestSuite) TestUnsupportedVersion(c *C) {
+	// Versions newer than supported are passed on without inspection of
+	// the body and cannot be answered by the proxy
+	req := readRequest(c, encodeRequest(api.ProduceKey, 100, "client1", encodeProduce))
+	c.Assert(req.versioned, Equals, false)
+	c.Assert(req.GetClientID(), Equals, "client1")
+	c.Assert(req.GetTopics(), IsNil)
+	c.Assert(req.MatchesRule([]api.PortRuleKafka{{Topic: "foo"}}), Equals, false)
+
+	_, err := req.CreateResponse(proto.ErrTopicAuthorizationFailed)
+	c.Assert(err, Not(IsNil))
+}
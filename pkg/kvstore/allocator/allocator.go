@@ -100,6 +100,9 @@ type kvstoreBackend struct {
 	// valid prefix
 	deleteInvalidPrefixes bool
 
+	// backend is the kvstore listed and watched for allocations
+	backend kvstore.BackendOperations
+
 	keyType allocator.AllocatorKey
 }
 
@@ -129,9 +132,25 @@ func NewKVStoreBackend(basePath, suffix string, typ allocator.AllocatorKey) (*kv
 		suffix:      suffix,
 		keyType:     typ,
 		lockless:    locklessCapability(),
+		backend:     kvstore.Client(),
 	}, nil
 }
 
+// NewRemoteKVStoreBackend creates a pkg/allocator.Backend compatible instance
+// which lists and watches the allocations in the kvstore represented by
+// backend, e.g. the kvstore of a remote cluster. It must only be used to
+// watch allocations with Allocator.WatchRemoteKVStore().
+func NewRemoteKVStoreBackend(basePath string, typ allocator.AllocatorKey, backend kvstore.BackendOperations) *kvstoreBackend {
+	return &kvstoreBackend{
+		basePrefix:  basePath,
+		idPrefix:    path.Join(basePath, "id"),
+		valuePrefix: path.Join(basePath, "value"),
+		lockPrefix:  path.Join(basePath, "locks"),
+		keyType:     typ,
+		backend:     backend,
+	}
+}
+
 // lockPath locks a key in the scope of an allocator
 func (k *kvstoreBackend) lockPath(ctx context.Context, key string) (*kvstore.Lock, error) {
 	suffix := strings.TrimPrefix(key, k.basePrefix)
@@ -478,7 +497,7 @@ func (k *kvstoreBackend) keyToID(key string) (id idpool.ID, err error) {
 }
 
 func (k *kvstoreBackend) ListAndWatch(handler allocator.CacheMutations, stopChan chan struct{}) {
-	watcher := kvstore.ListAndWatch(k.idPrefix, k.idPrefix, 512)
+	watcher := k.backend.ListAndWatch(k.idPrefix, k.idPrefix, 512)
 
 	for {
 		select {
@@ -504,7 +523,7 @@ func (k *kvstoreBackend) ListAndWatch(handler allocator.CacheMutations, stopChan
 				var key allocator.AllocatorKey
 
 				if len(event.Value) > 0 {
-					s, err := kvstore.Decode(string(event.Value))
+					s, err := k.backend.Decode(string(event.Value))
 					if err != nil {
 						log.WithError(err).WithFields(logrus.Fields{
 							fieldKey:   event.Key,
@@ -537,7 +556,7 @@ abort:
 }
 
 func (k *kvstoreBackend) Status() (string, error) {
-	return kvstore.Client().Status()
+	return k.backend.Status()
 }
 
 func (k *kvstoreBackend) Encode(v string) string {
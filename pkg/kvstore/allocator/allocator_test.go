@@ -56,6 +56,21 @@ func (e *AllocatorEtcdSuite) TearDownTest(c *C) {
 	kvstore.Close()
 }
 
+type AllocatorMemorySuite struct {
+	AllocatorSuite
+}
+
+var _ = Suite(&AllocatorMemorySuite{})
+
+func (e *AllocatorMemorySuite) SetUpTest(c *C) {
+	kvstore.SetupDummy("memory")
+}
+
+func (e *AllocatorMemorySuite) TearDownTest(c *C) {
+	kvstore.DeletePrefix(testPrefix)
+	kvstore.Close()
+}
+
 type AllocatorConsulSuite struct {
 	AllocatorSuite
 }
@@ -392,7 +407,7 @@ func (s *AllocatorSuite) TestRemoteCache(c *C) {
 	}
 
 	// watch the prefix in the same kvstore via a 2nd watcher
-	rc := a.WatchRemoteKVStore(kvstore.Client(), testName)
+	rc := a.WatchRemoteKVStore(NewRemoteKVStoreBackend(testName, TestAllocatorKey(""), kvstore.Client()))
 	c.Assert(rc, Not(IsNil))
 
 	// wait for remote cache to be populated
@@ -0,0 +1,902 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/spanstat"
+	"github.com/cilium/cilium/pkg/trigger"
+)
+
+const (
+	memoryName = "memory"
+
+	// memoryPathOption is the string representing the key mapping to the
+	// path of the file the in-memory kvstore is persisted to
+	memoryPathOption = "memory.path"
+
+	// memoryPersistInterval is the minimum interval between two writes of
+	// a persisted store to its file
+	memoryPersistInterval = 100 * time.Millisecond
+)
+
+// The memory backend is an in-process kvstore. All clients created with the
+// same path share the same store, clients created without a path share a
+// single anonymous store which is not persisted.
+//
+// Each client holds a lease for the lifetime of the client. Keys created with
+// a lease and locks held by the client are removed when the client is
+// closed. Keys attached to a lease are never persisted.
+type memoryModule struct {
+	opts backendOptions
+}
+
+func init() {
+	// register memory module for use
+	registerBackend(memoryName, newMemoryModule())
+}
+
+func newMemoryModule() backendModule {
+	return &memoryModule{
+		opts: backendOptions{
+			memoryPathOption: &backendOption{
+				description: "Path to the file the in-memory kvstore is persisted to (optional)",
+			},
+		},
+	}
+}
+
+func (m *memoryModule) createInstance() backendModule {
+	return newMemoryModule()
+}
+
+func (m *memoryModule) getName() string {
+	return memoryName
+}
+
+func (m *memoryModule) setConfigDummy() {
+}
+
+func (m *memoryModule) setConfig(opts map[string]string) error {
+	return setOpts(opts, m.opts)
+}
+
+func (m *memoryModule) setExtraConfig(opts *ExtraOptions) error {
+	return nil
+}
+
+func (m *memoryModule) getConfig() map[string]string {
+	return getOpts(m.opts)
+}
+
+func (m *memoryModule) newClient(opts *ExtraOptions) (BackendOperations, chan error) {
+	errChan := make(chan error, 1)
+	defer close(errChan)
+
+	store, err := getMemoryStore(m.opts[memoryPathOption].value)
+	if err != nil {
+		errChan <- err
+		return nil, errChan
+	}
+
+	return newMemoryClient(store), errChan
+}
+
+var (
+	memoryStoresMutex lock.Mutex
+	// memoryStores maps the path of a persisted store to the store, the
+	// anonymous store is stored with an empty path
+	memoryStores = map[string]*memoryStore{}
+)
+
+// getMemoryStore returns the store persisted to path, loading it from path
+// on first use. An empty path returns the anonymous store.
+func getMemoryStore(path string) (*memoryStore, error) {
+	memoryStoresMutex.Lock()
+	defer memoryStoresMutex.Unlock()
+
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s option: %s", memoryPathOption, err)
+		}
+		path = abs
+	}
+
+	if s, ok := memoryStores[path]; ok {
+		return s, nil
+	}
+
+	s := newMemoryStore(path)
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if path != "" {
+		t, err := trigger.NewTrigger(trigger.Parameters{
+			Name:        "kvstore-memory-persist",
+			MinInterval: memoryPersistInterval,
+			TriggerFunc: func(reasons []string) { s.persist() },
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize kvstore persistence trigger: %s", err)
+		}
+		s.persistTrigger = t
+	}
+	memoryStores[path] = s
+
+	return s, nil
+}
+
+type memoryEntry struct {
+	value          []byte
+	createRevision uint64
+	modRevision    uint64
+
+	// lease is the lease the entry is attached to, or 0 if it is not
+	// attached to a lease
+	lease uint64
+}
+
+// memoryFileEntry is the representation of an entry in the persisted file
+type memoryFileEntry struct {
+	Value          []byte `json:"value"`
+	CreateRevision uint64 `json:"createRevision"`
+	ModRevision    uint64 `json:"modRevision"`
+}
+
+// memoryFile is the representation of a store in the persisted file
+type memoryFile struct {
+	Revision uint64                     `json:"revision"`
+	Entries  map[string]memoryFileEntry `json:"entries"`
+}
+
+type memoryStore struct {
+	mutex lock.Mutex
+
+	// path is the path the store is persisted to, or empty
+	path string
+
+	revision  uint64
+	lastLease uint64
+	entries   map[string]*memoryEntry
+
+	// locks maps lock paths to the lock currently holding them
+	locks map[string]*memoryLock
+
+	watchers map[*memoryWatch]struct{}
+
+	// dirty is true if the store was modified since it was last persisted
+	dirty bool
+
+	// persistTrigger writes the store to its file, it is nil if the store
+	// is not persisted
+	persistTrigger *trigger.Trigger
+
+	// persistMutex serializes writes to the file of the store
+	persistMutex lock.Mutex
+}
+
+func newMemoryStore(path string) *memoryStore {
+	return &memoryStore{
+		path:     path,
+		entries:  map[string]*memoryEntry{},
+		locks:    map[string]*memoryLock{},
+		watchers: map[*memoryWatch]struct{}{},
+	}
+}
+
+// load loads the store from its file. A missing file is not an error.
+func (s *memoryStore) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to read kvstore file %s: %s", s.path, err)
+	}
+
+	f := memoryFile{}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("invalid kvstore file %s: %s", s.path, err)
+	}
+
+	s.revision = f.Revision
+	for key, e := range f.Entries {
+		s.entries[key] = &memoryEntry{
+			value:          e.Value,
+			createRevision: e.CreateRevision,
+			modRevision:    e.ModRevision,
+		}
+	}
+
+	return nil
+}
+
+// schedulePersistLocked marks the store as modified and schedules it to be
+// written to its file. Writes are rate limited to memoryPersistInterval.
+// s.mutex must be held.
+func (s *memoryStore) schedulePersistLocked() {
+	if s.persistTrigger == nil {
+		return
+	}
+
+	s.dirty = true
+	s.persistTrigger.Trigger()
+}
+
+// persist writes all entries not attached to a lease to the file of the
+// store if it was modified since it was last persisted. The entries are
+// collected under s.mutex but written to the file without holding it.
+func (s *memoryStore) persist() {
+	if s.path == "" {
+		return
+	}
+
+	s.persistMutex.Lock()
+	defer s.persistMutex.Unlock()
+
+	s.mutex.Lock()
+	if !s.dirty {
+		s.mutex.Unlock()
+		return
+	}
+	s.dirty = false
+
+	f := memoryFile{
+		Revision: s.revision,
+		Entries:  map[string]memoryFileEntry{},
+	}
+	for key, e := range s.entries {
+		if e.lease != 0 {
+			continue
+		}
+		// Values are never modified in place, the file entry can
+		// share them
+		f.Entries[key] = memoryFileEntry{
+			Value:          e.value,
+			CreateRevision: e.createRevision,
+			ModRevision:    e.modRevision,
+		}
+	}
+	s.mutex.Unlock()
+
+	if err := writeMemoryFile(s.path, &f); err != nil {
+		log.WithError(err).WithField("path", s.path).Error("Unable to persist in-memory kvstore")
+	}
+}
+
+// writeMemoryFile atomically replaces the file at path with f
+func writeMemoryFile(path string, f *memoryFile) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = out.Write(b); err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// newLeaseLocked returns a new lease ID. s.mutex must be held.
+func (s *memoryStore) newLeaseLocked() uint64 {
+	s.lastLease++
+	return s.lastLease
+}
+
+// setLocked creates or modifies key. s.mutex must be held.
+func (s *memoryStore) setLocked(key string, value []byte, lease uint64) {
+	s.revision++
+
+	typ := EventTypeModify
+	e, ok := s.entries[key]
+	if !ok {
+		typ = EventTypeCreate
+		e = &memoryEntry{createRevision: s.revision}
+		s.entries[key] = e
+	}
+	persisted := ok && e.lease == 0
+	e.value = copyBytes(value)
+	e.modRevision = s.revision
+	e.lease = lease
+
+	s.notifyLocked(KeyValueEvent{Typ: typ, Key: key, Value: copyBytes(value)})
+	if persisted || lease == 0 {
+		s.schedulePersistLocked()
+	}
+}
+
+// deleteLocked deletes key, returning true if it existed. The store is not
+// persisted. s.mutex must be held.
+func (s *memoryStore) deleteLocked(key string) bool {
+	e, ok := s.entries[key]
+	if !ok {
+		return false
+	}
+
+	s.revision++
+	delete(s.entries, key)
+	s.notifyLocked(KeyValueEvent{Typ: EventTypeDelete, Key: key, Value: e.value})
+
+	return true
+}
+
+// sortedKeysLocked returns the keys matching prefix in lexical order.
+// s.mutex must be held.
+func (s *memoryStore) sortedKeysLocked(prefix string) []string {
+	keys := []string{}
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// notifyLocked queues the event to all watchers of matching prefixes.
+// s.mutex must be held.
+func (s *memoryStore) notifyLocked(event KeyValueEvent) {
+	for mw := range s.watchers {
+		if strings.HasPrefix(event.Key, mw.watcher.prefix) {
+			mw.enqueue(event)
+		}
+	}
+}
+
+// checkLockedLocked returns ErrLockLeaseExpired if l is not a lock of the
+// store which is still held. s.mutex must be held.
+func (s *memoryStore) checkLockedLocked(l KVLocker) error {
+	if l == nil {
+		return ErrLockLeaseExpired
+	}
+	ml, ok := l.Comparator().(*memoryLock)
+	if !ok || s.locks[ml.path] != ml {
+		return ErrLockLeaseExpired
+	}
+	return nil
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// memoryWatch queues the events of a watcher. Events are queued without
+// bound so that changes to the store never block on slow watchers.
+type memoryWatch struct {
+	watcher *Watcher
+
+	mutex lock.Mutex
+	queue []KeyValueEvent
+
+	// wakeup is signalled whenever events are queued
+	wakeup chan struct{}
+}
+
+func (mw *memoryWatch) enqueue(event KeyValueEvent) {
+	mw.mutex.Lock()
+	mw.queue = append(mw.queue, event)
+	mw.mutex.Unlock()
+
+	select {
+	case mw.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (mw *memoryWatch) dequeue() (KeyValueEvent, bool) {
+	mw.mutex.Lock()
+	defer mw.mutex.Unlock()
+
+	if len(mw.queue) == 0 {
+		return KeyValueEvent{}, false
+	}
+	event := mw.queue[0]
+	mw.queue = mw.queue[1:]
+	return event, true
+}
+
+// memoryLock is a lock held in a memory store
+type memoryLock struct {
+	client *memoryClient
+	path   string
+
+	// released is closed when the lock is released
+	released chan struct{}
+}
+
+// Unlock releases the lock. Unlocking a lock which is no longer held is a
+// no-op.
+func (ml *memoryLock) Unlock() error {
+	s := ml.client.store
+	s.mutex.Lock()
+	ml.releaseLocked()
+	s.mutex.Unlock()
+	return nil
+}
+
+// releaseLocked releases the lock. The store mutex must be held.
+func (ml *memoryLock) releaseLocked() {
+	s := ml.client.store
+	if s.locks[ml.path] == ml {
+		delete(s.locks, ml.path)
+		close(ml.released)
+	}
+}
+
+// Comparator returns the lock itself, it is used to verify that the lock is
+// still held
+func (ml *memoryLock) Comparator() interface{} {
+	return ml
+}
+
+type memoryClient struct {
+	store *memoryStore
+	lease uint64
+
+	closeOnce sync.Once
+	// closed is closed when the client is closed
+	closed chan struct{}
+}
+
+func newMemoryClient(store *memoryStore) *memoryClient {
+	store.mutex.Lock()
+	lease := store.newLeaseLocked()
+	store.mutex.Unlock()
+
+	return &memoryClient{
+		store:  store,
+		lease:  lease,
+		closed: make(chan struct{}),
+	}
+}
+
+func (c *memoryClient) leaseID(lease bool) uint64 {
+	if lease {
+		return c.lease
+	}
+	return 0
+}
+
+// Connected returns a closed channel, the in-memory kvstore is always
+// connected
+func (c *memoryClient) Connected() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// Disconnected returns a channel which is closed when the client is closed
+func (c *memoryClient) Disconnected() <-chan struct{} {
+	return c.closed
+}
+
+// Status returns the status of the in-memory kvstore
+func (c *memoryClient) Status() (string, error) {
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	status := fmt.Sprintf("Memory: %d keys, revision %d", len(c.store.entries), c.store.revision)
+	if c.store.path != "" {
+		status += ", persisted to " + c.store.path
+	}
+	return status, nil
+}
+
+// LockPath locks the provided path. It blocks until the lock is acquired or
+// ctx is cancelled.
+func (c *memoryClient) LockPath(ctx context.Context, path string) (KVLocker, error) {
+	path = getLockPath(path)
+	s := c.store
+
+	for {
+		s.mutex.Lock()
+		holder, ok := s.locks[path]
+		if !ok {
+			ml := &memoryLock{
+				client:   c,
+				path:     path,
+				released: make(chan struct{}),
+			}
+			s.locks[path] = ml
+			s.mutex.Unlock()
+			return ml, nil
+		}
+		s.mutex.Unlock()
+
+		select {
+		case <-holder.released:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("lock cancelled via context: %s", ctx.Err())
+		}
+	}
+}
+
+// Get returns value of key
+func (c *memoryClient) Get(key string) ([]byte, error) {
+	return c.GetIfLocked(key, nil)
+}
+
+// GetIfLocked returns value of key if the client is still holding the given lock.
+func (c *memoryClient) GetIfLocked(key string, lock KVLocker) ([]byte, error) {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(key, metricRead, "GetLocked", duration.EndError(err).Total(), err)
+			return nil, err
+		}
+	}
+
+	increaseMetric(key, metricRead, "Get", duration.End(true).Total(), nil)
+	if e, ok := c.store.entries[key]; ok {
+		return copyBytes(e.value), nil
+	}
+	return nil, nil
+}
+
+// GetPrefix returns the first key which matches the prefix and its value
+func (c *memoryClient) GetPrefix(ctx context.Context, prefix string) (string, []byte, error) {
+	return c.GetPrefixIfLocked(ctx, prefix, nil)
+}
+
+// GetPrefixIfLocked returns the first key which matches the prefix and its value if the client is still holding the given lock.
+func (c *memoryClient) GetPrefixIfLocked(ctx context.Context, prefix string, lock KVLocker) (string, []byte, error) {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(prefix, metricRead, "GetPrefixLocked", duration.EndError(err).Total(), err)
+			return "", nil, err
+		}
+	}
+
+	increaseMetric(prefix, metricRead, "GetPrefix", duration.End(true).Total(), nil)
+	keys := c.store.sortedKeysLocked(prefix)
+	if len(keys) == 0 {
+		return "", nil, nil
+	}
+	return keys[0], copyBytes(c.store.entries[keys[0]].value), nil
+}
+
+// Set sets value of key
+func (c *memoryClient) Set(key string, value []byte) error {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	c.store.setLocked(key, value, 0)
+	c.store.mutex.Unlock()
+	increaseMetric(key, metricSet, "Set", duration.End(true).Total(), nil)
+	return nil
+}
+
+// Delete deletes a key
+func (c *memoryClient) Delete(key string) error {
+	return c.DeleteIfLocked(key, nil)
+}
+
+// DeleteIfLocked deletes a key if the client is still holding the given lock.
+func (c *memoryClient) DeleteIfLocked(key string, lock KVLocker) error {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(key, metricDelete, "DeleteLocked", duration.EndError(err).Total(), err)
+			return err
+		}
+	}
+
+	if c.store.deleteLocked(key) {
+		c.store.schedulePersistLocked()
+	}
+	increaseMetric(key, metricDelete, "Delete", duration.End(true).Total(), nil)
+	return nil
+}
+
+// DeletePrefix deletes all keys matching the prefix
+func (c *memoryClient) DeletePrefix(path string) error {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	keys := c.store.sortedKeysLocked(path)
+	for _, key := range keys {
+		c.store.deleteLocked(key)
+	}
+	if len(keys) > 0 {
+		c.store.schedulePersistLocked()
+	}
+	increaseMetric(path, metricDelete, "DeletePrefix", duration.End(true).Total(), nil)
+	return nil
+}
+
+// Update creates or updates a key with the value
+func (c *memoryClient) Update(ctx context.Context, key string, value []byte, lease bool) error {
+	return c.UpdateIfLocked(ctx, key, value, lease, nil)
+}
+
+// UpdateIfLocked updates a key if the client is still holding the given lock.
+func (c *memoryClient) UpdateIfLocked(ctx context.Context, key string, value []byte, lease bool, lock KVLocker) error {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(key, metricSet, "UpdateIfLocked", duration.EndError(err).Total(), err)
+			return err
+		}
+	}
+
+	c.store.setLocked(key, value, c.leaseID(lease))
+	increaseMetric(key, metricSet, "Update", duration.End(true).Total(), nil)
+	return nil
+}
+
+// UpdateIfDifferent updates a key if the value or the lease is different
+func (c *memoryClient) UpdateIfDifferent(ctx context.Context, key string, value []byte, lease bool) (bool, error) {
+	return c.UpdateIfDifferentIfLocked(ctx, key, value, lease, nil)
+}
+
+// UpdateIfDifferentIfLocked updates a key if the value or the lease is
+// different and if the client is still holding the given lock.
+func (c *memoryClient) UpdateIfDifferentIfLocked(ctx context.Context, key string, value []byte, lease bool, lock KVLocker) (bool, error) {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(key, metricSet, "UpdateIfDifferentIfLocked", duration.EndError(err).Total(), err)
+			return false, err
+		}
+	}
+
+	leaseID := c.leaseID(lease)
+	if e, ok := c.store.entries[key]; ok && e.lease == leaseID && bytes.Equal(e.value, value) {
+		return false, nil
+	}
+
+	c.store.setLocked(key, value, leaseID)
+	increaseMetric(key, metricSet, "UpdateIfDifferent", duration.End(true).Total(), nil)
+	return true, nil
+}
+
+// CreateOnly creates a key with the value and will fail if the key already exists
+func (c *memoryClient) CreateOnly(ctx context.Context, key string, value []byte, lease bool) (bool, error) {
+	return c.CreateOnlyIfLocked(ctx, key, value, lease, nil)
+}
+
+// CreateOnlyIfLocked atomically creates a key if the client is still holding the given lock or fails if it already exists
+func (c *memoryClient) CreateOnlyIfLocked(ctx context.Context, key string, value []byte, lease bool, lock KVLocker) (bool, error) {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(key, metricSet, "CreateOnlyLocked", duration.EndError(err).Total(), err)
+			return false, err
+		}
+	}
+
+	if _, ok := c.store.entries[key]; ok {
+		return false, nil
+	}
+
+	c.store.setLocked(key, value, c.leaseID(lease))
+	increaseMetric(key, metricSet, "CreateOnly", duration.End(true).Total(), nil)
+	return true, nil
+}
+
+// CreateIfExists creates a key with the value only if key condKey exists
+func (c *memoryClient) CreateIfExists(condKey, key string, value []byte, lease bool) error {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if _, ok := c.store.entries[condKey]; !ok {
+		err := fmt.Errorf("create was unsuccessful")
+		increaseMetric(key, metricSet, "CreateIfExists", duration.EndError(err).Total(), err)
+		return err
+	}
+
+	c.store.setLocked(key, value, c.leaseID(lease))
+	increaseMetric(key, metricSet, "CreateIfExists", duration.End(true).Total(), nil)
+	return nil
+}
+
+// ListPrefix returns a map of matching keys
+func (c *memoryClient) ListPrefix(prefix string) (KeyValuePairs, error) {
+	return c.ListPrefixIfLocked(prefix, nil)
+}
+
+// ListPrefixIfLocked returns a list of keys matching the prefix only if the client is still holding the given lock.
+func (c *memoryClient) ListPrefixIfLocked(prefix string, lock KVLocker) (KeyValuePairs, error) {
+	duration := spanstat.Start()
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if lock != nil {
+		if err := c.store.checkLockedLocked(lock); err != nil {
+			increaseMetric(prefix, metricRead, "ListPrefixLocked", duration.EndError(err).Total(), err)
+			return nil, err
+		}
+	}
+
+	p := KeyValuePairs{}
+	for key, e := range c.store.entries {
+		if strings.HasPrefix(key, prefix) {
+			p[key] = Value{
+				Data:        copyBytes(e.value),
+				ModRevision: e.modRevision,
+			}
+		}
+	}
+	increaseMetric(prefix, metricRead, "ListPrefix", duration.End(true).Total(), nil)
+	return p, nil
+}
+
+// Watch starts watching for changes in a prefix. The keys currently matching
+// the prefix are reported first, followed by EventTypeListDone. Watch blocks
+// until the watcher is stopped or the client is closed.
+func (c *memoryClient) Watch(w *Watcher) {
+	mw := &memoryWatch{
+		watcher: w,
+		wakeup:  make(chan struct{}, 1),
+	}
+
+	s := c.store
+	s.mutex.Lock()
+	for _, key := range s.sortedKeysLocked(w.prefix) {
+		mw.queue = append(mw.queue, KeyValueEvent{
+			Typ:   EventTypeCreate,
+			Key:   key,
+			Value: copyBytes(s.entries[key].value),
+		})
+	}
+	mw.queue = append(mw.queue, KeyValueEvent{Typ: EventTypeListDone})
+	s.watchers[mw] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.watchers, mw)
+		s.mutex.Unlock()
+
+		close(w.Events)
+		w.stopWait.Done()
+	}()
+
+	for {
+		for {
+			event, ok := mw.dequeue()
+			if !ok {
+				break
+			}
+
+			queueStart := spanstat.Start()
+			select {
+			case w.Events <- event:
+			case <-w.stopWatch:
+				return
+			case <-c.closed:
+				return
+			}
+			if event.Typ != EventTypeListDone {
+				trackEventQueued(event.Key, event.Typ, queueStart.End(true).Total())
+			}
+		}
+
+		select {
+		case <-mw.wakeup:
+		case <-w.stopWatch:
+			return
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// ListAndWatch implements the BackendOperations.ListAndWatch using the
+// in-memory kvstore
+func (c *memoryClient) ListAndWatch(name, prefix string, chanSize int) *Watcher {
+	w := newWatcher(name, prefix, chanSize)
+
+	log.WithField(fieldWatcher, w).Debug("Starting watcher...")
+
+	go c.Watch(w)
+
+	return w
+}
+
+// Close closes the client. All keys attached to the lease of the client are
+// deleted, all locks held by the client are released and all watchers of the
+// client are stopped. Pending changes are written to the file of the store.
+func (c *memoryClient) Close() {
+	c.closeOnce.Do(func() {
+		s := c.store
+		s.mutex.Lock()
+		for _, key := range s.sortedKeysLocked("") {
+			if s.entries[key].lease == c.lease {
+				s.deleteLocked(key)
+				// Leased keys are not persisted but the
+				// revision is
+				s.dirty = true
+			}
+		}
+		for _, ml := range s.locks {
+			if ml.client == c {
+				ml.releaseLocked()
+			}
+		}
+		s.mutex.Unlock()
+
+		close(c.closed)
+		s.persist()
+	})
+}
+
+// GetCapabilities returns the capabilities of the backend
+func (c *memoryClient) GetCapabilities() Capabilities {
+	return Capabilities(CapabilityCreateIfExists)
+}
+
+// Encode encodes a binary slice into a character set that the backend supports
+func (c *memoryClient) Encode(in []byte) string {
+	return string(in)
+}
+
+// Decode decodes a key previously encoded back into the original binary slice
+func (c *memoryClient) Decode(in string) ([]byte, error) {
+	return []byte(in), nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package kvstore
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cilium/cilium/pkg/checker"
+	"github.com/cilium/cilium/pkg/testutils"
+
+	. "gopkg.in/check.v1"
+)
+
+type MemorySuite struct {
+	BaseTests
+}
+
+var _ = Suite(&MemorySuite{})
+
+func (s *MemorySuite) SetUpTest(c *C) {
+	SetupDummy("memory")
+}
+
+func (s *MemorySuite) TearDownTest(c *C) {
+	Close()
+}
+
+func newTestMemoryClient(c *C, opts map[string]string) BackendOperations {
+	client, errChan := NewClient(memoryName, opts, nil)
+	c.Assert(client, Not(IsNil))
+	c.Assert(<-errChan, IsNil)
+	return client
+}
+
+func (s *MemorySuite) TestLease(c *C) {
+	client1 := newTestMemoryClient(c, nil)
+	client2 := newTestMemoryClient(c, nil)
+	defer client2.Close()
+	defer client2.DeletePrefix("lease-test/")
+
+	c.Assert(client1.Update(context.Background(), "lease-test/leased", []byte("1"), true), IsNil)
+	c.Assert(client1.Update(context.Background(), "lease-test/unleased", []byte("2"), false), IsNil)
+
+	// Attaching a lease is an update even if the value is the same
+	updated, err := client1.UpdateIfDifferent(context.Background(), "lease-test/unleased", []byte("2"), false)
+	c.Assert(err, IsNil)
+	c.Assert(updated, Equals, false)
+	updated, err = client2.UpdateIfDifferent(context.Background(), "lease-test/other", []byte("3"), true)
+	c.Assert(err, IsNil)
+	c.Assert(updated, Equals, true)
+
+	w := client2.ListAndWatch("lease-test", "lease-test/", 10)
+	defer w.Stop()
+	expectEvent(c, w, EventTypeCreate, "lease-test/leased", []byte("1"))
+	expectEvent(c, w, EventTypeCreate, "lease-test/other", []byte("3"))
+	expectEvent(c, w, EventTypeCreate, "lease-test/unleased", []byte("2"))
+	expectEvent(c, w, EventTypeListDone, "", nil)
+
+	// Closing the client expires its lease and deletes the leased keys
+	client1.Close()
+	expectEvent(c, w, EventTypeDelete, "lease-test/leased", []byte("1"))
+
+	pairs, err := client2.ListPrefix("lease-test/")
+	c.Assert(err, IsNil)
+	c.Assert(pairs, HasLen, 2)
+	c.Assert(pairs["lease-test/unleased"].Data, checker.DeepEquals, []byte("2"))
+	c.Assert(pairs["lease-test/other"].Data, checker.DeepEquals, []byte("3"))
+}
+
+func (s *MemorySuite) TestLockPath(c *C) {
+	client1 := newTestMemoryClient(c, nil)
+	client2 := newTestMemoryClient(c, nil)
+	defer client2.Close()
+
+	lock1, err := client1.LockPath(context.Background(), "lock-test/foo")
+	c.Assert(err, IsNil)
+
+	// The lock is held, the second attempt must time out
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	_, err = client2.LockPath(ctx, "lock-test/foo")
+	cancel()
+	c.Assert(err, Not(IsNil))
+
+	c.Assert(client1.Set("lock-test/key", []byte("1")), IsNil)
+	val, err := client1.GetIfLocked("lock-test/key", lock1)
+	c.Assert(err, IsNil)
+	c.Assert(val, checker.DeepEquals, []byte("1"))
+
+	// Closing the client releases the lock, operations requiring the
+	// lock must fail afterwards
+	acquired := make(chan KVLocker)
+	go func() {
+		lock2, err := client2.LockPath(context.Background(), "lock-test/foo")
+		c.Assert(err, IsNil)
+		acquired <- lock2
+	}()
+	client1.Close()
+
+	var lock2 KVLocker
+	select {
+	case lock2 = <-acquired:
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout while waiting for lock")
+	}
+
+	_, err = client2.GetIfLocked("lock-test/key", lock1)
+	c.Assert(err, Equals, ErrLockLeaseExpired)
+	success, err := client2.CreateOnlyIfLocked(context.Background(), "lock-test/key2", []byte("2"), false, lock1)
+	c.Assert(err, Equals, ErrLockLeaseExpired)
+	c.Assert(success, Equals, false)
+	c.Assert(client2.DeleteIfLocked("lock-test/key", lock2), IsNil)
+	c.Assert(lock2.Unlock(), IsNil)
+}
+
+func (s *MemorySuite) TestCloseStopsWatchers(c *C) {
+	client := newTestMemoryClient(c, nil)
+
+	w := client.ListAndWatch("close-test", "close-test/", 10)
+	defer w.Stop()
+	expectEvent(c, w, EventTypeListDone, "", nil)
+
+	// Closing the client stops the watcher and closes its channel
+	client.Close()
+	select {
+	case _, ok := <-w.Events:
+		c.Assert(ok, Equals, false)
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout while waiting for watcher to stop")
+	}
+}
+
+func (s *MemorySuite) TestPersistence(c *C) {
+	dir, err := ioutil.TempDir("", "cilium-kvstore-memory")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kvstore.json")
+	opts := map[string]string{memoryPathOption: path}
+
+	client := newTestMemoryClient(c, opts)
+	c.Assert(client.Set("persist-test/foo", []byte("foo")), IsNil)
+
+	// Changes are written to the file in the background
+	c.Assert(testutils.WaitUntil(func() bool {
+		b, err := ioutil.ReadFile(path)
+		return err == nil && bytes.Contains(b, []byte("persist-test/foo"))
+	}, 5*time.Second), IsNil)
+
+	c.Assert(client.Update(context.Background(), "persist-test/leased", []byte("bar"), true), IsNil)
+	c.Assert(client.Set("persist-test/deleted", []byte("baz")), IsNil)
+	c.Assert(client.Delete("persist-test/deleted"), IsNil)
+	client.Close()
+
+	// Drop the store from memory to force it to be loaded from the file
+	memoryStoresMutex.Lock()
+	for p := range memoryStores {
+		if p != "" {
+			delete(memoryStores, p)
+		}
+	}
+	memoryStoresMutex.Unlock()
+
+	client = newTestMemoryClient(c, opts)
+	defer client.Close()
+	pairs, err := client.ListPrefix("persist-test/")
+	c.Assert(err, IsNil)
+	c.Assert(pairs, HasLen, 1)
+	c.Assert(pairs["persist-test/foo"].Data, checker.DeepEquals, []byte("foo"))
+
+	// Revisions continue where they left off, including the deletion of
+	// the leased key on close
+	c.Assert(client.Set("persist-test/foo", []byte("foo2")), IsNil)
+	pairs, err = client.ListPrefix("persist-test/")
+	c.Assert(err, IsNil)
+	c.Assert(pairs["persist-test/foo"].ModRevision, Equals, uint64(6))
+
+	// The anonymous store is not affected
+	val, err := Get("persist-test/foo")
+	c.Assert(err, IsNil)
+	c.Assert(val, IsNil)
+}
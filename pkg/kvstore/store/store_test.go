@@ -56,6 +56,21 @@ func (e *StoreEtcdSuite) TearDownTest(c *C) {
 	kvstore.Close()
 }
 
+type StoreMemorySuite struct {
+	StoreSuite
+}
+
+var _ = Suite(&StoreMemorySuite{})
+
+func (e *StoreMemorySuite) SetUpTest(c *C) {
+	kvstore.SetupDummy("memory")
+}
+
+func (e *StoreMemorySuite) TearDownTest(c *C) {
+	kvstore.DeletePrefix(testPrefix)
+	kvstore.Close()
+}
+
 type StoreConsulSuite struct {
 	StoreSuite
 }
@@ -0,0 +1,110 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ClusterAffinity selects the clusters of which the backends are used by a
+// global service
+type ClusterAffinity string
+
+const (
+	// ClusterAffinityNone uses the backends of all clusters
+	ClusterAffinityNone = ClusterAffinity("none")
+
+	// ClusterAffinityLocalPreferred uses the backends of the local cluster
+	// as long as it has any, and the backends of all remote clusters
+	// otherwise.
+	ClusterAffinityLocalPreferred = ClusterAffinity("local-preferred")
+
+	// ClusterAffinityRemoteOnly only uses the backends of remote clusters,
+	// even if the local cluster has backends.
+	ClusterAffinityRemoteOnly = ClusterAffinity("remote-only")
+
+	// ClusterAffinityFailover uses the backends of the local cluster as
+	// long as it has any, and fails over to the backends of a single
+	// remote cluster otherwise, the first cluster with backends in name
+	// order. As the order does not depend on the node, all nodes fail over
+	// to the same cluster.
+	ClusterAffinityFailover = ClusterAffinity("failover")
+)
+
+// ParseClusterAffinity parses the name of a cluster affinity. An empty name
+// selects ClusterAffinityNone.
+func ParseClusterAffinity(name string) (ClusterAffinity, error) {
+	switch strings.ToLower(name) {
+	case "", string(ClusterAffinityNone):
+		return ClusterAffinityNone, nil
+	case string(ClusterAffinityLocalPreferred):
+		return ClusterAffinityLocalPreferred, nil
+	case string(ClusterAffinityRemoteOnly):
+		return ClusterAffinityRemoteOnly, nil
+	case string(ClusterAffinityFailover):
+		return ClusterAffinityFailover, nil
+	default:
+		return "", fmt.Errorf("unknown cluster affinity \"%s\"", name)
+	}
+}
+
+// SelectClusters returns the names of the clusters of which the backends are
+// used by a global service with the affinity, sorted by name. backends maps
+// the name of each cluster, including the local cluster, to its number of
+// healthy backends. Clusters without backends are never selected.
+func (a ClusterAffinity) SelectClusters(localCluster string, backends map[string]int) []string {
+	var remote []string
+	for cluster, n := range backends {
+		if cluster != localCluster && n > 0 {
+			remote = append(remote, cluster)
+		}
+	}
+	sort.Strings(remote)
+
+	hasLocal := backends[localCluster] > 0
+
+	switch a {
+	case ClusterAffinityRemoteOnly:
+		return remote
+
+	case ClusterAffinityLocalPreferred, ClusterAffinityFailover:
+		if hasLocal {
+			return []string{localCluster}
+		}
+		if a == ClusterAffinityFailover && len(remote) > 1 {
+			return remote[:1]
+		}
+		return remote
+
+	default:
+		if hasLocal {
+			remote = append(remote, localCluster)
+			sort.Strings(remote)
+		}
+		return remote
+	}
+}
+
+// ClusterSelection is the selection of the clusters of which the backends
+// are used by a global service
+type ClusterSelection struct {
+	// Affinity is the cluster affinity of the service
+	Affinity ClusterAffinity
+
+	// Clusters are the names of the selected clusters, sorted by name
+	Clusters []string
+}
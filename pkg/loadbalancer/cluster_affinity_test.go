@@ -0,0 +1,66 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package loadbalancer
+
+import (
+	"gopkg.in/check.v1"
+)
+
+func (s *TypesSuite) TestParseClusterAffinity(c *check.C) {
+	for name, expected := range map[string]ClusterAffinity{
+		"":                ClusterAffinityNone,
+		"none":            ClusterAffinityNone,
+		"local-preferred": ClusterAffinityLocalPreferred,
+		"Remote-Only":     ClusterAffinityRemoteOnly,
+		"failover":        ClusterAffinityFailover,
+	} {
+		affinity, err := ParseClusterAffinity(name)
+		c.Assert(err, check.IsNil)
+		c.Assert(affinity, check.Equals, expected)
+	}
+
+	_, err := ParseClusterAffinity("local-only")
+	c.Assert(err, check.Not(check.IsNil))
+}
+
+func (s *TypesSuite) TestSelectClusters(c *check.C) {
+	allHealthy := map[string]int{"local": 2, "c2": 1, "c1": 3}
+	localDown := map[string]int{"local": 0, "c2": 1, "c1": 3}
+	remoteDown := map[string]int{"local": 2, "c2": 0}
+
+	tests := []struct {
+		affinity ClusterAffinity
+		backends map[string]int
+		expected []string
+	}{
+		{ClusterAffinityNone, allHealthy, []string{"c1", "c2", "local"}},
+		{ClusterAffinityNone, localDown, []string{"c1", "c2"}},
+		{ClusterAffinityNone, remoteDown, []string{"local"}},
+		{ClusterAffinityLocalPreferred, allHealthy, []string{"local"}},
+		{ClusterAffinityLocalPreferred, localDown, []string{"c1", "c2"}},
+		{ClusterAffinityRemoteOnly, allHealthy, []string{"c1", "c2"}},
+		{ClusterAffinityRemoteOnly, remoteDown, nil},
+		{ClusterAffinityFailover, allHealthy, []string{"local"}},
+		{ClusterAffinityFailover, localDown, []string{"c1"}},
+		{ClusterAffinityFailover, map[string]int{"c2": 1}, []string{"c2"}},
+	}
+
+	for _, test := range tests {
+		c.Assert(test.affinity.SelectClusters("local", test.backends), check.DeepEquals, test.expected,
+			check.Commentf("affinity %s, backends %v", test.affinity, test.backends))
+	}
+}
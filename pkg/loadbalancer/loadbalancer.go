@@ -54,6 +54,34 @@ var (
 // L4Type name.
 type L4Type string
 
+// LBAlgorithm is the algorithm used to select the backend of a new
+// connection to a service
+type LBAlgorithm string
+
+const (
+	// LBAlgorithmRandom selects a backend based on the hash of the
+	// packet. Adding or removing a backend reshuffles the selection of
+	// all connections without a connection tracking entry.
+	LBAlgorithmRandom = LBAlgorithm("random")
+
+	// LBAlgorithmMaglev selects a backend via a Maglev lookup table.
+	// Adding or removing a backend only changes the selection of a small
+	// fraction of the connections.
+	LBAlgorithmMaglev = LBAlgorithm("maglev")
+)
+
+// ParseLBAlgorithm parses the name of a load-balancing algorithm
+func ParseLBAlgorithm(name string) (LBAlgorithm, error) {
+	switch strings.ToLower(name) {
+	case string(LBAlgorithmRandom):
+		return LBAlgorithmRandom, nil
+	case string(LBAlgorithmMaglev):
+		return LBAlgorithmMaglev, nil
+	default:
+		return "", fmt.Errorf("unknown load-balancing algorithm \"%s\"", name)
+	}
+}
+
 // FEPortName is the name of the frontend's port.
 type FEPortName string
 
@@ -82,6 +110,19 @@ type LBSVC struct {
 	Sha256 string
 	FE     L3n4AddrID
 	BES    []LBBackEnd
+
+	// Algorithm is the algorithm used to select the backend of a new
+	// connection
+	Algorithm LBAlgorithm
+
+	// SessionAffinityTimeoutSec is the time in seconds after which an
+	// idle ClientIP session affinity of the service expires. If 0, session
+	// affinity is disabled.
+	SessionAffinityTimeoutSec uint32
+
+	// Global is the selection of the clusters providing the backends of
+	// a global service, nil if the service is not a global service.
+	Global *ClusterSelection
 }
 
 type backendPlacement struct {
@@ -111,6 +152,18 @@ func (s *LBSVC) GetModel() *models.Service {
 		spec.BackendAddresses[i] = s.BES[placement.pos].GetBackendModel()
 	}
 
+	if s.Algorithm != "" || s.SessionAffinityTimeoutSec != 0 || s.Global != nil {
+		spec.Flags = &models.ServiceSpecFlags{
+			LbAlgorithm:            string(s.Algorithm),
+			SessionAffinity:        s.SessionAffinityTimeoutSec != 0,
+			SessionAffinityTimeout: int64(s.SessionAffinityTimeoutSec),
+		}
+		if s.Global != nil {
+			spec.Flags.GlobalServiceAffinity = string(s.Global.Affinity)
+			spec.Flags.GlobalServiceClusters = s.Global.Clusters
+		}
+	}
+
 	return &models.Service{
 		Spec: spec,
 		Status: &models.ServiceStatus{
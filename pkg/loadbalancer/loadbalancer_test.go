@@ -356,3 +356,27 @@ func TestL3n4AddrID_Equals(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLBAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    LBAlgorithm
+		wantErr bool
+	}{
+		{name: "random", want: LBAlgorithmRandom},
+		{name: "Maglev", want: LBAlgorithmMaglev},
+		{name: "roundrobin", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLBAlgorithm(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLBAlgorithm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLBAlgorithm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
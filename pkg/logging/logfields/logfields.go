@@ -81,6 +81,9 @@ const (
 	// DeletedPolicyID is the .NumericIdentity, or set or them
 	DeletedPolicyID = "policyID.Deleted"
 
+	// IsDeny is true if a policy entry denies rather than allows traffic
+	IsDeny = "isDeny"
+
 	// L3PolicyID is the identifier of a L3 Policy
 	L3PolicyID = "policyID.L3"
 
@@ -0,0 +1,125 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maglev implements the lookup table generation of Maglev consistent
+// hashing as described in "Maglev: A Fast and Reliable Software Network Load
+// Balancer" (Eisenbud et al., NSDI 2016), extended to honour backend weights.
+package maglev
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+)
+
+// Backend is a backend to be placed in the lookup table
+type Backend struct {
+	// Name identifies the backend. The position of the backend in the
+	// lookup table only depends on its name, so it must be stable across
+	// table regenerations, e.g. the "IP:port" of the backend.
+	Name string
+
+	// Weight is the relative weight of the backend. Backends with a
+	// weight of 0 are not placed in the table unless all backends have a
+	// weight of 0, in which case all backends are weighted equally.
+	Weight uint16
+}
+
+// permutation returns the offset and skip of the preference list of the
+// backend with the given name in a table of size m
+func permutation(name string, m uint64) (offset, skip uint64) {
+	sum := sha256.Sum256([]byte(name))
+	offset = binary.BigEndian.Uint64(sum[0:8]) % m
+	skip = binary.BigEndian.Uint64(sum[8:16])%(m-1) + 1
+	return
+}
+
+// GetLookupTable returns the Maglev lookup table of size m for the given
+// backends. Each entry of the table holds the index of a backend in the
+// backends slice. The number of entries assigned to each backend is
+// proportional to its weight. m must be a prime number larger than the
+// number of backends, otherwise the preference lists of the backends are not
+// permutations of the table and the result is undefined.
+//
+// The table does not depend on the order of the backends. Adding or removing
+// a backend only changes a small fraction of the entries.
+//
+// Returns nil if there are no backends.
+func GetLookupTable(backends []Backend, m uint64) []int {
+	if len(backends) == 0 || m < 2 {
+		return nil
+	}
+
+	// Iterate over the backends ordered by name so that ties are broken
+	// in the same way regardless of the order of the backends slice
+	order := make([]int, len(backends))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return backends[order[i]].Name < backends[order[j]].Name
+	})
+
+	weights := make([]uint64, len(backends))
+	maxWeight := uint64(0)
+	for i, b := range backends {
+		weights[i] = uint64(b.Weight)
+		if weights[i] > maxWeight {
+			maxWeight = weights[i]
+		}
+	}
+	if maxWeight == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		maxWeight = 1
+	}
+
+	offsets := make([]uint64, len(backends))
+	skips := make([]uint64, len(backends))
+	for i, b := range backends {
+		offsets[i], skips[i] = permutation(b.Name, m)
+	}
+
+	table := make([]int, m)
+	for i := range table {
+		table[i] = -1
+	}
+
+	// next is the position in the preference list of each backend,
+	// credits accumulate the weight of each backend in every round. A
+	// backend claims one entry per maxWeight credits, so the backends with
+	// the highest weight claim one entry in every round.
+	next := make([]uint64, len(backends))
+	credits := make([]uint64, len(backends))
+	filled := uint64(0)
+	for filled < m {
+		for _, i := range order {
+			credits[i] += weights[i]
+			for credits[i] >= maxWeight && filled < m {
+				credits[i] -= maxWeight
+				c := (offsets[i] + next[i]*skips[i]) % m
+				for table[c] >= 0 {
+					next[i]++
+					c = (offsets[i] + next[i]*skips[i]) % m
+				}
+				table[c] = i
+				next[i]++
+				filled++
+			}
+		}
+	}
+
+	return table
+}
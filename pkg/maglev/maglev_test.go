@@ -0,0 +1,113 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package maglev
+
+import (
+	"fmt"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type MaglevSuite struct{}
+
+var _ = Suite(&MaglevSuite{})
+
+const testTableSize = 1021
+
+func newBackends(n int) []Backend {
+	backends := make([]Backend, n)
+	for i := range backends {
+		backends[i] = Backend{Name: fmt.Sprintf("10.0.0.%d:80", i+1)}
+	}
+	return backends
+}
+
+func countEntries(table []int, n int) []int {
+	counts := make([]int, n)
+	for _, i := range table {
+		counts[i]++
+	}
+	return counts
+}
+
+func (s *MaglevSuite) TestEmpty(c *C) {
+	c.Assert(GetLookupTable(nil, testTableSize), IsNil)
+}
+
+func (s *MaglevSuite) TestEvenDistribution(c *C) {
+	backends := newBackends(7)
+	table := GetLookupTable(backends, testTableSize)
+	c.Assert(len(table), Equals, testTableSize)
+
+	// Unweighted backends differ by at most one entry
+	for _, n := range countEntries(table, len(backends)) {
+		c.Assert(n == testTableSize/7 || n == testTableSize/7+1, Equals, true,
+			Commentf("unexpected number of entries %d", n))
+	}
+}
+
+func (s *MaglevSuite) TestWeights(c *C) {
+	backends := newBackends(3)
+	backends[0].Weight = 1
+	backends[1].Weight = 3
+	backends[2].Weight = 0
+
+	table := GetLookupTable(backends, testTableSize)
+	counts := countEntries(table, len(backends))
+	c.Assert(counts[2], Equals, 0)
+	c.Assert(counts[0]+counts[1], Equals, testTableSize)
+	c.Assert(counts[1] >= 3*counts[0]-3 && counts[1] <= 3*counts[0]+3, Equals, true,
+		Commentf("unexpected distribution %v", counts))
+}
+
+func (s *MaglevSuite) TestOrderIndependent(c *C) {
+	backends := newBackends(5)
+	table := GetLookupTable(backends, testTableSize)
+
+	reversed := make([]Backend, len(backends))
+	for i, b := range backends {
+		reversed[len(backends)-1-i] = b
+	}
+	reversedTable := GetLookupTable(reversed, testTableSize)
+
+	for i := range table {
+		c.Assert(backends[table[i]].Name, Equals, reversed[reversedTable[i]].Name)
+	}
+}
+
+func (s *MaglevSuite) TestMinimalDisruption(c *C) {
+	backends := newBackends(10)
+	table := GetLookupTable(backends, testTableSize)
+
+	// Remove the last backend, entries of the remaining backends must
+	// mostly stay in place
+	newTable := GetLookupTable(backends[:9], testTableSize)
+	changed := 0
+	for i := range table {
+		if table[i] != 9 && table[i] != newTable[i] {
+			changed++
+		}
+	}
+	c.Assert(changed < testTableSize/10, Equals, true,
+		Commentf("%d entries of remaining backends changed", changed))
+}
@@ -27,12 +27,14 @@ import (
 
 	"github.com/cilium/cilium/pkg/bpf"
 	"github.com/cilium/cilium/pkg/defaults"
+	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 	"github.com/cilium/cilium/pkg/maps/nat"
 	"github.com/cilium/cilium/pkg/metrics"
 	"github.com/cilium/cilium/pkg/option"
 	"github.com/cilium/cilium/pkg/tuple"
+	"github.com/cilium/cilium/pkg/u8proto"
 
 	"github.com/sirupsen/logrus"
 )
@@ -95,7 +97,9 @@ const (
 type NatMap interface {
 	Open() error
 	Close() error
-	DeleteMapping(key tuple.TupleKey) error
+	Name() string
+	DeleteMapping(key tuple.TupleKey) (int, error)
+	CountMapping(key tuple.TupleKey) int
 }
 
 type mapAttributes struct {
@@ -215,6 +219,14 @@ type GCFilter struct {
 
 	// MatchIPs is the list of IPs to remove from the conntrack table
 	MatchIPs map[string]struct{}
+
+	// Match removes all entries matching each of the criteria of the
+	// query filter, e.g. the connections of an endpoint to a CIDR
+	Match *QueryFilter
+
+	// DryRun only counts the entries which would be removed without
+	// removing them
+	DryRun bool
 }
 
 // ToString iterates through Map m and writes the values of the ct entries in m
@@ -255,12 +267,17 @@ func NewMap(mapName string, mapType MapType) *Map {
 	return result
 }
 
-func purgeCtEntry6(m *Map, key CtKey, natMap NatMap) error {
-	err := m.Delete(key)
-	if err == nil && natMap != nil {
-		natMap.DeleteMapping(key.GetTupleKey())
+// purgeCtEntry6 removes the CT entry with the given key along with its NAT
+// mapping. It returns the number of NAT entries removed.
+func purgeCtEntry6(m *Map, key CtKey, natMap NatMap) (int, error) {
+	if err := m.Delete(key); err != nil {
+		return 0, err
+	}
+	if natMap == nil {
+		return 0, nil
 	}
-	return err
+	natDeleted, _ := natMap.DeleteMapping(key.GetTupleKey())
+	return natDeleted, nil
 }
 
 // doGC6 iterates through a CTv6 map and drops entries based on the given
@@ -268,12 +285,14 @@ func purgeCtEntry6(m *Map, key CtKey, natMap NatMap) error {
 func doGC6(m *Map, filter *GCFilter) gcStats {
 	natMap := mapInfo[m.mapType].natMap
 	stats := statStartGc(m)
+	stats.dryRun = filter.DryRun
 	defer stats.finish()
 
 	if natMap != nil {
 		err := natMap.Open()
 		if err == nil {
 			defer natMap.Close()
+			stats.natMap = natMap.Name()
 		} else {
 			natMap = nil
 		}
@@ -288,16 +307,24 @@ func doGC6(m *Map, filter *GCFilter) gcStats {
 			// In CT entries, the source address of the conntrack entry (`SourceAddr`) is
 			// the destination of the packet received, therefore it's the packet's
 			// destination IP
-			action := filter.doFiltering(currentKey6Global.DestAddr.IP(), currentKey6Global.SourceAddr.IP(), currentKey6Global.SourcePort,
+			action := filter.doFiltering(currentKey6Global, currentKey6Global.DestAddr.IP(), currentKey6Global.SourceAddr.IP(), currentKey6Global.SourcePort,
 				uint8(currentKey6Global.NextHeader), currentKey6Global.Flags, entry)
 
 			switch action {
 			case deleteEntry:
-				err := purgeCtEntry6(m, currentKey6Global, natMap)
+				if filter.DryRun {
+					stats.deleted++
+					if natMap != nil {
+						stats.natDeleted += uint32(natMap.CountMapping(currentKey6Global.GetTupleKey()))
+					}
+					break
+				}
+				natDeleted, err := purgeCtEntry6(m, currentKey6Global, natMap)
 				if err != nil {
 					log.WithError(err).WithField(logfields.Key, currentKey6Global.String()).Error("Unable to delete CT entry")
 				} else {
 					stats.deleted++
+					stats.natDeleted += uint32(natDeleted)
 				}
 			default:
 				stats.aliveEntries++
@@ -307,16 +334,24 @@ func doGC6(m *Map, filter *GCFilter) gcStats {
 			// In CT entries, the source address of the conntrack entry (`SourceAddr`) is
 			// the destination of the packet received, therefore it's the packet's
 			// destination IP
-			action := filter.doFiltering(currentKey6.DestAddr.IP(), currentKey6.SourceAddr.IP(), currentKey6.SourcePort,
+			action := filter.doFiltering(currentKey6, currentKey6.DestAddr.IP(), currentKey6.SourceAddr.IP(), currentKey6.SourcePort,
 				uint8(currentKey6.NextHeader), currentKey6.Flags, entry)
 
 			switch action {
 			case deleteEntry:
-				err := purgeCtEntry6(m, currentKey6, natMap)
+				if filter.DryRun {
+					stats.deleted++
+					if natMap != nil {
+						stats.natDeleted += uint32(natMap.CountMapping(currentKey6.GetTupleKey()))
+					}
+					break
+				}
+				natDeleted, err := purgeCtEntry6(m, currentKey6, natMap)
 				if err != nil {
 					log.WithError(err).WithField(logfields.Key, currentKey6.String()).Error("Unable to delete CT entry")
 				} else {
 					stats.deleted++
+					stats.natDeleted += uint32(natDeleted)
 				}
 			default:
 				stats.aliveEntries++
@@ -330,12 +365,17 @@ func doGC6(m *Map, filter *GCFilter) gcStats {
 	return stats
 }
 
-func purgeCtEntry4(m *Map, key CtKey, natMap NatMap) error {
-	err := m.Delete(key)
-	if err == nil && natMap != nil {
-		natMap.DeleteMapping(key.GetTupleKey())
+// purgeCtEntry4 removes the CT entry with the given key along with its NAT
+// mapping. It returns the number of NAT entries removed.
+func purgeCtEntry4(m *Map, key CtKey, natMap NatMap) (int, error) {
+	if err := m.Delete(key); err != nil {
+		return 0, err
 	}
-	return err
+	if natMap == nil {
+		return 0, nil
+	}
+	natDeleted, _ := natMap.DeleteMapping(key.GetTupleKey())
+	return natDeleted, nil
 }
 
 // doGC4 iterates through a CTv4 map and drops entries based on the given
@@ -343,11 +383,13 @@ func purgeCtEntry4(m *Map, key CtKey, natMap NatMap) error {
 func doGC4(m *Map, filter *GCFilter) gcStats {
 	natMap := mapInfo[m.mapType].natMap
 	stats := statStartGc(m)
+	stats.dryRun = filter.DryRun
 	defer stats.finish()
 
 	if natMap != nil {
 		if err := natMap.Open(); err == nil {
 			defer natMap.Close()
+			stats.natMap = natMap.Name()
 		} else {
 			natMap = nil
 		}
@@ -362,16 +404,24 @@ func doGC4(m *Map, filter *GCFilter) gcStats {
 			// In CT entries, the source address of the conntrack entry (`SourceAddr`) is
 			// the destination of the packet received, therefore it's the packet's
 			// destination IP
-			action := filter.doFiltering(currentKey4Global.DestAddr.IP(), currentKey4Global.SourceAddr.IP(), currentKey4Global.SourcePort,
+			action := filter.doFiltering(currentKey4Global, currentKey4Global.DestAddr.IP(), currentKey4Global.SourceAddr.IP(), currentKey4Global.SourcePort,
 				uint8(currentKey4Global.NextHeader), currentKey4Global.Flags, entry)
 
 			switch action {
 			case deleteEntry:
-				err := purgeCtEntry4(m, currentKey4Global, natMap)
+				if filter.DryRun {
+					stats.deleted++
+					if natMap != nil {
+						stats.natDeleted += uint32(natMap.CountMapping(currentKey4Global.GetTupleKey()))
+					}
+					break
+				}
+				natDeleted, err := purgeCtEntry4(m, currentKey4Global, natMap)
 				if err != nil {
 					log.WithError(err).WithField(logfields.Key, currentKey4Global.String()).Error("Unable to delete CT entry")
 				} else {
 					stats.deleted++
+					stats.natDeleted += uint32(natDeleted)
 				}
 			default:
 				stats.aliveEntries++
@@ -381,16 +431,24 @@ func doGC4(m *Map, filter *GCFilter) gcStats {
 			// In CT entries, the source address of the conntrack entry (`SourceAddr`) is
 			// the destination of the packet received, therefore it's the packet's
 			// destination IP
-			action := filter.doFiltering(currentKey4.DestAddr.IP(), currentKey4.SourceAddr.IP(), currentKey4.SourcePort,
+			action := filter.doFiltering(currentKey4, currentKey4.DestAddr.IP(), currentKey4.SourceAddr.IP(), currentKey4.SourcePort,
 				uint8(currentKey4.NextHeader), currentKey4.Flags, entry)
 
 			switch action {
 			case deleteEntry:
-				err := purgeCtEntry4(m, currentKey4, natMap)
+				if filter.DryRun {
+					stats.deleted++
+					if natMap != nil {
+						stats.natDeleted += uint32(natMap.CountMapping(currentKey4.GetTupleKey()))
+					}
+					break
+				}
+				natDeleted, err := purgeCtEntry4(m, currentKey4, natMap)
 				if err != nil {
 					log.WithError(err).WithField(logfields.Key, currentKey4.String()).Error("Unable to delete CT entry")
 				} else {
 					stats.deleted++
+					stats.natDeleted += uint32(natDeleted)
 				}
 			default:
 				stats.aliveEntries++
@@ -404,7 +462,7 @@ func doGC4(m *Map, filter *GCFilter) gcStats {
 	return stats
 }
 
-func (f *GCFilter) doFiltering(srcIP net.IP, dstIP net.IP, dstPort uint16, nextHdr, flags uint8, entry *CtEntry) (action int) {
+func (f *GCFilter) doFiltering(key CtKey, srcIP net.IP, dstIP net.IP, dstPort uint16, nextHdr, flags uint8, entry *CtEntry) (action int) {
 	if f.RemoveExpired && entry.Lifetime < f.Time {
 		return deleteEntry
 	}
@@ -425,38 +483,192 @@ func (f *GCFilter) doFiltering(srcIP net.IP, dstIP net.IP, dstPort uint16, nextH
 		}
 	}
 
+	if f.Match != nil {
+		if e := newEntry("", key.ToHost(), entry); e != nil && f.Match.matches(e) {
+			return deleteEntry
+		}
+	}
+
 	return noAction
 }
 
-func doGC(m *Map, filter *GCFilter) int {
+func doGC(m *Map, filter *GCFilter) gcStats {
 	if m.mapType.isIPv6() {
-		return int(doGC6(m, filter).deleted)
+		return doGC6(m, filter)
 	} else if m.mapType.isIPv4() {
-		return int(doGC4(m, filter).deleted)
+		return doGC4(m, filter)
 	}
 	log.Fatalf("Unsupported ct map type: %s", m.mapType.String())
-	return 0
+	return gcStats{}
 }
 
 // GC runs garbage collection for map m with name mapType with the given filter.
 // It returns how many items were deleted from m.
 func GC(m *Map, filter *GCFilter) int {
+	return Purge(m, filter).Deleted
+}
+
+// PurgeResult is the number of entries removed by Purge, or which would be
+// removed in dry-run mode.
+type PurgeResult struct {
+	// Deleted is the number of conntrack entries removed
+	Deleted int
+
+	// NatMap is the name of the NAT map holding the NAT mappings of the
+	// connections, empty if there is none
+	NatMap string
+
+	// NatDeleted is the number of entries removed from NatMap
+	NatDeleted int
+}
+
+// Purge removes the entries of map m matching the given filter along with
+// their NAT mappings, see GC().
+func Purge(m *Map, filter *GCFilter) PurgeResult {
 	if filter.RemoveExpired {
 		t, _ := bpf.GetMtime()
 		tsec := t / 1000000000
 		filter.Time = uint32(tsec)
 	}
 
-	return doGC(m, filter)
+	stats := doGC(m, filter)
+	return PurgeResult{
+		Deleted:    int(stats.deleted),
+		NatMap:     stats.natMap,
+		NatDeleted: int(stats.natDeleted),
+	}
 }
 
 // Flush runs garbage collection for map m with the name mapType, deleting all
 // entries. The specified map must be already opened using bpf.OpenMap().
 func (m *Map) Flush() int {
-	return doGC(m, &GCFilter{
+	return int(doGC(m, &GCFilter{
 		RemoveExpired: true,
 		Time:          MaxTime,
+	}).deleted)
+}
+
+// natConnection returns the connection the given NAT entry belongs to, in
+// the direction of the original connection, along with the key of the
+// conntrack entry of the connection in the global CT maps. key and value
+// are in network byte order.
+func natConnection(key bpf.MapKey, value bpf.MapValue) (*Entry, CtKey) {
+	var ctKey CtKey
+
+	switch k := key.(type) {
+	case *nat.NatKey4:
+		t := k.TupleKey4Global
+		if t.Flags&tuple.TUPLE_F_IN != 0 {
+			// The reverse entry translates back to the original
+			// source held in the value
+			v := value.(*nat.NatEntry4)
+			t.DestAddr, t.DestPort = t.SourceAddr, t.SourcePort
+			t.SourceAddr, t.SourcePort = v.Addr, v.Port
+			t.Flags = tuple.TUPLE_F_OUT
+		}
+		// Addresses are swapped in the CT key, see issue #5848
+		t.SourceAddr, t.DestAddr = t.DestAddr, t.SourceAddr
+		ctKey = &CtKey4Global{TupleKey4Global: t}
+	case *nat.NatKey6:
+		t := k.TupleKey6Global
+		if t.Flags&tuple.TUPLE_F_IN != 0 {
+			v := value.(*nat.NatEntry6)
+			t.DestAddr, t.DestPort = t.SourceAddr, t.SourcePort
+			t.SourceAddr, t.SourcePort = v.Addr, v.Port
+			t.Flags = tuple.TUPLE_F_OUT
+		}
+		t.SourceAddr, t.DestAddr = t.DestAddr, t.SourceAddr
+		ctKey = &CtKey6Global{TupleKey6Global: t}
+	default:
+		return nil, nil
+	}
+
+	entry := newEntry("", ctKey.ToHost(), &CtEntry{})
+	if entry == nil {
+		return nil, nil
+	}
+	return entry, ctKey
+}
+
+// purgeOrphanNatEntries removes the entries of natMap matching filter whose
+// connection has no entry in ctMapTCP or ctMapAny. It returns the number of
+// entries removed, or which would be removed if dryRun is true.
+func purgeOrphanNatEntries(natMap *nat.Map, ctMapTCP, ctMapAny *Map, filter *QueryFilter, dryRun bool) (int, error) {
+	for _, m := range []*bpf.Map{&natMap.Map, &ctMapTCP.Map, &ctMapAny.Map} {
+		if err := m.Open(); err != nil {
+			if os.IsNotExist(err) {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("unable to open %s: %s", m.Name(), err)
+		}
+		defer m.Close()
+	}
+
+	var orphans []bpf.MapKey
+	err := natMap.DumpWithCallback(func(key bpf.MapKey, value bpf.MapValue) {
+		entry, ctKey := natConnection(key, value)
+		if entry == nil || !filter.matches(entry) {
+			return
+		}
+		ctMap := ctMapAny
+		if entry.Protocol == u8proto.TCP {
+			ctMap = ctMapTCP
+		}
+		if _, err := ctMap.Lookup(ctKey); err == nil {
+			return
+		}
+		orphans = append(orphans, key.DeepCopyMapKey())
 	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to dump %s: %s", natMap.Name(), err)
+	}
+
+	if dryRun {
+		return len(orphans), nil
+	}
+
+	deleted := 0
+	for _, key := range orphans {
+		if err := natMap.Delete(key); err != nil {
+			log.WithError(err).WithField(logfields.Key, key.String()).Error("Unable to delete NAT entry")
+		} else {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// PurgeOrphanNatEntries removes the entries of the global NAT maps matching
+// filter whose connection no longer has an entry in the global CT maps, e.g.
+// because the CT entry expired or was removed without its NAT mapping. It
+// returns the number of entries removed, or which would be removed if dryRun
+// is true, per NAT map. Filtering on CT flags or security identity requires
+// the CT entry, so no NAT entries are removed for such filters. If ipv4 or
+// ipv6 are false, the maps for that protocol are not scanned.
+func PurgeOrphanNatEntries(filter *QueryFilter, ipv4, ipv6, dryRun bool) (map[string]int, error) {
+	result := map[string]int{}
+	if filter.Flags != 0 || filter.SecurityID != 0 {
+		return result, nil
+	}
+
+	nat4, nat6 := nat.GlobalMaps(ipv4, ipv6)
+	if ipv4 {
+		deleted, err := purgeOrphanNatEntries(nat4, NewMap(MapNameTCP4Global, MapTypeIPv4TCPGlobal),
+			NewMap(MapNameAny4Global, MapTypeIPv4AnyGlobal), filter, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		result[nat4.Name()] = deleted
+	}
+	if ipv6 {
+		deleted, err := purgeOrphanNatEntries(nat6, NewMap(MapNameTCP6Global, MapTypeIPv6TCPGlobal),
+			NewMap(MapNameAny6Global, MapTypeIPv6AnyGlobal), filter, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		result[nat6.Name()] = deleted
+	}
+	return result, nil
 }
 
 // DeleteIfUpgradeNeeded attempts to open the conntrack maps associated with
@@ -591,11 +803,41 @@ func Exists(e CtEndpoint, ipv4, ipv6 bool) bool {
 	return result
 }
 
-var cachedGCInterval time.Duration
+var (
+	cachedGCInterval time.Duration
+
+	// lastDeleteRatio is the deletion ratio of the last garbage
+	// collection run
+	lastDeleteRatio float64
+
+	// gcIntervalMutex protects cachedGCInterval and lastDeleteRatio
+	gcIntervalMutex lock.Mutex
+)
+
+// GCStatus returns the current garbage collection interval and the maximum
+// ratio of deleted entries to map size of the last run, which the interval
+// was derived from.
+func GCStatus() (interval time.Duration, deleteRatio float64) {
+	gcIntervalMutex.Lock()
+	defer gcIntervalMutex.Unlock()
+
+	if interval = option.Config.ConntrackGCInterval; interval == time.Duration(0) {
+		if interval = cachedGCInterval; interval == time.Duration(0) {
+			interval = defaults.ConntrackGCStartingInterval
+		}
+	}
+
+	return interval, lastDeleteRatio
+}
 
 // GetInterval returns the interval adjusted based on the deletion ratio of the
 // last run
 func GetInterval(mapType bpf.MapType, maxDeleteRatio float64) (interval time.Duration) {
+	gcIntervalMutex.Lock()
+	defer gcIntervalMutex.Unlock()
+
+	lastDeleteRatio = maxDeleteRatio
+
 	if val := option.Config.ConntrackGCInterval; val != time.Duration(0) {
 		interval = val
 		return
@@ -22,10 +22,14 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/cilium/cilium/common/types"
 	"github.com/cilium/cilium/pkg/bpf"
+	"github.com/cilium/cilium/pkg/byteorder"
 	"github.com/cilium/cilium/pkg/defaults"
+	"github.com/cilium/cilium/pkg/maps/nat"
 	"github.com/cilium/cilium/pkg/option"
 	"github.com/cilium/cilium/pkg/tuple"
+	"github.com/cilium/cilium/pkg/u8proto"
 
 	. "gopkg.in/check.v1"
 )
@@ -86,3 +90,68 @@ func (t *CTMapTestSuite) TestCalculateInterval(c *C) {
 	c.Assert(calculateInterval(bpf.MapTypeLRUHash, 24*time.Hour, 0.01), Equals, defaults.ConntrackGCMaxLRUInterval)
 	c.Assert(calculateInterval(bpf.MapTypeHash, 24*time.Hour, 0.01), Equals, defaults.ConntrackGCMaxInterval)
 }
+
+func (t *CTMapTestSuite) TestNatConnection(c *C) {
+	port := func(p uint16) uint16 {
+		return byteorder.HostToNetwork(p).(uint16)
+	}
+
+	// 10.0.0.1:40000 -> 10.0.1.5:80 translated to 192.168.1.1:50000
+	out := &nat.NatKey4{
+		TupleKey4Global: tuple.TupleKey4Global{
+			TupleKey4: tuple.TupleKey4{
+				SourceAddr: types.IPv4{10, 0, 0, 1},
+				DestAddr:   types.IPv4{10, 0, 1, 5},
+				SourcePort: port(40000),
+				DestPort:   port(80),
+				NextHeader: u8proto.TCP,
+				Flags:      tuple.TUPLE_F_OUT,
+			},
+		},
+	}
+	in := &nat.NatKey4{
+		TupleKey4Global: tuple.TupleKey4Global{
+			TupleKey4: tuple.TupleKey4{
+				SourceAddr: types.IPv4{10, 0, 1, 5},
+				DestAddr:   types.IPv4{192, 168, 1, 1},
+				SourcePort: port(80),
+				DestPort:   port(50000),
+				NextHeader: u8proto.TCP,
+				Flags:      tuple.TUPLE_F_IN,
+			},
+		},
+	}
+	expectedCtKey := &CtKey4Global{
+		TupleKey4Global: tuple.TupleKey4Global{
+			TupleKey4: tuple.TupleKey4{
+				DestAddr:   types.IPv4{10, 0, 0, 1},
+				SourceAddr: types.IPv4{10, 0, 1, 5},
+				SourcePort: port(40000),
+				DestPort:   port(80),
+				NextHeader: u8proto.TCP,
+				Flags:      tuple.TUPLE_F_OUT,
+			},
+		},
+	}
+
+	entry, ctKey := natConnection(out, &nat.NatEntry4{Addr: types.IPv4{192, 168, 1, 1}, Port: port(50000)})
+	c.Assert(entry, Not(IsNil))
+	c.Assert(entry.SrcIP.String(), Equals, "10.0.0.1")
+	c.Assert(entry.DstIP.String(), Equals, "10.0.1.5")
+	c.Assert(entry.SrcPort, Equals, uint16(40000))
+	c.Assert(entry.DstPort, Equals, uint16(80))
+	c.Assert(entry.Protocol, Equals, u8proto.TCP)
+	c.Assert(ctKey, DeepEquals, expectedCtKey)
+
+	entry, ctKey = natConnection(in, &nat.NatEntry4{Addr: types.IPv4{10, 0, 0, 1}, Port: port(40000)})
+	c.Assert(entry, Not(IsNil))
+	c.Assert(entry.SrcIP.String(), Equals, "10.0.0.1")
+	c.Assert(entry.DstIP.String(), Equals, "10.0.1.5")
+	c.Assert(entry.SrcPort, Equals, uint16(40000))
+	c.Assert(entry.DstPort, Equals, uint16(80))
+	c.Assert(ctKey, DeepEquals, expectedCtKey)
+
+	entry, ctKey = natConnection(&nat.NatKey6{}, &nat.NatEntry6{})
+	c.Assert(entry, IsNil)
+	c.Assert(ctKey, IsNil)
+}
@@ -30,6 +30,13 @@ type gcStats struct {
 	// deleted is the number of keys deleted
 	deleted uint32
 
+	// natMap is the name of the NAT map associated with the CT map, if
+	// any
+	natMap string
+
+	// natDeleted is the number of keys deleted from natMap
+	natDeleted uint32
+
 	// family is the address family
 	family gcFamily
 
@@ -38,6 +45,9 @@ type gcStats struct {
 
 	// dumpError records any error that occurred during the dump.
 	dumpError error
+
+	// dryRun is true if no entries were actually deleted
+	dryRun bool
 }
 
 type gcFamily int
@@ -94,6 +104,10 @@ func statStartGc(m *Map) gcStats {
 }
 
 func (s *gcStats) finish() {
+	if s.dryRun {
+		return
+	}
+
 	duration := s.Duration()
 	family := s.family.String()
 	switch s.family {
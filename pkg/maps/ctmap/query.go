@@ -0,0 +1,347 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctmap
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cilium/cilium/pkg/bpf"
+	"github.com/cilium/cilium/pkg/tuple"
+	"github.com/cilium/cilium/pkg/u8proto"
+)
+
+// States of a conntrack entry as reported by Entry.State()
+const (
+	// StateExpired is the state of entries whose lifetime has passed but
+	// which have not been garbage collected yet
+	StateExpired = "expired"
+
+	// StateOpening is the state of TCP connections which have not seen
+	// a packet without SYN yet
+	StateOpening = "opening"
+
+	// StateEstablished is the state of TCP connections which are not
+	// closing
+	StateEstablished = "established"
+
+	// StateClosing is the state of TCP connections closed in one
+	// direction
+	StateClosing = "closing"
+
+	// StateClosed is the state of TCP connections closed in both
+	// directions
+	StateClosed = "closed"
+
+	// StateActive is the state of all non-TCP entries which have not
+	// expired
+	StateActive = "active"
+)
+
+// Entry is a conntrack entry decoded from a conntrack map. Addresses and
+// ports are in the direction of the original connection.
+type Entry struct {
+	CtEntry
+
+	// MapName is the name of the map the entry was found in
+	MapName string
+
+	SrcIP   net.IP
+	DstIP   net.IP
+	SrcPort uint16
+	DstPort uint16
+
+	// Protocol is the L4 protocol of the connection
+	Protocol u8proto.U8proto
+
+	// TupleFlags are the TUPLE_F_* flags of the key
+	TupleFlags uint8
+}
+
+// newEntry decodes a key in host byte order and the associated value. nil
+// is returned for empty keys.
+func newEntry(mapName string, key CtKey, value *CtEntry) *Entry {
+	var k4 *tuple.TupleKey4
+	var k6 *tuple.TupleKey6
+
+	switch k := key.(type) {
+	case *CtKey4:
+		k4 = &k.TupleKey4
+	case *CtKey4Global:
+		k4 = &k.TupleKey4
+	case *CtKey6:
+		k6 = &k.TupleKey6
+	case *CtKey6Global:
+		k6 = &k.TupleKey6
+	default:
+		return nil
+	}
+
+	entry := &Entry{
+		CtEntry:    *value,
+		MapName:    mapName,
+		TupleFlags: key.GetFlags(),
+	}
+
+	// Addresses are swapped in the key, see issue #5848
+	if k4 != nil {
+		entry.SrcIP, entry.DstIP = k4.DestAddr.IP(), k4.SourceAddr.IP()
+		entry.SrcPort, entry.DstPort = k4.SourcePort, k4.DestPort
+		entry.Protocol = k4.NextHeader
+	} else {
+		entry.SrcIP, entry.DstIP = k6.DestAddr.IP(), k6.SourceAddr.IP()
+		entry.SrcPort, entry.DstPort = k6.SourcePort, k6.DestPort
+		entry.Protocol = k6.NextHeader
+	}
+
+	if entry.Protocol == 0 {
+		return nil
+	}
+
+	return entry
+}
+
+// Remaining returns the number of seconds until the entry expires relative
+// to the BPF monotonic time now in seconds, 0 if the entry has expired.
+func (e *Entry) Remaining(now uint32) uint32 {
+	if e.Lifetime < now {
+		return 0
+	}
+	return e.Lifetime - now
+}
+
+// State returns the connection state of the entry relative to the BPF
+// monotonic time now in seconds
+func (e *Entry) State(now uint32) string {
+	switch {
+	case e.Lifetime < now:
+		return StateExpired
+	case e.Protocol != u8proto.TCP:
+		return StateActive
+	case e.Flags&(RxClosing|TxClosing) == RxClosing|TxClosing:
+		return StateClosed
+	case e.Flags&(RxClosing|TxClosing) != 0:
+		return StateClosing
+	case e.Flags&SeenNonSyn != 0:
+		return StateEstablished
+	default:
+		return StateOpening
+	}
+}
+
+// FlagNames returns the names of the flags set in the entry
+func (e *Entry) FlagNames() []string {
+	var names []string
+	for _, f := range entryFlags {
+		if e.Flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// ParseFlags parses a comma separated list of CtEntry flag names, e.g.
+// "RxClosing,SeenNonSyn", into a flag mask. Names are case insensitive.
+func ParseFlags(s string) (uint16, error) {
+	var flags uint16
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, f := range entryFlags {
+			if strings.EqualFold(f.name, name) {
+				flags |= f.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown conntrack flag %q", name)
+		}
+	}
+
+	return flags, nil
+}
+
+// QueryFilter selects the entries returned by Query(). Fields left at
+// their zero value match all entries.
+type QueryFilter struct {
+	// SrcCIDR and DstCIDR match the source and destination address of
+	// the connection
+	SrcCIDR *net.IPNet
+	DstCIDR *net.IPNet
+
+	// Port matches either the source or the destination port. If EndPort
+	// is set, ports in the range from Port to EndPort match.
+	Port    uint16
+	EndPort uint16
+
+	// Protocol matches the L4 protocol of the connection
+	Protocol u8proto.U8proto
+
+	// Flags is a mask of CtEntry flags which must all be set
+	Flags uint16
+
+	// SecurityID matches the source security identity of the entry
+	SecurityID uint32
+
+	// MatchIPs restricts the entries to connections for which the
+	// source or destination IP is one of the IPs. The key is the IP in
+	// string form: net.IP.String()
+	MatchIPs map[string]struct{}
+}
+
+func (f *QueryFilter) portMatches(port uint16) bool {
+	if f.EndPort == 0 {
+		return port == f.Port
+	}
+	return port >= f.Port && port <= f.EndPort
+}
+
+func (f *QueryFilter) matches(e *Entry) bool {
+	if f.SrcCIDR != nil && !f.SrcCIDR.Contains(e.SrcIP) {
+		return false
+	}
+
+	if f.DstCIDR != nil && !f.DstCIDR.Contains(e.DstIP) {
+		return false
+	}
+
+	if f.Port != 0 && !f.portMatches(e.SrcPort) && !f.portMatches(e.DstPort) {
+		return false
+	}
+
+	if f.Protocol != u8proto.ANY && e.Protocol != f.Protocol {
+		return false
+	}
+
+	if e.Flags&f.Flags != f.Flags {
+		return false
+	}
+
+	if f.SecurityID != 0 && e.SourceSecurityID != f.SecurityID {
+		return false
+	}
+
+	if f.MatchIPs != nil {
+		_, srcIPExists := f.MatchIPs[e.SrcIP.String()]
+		_, dstIPExists := f.MatchIPs[e.DstIP.String()]
+		if !srcIPExists && !dstIPExists {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Query iterates over the entries of m matching filter and calls cb with
+// each of them. The specified map must be already opened using
+// bpf.OpenMap().
+func (m *Map) Query(filter *QueryFilter, cb func(*Entry)) error {
+	return m.DumpWithCallback(func(k bpf.MapKey, v bpf.MapValue) {
+		entry := newEntry(m.Name(), k.(CtKey).ToHost(), v.(*CtEntry))
+		if entry != nil && filter.matches(entry) {
+			cb(entry)
+		}
+	})
+}
+
+// MapStatistics is the number of entries of a conntrack map
+type MapStatistics struct {
+	Name       string
+	Entries    int
+	MaxEntries int
+}
+
+// FillRatio returns the ratio of entries to the maximum number of entries
+// of the map
+func (s *MapStatistics) FillRatio() float64 {
+	if s.MaxEntries == 0 {
+		return 0
+	}
+	return float64(s.Entries) / float64(s.MaxEntries)
+}
+
+// Statistics aggregates the entries of conntrack maps
+type Statistics struct {
+	// Entries is the total number of entries
+	Entries int
+
+	// PerEndpoint is the number of entries per endpoint ID. Entries
+	// which could not be attributed to an endpoint are not counted.
+	PerEndpoint map[uint16]int
+
+	// PerProtocol is the number of entries per L4 protocol name
+	PerProtocol map[string]int
+
+	// PerState is the number of entries per state as returned by
+	// Entry.State()
+	PerState map[string]int
+
+	// Maps contains the statistics of each map collected
+	Maps []MapStatistics
+}
+
+// NewStatistics returns empty conntrack statistics
+func NewStatistics() *Statistics {
+	return &Statistics{
+		PerEndpoint: map[uint16]int{},
+		PerProtocol: map[string]int{},
+		PerState:    map[string]int{},
+	}
+}
+
+// Add accounts entry to the statistics relative to the BPF monotonic time
+// now in seconds. endpointID is the ID of the endpoint the entry belongs
+// to, 0 if unknown.
+func (s *Statistics) Add(entry *Entry, now uint32, endpointID uint16) {
+	s.Entries++
+	if endpointID != 0 {
+		s.PerEndpoint[endpointID]++
+	}
+	s.PerProtocol[entry.Protocol.String()]++
+	s.PerState[entry.State(now)]++
+}
+
+// Collect accounts all entries of m to the statistics relative to the BPF
+// monotonic time now in seconds. endpointID returns the ID of the endpoint
+// an entry belongs to, 0 if unknown. The specified map must be already
+// opened using bpf.OpenMap().
+func (s *Statistics) Collect(m *Map, now uint32, endpointID func(*Entry) uint16) error {
+	ms := MapStatistics{
+		Name:       m.Name(),
+		MaxEntries: int(m.MapInfo.MaxEntries),
+	}
+	err := m.Query(&QueryFilter{}, func(entry *Entry) {
+		ms.Entries++
+		s.Add(entry, now, endpointID(entry))
+	})
+	s.Maps = append(s.Maps, ms)
+	return err
+}
+
+// Now returns the BPF monotonic time in seconds as used for the lifetime of
+// conntrack entries
+func Now() (uint32, error) {
+	t, err := bpf.GetMtime()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(t / 1000000000), nil
+}
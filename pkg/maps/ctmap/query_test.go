@@ -0,0 +1,157 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package ctmap
+
+import (
+	"net"
+
+	"github.com/cilium/cilium/common/types"
+	"github.com/cilium/cilium/pkg/tuple"
+	"github.com/cilium/cilium/pkg/u8proto"
+
+	. "gopkg.in/check.v1"
+)
+
+func (t *CTMapTestSuite) TestNewEntry(c *C) {
+	key := &CtKey4Global{
+		TupleKey4Global: tuple.TupleKey4Global{
+			TupleKey4: tuple.TupleKey4{
+				DestAddr:   types.IPv4{10, 0, 0, 1},
+				SourceAddr: types.IPv4{10, 0, 0, 2},
+				SourcePort: 40000,
+				DestPort:   80,
+				NextHeader: u8proto.TCP,
+				Flags:      TUPLE_F_OUT,
+			},
+		},
+	}
+	entry := newEntry("cilium_ct4_global", key, &CtEntry{Lifetime: 100, Flags: SeenNonSyn})
+	c.Assert(entry, Not(IsNil))
+	c.Assert(entry.MapName, Equals, "cilium_ct4_global")
+	c.Assert(entry.SrcIP.String(), Equals, "10.0.0.1")
+	c.Assert(entry.DstIP.String(), Equals, "10.0.0.2")
+	c.Assert(entry.SrcPort, Equals, uint16(40000))
+	c.Assert(entry.DstPort, Equals, uint16(80))
+	c.Assert(entry.Protocol, Equals, u8proto.TCP)
+	c.Assert(entry.TupleFlags, Equals, uint8(TUPLE_F_OUT))
+	c.Assert(entry.FlagNames(), DeepEquals, []string{"SeenNonSyn"})
+
+	c.Assert(newEntry("cilium_ct6_global", &CtKey6Global{}, &CtEntry{}), IsNil)
+}
+
+func (t *CTMapTestSuite) TestEntryState(c *C) {
+	tcp := func(lifetime uint32, flags uint16) *Entry {
+		return &Entry{CtEntry: CtEntry{Lifetime: lifetime, Flags: flags}, Protocol: u8proto.TCP}
+	}
+
+	c.Assert(tcp(10, SeenNonSyn).State(20), Equals, StateExpired)
+	c.Assert(tcp(30, 0).State(20), Equals, StateOpening)
+	c.Assert(tcp(30, SeenNonSyn).State(20), Equals, StateEstablished)
+	c.Assert(tcp(30, SeenNonSyn|RxClosing).State(20), Equals, StateClosing)
+	c.Assert(tcp(30, SeenNonSyn|RxClosing|TxClosing).State(20), Equals, StateClosed)
+
+	udp := &Entry{CtEntry: CtEntry{Lifetime: 30}, Protocol: u8proto.UDP}
+	c.Assert(udp.State(20), Equals, StateActive)
+	c.Assert(udp.Remaining(20), Equals, uint32(10))
+	c.Assert(udp.Remaining(40), Equals, uint32(0))
+}
+
+func (t *CTMapTestSuite) TestParseFlags(c *C) {
+	flags, err := ParseFlags("RxClosing, seennonsyn")
+	c.Assert(err, IsNil)
+	c.Assert(flags, Equals, uint16(RxClosing|SeenNonSyn))
+
+	flags, err = ParseFlags("")
+	c.Assert(err, IsNil)
+	c.Assert(flags, Equals, uint16(0))
+
+	_, err = ParseFlags("RxClosing,Foo")
+	c.Assert(err, Not(IsNil))
+}
+
+func (t *CTMapTestSuite) TestQueryFilter(c *C) {
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/24")
+	entry := &Entry{
+		CtEntry:  CtEntry{Flags: SeenNonSyn | TxClosing, SourceSecurityID: 1000},
+		SrcIP:    net.ParseIP("10.0.0.1"),
+		DstIP:    net.ParseIP("192.168.1.1"),
+		SrcPort:  40000,
+		DstPort:  53,
+		Protocol: u8proto.UDP,
+	}
+
+	c.Assert((&QueryFilter{}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{SrcCIDR: cidr}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{DstCIDR: cidr}).matches(entry), Equals, false)
+	c.Assert((&QueryFilter{Port: 53}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{Port: 40000}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{Port: 80}).matches(entry), Equals, false)
+	c.Assert((&QueryFilter{Protocol: u8proto.UDP}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{Protocol: u8proto.TCP}).matches(entry), Equals, false)
+	c.Assert((&QueryFilter{Flags: TxClosing}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{Flags: TxClosing | RxClosing}).matches(entry), Equals, false)
+	c.Assert((&QueryFilter{Port: 50, EndPort: 60}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{Port: 60, EndPort: 39999}).matches(entry), Equals, false)
+	c.Assert((&QueryFilter{SecurityID: 1000}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{SecurityID: 1001}).matches(entry), Equals, false)
+	c.Assert((&QueryFilter{MatchIPs: map[string]struct{}{"192.168.1.1": {}}}).matches(entry), Equals, true)
+	c.Assert((&QueryFilter{MatchIPs: map[string]struct{}{"10.0.0.2": {}}}).matches(entry), Equals, false)
+}
+
+func (t *CTMapTestSuite) TestStatistics(c *C) {
+	stats := NewStatistics()
+	stats.Add(&Entry{CtEntry: CtEntry{Lifetime: 30, Flags: SeenNonSyn}, Protocol: u8proto.TCP}, 20, 1)
+	stats.Add(&Entry{CtEntry: CtEntry{Lifetime: 30}, Protocol: u8proto.UDP}, 20, 1)
+	stats.Add(&Entry{CtEntry: CtEntry{Lifetime: 10}, Protocol: u8proto.UDP}, 20, 0)
+
+	c.Assert(stats.Entries, Equals, 3)
+	c.Assert(stats.PerEndpoint, DeepEquals, map[uint16]int{1: 2})
+	c.Assert(stats.PerProtocol, DeepEquals, map[string]int{"TCP": 1, "UDP": 2})
+	c.Assert(stats.PerState, DeepEquals, map[string]int{StateEstablished: 1, StateActive: 1, StateExpired: 1})
+
+	ms := MapStatistics{Entries: 25, MaxEntries: 100}
+	c.Assert(ms.FillRatio(), Equals, 0.25)
+}
+
+func (t *CTMapTestSuite) TestGCFilterMatch(c *C) {
+	key := &CtKey4Global{
+		TupleKey4Global: tuple.TupleKey4Global{
+			TupleKey4: tuple.TupleKey4{
+				DestAddr:   types.IPv4{10, 0, 0, 1},
+				SourceAddr: types.IPv4{10, 0, 0, 2},
+				SourcePort: 40000,
+				DestPort:   80,
+				NextHeader: u8proto.TCP,
+				Flags:      TUPLE_F_OUT,
+			},
+		},
+	}
+	// Keys are dumped from the map in network byte order
+	netKey := key.ToNetwork()
+	entry := &CtEntry{Lifetime: 100, SourceSecurityID: 1000}
+	filter := func(f *GCFilter) int {
+		return f.doFiltering(netKey, key.DestAddr.IP(), key.SourceAddr.IP(), key.SourcePort,
+			uint8(key.NextHeader), key.Flags, entry)
+	}
+
+	_, cidr, _ := net.ParseCIDR("10.0.0.2/32")
+	c.Assert(filter(&GCFilter{}), Equals, noAction)
+	c.Assert(filter(&GCFilter{Match: &QueryFilter{DstCIDR: cidr, Port: 80}}), Equals, deleteEntry)
+	c.Assert(filter(&GCFilter{Match: &QueryFilter{DstCIDR: cidr, Port: 443}}), Equals, noAction)
+	c.Assert(filter(&GCFilter{Match: &QueryFilter{SrcCIDR: cidr}}), Equals, noAction)
+	c.Assert(filter(&GCFilter{Match: &QueryFilter{SecurityID: 1000}}), Equals, deleteEntry)
+}
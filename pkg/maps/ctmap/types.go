@@ -495,27 +495,27 @@ const (
 	NodePort   = 1 << 5
 )
 
+// entryFlags maps the CtEntry flags to their names
+var entryFlags = []struct {
+	flag uint16
+	name string
+}{
+	{RxClosing, "RxClosing"},
+	{TxClosing, "TxClosing"},
+	{Nat64, "Nat64"},
+	{LBLoopback, "LBLoopback"},
+	{SeenNonSyn, "SeenNonSyn"},
+	{NodePort, "NodePort"},
+}
+
 func (c *CtEntry) flagsString() string {
 	var buffer bytes.Buffer
 
 	buffer.WriteString(fmt.Sprintf("Flags=%#04x [ ", c.Flags))
-	if (c.Flags & RxClosing) != 0 {
-		buffer.WriteString("RxClosing ")
-	}
-	if (c.Flags & TxClosing) != 0 {
-		buffer.WriteString("TxClosing ")
-	}
-	if (c.Flags & Nat64) != 0 {
-		buffer.WriteString("Nat64 ")
-	}
-	if (c.Flags & LBLoopback) != 0 {
-		buffer.WriteString("LBLoopback ")
-	}
-	if (c.Flags & SeenNonSyn) != 0 {
-		buffer.WriteString("SeenNonSyn ")
-	}
-	if (c.Flags & NodePort) != 0 {
-		buffer.WriteString("NodePort ")
+	for _, f := range entryFlags {
+		if (c.Flags & f.flag) != 0 {
+			buffer.WriteString(f.name + " ")
+		}
 	}
 	buffer.WriteString("]")
 	return buffer.String()
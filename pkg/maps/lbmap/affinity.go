@@ -0,0 +1,331 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lbmap
+
+import (
+	"fmt"
+	"net"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/cilium/common/types"
+	"github.com/cilium/cilium/pkg/bpf"
+	"github.com/cilium/cilium/pkg/byteorder"
+	"github.com/cilium/cilium/pkg/loadbalancer"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/option"
+)
+
+const (
+	// AffinityMaxEntries is the maximum number of client => backend
+	// mappings in each session affinity map
+	AffinityMaxEntries = 65536
+
+	// ServiceFlagSessionAffinity is set in the master service entry if
+	// connections of a client are sent to the backend selected for the
+	// previous connection of the client. The backend ID of the master
+	// service entry then holds the affinity timeout in seconds.
+	ServiceFlagSessionAffinity = 1 << 1
+)
+
+var (
+	// Affinity4Map maps IPv4 clients of a service to the backend selected
+	// for them
+	Affinity4Map = bpf.NewMap("cilium_lb4_affinity",
+		bpf.MapTypeLRUHash,
+		&Affinity4Key{},
+		int(unsafe.Sizeof(Affinity4Key{})),
+		&AffinityValue{},
+		int(unsafe.Sizeof(AffinityValue{})),
+		AffinityMaxEntries,
+		0, 0,
+		bpf.ConvertKeyValue)
+
+	// Affinity6Map maps IPv6 clients of a service to the backend selected
+	// for them
+	Affinity6Map = bpf.NewMap("cilium_lb6_affinity",
+		bpf.MapTypeLRUHash,
+		&Affinity6Key{},
+		int(unsafe.Sizeof(Affinity6Key{})),
+		&AffinityValue{},
+		int(unsafe.Sizeof(AffinityValue{})),
+		AffinityMaxEntries,
+		0, 0,
+		bpf.ConvertKeyValue)
+)
+
+// AffinityKey is the interface describing protocol independent key for the
+// session affinity maps.
+type AffinityKey interface {
+	bpf.MapKey
+
+	// Get client address
+	GetClientIP() net.IP
+
+	// Get reverse NAT identifier of the service in host byte order
+	GetRevNATID() uint16
+}
+
+// Affinity4Key must match 'struct lb4_affinity_key' in "bpf/lib/common.h".
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=github.com/cilium/cilium/pkg/bpf.MapKey
+type Affinity4Key struct {
+	ClientIP types.IPv4 `align:"client_ip"`
+	RevNATID uint16     `align:"rev_nat_id"`
+	Pad      uint16     `align:"pad"`
+}
+
+// NewAffinity4Key returns the key of the given client of the service with
+// the reverse NAT identifier revNATID
+func NewAffinity4Key(clientIP net.IP, revNATID uint16) *Affinity4Key {
+	key := Affinity4Key{
+		RevNATID: byteorder.HostToNetwork(revNATID).(uint16),
+	}
+	copy(key.ClientIP[:], clientIP.To4())
+	return &key
+}
+
+func (k *Affinity4Key) GetKeyPtr() unsafe.Pointer { return unsafe.Pointer(k) }
+func (k *Affinity4Key) NewValue() bpf.MapValue    { return &AffinityValue{} }
+func (k *Affinity4Key) GetClientIP() net.IP       { return k.ClientIP.IP() }
+
+func (k *Affinity4Key) GetRevNATID() uint16 {
+	return byteorder.NetworkToHost(k.RevNATID).(uint16)
+}
+
+func (k *Affinity4Key) String() string {
+	return fmt.Sprintf("%s (%d)", k.ClientIP, k.GetRevNATID())
+}
+
+// Affinity6Key must match 'struct lb6_affinity_key' in "bpf/lib/common.h".
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=github.com/cilium/cilium/pkg/bpf.MapKey
+type Affinity6Key struct {
+	ClientIP types.IPv6 `align:"client_ip"`
+	RevNATID uint16     `align:"rev_nat_id"`
+	Pad      uint16     `align:"pad"`
+}
+
+// NewAffinity6Key returns the key of the given client of the service with
+// the reverse NAT identifier revNATID
+func NewAffinity6Key(clientIP net.IP, revNATID uint16) *Affinity6Key {
+	key := Affinity6Key{
+		RevNATID: byteorder.HostToNetwork(revNATID).(uint16),
+	}
+	copy(key.ClientIP[:], clientIP.To16())
+	return &key
+}
+
+func (k *Affinity6Key) GetKeyPtr() unsafe.Pointer { return unsafe.Pointer(k) }
+func (k *Affinity6Key) NewValue() bpf.MapValue    { return &AffinityValue{} }
+func (k *Affinity6Key) GetClientIP() net.IP       { return k.ClientIP.IP() }
+
+func (k *Affinity6Key) GetRevNATID() uint16 {
+	return byteorder.NetworkToHost(k.RevNATID).(uint16)
+}
+
+func (k *Affinity6Key) String() string {
+	return fmt.Sprintf("%s (%d)", k.ClientIP, k.GetRevNATID())
+}
+
+// AffinityValue must match 'struct lb_affinity_val' in "bpf/lib/common.h".
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=github.com/cilium/cilium/pkg/bpf.MapValue
+type AffinityValue struct {
+	// LastUsed is the monotonic time in seconds at which the client
+	// last opened a connection to the service
+	LastUsed uint32 `align:"last_used"`
+
+	// BackendID is the backend selected for the client
+	BackendID uint32 `align:"backend_id"`
+}
+
+func (v *AffinityValue) GetValuePtr() unsafe.Pointer { return unsafe.Pointer(v) }
+
+func (v *AffinityValue) String() string {
+	return fmt.Sprintf("%d (last used %d)", v.BackendID, v.LastUsed)
+}
+
+// isAffinityStale returns true if the session affinity of a client expired
+// at time now, i.e. if the service with the given reverse NAT identifier
+// has no session affinity timeout in timeouts or if the client did not open
+// a connection for longer than the timeout.
+func isAffinityStale(timeouts map[uint16]uint32, revNATID uint16, value *AffinityValue, now uint32) bool {
+	timeout, ok := timeouts[revNATID]
+	if !ok {
+		return true
+	}
+	if now < value.LastUsed {
+		return false
+	}
+	return now-value.LastUsed > timeout
+}
+
+// affinityTimeouts returns the session affinity timeouts of all services of
+// the given services map keyed by their reverse NAT identifier
+func affinityTimeouts(svcMap *bpf.Map) (map[uint16]uint32, error) {
+	timeouts := map[uint16]uint32{}
+	err := svcMap.DumpWithCallback(func(key bpf.MapKey, value bpf.MapValue) {
+		svcKey := key.(ServiceKeyV2)
+		svcValue := value.(ServiceValueV2)
+		if svcKey.GetSlave() == 0 && svcValue.GetFlags()&ServiceFlagSessionAffinity != 0 {
+			timeouts[uint16(svcValue.GetRevNat())] = svcValue.GetSessionAffinityTimeoutSec()
+		}
+	})
+	return timeouts, err
+}
+
+// affinityEntry is an entry of a session affinity map
+type affinityEntry struct {
+	key   AffinityKey
+	value *AffinityValue
+}
+
+// dumpAffinityEntries returns the entries of affinityMap for which match
+// returns true. The session affinity maps can hold an entry per client, the
+// lbmap mutex must not be held while dumping them.
+func dumpAffinityEntries(affinityMap *bpf.Map, match func(AffinityKey, *AffinityValue) bool) []affinityEntry {
+	entries := []affinityEntry{}
+	err := affinityMap.DumpWithCallback(func(key bpf.MapKey, value bpf.MapValue) {
+		affinityKey := key.(AffinityKey)
+		affinityValue := value.(*AffinityValue)
+		if match(affinityKey, affinityValue) {
+			entries = append(entries, affinityEntry{
+				key:   key.DeepCopyMapKey().(AffinityKey),
+				value: affinityValue.DeepCopy(),
+			})
+		}
+	})
+	if err != nil {
+		log.WithError(err).WithField(logfields.BPFMapName, affinityMap.Name()).
+			Warning("Unable to dump session affinity map")
+	}
+	return entries
+}
+
+// deleteAffinityEntries removes the given entries from affinityMap. Returns
+// the number of removed entries.
+func deleteAffinityEntries(affinityMap *bpf.Map, entries []affinityEntry) int {
+	scopedLog := log.WithField(logfields.BPFMapName, affinityMap.Name())
+
+	deleted := 0
+	for _, entry := range entries {
+		if err := affinityMap.Delete(entry.key); err != nil {
+			scopedLog.WithError(err).WithField(logfields.BPFMapKey, entry.key).Debug("Unable to delete session affinity entry")
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
+
+// gcAffinityMap removes all stale entries from affinityMap which holds the
+// clients of the services in svcMap. Returns the number of removed entries.
+//
+// The candidates are collected without holding the lbmap mutex, they are
+// checked again against the services and removed with the mutex held so
+// that services do not change in between.
+func gcAffinityMap(affinityMap, svcMap *bpf.Map, now uint32) int {
+	scopedLog := log.WithField(logfields.BPFMapName, affinityMap.Name())
+
+	mutex.RLock()
+	timeouts, err := affinityTimeouts(svcMap)
+	mutex.RUnlock()
+	if err != nil {
+		scopedLog.WithError(err).Warning("Unable to dump service map, skipping session affinity garbage collection")
+		return 0
+	}
+
+	candidates := dumpAffinityEntries(affinityMap, func(key AffinityKey, value *AffinityValue) bool {
+		return isAffinityStale(timeouts, key.GetRevNATID(), value, now)
+	})
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	timeouts, err = affinityTimeouts(svcMap)
+	if err != nil {
+		scopedLog.WithError(err).Warning("Unable to dump service map, skipping session affinity garbage collection")
+		return 0
+	}
+
+	stale := make([]affinityEntry, 0, len(candidates))
+	for _, entry := range candidates {
+		if isAffinityStale(timeouts, entry.key.GetRevNATID(), entry.value, now) {
+			stale = append(stale, entry)
+		}
+	}
+	return deleteAffinityEntries(affinityMap, stale)
+}
+
+// isAffinityToBackends returns true if the session affinity of a client of
+// the service with the given reverse NAT identifier points to one of
+// backendIDs. A nil backendIDs matches all backends of the service.
+func isAffinityToBackends(key AffinityKey, value *AffinityValue, revNATID uint16, backendIDs map[loadbalancer.BackendID]struct{}) bool {
+	if key.GetRevNATID() != revNATID {
+		return false
+	}
+	if backendIDs == nil {
+		return true
+	}
+	_, ok := backendIDs[loadbalancer.BackendID(value.BackendID)]
+	return ok
+}
+
+// purgeAffinityEntries removes the session affinity entries of the service
+// with the given reverse NAT identifier which point to one of backendIDs, or
+// all entries of the service if backendIDs is nil. The datapath only checks
+// that the backend of an entry still exists, so entries of backends which
+// were removed from the service but are still in use by other services must
+// be removed. Returns the number of removed entries.
+//
+// Must be called without the lbmap mutex held, after the service has been
+// updated so that the datapath no longer creates entries for backendIDs.
+func purgeAffinityEntries(ipv6 bool, revNATID uint16, backendIDs map[loadbalancer.BackendID]struct{}) int {
+	affinityMap := Affinity4Map
+	if ipv6 {
+		affinityMap = Affinity6Map
+	}
+
+	entries := dumpAffinityEntries(affinityMap, func(key AffinityKey, value *AffinityValue) bool {
+		return isAffinityToBackends(key, value, revNATID, backendIDs)
+	})
+	return deleteAffinityEntries(affinityMap, entries)
+}
+
+// GCAffinityMaps removes the entries of the session affinity maps whose
+// service no longer has session affinity enabled or which have not been used
+// for longer than the session affinity timeout of their service. Returns the
+// number of removed entries.
+func GCAffinityMaps() int {
+	nsecNow, err := bpf.GetMtime()
+	if err != nil {
+		log.WithError(err).Warning("Unable to get monotonic time, skipping session affinity garbage collection")
+		return 0
+	}
+	now := uint32(nsecNow / uint64(time.Second))
+
+	deleted := 0
+	if option.Config.EnableIPv4 {
+		deleted += gcAffinityMap(Affinity4Map, Service4MapV2, now)
+	}
+	if option.Config.EnableIPv6 {
+		deleted += gcAffinityMap(Affinity6Map, Service6MapV2, now)
+	}
+	return deleted
+}
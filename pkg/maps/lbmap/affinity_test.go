@@ -0,0 +1,90 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package lbmap
+
+import (
+	"net"
+
+	"github.com/cilium/cilium/pkg/loadbalancer"
+
+	. "gopkg.in/check.v1"
+)
+
+func (b *LBMapTestSuite) TestAffinityKey(c *C) {
+	k4 := NewAffinity4Key(net.ParseIP("10.0.0.1"), 258)
+	c.Assert(k4.GetClientIP().String(), Equals, "10.0.0.1")
+	c.Assert(k4.GetRevNATID(), Equals, uint16(258))
+	c.Assert(k4.String(), Equals, "10.0.0.1 (258)")
+
+	k6 := NewAffinity6Key(net.ParseIP("f00d::1"), 258)
+	c.Assert(k6.GetClientIP().String(), Equals, "f00d::1")
+	c.Assert(k6.GetRevNATID(), Equals, uint16(258))
+}
+
+func (b *LBMapTestSuite) TestIsAffinityStale(c *C) {
+	timeouts := map[uint16]uint32{1: 60}
+
+	testCases := []struct {
+		name     string
+		revNATID uint16
+		lastUsed uint32
+		now      uint32
+		stale    bool
+	}{
+		{"within timeout", 1, 100, 150, false},
+		{"at timeout", 1, 100, 160, false},
+		{"expired", 1, 100, 161, true},
+		{"used after now", 1, 200, 100, false},
+		{"service without affinity", 2, 100, 100, true},
+	}
+
+	for _, tc := range testCases {
+		value := &AffinityValue{LastUsed: tc.lastUsed, BackendID: 1}
+		c.Assert(isAffinityStale(timeouts, tc.revNATID, value, tc.now), Equals, tc.stale,
+			Commentf("test case %q", tc.name))
+	}
+}
+
+func (b *LBMapTestSuite) TestIsAffinityToBackends(c *C) {
+	removed := map[loadbalancer.BackendID]struct{}{2: {}}
+
+	testCases := []struct {
+		name       string
+		key        AffinityKey
+		backendID  uint32
+		backendIDs map[loadbalancer.BackendID]struct{}
+		match      bool
+	}{
+		{"removed backend", NewAffinity4Key(net.ParseIP("10.0.0.1"), 1), 2, removed, true},
+		{"remaining backend", NewAffinity4Key(net.ParseIP("10.0.0.1"), 1), 3, removed, false},
+		{"other service", NewAffinity6Key(net.ParseIP("f00d::1"), 2), 2, removed, false},
+		{"all backends", NewAffinity6Key(net.ParseIP("f00d::1"), 1), 3, nil, true},
+	}
+
+	for _, tc := range testCases {
+		value := &AffinityValue{LastUsed: 100, BackendID: tc.backendID}
+		c.Assert(isAffinityToBackends(tc.key, value, 1, tc.backendIDs), Equals, tc.match,
+			Commentf("test case %q", tc.name))
+	}
+}
+
+func (b *LBMapTestSuite) TestSessionAffinityTimeout(c *C) {
+	for _, value := range []ServiceValueV2{&Service4ValueV2{}, &Service6ValueV2{}} {
+		value.SetSessionAffinityTimeoutSec(86400)
+		c.Assert(value.GetSessionAffinityTimeoutSec(), Equals, uint32(86400))
+	}
+}
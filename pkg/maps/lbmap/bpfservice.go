@@ -219,6 +219,19 @@ func (l *lbmapCache) getBackendKey(addrID BackendAddrID) BackendKey {
 	return l.backendIDByAddrID[addrID]
 }
 
+// getBackendIDs returns the identifiers of all backends of the service fe
+func (l *lbmapCache) getBackendIDs(fe ServiceKey) map[loadbalancer.BackendID]struct{} {
+	backendIDs := map[loadbalancer.BackendID]struct{}{}
+	if bpfSvc, ok := l.entries[fe.String()]; ok {
+		for addrID := range bpfSvc.backendsV2 {
+			if backendKey := l.backendIDByAddrID[addrID]; backendKey != nil {
+				backendIDs[backendKey.GetID()] = struct{}{}
+			}
+		}
+	}
+	return backendIDs
+}
+
 // removeServiceV2 removes the service v2 from the cache.
 func (l *lbmapCache) removeServiceV2(svcKey ServiceKeyV2) ([]BackendKey, int, error) {
 	frontendID := svcKey.String()
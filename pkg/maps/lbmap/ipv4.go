@@ -127,6 +127,23 @@ var (
 				return nil, nil, err
 			}
 
+			return svcKey.ToNetwork(), mapValue, nil
+		}).WithCache()
+	Maglev4Map = bpf.NewMap("cilium_lb4_maglev",
+		bpf.MapTypeHash,
+		&Service4KeyV2{},
+		int(unsafe.Sizeof(Service4KeyV2{})),
+		&MaglevValue{},
+		int(unsafe.Sizeof(MaglevValue{})),
+		MaglevMaxEntries,
+		0, 0,
+		func(key []byte, value []byte, mapKey bpf.MapKey, mapValue bpf.MapValue) (bpf.MapKey, bpf.MapValue, error) {
+			svcKey := mapKey.(*Service4KeyV2)
+
+			if _, _, err := bpf.ConvertKeyValue(key, value, svcKey, mapValue); err != nil {
+				return nil, nil, err
+			}
+
 			return svcKey.ToNetwork(), mapValue, nil
 		}).WithCache()
 )
@@ -361,6 +378,7 @@ func (k *Service4KeyV2) NewValue() bpf.MapValue    { return &Service4ValueV2{} }
 func (k *Service4KeyV2) IsIPv6() bool              { return false }
 func (k *Service4KeyV2) Map() *bpf.Map             { return Service4MapV2 }
 func (k *Service4KeyV2) RRMap() *bpf.Map           { return RRSeq4MapV2 }
+func (k *Service4KeyV2) MaglevMap() *bpf.Map       { return Maglev4Map }
 func (k *Service4KeyV2) SetSlave(slave int)        { k.Slave = uint16(slave) }
 func (k *Service4KeyV2) GetSlave() int             { return int(k.Slave) }
 func (k *Service4KeyV2) GetAddress() net.IP        { return k.Address.IP() }
@@ -381,7 +399,7 @@ type Service4ValueV2 struct {
 	Count     uint16 `align:"count"`
 	RevNat    uint16 `align:"rev_nat_index"`
 	Weight    uint16 `align:"weight"`
-	Pad       uint16
+	Flags     uint16 `align:"flags"`
 }
 
 func NewService4ValueV2(count uint16, backendID loadbalancer.BackendID, revNat uint16, weight uint16) *Service4ValueV2 {
@@ -407,6 +425,8 @@ func (s *Service4ValueV2) SetRevNat(id int)        { s.RevNat = uint16(id) }
 func (s *Service4ValueV2) GetRevNat() int          { return int(s.RevNat) }
 func (s *Service4ValueV2) SetWeight(weight uint16) { s.Weight = weight }
 func (s *Service4ValueV2) GetWeight() uint16       { return s.Weight }
+func (s *Service4ValueV2) SetFlags(flags uint16)   { s.Flags = flags }
+func (s *Service4ValueV2) GetFlags() uint16        { return s.Flags }
 func (s *Service4ValueV2) RevNatKey() RevNatKey    { return &RevNat4Key{s.RevNat} }
 
 func (s *Service4ValueV2) SetBackendID(id loadbalancer.BackendID) {
@@ -416,6 +436,13 @@ func (s *Service4ValueV2) GetBackendID() loadbalancer.BackendID {
 	return loadbalancer.BackendID(s.BackendID)
 }
 
+func (s *Service4ValueV2) SetSessionAffinityTimeoutSec(timeoutSec uint32) {
+	s.BackendID = timeoutSec
+}
+func (s *Service4ValueV2) GetSessionAffinityTimeoutSec() uint32 {
+	return s.BackendID
+}
+
 func (s *Service4ValueV2) ToNetwork() ServiceValueV2 {
 	n := *s
 	n.RevNat = byteorder.HostToNetwork(n.RevNat).(uint16)
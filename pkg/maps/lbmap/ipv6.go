@@ -131,6 +131,23 @@ var (
 				return nil, nil, err
 			}
 
+			return svcKey.ToNetwork(), mapValue, nil
+		}).WithCache()
+	Maglev6Map = bpf.NewMap("cilium_lb6_maglev",
+		bpf.MapTypeHash,
+		&Service6KeyV2{},
+		int(unsafe.Sizeof(Service6KeyV2{})),
+		&MaglevValue{},
+		int(unsafe.Sizeof(MaglevValue{})),
+		MaglevMaxEntries,
+		0, 0,
+		func(key []byte, value []byte, mapKey bpf.MapKey, mapValue bpf.MapValue) (bpf.MapKey, bpf.MapValue, error) {
+			svcKey := mapKey.(*Service6KeyV2)
+
+			if _, _, err := bpf.ConvertKeyValue(key, value, svcKey, mapValue); err != nil {
+				return nil, nil, err
+			}
+
 			return svcKey.ToNetwork(), mapValue, nil
 		}).WithCache()
 )
@@ -347,6 +364,7 @@ func (k *Service6KeyV2) NewValue() bpf.MapValue    { return &Service6ValueV2{} }
 func (k *Service6KeyV2) IsIPv6() bool              { return true }
 func (k *Service6KeyV2) Map() *bpf.Map             { return Service6MapV2 }
 func (k *Service6KeyV2) RRMap() *bpf.Map           { return RRSeq6MapV2 }
+func (k *Service6KeyV2) MaglevMap() *bpf.Map       { return Maglev6Map }
 func (k *Service6KeyV2) SetSlave(slave int)        { k.Slave = uint16(slave) }
 func (k *Service6KeyV2) GetSlave() int             { return int(k.Slave) }
 func (k *Service6KeyV2) GetAddress() net.IP        { return k.Address.IP() }
@@ -367,7 +385,7 @@ type Service6ValueV2 struct {
 	Count     uint16 `align:"count"`
 	RevNat    uint16 `align:"rev_nat_index"`
 	Weight    uint16 `align:"weight"`
-	Pad       uint16
+	Flags     uint16 `align:"flags"`
 }
 
 func NewService6ValueV2(count uint16, backendID loadbalancer.BackendID, revNat uint16, weight uint16) *Service6ValueV2 {
@@ -393,6 +411,8 @@ func (s *Service6ValueV2) SetRevNat(id int)        { s.RevNat = uint16(id) }
 func (s *Service6ValueV2) GetRevNat() int          { return int(s.RevNat) }
 func (s *Service6ValueV2) SetWeight(weight uint16) { s.Weight = weight }
 func (s *Service6ValueV2) GetWeight() uint16       { return s.Weight }
+func (s *Service6ValueV2) SetFlags(flags uint16)   { s.Flags = flags }
+func (s *Service6ValueV2) GetFlags() uint16        { return s.Flags }
 func (s *Service6ValueV2) RevNatKey() RevNatKey    { return &RevNat6Key{s.RevNat} }
 
 func (s *Service6ValueV2) SetBackendID(id loadbalancer.BackendID) {
@@ -402,6 +422,13 @@ func (s *Service6ValueV2) GetBackendID() loadbalancer.BackendID {
 	return loadbalancer.BackendID(s.BackendID)
 }
 
+func (s *Service6ValueV2) SetSessionAffinityTimeoutSec(timeoutSec uint32) {
+	s.BackendID = timeoutSec
+}
+func (s *Service6ValueV2) GetSessionAffinityTimeoutSec() uint32 {
+	return s.BackendID
+}
+
 func (s *Service6ValueV2) ToNetwork() ServiceValueV2 {
 	n := *s
 	n.RevNat = byteorder.HostToNetwork(n.RevNat).(uint16)
@@ -22,6 +22,7 @@ import (
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging"
 	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/maglev"
 	"github.com/cilium/cilium/pkg/option"
 	"github.com/cilium/cilium/pkg/u8proto"
 
@@ -42,6 +43,16 @@ const (
 	maxFrontEnds = 256
 	// MaxSeq is used by daemon for generating bpf define LB_RR_MAX_SEQ.
 	MaxSeq = 31
+	// MaglevTableSize is the number of entries in the Maglev lookup table
+	// of a service. It must be a prime number. It is used by daemon for
+	// generating bpf define LB_MAGLEV_LUT_SIZE.
+	MaglevTableSize = 1021
+	// MaglevMaxEntries is the maximum number of services using Maglev
+	MaglevMaxEntries = 4096
+
+	// ServiceFlagMaglev is set in the master service entry if the
+	// backend is selected via the Maglev lookup table of the service
+	ServiceFlagMaglev = 1 << 0
 )
 
 var (
@@ -146,9 +157,15 @@ func generateWrrSeq(weights []uint16) (*RRSeqValue, error) {
 	return &svcRRSeq, nil
 }
 
-// UpdateService adds or updates the given service in the bpf maps.
+// UpdateService adds or updates the given service in the bpf maps. algorithm
+// selects how the datapath picks the backend of new connections, if it is
+// LBAlgorithmMaglev the Maglev lookup table of the service is generated. If
+// sessionAffinityTimeoutSec is not 0, the datapath sends all connections of a
+// client to the same backend until the client has been idle for the given
+// number of seconds.
 func UpdateService(fe ServiceKey, backends []ServiceValue,
-	addRevNAT bool, revNATID int,
+	addRevNAT bool, revNATID int, algorithm loadbalancer.LBAlgorithm,
+	sessionAffinityTimeoutSec uint32,
 	acquireBackendID func(loadbalancer.L3n4Addr) (loadbalancer.BackendID, error),
 	releaseBackendID func(loadbalancer.BackendID)) error {
 
@@ -157,6 +174,15 @@ func UpdateService(fe ServiceKey, backends []ServiceValue,
 		"backends": backends,
 	})
 
+	// Session affinity entries of removed backends are purged once the
+	// mutex has been released, see purgeAffinityEntries
+	var purgeBackendIDs map[loadbalancer.BackendID]struct{}
+	defer func() {
+		if len(purgeBackendIDs) != 0 {
+			purgeAffinityEntries(fe.IsIPv6(), uint16(revNATID), purgeBackendIDs)
+		}
+	}()
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
@@ -175,6 +201,10 @@ func UpdateService(fe ServiceKey, backends []ServiceValue,
 	// Store mapping of backend addr ID => backend ID in the cache
 	cache.addBackendIDs(newBackendIDs)
 
+	// Remember the current backends of the service to find out which ones
+	// are removed from it
+	oldBackendIDs := cache.getBackendIDs(fe)
+
 	// Prepare the service cache for the updates
 	svc, addedBackends, removedBackendIDs, err := cache.prepareUpdate(fe, backends)
 	if err != nil {
@@ -200,10 +230,19 @@ func UpdateService(fe ServiceKey, backends []ServiceValue,
 	}
 
 	// Update the v2 service BPF maps
-	if err := updateServiceV2Locked(fe, besValuesV2, svc, addRevNAT, revNATID, weights, nNonZeroWeights); err != nil {
+	if err := updateServiceV2Locked(fe, besValuesV2, svc, addRevNAT, revNATID, algorithm,
+		sessionAffinityTimeoutSec, weights, nNonZeroWeights); err != nil {
 		return err
 	}
 
+	// Clients must no longer stick to backends removed from the service
+	if sessionAffinityTimeoutSec != 0 {
+		for backendID := range cache.getBackendIDs(fe) {
+			delete(oldBackendIDs, backendID)
+		}
+		purgeBackendIDs = oldBackendIDs
+	}
+
 	// Delete no longer needed backends
 	if err := removeBackendsLocked(removedBackendIDs, releaseBackendID); err != nil {
 		return err
@@ -269,12 +308,13 @@ func updateBackendsLocked(addedBackends map[loadbalancer.BackendID]ServiceValue)
 
 func updateServiceV2Locked(fe ServiceKey, backends serviceValueMap,
 	svc *bpfService,
-	addRevNAT bool, revNATID int,
-	weights []uint16, nNonZeroWeights uint16) error {
+	addRevNAT bool, revNATID int, algorithm loadbalancer.LBAlgorithm,
+	sessionAffinityTimeoutSec uint32, weights []uint16, nNonZeroWeights uint16) error {
 
 	var (
 		existingCount int
 		svcKeyV2      ServiceKeyV2
+		flags         uint16
 	)
 
 	if fe.IsIPv6() {
@@ -326,11 +366,29 @@ func updateServiceV2Locked(fe ServiceKey, backends serviceValueMap,
 		}()
 	}
 
-	err = updateMasterServiceV2(svcKeyV2, len(svc.backendsV2), nNonZeroWeights, revNATID)
+	// The lookup table must exist before the master entry refers to it
+	if algorithm == loadbalancer.LBAlgorithmMaglev && len(backends) > 0 {
+		if err = updateMaglevTableLocked(svcKeyV2, backends); err != nil {
+			return fmt.Errorf("unable to update Maglev lookup table of service %s: %s", svcKeyV2.String(), err)
+		}
+		flags |= ServiceFlagMaglev
+	}
+
+	if sessionAffinityTimeoutSec != 0 {
+		flags |= ServiceFlagSessionAffinity
+	}
+
+	err = updateMasterServiceV2(svcKeyV2, len(svc.backendsV2), nNonZeroWeights, revNATID, flags, sessionAffinityTimeoutSec)
 	if err != nil {
 		return fmt.Errorf("unable to update service %+v: %s", svcKeyV2, err)
 	}
 
+	if flags&ServiceFlagMaglev == 0 {
+		if err = lookupAndDeleteMaglevTable(svcKeyV2); err != nil {
+			return fmt.Errorf("unable to delete Maglev lookup table of service %s: %s", svcKeyV2.String(), err)
+		}
+	}
+
 	err = updateWrrSeqV2(svcKeyV2, weights)
 	if err != nil {
 		return fmt.Errorf("unable to update service weights for %s with value %+v: %s", svcKeyV2.String(), weights, err)
@@ -387,6 +445,8 @@ func DumpServiceMapsToUserspaceV2() (loadbalancer.SVCMap, []*loadbalancer.LBSVC,
 	newSVCList := []*loadbalancer.LBSVC{}
 	errors := []error{}
 	idCache := map[string]loadbalancer.ServiceID{}
+	algorithmCache := map[string]loadbalancer.LBAlgorithm{}
+	affinityCache := map[string]uint32{}
 	backendValueMap := map[loadbalancer.BackendID]BackendValue{}
 
 	parseBackendEntries := func(key bpf.MapKey, value bpf.MapValue) {
@@ -399,8 +459,17 @@ func DumpServiceMapsToUserspaceV2() (loadbalancer.SVCMap, []*loadbalancer.LBSVC,
 		svcKey := key.DeepCopyMapKey().(ServiceKeyV2)
 		svcValue := value.DeepCopyMapValue().(ServiceValueV2)
 
-		// Skip master service
+		// The master service only carries the service flags
 		if svcKey.GetSlave() == 0 {
+			fe := serviceKey2L3n4AddrV2(svcKey)
+			if svcValue.GetFlags()&ServiceFlagMaglev != 0 {
+				algorithmCache[fe.String()] = loadbalancer.LBAlgorithmMaglev
+			} else {
+				algorithmCache[fe.String()] = loadbalancer.LBAlgorithmRandom
+			}
+			if svcValue.GetFlags()&ServiceFlagSessionAffinity != 0 {
+				affinityCache[fe.String()] = svcValue.GetSessionAffinityTimeoutSec()
+			}
 			return
 		}
 
@@ -458,14 +527,19 @@ func DumpServiceMapsToUserspaceV2() (loadbalancer.SVCMap, []*loadbalancer.LBSVC,
 
 	// serviceKeynValue2FEnBE() cannot fill in the service ID reliably as
 	// not all BPF map entries contain the service ID. Do a pass over all
-	// parsed entries and fill in the service ID
+	// parsed entries and fill in the service ID, the algorithm and the
+	// session affinity timeout of the master service
 	for i := range newSVCList {
 		newSVCList[i].FE.ID = loadbalancer.ID(idCache[newSVCList[i].FE.String()])
+		newSVCList[i].Algorithm = algorithmCache[newSVCList[i].FE.String()]
+		newSVCList[i].SessionAffinityTimeoutSec = affinityCache[newSVCList[i].FE.String()]
 	}
 
 	// Do the same for the svcMap
 	for key, svc := range newSVCMap {
 		svc.FE.ID = loadbalancer.ID(idCache[svc.FE.String()])
+		svc.Algorithm = algorithmCache[svc.FE.String()]
+		svc.SessionAffinityTimeoutSec = affinityCache[svc.FE.String()]
 		newSVCMap[key] = svc
 	}
 
@@ -574,12 +648,15 @@ func lookupServiceV2(key ServiceKeyV2) (ServiceValueV2, error) {
 	return svc.ToNetwork(), nil
 }
 
-func updateMasterServiceV2(fe ServiceKeyV2, nbackends int, nonZeroWeights uint16, revNATID int) error {
+func updateMasterServiceV2(fe ServiceKeyV2, nbackends int, nonZeroWeights uint16, revNATID int,
+	flags uint16, sessionAffinityTimeoutSec uint32) error {
 	fe.SetSlave(0)
 	zeroValue := fe.NewValue().(ServiceValueV2)
 	zeroValue.SetCount(nbackends)
 	zeroValue.SetWeight(nonZeroWeights)
 	zeroValue.SetRevNat(revNATID)
+	zeroValue.SetFlags(flags)
+	zeroValue.SetSessionAffinityTimeoutSec(sessionAffinityTimeoutSec)
 
 	return updateServiceEndpointV2(fe, zeroValue)
 }
@@ -609,6 +686,64 @@ func updateServiceWeightsV2(key ServiceKeyV2, value *RRSeqValue) error {
 	return key.RRMap().Update(key.ToNetwork(), value)
 }
 
+// generateMaglevTable generates the Maglev lookup table of the given
+// backends. Backends are placed by their address so that the table does not
+// depend on the backend IDs allocated by this node.
+func generateMaglevTable(backends serviceValueMap) (*MaglevValue, error) {
+	addrIDs := make([]BackendAddrID, 0, len(backends))
+	maglevBackends := make([]maglev.Backend, 0, len(backends))
+	for addrID, svcVal := range backends {
+		addrIDs = append(addrIDs, addrID)
+		maglevBackends = append(maglevBackends, maglev.Backend{
+			Name:   string(addrID),
+			Weight: svcVal.GetWeight(),
+		})
+	}
+
+	table := maglev.GetLookupTable(maglevBackends, MaglevTableSize)
+	if len(table) != MaglevTableSize {
+		return nil, fmt.Errorf("no backends to place in the lookup table")
+	}
+
+	value := &MaglevValue{}
+	for i, backend := range table {
+		backendKey := cache.getBackendKey(addrIDs[backend])
+		if backendKey == nil {
+			return nil, fmt.Errorf("backend %s has no backend ID", addrIDs[backend])
+		}
+		value.BackendIDs[i] = uint16(backendKey.GetID())
+	}
+	return value, nil
+}
+
+// updateMaglevTableLocked updates cilium_lb6_maglev or cilium_lb4_maglev bpf
+// maps with the lookup table of the given backends.
+func updateMaglevTableLocked(key ServiceKeyV2, backends serviceValueMap) error {
+	value, err := generateMaglevTable(backends)
+	if err != nil {
+		return err
+	}
+
+	if _, err := key.MaglevMap().OpenOrCreate(); err != nil {
+		return err
+	}
+
+	key.SetSlave(0)
+	return key.MaglevMap().Update(key.ToNetwork(), value)
+}
+
+// lookupAndDeleteMaglevTable deletes entry from cilium_lb6_maglev or
+// cilium_lb4_maglev
+func lookupAndDeleteMaglevTable(key ServiceKeyV2) error {
+	key.SetSlave(0)
+	if _, err := key.MaglevMap().Lookup(key.ToNetwork()); err != nil {
+		// Ignore if entry is not found.
+		return nil
+	}
+
+	return key.MaglevMap().Delete(key.ToNetwork())
+}
+
 func deleteServiceLockedV2(key ServiceKeyV2) error {
 	err := key.Map().Delete(key.ToNetwork())
 	if err != nil {
@@ -669,6 +804,15 @@ func AddBackendIDsToCache(backendIDs map[BackendAddrID]BackendKey) {
 //
 //The given key has to be of the master service.
 func DeleteServiceV2(svc loadbalancer.L3n4AddrID, releaseBackendID func(loadbalancer.BackendID)) error {
+	// Session affinity entries of the service are purged once the mutex
+	// has been released, see purgeAffinityEntries
+	purge := false
+	defer func() {
+		if purge {
+			purgeAffinityEntries(svc.IsIPv6(), uint16(svc.ID), nil)
+		}
+	}()
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
@@ -698,6 +842,12 @@ func DeleteServiceV2(svc loadbalancer.L3n4AddrID, releaseBackendID func(loadbala
 		}
 	}
 
+	if err := lookupAndDeleteMaglevTable(svcKey); err != nil {
+		return err
+	}
+
+	purge = svc.ID != 0
+
 	for _, backendKey := range backendsToRemove {
 		if err := deleteBackendLocked(backendKey); err != nil {
 			return fmt.Errorf("Unable to delete backend with ID %d: %s", backendKey, err)
@@ -20,6 +20,8 @@ import (
 	"net"
 	"testing"
 
+	"github.com/cilium/cilium/pkg/checker"
+	"github.com/cilium/cilium/pkg/loadbalancer"
 	"github.com/cilium/cilium/pkg/u8proto"
 
 	. "gopkg.in/check.v1"
@@ -46,3 +48,65 @@ func (b *LBMapTestSuite) TestBackendAddrID(c *C) {
 	c.Assert(b6.BackendAddrID(), Equals, v6.BackendAddrID())
 
 }
+
+func (b *LBMapTestSuite) TestGenerateMaglevTable(c *C) {
+	oldCache := cache
+	cache = newLBMapCache()
+	defer func() { cache = oldCache }()
+
+	backends := serviceValueMap{}
+	backendIDs := map[BackendAddrID]BackendKey{}
+	for i := 1; i <= 3; i++ {
+		v := NewService4Value(0, net.IPv4(10, 0, 0, byte(i)), 80, 1, uint16(i))
+		backends[v.BackendAddrID()] = v
+		backendIDs[v.BackendAddrID()] = NewBackend4Key(loadbalancer.BackendID(i))
+	}
+
+	// Backends without an ID cannot be placed in the table
+	_, err := generateMaglevTable(backends)
+	c.Assert(err, Not(IsNil))
+
+	cache.addBackendIDs(backendIDs)
+	value, err := generateMaglevTable(backends)
+	c.Assert(err, IsNil)
+
+	counts := map[uint16]int{}
+	for _, id := range value.BackendIDs {
+		counts[id]++
+	}
+	c.Assert(len(counts), Equals, 3)
+	c.Assert(counts[1] < counts[2] && counts[2] < counts[3], Equals, true,
+		Commentf("entries not proportional to weights: %v", counts))
+
+	_, err = generateMaglevTable(serviceValueMap{})
+	c.Assert(err, Not(IsNil))
+}
+
+func (b *LBMapTestSuite) TestGetBackendIDs(c *C) {
+	oldCache := cache
+	cache = newLBMapCache()
+	defer func() { cache = oldCache }()
+
+	fe := NewService4Key(net.ParseIP("1.1.1.1"), 80, 0)
+	c.Assert(len(cache.getBackendIDs(fe)), Equals, 0)
+
+	backends := []ServiceValue{}
+	backendIDs := map[BackendAddrID]BackendKey{}
+	for i := 1; i <= 3; i++ {
+		v := NewService4Value(0, net.IPv4(10, 0, 0, byte(i)), 80, 1, 0)
+		backends = append(backends, v)
+		backendIDs[v.BackendAddrID()] = NewBackend4Key(loadbalancer.BackendID(i))
+	}
+	cache.addBackendIDs(backendIDs)
+
+	_, _, _, err := cache.prepareUpdate(fe, backends)
+	c.Assert(err, IsNil)
+	c.Assert(cache.getBackendIDs(fe), checker.DeepEquals,
+		map[loadbalancer.BackendID]struct{}{1: {}, 2: {}, 3: {}})
+
+	_, _, removed, err := cache.prepareUpdate(fe, backends[1:])
+	c.Assert(err, IsNil)
+	c.Assert(len(removed), Equals, 1)
+	c.Assert(cache.getBackendIDs(fe), checker.DeepEquals,
+		map[loadbalancer.BackendID]struct{}{2: {}, 3: {}})
+}
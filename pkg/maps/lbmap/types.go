@@ -125,6 +125,9 @@ type ServiceKeyV2 interface {
 	// Return the BPF Weighted Round Robin map matching the key type
 	RRMap() *bpf.Map
 
+	// Return the BPF Maglev lookup table map matching the key type
+	MaglevMap() *bpf.Map
+
 	// Set slave slot for the key
 	SetSlave(slave int)
 
@@ -166,6 +169,19 @@ type ServiceValueV2 interface {
 	// Get weight
 	GetWeight() uint16
 
+	// Set service flags (ServiceFlag*), only used for the master service
+	SetFlags(uint16)
+
+	// Get service flags
+	GetFlags() uint16
+
+	// Set session affinity timeout in seconds, only used for the master
+	// service with ServiceFlagSessionAffinity
+	SetSessionAffinityTimeoutSec(timeoutSec uint32)
+
+	// Get session affinity timeout in seconds
+	GetSessionAffinityTimeoutSec() uint32
+
 	// Set backend identifier
 	SetBackendID(id loadbalancer.BackendID)
 
@@ -275,6 +291,28 @@ func (s *RRSeqValue) String() string {
 	return fmt.Sprintf("count=%d idx=%v", s.Count, s.Idx)
 }
 
+type maglevTable [MaglevTableSize]uint16
+
+// DeepCopyInto is a deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *maglevTable) DeepCopyInto(out *maglevTable) {
+	copy(out[:], in[:])
+	return
+}
+
+// MaglevValue must match 'struct lb_maglev' in "bpf/lib/common.h".
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=github.com/cilium/cilium/pkg/bpf.MapValue
+type MaglevValue struct {
+	// Backend IDs of the lookup table entries
+	BackendIDs maglevTable `align:"backend_ids"`
+}
+
+func (m *MaglevValue) GetValuePtr() unsafe.Pointer { return unsafe.Pointer(m) }
+
+func (m *MaglevValue) String() string {
+	return fmt.Sprintf("maglev table of %d entries", len(m.BackendIDs))
+}
+
 // l3n4Addr2ServiceKey converts the given l3n4Addr to a ServiceKey with the slave ID
 // set to 0.
 func l3n4Addr2ServiceKey(l3n4Addr loadbalancer.L3n4AddrID) ServiceKey {
@@ -22,6 +22,80 @@ import (
 	bpf "github.com/cilium/cilium/pkg/bpf"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Affinity4Key) DeepCopyInto(out *Affinity4Key) {
+	*out = *in
+	in.ClientIP.DeepCopyInto(&out.ClientIP)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Affinity4Key.
+func (in *Affinity4Key) DeepCopy() *Affinity4Key {
+	if in == nil {
+		return nil
+	}
+	out := new(Affinity4Key)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyMapKey is an autogenerated deepcopy function, copying the receiver, creating a new bpf.MapKey.
+func (in *Affinity4Key) DeepCopyMapKey() bpf.MapKey {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Affinity6Key) DeepCopyInto(out *Affinity6Key) {
+	*out = *in
+	in.ClientIP.DeepCopyInto(&out.ClientIP)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Affinity6Key.
+func (in *Affinity6Key) DeepCopy() *Affinity6Key {
+	if in == nil {
+		return nil
+	}
+	out := new(Affinity6Key)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyMapKey is an autogenerated deepcopy function, copying the receiver, creating a new bpf.MapKey.
+func (in *Affinity6Key) DeepCopyMapKey() bpf.MapKey {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AffinityValue) DeepCopyInto(out *AffinityValue) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AffinityValue.
+func (in *AffinityValue) DeepCopy() *AffinityValue {
+	if in == nil {
+		return nil
+	}
+	out := new(AffinityValue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyMapValue is an autogenerated deepcopy function, copying the receiver, creating a new bpf.MapValue.
+func (in *AffinityValue) DeepCopyMapValue() bpf.MapValue {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backend4Key) DeepCopyInto(out *Backend4Key) {
 	*out = *in
@@ -120,6 +194,31 @@ func (in *Backend6Value) DeepCopyMapValue() bpf.MapValue {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaglevValue) DeepCopyInto(out *MaglevValue) {
+	*out = *in
+	in.BackendIDs.DeepCopyInto(&out.BackendIDs)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaglevValue.
+func (in *MaglevValue) DeepCopy() *MaglevValue {
+	if in == nil {
+		return nil
+	}
+	out := new(MaglevValue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyMapValue is an autogenerated deepcopy function, copying the receiver, creating a new bpf.MapValue.
+func (in *MaglevValue) DeepCopyMapValue() bpf.MapValue {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RRSeqValue) DeepCopyInto(out *RRSeqValue) {
 	*out = *in
@@ -179,7 +179,9 @@ func (m *Map) Flush() int {
 	return int(doFlush6(m).deleted)
 }
 
-func deleteMapping4(m *Map, ctKey *tuple.TupleKey4Global) error {
+// mappingKeys4 returns the keys of the entries of the NAT mapping of the
+// connection with the given conntrack tuple which exist in m.
+func mappingKeys4(m *Map, ctKey *tuple.TupleKey4Global) []bpf.MapKey {
 	key := NatKey4{
 		TupleKey4Global: *ctKey,
 	}
@@ -188,22 +190,27 @@ func deleteMapping4(m *Map, ctKey *tuple.TupleKey4Global) error {
 	key.SourceAddr = key.DestAddr
 	key.DestAddr = addr
 	valMap, err := m.Lookup(&key)
-	if err == nil {
-		val := *(*NatEntry4)(unsafe.Pointer(valMap.GetValuePtr()))
-		rkey := key
-		rkey.SourceAddr = key.DestAddr
-		rkey.SourcePort = key.DestPort
-		rkey.DestAddr = val.Addr
-		rkey.DestPort = val.Port
-		rkey.Flags = tuple.TUPLE_F_IN
-
-		m.Delete(&key)
-		m.Delete(&rkey)
+	if err != nil {
+		return nil
+	}
+	val := *(*NatEntry4)(unsafe.Pointer(valMap.GetValuePtr()))
+	rkey := key
+	rkey.SourceAddr = key.DestAddr
+	rkey.SourcePort = key.DestPort
+	rkey.DestAddr = val.Addr
+	rkey.DestPort = val.Port
+	rkey.Flags = tuple.TUPLE_F_IN
+
+	keys := []bpf.MapKey{&key}
+	if _, err := m.Lookup(&rkey); err == nil {
+		keys = append(keys, &rkey)
 	}
-	return nil
+	return keys
 }
 
-func deleteMapping6(m *Map, ctKey *tuple.TupleKey6Global) error {
+// mappingKeys6 returns the keys of the entries of the NAT mapping of the
+// connection with the given conntrack tuple which exist in m.
+func mappingKeys6(m *Map, ctKey *tuple.TupleKey6Global) []bpf.MapKey {
 	key := NatKey6{
 		TupleKey6Global: *ctKey,
 	}
@@ -212,30 +219,50 @@ func deleteMapping6(m *Map, ctKey *tuple.TupleKey6Global) error {
 	key.SourceAddr = key.DestAddr
 	key.DestAddr = addr
 	valMap, err := m.Lookup(&key)
-	if err == nil {
-		val := *(*NatEntry6)(unsafe.Pointer(valMap.GetValuePtr()))
-		rkey := key
-		rkey.SourceAddr = key.DestAddr
-		rkey.SourcePort = key.DestPort
-		rkey.DestAddr = val.Addr
-		rkey.DestPort = val.Port
-		rkey.Flags = tuple.TUPLE_F_IN
-
-		m.Delete(&key)
-		m.Delete(&rkey)
+	if err != nil {
+		return nil
+	}
+	val := *(*NatEntry6)(unsafe.Pointer(valMap.GetValuePtr()))
+	rkey := key
+	rkey.SourceAddr = key.DestAddr
+	rkey.SourcePort = key.DestPort
+	rkey.DestAddr = val.Addr
+	rkey.DestPort = val.Port
+	rkey.Flags = tuple.TUPLE_F_IN
+
+	keys := []bpf.MapKey{&key}
+	if _, err := m.Lookup(&rkey); err == nil {
+		keys = append(keys, &rkey)
 	}
-	return nil
+	return keys
 }
 
-// DeleteMapping removes a NAT mapping from the global NAT table.
-func (m *Map) DeleteMapping(key tuple.TupleKey) error {
+func (m *Map) mappingKeys(key tuple.TupleKey) []bpf.MapKey {
 	if key.GetFlags()&tuple.TUPLE_F_IN != 0 {
 		return nil
 	}
 	if m.v4 {
-		return deleteMapping4(m, key.(*tuple.TupleKey4Global))
+		return mappingKeys4(m, key.(*tuple.TupleKey4Global))
 	}
-	return deleteMapping6(m, key.(*tuple.TupleKey6Global))
+	return mappingKeys6(m, key.(*tuple.TupleKey6Global))
+}
+
+// DeleteMapping removes a NAT mapping from the global NAT table. It returns
+// the number of entries removed.
+func (m *Map) DeleteMapping(key tuple.TupleKey) (int, error) {
+	deleted := 0
+	for _, k := range m.mappingKeys(key) {
+		if err := m.Delete(k); err == nil {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// CountMapping returns the number of entries of the NAT mapping in the
+// global NAT table which would be removed by DeleteMapping.
+func (m *Map) CountMapping(key tuple.TupleKey) int {
+	return len(m.mappingKeys(key))
 }
 
 // GlobalMaps returns all global NAT maps.
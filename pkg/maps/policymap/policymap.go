@@ -62,7 +62,7 @@ type PolicyMap struct {
 }
 
 func (pe *PolicyEntry) String() string {
-	return fmt.Sprintf("%d %d %d", pe.ProxyPort, pe.Packets, pe.Bytes)
+	return fmt.Sprintf("%d %d %d %d", pe.ProxyPort, pe.Flags, pe.Packets, pe.Bytes)
 }
 
 // PolicyKey represents a key in the BPF policy map for an endpoint. It must
@@ -82,13 +82,26 @@ type PolicyKey struct {
 // +k8s:deepcopy-gen:interfaces=github.com/cilium/cilium/pkg/bpf.MapValue
 type PolicyEntry struct {
 	ProxyPort uint16 // In network byte-order
-	Pad0      uint16
+	Flags     uint8  // See PolicyEntryFlagDeny
+	Pad0      uint8
 	Pad1      uint16
 	Pad2      uint16
 	Packets   uint64
 	Bytes     uint64
 }
 
+const (
+	// PolicyEntryFlagDeny marks an entry which denies the traffic
+	// matching its key. It must match the 'deny' bit of policy_entry in
+	// bpf/lib/common.h.
+	PolicyEntryFlagDeny = 1 << 0
+)
+
+// IsDeny returns true if the entry denies the traffic matching its key.
+func (pe *PolicyEntry) IsDeny() bool {
+	return pe.Flags&PolicyEntryFlagDeny != 0
+}
+
 func (pe *PolicyEntry) GetValuePtr() unsafe.Pointer { return unsafe.Pointer(pe) }
 func (pe *PolicyEntry) NewValue() bpf.MapValue      { return &PolicyEntry{} }
 
@@ -188,6 +201,22 @@ func (pm *PolicyMap) Allow(id uint32, dport uint16, proto u8proto.U8proto, traff
 	return pm.Update(&key, &entry)
 }
 
+// DenyKey pushes an entry into the PolicyMap which denies traffic for the
+// given PolicyKey k. Returns an error if the update of the PolicyMap fails.
+func (pm *PolicyMap) DenyKey(k PolicyKey) error {
+	return pm.Deny(k.Identity, k.DestPort, u8proto.U8proto(k.Nexthdr), trafficdirection.TrafficDirection(k.TrafficDirection))
+}
+
+// Deny pushes an entry into the PolicyMap to deny traffic in the given
+// `trafficDirection` for identity `id` with destination port `dport` over
+// protocol `proto`. It is assumed that `dport` is in host byte-order.
+func (pm *PolicyMap) Deny(id uint32, dport uint16, proto u8proto.U8proto, trafficDirection trafficdirection.TrafficDirection) error {
+	key := newKey(id, dport, proto, trafficDirection)
+	entry := newEntry(0)
+	entry.Flags |= PolicyEntryFlagDeny
+	return pm.Update(&key, &entry)
+}
+
 // Exists determines whether PolicyMap currently contains an entry that
 // allows traffic in `trafficDirection` for identity `id` with destination port
 // `dport`over protocol `proto`. It is assumed that `dport` is in host byte-order.
@@ -28,6 +28,7 @@ type CounterVec interface {
 
 type GaugeVec interface {
 	WithLabelValues(lvls ...string) prometheus.Gauge
+	DeleteLabelValues(lvs ...string) bool
 	prometheus.Collector
 }
 
@@ -133,3 +134,7 @@ type gaugeVec struct {
 func (gv *gaugeVec) WithLabelValues(lvls ...string) prometheus.Gauge {
 	return NoOpGauge
 }
+
+func (gv *gaugeVec) DeleteLabelValues(lvs ...string) bool {
+	return false
+}
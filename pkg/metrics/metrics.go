@@ -157,6 +157,16 @@ const (
 
 	// LabelMapName is the label for the BPF map name
 	LabelMapName = "mapName"
+
+	// LabelSourceNode is the name of the node a probe was sent from
+	LabelSourceNode = "source_node"
+
+	// LabelDestinationNode is the name of the node a probe was sent to
+	LabelDestinationNode = "destination_node"
+
+	// LabelConnectivityType marks whether a probe was sent to the node
+	// itself or to its health endpoint
+	LabelConnectivityType = "type"
 )
 
 var (
@@ -358,6 +368,10 @@ var (
 	// GC job.
 	FQDNGarbageCollectorCleanedTotal = NoOpCounter
 
+	// FQDNProxyRateLimitedTotal is the number of DNS requests rejected by
+	// the DNS proxy because the endpoint exceeded its rate limit.
+	FQDNProxyRateLimitedTotal = NoOpCounter
+
 	// BPFSyscallDuration is the metric for bpf syscalls duration.
 	BPFSyscallDuration = NoOpObserverVec
 
@@ -376,6 +390,16 @@ var (
 	// TriggerPolicyUpdateCallDuration measures the latency and call
 	// duration of policy update triggers
 	TriggerPolicyUpdateCallDuration = NoOpObserverVec
+
+	// Node connectivity
+
+	// NodeConnectivityLatency is the round trip time of successful
+	// connectivity probes between nodes
+	NodeConnectivityLatency = NoOpObserverVec
+
+	// NodeConnectivityPacketLoss is the percentage of connectivity probes
+	// between nodes lost within the health history window
+	NodeConnectivityPacketLoss = NoOpGaugeVec
 )
 
 type Configuration struct {
@@ -424,11 +448,14 @@ type Configuration struct {
 	KVStoreOperationsDurationEnabled        bool
 	KVStoreEventsQueueDurationEnabled       bool
 	FQDNGarbageCollectorCleanedTotalEnabled bool
+	FQDNProxyRateLimitedTotalEnabled        bool
 	BPFSyscallDurationEnabled               bool
 	BPFMapOps                               bool
 	TriggerPolicyUpdateTotal                bool
 	TriggerPolicyUpdateFolds                bool
 	TriggerPolicyUpdateCallDuration         bool
+	NodeConnectivityLatencyEnabled          bool
+	NodeConnectivityPacketLossEnabled       bool
 }
 
 func DefaultMetrics() map[string]struct{} {
@@ -476,10 +503,12 @@ func DefaultMetrics() map[string]struct{} {
 		Namespace + "_" + SubsystemKVStore + "_operations_duration_seconds":          {},
 		Namespace + "_" + SubsystemKVStore + "_events_queue_seconds":                 {},
 		Namespace + "_fqdn_gc_deletions_total":                                       {},
+		Namespace + "_fqdn_proxy_rate_limited_total":                                 {},
 		Namespace + "_" + SubsystemBPF + "_map_ops_total":                            {},
 		Namespace + "_" + SubsystemTriggers + "_policy_update_total":                 {},
 		Namespace + "_" + SubsystemTriggers + "_policy_update_folds":                 {},
 		Namespace + "_" + SubsystemTriggers + "_policy_update_call_duration_seconds": {},
+		Namespace + "_node_connectivity_packet_loss_percent":                         {},
 	}
 }
 
@@ -966,6 +995,16 @@ func CreateConfiguration(metricsEnabled []string) (Configuration, []prometheus.C
 			collectors = append(collectors, FQDNGarbageCollectorCleanedTotal)
 			c.FQDNGarbageCollectorCleanedTotalEnabled = true
 
+		case Namespace + "_fqdn_proxy_rate_limited_total":
+			FQDNProxyRateLimitedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+				Namespace: Namespace,
+				Name:      "fqdn_proxy_rate_limited_total",
+				Help:      "Number of DNS requests rejected by the DNS proxy because the endpoint exceeded its rate limit",
+			})
+
+			collectors = append(collectors, FQDNProxyRateLimitedTotal)
+			c.FQDNProxyRateLimitedTotalEnabled = true
+
 		case Namespace + "_" + SubsystemBPF + "_syscall_duration_seconds":
 			BPFSyscallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 				Namespace: Namespace,
@@ -1020,6 +1059,29 @@ func CreateConfiguration(metricsEnabled []string) (Configuration, []prometheus.C
 
 			collectors = append(collectors, TriggerPolicyUpdateCallDuration)
 			c.TriggerPolicyUpdateCallDuration = true
+
+		case Namespace + "_node_connectivity_latency_seconds":
+			NodeConnectivityLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Name:      "node_connectivity_latency_seconds",
+				Help: "Round trip time in seconds of connectivity probes between nodes " +
+					"labeled by source and destination node, type and protocol",
+				Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+			}, []string{LabelSourceNode, LabelDestinationNode, LabelConnectivityType, LabelProtocol})
+
+			collectors = append(collectors, NodeConnectivityLatency)
+			c.NodeConnectivityLatencyEnabled = true
+
+		case Namespace + "_node_connectivity_packet_loss_percent":
+			NodeConnectivityPacketLoss = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Name:      "node_connectivity_packet_loss_percent",
+				Help: "Percentage of connectivity probes between nodes lost within the " +
+					"health history window labeled by source and destination node, type and protocol",
+			}, []string{LabelSourceNode, LabelDestinationNode, LabelConnectivityType, LabelProtocol})
+
+			collectors = append(collectors, NodeConnectivityPacketLoss)
+			c.NodeConnectivityPacketLossEnabled = true
 		}
 	}
 
@@ -28,6 +28,7 @@ import (
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging"
 	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/monitor/flow"
 	"github.com/cilium/cilium/pkg/monitor/payload"
 	"github.com/cilium/cilium/pkg/option"
 )
@@ -59,11 +60,12 @@ func buildServer(path string) (net.Listener, error) {
 // events from the BPF perf ring buffer and provides an interface to also pass
 // in non-BPF events.
 type Agent struct {
-	mutex     lock.Mutex
-	lost      uint64
-	server1_2 net.Listener
-	monitor   *Monitor
-	queue     chan payload.Payload
+	mutex         lock.Mutex
+	lost          uint64
+	server1_2     net.Listener
+	serverFlow1_0 net.Listener
+	monitor       *Monitor
+	queue         chan payload.Payload
 }
 
 // NewAgent creates a new monitor agent
@@ -77,9 +79,16 @@ func NewAgent(ctx context.Context, npages int) (a *Agent, err error) {
 		return
 	}
 
-	a.monitor = NewMonitor(ctx, npages, a.server1_2)
+	a.serverFlow1_0, err = buildServer(defaults.MonitorFlowSockPath1_0)
+	if err != nil {
+		a.server1_2.Close()
+		return
+	}
+
+	a.monitor = NewMonitor(ctx, npages, a.server1_2, a.serverFlow1_0)
 
 	log.Infof("Serving cilium node monitor v1.2 API at unix://%s", defaults.MonitorSockPath1_2)
+	log.Infof("Serving cilium node monitor flow v1.0 API at unix://%s", defaults.MonitorFlowSockPath1_0)
 
 	go a.eventDrainer()
 
@@ -89,9 +98,17 @@ func NewAgent(ctx context.Context, npages int) (a *Agent, err error) {
 // Stop stops the monitor agent
 func (a *Agent) Stop() {
 	a.server1_2.Close()
+	a.serverFlow1_0.Close()
 	close(a.queue)
 }
 
+// SetFlowResolvers sets the identity and endpoint resolvers used to enrich
+// the flows sent to flow listeners connecting after the call. Either may be
+// nil.
+func (a *Agent) SetFlowResolvers(identities flow.IdentityGetter, endpoints flow.EndpointGetter) {
+	a.monitor.SetFlowDecoder(flow.NewDecoder(identities, endpoints))
+}
+
 func (a *Agent) eventDrainer() {
 	for {
 		p, ok := <-a.queue
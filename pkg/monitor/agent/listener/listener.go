@@ -29,6 +29,8 @@ import (
 // - 1.2 which maintains a gob session per listener, thus only encoding the
 //   type information on the first payload sent. It does NOT prepend the a meta
 //   object.
+// Additionally, flow-1.0 sends decoded flows as JSON, filtered according to
+// the request sent by the client when connecting.
 type Version string
 
 const (
@@ -40,6 +42,10 @@ const (
 
 	// Version1_2 is the API 1.0 version of the protocol (see above).
 	Version1_2 = Version("1.2")
+
+	// VersionFlow1_0 is the API 1.0 version of the flow protocol (see
+	// above).
+	VersionFlow1_0 = Version("flow-1.0")
 )
 
 // MonitorListener is a generic consumer of monitor events. Implementers are
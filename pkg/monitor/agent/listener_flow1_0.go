@@ -0,0 +1,99 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/cilium/cilium/pkg/monitor/agent/listener"
+	"github.com/cilium/cilium/pkg/monitor/flow"
+	"github.com/cilium/cilium/pkg/monitor/payload"
+)
+
+// listenerFlow1_0 implements the flow-1.0 listener protocol. Payloads are
+// decoded into flows and the flows selected by the filters of the client are
+// sent as JSON.
+// cleanupFn is called on exit
+type listenerFlow1_0 struct {
+	conn      net.Conn
+	queue     chan *payload.Payload
+	decoder   *flow.Decoder
+	filters   flow.Filters
+	cleanupFn func(listener.MonitorListener)
+}
+
+func newListenerFlow1_0(c net.Conn, queueSize int, decoder *flow.Decoder, filters flow.Filters, cleanupFn func(listener.MonitorListener)) *listenerFlow1_0 {
+	ml := &listenerFlow1_0{
+		conn:      c,
+		queue:     make(chan *payload.Payload, queueSize),
+		decoder:   decoder,
+		filters:   filters,
+		cleanupFn: cleanupFn,
+	}
+
+	go ml.drainQueue()
+
+	return ml
+}
+
+func (ml *listenerFlow1_0) Enqueue(pl *payload.Payload) {
+	select {
+	case ml.queue <- pl:
+	default:
+		log.Debug("Per listener queue is full, dropping message")
+	}
+}
+
+// drainQueue decodes, filters and sends monitor payloads to the listener. It
+// is intended to be a goroutine.
+func (ml *listenerFlow1_0) drainQueue() {
+	defer func() {
+		ml.conn.Close()
+		ml.cleanupFn(ml)
+	}()
+
+	enc := json.NewEncoder(ml.conn)
+	for pl := range ml.queue {
+		f, err := ml.decoder.Decode(pl)
+		switch {
+		case err == flow.ErrUnsupportedEvent:
+			continue
+		case err != nil:
+			log.WithError(err).Debug("Unable to decode flow")
+			continue
+		}
+
+		if !ml.filters.Match(f) {
+			continue
+		}
+
+		if err := enc.Encode(f); err != nil {
+			switch {
+			case listener.IsDisconnected(err):
+				log.Debug("Listener disconnected")
+				return
+
+			default:
+				log.WithError(err).Warn("Removing listener due to write failure")
+				return
+			}
+		}
+	}
+}
+
+func (ml *listenerFlow1_0) Version() listener.Version {
+	return listener.VersionFlow1_0
+}
@@ -16,6 +16,7 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net"
 	"path"
@@ -28,6 +29,7 @@ import (
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 	"github.com/cilium/cilium/pkg/monitor/agent/listener"
+	"github.com/cilium/cilium/pkg/monitor/flow"
 	"github.com/cilium/cilium/pkg/monitor/payload"
 	"github.com/cilium/cilium/pkg/option"
 	"github.com/sirupsen/logrus"
@@ -35,6 +37,10 @@ import (
 
 const (
 	pollTimeout = 5000
+
+	// flowRequestTimeout is the time a flow listener has to send its
+	// request after connecting
+	flowRequestTimeout = 10 * time.Second
 )
 
 // isCtxDone is a utility function that returns true when the context's Done()
@@ -67,26 +73,50 @@ type Monitor struct {
 	listeners        map[listener.MonitorListener]struct{}
 	nPages           int
 	monitorEvents    *bpf.PerCpuEvents
+	flowDecoder      *flow.Decoder
 }
 
 // NewMonitor creates a Monitor, and starts client connection handling and agent event
 // handling.
 // Note that the perf buffer reader is started only when listeners are
 // connected.
-func NewMonitor(ctx context.Context, nPages int, server1_2 net.Listener) (m *Monitor) {
+func NewMonitor(ctx context.Context, nPages int, server1_2, serverFlow1_0 net.Listener) (m *Monitor) {
 	m = &Monitor{
 		ctx:              ctx,
 		listeners:        make(map[listener.MonitorListener]struct{}),
 		nPages:           nPages,
 		perfReaderCancel: func() {}, // no-op to avoid doing null checks everywhere
+		flowDecoder:      flow.NewDecoder(nil, nil),
 	}
 
 	// start new MonitorListener handler
 	go m.connectionHandler1_2(ctx, server1_2)
+	if serverFlow1_0 != nil {
+		go m.connectionHandlerFlow1_0(ctx, serverFlow1_0)
+	}
 
 	return
 }
 
+// SetFlowDecoder replaces the decoder used by flow listeners connecting
+// after the call.
+func (m *Monitor) SetFlowDecoder(decoder *flow.Decoder) {
+	m.Lock()
+	m.flowDecoder = decoder
+	m.Unlock()
+}
+
+// startPerfReaderLocked starts the perf reader if there are no listeners yet.
+// m must be locked.
+func (m *Monitor) startPerfReaderLocked(parentCtx context.Context) {
+	if len(m.listeners) == 0 {
+		m.perfReaderCancel() // don't leak any old readers, just in case.
+		perfEventReaderCtx, cancel := context.WithCancel(parentCtx)
+		m.perfReaderCancel = cancel
+		go m.perfEventReader(perfEventReaderCtx, m.nPages)
+	}
+}
+
 // registerNewListener adds the new MonitorListener to the global list. It also spawns
 // a singleton goroutine to read and distribute the events. It passes a
 // cancelable context to this goroutine and the cancelFunc is assigned to
@@ -97,12 +127,7 @@ func (m *Monitor) registerNewListener(parentCtx context.Context, conn net.Conn,
 	defer m.Unlock()
 
 	// If this is the first listener, start the perf reader
-	if len(m.listeners) == 0 {
-		m.perfReaderCancel() // don't leak any old readers, just in case.
-		perfEventReaderCtx, cancel := context.WithCancel(parentCtx)
-		m.perfReaderCancel = cancel
-		go m.perfEventReader(perfEventReaderCtx, m.nPages)
-	}
+	m.startPerfReaderLocked(parentCtx)
 
 	switch version {
 	case listener.Version1_2:
@@ -237,6 +262,64 @@ func (m *Monitor) connectionHandler1_2(parentCtx context.Context, server net.Lis
 	}
 }
 
+// registerNewFlowListener adds a new flow listener sending the flows selected
+// by req to conn. The perf reader is started as in registerNewListener.
+func (m *Monitor) registerNewFlowListener(parentCtx context.Context, conn net.Conn, req *flow.Request) {
+	m.Lock()
+	defer m.Unlock()
+
+	// If this is the first listener, start the perf reader
+	m.startPerfReaderLocked(parentCtx)
+
+	newListener := newListenerFlow1_0(conn, option.Config.MonitorQueueSize, m.flowDecoder, req.Filters, m.removeListener)
+	m.listeners[newListener] = struct{}{}
+
+	log.WithFields(logrus.Fields{
+		"count.listener": len(m.listeners),
+		"count.filters":  len(req.Filters),
+		"version":        listener.VersionFlow1_0,
+	}).Debug("New listener connected")
+}
+
+// connectionHandlerFlow1_0 handles all the incoming connections to the flow
+// socket. Each client must send a flow.Request before flows are sent to it.
+// It will block on Accept, but expects the caller to close server, inducing a
+// return.
+func (m *Monitor) connectionHandlerFlow1_0(parentCtx context.Context, server net.Listener) {
+	for !isCtxDone(parentCtx) {
+		conn, err := server.Accept()
+		switch {
+		case isCtxDone(parentCtx) && conn != nil:
+			conn.Close()
+			fallthrough
+
+		case isCtxDone(parentCtx) && conn == nil:
+			return
+
+		case err != nil:
+			log.WithError(err).Warn("Error accepting connection")
+			continue
+		}
+
+		go m.handleFlowRequest(parentCtx, conn)
+	}
+}
+
+// handleFlowRequest reads the flow request from conn and registers a new
+// flow listener for it.
+func (m *Monitor) handleFlowRequest(parentCtx context.Context, conn net.Conn) {
+	req := &flow.Request{}
+	conn.SetReadDeadline(time.Now().Add(flowRequestTimeout))
+	if err := json.NewDecoder(conn).Decode(req); err != nil {
+		log.WithError(err).Warn("Closing flow listener connection due to invalid request")
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	m.registerNewFlowListener(parentCtx, conn, req)
+}
+
 // send enqueues the payload to all listeners.
 func (m *Monitor) send(pl *payload.Payload) {
 	m.Lock()
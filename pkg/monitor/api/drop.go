@@ -76,6 +76,7 @@ var errors = map[uint8]string{
 	170: "Encapsulation traffic is prohibited",
 	171: "Invalid identity",
 	172: "Unknown sender",
+	173: "Policy denied by deny rule",
 }
 
 // DropReason prints the drop reason in a human readable string
@@ -463,6 +463,9 @@ func (n *DebugCapture) getJSON(data []byte, cpuPrefix string) (string, error) {
 	v := DebugCaptureToVerbose(n)
 	v.CPUPrefix = cpuPrefix
 	v.Summary = GetConnectionSummary(data[DebugCaptureLen:])
+	if n.Len > 0 && len(data) > DebugCaptureLen {
+		v.Packet = GetDissectSummary(data[DebugCaptureLen:])
+	}
 
 	ret, err := json.Marshal(v)
 	return string(ret), err
@@ -472,7 +475,7 @@ func (n *DebugCapture) getJSON(data []byte, cpuPrefix string) (string, error) {
 func (n *DebugCapture) DumpJSON(data []byte, cpuPrefix string) {
 	resp, err := n.getJSON(data, cpuPrefix)
 	if err != nil {
-		fmt.Println(fmt.Sprintf(`{"type":"debug_capture_error","message":%q}`, err.Error()))
+		printJSONError("debug_capture_error", err)
 		return
 	}
 	fmt.Println(resp)
@@ -486,10 +489,18 @@ type DebugCaptureVerbose struct {
 	Message   string `json:"message,omitempty"`
 	Prefix    string `json:"prefix,omitempty"`
 
-	Source uint16 `json:"source"`
-	Bytes  uint32 `json:"bytes"`
+	// SubType is the numeric capture point Message and Prefix are
+	// derived from
+	SubType uint8 `json:"subType"`
+
+	Source  uint16 `json:"source"`
+	Bytes   uint32 `json:"bytes"`
+	OrigLen uint32 `json:"origLen"`
 
 	Summary string `json:"summary,omitempty"`
+
+	// Packet is the dissected captured packet
+	Packet *DissectSummary `json:"packet,omitempty"`
 }
 
 // DebugCaptureToVerbose creates verbose notification from base TraceNotify
@@ -497,8 +508,10 @@ func DebugCaptureToVerbose(n *DebugCapture) DebugCaptureVerbose {
 	return DebugCaptureVerbose{
 		Type:    "capture",
 		Mark:    fmt.Sprintf("%#x", n.Hash),
+		SubType: n.SubType,
 		Source:  n.Source,
 		Bytes:   n.Len,
+		OrigLen: n.OrigLen,
 		Message: n.subTypeString(),
 		Prefix:  n.infoPrefix(),
 	}
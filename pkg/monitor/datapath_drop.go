@@ -83,9 +83,11 @@ func (n *DropNotify) getJSON(data []byte, cpuPrefix string) (string, error) {
 // DumpJSON prints notification in json format
 func (n *DropNotify) DumpJSON(data []byte, cpuPrefix string) {
 	resp, err := n.getJSON(data, cpuPrefix)
-	if err == nil {
-		fmt.Println(resp)
+	if err != nil {
+		printJSONError("drop_error", err)
+		return
 	}
+	fmt.Println(resp)
 }
 
 // DropNotifyVerbose represents a json notification printed by monitor
@@ -95,8 +97,12 @@ type DropNotifyVerbose struct {
 	Mark      string `json:"mark,omitempty"`
 	Reason    string `json:"reason,omitempty"`
 
+	// ReasonCode is the numeric drop reason Reason is derived from
+	ReasonCode uint8 `json:"reasonCode"`
+
 	Source   uint16 `json:"source"`
 	Bytes    uint32 `json:"bytes"`
+	CapLen   uint32 `json:"capLen"`
 	SrcLabel uint32 `json:"srcLabel"`
 	DstLabel uint32 `json:"dstLabel"`
 	DstID    uint32 `json:"dstID"`
@@ -107,13 +113,15 @@ type DropNotifyVerbose struct {
 //DropNotifyToVerbose creates verbose notification from DropNotify
 func DropNotifyToVerbose(n *DropNotify) DropNotifyVerbose {
 	return DropNotifyVerbose{
-		Type:     "drop",
-		Mark:     fmt.Sprintf("%#x", n.Hash),
-		Reason:   api.DropReason(n.SubType),
-		Source:   n.Source,
-		Bytes:    n.OrigLen,
-		SrcLabel: n.SrcLabel,
-		DstLabel: n.DstLabel,
-		DstID:    n.DstID,
+		Type:       "drop",
+		Mark:       fmt.Sprintf("%#x", n.Hash),
+		Reason:     api.DropReason(n.SubType),
+		ReasonCode: n.SubType,
+		Source:     n.Source,
+		Bytes:      n.OrigLen,
+		CapLen:     n.CapLen,
+		SrcLabel:   n.SrcLabel,
+		DstLabel:   n.DstLabel,
+		DstID:      n.DstID,
 	}
 }
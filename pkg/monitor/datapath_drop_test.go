@@ -0,0 +1,63 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/cilium/cilium/pkg/byteorder"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MonitorSuite) TestDropNotifyJSON(c *C) {
+	// Generated in scapy:
+	// Ether(src="01:23:45:67:89:ab", dst="02:33:45:67:89:ab")/IP(src="1.2.3.4",dst="5.6.7.8")/TCP(sport=80,dport=443)
+	packetData := []byte{2, 51, 69, 103, 137, 171, 1, 35, 69, 103, 137, 171, 8, 0, 69, 0, 0, 40, 0, 1, 0, 0, 64, 6, 106, 188, 1, 2, 3, 4, 5, 6, 7, 8, 0, 80, 1, 187, 0, 0, 0, 0, 0, 0, 0, 0, 80, 2, 32, 0, 125, 196, 0, 0}
+
+	dn := DropNotify{
+		Type:     1,
+		SubType:  133,
+		Source:   42,
+		OrigLen:  uint32(len(packetData)),
+		CapLen:   uint32(len(packetData)),
+		SrcLabel: 1234,
+		DstLabel: 2,
+	}
+	buf := &bytes.Buffer{}
+	c.Assert(binary.Write(buf, byteorder.Native, &dn), IsNil)
+	buf.Write(packetData)
+
+	out, err := dn.getJSON(buf.Bytes(), "CPU 01:")
+	c.Assert(err, IsNil)
+
+	v := DropNotifyVerbose{}
+	c.Assert(json.Unmarshal([]byte(out), &v), IsNil)
+	c.Assert(v.Type, Equals, "drop")
+	c.Assert(v.CPUPrefix, Equals, "CPU 01:")
+	c.Assert(v.Reason, Equals, "Policy denied (L3)")
+	c.Assert(v.ReasonCode, Equals, uint8(133))
+	c.Assert(v.Source, Equals, uint16(42))
+	c.Assert(v.CapLen, Equals, uint32(len(packetData)))
+	c.Assert(v.SrcLabel, Equals, uint32(1234))
+	c.Assert(v.DstLabel, Equals, uint32(2))
+	c.Assert(v.Summary, Not(IsNil))
+	c.Assert(v.Summary.L3.Src, Equals, "1.2.3.4")
+	c.Assert(v.Summary.L4.Dst, Equals, "443")
+}
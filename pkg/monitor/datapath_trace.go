@@ -72,7 +72,8 @@ var traceObsPoints = map[uint8]string{
 	TraceFromNetwork: "from-network",
 }
 
-func obsPoint(obsPoint uint8) string {
+// TraceObservationPoint returns the name of the trace observation point
+func TraceObservationPoint(obsPoint uint8) string {
 	if str, ok := traceObsPoints[obsPoint]; ok {
 		return str
 	}
@@ -158,7 +159,7 @@ func (n *TraceNotify) DumpInfo(data []byte) {
 // DumpVerbose prints the trace notification in human readable form
 func (n *TraceNotify) DumpVerbose(dissect bool, data []byte, prefix string) {
 	fmt.Printf("%s MARK %#x FROM %d %s: %d bytes (%d captured), state %s",
-		prefix, n.Hash, n.Source, obsPoint(n.ObsPoint), n.OrigLen, n.CapLen, connState(n.Reason))
+		prefix, n.Hash, n.Source, TraceObservationPoint(n.ObsPoint), n.OrigLen, n.CapLen, connState(n.Reason))
 
 	if n.Ifindex != 0 {
 		fmt.Printf(", interface %s", ifname(int(n.Ifindex)))
@@ -193,9 +194,11 @@ func (n *TraceNotify) getJSON(data []byte, cpuPrefix string) (string, error) {
 // DumpJSON prints notification in json format
 func (n *TraceNotify) DumpJSON(data []byte, cpuPrefix string) {
 	resp, err := n.getJSON(data, cpuPrefix)
-	if err == nil {
-		fmt.Println(resp)
+	if err != nil {
+		printJSONError("trace_error", err)
+		return
 	}
+	fmt.Println(resp)
 }
 
 // TraceNotifyVerbose represents a json notification printed by monitor
@@ -205,11 +208,13 @@ type TraceNotifyVerbose struct {
 	Mark             string `json:"mark,omitempty"`
 	Ifindex          string `json:"ifindex,omitempty"`
 	State            string `json:"state,omitempty"`
+	Encrypted        bool   `json:"encrypted,omitempty"`
 	ObservationPoint string `json:"observationPoint"`
 	TraceSummary     string `json:"traceSummary"`
 
 	Source   uint16 `json:"source"`
 	Bytes    uint32 `json:"bytes"`
+	CapLen   uint32 `json:"capLen"`
 	SrcLabel uint32 `json:"srcLabel"`
 	DstLabel uint32 `json:"dstLabel"`
 	DstID    uint16 `json:"dstID"`
@@ -224,10 +229,12 @@ func TraceNotifyToVerbose(n *TraceNotify) TraceNotifyVerbose {
 		Mark:             fmt.Sprintf("%#x", n.Hash),
 		Ifindex:          ifname(int(n.Ifindex)),
 		State:            connState(n.Reason),
-		ObservationPoint: obsPoint(n.ObsPoint),
+		Encrypted:        (n.Reason & TraceReasonEncryptMask) != 0,
+		ObservationPoint: TraceObservationPoint(n.ObsPoint),
 		TraceSummary:     n.traceSummary(),
 		Source:           n.Source,
 		Bytes:            n.OrigLen,
+		CapLen:           n.CapLen,
 		SrcLabel:         n.SrcLabel,
 		DstLabel:         n.DstLabel,
 		DstID:            n.DstID,
@@ -164,6 +164,60 @@ func GetConnectionSummary(data []byte) string {
 	return "[unknown]"
 }
 
+// ConnectionInfo is the decoded L3/L4 information of a packet
+type ConnectionInfo struct {
+	SrcIP    net.IP
+	DstIP    net.IP
+	Proto    string
+	SrcPort  uint16
+	DstPort  uint16
+	TCPFlags string
+	ICMPCode string
+}
+
+// GetConnectionInfo decodes the data into layers and returns the L3/L4
+// information of the packet. Fields of layers which are not present in the
+// data are left empty.
+func GetConnectionInfo(data []byte) *ConnectionInfo {
+	dissectLock.Lock()
+	defer dissectLock.Unlock()
+
+	initParser()
+	parser.DecodeLayers(data, &cache.decoded)
+
+	info := &ConnectionInfo{}
+	for _, typ := range cache.decoded {
+		switch typ {
+		case layers.LayerTypeIPv4:
+			info.SrcIP = copyIP(cache.ip4.SrcIP)
+			info.DstIP = copyIP(cache.ip4.DstIP)
+		case layers.LayerTypeIPv6:
+			info.SrcIP = copyIP(cache.ip6.SrcIP)
+			info.DstIP = copyIP(cache.ip6.DstIP)
+		case layers.LayerTypeTCP:
+			info.Proto = "tcp"
+			info.SrcPort, info.DstPort = uint16(cache.tcp.SrcPort), uint16(cache.tcp.DstPort)
+			info.TCPFlags = getTCPInfo()
+		case layers.LayerTypeUDP:
+			info.Proto = "udp"
+			info.SrcPort, info.DstPort = uint16(cache.udp.SrcPort), uint16(cache.udp.DstPort)
+		case layers.LayerTypeICMPv4:
+			info.Proto = "icmp"
+			info.ICMPCode = cache.icmp4.TypeCode.String()
+		case layers.LayerTypeICMPv6:
+			info.Proto = "icmpv6"
+			info.ICMPCode = cache.icmp6.TypeCode.String()
+		}
+	}
+	return info
+}
+
+// copyIP returns a copy of ip, as the decoded layers in the parser cache are
+// reused for the next packet.
+func copyIP(ip net.IP) net.IP {
+	return append(net.IP(nil), ip...)
+}
+
 // Dissect parses and prints the provided data if dissect is set to true,
 // otherwise the data is printed as HEX output
 func Dissect(dissect bool, data []byte) {
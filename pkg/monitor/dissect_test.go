@@ -61,3 +61,18 @@ func (s *MonitorSuite) TestDissectSummary(c *C) {
 	c.Assert(summary.L4.Src, Equals, sport)
 	c.Assert(summary.L4.Dst, Equals, dport)
 }
+
+func (s *MonitorSuite) TestConnectionInfo(c *C) {
+	// Generated in scapy:
+	// Ether(src="01:23:45:67:89:ab", dst="02:33:45:67:89:ab")/IP(src="1.2.3.4",dst="5.6.7.8")/TCP(sport=80,dport=443)
+	packetData := []byte{2, 51, 69, 103, 137, 171, 1, 35, 69, 103, 137, 171, 8, 0, 69, 0, 0, 40, 0, 1, 0, 0, 64, 6, 106, 188, 1, 2, 3, 4, 5, 6, 7, 8, 0, 80, 1, 187, 0, 0, 0, 0, 0, 0, 0, 0, 80, 2, 32, 0, 125, 196, 0, 0}
+
+	info := GetConnectionInfo(packetData)
+
+	c.Assert(info.SrcIP.String(), Equals, "1.2.3.4")
+	c.Assert(info.DstIP.String(), Equals, "5.6.7.8")
+	c.Assert(info.Proto, Equals, "tcp")
+	c.Assert(info.SrcPort, Equals, uint16(80))
+	c.Assert(info.DstPort, Equals, uint16(443))
+	c.Assert(info.TCPFlags, Equals, "SYN")
+}
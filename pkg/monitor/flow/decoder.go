@@ -0,0 +1,258 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cilium/cilium/pkg/byteorder"
+	"github.com/cilium/cilium/pkg/identity"
+	k8sConst "github.com/cilium/cilium/pkg/k8s/apis/cilium.io"
+	"github.com/cilium/cilium/pkg/labels"
+	"github.com/cilium/cilium/pkg/monitor"
+	monitorAPI "github.com/cilium/cilium/pkg/monitor/api"
+	"github.com/cilium/cilium/pkg/monitor/payload"
+	"github.com/cilium/cilium/pkg/proxy/accesslog"
+)
+
+// ErrUnsupportedEvent is returned by Decode for events which do not
+// describe a flow, e.g. debug messages, agent notifications or lost events.
+var ErrUnsupportedEvent = errors.New("event does not describe a flow")
+
+// IdentityGetter resolves numeric security identities
+type IdentityGetter interface {
+	// LookupIdentityByID returns the identity with the given numeric ID,
+	// or nil if it is not known
+	LookupIdentityByID(id identity.NumericIdentity) *identity.Identity
+}
+
+// EndpointGetter resolves local endpoints
+type EndpointGetter interface {
+	// GetEndpointPodInfo returns the Kubernetes namespace and pod name of
+	// the local endpoint with the given ID. ok is false if the endpoint
+	// is not known.
+	GetEndpointPodInfo(id uint64) (namespace, podName string, ok bool)
+}
+
+// Decoder decodes monitor payloads into flows. The getters are optional, if
+// they are nil the corresponding fields of the flow are left empty.
+type Decoder struct {
+	Identities IdentityGetter
+	Endpoints  EndpointGetter
+
+	// now returns the current time, it is overridden in unit tests
+	now func() time.Time
+}
+
+// NewDecoder returns a new decoder using the given getters
+func NewDecoder(identities IdentityGetter, endpoints EndpointGetter) *Decoder {
+	return &Decoder{
+		Identities: identities,
+		Endpoints:  endpoints,
+		now:        time.Now,
+	}
+}
+
+// Decode decodes the payload into a flow. ErrUnsupportedEvent is returned
+// if the payload does not describe a flow.
+func (d *Decoder) Decode(pl *payload.Payload) (*Flow, error) {
+	if pl.Type != payload.EventSample || len(pl.Data) == 0 {
+		return nil, ErrUnsupportedEvent
+	}
+
+	var (
+		f   *Flow
+		err error
+	)
+	switch pl.Data[0] {
+	case monitorAPI.MessageTypeDrop:
+		f, err = d.decodeDrop(pl.Data)
+	case monitorAPI.MessageTypeTrace:
+		f, err = d.decodeTrace(pl.Data)
+	case monitorAPI.MessageTypeAccessLog:
+		f, err = d.decodeLogRecord(pl.Data)
+	default:
+		return nil, ErrUnsupportedEvent
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.CPU = pl.CPU
+	d.resolveEndpoint(&f.Source)
+	d.resolveEndpoint(&f.Destination)
+	return f, nil
+}
+
+func (d *Decoder) decodeDrop(data []byte) (*Flow, error) {
+	dn := monitor.DropNotify{}
+	if err := binary.Read(bytes.NewReader(data), byteorder.Native, &dn); err != nil {
+		return nil, fmt.Errorf("unable to parse drop notification: %s", err)
+	}
+
+	f := &Flow{
+		Time:        d.now(),
+		Type:        TypeDrop,
+		Verdict:     VerdictDropped,
+		DropReason:  monitorAPI.DropReason(dn.SubType),
+		Source:      Endpoint{ID: uint64(dn.Source), Identity: dn.SrcLabel},
+		Destination: Endpoint{ID: uint64(dn.DstID), Identity: dn.DstLabel},
+	}
+	if dn.CapLen > 0 && len(data) > monitor.DropNotifyLen {
+		setConnectionInfo(f, data[monitor.DropNotifyLen:])
+	}
+	return f, nil
+}
+
+func (d *Decoder) decodeTrace(data []byte) (*Flow, error) {
+	tn := monitor.TraceNotify{}
+	if err := binary.Read(bytes.NewReader(data), byteorder.Native, &tn); err != nil {
+		return nil, fmt.Errorf("unable to parse trace notification: %s", err)
+	}
+
+	f := &Flow{
+		Time:             d.now(),
+		Type:             TypeTrace,
+		Verdict:          VerdictForwarded,
+		ObservationPoint: monitor.TraceObservationPoint(tn.ObsPoint),
+		Source:           Endpoint{ID: uint64(tn.Source), Identity: tn.SrcLabel},
+		Destination:      Endpoint{ID: uint64(tn.DstID), Identity: tn.DstLabel},
+	}
+	if tn.CapLen > 0 && len(data) > monitor.TraceNotifyLen {
+		setConnectionInfo(f, data[monitor.TraceNotifyLen:])
+	}
+	return f, nil
+}
+
+func (d *Decoder) decodeLogRecord(data []byte) (*Flow, error) {
+	lr := monitor.LogRecordNotify{}
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&lr); err != nil {
+		return nil, fmt.Errorf("unable to decode log record notification: %s", err)
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, lr.Timestamp)
+	if err != nil {
+		ts = d.now()
+	}
+
+	f := &Flow{
+		Time:             ts,
+		Type:             TypeL7,
+		ObservationPoint: string(lr.ObservationPoint),
+		Source:           logRecordEndpoint(&lr.SourceEndpoint),
+		Destination:      logRecordEndpoint(&lr.DestinationEndpoint),
+		L7: &L7{
+			Type:     lr.Type,
+			Protocol: lr.L7Proto(),
+			HTTP:     lr.HTTP,
+			Kafka:    lr.Kafka,
+			DNS:      lr.DNS,
+			Other:    lr.L7,
+		},
+	}
+
+	switch lr.Verdict {
+	case accesslog.VerdictForwarded:
+		f.Verdict = VerdictForwarded
+	case accesslog.VerdictDenied:
+		f.Verdict = VerdictDropped
+		f.DropReason = lr.Info
+	default:
+		f.Verdict = VerdictError
+	}
+
+	if lr.IPVersion == accesslog.VersionIPV6 {
+		f.IP = &IP{Source: lr.SourceEndpoint.IPv6, Destination: lr.DestinationEndpoint.IPv6}
+	} else {
+		f.IP = &IP{Source: lr.SourceEndpoint.IPv4, Destination: lr.DestinationEndpoint.IPv4}
+	}
+	if lr.SourceEndpoint.Port != 0 || lr.DestinationEndpoint.Port != 0 {
+		f.L4 = &L4{
+			Protocol:        "tcp",
+			SourcePort:      lr.SourceEndpoint.Port,
+			DestinationPort: lr.DestinationEndpoint.Port,
+		}
+		if lr.DNS != nil {
+			f.L4.Protocol = "udp"
+		}
+	}
+
+	return f, nil
+}
+
+func logRecordEndpoint(info *accesslog.EndpointInfo) Endpoint {
+	return Endpoint{
+		ID:       info.ID,
+		Identity: uint32(info.Identity),
+		Labels:   info.Labels,
+	}
+}
+
+func setConnectionInfo(f *Flow, data []byte) {
+	info := monitor.GetConnectionInfo(data)
+	if info.SrcIP != nil && info.DstIP != nil {
+		f.IP = &IP{Source: info.SrcIP.String(), Destination: info.DstIP.String()}
+	}
+	if info.Proto != "" {
+		f.L4 = &L4{
+			Protocol:        info.Proto,
+			SourcePort:      info.SrcPort,
+			DestinationPort: info.DstPort,
+			TCPFlags:        info.TCPFlags,
+			ICMPCode:        info.ICMPCode,
+		}
+	}
+}
+
+// resolveEndpoint fills in the labels, namespace and pod name of the
+// endpoint if they are not known yet.
+func (d *Decoder) resolveEndpoint(ep *Endpoint) {
+	if len(ep.Labels) == 0 && ep.Identity != 0 && d.Identities != nil {
+		if id := d.Identities.LookupIdentityByID(identity.NumericIdentity(ep.Identity)); id != nil {
+			if id.LabelArray != nil {
+				ep.Labels = id.LabelArray.GetModel()
+			} else {
+				ep.Labels = id.Labels.LabelArray().GetModel()
+			}
+		}
+	}
+
+	if ep.ID != 0 && d.Endpoints != nil {
+		if namespace, podName, ok := d.Endpoints.GetEndpointPodInfo(ep.ID); ok {
+			ep.Namespace, ep.PodName = namespace, podName
+		}
+	}
+
+	if ep.Namespace == "" {
+		ep.Namespace = namespaceFromLabels(ep.Labels)
+	}
+}
+
+// namespaceFromLabels returns the value of the Kubernetes pod namespace label
+// in lbls, or an empty string if there is none.
+func namespaceFromLabels(lbls []string) string {
+	for _, l := range lbls {
+		lbl := labels.ParseLabel(l)
+		if lbl.Source == labels.LabelSourceK8s && lbl.Key == k8sConst.PodNamespaceLabel {
+			return lbl.Value
+		}
+	}
+	return ""
+}
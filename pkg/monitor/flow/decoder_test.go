@@ -0,0 +1,217 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package flow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/pkg/byteorder"
+	"github.com/cilium/cilium/pkg/identity"
+	"github.com/cilium/cilium/pkg/labels"
+	"github.com/cilium/cilium/pkg/monitor"
+	monitorAPI "github.com/cilium/cilium/pkg/monitor/api"
+	"github.com/cilium/cilium/pkg/monitor/payload"
+	"github.com/cilium/cilium/pkg/proxy/accesslog"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type FlowSuite struct{}
+
+var _ = Suite(&FlowSuite{})
+
+// Generated in scapy:
+// Ether(src="01:23:45:67:89:ab", dst="02:33:45:67:89:ab")/IP(src="1.2.3.4",dst="5.6.7.8")/TCP(sport=80,dport=443)
+var packetData = []byte{2, 51, 69, 103, 137, 171, 1, 35, 69, 103, 137, 171, 8, 0, 69, 0, 0, 40, 0, 1, 0, 0, 64, 6, 106, 188, 1, 2, 3, 4, 5, 6, 7, 8, 0, 80, 1, 187, 0, 0, 0, 0, 0, 0, 0, 0, 80, 2, 32, 0, 125, 196, 0, 0}
+
+var testTime = time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type testIdentityGetter map[identity.NumericIdentity]*identity.Identity
+
+func (t testIdentityGetter) LookupIdentityByID(id identity.NumericIdentity) *identity.Identity {
+	return t[id]
+}
+
+type testEndpointGetter map[uint64][2]string
+
+func (t testEndpointGetter) GetEndpointPodInfo(id uint64) (string, string, bool) {
+	info, ok := t[id]
+	return info[0], info[1], ok
+}
+
+func newTestDecoder() *Decoder {
+	d := NewDecoder(
+		testIdentityGetter{
+			1234: identity.NewIdentity(1234, labels.Map2Labels(map[string]string{
+				"app":                         "foo",
+				"io.kubernetes.pod.namespace": "default",
+			}, labels.LabelSourceK8s)),
+		},
+		testEndpointGetter{
+			42: {"kube-system", "coredns-1"},
+		})
+	d.now = func() time.Time { return testTime }
+	return d
+}
+
+func encodeNotify(c *C, hdr interface{}, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	c.Assert(binary.Write(buf, byteorder.Native, hdr), IsNil)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func (s *FlowSuite) TestDecodeDrop(c *C) {
+	dn := monitor.DropNotify{
+		Type:     monitorAPI.MessageTypeDrop,
+		SubType:  133,
+		Source:   42,
+		OrigLen:  uint32(len(packetData)),
+		CapLen:   uint32(len(packetData)),
+		SrcLabel: 1234,
+		DstLabel: 2,
+	}
+	pl := &payload.Payload{
+		Data: encodeNotify(c, &dn, packetData),
+		CPU:  3,
+		Type: payload.EventSample,
+	}
+
+	f, err := newTestDecoder().Decode(pl)
+	c.Assert(err, IsNil)
+	c.Assert(f.Time, Equals, testTime)
+	c.Assert(f.Type, Equals, TypeDrop)
+	c.Assert(f.Verdict, Equals, VerdictDropped)
+	c.Assert(f.DropReason, Equals, monitorAPI.DropReason(133))
+	c.Assert(f.CPU, Equals, 3)
+
+	c.Assert(f.Source.ID, Equals, uint64(42))
+	c.Assert(f.Source.Identity, Equals, uint32(1234))
+	c.Assert(f.Source.Labels, DeepEquals, []string{"k8s:app=foo", "k8s:io.kubernetes.pod.namespace=default"})
+	// The namespace of the endpoint takes precedence over the labels
+	c.Assert(f.Source.Namespace, Equals, "kube-system")
+	c.Assert(f.Source.PodName, Equals, "coredns-1")
+	c.Assert(f.Destination.Identity, Equals, uint32(2))
+	c.Assert(f.Destination.Labels, IsNil)
+
+	c.Assert(f.IP, DeepEquals, &IP{Source: "1.2.3.4", Destination: "5.6.7.8"})
+	c.Assert(f.L4, DeepEquals, &L4{Protocol: "tcp", SourcePort: 80, DestinationPort: 443, TCPFlags: "SYN"})
+	c.Assert(f.L7, IsNil)
+}
+
+func (s *FlowSuite) TestDecodeTrace(c *C) {
+	tn := monitor.TraceNotify{
+		Type:     monitorAPI.MessageTypeTrace,
+		ObsPoint: monitor.TraceToLxc,
+		Source:   7,
+		SrcLabel: 1234,
+		DstLabel: 5678,
+		DstID:    8,
+	}
+	pl := &payload.Payload{
+		Data: encodeNotify(c, &tn, nil),
+		Type: payload.EventSample,
+	}
+
+	f, err := newTestDecoder().Decode(pl)
+	c.Assert(err, IsNil)
+	c.Assert(f.Type, Equals, TypeTrace)
+	c.Assert(f.Verdict, Equals, VerdictForwarded)
+	c.Assert(f.ObservationPoint, Equals, "to-endpoint")
+	c.Assert(f.Source.ID, Equals, uint64(7))
+	// No endpoint information, the namespace is derived from the labels
+	c.Assert(f.Source.Namespace, Equals, "default")
+	c.Assert(f.Destination.ID, Equals, uint64(8))
+	c.Assert(f.Destination.Identity, Equals, uint32(5678))
+	c.Assert(f.Destination.Namespace, Equals, "")
+	c.Assert(f.IP, IsNil)
+	c.Assert(f.L4, IsNil)
+}
+
+func (s *FlowSuite) TestDecodeLogRecord(c *C) {
+	lr := accesslog.LogRecord{
+		Type:             accesslog.TypeRequest,
+		Timestamp:        testTime.Format(time.RFC3339Nano),
+		ObservationPoint: accesslog.Ingress,
+		Verdict:          accesslog.VerdictDenied,
+		Info:             "policy denied",
+		IPVersion:        accesslog.VersionIPv4,
+		SourceEndpoint: accesslog.EndpointInfo{
+			IPv4:     "10.0.0.1",
+			Port:     34567,
+			Identity: 5678,
+			Labels:   []string{"k8s:io.kubernetes.pod.namespace=prod"},
+		},
+		DestinationEndpoint: accesslog.EndpointInfo{
+			ID:       42,
+			IPv4:     "10.0.0.2",
+			Port:     80,
+			Identity: 1234,
+		},
+		HTTP: &accesslog.LogRecordHTTP{Method: "GET"},
+	}
+	buf := &bytes.Buffer{}
+	c.Assert(gob.NewEncoder(buf).Encode(lr), IsNil)
+	pl := &payload.Payload{
+		Data: append([]byte{byte(monitorAPI.MessageTypeAccessLog)}, buf.Bytes()...),
+		Type: payload.EventSample,
+	}
+
+	f, err := newTestDecoder().Decode(pl)
+	c.Assert(err, IsNil)
+	c.Assert(f.Time.Equal(testTime), Equals, true)
+	c.Assert(f.Type, Equals, TypeL7)
+	c.Assert(f.Verdict, Equals, VerdictDropped)
+	c.Assert(f.DropReason, Equals, "policy denied")
+	c.Assert(f.ObservationPoint, Equals, "Ingress")
+	c.Assert(f.Source.Namespace, Equals, "prod")
+	c.Assert(f.Destination.Namespace, Equals, "kube-system")
+	c.Assert(f.Destination.Labels, HasLen, 2)
+	c.Assert(f.IP, DeepEquals, &IP{Source: "10.0.0.1", Destination: "10.0.0.2"})
+	c.Assert(f.L4, DeepEquals, &L4{Protocol: "tcp", SourcePort: 34567, DestinationPort: 80})
+	c.Assert(f.L7.Protocol, Equals, "http")
+	c.Assert(f.L7.HTTP.Method, Equals, "GET")
+}
+
+func (s *FlowSuite) TestDecodeUnsupported(c *C) {
+	d := newTestDecoder()
+
+	_, err := d.Decode(&payload.Payload{Type: payload.RecordLost, Lost: 10})
+	c.Assert(err, Equals, ErrUnsupportedEvent)
+
+	_, err = d.Decode(&payload.Payload{
+		Data: []byte{byte(monitorAPI.MessageTypeDebug)},
+		Type: payload.EventSample,
+	})
+	c.Assert(err, Equals, ErrUnsupportedEvent)
+
+	_, err = d.Decode(&payload.Payload{
+		Data: []byte{byte(monitorAPI.MessageTypeDrop)},
+		Type: payload.EventSample,
+	})
+	c.Assert(err, Not(IsNil))
+	c.Assert(err, Not(Equals), ErrUnsupportedEvent)
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+// Filter selects flows. All fields which are set must match for a flow to be
+// selected, a field matches if the flow matches any of its values. An empty
+// filter selects all flows.
+type Filter struct {
+	// SourceIdentity matches the security identity of the source
+	SourceIdentity []uint32 `json:"sourceIdentity,omitempty"`
+
+	// DestinationIdentity matches the security identity of the destination
+	DestinationIdentity []uint32 `json:"destinationIdentity,omitempty"`
+
+	// Identity matches the security identity of either the source or the
+	// destination
+	Identity []uint32 `json:"identity,omitempty"`
+
+	// Endpoint matches the local endpoint ID of either the source or the
+	// destination. Peers which are not local endpoints never match.
+	Endpoint []uint64 `json:"endpoint,omitempty"`
+
+	// Namespace matches the Kubernetes namespace of either the source or
+	// the destination. Peers without a known namespace never match.
+	Namespace []string `json:"namespace,omitempty"`
+
+	// Verdict matches the verdict on the flow
+	Verdict []Verdict `json:"verdict,omitempty"`
+
+	// Type matches the type of event the flow was decoded from
+	Type []Type `json:"type,omitempty"`
+}
+
+// Match returns true if the flow is selected by the filter
+func (f *Filter) Match(fl *Flow) bool {
+	if len(f.SourceIdentity) > 0 && !containsUint32(f.SourceIdentity, fl.Source.Identity) {
+		return false
+	}
+	if len(f.DestinationIdentity) > 0 && !containsUint32(f.DestinationIdentity, fl.Destination.Identity) {
+		return false
+	}
+	if len(f.Identity) > 0 &&
+		!containsUint32(f.Identity, fl.Source.Identity) &&
+		!containsUint32(f.Identity, fl.Destination.Identity) {
+		return false
+	}
+	if len(f.Endpoint) > 0 &&
+		!containsUint64(f.Endpoint, fl.Source.ID) &&
+		!containsUint64(f.Endpoint, fl.Destination.ID) {
+		return false
+	}
+	if len(f.Namespace) > 0 &&
+		!containsString(f.Namespace, fl.Source.Namespace) &&
+		!containsString(f.Namespace, fl.Destination.Namespace) {
+		return false
+	}
+	if len(f.Verdict) > 0 {
+		found := false
+		for _, v := range f.Verdict {
+			if v == fl.Verdict {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Type) > 0 {
+		found := false
+		for _, t := range f.Type {
+			if t == fl.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Filters is a list of filters. A flow is selected if any of the filters
+// selects it, an empty list selects all flows.
+type Filters []Filter
+
+// Match returns true if the flow is selected by any of the filters, or if
+// the list is empty
+func (fs Filters) Match(fl *Flow) bool {
+	if len(fs) == 0 {
+		return true
+	}
+	for i := range fs {
+		if fs[i].Match(fl) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint32(values []uint32, v uint32) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint64(values []uint64, v uint64) bool {
+	if v == 0 {
+		return false
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
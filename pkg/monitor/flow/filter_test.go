@@ -0,0 +1,67 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package flow
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *FlowSuite) TestFilterMatch(c *C) {
+	f := &Flow{
+		Type:        TypeDrop,
+		Verdict:     VerdictDropped,
+		Source:      Endpoint{ID: 42, Identity: 1234, Namespace: "default"},
+		Destination: Endpoint{Identity: 2},
+	}
+
+	testCases := []struct {
+		filter Filter
+		match  bool
+	}{
+		{Filter{}, true},
+		{Filter{SourceIdentity: []uint32{1234}}, true},
+		{Filter{SourceIdentity: []uint32{2}}, false},
+		{Filter{DestinationIdentity: []uint32{1, 2}}, true},
+		{Filter{Identity: []uint32{2}}, true},
+		{Filter{Identity: []uint32{3}}, false},
+		{Filter{Endpoint: []uint64{42}}, true},
+		{Filter{Endpoint: []uint64{0}}, false},
+		{Filter{Namespace: []string{"default"}}, true},
+		{Filter{Namespace: []string{""}}, false},
+		{Filter{Verdict: []Verdict{VerdictForwarded, VerdictDropped}}, true},
+		{Filter{Verdict: []Verdict{VerdictForwarded}}, false},
+		{Filter{Type: []Type{TypeL7}}, false},
+		// All fields must match
+		{Filter{Identity: []uint32{1234}, Verdict: []Verdict{VerdictDropped}}, true},
+		{Filter{Identity: []uint32{1234}, Verdict: []Verdict{VerdictError}}, false},
+	}
+
+	for i, tc := range testCases {
+		c.Assert(tc.filter.Match(f), Equals, tc.match, Commentf("test case %d", i))
+	}
+}
+
+func (s *FlowSuite) TestFiltersMatch(c *C) {
+	f := &Flow{Verdict: VerdictForwarded}
+
+	c.Assert(Filters(nil).Match(f), Equals, true)
+	c.Assert(Filters{{Verdict: []Verdict{VerdictDropped}}}.Match(f), Equals, false)
+	c.Assert(Filters{
+		{Verdict: []Verdict{VerdictDropped}},
+		{Verdict: []Verdict{VerdictForwarded}},
+	}.Match(f), Equals, true)
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flow provides decoded, structured flow records for the events
+// emitted by the monitor, along with the filters and the listener protocol
+// used to export them.
+package flow
+
+import (
+	"time"
+
+	"github.com/cilium/cilium/pkg/proxy/accesslog"
+)
+
+// Verdict is the verdict on a flow
+type Verdict string
+
+const (
+	// VerdictForwarded indicates that the flow was forwarded
+	VerdictForwarded Verdict = "forwarded"
+
+	// VerdictDropped indicates that the flow was dropped or denied
+	VerdictDropped Verdict = "dropped"
+
+	// VerdictError indicates that there was an error processing the flow
+	VerdictError Verdict = "error"
+)
+
+// Type is the type of event a flow was decoded from
+type Type string
+
+const (
+	// TypeTrace is a flow decoded from a datapath trace notification
+	TypeTrace Type = "trace"
+
+	// TypeDrop is a flow decoded from a datapath drop notification
+	TypeDrop Type = "drop"
+
+	// TypeL7 is a flow decoded from a proxy access log record
+	TypeL7 Type = "l7"
+)
+
+// Endpoint describes one side of a flow
+type Endpoint struct {
+	// ID is the local endpoint ID, or 0 if the peer is not a local
+	// endpoint or the ID is not known
+	ID uint64 `json:"id,omitempty"`
+
+	// Identity is the numeric security identity
+	Identity uint32 `json:"identity,omitempty"`
+
+	// Labels are the security relevant labels of the identity
+	Labels []string `json:"labels,omitempty"`
+
+	// Namespace is the Kubernetes namespace, if known
+	Namespace string `json:"namespace,omitempty"`
+
+	// PodName is the Kubernetes pod name, if known
+	PodName string `json:"podName,omitempty"`
+}
+
+// IP is the L3 information of a flow
+type IP struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// L4 is the L4 information of a flow
+type L4 struct {
+	Protocol        string `json:"protocol"`
+	SourcePort      uint16 `json:"sourcePort,omitempty"`
+	DestinationPort uint16 `json:"destinationPort,omitempty"`
+	TCPFlags        string `json:"tcpFlags,omitempty"`
+	ICMPCode        string `json:"icmpCode,omitempty"`
+}
+
+// L7 is the L7 information of a flow, as reported by the proxy
+type L7 struct {
+	// Type is the type of the L7 record, e.g. Request or Response
+	Type accesslog.FlowType `json:"type"`
+
+	// Protocol is the L7 protocol, e.g. http, kafka or dns
+	Protocol string `json:"protocol"`
+
+	HTTP  *accesslog.LogRecordHTTP  `json:"http,omitempty"`
+	Kafka *accesslog.LogRecordKafka `json:"kafka,omitempty"`
+	DNS   *accesslog.LogRecordDNS   `json:"dns,omitempty"`
+	Other *accesslog.LogRecordL7    `json:"other,omitempty"`
+}
+
+// Flow is a decoded flow record
+type Flow struct {
+	// Time is the time at which the event was decoded for datapath
+	// events, or the timestamp reported by the proxy for L7 events
+	Time time.Time `json:"time"`
+
+	// Type is the type of the event the flow was decoded from
+	Type Type `json:"type"`
+
+	// Verdict is the verdict on the flow
+	Verdict Verdict `json:"verdict"`
+
+	// DropReason is the reason of the drop, if Verdict is
+	// VerdictDropped
+	DropReason string `json:"dropReason,omitempty"`
+
+	// ObservationPoint is where the flow was observed, e.g. to-endpoint
+	// for trace events or Ingress/Egress for L7 events
+	ObservationPoint string `json:"observationPoint,omitempty"`
+
+	// CPU is the CPU on which the event was observed
+	CPU int `json:"cpu"`
+
+	Source      Endpoint `json:"source"`
+	Destination Endpoint `json:"destination"`
+
+	IP *IP `json:"ip,omitempty"`
+	L4 *L4 `json:"l4,omitempty"`
+	L7 *L7 `json:"l7,omitempty"`
+}
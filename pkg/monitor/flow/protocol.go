@@ -0,0 +1,75 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// The flow listener protocol is a JSON stream over a unix socket. After
+// connecting, the client sends a single Request object. The server then
+// writes one Flow object per line for every flow selected by the filters of
+// the request until the connection is closed.
+
+// Request is sent by a client to subscribe to flows
+type Request struct {
+	// Filters selects the flows sent to the client. If empty, all flows
+	// are sent.
+	Filters Filters `json:"filters,omitempty"`
+}
+
+// Client is a connection to the flow listener socket of the monitor agent
+type Client struct {
+	conn net.Conn
+	dec  *json.Decoder
+}
+
+// Dial connects to the flow listener socket at path and subscribes to the
+// flows selected by req
+func Dial(path string, req *Request) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if req == nil {
+		req = &Request{}
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to send flow request: %s", err)
+	}
+
+	return &Client{
+		conn: conn,
+		dec:  json.NewDecoder(conn),
+	}, nil
+}
+
+// Next blocks until the next flow is received
+func (c *Client) Next() (*Flow, error) {
+	f := &Flow{}
+	if err := c.dec.Decode(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -18,7 +18,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/cilium/cilium/pkg/byteorder"
 	"github.com/cilium/cilium/pkg/logging"
@@ -26,6 +28,7 @@ import (
 	"github.com/cilium/cilium/pkg/monitor"
 	monitorAPI "github.com/cilium/cilium/pkg/monitor/api"
 	"github.com/cilium/cilium/pkg/monitor/payload"
+	"github.com/cilium/cilium/pkg/monitor/pcapng"
 )
 
 var log = logging.DefaultLogger.WithField(logfields.LogSubsys, "monitor-format")
@@ -59,6 +62,10 @@ type MonitorFormatter struct {
 	Hex        bool
 	JSONOutput bool
 	Verbosity  Verbosity
+
+	// PcapWriter, if set, receives the packets captured by drop and trace
+	// notifications matching the filters
+	PcapWriter *pcapng.Writer
 }
 
 // NewMonitorFormatter returns a new formatter with default configuration.
@@ -101,6 +108,13 @@ func (m *MonitorFormatter) dropEvents(prefix string, data []byte) {
 		fmt.Printf("Error while parsing drop notification message: %s\n", err)
 	}
 	if m.match(monitorAPI.MessageTypeDrop, dn.Source, uint16(dn.DstID)) {
+		if m.PcapWriter != nil && dn.CapLen > 0 && len(data) > monitor.DropNotifyLen {
+			comment := fmt.Sprintf("drop (%s) endpoint %d -> %d, identity %d -> %d",
+				monitorAPI.DropReason(dn.SubType), dn.Source, dn.DstID, dn.SrcLabel, dn.DstLabel)
+			// Drop notifications do not carry the interface
+			m.writePcap(0, data[monitor.DropNotifyLen:], dn.CapLen, dn.OrigLen, comment)
+		}
+
 		switch m.Verbosity {
 		case INFO:
 			dn.DumpInfo(data)
@@ -121,6 +135,12 @@ func (m *MonitorFormatter) traceEvents(prefix string, data []byte) {
 		fmt.Printf("Error while parsing trace notification message: %s\n", err)
 	}
 	if m.match(monitorAPI.MessageTypeTrace, tn.Source, tn.DstID) {
+		if m.PcapWriter != nil && tn.CapLen > 0 && len(data) > monitor.TraceNotifyLen {
+			comment := fmt.Sprintf("trace %s endpoint %d -> %d, identity %d -> %d",
+				monitor.TraceObservationPoint(tn.ObsPoint), tn.Source, tn.DstID, tn.SrcLabel, tn.DstLabel)
+			m.writePcap(int(tn.Ifindex), data[monitor.TraceNotifyLen:], tn.CapLen, tn.OrigLen, comment)
+		}
+
 		switch m.Verbosity {
 		case INFO:
 			tn.DumpInfo(data)
@@ -133,6 +153,25 @@ func (m *MonitorFormatter) traceEvents(prefix string, data []byte) {
 	}
 }
 
+// writePcap writes the packet captured on the interface with the given
+// ifindex to PcapWriter. At most capLen bytes of data are written. Packets of
+// unknown interfaces are assumed to start with an Ethernet header.
+func (m *MonitorFormatter) writePcap(ifindex int, data []byte, capLen, origLen uint32, comment string) {
+	if uint32(len(data)) > capLen {
+		data = data[:capLen]
+	}
+	iface := pcapng.Interface{LinkType: pcapng.LinkTypeEthernet}
+	if name, l3, ok := monitor.LinkInfo(ifindex); ok {
+		iface.Name = name
+		if l3 {
+			iface.LinkType = pcapng.LinkTypeRaw
+		}
+	}
+	if err := m.PcapWriter.WritePacket(iface, time.Now(), data, origLen, comment); err != nil {
+		log.WithError(err).Warn("Unable to write packet to pcap file")
+	}
+}
+
 // debugEvents prints out all the debug messages.
 func (m *MonitorFormatter) debugEvents(prefix string, data []byte) {
 	dm := monitor.DebugMsg{}
@@ -233,7 +272,11 @@ func (m *MonitorFormatter) FormatSample(data []byte, cpu int) {
 	case monitorAPI.MessageTypeAgent:
 		m.agentEvents(prefix, data)
 	default:
-		fmt.Printf("%s Unknown event: %+v\n", prefix, data)
+		if m.Verbosity == JSON {
+			fmt.Printf(`{"cpu":%q,"type":"unknown","messageType":%d}`+"\n", prefix, messageType)
+		} else {
+			fmt.Printf("%s Unknown event: %+v\n", prefix, data)
+		}
 	}
 }
 
@@ -242,6 +285,25 @@ func LostEvent(lost uint64, cpu int) {
 	fmt.Printf("CPU %02d: Lost %d events\n", cpu, lost)
 }
 
+// LostEventVerbose represents a json notification of lost events
+type LostEventVerbose struct {
+	CPUPrefix string `json:"cpu,omitempty"`
+	Type      string `json:"type"`
+	Lost      uint64 `json:"lost"`
+}
+
+// lostEventJSON formats a lost event in json format
+func lostEventJSON(lost uint64, cpu int) {
+	v := LostEventVerbose{
+		CPUPrefix: fmt.Sprintf("CPU %02d:", cpu),
+		Type:      "lost",
+		Lost:      lost,
+	}
+	if ret, err := json.Marshal(v); err == nil {
+		fmt.Println(string(ret))
+	}
+}
+
 // FormatEvent formats an event from the specified payload to stdout.
 //
 // Returns true if the event was successfully printed, false otherwise.
@@ -250,7 +312,11 @@ func (m *MonitorFormatter) FormatEvent(pl *payload.Payload) bool {
 	case payload.EventSample:
 		m.FormatSample(pl.Data, pl.CPU)
 	case payload.RecordLost:
-		LostEvent(pl.Lost, pl.CPU)
+		if m.Verbosity == JSON {
+			lostEventJSON(pl.Lost, pl.CPU)
+		} else {
+			LostEvent(pl.Lost, pl.CPU)
+		}
 	default:
 		return false
 	}
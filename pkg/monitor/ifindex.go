@@ -23,7 +23,14 @@ import (
 	"github.com/vishvananda/netlink"
 )
 
-type linkMap map[int]string
+// linkInfo is the name of a link and whether it is an L3 device, i.e. its
+// packets carry no Ethernet header
+type linkInfo struct {
+	name string
+	l3   bool
+}
+
+type linkMap map[int]linkInfo
 
 var (
 	ifindexMap = linkMap{}
@@ -34,13 +41,24 @@ func ifname(ifindex int) string {
 	mutex.RLock()
 	defer mutex.RUnlock()
 
-	if name, ok := ifindexMap[ifindex]; ok {
-		return name
+	if link, ok := ifindexMap[ifindex]; ok {
+		return link.name
 	}
 
 	return fmt.Sprintf("%d", ifindex)
 }
 
+// LinkInfo returns the name of the link with the given ifindex and whether
+// it is an L3 device, whose packets start with the IP header. ok is false
+// if the link is unknown.
+func LinkInfo(ifindex int) (name string, l3 bool, ok bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	link, ok := ifindexMap[ifindex]
+	return link.name, link.l3, ok
+}
+
 func init() {
 	go func() {
 		for {
@@ -52,7 +70,11 @@ func init() {
 			}
 
 			for _, link := range links {
-				newMap[link.Attrs().Index] = link.Attrs().Name
+				encap := link.Attrs().EncapType
+				newMap[link.Attrs().Index] = linkInfo{
+					name: link.Attrs().Name,
+					l3:   encap != "ether" && encap != "loopback",
+				}
 			}
 
 			mutex.Lock()
@@ -39,7 +39,8 @@ func (l *LogRecordNotify) direction() string {
 	}
 }
 
-func (l *LogRecordNotify) l7Proto() string {
+// L7Proto returns the name of the L7 protocol of the log record
+func (l *LogRecordNotify) L7Proto() string {
 	if l.HTTP != nil {
 		return "http"
 	}
@@ -64,14 +65,14 @@ func (l *LogRecordNotify) DumpInfo() {
 	switch l.Type {
 	case accesslog.TypeRequest:
 		fmt.Printf("%s %s %s from %d (%s) to %d (%s), identity %d->%d, verdict %s",
-			l.direction(), l.Type, l.l7Proto(), l.SourceEndpoint.ID, l.SourceEndpoint.Labels,
+			l.direction(), l.Type, l.L7Proto(), l.SourceEndpoint.ID, l.SourceEndpoint.Labels,
 			l.DestinationEndpoint.ID, l.DestinationEndpoint.Labels,
 			l.SourceEndpoint.Identity, l.DestinationEndpoint.Identity,
 			l.Verdict)
 
 	case accesslog.TypeResponse:
 		fmt.Printf("%s %s %s to %d (%s) from %d (%s), identity %d->%d, verdict %s",
-			l.direction(), l.Type, l.l7Proto(), l.SourceEndpoint.ID, l.SourceEndpoint.Labels,
+			l.direction(), l.Type, l.L7Proto(), l.SourceEndpoint.ID, l.SourceEndpoint.Labels,
 			l.DestinationEndpoint.ID, l.DestinationEndpoint.Labels,
 			l.SourceEndpoint.Identity, l.DestinationEndpoint.Identity,
 			l.Verdict)
@@ -178,7 +179,7 @@ func LogRecordNotifyToVerbose(n *LogRecordNotify) LogRecordNotifyVerbose {
 		Type:             "logRecord",
 		ObservationPoint: n.ObservationPoint,
 		FlowType:         n.Type,
-		L7Proto:          n.l7Proto(),
+		L7Proto:          n.L7Proto(),
 		SrcEpID:          n.SourceEndpoint.ID,
 		SrcEpLabels:      n.SourceEndpoint.Labels,
 		SrcIdentity:      n.SourceEndpoint.Identity,
@@ -0,0 +1,198 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pcapng implements a minimal writer for the pcapng capture file
+// format, see https://github.com/pcapng/pcapng. Packets may be captured on
+// interfaces of different link types, each packet may carry a comment.
+package pcapng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+)
+
+const (
+	blockTypeSectionHeader  = 0x0A0D0D0A
+	blockTypeInterfaceDesc  = 0x00000001
+	blockTypeEnhancedPacket = 0x00000006
+
+	byteOrderMagic = 0x1A2B3C4D
+
+	optEndOfOpt = 0
+	optComment  = 1
+	optIfName   = 2
+
+	// LinkTypeEthernet is the link type of packets starting with an
+	// Ethernet header
+	LinkTypeEthernet = 1
+
+	// LinkTypeRaw is the link type of packets captured on L3 devices,
+	// starting with the IPv4 or IPv6 header
+	LinkTypeRaw = 101
+
+	// DefaultSnapLen is the snapshot length announced in the interface
+	// description block
+	DefaultSnapLen = 65535
+)
+
+// byteOrder is the byte order used for all blocks. Readers detect it from
+// the byte order magic of the section header block.
+var byteOrder = binary.LittleEndian
+
+// Interface is an interface packets are captured on
+type Interface struct {
+	// Name is the name of the interface, it may be empty if unknown
+	Name string
+
+	// LinkType is the link type of the packets captured on the interface
+	LinkType uint16
+}
+
+// option is an option of a block
+type option struct {
+	code  uint16
+	value string
+}
+
+// Writer writes packets to a pcapng stream. Each block is written with a
+// single call to Write, so a stream interrupted between two packets is
+// still a valid capture. Writer is safe for concurrent use.
+type Writer struct {
+	mutex lock.Mutex
+	w     io.Writer
+
+	// interfaces maps the interfaces described in the stream so far to
+	// their interface ID
+	interfaces map[Interface]uint32
+}
+
+// NewWriter writes the section header block to w and returns a Writer to
+// write packets to it
+func NewWriter(w io.Writer) (*Writer, error) {
+	pw := &Writer{
+		w:          w,
+		interfaces: map[Interface]uint32{},
+	}
+
+	// Section header: byte order magic, version 1.0, unknown section length
+	shb := &bytes.Buffer{}
+	binary.Write(shb, byteOrder, uint32(byteOrderMagic))
+	binary.Write(shb, byteOrder, uint16(1))
+	binary.Write(shb, byteOrder, uint16(0))
+	binary.Write(shb, byteOrder, int64(-1))
+	if err := pw.writeBlock(blockTypeSectionHeader, shb.Bytes()); err != nil {
+		return nil, err
+	}
+
+	return pw, nil
+}
+
+// interfaceIDLocked returns the ID of iface, writing its interface
+// description block first if it has not been described yet. Must be called
+// with pw.mutex held.
+func (pw *Writer) interfaceIDLocked(iface Interface) (uint32, error) {
+	if id, ok := pw.interfaces[iface]; ok {
+		return id, nil
+	}
+
+	// Interface description: link type, reserved, snaplen
+	idb := &bytes.Buffer{}
+	binary.Write(idb, byteOrder, iface.LinkType)
+	binary.Write(idb, byteOrder, uint16(0))
+	binary.Write(idb, byteOrder, uint32(DefaultSnapLen))
+	var opts []option
+	if iface.Name != "" {
+		opts = append(opts, option{code: optIfName, value: iface.Name})
+	}
+	if err := pw.writeBlock(blockTypeInterfaceDesc, idb.Bytes(), opts...); err != nil {
+		return 0, err
+	}
+
+	id := uint32(len(pw.interfaces))
+	pw.interfaces[iface] = id
+	return id, nil
+}
+
+// WritePacket writes a packet captured on iface at ts. data holds the
+// captured bytes and origLen is the length of the packet on the wire. If
+// comment is not empty, it is attached to the packet.
+func (pw *Writer) WritePacket(iface Interface, ts time.Time, data []byte, origLen uint32, comment string) error {
+	// Timestamps use the default resolution of microseconds
+	usec := uint64(ts.UnixNano() / int64(time.Microsecond))
+	if origLen < uint32(len(data)) {
+		origLen = uint32(len(data))
+	}
+
+	pw.mutex.Lock()
+	defer pw.mutex.Unlock()
+
+	id, err := pw.interfaceIDLocked(iface)
+	if err != nil {
+		return err
+	}
+
+	epb := &bytes.Buffer{}
+	binary.Write(epb, byteOrder, id)
+	binary.Write(epb, byteOrder, uint32(usec>>32))
+	binary.Write(epb, byteOrder, uint32(usec))
+	binary.Write(epb, byteOrder, uint32(len(data)))
+	binary.Write(epb, byteOrder, origLen)
+	epb.Write(data)
+	epb.Write(padding(len(data)))
+
+	var opts []option
+	if comment != "" {
+		opts = append(opts, option{code: optComment, value: comment})
+	}
+	return pw.writeBlock(blockTypeEnhancedPacket, epb.Bytes(), opts...)
+}
+
+// writeBlock writes a block of type blockType with the given body and
+// options. Must be called with pw.mutex held, except by NewWriter.
+func (pw *Writer) writeBlock(blockType uint32, body []byte, options ...option) error {
+	var opts []byte
+	if len(options) > 0 {
+		buf := &bytes.Buffer{}
+		for _, opt := range options {
+			binary.Write(buf, byteOrder, opt.code)
+			binary.Write(buf, byteOrder, uint16(len(opt.value)))
+			buf.WriteString(opt.value)
+			buf.Write(padding(len(opt.value)))
+		}
+		binary.Write(buf, byteOrder, uint16(optEndOfOpt))
+		binary.Write(buf, byteOrder, uint16(0))
+		opts = buf.Bytes()
+	}
+
+	// Block type, total length, body, options, total length
+	totalLen := uint32(12 + len(body) + len(opts))
+	block := &bytes.Buffer{}
+	binary.Write(block, byteOrder, blockType)
+	binary.Write(block, byteOrder, totalLen)
+	block.Write(body)
+	block.Write(opts)
+	binary.Write(block, byteOrder, totalLen)
+
+	_, err := pw.w.Write(block.Bytes())
+	return err
+}
+
+// padding returns the padding needed to align n bytes to 32 bits
+func padding(n int) []byte {
+	return make([]byte, (4-n%4)%4)
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package pcapng
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type PcapngSuite struct{}
+
+var _ = Suite(&PcapngSuite{})
+
+type block struct {
+	blockType uint32
+	body      []byte
+}
+
+// readBlocks splits a pcapng stream into its blocks, checking that the
+// leading and trailing lengths match
+func readBlocks(c *C, data []byte) []block {
+	blocks := []block{}
+	for len(data) > 0 {
+		c.Assert(len(data) >= 12, Equals, true)
+		totalLen := byteOrder.Uint32(data[4:8])
+		c.Assert(totalLen%4, Equals, uint32(0))
+		c.Assert(int(totalLen) <= len(data), Equals, true)
+		c.Assert(byteOrder.Uint32(data[totalLen-4:totalLen]), Equals, totalLen)
+		blocks = append(blocks, block{
+			blockType: byteOrder.Uint32(data[0:4]),
+			body:      data[8 : totalLen-4],
+		})
+		data = data[totalLen:]
+	}
+	return blocks
+}
+
+func (s *PcapngSuite) TestWriter(c *C) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf)
+	c.Assert(err, IsNil)
+
+	eth := Interface{LinkType: LinkTypeEthernet}
+	ts := time.Unix(1559390400, 123456000)
+	packet := []byte{1, 2, 3, 4, 5}
+	c.Assert(w.WritePacket(eth, ts, packet, 100, "endpoint 42"), IsNil)
+	c.Assert(w.WritePacket(eth, ts, packet, 0, ""), IsNil)
+
+	blocks := readBlocks(c, buf.Bytes())
+	c.Assert(blocks, HasLen, 4)
+
+	c.Assert(blocks[0].blockType, Equals, uint32(blockTypeSectionHeader))
+	c.Assert(byteOrder.Uint32(blocks[0].body[0:4]), Equals, uint32(byteOrderMagic))
+
+	// The interface is described before its first packet, without options
+	// if it has no name
+	c.Assert(blocks[1].blockType, Equals, uint32(blockTypeInterfaceDesc))
+	c.Assert(byteOrder.Uint16(blocks[1].body[0:2]), Equals, uint16(LinkTypeEthernet))
+	c.Assert(blocks[1].body, HasLen, 8)
+
+	epb := blocks[2]
+	c.Assert(epb.blockType, Equals, uint32(blockTypeEnhancedPacket))
+	c.Assert(byteOrder.Uint32(epb.body[0:4]), Equals, uint32(0))
+	usec := uint64(byteOrder.Uint32(epb.body[4:8]))<<32 | uint64(byteOrder.Uint32(epb.body[8:12]))
+	c.Assert(usec, Equals, uint64(1559390400123456))
+	c.Assert(byteOrder.Uint32(epb.body[12:16]), Equals, uint32(len(packet)))
+	c.Assert(byteOrder.Uint32(epb.body[16:20]), Equals, uint32(100))
+	c.Assert(epb.body[20:25], DeepEquals, packet)
+
+	// Packet data is padded to 32 bits and followed by the comment option
+	opts := epb.body[28:]
+	c.Assert(byteOrder.Uint16(opts[0:2]), Equals, uint16(optComment))
+	c.Assert(byteOrder.Uint16(opts[2:4]), Equals, uint16(len("endpoint 42")))
+	c.Assert(string(opts[4:15]), Equals, "endpoint 42")
+	c.Assert(opts[16:], DeepEquals, []byte{0, 0, 0, 0})
+
+	// Without a comment there are no options, and the original length is
+	// at least the captured length
+	epb = blocks[3]
+	c.Assert(byteOrder.Uint32(epb.body[16:20]), Equals, uint32(len(packet)))
+	c.Assert(epb.body, HasLen, 28)
+}
+
+func (s *PcapngSuite) TestInterfaces(c *C) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf)
+	c.Assert(err, IsNil)
+
+	eth := Interface{Name: "eth0", LinkType: LinkTypeEthernet}
+	wg := Interface{Name: "cilium_wg0", LinkType: LinkTypeRaw}
+	ts := time.Unix(1559390400, 0)
+	packet := []byte{0x45, 0, 0, 20}
+	c.Assert(w.WritePacket(eth, ts, packet, 0, ""), IsNil)
+	c.Assert(w.WritePacket(wg, ts, packet, 0, ""), IsNil)
+	c.Assert(w.WritePacket(eth, ts, packet, 0, ""), IsNil)
+
+	blocks := readBlocks(c, buf.Bytes())
+	c.Assert(blocks, HasLen, 6)
+
+	// Each interface is described once with its link type and name
+	for i, iface := range []struct {
+		block int
+		Interface
+	}{{1, eth}, {3, wg}} {
+		idb := blocks[iface.block]
+		c.Assert(idb.blockType, Equals, uint32(blockTypeInterfaceDesc))
+		c.Assert(byteOrder.Uint16(idb.body[0:2]), Equals, iface.LinkType)
+		opts := idb.body[8:]
+		c.Assert(byteOrder.Uint16(opts[0:2]), Equals, uint16(optIfName))
+		c.Assert(byteOrder.Uint16(opts[2:4]), Equals, uint16(len(iface.Name)))
+		c.Assert(string(opts[4:4+len(iface.Name)]), Equals, iface.Name)
+
+		epb := blocks[iface.block+1]
+		c.Assert(epb.blockType, Equals, uint32(blockTypeEnhancedPacket))
+		c.Assert(byteOrder.Uint32(epb.body[0:4]), Equals, uint32(i))
+	}
+
+	// Packets of an interface described before refer to its ID
+	c.Assert(blocks[5].blockType, Equals, uint32(blockTypeEnhancedPacket))
+	c.Assert(byteOrder.Uint32(blocks[5].body[0:4]), Equals, uint32(0))
+}
@@ -15,6 +15,8 @@
 package monitor
 
 import (
+	"encoding/json"
+	"fmt"
 	"sort"
 
 	monitorAPI "github.com/cilium/cilium/pkg/monitor/api"
@@ -24,6 +26,19 @@ import (
 
 var _ pflag.Value = &monitorAPI.MessageTypeFilter{}
 
+// jsonError is printed in place of a notification which cannot be encoded
+// to JSON
+type jsonError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// printJSONError prints err as a JSON message of the given type
+func printJSONError(msgType string, err error) {
+	b, _ := json.Marshal(jsonError{Type: msgType, Message: err.Error()})
+	fmt.Println(string(b))
+}
+
 // GetAllTypes returns a slice of all known message types, sorted
 func GetAllTypes() []string {
 	types := make([]string, len(monitorAPI.MessageTypeNames))
@@ -54,6 +54,16 @@ const (
 	//    Total extra bytes:  77B
 	EncryptionIPsecOverhead = 77
 
+	// WireguardOverhead is the number of bytes used for WireGuard
+	// encapsulation. It accounts for:
+	//    Outer IPv6 header:  40B
+	//    Outer UDP header:    8B
+	//    WireGuard header:   16B
+	//    Poly1305 tag:       16B
+	//			  ---
+	//    Total extra bytes:  80B
+	WireguardOverhead = 80
+
 	// EncryptionDefaultAuthKeyLength is 16 representing 128B key recommended
 	// size for GCM(AES*) in RFC4106. Users may input other lengths via
 	// key secrets.
@@ -82,15 +92,18 @@ type Configuration struct {
 	// which will include additional encryption overhead if needed.
 	encryptMTU int
 
-	encapEnabled   bool
-	encryptEnabled bool
+	encapEnabled     bool
+	encryptEnabled   bool
+	wireguardEnabled bool
 }
 
 // NewConfiguration returns a new MTU configuration. The MTU can be manually
 // specified, otherwise it will be automatically detected. if encapEnabled is
 // true, the MTU is adjusted to account for encapsulation overhead for all
-// routes involved in node to node communication.
-func NewConfiguration(authKeySize int, encryptEnabled bool, encapEnabled bool, mtu int) Configuration {
+// routes involved in node to node communication. If wireguardEnabled is true,
+// the MTU is adjusted to account for the WireGuard encapsulation overhead in
+// place of the IPsec overhead.
+func NewConfiguration(authKeySize int, encryptEnabled bool, encapEnabled bool, wireguardEnabled bool, mtu int) Configuration {
 	encryptOverhead := 0
 
 	if mtu == 0 {
@@ -109,12 +122,17 @@ func NewConfiguration(authKeySize int, encryptEnabled bool, encapEnabled bool, m
 		encryptOverhead = EncryptionIPsecOverhead + (authKeySize - EncryptionDefaultAuthKeyLength)
 	}
 
+	if wireguardEnabled {
+		encryptOverhead = WireguardOverhead
+	}
+
 	conf := Configuration{
-		standardMTU:    mtu,
-		tunnelMTU:      mtu - (TunnelOverhead + encryptOverhead),
-		encryptMTU:     mtu - encryptOverhead,
-		encapEnabled:   encapEnabled,
-		encryptEnabled: encryptEnabled,
+		standardMTU:      mtu,
+		tunnelMTU:        mtu - (TunnelOverhead + encryptOverhead),
+		encryptMTU:       mtu - encryptOverhead,
+		encapEnabled:     encapEnabled,
+		encryptEnabled:   encryptEnabled || wireguardEnabled,
+		wireguardEnabled: wireguardEnabled,
 	}
 
 	if conf.tunnelMTU < 0 {
@@ -134,12 +152,18 @@ func (c *Configuration) GetRouteMTU() int {
 
 	if c.encryptEnabled && !c.encapEnabled {
 		if c.encryptMTU == 0 {
+			if c.wireguardEnabled {
+				return EthernetMTU - WireguardOverhead
+			}
 			return EthernetMTU - EncryptionIPsecOverhead
 		}
 		return c.encryptMTU
 	}
 
 	if c.tunnelMTU == 0 {
+		if c.wireguardEnabled {
+			return EthernetMTU - (TunnelOverhead + WireguardOverhead)
+		}
 		if c.encryptEnabled {
 			return EthernetMTU - (TunnelOverhead + EncryptionIPsecOverhead)
 		}
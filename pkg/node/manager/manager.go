@@ -235,33 +235,40 @@ func (m *Manager) backgroundSyncInterval() time.Duration {
 	return m.ClusterSizeDependantInterval(baseBackgroundSyncInterval)
 }
 
+// ValidateNodes validates the datapath implementation of all nodes. This
+// reinstalls the datapath state of all nodes, e.g. after the encryption keys
+// have changed.
+func (m *Manager) ValidateNodes() {
+	// get a copy of the node identities to avoid locking the entire manager
+	// throughout the process of running the datapath validation.
+	nodes := m.GetNodeIdentities()
+	for _, nodeIdentity := range nodes {
+		// Retrieve latest node information in case any event
+		// changed the node since the call to GetNodes()
+		m.mutex.RLock()
+		entry, ok := m.nodes[nodeIdentity]
+		if !ok {
+			m.mutex.RUnlock()
+			continue
+		}
+
+		entry.mutex.Lock()
+		m.mutex.RUnlock()
+		m.Iter(func(nh datapath.NodeHandler) {
+			nh.NodeValidateImplementation(entry.node)
+		})
+		entry.mutex.Unlock()
+
+		m.metricDatapathValidations.Inc()
+	}
+}
+
 func (m *Manager) backgroundSync() {
 	for {
 		syncInterval := m.backgroundSyncInterval()
 		log.WithField("syncInterval", syncInterval.String()).Debug("Performing regular background work")
 
-		// get a copy of the node identities to avoid locking the entire manager
-		// throughout the process of running the datapath validation.
-		nodes := m.GetNodeIdentities()
-		for _, nodeIdentity := range nodes {
-			// Retrieve latest node information in case any event
-			// changed the node since the call to GetNodes()
-			m.mutex.RLock()
-			entry, ok := m.nodes[nodeIdentity]
-			if !ok {
-				m.mutex.RUnlock()
-				continue
-			}
-
-			entry.mutex.Lock()
-			m.mutex.RUnlock()
-			m.Iter(func(nh datapath.NodeHandler) {
-				nh.NodeValidateImplementation(entry.node)
-			})
-			entry.mutex.Unlock()
-
-			m.metricDatapathValidations.Inc()
-		}
+		m.ValidateNodes()
 
 		select {
 		case <-m.closeChan:
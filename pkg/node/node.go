@@ -44,11 +44,12 @@ func (nn Identity) String() string {
 // instance. Invalid IP and CIDRs are silently ignored
 func ParseCiliumNode(n *ciliumv2.CiliumNode) (node Node) {
 	node = Node{
-		Name:          n.Name,
-		EncryptionKey: uint8(n.Spec.Encryption.Key),
-		Cluster:       option.Config.ClusterName,
-		ClusterID:     option.Config.ClusterID,
-		Source:        source.CustomResource,
+		Name:               n.Name,
+		EncryptionKey:      uint8(n.Spec.Encryption.Key),
+		WireguardPublicKey: n.Spec.Wireguard.PublicKey,
+		Cluster:            option.Config.ClusterName,
+		ClusterID:          option.Config.ClusterID,
+		Source:             source.CustomResource,
 	}
 
 	for _, cidrString := range n.Spec.IPAM.PodCIDRs {
@@ -115,6 +116,10 @@ type Node struct {
 
 	// Key index used for transparent encryption or 0 for no encryption
 	EncryptionKey uint8
+
+	// WireguardPublicKey is the base64 encoded WireGuard public key of the
+	// node or empty if WireGuard is disabled
+	WireguardPublicKey string
 }
 
 // Fullname returns the node's full name including the cluster name if a
@@ -318,7 +323,8 @@ func (n *Node) PublicAttrEquals(o *Node) bool {
 		n.IPv4HealthIP.Equal(o.IPv4HealthIP) &&
 		n.IPv6HealthIP.Equal(o.IPv6HealthIP) &&
 		n.ClusterID == o.ClusterID &&
-		n.Source == o.Source {
+		n.Source == o.Source &&
+		n.WireguardPublicKey == o.WireguardPublicKey {
 
 		if len(n.IPAddresses) != len(o.IPAddresses) {
 			return false
@@ -45,6 +45,8 @@ var (
 	ipv6AllocRange      *cidr.CIDR
 
 	ipsecKeyIdentity uint8
+
+	wireguardPublicKey string
 )
 
 func makeIPv6HostIP() net.IP {
@@ -432,3 +434,15 @@ func SetIPsecKeyIdentity(id uint8) {
 func GetIPsecKeyIdentity() uint8 {
 	return ipsecKeyIdentity
 }
+
+// SetWireguardPublicKey sets the base64 encoded WireGuard public key of the
+// node
+func SetWireguardPublicKey(key string) {
+	wireguardPublicKey = key
+}
+
+// GetWireguardPublicKey returns the WireGuard public key of the node or an
+// empty string if WireGuard is disabled
+func GetWireguardPublicKey() string {
+	return wireguardPublicKey
+}
@@ -90,6 +90,9 @@ func (s *NodeSuite) TestParseCiliumNode(c *C) {
 			Encryption: ciliumv2.EncryptionSpec{
 				Key: 10,
 			},
+			Wireguard: ciliumv2.WireguardSpec{
+				PublicKey: "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo=",
+			},
 			IPAM: ciliumv2.IPAMSpec{
 				PodCIDRs: []string{
 					"10.10.0.0/16",
@@ -113,11 +116,12 @@ func (s *NodeSuite) TestParseCiliumNode(c *C) {
 			{Type: addressing.NodeInternalIP, IP: net.ParseIP("c0de::1")},
 			{Type: addressing.NodeExternalIP, IP: net.ParseIP("c0de::2")},
 		},
-		EncryptionKey: uint8(10),
-		IPv4AllocCIDR: cidr.MustParseCIDR("10.10.0.0/16"),
-		IPv6AllocCIDR: cidr.MustParseCIDR("c0de::/96"),
-		IPv4HealthIP:  net.ParseIP("1.1.1.1"),
-		IPv6HealthIP:  net.ParseIP("c0de::1"),
+		EncryptionKey:      uint8(10),
+		WireguardPublicKey: "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo=",
+		IPv4AllocCIDR:      cidr.MustParseCIDR("10.10.0.0/16"),
+		IPv6AllocCIDR:      cidr.MustParseCIDR("c0de::/96"),
+		IPv4HealthIP:       net.ParseIP("1.1.1.1"),
+		IPv6HealthIP:       net.ParseIP("c0de::1"),
 	})
 }
 
@@ -19,12 +19,15 @@ import (
 	"time"
 
 	"github.com/cilium/cilium/pkg/aws/metadata"
+	azuremetadata "github.com/cilium/cilium/pkg/azure/metadata"
+	azuretypes "github.com/cilium/cilium/pkg/azure/types"
 	"github.com/cilium/cilium/pkg/cidr"
 	"github.com/cilium/cilium/pkg/controller"
 	"github.com/cilium/cilium/pkg/datapath"
 	"github.com/cilium/cilium/pkg/defaults"
 	"github.com/cilium/cilium/pkg/k8s"
 	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging"
 	"github.com/cilium/cilium/pkg/logging/logfields"
 	"github.com/cilium/cilium/pkg/mtu"
@@ -55,6 +58,11 @@ type NodeDiscovery struct {
 	Registrar   nodestore.NodeRegistrar
 	LocalNode   node.Node
 	Registered  chan struct{}
+	controllers *controller.Manager
+
+	// localNodeLock protects LocalNode against concurrent modifications
+	// once discovery has started
+	localNodeLock lock.Mutex
 }
 
 func enableLocalNodeRoute() bool {
@@ -95,6 +103,7 @@ func NewNodeDiscovery(manager *nodemanager.Manager, mtuConfig mtu.Configuration)
 			EnableLocalNodeRoute:    enableLocalNodeRoute(),
 			AuxiliaryPrefixes:       auxPrefixes,
 			EnableIPSec:             option.Config.EnableIPSec,
+			EnableWireguard:         option.Config.EnableWireguard,
 			EncryptNode:             option.Config.EncryptNode,
 			IPv4PodSubnets:          option.Config.IPv4PodSubnets,
 			IPv6PodSubnets:          option.Config.IPv6PodSubnets,
@@ -102,7 +111,8 @@ func NewNodeDiscovery(manager *nodemanager.Manager, mtuConfig mtu.Configuration)
 		LocalNode: node.Node{
 			Source: source.Local,
 		},
-		Registered: make(chan struct{}),
+		Registered:  make(chan struct{}),
+		controllers: controller.NewManager(),
 	}
 }
 
@@ -118,6 +128,7 @@ type Configuration interface {
 // agent startup to configure the local node based on the configuration options
 // passed to the agent. nodeName is the name to be used in the local agent.
 func (n *NodeDiscovery) StartDiscovery(nodeName string, conf Configuration) {
+	n.localNodeLock.Lock()
 	n.LocalNode.Name = nodeName
 	n.LocalNode.Cluster = option.Config.ClusterName
 	n.LocalNode.IPAddresses = []node.Address{}
@@ -125,6 +136,7 @@ func (n *NodeDiscovery) StartDiscovery(nodeName string, conf Configuration) {
 	n.LocalNode.IPv6AllocCIDR = node.GetIPv6AllocRange()
 	n.LocalNode.ClusterID = option.Config.ClusterID
 	n.LocalNode.EncryptionKey = node.GetIPsecKeyIdentity()
+	n.LocalNode.WireguardPublicKey = node.GetWireguardPublicKey()
 
 	if node.GetExternalIPv4() != nil {
 		n.LocalNode.IPAddresses = append(n.LocalNode.IPAddresses, node.Address{
@@ -155,11 +167,12 @@ func (n *NodeDiscovery) StartDiscovery(nodeName string, conf Configuration) {
 	}
 
 	n.Manager.NodeUpdated(n.LocalNode)
+	n.localNodeLock.Unlock()
 
 	go func() {
 		log.Info("Adding local node to cluster")
 		for {
-			if err := n.Registrar.RegisterNode(&n.LocalNode, n.Manager); err != nil {
+			if err := n.Registrar.RegisterNode(n.localNodeCopy(), n.Manager); err != nil {
 				log.WithError(err).Error("Unable to initialize local node. Retrying...")
 				time.Sleep(time.Second)
 			} else {
@@ -189,7 +202,7 @@ func (n *NodeDiscovery) StartDiscovery(nodeName string, conf Configuration) {
 			controller.NewManager().UpdateController("propagating local node change to kv-store",
 				controller.ControllerParams{
 					DoFunc: func(ctx context.Context) error {
-						err := n.Registrar.UpdateLocalKeySync(&n.LocalNode)
+						err := n.Registrar.UpdateLocalKeySync(n.localNodeCopy())
 						if err != nil {
 							log.WithError(err).Error("Unable to propagate local node change to kvstore")
 						}
@@ -200,6 +213,64 @@ func (n *NodeDiscovery) StartDiscovery(nodeName string, conf Configuration) {
 	}
 }
 
+// localNodeCopy returns a copy of the local node which can be handed to the
+// node store without being affected by later modifications
+func (n *NodeDiscovery) localNodeCopy() *node.Node {
+	n.localNodeLock.Lock()
+	defer n.localNodeLock.Unlock()
+	return n.LocalNode.DeepCopy()
+}
+
+// UpdateEncryptionKey advertises the new encryption key ID of the local node
+// to all other nodes, e.g. after the IPsec keys have been rotated
+func (n *NodeDiscovery) UpdateEncryptionKey(spi uint8) {
+	n.localNodeLock.Lock()
+	n.LocalNode.EncryptionKey = spi
+	n.Manager.NodeUpdated(n.LocalNode)
+	n.localNodeLock.Unlock()
+
+	if option.Config.KVStore != "" {
+		n.controllers.UpdateController("propagating local encryption key to kv-store",
+			controller.ControllerParams{
+				DoFunc: func(ctx context.Context) error {
+					select {
+					case <-n.Registered:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+					return n.Registrar.UpdateLocalKeySync(n.localNodeCopy())
+				},
+			})
+	}
+
+	if k8s.IsEnabled() && option.Config.AutoCreateCiliumNodeResource {
+		n.controllers.UpdateController("propagating local encryption key to CiliumNode",
+			controller.ControllerParams{
+				DoFunc: func(ctx context.Context) error {
+					return updateCiliumNodeEncryptionKey(spi)
+				},
+			})
+	}
+}
+
+// updateCiliumNodeEncryptionKey sets the encryption key ID in the CiliumNode
+// resource representing the local node
+func updateCiliumNodeEncryptionKey(spi uint8) error {
+	ciliumClient := k8s.CiliumClient()
+
+	nodeResource, err := ciliumClient.CiliumV2().CiliumNodes().Get(node.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if nodeResource.Spec.Encryption.Key == int(spi) {
+		return nil
+	}
+
+	nodeResource.Spec.Encryption.Key = int(spi)
+	_, err = ciliumClient.CiliumV2().CiliumNodes().Update(nodeResource)
+	return err
+}
+
 // Close shuts down the node discovery engine
 func (n *NodeDiscovery) Close() {
 	n.Manager.Close()
@@ -246,16 +317,21 @@ func (n *NodeDiscovery) UpdateCiliumNodeResource(conf Configuration) {
 		})
 	}
 
-	nodeResource.Spec.IPAM.PodCIDRs = []string{}
-	if cidr := node.GetIPv4AllocRange(); cidr != nil {
-		nodeResource.Spec.IPAM.PodCIDRs = append(nodeResource.Spec.IPAM.PodCIDRs, cidr.String())
-	}
+	// In cluster-pool IPAM mode, the PodCIDRs are allocated by
+	// cilium-operator and must not be overwritten
+	if option.Config.IPAM != option.IPAMClusterPool {
+		nodeResource.Spec.IPAM.PodCIDRs = []string{}
+		if cidr := node.GetIPv4AllocRange(); cidr != nil {
+			nodeResource.Spec.IPAM.PodCIDRs = append(nodeResource.Spec.IPAM.PodCIDRs, cidr.String())
+		}
 
-	if cidr := node.GetIPv6AllocRange(); cidr != nil {
-		nodeResource.Spec.IPAM.PodCIDRs = append(nodeResource.Spec.IPAM.PodCIDRs, cidr.String())
+		if cidr := node.GetIPv6AllocRange(); cidr != nil {
+			nodeResource.Spec.IPAM.PodCIDRs = append(nodeResource.Spec.IPAM.PodCIDRs, cidr.String())
+		}
 	}
 
 	nodeResource.Spec.Encryption.Key = int(node.GetIPsecKeyIdentity())
+	nodeResource.Spec.Wireguard.PublicKey = node.GetWireguardPublicKey()
 
 	nodeResource.Spec.HealthAddressing.IPv4 = ""
 	if ip := n.LocalNode.IPv4HealthIP; ip != nil {
@@ -312,6 +388,18 @@ func (n *NodeDiscovery) UpdateCiliumNodeResource(conf Configuration) {
 		nodeResource.Spec.ENI.AvailabilityZone = availabilityZone
 	}
 
+	if option.Config.IPAM == option.IPAMAzure {
+		instanceID, _, _, err := azuremetadata.GetInstanceMetadata()
+		if err != nil {
+			log.WithError(err).Fatal("Unable to retrieve InstanceID of own Azure virtual machine")
+		}
+		if azuretypes.IsScaleSetInstance(instanceID) {
+			log.WithField("instanceID", instanceID).Fatal("Azure IPAM mode does not support virtual machine scale set instances")
+		}
+
+		nodeResource.Spec.Azure.InstanceID = instanceID
+	}
+
 	if performUpdate {
 		_, err = ciliumClient.CiliumV2().CiliumNodes().Update(nodeResource)
 		if err != nil {
@@ -32,6 +32,7 @@ import (
 	"github.com/cilium/cilium/pkg/cidr"
 	"github.com/cilium/cilium/pkg/defaults"
 	"github.com/cilium/cilium/pkg/ip"
+	"github.com/cilium/cilium/pkg/loadbalancer"
 	"github.com/cilium/cilium/pkg/lock"
 	"github.com/cilium/cilium/pkg/logging"
 	"github.com/cilium/cilium/pkg/logging/logfields"
@@ -218,6 +219,10 @@ const (
 	// NodePortRange defines a custom range where to look up NodePort services
 	NodePortRange = "node-port-range"
 
+	// LBAlgorithm is the default algorithm used to select the backend of
+	// new connections to services
+	LBAlgorithm = "lb-algorithm"
+
 	// LibDir enables the directory path to store runtime build environment
 	LibDir = "lib-dir"
 
@@ -305,6 +310,18 @@ const (
 	// ToFQDNsProxyPort is the global port on which the in-agent DNS proxy should listen. Default 0 is a OS-assigned port.
 	ToFQDNsProxyPort = "tofqdns-proxy-port"
 
+	// ToFQDNsProxyRateLimit is the maximum number of DNS requests per second
+	// the in-agent DNS proxy forwards for each endpoint. 0 disables rate limiting.
+	ToFQDNsProxyRateLimit = "tofqdns-proxy-rate-limit"
+
+	// ToFQDNsProxyRateLimitBurst is the maximum number of DNS requests of an
+	// endpoint the in-agent DNS proxy forwards in a burst
+	ToFQDNsProxyRateLimitBurst = "tofqdns-proxy-rate-limit-burst"
+
+	// ToFQDNsProxyCacheSize is the maximum number of DNS responses cached by
+	// the in-agent DNS proxy. 0 disables caching.
+	ToFQDNsProxyCacheSize = "tofqdns-proxy-cache-size"
+
 	// ToFQDNsEnablePoller enables proactive polling of DNS names in toFQDNs.matchName rules.
 	ToFQDNsEnablePoller = "tofqdns-enable-poller"
 
@@ -451,6 +468,19 @@ const (
 	// the default for denied DNS requests.
 	FQDNProxyDenyWithRefused = "refused"
 
+	// FQDNRateLimitResponseCode is the name for the option for the dns-proxy
+	// response code to requests exceeding the rate limit
+	FQDNRateLimitResponseCode = "tofqdns-proxy-rate-limit-response-code"
+
+	// FQDNProxyRateLimitWithRefused is the response code for Domain refused.
+	// It is the default for rate limited DNS requests.
+	FQDNProxyRateLimitWithRefused = "refused"
+
+	// FQDNProxyRateLimitWithServerFailure is the response code for Server
+	// failure. Stub resolvers usually retry the request with the next
+	// nameserver on a SERVFAIL.
+	FQDNProxyRateLimitWithServerFailure = "serverFailure"
+
 	// PreAllocateMapsName is the name of the option PreAllocateMaps
 	PreAllocateMapsName = "preallocate-bpf-maps"
 
@@ -463,6 +493,13 @@ const (
 	// IPSecKeyFileName is the name of the option for ipsec key file
 	IPSecKeyFileName = "ipsec-key-file"
 
+	// EnableWireguardName is the name of the option to enable WireGuard
+	EnableWireguardName = "enable-wireguard"
+
+	// WireguardListenPortName is the name of the option for the UDP port
+	// WireGuard listens on
+	WireguardListenPortName = "wireguard-listen-port"
+
 	// KVstoreLeaseTTL is the time-to-live for lease in kvstore.
 	KVstoreLeaseTTL = "kvstore-lease-ttl"
 
@@ -483,6 +520,9 @@ const (
 	// EnableHealthChecking is the name of the EnableHealthChecking option
 	EnableHealthChecking = "enable-health-checking"
 
+	// HealthHistoryWindow is the name of the HealthHistoryWindow option
+	HealthHistoryWindow = "health-history-window"
+
 	// PolicyQueueSize is the size of the queues utilized by the policy
 	// repository.
 	PolicyQueueSize = "policy-queue-size"
@@ -547,12 +587,69 @@ const (
 	// IPAMENI is the value to select the AWS ENI IPAM plugin for option.IPAM
 	IPAMENI = "eni"
 
+	// IPAMAzure is the value to select the Azure network interface IPAM
+	// plugin for option.IPAM
+	IPAMAzure = "azure"
+
+	// IPAMClusterPool is the value to select the cluster-pool IPAM plugin
+	// for option.IPAM. cilium-operator allocates the PodCIDRs of each node
+	// out of the cluster-pool CIDRs.
+	IPAMClusterPool = "cluster-pool"
+
+	// ClusterPoolIPv4CIDR is the list of cluster CIDRs out of which
+	// cilium-operator allocates IPv4 PodCIDRs in cluster-pool IPAM mode
+	ClusterPoolIPv4CIDR = "cluster-pool-ipv4-cidr"
+
+	// ClusterPoolIPv6CIDR is the list of cluster CIDRs out of which
+	// cilium-operator allocates IPv6 PodCIDRs in cluster-pool IPAM mode
+	ClusterPoolIPv6CIDR = "cluster-pool-ipv6-cidr"
+
+	// ClusterPoolIPv4MaskSize is the mask size of the IPv4 PodCIDRs
+	// allocated in cluster-pool IPAM mode
+	ClusterPoolIPv4MaskSize = "cluster-pool-ipv4-mask-size"
+
+	// ClusterPoolIPv6MaskSize is the mask size of the IPv6 PodCIDRs
+	// allocated in cluster-pool IPAM mode
+	ClusterPoolIPv6MaskSize = "cluster-pool-ipv6-mask-size"
+
 	// AWSClientQPSLimit is the queries per second limit for the AWS client used by AWS ENI IPAM
 	AWSClientQPSLimit = "aws-client-qps"
 
 	// AWSClientBurst is the burst value allowed for the AWS client used by the AWS ENI IPAM
 	AWSClientBurst = "aws-client-burst"
 
+	// AzureSubscriptionID is the subscription ID of the Azure resources
+	// managed by the Azure IPAM
+	AzureSubscriptionID = "azure-subscription-id"
+
+	// AzureResourceGroup is the resource group of the network interfaces
+	// and virtual networks managed by the Azure IPAM
+	AzureResourceGroup = "azure-resource-group"
+
+	// AzureTenantID is the tenant ID of the service principal used by the
+	// Azure IPAM
+	AzureTenantID = "azure-tenant-id"
+
+	// AzureClientID is the client ID of the service principal or user
+	// assigned managed identity used by the Azure IPAM
+	AzureClientID = "azure-client-id"
+
+	// AzureClientSecret is the client secret of the service principal used
+	// by the Azure IPAM
+	AzureClientSecret = "azure-client-secret"
+
+	// AzureClientQPSLimit is the queries per second limit for the Azure
+	// client used by the Azure IPAM
+	AzureClientQPSLimit = "azure-client-qps"
+
+	// AzureClientBurst is the burst value allowed for the Azure client used
+	// by the Azure IPAM
+	AzureClientBurst = "azure-client-burst"
+
+	// ToGroupsInventorySource is the HTTP(S) URL or file path of the
+	// inventory used by the inventory ToGroups provider
+	ToGroupsInventorySource = "togroups-inventory-source"
+
 	// K8sClientQPSLimit is the queries per second limit for the K8s client. Defaults to k8s client defaults.
 	K8sClientQPSLimit = "k8s-client-qps"
 
@@ -598,6 +695,8 @@ const (
 var (
 	FQDNRejectOptions = []string{FQDNProxyDenyWithNameError, FQDNProxyDenyWithRefused}
 
+	FQDNRateLimitOptions = []string{FQDNProxyRateLimitWithRefused, FQDNProxyRateLimitWithServerFailure}
+
 	// ContainerRuntimeAuto is the configuration for autodetecting the
 	// container runtime backends that Cilium should use.
 	ContainerRuntimeAuto = []string{"auto"}
@@ -932,6 +1031,12 @@ type DaemonConfig struct {
 	// IPSec key file for stored keys
 	IPSecKeyFile string
 
+	// EnableWireguard is true when WireGuard encryption is enabled
+	EnableWireguard bool
+
+	// WireguardListenPort is the UDP port WireGuard listens on
+	WireguardListenPort int
+
 	// MonitorQueueSize is the size of the monitor event queue
 	MonitorQueueSize int
 
@@ -999,6 +1104,18 @@ type DaemonConfig struct {
 	// DefaultDNSProxy below.
 	ToFQDNsProxyPort int
 
+	// ToFQDNsProxyRateLimit is the maximum number of DNS requests per second
+	// the DNS Proxy forwards for each endpoint. 0 disables rate limiting.
+	ToFQDNsProxyRateLimit float64
+
+	// ToFQDNsProxyRateLimitBurst is the maximum number of DNS requests of an
+	// endpoint the DNS Proxy forwards in a burst
+	ToFQDNsProxyRateLimitBurst int
+
+	// ToFQDNsProxyCacheSize is the maximum number of DNS responses cached by
+	// the DNS Proxy. 0 disables caching.
+	ToFQDNsProxyCacheSize int
+
 	// ToFQDNsEnablePoller enables the DNS poller that polls toFQDNs.matchName
 	ToFQDNsEnablePoller bool
 
@@ -1013,6 +1130,10 @@ type DaemonConfig struct {
 	// FQDNRejectResponse is the dns-proxy response for invalid dns-proxy request
 	FQDNRejectResponse string
 
+	// FQDNRateLimitResponse is the dns-proxy response for dns-proxy requests
+	// exceeding the rate limit
+	FQDNRateLimitResponse string
+
 	// Path to a file with DNS cache data to preload on startup
 	ToFQDNsPreCache string
 
@@ -1040,6 +1161,10 @@ type DaemonConfig struct {
 	// health endpoints
 	EnableHealthChecking bool
 
+	// HealthHistoryWindow is the time window over which the history of
+	// connectivity health probes is kept
+	HealthHistoryWindow time.Duration
+
 	// KVstoreKeepAliveInterval is the interval in which the lease is being
 	// renewed. This must be set to a value lesser than the LeaseTTL ideally
 	// by a factor of 3.
@@ -1143,6 +1268,10 @@ type DaemonConfig struct {
 	// NodePortMax is the maximum port address for the NodePort range
 	NodePortMax int
 
+	// LBAlgorithm is the algorithm used to select the backend of new
+	// connections to services which do not select an algorithm
+	LBAlgorithm string
+
 	// excludeLocalAddresses excludes certain addresses to be recognized as
 	// a local address
 	excludeLocalAddresses []*net.IPNet
@@ -1189,6 +1318,7 @@ var (
 		IPv6ClusterAllocCIDRBase:     defaults.IPv6ClusterAllocCIDRBase,
 		EnableHostIPRestore:          defaults.EnableHostIPRestore,
 		EnableHealthChecking:         defaults.EnableHealthChecking,
+		HealthHistoryWindow:          defaults.HealthHistoryWindow,
 		EnableIPv4:                   defaults.EnableIPv4,
 		EnableIPv6:                   defaults.EnableIPv6,
 		ToFQDNsMaxIPsPerHost:         defaults.ToFQDNsMaxIPsPerHost,
@@ -1329,6 +1459,10 @@ func (c *DaemonConfig) Validate() error {
 		return fmt.Errorf("IPv6 cannot be enabled in ENI IPAM mode")
 	}
 
+	if c.IPAM == IPAMAzure && c.EnableIPv6 {
+		return fmt.Errorf("IPv6 cannot be enabled in Azure IPAM mode")
+	}
+
 	switch c.Tunnel {
 	case TunnelVXLAN, TunnelGeneve, "":
 	case TunnelDisabled:
@@ -1395,6 +1529,13 @@ func (c *DaemonConfig) Validate() error {
 			int64(defaults.KVstoreLeaseMaxTTL.Seconds()))
 	}
 
+	if c.LBAlgorithm == "" {
+		c.LBAlgorithm = defaults.LBAlgorithm
+	}
+	if _, err := loadbalancer.ParseLBAlgorithm(c.LBAlgorithm); err != nil {
+		return fmt.Errorf("invalid value '%s' of option --%s: %s", c.LBAlgorithm, LBAlgorithm, err)
+	}
+
 	if c.WriteCNIConfigurationWhenReady != "" && c.ReadCNIConfiguration == "" {
 		return fmt.Errorf("%s must be set when using %s", ReadCNIConfiguration, WriteCNIConfigurationWhenReady)
 	}
@@ -1521,6 +1662,8 @@ func (c *DaemonConfig) Populate() {
 	c.EnableIPv4 = getIPv4Enabled()
 	c.EnableIPv6 = viper.GetBool(EnableIPv6Name)
 	c.EnableIPSec = viper.GetBool(EnableIPSecName)
+	c.EnableWireguard = viper.GetBool(EnableWireguardName)
+	c.WireguardListenPort = viper.GetInt(WireguardListenPortName)
 	c.EndpointInterfaceNamePrefix = viper.GetString(EndpointInterfaceNamePrefix)
 	c.DevicePreFilter = viper.GetString(PrefilterDevice)
 	c.DisableCiliumEndpointCRD = viper.GetBool(DisableCiliumEndpointCRDName)
@@ -1531,9 +1674,11 @@ func (c *DaemonConfig) Populate() {
 	c.EnableAutoDirectRouting = viper.GetBool(EnableAutoDirectRoutingName)
 	c.EnableEndpointRoutes = viper.GetBool(EnableEndpointRoutes)
 	c.EnableHealthChecking = viper.GetBool(EnableHealthChecking)
+	c.HealthHistoryWindow = viper.GetDuration(HealthHistoryWindow)
 	c.EnablePolicy = strings.ToLower(viper.GetString(EnablePolicy))
 	c.EnableTracing = viper.GetBool(EnableTracing)
 	c.EnableNodePort = viper.GetBool(EnableNodePort)
+	c.LBAlgorithm = viper.GetString(LBAlgorithm)
 	c.EncryptInterface = viper.GetString(EncryptInterface)
 	c.EncryptNode = viper.GetBool(EncryptNode)
 	c.EnvoyLogPath = viper.GetString(EnvoyLog)
@@ -1633,6 +1778,9 @@ func (c *DaemonConfig) Populate() {
 		c.ToFQDNsMinTTL = defaults.ToFQDNsMinTTL
 	}
 	c.ToFQDNsProxyPort = viper.GetInt(ToFQDNsProxyPort)
+	c.ToFQDNsProxyRateLimit = viper.GetFloat64(ToFQDNsProxyRateLimit)
+	c.ToFQDNsProxyRateLimitBurst = viper.GetInt(ToFQDNsProxyRateLimitBurst)
+	c.ToFQDNsProxyCacheSize = viper.GetInt(ToFQDNsProxyCacheSize)
 	c.ToFQDNsPreCache = viper.GetString(ToFQDNsPreCache)
 
 	// Convert IP strings into net.IPNet types
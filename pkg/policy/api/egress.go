@@ -182,20 +182,7 @@ func (e *EgressRule) GetDestinationEndpointSelectorsWithRequirements(requirement
 		e.SetAggregatedSelectors()
 	}
 	res := make(EndpointSelectorSlice, 0, len(e.ToEndpoints)+len(e.aggregatedSelectors))
-
-	if len(requirements) > 0 && len(e.ToEndpoints) > 0 {
-		for idx := range e.ToEndpoints {
-			sel := *e.ToEndpoints[idx].DeepCopy()
-			sel.MatchExpressions = append(sel.MatchExpressions, requirements...)
-			sel.SyncRequirementsWithLabelSelector()
-			// Even though this string is deep copied, we need to override it
-			// because we are updating the contents of the MatchExpressions.
-			sel.cachedLabelSelectorString = sel.LabelSelector.String()
-			res = append(res, sel)
-		}
-	} else {
-		res = append(res, e.ToEndpoints...)
-	}
+	res = append(res, selectorsWithRequirements(e.ToEndpoints, requirements)...)
 	return append(res, e.aggregatedSelectors...)
 }
 
@@ -236,3 +223,111 @@ func (e *EgressRule) CreateDerivative() (*EgressRule, error) {
 	e.SetAggregatedSelectors()
 	return newRule, nil
 }
+
+// EgressDenyRule contains all rule types which can be applied at egress, i.e.
+// network traffic that originates inside the endpoint and exits the endpoint
+// selected by the endpointSelector, and which must be denied regardless of
+// any EgressRule that would allow it.
+//
+// - All members of this structure are optional. If omitted or empty, the
+//   member will have no effect on the rule.
+//
+// - If multiple members are set, all of them need to match in order for
+//   the rule to take effect. The exception to this rule is ToRequires field;
+//   the effects of any Requires field in any rule will apply to all other
+//   rules as well.
+type EgressDenyRule struct {
+	// ToEndpoints is a list of endpoints identified by an EndpointSelector to
+	// which the endpoints subject to the rule are not allowed to communicate.
+	//
+	// Example:
+	// Any endpoint with the label "role=frontend" cannot communicate with any
+	// endpoint carrying the label "role=backend".
+	//
+	// +optional
+	ToEndpoints []EndpointSelector `json:"toEndpoints,omitempty"`
+
+	// ToRequires is a list of additional constraints which must be met
+	// in order for the selected endpoints to be denied. These additional
+	// constraints do not by themselves deny any traffic and must always be
+	// accompanied with at least one matching ToEndpoints.
+	//
+	// +optional
+	ToRequires []EndpointSelector `json:"toRequires,omitempty"`
+
+	// ToPorts is a list of destination ports identified by port number and
+	// protocol which the endpoint subject to the rule is not allowed to
+	// connect to.
+	//
+	// Example:
+	// Any endpoint with the label "role=frontend" is not allowed to initiate
+	// connections to destination port 8080/tcp
+	//
+	// +optional
+	ToPorts []PortDenyRule `json:"toPorts,omitempty"`
+
+	// ToCIDR is a list of IP blocks to which the endpoint subject to the rule
+	// is not allowed to initiate connections. This will match on the
+	// destination IP address of outgoing connections.
+	//
+	// Example:
+	// Any endpoint with the label "app=database-proxy" is not allowed to
+	// initiate connections to 10.2.3.0/24
+	//
+	// +optional
+	ToCIDR CIDRSlice `json:"toCIDR,omitempty"`
+
+	// ToCIDRSet is a list of IP blocks to which the endpoint subject to the
+	// rule is not allowed to initiate connections, along with a list of
+	// subnets contained within their corresponding IP block which are not
+	// subject to the deny.
+	//
+	// Example:
+	// Any endpoint with the label "app=database-proxy" is not allowed to
+	// initiate connections to 169.254.169.254/32.
+	//
+	// +optional
+	ToCIDRSet CIDRRuleSlice `json:"toCIDRSet,omitempty"`
+
+	// ToEntities is a list of special entities to which the endpoint subject
+	// to the rule is not allowed to initiate connections. Supported entities
+	// are `world`, `cluster` and `host`
+	//
+	// +optional
+	ToEntities EntitySlice `json:"toEntities,omitempty"`
+
+	// TODO: Move this to the policy package (https://github.com/cilium/cilium/issues/8353)
+	aggregatedSelectors EndpointSelectorSlice
+}
+
+// SetAggregatedSelectors creates a single slice containing all of the
+// following fields within the EgressDenyRule, converted to EndpointSelector,
+// to be stored within the EgressDenyRule for easy lookup while performing
+// policy evaluation for the rule:
+// * ToEntities
+// * ToCIDR
+// * ToCIDRSet
+//
+// ToEndpoints is not aggregated due to requirement folding in
+// GetDestinationEndpointSelectorsWithRequirements()
+func (e *EgressDenyRule) SetAggregatedSelectors() {
+	res := make(EndpointSelectorSlice, 0, len(e.ToEntities)+len(e.ToCIDR)+len(e.ToCIDRSet))
+	res = append(res, e.ToEntities.GetAsEndpointSelectors()...)
+	res = append(res, e.ToCIDR.GetAsEndpointSelectors()...)
+	res = append(res, e.ToCIDRSet.GetAsEndpointSelectors()...)
+	// Goroutines can race setting this, but they will all compute
+	// the same result, so it does not matter.
+	e.aggregatedSelectors = res
+}
+
+// GetDestinationEndpointSelectorsWithRequirements returns a slice of
+// endpoints selectors covering all L3 destination selectors of the egress
+// deny rule
+func (e *EgressDenyRule) GetDestinationEndpointSelectorsWithRequirements(requirements []metav1.LabelSelectorRequirement) EndpointSelectorSlice {
+	if e.aggregatedSelectors == nil {
+		e.SetAggregatedSelectors()
+	}
+	res := make(EndpointSelectorSlice, 0, len(e.ToEndpoints)+len(e.aggregatedSelectors))
+	res = append(res, selectorsWithRequirements(e.ToEndpoints, requirements)...)
+	return append(res, e.aggregatedSelectors...)
+}
@@ -23,7 +23,8 @@ import (
 )
 
 const (
-	AWSProvider = "AWS" // AWS provider key
+	AWSProvider       = "AWS"       // AWS provider key
+	InventoryProvider = "Inventory" // Inventory provider key
 )
 
 var (
@@ -37,7 +38,8 @@ type GroupProviderFunc func(*ToGroups) ([]net.IP, error)
 // ToGroups structure to store all kinds of new integrations that needs a new
 // derivative policy.
 type ToGroups struct {
-	AWS *AWSGroup `json:"aws,omitempty"`
+	AWS       *AWSGroup       `json:"aws,omitempty"`
+	Inventory *InventoryGroup `json:"inventory,omitempty"`
 }
 
 // AWSGroup is an structure that can be used to whitelisting information from AWS integration
@@ -48,6 +50,14 @@ type AWSGroup struct {
 	Region              string            `json:"region,omitempty"`
 }
 
+// InventoryGroup is a structure that can be used to whitelist the IPs of
+// groups of an inventory, e.g. a CMDB, which lists the IPs of each group.
+// The inventory source is configured in the operator.
+type InventoryGroup struct {
+	// Groups are the names of the inventory groups whose IPs are selected
+	Groups []string `json:"groups,omitempty"`
+}
+
 // RegisterToGroupsProvider it will register a new callback that will be used
 // when a new ToGroups rule is added.
 func RegisterToGroupsProvider(providerName string, callback GroupProviderFunc) {
@@ -61,23 +71,40 @@ func (group *ToGroups) GetCidrSet() ([]CIDRRule, error) {
 	var ips []net.IP
 	// Get per  provider CIDRSet
 	if group.AWS != nil {
-		callbackInterface, ok := providers.Load(AWSProvider)
-		if !ok {
-			return nil, fmt.Errorf("Provider %s is not registered", AWSProvider)
-		}
-		callback, ok := callbackInterface.(GroupProviderFunc)
-		if !ok {
-			return nil, fmt.Errorf("Provider callback for %s is not a valid instance", AWSProvider)
-		}
-		awsIPs, err := callback(group)
+		awsIPs, err := group.getProviderIPs(AWSProvider)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"Cannot retrieve data from %s provider: %s",
-				AWSProvider, err)
+			return nil, err
 		}
 		ips = append(ips, awsIPs...)
 	}
+	if group.Inventory != nil {
+		inventoryIPs, err := group.getProviderIPs(InventoryProvider)
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, inventoryIPs...)
+	}
 
 	resultIps := ip.KeepUniqueIPs(ips)
 	return IPsToCIDRRules(resultIps), nil
 }
+
+// getProviderIPs returns the IPs selected by group using the callback of the
+// given provider
+func (group *ToGroups) getProviderIPs(providerName string) ([]net.IP, error) {
+	callbackInterface, ok := providers.Load(providerName)
+	if !ok {
+		return nil, fmt.Errorf("Provider %s is not registered", providerName)
+	}
+	callback, ok := callbackInterface.(GroupProviderFunc)
+	if !ok {
+		return nil, fmt.Errorf("Provider callback for %s is not a valid instance", providerName)
+	}
+	ips, err := callback(group)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Cannot retrieve data from %s provider: %s",
+			providerName, err)
+	}
+	return ips, nil
+}
@@ -112,3 +112,23 @@ func (s *PolicyAPITestSuite) TestWithoutProviderRegister(c *C) {
 	c.Assert(cidr, IsNil)
 	c.Assert(err, NotNil)
 }
+
+func (s *PolicyAPITestSuite) TestGetCIDRSetWithMultipleProviders(c *C) {
+	RegisterToGroupsProvider(AWSProvider, GetCallBackWithRule("192.168.1.1", "192.168.10.10"))
+	RegisterToGroupsProvider(InventoryProvider, GetCallBackWithRule("192.168.10.10", "10.0.0.1"))
+	defer providers.Delete(InventoryProvider)
+
+	group := GetToGroupsRule()
+	group.Inventory = &InventoryGroup{Groups: []string{"db"}}
+	cidr, err := group.GetCidrSet()
+	c.Assert(err, IsNil)
+	c.Assert(cidr, checker.DeepEquals, []CIDRRule{
+		{Cidr: "10.0.0.1/32", ExceptCIDRs: []CIDR{}, Generated: true},
+		{Cidr: "192.168.1.1/32", ExceptCIDRs: []CIDR{}, Generated: true},
+		{Cidr: "192.168.10.10/32", ExceptCIDRs: []CIDR{}, Generated: true}})
+
+	providers.Delete(InventoryProvider)
+	cidr, err = group.GetCidrSet()
+	c.Assert(cidr, IsNil)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// GRPCServiceValidName matches a fully qualified gRPC service name,
+	// e.g. "helloworld.Greeter"
+	GRPCServiceValidName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+	// GRPCMethodValidName matches a gRPC method name, e.g. "SayHello"
+	GRPCMethodValidName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// PortRuleGRPC is a list of gRPC protocol constraints. gRPC requests are
+// HTTP/2 POST requests to the path "/<service>/<method>", so these rules are
+// enforced by the HTTP proxy.
+type PortRuleGRPC struct {
+	// Service is the fully qualified name of the gRPC service, including
+	// the protobuf package name, e.g. "helloworld.Greeter".
+	Service string `json:"service"`
+
+	// Method is the name of a method of the service, e.g. "SayHello".
+	//
+	// If omitted or empty, all methods of the service are allowed.
+	//
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Metadata is a list of gRPC metadata headers which must be present in
+	// the request. Each entry is either a header name, in which case the
+	// header must be present, or a "name: value" pair, in which case the
+	// header must be present with the given value. If omitted or empty,
+	// requests are allowed regardless of the metadata present.
+	//
+	// +optional
+	Metadata []string `json:"metadata,omitempty"`
+}
+
+// Path returns the HTTP/2 request path for the gRPC rule. If no method is
+// specified, the path prefix common to all methods of the service is
+// returned.
+func (g *PortRuleGRPC) Path() string {
+	return "/" + g.Service + "/" + g.Method
+}
+
+// Sanitize sanitizes gRPC rules. It ensures that the service and method are
+// valid names and that the metadata headers are well formed. If the rule is
+// invalid, returns an error.
+func (g *PortRuleGRPC) Sanitize() error {
+	if g.Service == "" {
+		return fmt.Errorf("gRPC service must be specified")
+	}
+	if !GRPCServiceValidName.MatchString(g.Service) {
+		return fmt.Errorf("invalid gRPC service name \"%s\"", g.Service)
+	}
+	if g.Method != "" && !GRPCMethodValidName.MatchString(g.Method) {
+		return fmt.Errorf("invalid gRPC method name \"%s\"", g.Method)
+	}
+
+	for _, md := range g.Metadata {
+		key := strings.TrimRight(strings.SplitN(md, " ", 2)[0], ":")
+		if key == "" {
+			return fmt.Errorf("invalid gRPC metadata \"%s\": empty key", md)
+		}
+		// Pseudo-headers are used to match the service and method and
+		// cannot be specified as metadata.
+		if strings.HasPrefix(key, ":") {
+			return fmt.Errorf("invalid gRPC metadata \"%s\": pseudo-headers are not allowed", md)
+		}
+	}
+
+	return nil
+}
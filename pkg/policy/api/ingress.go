@@ -140,20 +140,7 @@ func (i *IngressRule) GetSourceEndpointSelectorsWithRequirements(requirements []
 		i.SetAggregatedSelectors()
 	}
 	res := make(EndpointSelectorSlice, 0, len(i.FromEndpoints)+len(i.aggregatedSelectors))
-	if len(requirements) > 0 && len(i.FromEndpoints) > 0 {
-		for idx := range i.FromEndpoints {
-			sel := *i.FromEndpoints[idx].DeepCopy()
-			sel.MatchExpressions = append(sel.MatchExpressions, requirements...)
-			sel.SyncRequirementsWithLabelSelector()
-			// Even though this string is deep copied, we need to override it
-			// because we are updating the contents of the MatchExpressions.
-			sel.cachedLabelSelectorString = sel.LabelSelector.String()
-			res = append(res, sel)
-		}
-	} else {
-		res = append(res, i.FromEndpoints...)
-	}
-
+	res = append(res, selectorsWithRequirements(i.FromEndpoints, requirements)...)
 	return append(res, i.aggregatedSelectors...)
 }
 
@@ -163,3 +150,115 @@ func (i *IngressRule) GetSourceEndpointSelectorsWithRequirements(requirements []
 func (i *IngressRule) IsLabelBased() bool {
 	return len(i.FromRequires)+len(i.FromCIDR)+len(i.FromCIDRSet) == 0
 }
+
+// IngressDenyRule contains all rule types which can be applied at ingress,
+// i.e. network traffic that originates outside of the endpoint and is
+// entering the endpoint selected by the endpointSelector, and which must be
+// denied regardless of any IngressRule that would allow it.
+//
+// - All members of this structure are optional. If omitted or empty, the
+//   member will have no effect on the rule.
+//
+// - If multiple members are set, all of them need to match in order for
+//   the rule to take effect. The exception to this rule is FromRequires field;
+//   the effects of any Requires field in any rule will apply to all other
+//   rules as well.
+//
+// - Combining ToPorts and FromCIDR or FromCIDRSet in the same rule is not
+//   supported and any such rules will be rejected.
+type IngressDenyRule struct {
+	// FromEndpoints is a list of endpoints identified by an
+	// EndpointSelector which are not allowed to communicate with the
+	// endpoint subject to the rule.
+	//
+	// Example:
+	// Any endpoint with the label "role=backend" cannot be consumed by any
+	// endpoint carrying the label "role=frontend".
+	//
+	// +optional
+	FromEndpoints []EndpointSelector `json:"fromEndpoints,omitempty"`
+
+	// FromRequires is a list of additional constraints which must be met
+	// in order for the selected endpoints to be denied. These additional
+	// constraints do not by themselves deny any traffic and must always be
+	// accompanied with at least one matching FromEndpoints.
+	//
+	// +optional
+	FromRequires []EndpointSelector `json:"fromRequires,omitempty"`
+
+	// ToPorts is a list of destination ports identified by port number and
+	// protocol on which the endpoint subject to the rule is not allowed to
+	// receive connections.
+	//
+	// Example:
+	// Any endpoint with the label "app=httpd" cannot accept incoming
+	// connections on port 80/tcp.
+	//
+	// +optional
+	ToPorts []PortDenyRule `json:"toPorts,omitempty"`
+
+	// FromCIDR is a list of IP blocks from which the endpoint subject to the
+	// rule is not allowed to receive connections. This will match on the
+	// source IP address of incoming connections.
+	//
+	// Example:
+	// Any endpoint with the label "app=my-legacy-pet" is not allowed to
+	// receive connections from 10.3.9.1
+	//
+	// +optional
+	FromCIDR CIDRSlice `json:"fromCIDR,omitempty"`
+
+	// FromCIDRSet is a list of IP blocks from which the endpoint subject to
+	// the rule is not allowed to receive connections, along with a list of
+	// subnets contained within their corresponding IP block which are not
+	// subject to the deny.
+	//
+	// Example:
+	// Any endpoint with the label "app=my-legacy-pet" is not allowed to
+	// receive connections from 10.0.0.0/8 except from IPs in subnet
+	// 10.96.0.0/12.
+	//
+	// +optional
+	FromCIDRSet CIDRRuleSlice `json:"fromCIDRSet,omitempty"`
+
+	// FromEntities is a list of special entities from which the endpoint
+	// subject to the rule is not allowed to receive connections. Supported
+	// entities are `world`, `cluster` and `host`
+	//
+	// +optional
+	FromEntities EntitySlice `json:"fromEntities,omitempty"`
+
+	// TODO: Move this to the policy package (https://github.com/cilium/cilium/issues/8353)
+	aggregatedSelectors EndpointSelectorSlice
+}
+
+// SetAggregatedSelectors creates a single slice containing all of the
+// following fields within the IngressDenyRule, converted to
+// EndpointSelector, to be stored within the IngressDenyRule for easy lookup
+// while performing policy evaluation for the rule:
+// * FromEntities
+// * FromCIDR
+// * FromCIDRSet
+//
+// FromEndpoints is not aggregated due to requirement folding in
+// GetSourceEndpointSelectorsWithRequirements()
+func (i *IngressDenyRule) SetAggregatedSelectors() {
+	res := make(EndpointSelectorSlice, 0, len(i.FromEntities)+len(i.FromCIDR)+len(i.FromCIDRSet))
+	res = append(res, i.FromEntities.GetAsEndpointSelectors()...)
+	res = append(res, i.FromCIDR.GetAsEndpointSelectors()...)
+	res = append(res, i.FromCIDRSet.GetAsEndpointSelectors()...)
+	// Goroutines can race setting this, but they will all compute
+	// the same result, so it does not matter.
+	i.aggregatedSelectors = res
+}
+
+// GetSourceEndpointSelectorsWithRequirements returns a slice of endpoints
+// selectors covering all L3 source selectors of the ingress deny rule
+func (i *IngressDenyRule) GetSourceEndpointSelectorsWithRequirements(requirements []metav1.LabelSelectorRequirement) EndpointSelectorSlice {
+	if i.aggregatedSelectors == nil {
+		i.SetAggregatedSelectors()
+	}
+	res := make(EndpointSelectorSlice, 0, len(i.FromEndpoints)+len(i.aggregatedSelectors))
+	res = append(res, selectorsWithRequirements(i.FromEndpoints, requirements)...)
+	return append(res, i.aggregatedSelectors...)
+}
@@ -93,6 +93,33 @@ type PortRuleKafka struct {
 	// +optional
 	Topic string `json:"topic,omitempty"`
 
+	// Principal is the SASL principal authenticated on the connection, i.e.
+	// the authorization identity, or if none was provided the
+	// authentication identity, of the PLAIN and SCRAM mechanisms.
+	//
+	// This constraint is ignored for the requests which are part of the
+	// authentication exchange, i.e. "apiversions", "saslhandshake" and
+	// "saslauthenticate". All other requests are rejected until a principal
+	// has been authenticated.
+	//
+	// If omitted or empty, all principals are allowed, including
+	// unauthenticated connections.
+	//
+	// +optional
+	Principal string `json:"principal,omitempty"`
+
+	// ConsumerGroup is the consumer group contained in the message.
+	//
+	// This constraint is ignored if the matched request message type
+	// doesn't contain a consumer group. Requests which do contain a group
+	// are "joingroup", "syncgroup", "heartbeat", "leavegroup",
+	// "offsetcommit", "offsetfetch" and "txnoffsetcommit".
+	//
+	// If omitted or empty, all consumer groups are allowed.
+	//
+	// +optional
+	ConsumerGroup string `json:"consumerGroup,omitempty"`
+
 	// --------------------------------------------------------------------
 	// Private fields. These fields are used internally and are not exposed
 	// via the API.
@@ -135,10 +162,12 @@ const (
 // List of Kafka apiKey which are not associated with
 // any topic
 const (
-	HeartbeatKey   = 12
-	LeaveGroupKey  = 13
-	SyncgroupKey   = 14
-	APIVersionsKey = 18
+	HeartbeatKey        = 12
+	LeaveGroupKey       = 13
+	SyncgroupKey        = 14
+	SaslHandshakeKey    = 17
+	APIVersionsKey      = 18
+	SaslAuthenticateKey = 36
 )
 
 // List of Kafka Roles
@@ -151,80 +180,116 @@ const (
 // with the key values.
 // Reference: https://kafka.apache.org/protocol#protocol_api_keys
 var KafkaAPIKeyMap = map[string]int16{
-	"produce":              0,  /* Produce */
-	"fetch":                1,  /* Fetch */
-	"offsets":              2,  /* Offsets */
-	"metadata":             3,  /* Metadata */
-	"leaderandisr":         4,  /* LeaderAndIsr */
-	"stopreplica":          5,  /* StopReplica */
-	"updatemetadata":       6,  /* UpdateMetadata */
-	"controlledshutdown":   7,  /* ControlledShutdown */
-	"offsetcommit":         8,  /* OffsetCommit */
-	"offsetfetch":          9,  /* OffsetFetch */
-	"findcoordinator":      10, /* FindCoordinator */
-	"joingroup":            11, /* JoinGroup */
-	"heartbeat":            12, /* Heartbeat */
-	"leavegroup":           13, /* LeaveGroup */
-	"syncgroup":            14, /* SyncGroup */
-	"describegroups":       15, /* DescribeGroups */
-	"listgroups":           16, /* ListGroups */
-	"saslhandshake":        17, /* SaslHandshake */
-	"apiversions":          18, /* ApiVersions */
-	"createtopics":         19, /* CreateTopics */
-	"deletetopics":         20, /* DeleteTopics */
-	"deleterecords":        21, /* DeleteRecords */
-	"initproducerid":       22, /* InitProducerId */
-	"offsetforleaderepoch": 23, /* OffsetForLeaderEpoch */
-	"addpartitionstotxn":   24, /* AddPartitionsToTxn */
-	"addoffsetstotxn":      25, /* AddOffsetsToTxn */
-	"endtxn":               26, /* EndTxn */
-	"writetxnmarkers":      27, /* WriteTxnMarkers */
-	"txnoffsetcommit":      28, /* TxnOffsetCommit */
-	"describeacls":         29, /* DescribeAcls */
-	"createacls":           30, /* CreateAcls */
-	"deleteacls":           31, /* DeleteAcls */
-	"describeconfigs":      32, /* DescribeConfigs */
-	"alterconfigs":         33, /* AlterConfigs */
+	"produce":                      0,  /* Produce */
+	"fetch":                        1,  /* Fetch */
+	"offsets":                      2,  /* Offsets */
+	"metadata":                     3,  /* Metadata */
+	"leaderandisr":                 4,  /* LeaderAndIsr */
+	"stopreplica":                  5,  /* StopReplica */
+	"updatemetadata":               6,  /* UpdateMetadata */
+	"controlledshutdown":           7,  /* ControlledShutdown */
+	"offsetcommit":                 8,  /* OffsetCommit */
+	"offsetfetch":                  9,  /* OffsetFetch */
+	"findcoordinator":              10, /* FindCoordinator */
+	"joingroup":                    11, /* JoinGroup */
+	"heartbeat":                    12, /* Heartbeat */
+	"leavegroup":                   13, /* LeaveGroup */
+	"syncgroup":                    14, /* SyncGroup */
+	"describegroups":               15, /* DescribeGroups */
+	"listgroups":                   16, /* ListGroups */
+	"saslhandshake":                17, /* SaslHandshake */
+	"apiversions":                  18, /* ApiVersions */
+	"createtopics":                 19, /* CreateTopics */
+	"deletetopics":                 20, /* DeleteTopics */
+	"deleterecords":                21, /* DeleteRecords */
+	"initproducerid":               22, /* InitProducerId */
+	"offsetforleaderepoch":         23, /* OffsetForLeaderEpoch */
+	"addpartitionstotxn":           24, /* AddPartitionsToTxn */
+	"addoffsetstotxn":              25, /* AddOffsetsToTxn */
+	"endtxn":                       26, /* EndTxn */
+	"writetxnmarkers":              27, /* WriteTxnMarkers */
+	"txnoffsetcommit":              28, /* TxnOffsetCommit */
+	"describeacls":                 29, /* DescribeAcls */
+	"createacls":                   30, /* CreateAcls */
+	"deleteacls":                   31, /* DeleteAcls */
+	"describeconfigs":              32, /* DescribeConfigs */
+	"alterconfigs":                 33, /* AlterConfigs */
+	"alterreplicalogdirs":          34, /* AlterReplicaLogDirs */
+	"describelogdirs":              35, /* DescribeLogDirs */
+	"saslauthenticate":             36, /* SaslAuthenticate */
+	"createpartitions":             37, /* CreatePartitions */
+	"createdelegationtoken":        38, /* CreateDelegationToken */
+	"renewdelegationtoken":         39, /* RenewDelegationToken */
+	"expiredelegationtoken":        40, /* ExpireDelegationToken */
+	"describedelegationtoken":      41, /* DescribeDelegationToken */
+	"deletegroups":                 42, /* DeleteGroups */
+	"electleaders":                 43, /* ElectLeaders */
+	"incrementalalterconfigs":      44, /* IncrementalAlterConfigs */
+	"alterpartitionreassignments":  45, /* AlterPartitionReassignments */
+	"listpartitionreassignments":   46, /* ListPartitionReassignments */
+	"offsetdelete":                 47, /* OffsetDelete */
+	"describeclientquotas":         48, /* DescribeClientQuotas */
+	"alterclientquotas":            49, /* AlterClientQuotas */
+	"describeuserscramcredentials": 50, /* DescribeUserScramCredentials */
+	"alteruserscramcredentials":    51, /* AlterUserScramCredentials */
 }
 
 // KafkaReverseApiKeyMap is the map of all allowed kafka API keys
 // with the key values.
 // Reference: https://kafka.apache.org/protocol#protocol_api_keys
 var KafkaReverseAPIKeyMap = map[int16]string{
-	0:  "produce",              /* Produce */
-	1:  "fetch",                /* Fetch */
-	2:  "offsets",              /* Offsets */
-	3:  "metadata",             /* Metadata */
-	4:  "leaderandisr",         /* LeaderAndIsr */
-	5:  "stopreplica",          /* StopReplica */
-	6:  "updatemetadata",       /* UpdateMetadata */
-	7:  "controlledshutdown",   /* ControlledShutdown */
-	8:  "offsetcommit",         /* OffsetCommit */
-	9:  "offsetfetch",          /* OffsetFetch */
-	10: "findcoordinator",      /* FindCoordinator */
-	11: "joingroup",            /* JoinGroup */
-	12: "heartbeat",            /* Heartbeat */
-	13: "leavegroup",           /* LeaveGroup */
-	14: "syncgroup",            /* SyncGroup */
-	15: "describegroups",       /* DescribeGroups */
-	16: "listgroups",           /* ListGroups */
-	17: "saslhandshake",        /* SaslHandshake */
-	18: "apiversions",          /* ApiVersions */
-	19: "createtopics",         /* CreateTopics */
-	20: "deletetopics",         /* DeleteTopics */
-	21: "deleterecords",        /* DeleteRecords */
-	22: "initproducerid",       /* InitProducerId */
-	23: "offsetforleaderepoch", /* OffsetForLeaderEpoch */
-	24: "addpartitionstotxn",   /* AddPartitionsToTxn */
-	25: "addoffsetstotxn",      /* AddOffsetsToTxn */
-	26: "endtxn",               /* EndTxn */
-	27: "writetxnmarkers",      /* WriteTxnMarkers */
-	28: "txnoffsetcommit",      /* TxnOffsetCommit */
-	29: "describeacls",         /* DescribeAcls */
-	30: "createacls",           /* CreateAcls */
-	31: "deleteacls",           /* DeleteAcls */
-	32: "describeconfigs",      /* DescribeConfigs */
-	33: "alterconfigs",         /* AlterConfigs */
+	0:  "produce",                      /* Produce */
+	1:  "fetch",                        /* Fetch */
+	2:  "offsets",                      /* Offsets */
+	3:  "metadata",                     /* Metadata */
+	4:  "leaderandisr",                 /* LeaderAndIsr */
+	5:  "stopreplica",                  /* StopReplica */
+	6:  "updatemetadata",               /* UpdateMetadata */
+	7:  "controlledshutdown",           /* ControlledShutdown */
+	8:  "offsetcommit",                 /* OffsetCommit */
+	9:  "offsetfetch",                  /* OffsetFetch */
+	10: "findcoordinator",              /* FindCoordinator */
+	11: "joingroup",                    /* JoinGroup */
+	12: "heartbeat",                    /* Heartbeat */
+	13: "leavegroup",                   /* LeaveGroup */
+	14: "syncgroup",                    /* SyncGroup */
+	15: "describegroups",               /* DescribeGroups */
+	16: "listgroups",                   /* ListGroups */
+	17: "saslhandshake",                /* SaslHandshake */
+	18: "apiversions",                  /* ApiVersions */
+	19: "createtopics",                 /* CreateTopics */
+	20: "deletetopics",                 /* DeleteTopics */
+	21: "deleterecords",                /* DeleteRecords */
+	22: "initproducerid",               /* InitProducerId */
+	23: "offsetforleaderepoch",         /* OffsetForLeaderEpoch */
+	24: "addpartitionstotxn",           /* AddPartitionsToTxn */
+	25: "addoffsetstotxn",              /* AddOffsetsToTxn */
+	26: "endtxn",                       /* EndTxn */
+	27: "writetxnmarkers",              /* WriteTxnMarkers */
+	28: "txnoffsetcommit",              /* TxnOffsetCommit */
+	29: "describeacls",                 /* DescribeAcls */
+	30: "createacls",                   /* CreateAcls */
+	31: "deleteacls",                   /* DeleteAcls */
+	32: "describeconfigs",              /* DescribeConfigs */
+	33: "alterconfigs",                 /* AlterConfigs */
+	34: "alterreplicalogdirs",          /* AlterReplicaLogDirs */
+	35: "describelogdirs",              /* DescribeLogDirs */
+	36: "saslauthenticate",             /* SaslAuthenticate */
+	37: "createpartitions",             /* CreatePartitions */
+	38: "createdelegationtoken",        /* CreateDelegationToken */
+	39: "renewdelegationtoken",         /* RenewDelegationToken */
+	40: "expiredelegationtoken",        /* ExpireDelegationToken */
+	41: "describedelegationtoken",      /* DescribeDelegationToken */
+	42: "deletegroups",                 /* DeleteGroups */
+	43: "electleaders",                 /* ElectLeaders */
+	44: "incrementalalterconfigs",      /* IncrementalAlterConfigs */
+	45: "alterpartitionreassignments",  /* AlterPartitionReassignments */
+	46: "listpartitionreassignments",   /* ListPartitionReassignments */
+	47: "offsetdelete",                 /* OffsetDelete */
+	48: "describeclientquotas",         /* DescribeClientQuotas */
+	49: "alterclientquotas",            /* AlterClientQuotas */
+	50: "describeuserscramcredentials", /* DescribeUserScramCredentials */
+	51: "alteruserscramcredentials",    /* AlterUserScramCredentials */
 }
 
 // KafkaRole is the list of all low-level apiKeys to
@@ -277,15 +342,18 @@ func (kr *PortRuleKafka) MapRoleToAPIKey() error {
 	// apiversions. While for consume, we need to add mandatory apiKeys like
 	// fetch, offsets, offsetcommit, offsetfetch, apiversions, metadata,
 	// findcoordinator, joingroup, heartbeat,
-	// leavegroup and syncgroup.
+	// leavegroup and syncgroup. Both roles include the apiKeys saslhandshake
+	// and saslauthenticate to allow clients to authenticate.
 	switch strings.ToLower(kr.Role) {
 	case ProduceRole:
-		kr.apiKeyInt = KafkaRole{ProduceKey, MetadataKey, APIVersionsKey}
+		kr.apiKeyInt = KafkaRole{ProduceKey, MetadataKey, APIVersionsKey,
+			SaslHandshakeKey, SaslAuthenticateKey}
 		return nil
 	case ConsumeRole:
 		kr.apiKeyInt = KafkaRole{FetchKey, OffsetsKey, MetadataKey,
 			OffsetCommitKey, OffsetFetchKey, FindCoordinatorKey,
-			JoinGroupKey, HeartbeatKey, LeaveGroupKey, SyncgroupKey, APIVersionsKey}
+			JoinGroupKey, HeartbeatKey, LeaveGroupKey, SyncgroupKey, APIVersionsKey,
+			SaslHandshakeKey, SaslAuthenticateKey}
 		return nil
 	default:
 		return fmt.Errorf("Invalid Kafka Role %s", kr.Role)
@@ -75,6 +75,16 @@ type PortRule struct {
 	Rules *L7Rules `json:"rules,omitempty"`
 }
 
+// PortDenyRule is a list of ports/protocol combinations which must be
+// denied. Unlike PortRule, it cannot carry Layer 7 rules as denied traffic
+// is never redirected to a proxy.
+type PortDenyRule struct {
+	// Ports is a list of L4 port/protocol
+	//
+	// +optional
+	Ports []PortProtocol `json:"ports,omitempty"`
+}
+
 // L7Rules is a union of port level rule types. Mixing of different port
 // level rule types is disallowed, so exactly one of the following must be set.
 // If none are specified, then no additional port level rules are applied.
@@ -94,6 +104,11 @@ type L7Rules struct {
 	// +optional
 	DNS []PortRuleDNS `json:"dns,omitempty"`
 
+	// gRPC-specific rules.
+	//
+	// +optional
+	GRPC []PortRuleGRPC `json:"grpc,omitempty"`
+
 	// Name of the L7 protocol for which the Key-value pair rules apply
 	//
 	// +optional
@@ -111,10 +126,10 @@ func (rules *L7Rules) Len() int {
 	if rules == nil {
 		return 0
 	}
-	return len(rules.HTTP) + len(rules.Kafka) + len(rules.DNS) + len(rules.L7)
+	return len(rules.HTTP) + len(rules.Kafka) + len(rules.DNS) + len(rules.GRPC) + len(rules.L7)
 }
 
 // IsEmpty returns whether the `L7Rules` is nil or contains nil rules.
 func (rules *L7Rules) IsEmpty() bool {
-	return rules == nil || (rules.HTTP == nil && rules.Kafka == nil && rules.DNS == nil && rules.L7 == nil)
+	return rules == nil || (rules.HTTP == nil && rules.Kafka == nil && rules.DNS == nil && rules.GRPC == nil && rules.L7 == nil)
 }
@@ -46,6 +46,24 @@ type Rule struct {
 	// +optional
 	Egress []EgressRule `json:"egress,omitempty"`
 
+	// IngressDeny is a list of IngressDenyRule which are enforced at
+	// ingress. Any traffic matching an IngressDenyRule is dropped, even if
+	// it is also allowed by an IngressRule of this or any other rule.
+	// Unlike Ingress, IngressDeny on its own does not put the selected
+	// endpoints into default-deny mode.
+	//
+	// +optional
+	IngressDeny []IngressDenyRule `json:"ingressDeny,omitempty"`
+
+	// EgressDeny is a list of EgressDenyRule which are enforced at egress.
+	// Any traffic matching an EgressDenyRule is dropped, even if it is also
+	// allowed by an EgressRule of this or any other rule. Unlike Egress,
+	// EgressDeny on its own does not put the selected endpoints into
+	// default-deny mode.
+	//
+	// +optional
+	EgressDeny []EgressDenyRule `json:"egressDeny,omitempty"`
+
 	// Labels is a list of optional strings which can be used to
 	// re-identify the rule or to store metadata. It is possible to lookup
 	// or delete strings based on labels. Labels are not required to be
@@ -85,6 +103,18 @@ func (r *Rule) WithEgressRules(rules []EgressRule) *Rule {
 	return r
 }
 
+// WithIngressDenyRules configures the Rule with the specified rules.
+func (r *Rule) WithIngressDenyRules(rules []IngressDenyRule) *Rule {
+	r.IngressDeny = rules
+	return r
+}
+
+// WithEgressDenyRules configures the Rule with the specified rules.
+func (r *Rule) WithEgressDenyRules(rules []EgressDenyRule) *Rule {
+	r.EgressDeny = rules
+	return r
+}
+
 // WithLabels configures the Rule with the specified labels metadata.
 func (r *Rule) WithLabels(labels labels.LabelArray) *Rule {
 	r.Labels = labels
@@ -65,6 +65,18 @@ func (r Rule) Sanitize() error {
 		}
 	}
 
+	for i := range r.IngressDeny {
+		if err := r.IngressDeny[i].sanitize(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.EgressDeny {
+		if err := r.EgressDeny[i].sanitize(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -75,6 +87,7 @@ func countL7Rules(ports []PortRule) map[string]int {
 			result["DNS"] += len(port.Rules.DNS)
 			result["HTTP"] += len(port.Rules.HTTP)
 			result["Kafka"] += len(port.Rules.Kafka)
+			result["gRPC"] += len(port.Rules.GRPC)
 		}
 	}
 	return result
@@ -98,6 +111,7 @@ func (i *IngressRule) sanitize() error {
 		"DNS":   false,
 		"Kafka": true,
 		"HTTP":  true,
+		"gRPC":  true,
 	}
 
 	for m1 := range l3Members {
@@ -195,6 +209,7 @@ func (e *EgressRule) sanitize() error {
 		"DNS":   true,
 		"Kafka": false,
 		"HTTP":  true,
+		"gRPC":  true,
 	}
 
 	for m1 := range l3Members {
@@ -274,6 +289,162 @@ func (e *EgressRule) sanitize() error {
 	return nil
 }
 
+func (i *IngressDenyRule) sanitize() error {
+	l3Members := map[string]int{
+		"FromEndpoints": len(i.FromEndpoints),
+		"FromCIDR":      len(i.FromCIDR),
+		"FromCIDRSet":   len(i.FromCIDRSet),
+		"FromEntities":  len(i.FromEntities),
+	}
+	l3DependentL4Support := map[interface{}]bool{
+		"FromEndpoints": true,
+		"FromCIDR":      false,
+		"FromCIDRSet":   false,
+		"FromEntities":  true,
+	}
+
+	for m1 := range l3Members {
+		for m2 := range l3Members {
+			if m2 != m1 && l3Members[m1] > 0 && l3Members[m2] > 0 {
+				return fmt.Errorf("Combining %s and %s is not supported yet", m1, m2)
+			}
+		}
+	}
+	for member := range l3Members {
+		if l3Members[member] > 0 && len(i.ToPorts) > 0 && !l3DependentL4Support[member] {
+			return fmt.Errorf("Combining %s and ToPorts is not supported yet", member)
+		}
+	}
+
+	for _, es := range i.FromEndpoints {
+		if err := es.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	for _, es := range i.FromRequires {
+		if err := es.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	for n := range i.ToPorts {
+		if err := i.ToPorts[n].sanitize(); err != nil {
+			return err
+		}
+	}
+
+	prefixLengths := map[int]exists{}
+	for n := range i.FromCIDR {
+		prefixLength, err := i.FromCIDR[n].sanitize()
+		if err != nil {
+			return err
+		}
+		prefixLengths[prefixLength] = exists{}
+	}
+
+	for n := range i.FromCIDRSet {
+		prefixLength, err := i.FromCIDRSet[n].sanitize()
+		if err != nil {
+			return err
+		}
+		prefixLengths[prefixLength] = exists{}
+	}
+
+	for _, fromEntity := range i.FromEntities {
+		_, ok := EntitySelectorMapping[fromEntity]
+		if !ok {
+			return fmt.Errorf("unsupported entity: %s", fromEntity)
+		}
+	}
+
+	if l := len(prefixLengths); l > MaxCIDRPrefixLengths {
+		return fmt.Errorf("too many ingress deny CIDR prefix lengths %d/%d", l, MaxCIDRPrefixLengths)
+	}
+
+	i.SetAggregatedSelectors()
+
+	return nil
+}
+
+func (e *EgressDenyRule) sanitize() error {
+	l3Members := map[string]int{
+		"ToCIDR":      len(e.ToCIDR),
+		"ToCIDRSet":   len(e.ToCIDRSet),
+		"ToEndpoints": len(e.ToEndpoints),
+		"ToEntities":  len(e.ToEntities),
+	}
+
+	for m1 := range l3Members {
+		for m2 := range l3Members {
+			if m2 != m1 && l3Members[m1] > 0 && l3Members[m2] > 0 {
+				return fmt.Errorf("Combining %s and %s is not supported yet", m1, m2)
+			}
+		}
+	}
+
+	for _, es := range e.ToEndpoints {
+		if err := es.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	for _, es := range e.ToRequires {
+		if err := es.sanitize(); err != nil {
+			return err
+		}
+	}
+
+	for i := range e.ToPorts {
+		if err := e.ToPorts[i].sanitize(); err != nil {
+			return err
+		}
+	}
+
+	prefixLengths := map[int]exists{}
+	for i := range e.ToCIDR {
+		prefixLength, err := e.ToCIDR[i].sanitize()
+		if err != nil {
+			return err
+		}
+		prefixLengths[prefixLength] = exists{}
+	}
+	for i := range e.ToCIDRSet {
+		prefixLength, err := e.ToCIDRSet[i].sanitize()
+		if err != nil {
+			return err
+		}
+		prefixLengths[prefixLength] = exists{}
+	}
+
+	for _, toEntity := range e.ToEntities {
+		_, ok := EntitySelectorMapping[toEntity]
+		if !ok {
+			return fmt.Errorf("unsupported entity: %s", toEntity)
+		}
+	}
+
+	if l := len(prefixLengths); l > MaxCIDRPrefixLengths {
+		return fmt.Errorf("too many egress deny CIDR prefix lengths %d/%d", l, MaxCIDRPrefixLengths)
+	}
+
+	e.SetAggregatedSelectors()
+
+	return nil
+}
+
+func (pr *PortDenyRule) sanitize() error {
+	if len(pr.Ports) > maxPorts {
+		return fmt.Errorf("too many ports, the max is %d", maxPorts)
+	}
+	for i := range pr.Ports {
+		if err := pr.Ports[i].sanitize(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Sanitize sanitizes Kafka rules
 // TODO we need to add support to check
 // wildcard and prefix/suffix later on.
@@ -325,8 +496,13 @@ func (kr *PortRuleKafka) Sanitize() error {
 func (pr *L7Rules) sanitize(ports []PortProtocol) error {
 	nTypes := 0
 
-	if pr.HTTP != nil {
+	// gRPC rules are enforced by the HTTP parser, so they may be combined
+	// with HTTP rules
+	if pr.HTTP != nil || pr.GRPC != nil {
 		nTypes++
+	}
+
+	if pr.HTTP != nil {
 		for i := range pr.HTTP {
 			if err := pr.HTTP[i].Sanitize(); err != nil {
 				return err
@@ -343,6 +519,14 @@ func (pr *L7Rules) sanitize(ports []PortProtocol) error {
 		}
 	}
 
+	if pr.GRPC != nil {
+		for i := range pr.GRPC {
+			if err := pr.GRPC[i].Sanitize(); err != nil {
+				return err
+			}
+		}
+	}
+
 	if pr.DNS != nil {
 		// Forthcoming TPROXY redirection restricts DNS proxy to the standard DNS port (53).
 		// Require the port 53 be explicitly configured, and disallow other port numbers.
@@ -600,3 +600,141 @@ func (s *PolicyAPITestSuite) TestL7RuleDirectionalitySupport(c *C) {
 	c.Assert(err, Not(IsNil))
 
 }
+
+func (s *PolicyAPITestSuite) TestDenyRulesSanitize(c *C) {
+	validDenyRule := Rule{
+		EndpointSelector: WildcardEndpointSelector,
+		IngressDeny: []IngressDenyRule{
+			{
+				FromEndpoints: []EndpointSelector{WildcardEndpointSelector},
+				ToPorts: []PortDenyRule{{
+					Ports: []PortProtocol{
+						{Port: "23", Protocol: ProtoTCP},
+					},
+				}},
+			},
+		},
+		EgressDeny: []EgressDenyRule{
+			{
+				ToCIDR: []CIDR{"10.0.0.0/8"},
+			},
+		},
+	}
+	c.Assert(validDenyRule.Sanitize(), IsNil)
+
+	// Combining FromCIDR and ToPorts is not supported.
+	invalidDenyRule := Rule{
+		EndpointSelector: WildcardEndpointSelector,
+		IngressDeny: []IngressDenyRule{
+			{
+				FromCIDR: []CIDR{"10.0.0.0/8"},
+				ToPorts: []PortDenyRule{{
+					Ports: []PortProtocol{
+						{Port: "23", Protocol: ProtoTCP},
+					},
+				}},
+			},
+		},
+	}
+	c.Assert(invalidDenyRule.Sanitize(), Not(IsNil))
+
+	// Unknown entities are rejected.
+	invalidDenyRule = Rule{
+		EndpointSelector: WildcardEndpointSelector,
+		EgressDeny: []EgressDenyRule{
+			{
+				ToEntities: []Entity{"unknown"},
+			},
+		},
+	}
+	c.Assert(invalidDenyRule.Sanitize(), Not(IsNil))
+
+	// Invalid ports are rejected.
+	invalidDenyRule = Rule{
+		EndpointSelector: WildcardEndpointSelector,
+		EgressDeny: []EgressDenyRule{
+			{
+				ToPorts: []PortDenyRule{{
+					Ports: []PortProtocol{
+						{Port: "0", Protocol: ProtoTCP},
+					},
+				}},
+			},
+		},
+	}
+	c.Assert(invalidDenyRule.Sanitize(), Not(IsNil))
+}
+
+func (s *PolicyAPITestSuite) TestGRPCRuleSanitize(c *C) {
+	validGRPCRule := Rule{
+		EndpointSelector: WildcardEndpointSelector,
+		Ingress: []IngressRule{
+			{
+				FromEndpoints: []EndpointSelector{WildcardEndpointSelector},
+				ToPorts: []PortRule{{
+					Ports: []PortProtocol{
+						{Port: "50051", Protocol: ProtoTCP},
+					},
+					Rules: &L7Rules{
+						GRPC: []PortRuleGRPC{
+							{Service: "helloworld.Greeter", Method: "SayHello"},
+							{Service: "grpc.health.v1.Health", Metadata: []string{"x-tenant: blue"}},
+						},
+					},
+				}},
+			},
+		},
+	}
+	c.Assert(validGRPCRule.Sanitize(), IsNil)
+
+	invalidGRPCRules := []PortRuleGRPC{
+		{Method: "SayHello"},
+		{Service: "helloworld/Greeter"},
+		{Service: "helloworld.Greeter", Method: "Say.Hello"},
+		{Service: "helloworld.Greeter", Metadata: []string{":path: /foo"}},
+	}
+	for _, grpcRule := range invalidGRPCRules {
+		rule := Rule{
+			EndpointSelector: WildcardEndpointSelector,
+			Egress: []EgressRule{
+				{
+					ToPorts: []PortRule{{
+						Ports: []PortProtocol{
+							{Port: "50051", Protocol: ProtoTCP},
+						},
+						Rules: &L7Rules{
+							GRPC: []PortRuleGRPC{grpcRule},
+						},
+					}},
+				},
+			},
+		}
+		c.Assert(rule.Sanitize(), Not(IsNil), Commentf("%+v should be rejected", grpcRule))
+	}
+
+	// gRPC rules are enforced by the HTTP parser and may be mixed with
+	// HTTP rules, but not with other L7 rule types
+	mixedRule := Rule{
+		EndpointSelector: WildcardEndpointSelector,
+		Egress: []EgressRule{
+			{
+				ToPorts: []PortRule{{
+					Ports: []PortProtocol{
+						{Port: "50051", Protocol: ProtoTCP},
+					},
+					Rules: &L7Rules{
+						HTTP: []PortRuleHTTP{{Path: "/"}},
+						GRPC: []PortRuleGRPC{{Service: "helloworld.Greeter"}},
+					},
+				}},
+			},
+		},
+	}
+	c.Assert(mixedRule.Sanitize(), IsNil)
+
+	mixedRule.Egress[0].ToPorts[0].Rules = &L7Rules{
+		Kafka: []PortRuleKafka{{Topic: "foo"}},
+		GRPC:  []PortRuleGRPC{{Service: "helloworld.Greeter"}},
+	}
+	c.Assert(mixedRule.Sanitize(), Not(IsNil))
+}
@@ -386,3 +386,23 @@ func (s EndpointSelectorSlice) SelectsAllEndpoints() bool {
 	}
 	return false
 }
+
+// selectorsWithRequirements returns a copy of the given selectors with the
+// specified requirements folded into each selector's MatchExpressions. If no
+// requirements are specified, the selectors are returned unmodified.
+func selectorsWithRequirements(selectors []EndpointSelector, requirements []metav1.LabelSelectorRequirement) EndpointSelectorSlice {
+	if len(requirements) == 0 || len(selectors) == 0 {
+		return selectors
+	}
+	res := make(EndpointSelectorSlice, 0, len(selectors))
+	for idx := range selectors {
+		sel := *selectors[idx].DeepCopy()
+		sel.MatchExpressions = append(sel.MatchExpressions, requirements...)
+		sel.SyncRequirementsWithLabelSelector()
+		// Even though this string is deep copied, we need to override it
+		// because we are updating the contents of the MatchExpressions.
+		sel.cachedLabelSelectorString = sel.LabelSelector.String()
+		res = append(res, sel)
+	}
+	return res
+}
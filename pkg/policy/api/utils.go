@@ -69,10 +69,38 @@ func (d *PortRuleDNS) Exists(rules L7Rules) bool {
 	return false
 }
 
+// Exists returns true if the gRPC rule already exists in the list of rules
+func (g *PortRuleGRPC) Exists(rules L7Rules) bool {
+	for _, existingRule := range rules.GRPC {
+		if g.Equal(existingRule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Equal returns true if both gRPC rules are equal
+func (g *PortRuleGRPC) Equal(o PortRuleGRPC) bool {
+	if g.Service != o.Service ||
+		g.Method != o.Method ||
+		len(g.Metadata) != len(o.Metadata) {
+		return false
+	}
+
+	for i, value := range g.Metadata {
+		if o.Metadata[i] != value {
+			return false
+		}
+	}
+	return true
+}
+
 // Equal returns true if both rules are equal
 func (k *PortRuleKafka) Equal(o PortRuleKafka) bool {
 	return k.APIVersion == o.APIVersion && k.APIKey == o.APIKey &&
-		k.Topic == o.Topic && k.ClientID == o.ClientID && k.Role == o.Role
+		k.Topic == o.Topic && k.ClientID == o.ClientID && k.Role == o.Role &&
+		k.Principal == o.Principal && k.ConsumerGroup == o.ConsumerGroup
 }
 
 // Exists returns true if the L7 rule already exists in the list of rules
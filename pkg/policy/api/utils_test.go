@@ -53,10 +53,14 @@ func (s *PolicyAPITestSuite) TestKafkaEqual(c *C) {
 	rule1 := PortRuleKafka{APIVersion: "1", APIKey: "foo", Topic: "topic1"}
 	rule2 := PortRuleKafka{APIVersion: "1", APIKey: "bar", Topic: "topic1"}
 	rule3 := PortRuleKafka{APIVersion: "1", APIKey: "foo", Topic: "topic2"}
+	rule4 := PortRuleKafka{APIVersion: "1", APIKey: "foo", Topic: "topic1", Principal: "alice"}
+	rule5 := PortRuleKafka{APIVersion: "1", APIKey: "foo", Topic: "topic1", ConsumerGroup: "group1"}
 
 	c.Assert(rule1.Equal(rule1), Equals, true)
 	c.Assert(rule1.Equal(rule2), Equals, false)
 	c.Assert(rule1.Equal(rule3), Equals, false)
+	c.Assert(rule1.Equal(rule4), Equals, false)
+	c.Assert(rule1.Equal(rule5), Equals, false)
 
 	rules := L7Rules{
 		Kafka: []PortRuleKafka{rule1, rule2},
@@ -119,6 +119,67 @@ func (in CIDRSlice) DeepCopy() CIDRSlice {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressDenyRule) DeepCopyInto(out *EgressDenyRule) {
+	*out = *in
+	if in.ToEndpoints != nil {
+		in, out := &in.ToEndpoints, &out.ToEndpoints
+		*out = make([]EndpointSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ToRequires != nil {
+		in, out := &in.ToRequires, &out.ToRequires
+		*out = make([]EndpointSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ToPorts != nil {
+		in, out := &in.ToPorts, &out.ToPorts
+		*out = make([]PortDenyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ToCIDR != nil {
+		in, out := &in.ToCIDR, &out.ToCIDR
+		*out = make(CIDRSlice, len(*in))
+		copy(*out, *in)
+	}
+	if in.ToCIDRSet != nil {
+		in, out := &in.ToCIDRSet, &out.ToCIDRSet
+		*out = make(CIDRRuleSlice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ToEntities != nil {
+		in, out := &in.ToEntities, &out.ToEntities
+		*out = make(EntitySlice, len(*in))
+		copy(*out, *in)
+	}
+	if in.aggregatedSelectors != nil {
+		in, out := &in.aggregatedSelectors, &out.aggregatedSelectors
+		*out = make(EndpointSelectorSlice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressDenyRule.
+func (in *EgressDenyRule) DeepCopy() *EgressDenyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressDenyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressRule) DeepCopyInto(out *EgressRule) {
 	*out = *in
@@ -309,6 +370,67 @@ func (in FQDNSelectorSlice) DeepCopy() FQDNSelectorSlice {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressDenyRule) DeepCopyInto(out *IngressDenyRule) {
+	*out = *in
+	if in.FromEndpoints != nil {
+		in, out := &in.FromEndpoints, &out.FromEndpoints
+		*out = make([]EndpointSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FromRequires != nil {
+		in, out := &in.FromRequires, &out.FromRequires
+		*out = make([]EndpointSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ToPorts != nil {
+		in, out := &in.ToPorts, &out.ToPorts
+		*out = make([]PortDenyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FromCIDR != nil {
+		in, out := &in.FromCIDR, &out.FromCIDR
+		*out = make(CIDRSlice, len(*in))
+		copy(*out, *in)
+	}
+	if in.FromCIDRSet != nil {
+		in, out := &in.FromCIDRSet, &out.FromCIDRSet
+		*out = make(CIDRRuleSlice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FromEntities != nil {
+		in, out := &in.FromEntities, &out.FromEntities
+		*out = make(EntitySlice, len(*in))
+		copy(*out, *in)
+	}
+	if in.aggregatedSelectors != nil {
+		in, out := &in.aggregatedSelectors, &out.aggregatedSelectors
+		*out = make(EndpointSelectorSlice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressDenyRule.
+func (in *IngressDenyRule) DeepCopy() *IngressDenyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressDenyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressRule) DeepCopyInto(out *IngressRule) {
 	*out = *in
@@ -370,6 +492,27 @@ func (in *IngressRule) DeepCopy() *IngressRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryGroup) DeepCopyInto(out *InventoryGroup) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryGroup.
+func (in *InventoryGroup) DeepCopy() *InventoryGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K8sServiceNamespace) DeepCopyInto(out *K8sServiceNamespace) {
 	*out = *in
@@ -445,6 +588,13 @@ func (in *L7Rules) DeepCopyInto(out *L7Rules) {
 		*out = make([]PortRuleDNS, len(*in))
 		copy(*out, *in)
 	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = make([]PortRuleGRPC, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.L7 != nil {
 		in, out := &in.L7, &out.L7
 		*out = make([]PortRuleL7, len(*in))
@@ -471,6 +621,27 @@ func (in *L7Rules) DeepCopy() *L7Rules {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortDenyRule) DeepCopyInto(out *PortDenyRule) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]PortProtocol, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortDenyRule.
+func (in *PortDenyRule) DeepCopy() *PortDenyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PortDenyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PortProtocol) DeepCopyInto(out *PortProtocol) {
 	*out = *in
@@ -529,6 +700,27 @@ func (in *PortRuleDNS) DeepCopy() *PortRuleDNS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortRuleGRPC) DeepCopyInto(out *PortRuleGRPC) {
+	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortRuleGRPC.
+func (in *PortRuleGRPC) DeepCopy() *PortRuleGRPC {
+	if in == nil {
+		return nil
+	}
+	out := new(PortRuleGRPC)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PortRuleHTTP) DeepCopyInto(out *PortRuleHTTP) {
 	*out = *in
@@ -616,6 +808,20 @@ func (in *Rule) DeepCopyInto(out *Rule) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.IngressDeny != nil {
+		in, out := &in.IngressDeny, &out.IngressDeny
+		*out = make([]IngressDenyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EgressDeny != nil {
+		in, out := &in.EgressDeny, &out.EgressDeny
+		*out = make([]EgressDenyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.Labels = in.Labels.DeepCopy()
 	return
 }
@@ -722,6 +928,11 @@ func (in *ToGroups) DeepCopyInto(out *ToGroups) {
 		*out = new(AWSGroup)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(InventoryGroup)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
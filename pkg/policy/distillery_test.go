@@ -246,8 +246,8 @@ var (
 	mapKeyAllow___L4 = Key{0, 80, 6, dirIngress}
 	mapKeyAllowAll__ = Key{0, 0, 0, dirIngress}
 	// Desired map entries for no L7 redirect / redirect to Proxy
-	mapEntryL7None_ = MapStateEntry{l7RedirectNone_}
-	mapEntryL7Proxy = MapStateEntry{l7RedirectProxy}
+	mapEntryL7None_ = MapStateEntry{ProxyPort: l7RedirectNone_}
+	mapEntryL7Proxy = MapStateEntry{ProxyPort: l7RedirectProxy}
 )
 
 // combineL4L7 returns a new PortRule that refers to the specified l4 ports and
@@ -318,9 +318,9 @@ func (d *policyDistillery) distillPolicy(epLabels labels.LabelArray) (MapState,
 		for _, key := range l4.ToKeys(0) {
 			io.WriteString(d.log, fmt.Sprintf("[distill] L4 ingress allow %+v (parser=%s, redirect=%t)\n", key, l4.L7Parser, l4.IsRedirect()))
 			if l4.IsRedirect() {
-				result[key] = MapStateEntry{l7RedirectProxy}
+				result[key] = MapStateEntry{ProxyPort: l7RedirectProxy}
 			} else {
-				result[key] = MapStateEntry{l7RedirectNone_}
+				result[key] = MapStateEntry{ProxyPort: l7RedirectNone_}
 			}
 		}
 	}
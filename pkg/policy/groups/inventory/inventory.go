@@ -0,0 +1,184 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory implements a ToGroups provider which selects the IPs of
+// groups listed in an inventory, e.g. a CMDB. The inventory is a JSON object
+// mapping group names to lists of IPs:
+//
+//	{"db-servers": ["10.1.0.10", "10.1.0.11"], "ldap": ["10.2.0.5"]}
+//
+// It is read from an HTTP(S) URL or from a local file.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cilium/cilium/pkg/lock"
+	"github.com/cilium/cilium/pkg/logging"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// fetchTimeout is the maximum time to wait for the inventory source
+	fetchTimeout = 30 * time.Second
+
+	// maxInventorySize is the maximum size of the inventory in bytes
+	maxInventorySize = 16 << 20
+
+	// minRefreshInterval is the minimum time between two fetches of the
+	// inventory. Derivative policies of all CiliumNetworkPolicies are
+	// updated at once, they share the fetched inventory.
+	minRefreshInterval = 30 * time.Second
+)
+
+var (
+	log = logging.DefaultLogger.WithField(logfields.LogSubsys, "policy-groups-inventory")
+
+	mutex     lock.Mutex
+	source    string
+	inventory map[string][]net.IP
+	fetched   time.Time
+
+	// now is replaced in tests
+	now = time.Now
+)
+
+func init() {
+	api.RegisterToGroupsProvider(api.InventoryProvider, GetIPsFromGroup)
+}
+
+// SetSource sets the location of the inventory. src is either an http:// or
+// https:// URL or the path of a local file, optionally prefixed with
+// file://. An empty src disables the provider.
+func SetSource(src string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	source = src
+	inventory = nil
+	fetched = time.Time{}
+}
+
+// GetIPsFromGroup returns the IPs of the inventory groups selected by group
+func GetIPsFromGroup(group *api.ToGroups) ([]net.IP, error) {
+	if group.Inventory == nil {
+		return []net.IP{}, fmt.Errorf("no inventory data available")
+	}
+
+	inv, err := getInventory()
+	if err != nil {
+		return []net.IP{}, err
+	}
+
+	result := []net.IP{}
+	for _, name := range group.Inventory.Groups {
+		ips, ok := inv[name]
+		if !ok {
+			log.WithField("group", name).Warning("Group not found in inventory")
+			continue
+		}
+		result = append(result, ips...)
+	}
+	return result, nil
+}
+
+// getInventory returns the inventory, fetching it from the source if it has
+// not been fetched within minRefreshInterval
+func getInventory() (map[string][]net.IP, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if source == "" {
+		return nil, fmt.Errorf("no inventory source configured")
+	}
+	if inventory != nil && now().Sub(fetched) < minRefreshInterval {
+		return inventory, nil
+	}
+
+	inv, err := fetchInventory(source)
+	if err != nil {
+		return nil, err
+	}
+	inventory = inv
+	fetched = now()
+	return inventory, nil
+}
+
+// fetchInventory reads and parses the inventory from src
+func fetchInventory(src string) (map[string][]net.IP, error) {
+	var r io.ReadCloser
+	switch {
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		client := &http.Client{Timeout: fetchTimeout}
+		resp, err := client.Get(src)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot retrieve inventory: %s", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("Cannot retrieve inventory: unexpected status %s", resp.Status)
+		}
+		r = resp.Body
+	default:
+		f, err := os.Open(strings.TrimPrefix(src, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("Cannot open inventory: %s", err)
+		}
+		r = f
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxInventorySize))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read inventory: %s", err)
+	}
+	return parseInventory(data)
+}
+
+// parseInventory parses an inventory mapping group names to lists of IPs.
+// Invalid IPs are skipped.
+func parseInventory(data []byte) (map[string][]net.IP, error) {
+	groups := map[string][]string{}
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("Cannot parse inventory: %s", err)
+	}
+
+	inv := make(map[string][]net.IP, len(groups))
+	for name, ipStrs := range groups {
+		ips := make([]net.IP, 0, len(ipStrs))
+		for _, ipStr := range ipStrs {
+			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				log.WithFields(logrus.Fields{
+					"group":          name,
+					logfields.IPAddr: ipStr,
+				}).Warning("Skipping invalid IP in inventory")
+				continue
+			}
+			ips = append(ips, ip)
+		}
+		inv[name] = ips
+	}
+	return inv, nil
+}
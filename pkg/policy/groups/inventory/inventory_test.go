@@ -0,0 +1,152 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package inventory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/pkg/checker"
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type InventoryTestSuite struct{}
+
+var _ = Suite(&InventoryTestSuite{})
+
+const testInventory = `{
+	"db": ["10.0.0.1", "10.0.0.2"],
+	"ldap": ["10.0.1.1", "not-an-ip", "f00d::1"]
+}`
+
+func (s *InventoryTestSuite) TearDownTest(c *C) {
+	SetSource("")
+	now = time.Now
+}
+
+func inventoryGroup(groups ...string) *api.ToGroups {
+	return &api.ToGroups{Inventory: &api.InventoryGroup{Groups: groups}}
+}
+
+func (s *InventoryTestSuite) TestParseInventory(c *C) {
+	inv, err := parseInventory([]byte(testInventory))
+	c.Assert(err, IsNil)
+	c.Assert(inv, checker.DeepEquals, map[string][]net.IP{
+		"db":   {net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+		"ldap": {net.ParseIP("10.0.1.1"), net.ParseIP("f00d::1")},
+	})
+
+	_, err = parseInventory([]byte(`["10.0.0.1"]`))
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *InventoryTestSuite) TestNoSource(c *C) {
+	_, err := GetIPsFromGroup(inventoryGroup("db"))
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *InventoryTestSuite) TestFileSource(c *C) {
+	dir, err := ioutil.TempDir("", "inventory")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "inventory.json")
+	c.Assert(ioutil.WriteFile(path, []byte(testInventory), 0600), IsNil)
+
+	SetSource("file://" + path)
+	ips, err := GetIPsFromGroup(inventoryGroup("db", "ldap", "unknown"))
+	c.Assert(err, IsNil)
+	c.Assert(ips, checker.DeepEquals, []net.IP{
+		net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"),
+		net.ParseIP("10.0.1.1"), net.ParseIP("f00d::1"),
+	})
+
+	// Plain paths work as well
+	SetSource(path)
+	ips, err = GetIPsFromGroup(inventoryGroup("db"))
+	c.Assert(err, IsNil)
+	c.Assert(ips, HasLen, 2)
+}
+
+func (s *InventoryTestSuite) TestHTTPSourceRefresh(c *C) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"db": ["10.0.0.%d"]}`, requests)
+	}))
+	defer server.Close()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+
+	SetSource(server.URL)
+	ips, err := GetIPsFromGroup(inventoryGroup("db"))
+	c.Assert(err, IsNil)
+	c.Assert(ips, checker.DeepEquals, []net.IP{net.ParseIP("10.0.0.1")})
+
+	// The fetched inventory is reused within minRefreshInterval
+	current = current.Add(minRefreshInterval / 2)
+	ips, err = GetIPsFromGroup(inventoryGroup("db"))
+	c.Assert(err, IsNil)
+	c.Assert(ips, checker.DeepEquals, []net.IP{net.ParseIP("10.0.0.1")})
+	c.Assert(requests, Equals, 1)
+
+	current = current.Add(minRefreshInterval)
+	ips, err = GetIPsFromGroup(inventoryGroup("db"))
+	c.Assert(err, IsNil)
+	c.Assert(ips, checker.DeepEquals, []net.IP{net.ParseIP("10.0.0.2")})
+	c.Assert(requests, Equals, 2)
+}
+
+func (s *InventoryTestSuite) TestHTTPSourceError(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	SetSource(server.URL)
+	_, err := GetIPsFromGroup(inventoryGroup("db"))
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *InventoryTestSuite) TestGetCidrSet(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testInventory)
+	}))
+	defer server.Close()
+
+	SetSource(server.URL)
+	cidrs, err := inventoryGroup("db").GetCidrSet()
+	c.Assert(err, IsNil)
+	c.Assert(cidrs, checker.DeepEquals, []api.CIDRRule{
+		{Cidr: "10.0.0.1/32", ExceptCIDRs: []api.CIDR{}, Generated: true},
+		{Cidr: "10.0.0.2/32", ExceptCIDRs: []api.CIDR{}, Generated: true},
+	})
+}
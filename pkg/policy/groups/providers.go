@@ -16,5 +16,6 @@ package groups
 
 // Empty imports to register providers
 import (
-	_ "github.com/cilium/cilium/pkg/policy/groups/aws" // AWS import to be able to register the provider.
+	_ "github.com/cilium/cilium/pkg/policy/groups/aws"       // AWS import to be able to register the provider.
+	_ "github.com/cilium/cilium/pkg/policy/groups/inventory" // Inventory import to be able to register the provider.
 )
@@ -125,6 +125,9 @@ type L4Filter struct {
 	L7RulesPerEp L7DataMap `json:"l7-rules,omitempty"`
 	// Ingress is true if filter applies at ingress; false if it applies at egress.
 	Ingress bool `json:"-"`
+	// IsDeny is true if traffic selected by this filter must be denied
+	// rather than allowed.
+	IsDeny bool `json:"deny,omitempty"`
 	// The rule labels of this Filter
 	DerivedFromRules labels.LabelArrayList `json:"-"`
 
@@ -247,7 +250,7 @@ func (l4 *L4Filter) IdentitySelectionUpdated(selector CachedSelector, selections
 		if l4.Ingress {
 			direction = trafficdirection.Ingress
 		}
-		l4Policy.AccumulateMapChanges(added, deleted, uint16(l4.Port), uint8(l4.U8Proto), direction)
+		l4Policy.AccumulateMapChanges(added, deleted, uint16(l4.Port), uint8(l4.U8Proto), direction, l4.IsDeny)
 	}
 }
 
@@ -334,7 +337,8 @@ func createL4Filter(peerEndpoints api.EndpointSelectorSlice, rule api.PortRule,
 
 	if protocol == api.ProtoTCP && rule.Rules != nil {
 		switch {
-		case len(rule.Rules.HTTP) > 0:
+		case len(rule.Rules.HTTP) > 0, len(rule.Rules.GRPC) > 0:
+			// gRPC rules are enforced by the HTTP parser
 			l4.L7Parser = ParserTypeHTTP
 		case len(rule.Rules.Kafka) > 0:
 			l4.L7Parser = ParserTypeKafka
@@ -355,6 +359,18 @@ func createL4Filter(peerEndpoints api.EndpointSelectorSlice, rule api.PortRule,
 	return l4
 }
 
+// createL4DenyFilter creates a filter for L4 policy that denies traffic
+// to / from the specified endpoints at the given port/protocol, with
+// reference to the original rules that the filter is derived from. Deny
+// filters never carry L7 rules.
+func createL4DenyFilter(peerEndpoints api.EndpointSelectorSlice, port api.PortProtocol,
+	protocol api.L4Proto, ruleLabels labels.LabelArray, ingress bool, selectorCache *SelectorCache) *L4Filter {
+
+	filter := createL4Filter(peerEndpoints, api.PortRule{}, port, protocol, ruleLabels, ingress, selectorCache, nil)
+	filter.IsDeny = true
+	return filter
+}
+
 // detach releases the references held in the L4Filter and must be called before
 // the filter is left to be garbage collected.
 func (l4 *L4Filter) detach(selectorCache *SelectorCache) {
@@ -444,6 +460,28 @@ func (l4 *L4Filter) matchesLabels(labels labels.LabelArray) bool {
 	return false
 }
 
+// GetL7RulesForLabels returns the L7 rules of the filter which apply to the
+// remote endpoint with the given labels. If any selector matching the labels
+// is not constrained at L7, nil is returned and all requests are allowed.
+//
+// Note: Only used for policy simulation
+func (l4 *L4Filter) GetL7RulesForLabels(labels labels.LabelArray) []api.L7Rules {
+	var rules []api.L7Rules
+	for sel, l7 := range l4.L7RulesPerEp {
+		if !sel.IsWildcard() {
+			idSel, ok := sel.(*labelIdentitySelector)
+			if !ok || !idSel.xxxMatches(labels) {
+				continue
+			}
+		}
+		if l7.Len() == 0 {
+			return nil
+		}
+		rules = append(rules, l7)
+	}
+	return rules
+}
+
 // L4PolicyMap is a list of L4 filters indexable by protocol/port
 // key format: "port/proto"
 type L4PolicyMap map[string]*L4Filter
@@ -531,6 +569,13 @@ type L4Policy struct {
 	Ingress L4PolicyMap
 	Egress  L4PolicyMap
 
+	// IngressDeny and EgressDeny contain the filters derived from deny
+	// rules. Traffic selected by these filters is denied even if it is
+	// allowed by the filters in Ingress or Egress. They are nil if no
+	// deny rules apply.
+	IngressDeny L4PolicyMap
+	EgressDeny  L4PolicyMap
+
 	// Revision is the repository revision used to generate this policy.
 	Revision uint64
 
@@ -577,10 +622,18 @@ func (l4 *L4Policy) insertUser(user *EndpointPolicy) {
 // The caller is responsible for making sure the same identity is not
 // present in both 'adds' and 'deletes'.
 func (l4 *L4Policy) AccumulateMapChanges(adds, deletes []identity.NumericIdentity,
-	port uint16, proto uint8, direction trafficdirection.TrafficDirection) {
+	port uint16, proto uint8, direction trafficdirection.TrafficDirection, isDeny bool) {
+	// Deleting deny entries, or L3-only allow entries that deny entries
+	// may have been derived from, can not be done incrementally.
+	recompute := len(deletes) > 0 &&
+		(isDeny || (port == 0 && proto == 0 && l4.hasDeny()))
+
 	l4.mutex.RLock()
 	for epPolicy := range l4.users {
-		epPolicy.PolicyMapChanges.AccumulateMapChanges(adds, deletes, port, proto, direction)
+		epPolicy.PolicyMapChanges.AccumulateMapChanges(adds, deletes, port, proto, direction, isDeny)
+		if recompute {
+			epPolicy.PolicyMapChanges.RequireRecompute()
+		}
 	}
 	l4.mutex.RUnlock()
 }
@@ -592,6 +645,8 @@ func (l4 *L4Policy) AccumulateMapChanges(adds, deletes []identity.NumericIdentit
 func (l4 *L4Policy) Detach(selectorCache *SelectorCache) {
 	l4.Ingress.Detach(selectorCache)
 	l4.Egress.Detach(selectorCache)
+	l4.IngressDeny.Detach(selectorCache)
+	l4.EgressDeny.Detach(selectorCache)
 
 	l4.mutex.Lock()
 	l4.users = nil
@@ -603,6 +658,8 @@ func (l4 *L4Policy) Detach(selectorCache *SelectorCache) {
 func (l4 *L4Policy) Attach() {
 	l4.Ingress.Attach(l4)
 	l4.Egress.Attach(l4)
+	l4.IngressDeny.Attach(l4)
+	l4.EgressDeny.Attach(l4)
 }
 
 // IngressCoversContext checks if the receiver's ingress L4Policy contains
@@ -621,15 +678,84 @@ func (l4 *L4PolicyMap) EgressCoversContext(ctx *SearchContext) api.Decision {
 	return l4.containsAllL3L4(ctx.To, ctx.DPorts)
 }
 
+// deniesAnyL3L4 returns the first deny filter in the L4PolicyMap which
+// matches the given labels on any of the given ports, or nil if no deny
+// filter matches.
+//
+// Note: Only used for policy tracing
+func (l4 L4PolicyMap) deniesAnyL3L4(labels labels.LabelArray, ports []*models.Port) *L4Filter {
+	// Check L3-only filters first.
+	filter, match := l4[api.PortProtocolAny]
+	if match && filter.matchesLabels(labels) {
+		return filter
+	}
+
+	for _, l4Ctx := range ports {
+		protocols := []string{l4Ctx.Protocol}
+		switch l4Ctx.Protocol {
+		case "", models.PortProtocolANY:
+			protocols = []string{models.PortProtocolTCP, models.PortProtocolUDP}
+		}
+		for _, proto := range protocols {
+			port := fmt.Sprintf("%d/%s", l4Ctx.Port, proto)
+			if filter, match := l4[port]; match && filter.matchesLabels(labels) {
+				return filter
+			}
+		}
+	}
+	return nil
+}
+
+// IngressDeniedByContext returns the ingress deny filter which matches the
+// `dPorts` and `labels` in the given context, or nil if none matches.
+//
+// Note: Only used for policy tracing
+func (l4 L4PolicyMap) IngressDeniedByContext(ctx *SearchContext) *L4Filter {
+	return l4.deniesAnyL3L4(ctx.From, ctx.DPorts)
+}
+
+// EgressDeniedByContext returns the egress deny filter which matches the
+// `dPorts` and `labels` in the given context, or nil if none matches.
+//
+// Note: Only used for policy tracing
+func (l4 L4PolicyMap) EgressDeniedByContext(ctx *SearchContext) *L4Filter {
+	return l4.deniesAnyL3L4(ctx.To, ctx.DPorts)
+}
+
+// MatchingFilter returns the filter which applies to traffic on the given
+// port and protocol from or to the remote endpoint with the given labels.
+// Port specific filters take precedence over L3-only filters, matching the
+// lookup order of the datapath. Returns nil if no filter applies.
+//
+// Note: Only used for policy simulation
+func (l4 L4PolicyMap) MatchingFilter(labels labels.LabelArray, port uint16, protocol api.L4Proto) *L4Filter {
+	if filter, match := l4[fmt.Sprintf("%d/%s", port, protocol)]; match && filter.matchesLabels(labels) {
+		return filter
+	}
+	if filter, match := l4[fmt.Sprintf("0/%s", protocol)]; match && filter.matchesLabels(labels) {
+		return filter
+	}
+	if filter, match := l4[api.PortProtocolAny]; match && filter.matchesLabels(labels) {
+		return filter
+	}
+	return nil
+}
+
 // HasRedirect returns true if the L4 policy contains at least one port redirection
 func (l4 *L4Policy) HasRedirect() bool {
 	return l4 != nil && (l4.Ingress.HasRedirect() || l4.Egress.HasRedirect())
 }
 
+// hasDeny returns true if the L4 policy contains at least one deny filter.
+func (l4 *L4Policy) hasDeny() bool {
+	return l4 != nil && (len(l4.IngressDeny) > 0 || len(l4.EgressDeny) > 0)
+}
+
 // RequiresConntrack returns true if if the L4 configuration requires
 // connection tracking to be enabled.
 func (l4 *L4Policy) RequiresConntrack() bool {
-	return l4 != nil && (len(l4.Ingress) > 0 || len(l4.Egress) > 0)
+	return l4 != nil && (len(l4.Ingress) > 0 || len(l4.Egress) > 0 ||
+		len(l4.IngressDeny) > 0 || len(l4.EgressDeny) > 0)
 }
 
 func (l4 *L4Policy) GetModel() *models.L4Policy {
@@ -638,19 +764,23 @@ func (l4 *L4Policy) GetModel() *models.L4Policy {
 	}
 
 	ingress := []*models.PolicyRule{}
-	for _, v := range l4.Ingress {
-		ingress = append(ingress, &models.PolicyRule{
-			Rule:             v.MarshalIndent(),
-			DerivedFromRules: v.DerivedFromRules.GetModel(),
-		})
+	for _, m := range []L4PolicyMap{l4.Ingress, l4.IngressDeny} {
+		for _, v := range m {
+			ingress = append(ingress, &models.PolicyRule{
+				Rule:             v.MarshalIndent(),
+				DerivedFromRules: v.DerivedFromRules.GetModel(),
+			})
+		}
 	}
 
 	egress := []*models.PolicyRule{}
-	for _, v := range l4.Egress {
-		egress = append(egress, &models.PolicyRule{
-			Rule:             v.MarshalIndent(),
-			DerivedFromRules: v.DerivedFromRules.GetModel(),
-		})
+	for _, m := range []L4PolicyMap{l4.Egress, l4.EgressDeny} {
+		for _, v := range m {
+			egress = append(egress, &models.PolicyRule{
+				Rule:             v.MarshalIndent(),
+				DerivedFromRules: v.DerivedFromRules.GetModel(),
+			})
+		}
 	}
 
 	return &models.L4Policy{
@@ -68,6 +68,117 @@ type MapStateEntry struct {
 	// If 0 (default), there is no proxy redirection for the corresponding
 	// Key.
 	ProxyPort uint16
+
+	// IsDeny is true when the traffic specified by the Key must be denied.
+	// Deny entries never redirect to a proxy.
+	IsDeny bool
+}
+
+// isL3Only returns true if the key matches on all ports and protocols.
+func (k Key) isL3Only() bool {
+	return k.DestPort == 0 && k.Nexthdr == 0
+}
+
+// covers returns true if all traffic selected by 'other' is also selected by
+// the receiver key.
+func (k Key) covers(other Key) bool {
+	if k.TrafficDirection != other.TrafficDirection {
+		return false
+	}
+	if k.Identity != 0 && k.Identity != other.Identity {
+		return false
+	}
+	if !k.isL3Only() {
+		return k.DestPort == other.DestPort && k.Nexthdr == other.Nexthdr
+	}
+	return true
+}
+
+// IsDenied returns true if the key is covered by any deny entry in the
+// MapState, i.e. inserting an allow entry for the key would have no effect.
+func (keys MapState) IsDenied(key Key) bool {
+	candidates := []Key{
+		key,
+		{Identity: key.Identity, TrafficDirection: key.TrafficDirection},
+		{DestPort: key.DestPort, Nexthdr: key.Nexthdr, TrafficDirection: key.TrafficDirection},
+		{TrafficDirection: key.TrafficDirection},
+	}
+	for _, k := range candidates {
+		if entry, ok := keys[k]; ok && entry.IsDeny && k.covers(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// DenyPreferredInsert inserts a key and entry into the MapState, making sure
+// that deny entries take precedence over allow entries regardless of the
+// order of insertion. See DenyPreferredInsertWithChanges().
+func (keys MapState) DenyPreferredInsert(newKey Key, newEntry MapStateEntry) {
+	keys.DenyPreferredInsertWithChanges(newKey, newEntry, nil, nil)
+}
+
+// DenyPreferredInsertWithChanges inserts a key and entry into the MapState
+// so that deny entries take precedence over allow entries. Any keys that are
+// added to or deleted from the MapState as a result are also recorded in
+// 'adds' and 'deletes', respectively, if they are non-nil.
+//
+// The datapath looks up the policy map in the order (identity, port),
+// (identity, ANY), (ANY, port), (ANY, ANY) and uses the first hit. Thus:
+// - An allow entry covered by a deny entry is never inserted.
+// - A deny entry removes all allow entries it covers.
+// - An L4-only deny (ANY, port) would be shadowed by an L3-only allow
+//   (identity, ANY), so a more specific (identity, port) deny entry is
+//   inserted for each such allow entry.
+func (keys MapState) DenyPreferredInsertWithChanges(newKey Key, newEntry MapStateEntry, adds, deletes MapState) {
+	if newEntry.IsDeny {
+		newEntry.ProxyPort = 0
+		for k, v := range keys {
+			if v.IsDeny || k == newKey {
+				continue
+			}
+			if newKey.covers(k) {
+				delete(keys, k)
+				if deletes != nil {
+					deletes[k] = v
+				}
+			} else if newKey.Identity == 0 && !newKey.isL3Only() && k.Identity != 0 && k.isL3Only() &&
+				k.TrafficDirection == newKey.TrafficDirection {
+				keys.insertWithChanges(Key{
+					Identity:         k.Identity,
+					DestPort:         newKey.DestPort,
+					Nexthdr:          newKey.Nexthdr,
+					TrafficDirection: newKey.TrafficDirection,
+				}, newEntry, adds)
+			}
+		}
+		keys.insertWithChanges(newKey, newEntry, adds)
+		return
+	}
+
+	if keys.IsDenied(newKey) {
+		return
+	}
+	if newKey.Identity != 0 && newKey.isL3Only() {
+		for k, v := range keys {
+			if v.IsDeny && k.Identity == 0 && !k.isL3Only() && k.TrafficDirection == newKey.TrafficDirection {
+				keys.insertWithChanges(Key{
+					Identity:         newKey.Identity,
+					DestPort:         k.DestPort,
+					Nexthdr:          k.Nexthdr,
+					TrafficDirection: k.TrafficDirection,
+				}, v, adds)
+			}
+		}
+	}
+	keys.insertWithChanges(newKey, newEntry, adds)
+}
+
+func (keys MapState) insertWithChanges(key Key, entry MapStateEntry, adds MapState) {
+	keys[key] = entry
+	if adds != nil {
+		adds[key] = entry
+	}
 }
 
 // DetermineAllowLocalhostIngress determines whether communication should be allowed
@@ -77,7 +188,7 @@ type MapStateEntry struct {
 func (keys MapState) DetermineAllowLocalhostIngress(l4Policy *L4Policy) {
 
 	if option.Config.AlwaysAllowLocalhost() || (l4Policy != nil && l4Policy.HasRedirect()) {
-		keys[localHostKey] = MapStateEntry{}
+		keys.DenyPreferredInsert(localHostKey, MapStateEntry{})
 	}
 }
 
@@ -112,6 +223,9 @@ type MapChanges struct {
 	mutex   lock.Mutex
 	adds    MapState
 	deletes MapState
+	// recompute is set when the accumulated changes can not be applied
+	// incrementally, see RequireRecompute().
+	recompute bool
 }
 
 // AccumulateMapChanges accumulates the given changes to the
@@ -124,7 +238,7 @@ type MapChanges struct {
 // cases where an identity is first added and then deleted, or first
 // deleted and then added.
 func (mc *MapChanges) AccumulateMapChanges(adds, deletes []identity.NumericIdentity,
-	port uint16, proto uint8, direction trafficdirection.TrafficDirection) {
+	port uint16, proto uint8, direction trafficdirection.TrafficDirection, isDeny bool) {
 	key := Key{
 		// The actual identity is set in the loops below
 		Identity: 0,
@@ -135,6 +249,7 @@ func (mc *MapChanges) AccumulateMapChanges(adds, deletes []identity.NumericIdent
 	}
 	value := MapStateEntry{
 		ProxyPort: 0, // Will be updated by the caller when applicable
+		IsDeny:    isDeny,
 	}
 
 	if option.Config.Debug {
@@ -144,6 +259,7 @@ func (mc *MapChanges) AccumulateMapChanges(adds, deletes []identity.NumericIdent
 			logfields.Port:             port,
 			logfields.Protocol:         proto,
 			logfields.TrafficDirection: direction,
+			logfields.IsDeny:           isDeny,
 		}).Debug("AccumulateMapChanges")
 	}
 
@@ -154,6 +270,10 @@ func (mc *MapChanges) AccumulateMapChanges(adds, deletes []identity.NumericIdent
 		}
 		for _, id := range adds {
 			key.Identity = id.Uint32()
+			// A deny for the same key takes precedence over an allow
+			if old, ok := mc.adds[key]; ok && old.IsDeny && !isDeny {
+				continue
+			}
 			mc.adds[key] = value
 			// Remove a potential previously deleted key
 			if mc.deletes != nil {
@@ -177,14 +297,34 @@ func (mc *MapChanges) AccumulateMapChanges(adds, deletes []identity.NumericIdent
 	mc.mutex.Unlock()
 }
 
+// RequireRecompute marks the MapChanges as not being applicable
+// incrementally. This is the case when deny entries are removed, as the
+// allow entries they have shadowed must be restored, and when L3-only allow
+// entries are removed while deny entries exist, as the deny entries derived
+// from them must be removed as well.
+func (mc *MapChanges) RequireRecompute() {
+	mc.mutex.Lock()
+	mc.recompute = true
+	mc.mutex.Unlock()
+}
+
 // ConsumeMapChanges transfers the changes from MapChanges to the caller.
 // May return nil maps.
 func (mc *MapChanges) ConsumeMapChanges() (adds, deletes MapState) {
+	adds, deletes, _ = mc.consumeMapChanges()
+	return adds, deletes
+}
+
+// consumeMapChanges transfers the changes from MapChanges to the caller,
+// also returning whether the changes require the MapState to be recomputed.
+func (mc *MapChanges) consumeMapChanges() (adds, deletes MapState, recompute bool) {
 	mc.mutex.Lock()
 	adds = mc.adds
 	mc.adds = nil
 	deletes = mc.deletes
 	mc.deletes = nil
+	recompute = mc.recompute
+	mc.recompute = false
 	mc.mutex.Unlock()
-	return adds, deletes
+	return adds, deletes, recompute
 }
@@ -17,6 +17,7 @@
 package policy
 
 import (
+	"github.com/cilium/cilium/pkg/checker"
 	"github.com/cilium/cilium/pkg/policy/trafficdirection"
 
 	"gopkg.in/check.v1"
@@ -31,3 +32,48 @@ func (ds *PolicyTestSuite) TestPolicyKeyTrafficDirection(c *check.C) {
 	c.Assert(k.IsIngress(), check.Equals, false)
 	c.Assert(k.IsEgress(), check.Equals, true)
 }
+
+func (ds *PolicyTestSuite) TestMapStateDenyPreferredInsert(c *check.C) {
+	ingress := trafficdirection.Ingress.Uint8()
+	allowL3 := Key{Identity: 100, TrafficDirection: ingress}
+	allowL3L4 := Key{Identity: 101, DestPort: 80, Nexthdr: 6, TrafficDirection: ingress}
+	denyL4 := Key{DestPort: 80, Nexthdr: 6, TrafficDirection: ingress}
+
+	keys := MapState{}
+	keys.DenyPreferredInsert(allowL3, MapStateEntry{})
+	keys.DenyPreferredInsert(allowL3L4, MapStateEntry{ProxyPort: 8080})
+	adds, deletes := MapState{}, MapState{}
+	keys.DenyPreferredInsertWithChanges(denyL4, MapStateEntry{ProxyPort: 8080, IsDeny: true}, adds, deletes)
+
+	// The covered L3/L4 allow is removed, the L3-only allow is kept
+	// but shadowed by a more specific deny for the denied port.
+	derived := Key{Identity: 100, DestPort: 80, Nexthdr: 6, TrafficDirection: ingress}
+	c.Assert(keys, checker.DeepEquals, MapState{
+		allowL3: MapStateEntry{},
+		denyL4:  MapStateEntry{IsDeny: true},
+		derived: MapStateEntry{IsDeny: true},
+	})
+	c.Assert(adds, checker.DeepEquals, MapState{
+		denyL4:  MapStateEntry{IsDeny: true},
+		derived: MapStateEntry{IsDeny: true},
+	})
+	c.Assert(deletes, checker.DeepEquals, MapState{
+		allowL3L4: MapStateEntry{ProxyPort: 8080},
+	})
+
+	// Allows covered by an existing deny are not inserted.
+	c.Assert(keys.IsDenied(allowL3L4), check.Equals, true)
+	keys.DenyPreferredInsert(allowL3L4, MapStateEntry{})
+	_, ok := keys[allowL3L4]
+	c.Assert(ok, check.Equals, false)
+
+	// A new L3-only allow gets the deny for the denied port, too.
+	allowL3New := Key{Identity: 102, TrafficDirection: ingress}
+	keys.DenyPreferredInsert(allowL3New, MapStateEntry{})
+	c.Assert(keys[Key{Identity: 102, DestPort: 80, Nexthdr: 6, TrafficDirection: ingress}], check.Equals, MapStateEntry{IsDeny: true})
+	c.Assert(keys[allowL3New], check.Equals, MapStateEntry{})
+
+	// Egress entries are unaffected by ingress denies.
+	egressKey := Key{Identity: 101, DestPort: 80, Nexthdr: 6, TrafficDirection: trafficdirection.Egress.Uint8()}
+	c.Assert(keys.IsDenied(egressKey), check.Equals, false)
+}
@@ -115,6 +115,15 @@ func (state *traceState) trace(rules int, ctx *SearchContext) {
 	}
 }
 
+func (state *traceState) traceDeny(rules int, ctx *SearchContext) {
+	ctx.PolicyTrace("%d/%d rules selected\n", state.selectedRules, rules)
+	if state.matchedRules > 0 {
+		ctx.PolicyTrace("Found deny rule\n")
+	} else {
+		ctx.PolicyTrace("Found no deny rule\n")
+	}
+}
+
 // This belongs to l4.go as this manipulates L4Filters
 func wildcardL3L4Rule(proto api.L4Proto, port int, endpoints api.EndpointSelectorSlice,
 	ruleLabels labels.LabelArray, l4Policy L4PolicyMap, selectorCache *SelectorCache) {
@@ -213,10 +222,37 @@ func (p *Repository) ResolveL4EgressPolicy(ctx *SearchContext) (L4PolicyMap, err
 	return result, nil
 }
 
+// ResolveL4IngressDenyPolicy resolves the L4 ingress deny policy for a set of
+// endpoints by searching the policy repository for `IngressDeny` rules that
+// are attached to a `Rule` where the EndpointSelector matches `ctx.To`.
+//
+// Caller must release resources by calling Detach() on the returned map!
+//
+// Note: Only used for policy tracing
+func (p *Repository) ResolveL4IngressDenyPolicy(ctx *SearchContext) (L4PolicyMap, error) {
+	return p.rules.resolveL4IngressDenyPolicy(ctx, p.GetSelectorCache())
+}
+
+// ResolveL4EgressDenyPolicy resolves the L4 egress deny policy for a set of
+// endpoints by searching the policy repository for `EgressDeny` rules that
+// are attached to a `Rule` where the EndpointSelector matches `ctx.From`.
+//
+// Caller must release resources by calling Detach() on the returned map!
+//
+// Note: Only used for policy tracing
+func (p *Repository) ResolveL4EgressDenyPolicy(ctx *SearchContext) (L4PolicyMap, error) {
+	return p.rules.resolveL4EgressDenyPolicy(ctx, p.GetSelectorCache())
+}
+
+// traceDenyingFilter reports the rules from which the deny filter was derived.
+func traceDenyingFilter(ctx *SearchContext, filter *L4Filter) {
+	ctx.PolicyTrace("Denied by rule(s) with labels %s on %d/%s\n", filter.DerivedFromRules, filter.Port, filter.Protocol)
+}
+
 // AllowsIngressRLocked evaluates the policy repository for the provided search
-// context and returns the verdict for ingress. If no matching policy allows for
-// the  connection, the request will be denied. The policy repository mutex must
-// be held.
+// context and returns the verdict for ingress. If any matching deny rule
+// applies, or if no matching policy allows for the connection, the request
+// will be denied. The policy repository mutex must be held.
 func (p *Repository) AllowsIngressRLocked(ctx *SearchContext) api.Decision {
 	// Lack of DPorts in the SearchContext means L3-only search
 	if len(ctx.DPorts) == 0 {
@@ -229,6 +265,19 @@ func (p *Repository) AllowsIngressRLocked(ctx *SearchContext) api.Decision {
 	}
 
 	ctx.PolicyTrace("Tracing %s", ctx.String())
+	denyPolicy, err := p.ResolveL4IngressDenyPolicy(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Evaluation error while resolving L4 ingress deny policy")
+	} else {
+		filter := denyPolicy.IngressDeniedByContext(ctx)
+		denyPolicy.Detach(p.GetSelectorCache())
+		if filter != nil {
+			traceDenyingFilter(ctx, filter)
+			ctx.PolicyTrace("Ingress verdict: %s", api.Denied.String())
+			return api.Denied
+		}
+	}
+
 	ingressPolicy, err := p.ResolveL4IngressPolicy(ctx)
 	if err != nil {
 		log.WithError(err).Warn("Evaluation error while resolving L4 ingress policy")
@@ -246,9 +295,9 @@ func (p *Repository) AllowsIngressRLocked(ctx *SearchContext) api.Decision {
 }
 
 // AllowsEgressRLocked evaluates the policy repository for the provided search
-// context and returns the verdict. If no matching policy allows for the
-// connection, the request will be denied. The policy repository mutex must be
-// held.
+// context and returns the verdict. If any matching deny rule applies, or if no
+// matching policy allows for the connection, the request will be denied. The
+// policy repository mutex must be held.
 //
 // NOTE: This is only called from unit tests, but from multiple packages.
 func (p *Repository) AllowsEgressRLocked(ctx *SearchContext) api.Decision {
@@ -263,6 +312,19 @@ func (p *Repository) AllowsEgressRLocked(ctx *SearchContext) api.Decision {
 	}
 
 	ctx.PolicyTrace("Tracing %s\n", ctx.String())
+	denyPolicy, err := p.ResolveL4EgressDenyPolicy(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Evaluation error while resolving L4 egress deny policy")
+	} else {
+		filter := denyPolicy.EgressDeniedByContext(ctx)
+		denyPolicy.Detach(p.GetSelectorCache())
+		if filter != nil {
+			traceDenyingFilter(ctx, filter)
+			ctx.PolicyTrace("Egress verdict: %s", api.Denied.String())
+			return api.Denied
+		}
+	}
+
 	egressPolicy, err := p.ResolveL4EgressPolicy(ctx)
 	if err != nil {
 		log.WithError(err).Warn("Evaluation error while resolving L4 egress policy")
@@ -653,6 +715,25 @@ func (p *Repository) resolvePolicyLocked(securityIdentity *identity.Identity) (*
 		calculatedPolicy.L4Policy.Egress = newL4EgressPolicy
 	}
 
+	// Deny rules are resolved even if policy enforcement is not enabled in
+	// the given direction, as they take precedence over the allow-all
+	// policy in that case.
+	newL4IngressDenyPolicy, err := matchingRules.resolveL4IngressDenyPolicy(&ingressCtx, p.GetSelectorCache())
+	if err != nil {
+		return nil, err
+	}
+	if len(newL4IngressDenyPolicy) > 0 {
+		calculatedPolicy.L4Policy.IngressDeny = newL4IngressDenyPolicy
+	}
+
+	newL4EgressDenyPolicy, err := matchingRules.resolveL4EgressDenyPolicy(&egressCtx, p.GetSelectorCache())
+	if err != nil {
+		return nil, err
+	}
+	if len(newL4EgressDenyPolicy) > 0 {
+		calculatedPolicy.L4Policy.EgressDeny = newL4EgressDenyPolicy
+	}
+
 	// Make the calculated policy ready for incremental updates
 	calculatedPolicy.Attach()
 
@@ -19,6 +19,7 @@ package policy
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/cilium/cilium/api/v1/models"
@@ -556,6 +557,128 @@ func (ds *PolicyTestSuite) TestAllowsEgress(c *C) {
 	}), Equals, api.Denied)
 }
 
+func (ds *PolicyTestSuite) TestDenyIngress(c *C) {
+	repo := NewPolicyRepository()
+	repo.selectorCache = testSelectorCache
+
+	tag1 := labels.LabelArray{labels.ParseLabel("tag1")}
+	rule1 := api.Rule{
+		EndpointSelector: api.NewESFromLabels(labels.ParseSelectLabel("bar")),
+		Ingress: []api.IngressRule{
+			{
+				FromEndpoints: []api.EndpointSelector{
+					api.NewESFromLabels(labels.ParseSelectLabel("foo")),
+					api.NewESFromLabels(labels.ParseSelectLabel("baz")),
+				},
+			},
+		},
+		IngressDeny: []api.IngressDenyRule{
+			{
+				FromEndpoints: []api.EndpointSelector{
+					api.NewESFromLabels(labels.ParseSelectLabel("baz")),
+				},
+			},
+			{
+				ToPorts: []api.PortDenyRule{{
+					Ports: []api.PortProtocol{
+						{Port: "23", Protocol: api.ProtoTCP},
+					},
+				}},
+			},
+		},
+		Labels: tag1,
+	}
+
+	oldPolicyEnable := GetPolicyEnabled()
+	defer SetPolicyEnabled(oldPolicyEnable)
+	SetPolicyEnabled(option.DefaultEnforcement)
+
+	repo.Mutex.Lock()
+	defer repo.Mutex.Unlock()
+	repo.AddListLocked(api.Rules{&rule1})
+
+	// foo=>bar is allowed
+	c.Assert(repo.AllowsIngressRLocked(buildSearchCtx("foo", "bar", 80)), Equals, api.Allowed)
+
+	// baz=>bar is denied even though it is also allowed
+	buffer := new(bytes.Buffer)
+	ctx := buildSearchCtx("baz", "bar", 80)
+	ctx.Logging = logging.NewLogBackend(buffer, "", 0)
+	c.Assert(repo.AllowsIngressRLocked(ctx), Equals, api.Denied)
+	c.Assert(strings.Contains(buffer.String(), "Found deny rule"), Equals, true)
+	c.Assert(strings.Contains(buffer.String(), "Denied by rule(s) with labels [[unspec:tag1]]"), Equals, true)
+
+	// foo=>bar on the denied port is denied
+	c.Assert(repo.AllowsIngressRLocked(buildSearchCtx("foo", "bar", 23)), Equals, api.Denied)
+
+	// Deny-only rules do not enable enforcement for other endpoints
+	rule2 := api.Rule{
+		EndpointSelector: api.NewESFromLabels(labels.ParseSelectLabel("qux")),
+		IngressDeny:      rule1.IngressDeny,
+		Labels:           tag1,
+	}
+	repo.AddListLocked(api.Rules{&rule2})
+	quxIdentity := identity.NewIdentity(identity.NumericIdentity(9002), labels.ParseSelectLabelArray("qux").Labels())
+	ingress, _, matchingRules := repo.computePolicyEnforcementAndRules(quxIdentity)
+	c.Assert(ingress, Equals, false)
+	c.Assert(len(matchingRules), Equals, 1)
+}
+
+func (ds *PolicyTestSuite) TestMatchingFilter(c *C) {
+	repo := NewPolicyRepository()
+	repo.selectorCache = testSelectorCache
+
+	tag1 := labels.LabelArray{labels.ParseLabel("tag1")}
+	rule1 := api.Rule{
+		EndpointSelector: api.NewESFromLabels(labels.ParseSelectLabel("bar")),
+		Ingress: []api.IngressRule{
+			{
+				FromEndpoints: []api.EndpointSelector{
+					api.NewESFromLabels(labels.ParseSelectLabel("foo")),
+				},
+			},
+			{
+				FromEndpoints: []api.EndpointSelector{
+					api.NewESFromLabels(labels.ParseSelectLabel("baz")),
+				},
+				ToPorts: []api.PortRule{{
+					Ports: []api.PortProtocol{
+						{Port: "80", Protocol: api.ProtoTCP},
+					},
+					Rules: &api.L7Rules{
+						HTTP: []api.PortRuleHTTP{{Method: "GET"}},
+					},
+				}},
+			},
+		},
+		Labels: tag1,
+	}
+	rule1.Sanitize()
+
+	repo.Mutex.Lock()
+	defer repo.Mutex.Unlock()
+	repo.AddListLocked(api.Rules{&rule1})
+
+	ctx := buildSearchCtx("baz", "bar", 80)
+	ctx.DPorts[0].Protocol = models.PortProtocolTCP
+	l4policy, err := repo.ResolveL4IngressPolicy(ctx)
+	c.Assert(err, IsNil)
+	defer l4policy.Detach(repo.GetSelectorCache())
+
+	// The port specific filter applies to baz
+	filter := l4policy.MatchingFilter(labels.ParseSelectLabelArray("baz"), 80, api.ProtoTCP)
+	c.Assert(filter, Not(IsNil))
+	c.Assert(filter.Port, Equals, 80)
+	c.Assert(filter.DerivedFromRules[0], checker.DeepEquals, tag1)
+	l7 := filter.GetL7RulesForLabels(labels.ParseSelectLabelArray("baz"))
+	c.Assert(l7, checker.DeepEquals, []api.L7Rules{{HTTP: []api.PortRuleHTTP{{Method: "GET"}}}})
+	c.Assert(filter.GetL7RulesForLabels(labels.ParseSelectLabelArray("qux")), IsNil)
+
+	// No filter applies to baz on other ports
+	c.Assert(l4policy.MatchingFilter(labels.ParseSelectLabelArray("baz"), 8080, api.ProtoTCP), IsNil)
+	c.Assert(l4policy.MatchingFilter(labels.ParseSelectLabelArray("qux"), 80, api.ProtoTCP), IsNil)
+}
+
 func (ds *PolicyTestSuite) TestWildcardL3RulesIngress(c *C) {
 	repo := NewPolicyRepository()
 	repo.selectorCache = testSelectorCache
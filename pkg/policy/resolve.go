@@ -137,24 +137,64 @@ func (p *selectorPolicy) DistillPolicy(policyOwner PolicyOwner) *EndpointPolicy
 	// Must come after the 'insertUser()' above to guarantee
 	// PolicyMapCanges will contain all changes that are applied
 	// after the computation of PolicyMapState has started.
-	calculatedPolicy.computeDesiredL4PolicyMapEntries()
+	calculatedPolicy.computeDesiredL4PolicyMapEntries(calculatedPolicy.PolicyMapState)
 	calculatedPolicy.PolicyMapState.DetermineAllowLocalhostIngress(p.L4Policy)
 
 	return calculatedPolicy
 }
 
+// ConsumeMapChanges transfers the pending changes to the PolicyMapState
+// to the caller. May return nil maps.
+//
+// If the pending changes can not be applied incrementally, the desired
+// PolicyMapState is recomputed from the L4Policy and 'recomputed' is
+// returned as true. In that case 'adds' and 'deletes' contain the complete
+// differences to the current PolicyMapState and must be applied as-is,
+// without any deny precedence processing.
+func (p *EndpointPolicy) ConsumeMapChanges() (adds, deletes MapState, recomputed bool) {
+	adds, deletes, recompute := p.PolicyMapChanges.consumeMapChanges()
+	if !recompute {
+		return adds, deletes, false
+	}
+
+	desired := make(MapState)
+	if !p.IngressPolicyEnabled || !p.EgressPolicyEnabled {
+		desired.AllowAllIdentities(!p.IngressPolicyEnabled, !p.EgressPolicyEnabled)
+	}
+	p.computeDesiredL4PolicyMapEntries(desired)
+	desired.DetermineAllowLocalhostIngress(p.L4Policy)
+
+	adds = make(MapState)
+	deletes = make(MapState)
+	for k, v := range desired {
+		if old, ok := p.PolicyMapState[k]; !ok || old != v {
+			adds[k] = v
+		}
+	}
+	for k, v := range p.PolicyMapState {
+		if _, ok := desired[k]; !ok {
+			deletes[k] = v
+		}
+	}
+	return adds, deletes, true
+}
+
 // computeDesiredL4PolicyMapEntries transforms the EndpointPolicy.L4Policy into
-// the datapath-friendly format inside EndpointPolicy.PolicyMapState.
-func (p *EndpointPolicy) computeDesiredL4PolicyMapEntries() {
+// the datapath-friendly format inside the given MapState.
+func (p *EndpointPolicy) computeDesiredL4PolicyMapEntries(policyMapState MapState) {
 
 	if p.L4Policy == nil {
 		return
 	}
-	p.computeDirectionL4PolicyMapEntries(p.L4Policy.Ingress, trafficdirection.Ingress)
-	p.computeDirectionL4PolicyMapEntries(p.L4Policy.Egress, trafficdirection.Egress)
+	p.computeDirectionL4PolicyMapEntries(policyMapState, p.L4Policy.Ingress, trafficdirection.Ingress)
+	p.computeDirectionL4PolicyMapEntries(policyMapState, p.L4Policy.Egress, trafficdirection.Egress)
+	p.computeDirectionL4PolicyMapEntries(policyMapState, p.L4Policy.IngressDeny, trafficdirection.Ingress)
+	p.computeDirectionL4PolicyMapEntries(policyMapState, p.L4Policy.EgressDeny, trafficdirection.Egress)
 }
 
-func (p *EndpointPolicy) computeDirectionL4PolicyMapEntries(l4PolicyMap L4PolicyMap, direction trafficdirection.TrafficDirection) {
+func (p *EndpointPolicy) computeDirectionL4PolicyMapEntries(policyMapState MapState, l4PolicyMap L4PolicyMap, direction trafficdirection.TrafficDirection) {
+	// Only pay for the deny precedence checks if there are deny filters.
+	hasDeny := p.L4Policy.hasDeny()
 	for _, filter := range l4PolicyMap {
 		keysFromFilter := filter.ToKeys(direction)
 		for _, keyFromFilter := range keysFromFilter {
@@ -171,7 +211,12 @@ func (p *EndpointPolicy) computeDirectionL4PolicyMapEntries(l4PolicyMap L4Policy
 					continue
 				}
 			}
-			p.PolicyMapState[keyFromFilter] = MapStateEntry{ProxyPort: proxyPort}
+			entry := MapStateEntry{ProxyPort: proxyPort, IsDeny: filter.IsDeny}
+			if hasDeny {
+				policyMapState.DenyPreferredInsert(keyFromFilter, entry)
+			} else {
+				policyMapState[keyFromFilter] = entry
+			}
 		}
 	}
 }
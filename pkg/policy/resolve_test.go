@@ -639,3 +639,121 @@ func (ds *PolicyTestSuite) TestMapStateWithIngress(c *C) {
 		{Identity: 193, DestPort: 80, Nexthdr: 6}: {},
 	})
 }
+
+func (ds *PolicyTestSuite) TestMapStateWithIngressDenyChanges(c *C) {
+	repo := bootstrapRepo(GenerateL3IngressRules, 1000, c)
+
+	idFooSelectLabelArray := labels.ParseSelectLabelArray("id=foo")
+	idFooSelectLabels := labels.Labels{}
+	for _, lbl := range idFooSelectLabelArray {
+		idFooSelectLabels[lbl.Key] = lbl
+	}
+	fooIdentity := identity.NewIdentity(12345, idFooSelectLabels)
+
+	lblTest := labels.ParseSelectLabel("id=resolve_test_2")
+	lblDeny := labels.ParseSelectLabel("deny")
+
+	rule1 := api.Rule{
+		EndpointSelector: api.NewESFromLabels(labels.ParseSelectLabel("id=foo")),
+		Ingress: []api.IngressRule{
+			{
+				FromEndpoints: []api.EndpointSelector{
+					api.NewESFromLabels(lblTest),
+				},
+			},
+		},
+		IngressDeny: []api.IngressDenyRule{
+			{
+				FromEndpoints: []api.EndpointSelector{
+					api.NewESFromLabels(lblTest, lblDeny),
+				},
+			},
+			{
+				ToPorts: []api.PortDenyRule{{
+					Ports: []api.PortProtocol{
+						{Port: "23", Protocol: api.ProtoTCP},
+					},
+				}},
+			},
+		},
+	}
+
+	rule1.Sanitize()
+	_, _, err := repo.Add(rule1, []Endpoint{})
+	c.Assert(err, IsNil)
+
+	repo.Mutex.RLock()
+	defer repo.Mutex.RUnlock()
+	selPolicy, err := repo.resolvePolicyLocked(fooIdentity)
+	c.Assert(err, IsNil)
+	policy := selPolicy.DistillPolicy(DummyOwner{})
+	defer policy.selectorPolicy.Detach()
+
+	// applyChanges applies the pending changes the same way the
+	// endpoint applies them to the bpf policy map.
+	applyChanges := func() bool {
+		adds, deletes, recomputed := policy.ConsumeMapChanges()
+		if recomputed {
+			for k, v := range adds {
+				policy.PolicyMapState[k] = v
+			}
+			for k := range deletes {
+				delete(policy.PolicyMapState, k)
+			}
+			return true
+		}
+		for k, v := range adds {
+			policy.PolicyMapState.DenyPreferredInsert(k, v)
+		}
+		for k, v := range deletes {
+			if old, ok := policy.PolicyMapState[k]; ok && old.IsDeny != v.IsDeny {
+				continue
+			}
+			delete(policy.PolicyMapState, k)
+		}
+		return false
+	}
+
+	initialState := MapState{
+		{TrafficDirection: trafficdirection.Egress.Uint8()}: {},
+		{DestPort: 23, Nexthdr: 6}:                          {IsDeny: true},
+	}
+	c.Assert(policy.PolicyMapState, checker.Equals, initialState)
+
+	denyIdentity := cache.IdentityCache{
+		identity.NumericIdentity(195): labels.ParseSelectLabelArray("id=resolve_test_2", "deny"),
+	}
+	allowIdentity := cache.IdentityCache{
+		identity.NumericIdentity(196): labels.ParseSelectLabelArray("id=resolve_test_2"),
+	}
+
+	// Adding identities is applied incrementally. The allow for the
+	// denied identity is shadowed, and the L3-only allow gets a deny
+	// entry for the denied port.
+	testSelectorCache.UpdateIdentities(denyIdentity, nil)
+	testSelectorCache.UpdateIdentities(allowIdentity, nil)
+	c.Assert(applyChanges(), Equals, false)
+	c.Assert(policy.PolicyMapState, checker.Equals, MapState{
+		{TrafficDirection: trafficdirection.Egress.Uint8()}: {},
+		{DestPort: 23, Nexthdr: 6}:                          {IsDeny: true},
+		{Identity: 195}:                                     {IsDeny: true},
+		{Identity: 196}:                                     {},
+		{Identity: 196, DestPort: 23, Nexthdr: 6}:           {IsDeny: true},
+	})
+
+	// Removing the denied identity requires recomputation
+	testSelectorCache.UpdateIdentities(nil, denyIdentity)
+	c.Assert(applyChanges(), Equals, true)
+	c.Assert(policy.PolicyMapState, checker.Equals, MapState{
+		{TrafficDirection: trafficdirection.Egress.Uint8()}: {},
+		{DestPort: 23, Nexthdr: 6}:                          {IsDeny: true},
+		{Identity: 196}:                                     {},
+		{Identity: 196, DestPort: 23, Nexthdr: 6}:           {IsDeny: true},
+	})
+
+	// Removing the L3-only allow also removes the deny entry derived
+	// from it.
+	testSelectorCache.UpdateIdentities(nil, allowIdentity)
+	c.Assert(applyChanges(), Equals, true)
+	c.Assert(policy.PolicyMapState, checker.Equals, initialState)
+}
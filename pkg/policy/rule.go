@@ -106,7 +106,9 @@ func mergePortProto(ctx *SearchContext, existingFilter, filterToMerge *L4Filter,
 	for cs, newL7Rules := range filterToMerge.L7RulesPerEp {
 		if l7Rules, ok := existingFilter.L7RulesPerEp[cs]; ok {
 			switch {
-			case len(newL7Rules.HTTP) > 0:
+			case len(newL7Rules.HTTP) > 0, len(newL7Rules.GRPC) > 0:
+				// gRPC rules are enforced by the HTTP parser, so they
+				// can be combined with HTTP rules.
 				if len(l7Rules.Kafka) > 0 || len(l7Rules.DNS) > 0 || l7Rules.L7Proto != "" {
 					ctx.PolicyTrace("   Merge conflict: mismatching L7 rule types.\n")
 					return fmt.Errorf("cannot merge conflicting L7 rule types")
@@ -117,8 +119,13 @@ func mergePortProto(ctx *SearchContext, existingFilter, filterToMerge *L4Filter,
 						l7Rules.HTTP = append(l7Rules.HTTP, newRule)
 					}
 				}
+				for _, newRule := range newL7Rules.GRPC {
+					if !newRule.Exists(l7Rules) {
+						l7Rules.GRPC = append(l7Rules.GRPC, newRule)
+					}
+				}
 			case len(newL7Rules.Kafka) > 0:
-				if len(l7Rules.HTTP) > 0 || len(l7Rules.DNS) > 0 || l7Rules.L7Proto != "" {
+				if len(l7Rules.HTTP) > 0 || len(l7Rules.GRPC) > 0 || len(l7Rules.DNS) > 0 || l7Rules.L7Proto != "" {
 					ctx.PolicyTrace("   Merge conflict: mismatching L7 rule types.\n")
 					return fmt.Errorf("cannot merge conflicting L7 rule types")
 				}
@@ -129,7 +136,7 @@ func mergePortProto(ctx *SearchContext, existingFilter, filterToMerge *L4Filter,
 					}
 				}
 			case newL7Rules.L7Proto != "":
-				if len(l7Rules.Kafka) > 0 || len(l7Rules.HTTP) > 0 || len(l7Rules.DNS) > 0 || (l7Rules.L7Proto != "" && l7Rules.L7Proto != newL7Rules.L7Proto) {
+				if len(l7Rules.Kafka) > 0 || len(l7Rules.HTTP) > 0 || len(l7Rules.GRPC) > 0 || len(l7Rules.DNS) > 0 || (l7Rules.L7Proto != "" && l7Rules.L7Proto != newL7Rules.L7Proto) {
 					ctx.PolicyTrace("   Merge conflict: mismatching L7 rule types.\n")
 					return fmt.Errorf("cannot merge conflicting L7 rule types")
 				}
@@ -143,7 +150,7 @@ func mergePortProto(ctx *SearchContext, existingFilter, filterToMerge *L4Filter,
 					}
 				}
 			case len(newL7Rules.DNS) > 0:
-				if len(l7Rules.HTTP) > 0 || len(l7Rules.Kafka) > 0 || len(l7Rules.L7) > 0 {
+				if len(l7Rules.HTTP) > 0 || len(l7Rules.GRPC) > 0 || len(l7Rules.Kafka) > 0 || len(l7Rules.L7) > 0 {
 					ctx.PolicyTrace("   Merge conflict: mismatching L7 rule types.\n")
 					return fmt.Errorf("cannot merge conflicting L7 rule types")
 				}
@@ -301,6 +308,9 @@ func mergeIngress(ctx *SearchContext, fromEndpoints api.EndpointSelectorSlice, t
 			for _, l7 := range r.Rules.HTTP {
 				ctx.PolicyTrace("          %+v\n", l7)
 			}
+			for _, l7 := range r.Rules.GRPC {
+				ctx.PolicyTrace("          %+v\n", l7)
+			}
 			for _, l7 := range r.Rules.Kafka {
 				ctx.PolicyTrace("          %+v\n", l7)
 			}
@@ -523,6 +533,9 @@ func mergeEgress(ctx *SearchContext, toEndpoints api.EndpointSelectorSlice, toPo
 			for _, l7 := range r.Rules.HTTP {
 				ctx.PolicyTrace("          %+v\n", l7)
 			}
+			for _, l7 := range r.Rules.GRPC {
+				ctx.PolicyTrace("          %+v\n", l7)
+			}
 			for _, l7 := range r.Rules.Kafka {
 				ctx.PolicyTrace("          %+v\n", l7)
 			}
@@ -616,3 +629,197 @@ func (r *rule) resolveEgressPolicy(ctx *SearchContext, state *traceState, result
 
 	return nil, nil
 }
+
+// ****************** DENY POLICY ******************
+
+func traceL3Deny(ctx *SearchContext, peerEndpoints api.EndpointSelectorSlice, direction string) {
+	var result bytes.Buffer
+
+	for _, sel := range peerEndpoints {
+		if len(sel.MatchLabels) > 0 {
+			result.WriteString("    Denies ")
+			result.WriteString(direction)
+			result.WriteString(" labels ")
+			result.WriteString(sel.String())
+			result.WriteString("\n")
+		}
+	}
+	ctx.PolicyTrace("%s", result.String())
+}
+
+// rulePortsOverlapSearchContext returns true if any of the given ports
+// selects any of the traffic in the SearchContext. Unlike
+// rulePortsCoverSearchContext(), a rule for a specific protocol matches a
+// search for any protocol, as some of the traffic searched for is denied.
+func rulePortsOverlapSearchContext(ports []api.PortProtocol, ctx *SearchContext) bool {
+	if len(ctx.DPorts) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		for _, dp := range ctx.DPorts {
+			tracePort := api.PortProtocol{
+				Port:     fmt.Sprintf("%d", dp.Port),
+				Protocol: api.L4Proto(dp.Protocol),
+			}
+			if p.Covers(tracePort) || tracePort.Covers(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mergeDenyPortProto merges all deny rules which share the same port &
+// protocol into the L4Filter mapped to by the specified port and protocol in
+// resMap.
+func mergeDenyPortProto(ctx *SearchContext, endpoints api.EndpointSelectorSlice, p api.PortProtocol,
+	proto api.L4Proto, ruleLabels labels.LabelArray, ingress bool, resMap L4PolicyMap, selectorCache *SelectorCache) (int, error) {
+
+	key := p.Port + "/" + string(proto)
+	existingFilter, ok := resMap[key]
+	if !ok {
+		resMap[key] = createL4DenyFilter(endpoints, p, proto, ruleLabels, ingress, selectorCache)
+		return 1, nil
+	}
+
+	filterToMerge := createL4DenyFilter(endpoints, p, proto, ruleLabels, ingress, selectorCache)
+	if err := mergePortProto(ctx, existingFilter, filterToMerge, selectorCache); err != nil {
+		filterToMerge.detach(selectorCache)
+		return 0, err
+	}
+	existingFilter.DerivedFromRules = append(existingFilter.DerivedFromRules, ruleLabels)
+	resMap[key] = existingFilter
+
+	return 1, nil
+}
+
+// mergeDeny merges the deny rule specified by peerEndpoints and toPorts into
+// resMap. 'peerLabels' are the labels of the peer in the given SearchContext,
+// i.e. ctx.From for ingress and ctx.To for egress.
+func mergeDeny(ctx *SearchContext, peerLabels labels.LabelArray, peerEndpoints api.EndpointSelectorSlice, toPorts []api.PortDenyRule,
+	ruleLabels labels.LabelArray, ingress bool, resMap L4PolicyMap, selectorCache *SelectorCache) (int, error) {
+	found := 0
+
+	direction := "to"
+	if ingress {
+		direction = "from"
+	}
+
+	if peerLabels != nil && len(peerEndpoints) > 0 {
+		if ctx.TraceEnabled() {
+			traceL3Deny(ctx, peerEndpoints, direction)
+		}
+		if !peerEndpoints.Matches(peerLabels) {
+			ctx.PolicyTrace("      No label match for %s", peerLabels)
+			return 0, nil
+		}
+		ctx.PolicyTrace("      Found all required labels")
+	}
+
+	// L3-only rule (with requirements folded into peerEndpoints).
+	if len(toPorts) == 0 && len(peerEndpoints) > 0 {
+		cnt, err := mergeDenyPortProto(ctx, peerEndpoints, api.PortProtocol{Port: "0", Protocol: api.ProtoAny}, api.ProtoAny, ruleLabels, ingress, resMap, selectorCache)
+		if err != nil {
+			return found, err
+		}
+		found += cnt
+	}
+
+	for _, r := range toPorts {
+		// An empty slice of EndpointSelector indicates that the rule denies
+		// all at L3.
+		if len(peerEndpoints) == 0 {
+			peerEndpoints = api.EndpointSelectorSlice{api.WildcardEndpointSelector}
+		}
+
+		ctx.PolicyTrace("      Denies port %v\n", r.Ports)
+		if !rulePortsOverlapSearchContext(r.Ports, ctx) {
+			ctx.PolicyTrace("        No port match found\n")
+			continue
+		}
+
+		for _, p := range r.Ports {
+			protocols := []api.L4Proto{p.Protocol}
+			if p.Protocol == api.ProtoAny {
+				protocols = []api.L4Proto{api.ProtoTCP, api.ProtoUDP}
+			}
+			for _, proto := range protocols {
+				cnt, err := mergeDenyPortProto(ctx, peerEndpoints, p, proto, ruleLabels, ingress, resMap, selectorCache)
+				if err != nil {
+					return found, err
+				}
+				found += cnt
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// resolveIngressDenyPolicy analyzes the IngressDeny section of the rule
+// against the given SearchContext, and merges it with any prior-generated
+// deny policy within the provided L4PolicyMap.
+func (r *rule) resolveIngressDenyPolicy(ctx *SearchContext, state *traceState, result L4PolicyMap, requirements []v1.LabelSelectorRequirement, selectorCache *SelectorCache) (L4PolicyMap, error) {
+	if !ctx.rulesSelect {
+		if !r.EndpointSelector.Matches(ctx.To) {
+			state.unSelectRule(ctx, ctx.To, r)
+			return nil, nil
+		}
+	}
+
+	if len(r.IngressDeny) == 0 {
+		return nil, nil
+	}
+
+	state.selectRule(ctx, r)
+	found := 0
+
+	for _, denyRule := range r.IngressDeny {
+		fromEndpoints := denyRule.GetSourceEndpointSelectorsWithRequirements(requirements)
+		cnt, err := mergeDeny(ctx, ctx.From, fromEndpoints, denyRule.ToPorts, r.Rule.Labels.DeepCopy(), true, result, selectorCache)
+		if err != nil {
+			return nil, err
+		}
+		found += cnt
+	}
+
+	if found > 0 {
+		return result, nil
+	}
+
+	return nil, nil
+}
+
+// resolveEgressDenyPolicy analyzes the EgressDeny section of the rule
+// against the given SearchContext, and merges it with any prior-generated
+// deny policy within the provided L4PolicyMap.
+func (r *rule) resolveEgressDenyPolicy(ctx *SearchContext, state *traceState, result L4PolicyMap, requirements []v1.LabelSelectorRequirement, selectorCache *SelectorCache) (L4PolicyMap, error) {
+	if !ctx.rulesSelect {
+		if !r.EndpointSelector.Matches(ctx.From) {
+			state.unSelectRule(ctx, ctx.From, r)
+			return nil, nil
+		}
+	}
+
+	if len(r.EgressDeny) == 0 {
+		return nil, nil
+	}
+
+	state.selectRule(ctx, r)
+	found := 0
+
+	for _, denyRule := range r.EgressDeny {
+		toEndpoints := denyRule.GetDestinationEndpointSelectorsWithRequirements(requirements)
+		cnt, err := mergeDeny(ctx, ctx.To, toEndpoints, denyRule.ToPorts, r.Rule.Labels.DeepCopy(), false, result, selectorCache)
+		if err != nil {
+			return nil, err
+		}
+		found += cnt
+	}
+
+	if found > 0 {
+		return result, nil
+	}
+
+	return nil, nil
+}
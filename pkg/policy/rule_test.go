@@ -808,6 +808,96 @@ func (ds *PolicyTestSuite) TestMergeL7PolicyEgress(c *C) {
 	expected.Detach(testSelectorCache)
 }
 
+func (ds *PolicyTestSuite) TestMergeL7PolicyHTTPAndGRPC(c *C) {
+	toBar := &SearchContext{To: labels.ParseSelectLabelArray("bar")}
+
+	grpcPort := []api.PortProtocol{{Port: "50051", Protocol: api.ProtoTCP}}
+	rule1 := &rule{
+		Rule: api.Rule{
+			EndpointSelector: api.NewESFromLabels(labels.ParseSelectLabel("bar")),
+			Ingress: []api.IngressRule{
+				{
+					ToPorts: []api.PortRule{{
+						Ports: grpcPort,
+						Rules: &api.L7Rules{
+							HTTP: []api.PortRuleHTTP{{Path: "/healthz"}},
+							GRPC: []api.PortRuleGRPC{{Service: "helloworld.Greeter"}},
+						},
+					}},
+				},
+				{
+					ToPorts: []api.PortRule{{
+						Ports: grpcPort,
+						Rules: &api.L7Rules{
+							GRPC: []api.PortRuleGRPC{
+								{Service: "helloworld.Greeter"},
+								{Service: "grpc.health.v1.Health"},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	c.Assert(rule1.Sanitize(), IsNil)
+
+	expected := L4PolicyMap{"50051/TCP": &L4Filter{
+		Port: 50051, Protocol: api.ProtoTCP, U8Proto: 6,
+		allowsAllAtL3:   true,
+		CachedSelectors: CachedSelectorSlice{wildcardCachedSelector},
+		L7Parser:        ParserTypeHTTP,
+		L7RulesPerEp: L7DataMap{
+			wildcardCachedSelector: api.L7Rules{
+				HTTP: []api.PortRuleHTTP{{Path: "/healthz"}},
+				GRPC: []api.PortRuleGRPC{
+					{Service: "helloworld.Greeter"},
+					{Service: "grpc.health.v1.Health"},
+				},
+			},
+		},
+		Ingress:          true,
+		DerivedFromRules: labels.LabelArrayList{nil, nil},
+	}}
+
+	state := traceState{}
+	res, err := rule1.resolveIngressPolicy(toBar, &state, L4PolicyMap{}, nil, testSelectorCache)
+	c.Assert(err, IsNil)
+	c.Assert(res, Not(IsNil))
+	c.Assert(res, checker.Equals, expected)
+	res.Detach(testSelectorCache)
+	expected.Detach(testSelectorCache)
+
+	// gRPC rules cannot be merged with Kafka rules on the same port
+	rule2 := &rule{
+		Rule: api.Rule{
+			EndpointSelector: api.NewESFromLabels(labels.ParseSelectLabel("bar")),
+			Ingress: []api.IngressRule{
+				{
+					ToPorts: []api.PortRule{{
+						Ports: grpcPort,
+						Rules: &api.L7Rules{
+							Kafka: []api.PortRuleKafka{{Topic: "foo"}},
+						},
+					}},
+				},
+				{
+					ToPorts: []api.PortRule{{
+						Ports: grpcPort,
+						Rules: &api.L7Rules{
+							GRPC: []api.PortRuleGRPC{{Service: "helloworld.Greeter"}},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	state = traceState{}
+	res, err = rule2.resolveIngressPolicy(toBar, &state, L4PolicyMap{}, nil, testSelectorCache)
+	c.Assert(err, Not(IsNil))
+	c.Assert(res, IsNil)
+}
+
 func (ds *PolicyTestSuite) TestRuleWithNoEndpointSelector(c *C) {
 	apiRule1 := api.Rule{
 		Ingress: []api.IngressRule{
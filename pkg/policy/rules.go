@@ -203,6 +203,116 @@ func (rules ruleSlice) resolveL4EgressPolicy(ctx *SearchContext, revision uint64
 	return result, nil
 }
 
+func (rules ruleSlice) resolveL4IngressDenyPolicy(ctx *SearchContext, selectorCache *SelectorCache) (L4PolicyMap, error) {
+	result := L4PolicyMap{}
+
+	state := traceState{}
+	var matchedRules ruleSlice
+	var requirements []v1.LabelSelectorRequirement
+
+	// Only rules with IngressDeny sections are considered. Only the
+	// FromRequires of deny rules apply to deny rules, as the FromRequires of
+	// allow rules restrict what is allowed.
+	for _, r := range rules {
+		if len(r.IngressDeny) == 0 {
+			continue
+		}
+		if ctx.rulesSelect || r.EndpointSelector.Matches(ctx.To) {
+			matchedRules = append(matchedRules, r)
+			for _, denyRule := range r.IngressDeny {
+				for _, requirement := range denyRule.FromRequires {
+					requirements = append(requirements, requirement.ConvertToLabelSelectorRequirementSlice()...)
+				}
+			}
+		}
+	}
+
+	// Keep the policy trace quiet if there are no deny rules to consider
+	if len(matchedRules) == 0 {
+		return result, nil
+	}
+
+	ctx.PolicyTrace("\n")
+	ctx.PolicyTrace("Resolving ingress deny policy for %+v\n", ctx.To)
+
+	// Only dealing with matching rules from now on. Mark it in the ctx
+	oldRulesSelect := ctx.rulesSelect
+	ctx.rulesSelect = true
+
+	for _, r := range matchedRules {
+		found, err := r.resolveIngressDenyPolicy(ctx, &state, result, requirements, selectorCache)
+		if err != nil {
+			return nil, err
+		}
+		state.ruleID++
+		if found != nil {
+			state.matchedRules++
+		}
+	}
+
+	state.traceDeny(len(rules), ctx)
+
+	// Restore ctx in case caller uses it again.
+	ctx.rulesSelect = oldRulesSelect
+
+	return result, nil
+}
+
+func (rules ruleSlice) resolveL4EgressDenyPolicy(ctx *SearchContext, selectorCache *SelectorCache) (L4PolicyMap, error) {
+	result := L4PolicyMap{}
+
+	state := traceState{}
+	var matchedRules ruleSlice
+	var requirements []v1.LabelSelectorRequirement
+
+	// Only rules with EgressDeny sections are considered. Only the
+	// ToRequires of deny rules apply to deny rules, as the ToRequires of
+	// allow rules restrict what is allowed.
+	for _, r := range rules {
+		if len(r.EgressDeny) == 0 {
+			continue
+		}
+		if ctx.rulesSelect || r.EndpointSelector.Matches(ctx.From) {
+			matchedRules = append(matchedRules, r)
+			for _, denyRule := range r.EgressDeny {
+				for _, requirement := range denyRule.ToRequires {
+					requirements = append(requirements, requirement.ConvertToLabelSelectorRequirementSlice()...)
+				}
+			}
+		}
+	}
+
+	// Keep the policy trace quiet if there are no deny rules to consider
+	if len(matchedRules) == 0 {
+		return result, nil
+	}
+
+	ctx.PolicyTrace("\n")
+	ctx.PolicyTrace("Resolving egress deny policy for %+v\n", ctx.From)
+
+	// Only dealing with matching rules from now on. Mark it in the ctx
+	oldRulesSelect := ctx.rulesSelect
+	ctx.rulesSelect = true
+
+	for _, r := range matchedRules {
+		found, err := r.resolveEgressDenyPolicy(ctx, &state, result, requirements, selectorCache)
+		if err != nil {
+			return nil, err
+		}
+		state.ruleID++
+		if found != nil {
+			state.matchedRules++
+		}
+	}
+
+	state.traceDeny(len(rules), ctx)
+
+	// Restore ctx in case caller uses it again.
+	ctx.rulesSelect = oldRulesSelect
+
+	return result, nil
+}
+
 func (rules ruleSlice) resolveCIDRPolicy(ctx *SearchContext) *CIDRPolicy {
 	result := NewCIDRPolicy()
 
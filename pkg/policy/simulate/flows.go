@@ -0,0 +1,178 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Flow is a connection to simulate. The source is always a pod, the
+// destination is either a pod, the backends of a service or a CIDR.
+type Flow struct {
+	// Name identifies the flow in the results
+	Name string `yaml:"name"`
+
+	// Src is the source pod in the form [namespace/]name
+	Src string `yaml:"src"`
+
+	// Dst is the destination pod in the form [namespace/]name
+	Dst string `yaml:"dst,omitempty"`
+
+	// DstService is the destination service in the form
+	// [namespace/]name. The flow is simulated towards each backend pod
+	// selected by the service, with Port mapped to the target port.
+	DstService string `yaml:"dstService,omitempty"`
+
+	// DstCIDR is the destination IP or CIDR outside of the cluster
+	DstCIDR string `yaml:"dstCIDR,omitempty"`
+
+	// Port is the destination port
+	Port uint16 `yaml:"port"`
+
+	// Protocol is the L4 protocol, TCP if omitted
+	Protocol string `yaml:"protocol,omitempty"`
+
+	// HTTP is the optional HTTP request sent over the connection
+	HTTP *HTTPRequest `yaml:"http,omitempty"`
+
+	// DNS is the optional name looked up over the connection
+	DNS string `yaml:"dns,omitempty"`
+
+	// Expect is the optional expected verdict, "allowed" or "denied"
+	Expect string `yaml:"expect,omitempty"`
+}
+
+// HTTPRequest is an HTTP request of a flow
+type HTTPRequest struct {
+	Method  string            `yaml:"method,omitempty" json:"method,omitempty"`
+	Path    string            `yaml:"path,omitempty" json:"path,omitempty"`
+	Host    string            `yaml:"host,omitempty" json:"host,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// ParseFlowsFile parses the list of flows in the YAML file at path
+func ParseFlowsFile(path string) ([]*Flow, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	flows, err := ParseFlows(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return flows, nil
+}
+
+// ParseFlows parses and validates a YAML list of flows
+func ParseFlows(data []byte) ([]*Flow, error) {
+	var flows []*Flow
+	if err := yaml.UnmarshalStrict(data, &flows); err != nil {
+		return nil, err
+	}
+	for i, f := range flows {
+		if f.Name == "" {
+			f.Name = fmt.Sprintf("flow-%d", i+1)
+		}
+		if err := f.sanitize(); err != nil {
+			return nil, fmt.Errorf("flow %q: %s", f.Name, err)
+		}
+	}
+	return flows, nil
+}
+
+// sanitize validates the flow and fills in defaults
+func (f *Flow) sanitize() error {
+	if f.Src == "" {
+		return fmt.Errorf("missing source pod")
+	}
+
+	dsts := 0
+	for _, dst := range []string{f.Dst, f.DstService, f.DstCIDR} {
+		if dst != "" {
+			dsts++
+		}
+	}
+	if dsts != 1 {
+		return fmt.Errorf("exactly one of dst, dstService and dstCIDR must be specified")
+	}
+	if f.DstCIDR != "" {
+		if _, err := parseCIDR(f.DstCIDR); err != nil {
+			return err
+		}
+	}
+
+	if f.Port == 0 {
+		return fmt.Errorf("missing destination port")
+	}
+	if f.Protocol == "" {
+		f.Protocol = string(api.ProtoTCP)
+	}
+	f.Protocol = strings.ToUpper(f.Protocol)
+	switch api.L4Proto(f.Protocol) {
+	case api.ProtoTCP, api.ProtoUDP:
+	default:
+		return fmt.Errorf("invalid protocol %q", f.Protocol)
+	}
+
+	if f.HTTP != nil && f.DNS != "" {
+		return fmt.Errorf("http and dns are mutually exclusive")
+	}
+
+	switch f.Expect {
+	case "", api.Allowed.String(), api.Denied.String():
+	default:
+		return fmt.Errorf("invalid expected verdict %q", f.Expect)
+	}
+
+	return nil
+}
+
+// parseCIDR parses an IP or a CIDR
+func parseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP %q", s)
+		}
+		bits := net.IPv6len * 8
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = net.IPv4len * 8
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q", s)
+	}
+	return ipnet, nil
+}
+
+// splitName splits a [namespace/]name reference, defaulting to the
+// "default" namespace
+func splitName(ref string) (namespace, name string) {
+	if i := strings.IndexByte(ref, '/'); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return corev1.NamespaceDefault, ref
+}
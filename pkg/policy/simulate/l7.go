@@ -0,0 +1,114 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cilium/cilium/pkg/fqdn/matchpattern"
+	"github.com/cilium/cilium/pkg/policy/api"
+)
+
+// regexMatches returns true if the regular expression expr matches all of
+// s, as done by the proxy for HTTP rules. An empty expression matches
+// everything.
+func regexMatches(expr, s string) bool {
+	if expr == "" {
+		return true
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+// headersMatch returns true if all headers in the form "name" or
+// "name: value" are present in the request
+func headersMatch(headers []string, req *HTTPRequest) bool {
+	for _, hdr := range headers {
+		strs := strings.SplitN(hdr, " ", 2)
+		key := http.CanonicalHeaderKey(strings.TrimRight(strs[0], ":"))
+		found := false
+		for k, v := range req.Headers {
+			if http.CanonicalHeaderKey(k) == key && (len(strs) == 1 || v == strs[1]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// httpRuleMatches returns true if the HTTP rule allows the request
+func httpRuleMatches(rule *api.PortRuleHTTP, req *HTTPRequest) bool {
+	return regexMatches(rule.Method, req.Method) &&
+		regexMatches(rule.Path, req.Path) &&
+		regexMatches(rule.Host, req.Host) &&
+		headersMatch(rule.Headers, req)
+}
+
+// grpcRuleMatches returns true if the gRPC rule allows the request. gRPC
+// requests are always POST requests to "/<service>/<method>".
+func grpcRuleMatches(rule *api.PortRuleGRPC, req *HTTPRequest) bool {
+	if req.Method != http.MethodPost || !headersMatch(rule.Metadata, req) {
+		return false
+	}
+	if rule.Method != "" {
+		return req.Path == rule.Path()
+	}
+	return strings.HasPrefix(req.Path, rule.Path())
+}
+
+// httpAllowed returns true if any of the rules allows the request
+func httpAllowed(rules []api.L7Rules, req *HTTPRequest) bool {
+	for _, l7 := range rules {
+		for i := range l7.HTTP {
+			if httpRuleMatches(&l7.HTTP[i], req) {
+				return true
+			}
+		}
+		for i := range l7.GRPC {
+			if grpcRuleMatches(&l7.GRPC[i], req) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dnsAllowed returns true if any of the rules allows looking up name
+func dnsAllowed(rules []api.L7Rules, name string) bool {
+	name = matchpattern.Sanitize(name)
+	for _, l7 := range rules {
+		for _, dns := range l7.DNS {
+			if dns.MatchName != "" && matchpattern.Sanitize(dns.MatchName) == name {
+				return true
+			}
+			if dns.MatchPattern != "" {
+				re, err := matchpattern.Validate(matchpattern.Sanitize(dns.MatchPattern))
+				if err == nil && re.MatchString(name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
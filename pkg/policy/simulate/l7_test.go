@@ -0,0 +1,70 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package simulate
+
+import (
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *SimulateSuite) TestHTTPAllowed(c *C) {
+	rules := []api.L7Rules{{
+		HTTP: []api.PortRuleHTTP{
+			{Method: "GET", Path: "/public/.*"},
+			{Method: "PUT|POST", Path: "/upload", Headers: []string{"X-Token: secret"}},
+		},
+		GRPC: []api.PortRuleGRPC{
+			{Service: "helloworld.Greeter", Method: "SayHello"},
+			{Service: "store.Inventory"},
+		},
+	}}
+
+	for _, tc := range []struct {
+		req     HTTPRequest
+		allowed bool
+	}{
+		{HTTPRequest{Method: "GET", Path: "/public/index.html"}, true},
+		{HTTPRequest{Method: "GET", Path: "/private/public/"}, false},
+		{HTTPRequest{Method: "HEAD", Path: "/public/index.html"}, false},
+		{HTTPRequest{Method: "POST", Path: "/upload", Headers: map[string]string{"x-token": "secret"}}, true},
+		{HTTPRequest{Method: "POST", Path: "/upload", Headers: map[string]string{"x-token": "guess"}}, false},
+		{HTTPRequest{Method: "POST", Path: "/upload"}, false},
+		{HTTPRequest{Method: "POST", Path: "/helloworld.Greeter/SayHello"}, true},
+		{HTTPRequest{Method: "POST", Path: "/helloworld.Greeter/SayBye"}, false},
+		{HTTPRequest{Method: "GET", Path: "/helloworld.Greeter/SayHello"}, false},
+		{HTTPRequest{Method: "POST", Path: "/store.Inventory/List"}, true},
+	} {
+		req := tc.req
+		c.Assert(httpAllowed(rules, &req), Equals, tc.allowed, Commentf("%+v", req))
+	}
+}
+
+func (s *SimulateSuite) TestDNSAllowed(c *C) {
+	rules := []api.L7Rules{{
+		DNS: []api.PortRuleDNS{
+			{MatchName: "cilium.io"},
+			{MatchPattern: "*.example.com"},
+		},
+	}}
+
+	c.Assert(dnsAllowed(rules, "cilium.io"), Equals, true)
+	c.Assert(dnsAllowed(rules, "Cilium.IO."), Equals, true)
+	c.Assert(dnsAllowed(rules, "www.cilium.io"), Equals, false)
+	c.Assert(dnsAllowed(rules, "www.example.com"), Equals, true)
+	c.Assert(dnsAllowed(rules, "example.com"), Equals, false)
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cilium/cilium/pkg/k8s"
+	cilium_v2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	ciliumScheme "github.com/cilium/cilium/pkg/k8s/client/clientset/versioned/scheme"
+	k8sUtils "github.com/cilium/cilium/pkg/k8s/utils"
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	k8sScheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var (
+	// scheme knows about both the Kubernetes and the Cilium resources
+	scheme       = runtime.NewScheme()
+	deserializer runtime.Decoder
+)
+
+func init() {
+	utilruntime.Must(k8sScheme.AddToScheme(scheme))
+	utilruntime.Must(ciliumScheme.AddToScheme(scheme))
+	deserializer = serializer.NewCodecFactory(scheme).UniversalDeserializer()
+}
+
+// Manifests is the set of Kubernetes resources the simulation is run
+// against
+type Manifests struct {
+	// Rules are the policy rules translated from all CiliumNetworkPolicies
+	// and NetworkPolicies
+	Rules api.Rules
+
+	// Pods indexed by "namespace/name". Workloads such as Deployments are
+	// represented by a single pod named after the workload.
+	Pods map[string]*corev1.Pod
+
+	// Namespaces indexed by name
+	Namespaces map[string]*corev1.Namespace
+
+	// Services indexed by "namespace/name"
+	Services map[string]*corev1.Service
+}
+
+// NewManifests returns an empty set of manifests
+func NewManifests() *Manifests {
+	return &Manifests{
+		Pods:       map[string]*corev1.Pod{},
+		Namespaces: map[string]*corev1.Namespace{},
+		Services:   map[string]*corev1.Service{},
+	}
+}
+
+// LoadFile adds all resources of the YAML or JSON file to the manifests.
+// Multiple resources in a file must be separated by "---".
+func (m *Manifests) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Load(data); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
+
+// Load adds all resources of the YAML or JSON documents in data to the
+// manifests
+func (m *Manifests) Load(data []byte) error {
+	for _, doc := range bytes.Split(data, []byte("\n---")) {
+		// Ignore empty documents, e.g. file starting with ---
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, _, err := deserializer.Decode(doc, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err := m.add(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manifests) add(obj runtime.Object) error {
+	switch o := obj.(type) {
+	case *corev1.List:
+		for _, item := range o.Items {
+			obj, _, err := deserializer.Decode(item.Raw, nil, nil)
+			if err != nil {
+				return err
+			}
+			if err := m.add(obj); err != nil {
+				return err
+			}
+		}
+	case *cilium_v2.CiliumNetworkPolicy:
+		rules, err := o.Parse()
+		if err != nil {
+			return err
+		}
+		m.Rules = append(m.Rules, rules...)
+	case *cilium_v2.CiliumNetworkPolicyList:
+		for i := range o.Items {
+			if err := m.add(&o.Items[i]); err != nil {
+				return err
+			}
+		}
+	case *networkingv1.NetworkPolicy:
+		rules, err := k8s.ParseNetworkPolicy(o)
+		if err != nil {
+			return err
+		}
+		m.Rules = append(m.Rules, rules...)
+	case *networkingv1.NetworkPolicyList:
+		for i := range o.Items {
+			if err := m.add(&o.Items[i]); err != nil {
+				return err
+			}
+		}
+	case *corev1.Pod:
+		o.Namespace = k8sUtils.ExtractNamespace(&o.ObjectMeta)
+		m.Pods[k8sUtils.GetObjNamespaceName(&o.ObjectMeta)] = o
+	case *corev1.PodList:
+		for i := range o.Items {
+			if err := m.add(&o.Items[i]); err != nil {
+				return err
+			}
+		}
+	case *corev1.ReplicationController:
+		if o.Spec.Template != nil {
+			m.addWorkload(&o.ObjectMeta, o.Spec.Template)
+		}
+	case *appsv1.Deployment:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *appsv1.ReplicaSet:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *appsv1.StatefulSet:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *appsv1.DaemonSet:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *extensionsv1beta1.Deployment:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *extensionsv1beta1.ReplicaSet:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *extensionsv1beta1.DaemonSet:
+		m.addWorkload(&o.ObjectMeta, &o.Spec.Template)
+	case *corev1.Namespace:
+		m.Namespaces[o.Name] = o
+	case *corev1.Service:
+		o.Namespace = k8sUtils.ExtractNamespace(&o.ObjectMeta)
+		m.Services[k8sUtils.GetObjNamespaceName(&o.ObjectMeta)] = o
+	default:
+		return fmt.Errorf("unsupported resource type %T", obj)
+	}
+	return nil
+}
+
+// addWorkload adds a pod named after the workload with the pod template of
+// the workload
+func (m *Manifests) addWorkload(meta *metav1.ObjectMeta, template *corev1.PodTemplateSpec) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      meta.Name,
+			Namespace: k8sUtils.ExtractNamespace(meta),
+			Labels:    template.Labels,
+		},
+		Spec: template.Spec,
+	}
+	m.Pods[k8sUtils.GetObjNamespaceName(&pod.ObjectMeta)] = pod
+}
@@ -0,0 +1,474 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulate evaluates connections between pods described by
+// Kubernetes manifests against CiliumNetworkPolicies and NetworkPolicies,
+// without a running agent.
+package simulate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cilium/cilium/api/v1/models"
+	k8sConst "github.com/cilium/cilium/pkg/k8s/apis/cilium.io"
+	"github.com/cilium/cilium/pkg/labels"
+	"github.com/cilium/cilium/pkg/labels/cidr"
+	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/policy"
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	"github.com/op/go-logging"
+	corev1 "k8s.io/api/core/v1"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DirectionVerdict is the verdict of the policy enforced on one side of a
+// flow
+type DirectionVerdict struct {
+	// Verdict is the policy decision
+	Verdict api.Decision `json:"verdict"`
+
+	// Reason explains the verdict
+	Reason string `json:"reason"`
+
+	// Rules are the rules which allowed or denied the flow
+	Rules []string `json:"rules,omitempty"`
+}
+
+// FlowResult is the result of the simulation of a flow
+type FlowResult struct {
+	// Name is the name of the flow
+	Name string `json:"name"`
+
+	// Src is the source pod
+	Src string `json:"src"`
+
+	// Dst is the destination pod or CIDR
+	Dst string `json:"dst"`
+
+	// Port is the destination port, after service translation
+	Port uint16 `json:"port"`
+
+	// Protocol is the L4 protocol
+	Protocol string `json:"protocol"`
+
+	// HTTP is the HTTP request of the flow
+	HTTP *HTTPRequest `json:"http,omitempty"`
+
+	// DNS is the name looked up by the flow
+	DNS string `json:"dns,omitempty"`
+
+	// Verdict is the final verdict of the flow. It is only allowed if
+	// both egress and ingress allow the flow.
+	Verdict api.Decision `json:"verdict"`
+
+	// Expect is the expected verdict of the flow, if any
+	Expect string `json:"expect,omitempty"`
+
+	// Egress is the verdict of the egress policy of the source
+	Egress *DirectionVerdict `json:"egress"`
+
+	// Ingress is the verdict of the ingress policy of the destination,
+	// nil for CIDR destinations
+	Ingress *DirectionVerdict `json:"ingress,omitempty"`
+
+	// Trace is the policy trace of the flow
+	Trace string `json:"trace,omitempty"`
+}
+
+// Unexpected returns true if the flow has an expected verdict which differs
+// from the simulated one
+func (r *FlowResult) Unexpected() bool {
+	return r.Expect != "" && r.Expect != r.Verdict.String()
+}
+
+// endpoint is the source or destination of a flow
+type endpoint struct {
+	name   string
+	labels labels.LabelArray
+	pod    *corev1.Pod
+}
+
+// Simulator evaluates flows against the policy rules of a set of manifests
+type Simulator struct {
+	manifests   *Manifests
+	repo        *policy.Repository
+	clusterName string
+
+	// EnforcementMode is the policy enforcement mode of the agent
+	EnforcementMode string
+
+	// Trace enables the policy trace of the flows
+	Trace policy.Tracing
+}
+
+// NewSimulator returns a simulator for the policy rules of the manifests.
+// The pods are assumed to run in the cluster with the given name.
+func NewSimulator(manifests *Manifests, clusterName string) *Simulator {
+	api.InitEntities(clusterName)
+
+	repo := policy.NewPolicyRepository()
+	repo.AddList(manifests.Rules)
+
+	return &Simulator{
+		manifests:       manifests,
+		repo:            repo,
+		clusterName:     clusterName,
+		EnforcementMode: option.DefaultEnforcement,
+	}
+}
+
+// podLabels returns the security relevant labels of the pod, as derived by
+// the agent
+func (s *Simulator) podLabels(pod *corev1.Pod) labels.LabelArray {
+	lbls := map[string]string{}
+	for k, v := range pod.Labels {
+		lbls[k] = v
+	}
+	if ns, ok := s.manifests.Namespaces[pod.Namespace]; ok {
+		for k, v := range ns.Labels {
+			lbls[policy.JoinPath(k8sConst.PodNamespaceMetaLabels, k)] = v
+		}
+	}
+	lbls[k8sConst.PodNamespaceLabel] = pod.Namespace
+
+	if pod.Spec.ServiceAccountName != "" {
+		lbls[k8sConst.PolicyLabelServiceAccount] = pod.Spec.ServiceAccountName
+	} else {
+		delete(lbls, k8sConst.PolicyLabelServiceAccount)
+	}
+	lbls[k8sConst.PolicyLabelCluster] = s.clusterName
+
+	return labels.Map2Labels(lbls, labels.LabelSourceK8s).LabelArray()
+}
+
+func (s *Simulator) lookupPod(ref string) (*endpoint, error) {
+	namespace, name := splitName(ref)
+	pod, ok := s.manifests.Pods[namespace+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+	}
+	return &endpoint{
+		name:   namespace + "/" + name,
+		labels: s.podLabels(pod),
+		pod:    pod,
+	}, nil
+}
+
+// serviceBackends returns the pods selected by the service and the target
+// port of each pod for the given service port
+func (s *Simulator) serviceBackends(ref string, port uint16, protocol string) ([]*endpoint, []uint16, error) {
+	namespace, name := splitName(ref)
+	svc, ok := s.manifests.Services[namespace+"/"+name]
+	if !ok {
+		return nil, nil, fmt.Errorf("service %s/%s not found", namespace, name)
+	}
+
+	var svcPort *corev1.ServicePort
+	for i, p := range svc.Spec.Ports {
+		proto := string(p.Protocol)
+		if proto == "" {
+			proto = string(corev1.ProtocolTCP)
+		}
+		if uint16(p.Port) == port && proto == protocol {
+			svcPort = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		return nil, nil, fmt.Errorf("service %s/%s has no port %d/%s", namespace, name, port, protocol)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, nil, fmt.Errorf("service %s/%s has no selector", namespace, name)
+	}
+
+	// Sort the backends for stable results
+	podNames := make([]string, 0, len(s.manifests.Pods))
+	for podName := range s.manifests.Pods {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	var (
+		backends []*endpoint
+		ports    []uint16
+	)
+	selector := k8sLabels.SelectorFromSet(svc.Spec.Selector)
+	for _, podName := range podNames {
+		pod := s.manifests.Pods[podName]
+		if pod.Namespace != namespace || !selector.Matches(k8sLabels.Set(pod.Labels)) {
+			continue
+		}
+		targetPort, ok := resolveTargetPort(pod, svcPort)
+		if !ok {
+			continue
+		}
+		backends = append(backends, &endpoint{
+			name:   podName,
+			labels: s.podLabels(pod),
+			pod:    pod,
+		})
+		ports = append(ports, targetPort)
+	}
+	if len(backends) == 0 {
+		return nil, nil, fmt.Errorf("service %s/%s has no backend pods", namespace, name)
+	}
+
+	return backends, ports, nil
+}
+
+// resolveTargetPort returns the port of the pod the service port is
+// forwarded to
+func resolveTargetPort(pod *corev1.Pod, svcPort *corev1.ServicePort) (uint16, bool) {
+	switch {
+	case svcPort.TargetPort.Type == intstr.String && svcPort.TargetPort.StrVal != "":
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == svcPort.TargetPort.StrVal {
+					return uint16(p.ContainerPort), true
+				}
+			}
+		}
+		return 0, false
+	case svcPort.TargetPort.Type == intstr.Int && svcPort.TargetPort.IntVal != 0:
+		return uint16(svcPort.TargetPort.IntVal), true
+	default:
+		return uint16(svcPort.Port), true
+	}
+}
+
+// Simulate returns the results of the flow. A flow towards a service has a
+// result for each backend pod.
+func (s *Simulator) Simulate(flow *Flow) ([]*FlowResult, error) {
+	src, err := s.lookupPod(flow.Src)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dsts  []*endpoint
+		ports []uint16
+	)
+	switch {
+	case flow.Dst != "":
+		dst, err := s.lookupPod(flow.Dst)
+		if err != nil {
+			return nil, err
+		}
+		dsts, ports = []*endpoint{dst}, []uint16{flow.Port}
+	case flow.DstService != "":
+		dsts, ports, err = s.serviceBackends(flow.DstService, flow.Port, flow.Protocol)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		ipnet, err := parseCIDR(flow.DstCIDR)
+		if err != nil {
+			return nil, err
+		}
+		dst := &endpoint{
+			name:   ipnet.String(),
+			labels: cidr.GetCIDRLabels(ipnet).LabelArray(),
+		}
+		dsts, ports = []*endpoint{dst}, []uint16{flow.Port}
+	}
+
+	results := make([]*FlowResult, 0, len(dsts))
+	for i, dst := range dsts {
+		results = append(results, s.simulate(flow, src, dst, ports[i]))
+	}
+	return results, nil
+}
+
+func (s *Simulator) simulate(flow *Flow, src, dst *endpoint, port uint16) *FlowResult {
+	buffer := new(bytes.Buffer)
+	ctx := &policy.SearchContext{
+		Trace: s.Trace,
+		From:  src.labels,
+		To:    dst.labels,
+		DPorts: []*models.Port{{
+			Port:     port,
+			Protocol: flow.Protocol,
+		}},
+	}
+	if s.Trace != policy.TRACE_DISABLED {
+		ctx.Logging = logging.NewLogBackend(buffer, "", 0)
+	}
+
+	result := &FlowResult{
+		Name:     flow.Name,
+		Src:      src.name,
+		Dst:      dst.name,
+		Port:     port,
+		Protocol: flow.Protocol,
+		HTTP:     flow.HTTP,
+		DNS:      flow.DNS,
+		Expect:   flow.Expect,
+	}
+
+	s.repo.Mutex.RLock()
+	result.Egress = s.evaluate(ctx, flow, false)
+	if dst.pod != nil {
+		result.Ingress = s.evaluate(ctx, flow, true)
+	}
+	s.repo.Mutex.RUnlock()
+
+	result.Verdict = result.Egress.Verdict
+	if result.Ingress != nil && result.Ingress.Verdict != api.Allowed {
+		result.Verdict = result.Ingress.Verdict
+	}
+	result.Trace = buffer.String()
+
+	return result
+}
+
+// evaluate returns the verdict of the ingress policy of ctx.To or the egress
+// policy of ctx.From. Must be called with the repository mutex held for
+// reading.
+func (s *Simulator) evaluate(ctx *policy.SearchContext, flow *Flow, ingress bool) *DirectionVerdict {
+	subject, peer := ctx.From, ctx.To
+	resolveDeny, resolve := s.repo.ResolveL4EgressDenyPolicy, s.repo.ResolveL4EgressPolicy
+	if ingress {
+		subject, peer = ctx.To, ctx.From
+		resolveDeny, resolve = s.repo.ResolveL4IngressDenyPolicy, s.repo.ResolveL4IngressPolicy
+	}
+	ctx.PolicyTrace("Simulating %s\n", direction(ingress))
+
+	if s.EnforcementMode == option.NeverEnforce {
+		return &DirectionVerdict{Verdict: api.Allowed, Reason: "policy enforcement is disabled"}
+	}
+
+	// Deny rules apply even if the subject is not in default deny mode
+	denyPolicy, err := resolveDeny(ctx)
+	if err != nil {
+		return &DirectionVerdict{Verdict: api.Denied, Reason: fmt.Sprintf("unable to resolve deny policy: %s", err)}
+	}
+	filter := denyPolicy.MatchingFilter(peer, ctx.DPorts[0].Port, api.L4Proto(flow.Protocol))
+	denyPolicy.Detach(s.repo.GetSelectorCache())
+	if filter != nil {
+		return &DirectionVerdict{
+			Verdict: api.Denied,
+			Reason:  "denied by rule",
+			Rules:   ruleNames(filter.DerivedFromRules),
+		}
+	}
+
+	ingressMatch, egressMatch := s.repo.GetRulesMatching(subject)
+	if s.EnforcementMode != option.AlwaysEnforce && (ingress && !ingressMatch || !ingress && !egressMatch) {
+		return &DirectionVerdict{
+			Verdict: api.Allowed,
+			Reason:  fmt.Sprintf("no %s policy selects the %s", direction(ingress), peerName(!ingress)),
+		}
+	}
+
+	allowPolicy, err := resolve(ctx)
+	if err != nil {
+		return &DirectionVerdict{Verdict: api.Denied, Reason: fmt.Sprintf("unable to resolve policy: %s", err)}
+	}
+	defer allowPolicy.Detach(s.repo.GetSelectorCache())
+
+	filter = allowPolicy.MatchingFilter(peer, ctx.DPorts[0].Port, api.L4Proto(flow.Protocol))
+	if filter == nil {
+		return &DirectionVerdict{
+			Verdict: api.Denied,
+			Reason:  fmt.Sprintf("no rule allows the %s", peerName(ingress)),
+		}
+	}
+
+	verdict := &DirectionVerdict{
+		Verdict: api.Allowed,
+		Reason:  "allowed by rule",
+		Rules:   ruleNames(filter.DerivedFromRules),
+	}
+	switch filter.L7Parser {
+	case policy.ParserTypeNone:
+	case policy.ParserTypeHTTP:
+		rules := filter.GetL7RulesForLabels(peer)
+		switch {
+		case flow.HTTP == nil:
+			verdict.Reason = "allowed at L4, HTTP rules not evaluated without a request"
+		case rules != nil && !httpAllowed(rules, flow.HTTP):
+			verdict.Verdict = api.Denied
+			verdict.Reason = "HTTP request denied by rule"
+		}
+	case policy.ParserTypeDNS:
+		rules := filter.GetL7RulesForLabels(peer)
+		switch {
+		case flow.DNS == "":
+			verdict.Reason = "allowed at L4, DNS rules not evaluated without a name"
+		case rules != nil && !dnsAllowed(rules, flow.DNS):
+			verdict.Verdict = api.Denied
+			verdict.Reason = "DNS lookup denied by rule"
+		}
+	default:
+		verdict.Reason = fmt.Sprintf("allowed at L4, %s rules not evaluated", filter.L7Parser)
+	}
+	ctx.PolicyTrace("%s verdict: %s\n", strings.Title(direction(ingress)), verdict.Verdict)
+
+	return verdict
+}
+
+func direction(ingress bool) string {
+	if ingress {
+		return "ingress"
+	}
+	return "egress"
+}
+
+func peerName(source bool) string {
+	if source {
+		return "source"
+	}
+	return "destination"
+}
+
+// ruleName returns a readable name of the rule with the given labels, e.g.
+// "CiliumNetworkPolicy default/allow-frontend"
+func ruleName(lbls labels.LabelArray) string {
+	var derivedFrom, namespace, name string
+	for _, l := range lbls {
+		switch l.Key {
+		case k8sConst.PolicyLabelDerivedFrom:
+			derivedFrom = l.Value
+		case k8sConst.PolicyLabelNamespace:
+			namespace = l.Value
+		case k8sConst.PolicyLabelName:
+			name = l.Value
+		}
+	}
+	if derivedFrom == "" || name == "" {
+		return lbls.String()
+	}
+	return fmt.Sprintf("%s %s/%s", derivedFrom, namespace, name)
+}
+
+// ruleNames returns the sorted, unique names of the rules
+func ruleNames(list labels.LabelArrayList) []string {
+	seen := map[string]struct{}{}
+	names := make([]string, 0, len(list))
+	for _, lbls := range list {
+		name := ruleName(lbls)
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
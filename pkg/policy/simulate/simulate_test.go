@@ -0,0 +1,374 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package simulate
+
+import (
+	"testing"
+
+	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/policy/api"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type SimulateSuite struct{}
+
+var _ = Suite(&SimulateSuite{})
+
+const testManifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+  labels:
+    team: web
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: frontend
+  namespace: shop
+  labels:
+    app: frontend
+spec:
+  serviceAccountName: frontend
+  containers:
+  - name: frontend
+    image: frontend
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: backend-1
+  namespace: shop
+  labels:
+    app: backend
+spec:
+  containers:
+  - name: backend
+    image: backend
+    ports:
+    - name: http
+      containerPort: 8080
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: backend-2
+  namespace: shop
+  labels:
+    app: backend
+spec:
+  containers:
+  - name: backend
+    image: backend
+    ports:
+    - name: http
+      containerPort: 8080
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: db
+  namespace: shop
+  labels:
+    app: db
+spec:
+  containers:
+  - name: db
+    image: db
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-dns
+  namespace: kube-system
+  labels:
+    k8s-app: kube-dns
+spec:
+  containers:
+  - name: dns
+    image: dns
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cache
+  namespace: shop
+spec:
+  selector:
+    matchLabels:
+      app: cache
+  template:
+    metadata:
+      labels:
+        app: cache
+    spec:
+      containers:
+      - name: cache
+        image: cache
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: shop
+spec:
+  selector:
+    app: backend
+  ports:
+  - port: 80
+    targetPort: http
+---
+apiVersion: cilium.io/v2
+kind: CiliumNetworkPolicy
+metadata:
+  name: backend
+  namespace: shop
+spec:
+  endpointSelector:
+    matchLabels:
+      app: backend
+  ingress:
+  - fromEndpoints:
+    - matchLabels:
+        app: frontend
+    toPorts:
+    - ports:
+      - port: "8080"
+        protocol: TCP
+      rules:
+        http:
+        - method: GET
+          path: /api/.*
+---
+apiVersion: cilium.io/v2
+kind: CiliumNetworkPolicy
+metadata:
+  name: frontend
+  namespace: shop
+spec:
+  endpointSelector:
+    matchLabels:
+      app: frontend
+  egress:
+  - toEndpoints:
+    - matchLabels:
+        app: backend
+  - toEndpoints:
+    - matchLabels:
+        k8s:io.kubernetes.pod.namespace: kube-system
+        k8s-app: kube-dns
+    toPorts:
+    - ports:
+      - port: "53"
+        protocol: UDP
+      rules:
+        dns:
+        - matchPattern: "*.example.com"
+  - toCIDR:
+    - 192.0.2.0/24
+  egressDeny:
+  - toCIDR:
+    - 192.0.2.66/32
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: db
+  namespace: shop
+spec:
+  podSelector:
+    matchLabels:
+      app: db
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app: backend
+`
+
+const testFlows = `
+- name: api
+  src: shop/frontend
+  dstService: shop/backend
+  port: 80
+  http:
+    method: GET
+    path: /api/items
+  expect: allowed
+- name: admin
+  src: shop/frontend
+  dst: shop/backend-1
+  port: 8080
+  http:
+    method: POST
+    path: /admin
+  expect: allowed
+- name: db
+  src: shop/frontend
+  dst: shop/db
+  port: 5432
+- src: shop/frontend
+  dst: kube-system/kube-dns
+  port: 53
+  protocol: udp
+  dns: www.example.com
+- src: shop/frontend
+  dst: kube-system/kube-dns
+  port: 53
+  protocol: udp
+  dns: cilium.io
+- src: shop/frontend
+  dstCIDR: 192.0.2.10
+  port: 443
+- src: shop/frontend
+  dstCIDR: 192.0.2.66
+  port: 443
+- src: shop/db
+  dstCIDR: 198.51.100.0/24
+  port: 443
+`
+
+func (s *SimulateSuite) simulate(c *C, mode string) []*FlowResult {
+	m := NewManifests()
+	c.Assert(m.Load([]byte(testManifests)), IsNil)
+	c.Assert(len(m.Pods), Equals, 6)
+	c.Assert(len(m.Services), Equals, 1)
+	c.Assert(len(m.Rules), Equals, 3)
+
+	flows, err := ParseFlows([]byte(testFlows))
+	c.Assert(err, IsNil)
+
+	sim := NewSimulator(m, "default")
+	sim.EnforcementMode = mode
+
+	var results []*FlowResult
+	for _, flow := range flows {
+		res, err := sim.Simulate(flow)
+		c.Assert(err, IsNil, Commentf("flow %s", flow.Name))
+		results = append(results, res...)
+	}
+	return results
+}
+
+func (s *SimulateSuite) TestSimulate(c *C) {
+	results := s.simulate(c, option.DefaultEnforcement)
+	c.Assert(len(results), Equals, 9)
+
+	// Service backends with the target port resolved by name
+	for i, dst := range []string{"shop/backend-1", "shop/backend-2"} {
+		c.Assert(results[i].Dst, Equals, dst)
+		c.Assert(results[i].Port, Equals, uint16(8080))
+		c.Assert(results[i].Verdict, Equals, api.Allowed)
+		c.Assert(results[i].Egress.Rules, DeepEquals, []string{"CiliumNetworkPolicy shop/frontend"})
+		c.Assert(results[i].Ingress.Rules, DeepEquals, []string{"CiliumNetworkPolicy shop/backend"})
+		c.Assert(results[i].Unexpected(), Equals, false)
+	}
+
+	// HTTP request not allowed by the L7 rules of the backend
+	c.Assert(results[2].Egress.Verdict, Equals, api.Allowed)
+	c.Assert(results[2].Ingress.Verdict, Equals, api.Denied)
+	c.Assert(results[2].Verdict, Equals, api.Denied)
+	c.Assert(results[2].Unexpected(), Equals, true)
+
+	// Denied at egress, the NetworkPolicy of db only allows the backend
+	c.Assert(results[3].Egress.Verdict, Equals, api.Denied)
+	c.Assert(results[3].Egress.Rules, IsNil)
+	c.Assert(results[3].Ingress.Verdict, Equals, api.Denied)
+
+	// DNS
+	c.Assert(results[4].Verdict, Equals, api.Allowed)
+	c.Assert(results[4].Ingress.Reason, Equals, "no ingress policy selects the destination")
+	c.Assert(results[5].Verdict, Equals, api.Denied)
+	c.Assert(results[5].Egress.Reason, Equals, "DNS lookup denied by rule")
+
+	// CIDR destinations are only subject to egress policy
+	c.Assert(results[6].Verdict, Equals, api.Allowed)
+	c.Assert(results[6].Ingress, IsNil)
+	c.Assert(results[7].Verdict, Equals, api.Denied)
+	c.Assert(results[7].Egress.Reason, Equals, "denied by rule")
+	c.Assert(results[7].Egress.Rules, DeepEquals, []string{"CiliumNetworkPolicy shop/frontend"})
+	c.Assert(results[8].Verdict, Equals, api.Allowed)
+}
+
+func (s *SimulateSuite) TestLoadWorkload(c *C) {
+	m := NewManifests()
+	c.Assert(m.Load([]byte(testManifests)), IsNil)
+
+	pod, ok := m.Pods["shop/cache"]
+	c.Assert(ok, Equals, true)
+	c.Assert(pod.Labels, DeepEquals, map[string]string{"app": "cache"})
+	c.Assert(pod.Spec.Containers[0].Name, Equals, "cache")
+}
+
+func (s *SimulateSuite) TestSimulateEnforcementMode(c *C) {
+	results := s.simulate(c, option.AlwaysEnforce)
+	// db has no egress policy
+	c.Assert(results[8].Verdict, Equals, api.Denied)
+	c.Assert(results[4].Verdict, Equals, api.Denied)
+
+	results = s.simulate(c, option.NeverEnforce)
+	for _, r := range results {
+		c.Assert(r.Verdict, Equals, api.Allowed)
+	}
+}
+
+func (s *SimulateSuite) TestSimulateErrors(c *C) {
+	m := NewManifests()
+	c.Assert(m.Load([]byte(testManifests)), IsNil)
+	sim := NewSimulator(m, "default")
+
+	for _, flow := range []*Flow{
+		{Src: "shop/unknown", Dst: "shop/db", Port: 80, Protocol: "TCP"},
+		{Src: "shop/frontend", DstService: "shop/unknown", Port: 80, Protocol: "TCP"},
+		{Src: "shop/frontend", DstService: "shop/backend", Port: 81, Protocol: "TCP"},
+	} {
+		_, err := sim.Simulate(flow)
+		c.Assert(err, Not(IsNil))
+	}
+
+	c.Assert(m.Load([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n")), Not(IsNil))
+}
+
+func (s *SimulateSuite) TestParseFlows(c *C) {
+	flows, err := ParseFlows([]byte(testFlows))
+	c.Assert(err, IsNil)
+	c.Assert(flows[0].Protocol, Equals, "TCP")
+	c.Assert(flows[3].Name, Equals, "flow-4")
+	c.Assert(flows[3].Protocol, Equals, "UDP")
+
+	for _, invalid := range []string{
+		"- {dst: a, port: 80}",
+		"- {src: a, port: 80}",
+		"- {src: a, dst: b, dstCIDR: 10.0.0.1, port: 80}",
+		"- {src: a, dstCIDR: 10.0.0.300, port: 80}",
+		"- {src: a, dst: b}",
+		"- {src: a, dst: b, port: 80, protocol: icmp}",
+		"- {src: a, dst: b, port: 80, dns: foo, http: {method: GET}}",
+		"- {src: a, dst: b, port: 80, expect: maybe}",
+		"- {src: a, dst: b, port: 80, unknown: field}",
+	} {
+		_, err := ParseFlows([]byte(invalid))
+		c.Assert(err, Not(IsNil), Commentf("%s", invalid))
+	}
+}
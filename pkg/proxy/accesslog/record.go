@@ -297,6 +297,18 @@ type LogRecordDNS struct {
 	// https://www.iana.org/assignments/dns-parameters/dns-parameters.xhtml#dns-parameters-4
 	// Use github.com/miekg/dns.TypeToString map to retrieve string representation
 	AnswerTypes []uint16 `json:"AnswerTypes,omitempty"`
+
+	// EDNSUDPSize is the UDP payload size announced in the EDNS(0) OPT
+	// record. This field is filled only for DNS messages with an OPT record.
+	EDNSUDPSize uint16 `json:"EDNSUDPSize,omitempty"`
+
+	// EDNSClientSubnet is the EDNS client-subnet option in CIDR notation
+	// This field is filled only for DNS messages with a client-subnet option.
+	EDNSClientSubnet string `json:"EDNSClientSubnet,omitempty"`
+
+	// EDNSOptions are the EDNS(0) options of the OPT record in the form
+	// "NAME=value"
+	EDNSOptions []string `json:"EDNSOptions,omitempty"`
 }
 
 // LogRecordL7 contains the generic L7 portion of a log record
@@ -312,6 +312,10 @@ func (k *kafkaRedirect) handleRequest(pair *connectionPair, req *kafka.RequestMe
 	scopedLog := log.WithField(fieldID, pair.String())
 	flowdebug.Log(scopedLog.WithField(logfields.Request, req.String()), "Handling Kafka request")
 
+	// The principal is authenticated by the SaslAuthenticate exchange
+	// tracked by the correlation cache of the connection
+	req.SetPrincipal(correlationCache.Principal())
+
 	record := k.newLogRecordFromRequest(req)
 
 	record.ApplyTags(logger.LogTags.Addressing(logger.AddressingInfo{
@@ -0,0 +1,416 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/cilium/proxylib/proxylib"
+
+	"github.com/cilium/proxy/go/cilium/api"
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// MQTT Parser
+//
+// Spec: http://docs.oasis-open.org/mqtt/mqtt/v3.1.1/mqtt-v3.1.1.html
+//       https://docs.oasis-open.org/mqtt/mqtt/v5.0/mqtt-v5.0.html
+//
+
+// The MQTT parser supports filtering on the client identifier of the
+// CONNECT packet and on the topics of PUBLISH and SUBSCRIBE packets of
+// MQTT 3.1, 3.1.1 and 5.
+//
+// Policy Examples:
+// {client_id : "^sensor-[0-9]+$"} - Allow clients with matching identifiers to publish and subscribe to any topic
+// {client_id : "^sensor-", action : "publish", topic : "sensors/+/temperature"} - Allow matching clients to publish the temperature of any sensor
+// {action : "subscribe", topic : "alerts/#"} - Allow subscriptions to "alerts" and all its subtopics
+//
+// The CONNECT packet is allowed if any rule matches the client identifier.
+// A PUBLISH is allowed if the topic filter of a rule matches the topic
+// name. A SUBSCRIBE is allowed if, for each of the requested topic filters,
+// a rule's topic filter matches every topic the requested filter matches,
+// e.g., "alerts/#" covers the subscription "alerts/+/critical", but "alerts/+"
+// does not cover "alerts/#".
+//
+// Denied packets are answered with negative acknowledgements:
+// - CONNECT with a CONNACK "not authorized", all further data is dropped
+// - SUBSCRIBE with a SUBACK with a failure code for each topic filter
+// - PUBLISH with QoS 1 with a PUBACK, with QoS 2 with a PUBREC. MQTT 5
+//   acknowledgements carry the reason code "not authorized", MQTT 3.1.1 has
+//   no means to signal the failure so the message is silently discarded.
+// - PUBLISH with QoS 0 is dropped
+//
+// A SUBSCRIBE is denied as a whole if any of its topic filters is denied.
+
+// Rule matches against MQTT requests
+type Rule struct {
+	clientID *regexp.Regexp
+	action   string
+	topic    string
+}
+
+type mqttRequest struct {
+	// action is "connect", "publish" or "subscribe"
+	action   string
+	clientID string
+	// topic is the topic name of a PUBLISH or a topic filter of a
+	// SUBSCRIBE
+	topic string
+}
+
+const (
+	actionConnect   = "connect"
+	actionPublish   = "publish"
+	actionSubscribe = "subscribe"
+)
+
+// Matches returns true if the Rule matches
+func (rule *Rule) Matches(data interface{}) bool {
+	req, ok := data.(mqttRequest)
+	if !ok {
+		log.Warning("Matches() called with type other than mqttRequest")
+		return false
+	}
+
+	if rule.clientID != nil && !rule.clientID.MatchString(req.clientID) {
+		log.Debugf("MQTTRule: client_id mismatch %s, %s", rule.clientID.String(), req.clientID)
+		return false
+	}
+	if req.action == actionConnect {
+		return true
+	}
+	if rule.action != "" && rule.action != req.action {
+		log.Debugf("MQTTRule: action mismatch %s, %s", rule.action, req.action)
+		return false
+	}
+	if rule.topic != "" && !filterCovers(rule.topic, req.topic) {
+		log.Debugf("MQTTRule: topic mismatch %s, %s", rule.topic, req.topic)
+		return false
+	}
+	return true
+}
+
+// L7RuleParser parses protobuf L7 rules to an array of Rule
+// May panic
+func L7RuleParser(rule *cilium.PortNetworkPolicyRule) []proxylib.L7NetworkPolicyRule {
+	var rules []proxylib.L7NetworkPolicyRule
+	l7Rules := rule.GetL7Rules()
+	if l7Rules == nil {
+		return rules
+	}
+	for _, l7Rule := range l7Rules.GetL7Rules() {
+		var mr Rule
+		for k, v := range l7Rule.Rule {
+			switch k {
+			case "client_id":
+				if v != "" {
+					mr.clientID = regexp.MustCompile(v)
+				}
+			case "action":
+				mr.action = strings.ToLower(v)
+				if mr.action != "" && mr.action != actionPublish && mr.action != actionSubscribe {
+					proxylib.ParseError(fmt.Sprintf("Unable to parse L7 mqtt rule with invalid action: '%s'", v), rule)
+				}
+			case "topic":
+				if v != "" && !validTopicFilter(v) {
+					proxylib.ParseError(fmt.Sprintf("Unable to parse L7 mqtt rule with invalid topic filter: '%s'", v), rule)
+				}
+				mr.topic = v
+			default:
+				proxylib.ParseError(fmt.Sprintf("Unsupported key: %s", k), rule)
+			}
+		}
+		log.Debugf("Parsed Rule: %v", mr)
+		rules = append(rules, &mr)
+	}
+	return rules
+}
+
+// ParserFactory implements proxylib.ParserFactory
+type ParserFactory struct{}
+
+// Create creates MQTT parser
+func (f *ParserFactory) Create(connection *proxylib.Connection) proxylib.Parser {
+	log.Debugf("MQTTParserFactory: Create: %v", connection)
+	return &Parser{
+		connection:  connection,
+		aliases:     map[uint16]string{},
+		deniedQoS2:  map[uint16]struct{}{},
+		replyIntact: true,
+	}
+}
+
+// compile time check for interface implementation
+var _ proxylib.ParserFactory = &ParserFactory{}
+
+const (
+	parserName = "mqtt"
+)
+
+func init() {
+	log.Debug("init(): Registering mqttParserFactory")
+	proxylib.RegisterParserFactory(parserName, &ParserFactory{})
+	proxylib.RegisterL7RuleParser(parserName, L7RuleParser)
+}
+
+// Parser implements proxylib.Parser
+type Parser struct {
+	connection *proxylib.Connection
+
+	// connected is set once the CONNECT packet has been allowed
+	connected bool
+	// denied is set if the CONNECT packet has been denied
+	denied bool
+
+	level    byte
+	clientID string
+
+	// aliases maps the MQTT 5 topic aliases established by allowed
+	// PUBLISH packets to their topic names
+	aliases map[uint16]string
+
+	// deniedQoS2 holds the packet identifiers of denied MQTT 3.1 and
+	// 3.1.1 PUBLISH packets with QoS 2, the PUBREL of which is answered
+	// with a PUBCOMP
+	deniedQoS2 map[uint16]struct{}
+
+	// replyIntact is false while the server is sending a packet which
+	// has been passed but not yet been seen in full. Acknowledgements are
+	// held back in pendingReplies in the meantime as injecting them would
+	// corrupt the packet.
+	replyIntact    bool
+	pendingReplies [][]byte
+}
+
+var _ proxylib.Parser = &Parser{}
+
+func (p *Parser) logEntry(action, topic string, qos byte) *cilium.LogEntry_GenericL7 {
+	fields := map[string]string{
+		"type":      action,
+		"client_id": p.clientID,
+	}
+	if action != actionConnect {
+		fields["topic"] = topic
+		fields["qos"] = strconv.Itoa(int(qos))
+	}
+	return &cilium.LogEntry_GenericL7{
+		GenericL7: &cilium.L7LogEntry{
+			Proto:  parserName,
+			Fields: fields,
+		},
+	}
+}
+
+// injectReply sends msg to the client
+func (p *Parser) injectReply(msg []byte) {
+	if p.replyIntact {
+		p.connection.Inject(true, msg)
+	} else {
+		p.pendingReplies = append(p.pendingReplies, msg)
+	}
+}
+
+// OnData parses MQTT data
+func (p *Parser) OnData(reply, endStream bool, dataBuffers [][]byte) (proxylib.OpType, int) {
+	if reply {
+		return p.onReply(dataBuffers)
+	}
+
+	// TODO: don't copy data to new slices
+	data := bytes.Join(dataBuffers, []byte{})
+
+	if p.denied {
+		if len(data) == 0 {
+			return proxylib.MORE, 1
+		}
+		return proxylib.DROP, len(data)
+	}
+
+	packetType, flags, hdrLen, remLen, more, err := parseHeader(data)
+	if err != nil {
+		log.WithError(err).Error("Could not parse MQTT fixed header")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_LENGTH)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+	length := hdrLen + remLen
+
+	if !p.connected && packetType != packetConnect {
+		log.Errorf("Unexpected MQTT packet type %d before CONNECT", packetType)
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+	}
+
+	switch packetType {
+	case packetConnect:
+		if len(data) < length {
+			return proxylib.MORE, length - len(data)
+		}
+		if p.connected {
+			log.Error("Unexpected second MQTT CONNECT packet")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+		connect, err := parseConnect(data[hdrLen:length])
+		if err != nil {
+			log.WithError(err).Error("Could not parse MQTT CONNECT packet")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+		p.level = connect.level
+		p.clientID = connect.clientID
+
+		if p.connection.Matches(mqttRequest{action: actionConnect, clientID: p.clientID}) {
+			p.connected = true
+			p.connection.Log(cilium.EntryType_Request, p.logEntry(actionConnect, "", 0))
+			return proxylib.PASS, length
+		}
+
+		p.denied = true
+		p.injectReply(connackDenied(p.level))
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry(actionConnect, "", 0))
+		return proxylib.DROP, length
+
+	case packetPublish:
+		// Only the variable header is needed, the payload may be
+		// passed before it has been received
+		body := data[hdrLen:]
+		if len(body) > remLen {
+			body = body[:remLen]
+		}
+		pub, err := parsePublish(flags, body, p.level)
+		if err == errTruncated && len(body) < remLen {
+			return proxylib.MORE, 1
+		}
+		if err != nil {
+			log.WithError(err).Error("Could not parse MQTT PUBLISH packet")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+
+		topic := pub.topic
+		if topic == "" && pub.alias != 0 {
+			topic = p.aliases[pub.alias]
+		}
+
+		req := mqttRequest{action: actionPublish, clientID: p.clientID, topic: topic}
+		if topic != "" && p.connection.Matches(req) {
+			if pub.alias != 0 && pub.topic != "" {
+				p.aliases[pub.alias] = pub.topic
+			}
+			p.connection.Log(cilium.EntryType_Request, p.logEntry(actionPublish, topic, pub.qos))
+			return proxylib.PASS, length
+		}
+
+		switch pub.qos {
+		case 1:
+			p.injectReply(ackDenied(packetPuback, pub.packetID, p.level))
+		case 2:
+			p.injectReply(ackDenied(packetPubrec, pub.packetID, p.level))
+			// The QoS 2 flow ends with the negative PUBREC in MQTT 5
+			if p.level != version5 {
+				p.deniedQoS2[pub.packetID] = struct{}{}
+			}
+		}
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry(actionPublish, topic, pub.qos))
+		return proxylib.DROP, length
+
+	case packetPubrel:
+		if len(data) < length {
+			return proxylib.MORE, length - len(data)
+		}
+		packetID, err := parsePacketID(data[hdrLen:length])
+		if err != nil {
+			log.WithError(err).Error("Could not parse MQTT PUBREL packet")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+		if _, ok := p.deniedQoS2[packetID]; ok {
+			delete(p.deniedQoS2, packetID)
+			p.injectReply(pubcomp(packetID))
+			return proxylib.DROP, length
+		}
+		return proxylib.PASS, length
+
+	case packetSubscribe:
+		if len(data) < length {
+			return proxylib.MORE, length - len(data)
+		}
+		sub, err := parseSubscribe(data[hdrLen:length], p.level)
+		if err != nil {
+			log.WithError(err).Error("Could not parse MQTT SUBSCRIBE packet")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+
+		allowed := true
+		for _, filter := range sub.filters {
+			req := mqttRequest{action: actionSubscribe, clientID: p.clientID, topic: subscriptionFilter(filter)}
+			if !p.connection.Matches(req) {
+				allowed = false
+				break
+			}
+		}
+		topics := strings.Join(sub.filters, ",")
+		if allowed {
+			p.connection.Log(cilium.EntryType_Request, p.logEntry(actionSubscribe, topics, 0))
+			return proxylib.PASS, length
+		}
+
+		p.injectReply(subackDenied(sub.packetID, len(sub.filters), p.level))
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry(actionSubscribe, topics, 0))
+		return proxylib.DROP, length
+	}
+
+	return proxylib.PASS, length
+}
+
+func (p *Parser) onReply(dataBuffers [][]byte) (proxylib.OpType, int) {
+	// TODO: don't copy data to new slices
+	data := bytes.Join(dataBuffers, []byte{})
+
+	// New data always starts at a packet boundary
+	if len(data) > 0 {
+		p.replyIntact = true
+	}
+	if p.replyIntact && len(p.pendingReplies) > 0 {
+		injected := 0
+		for _, msg := range p.pendingReplies {
+			injected += p.connection.Inject(true, msg)
+		}
+		p.pendingReplies = nil
+		return proxylib.INJECT, injected
+	}
+
+	if len(data) == 0 {
+		return proxylib.NOP, 0
+	}
+
+	_, _, hdrLen, remLen, more, err := parseHeader(data)
+	if err != nil {
+		log.WithError(err).Error("Could not parse MQTT fixed header")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_LENGTH)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+
+	length := hdrLen + remLen
+	if length > len(data) {
+		p.replyIntact = false
+	}
+	return proxylib.PASS, length
+}
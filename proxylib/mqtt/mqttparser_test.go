@@ -0,0 +1,342 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package mqtt
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/cilium/cilium/proxylib/accesslog"
+	"github.com/cilium/cilium/proxylib/proxylib"
+	"github.com/cilium/cilium/proxylib/test"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type MQTTSuite struct {
+	logServer *test.AccessLogServer
+	ins       *proxylib.Instance
+}
+
+var _ = Suite(&MQTTSuite{})
+
+// Set up access log server and Library instance for all the test cases
+func (s *MQTTSuite) SetUpSuite(c *C) {
+	s.logServer = test.StartAccessLogServer("access_log.sock", 10)
+	c.Assert(s.logServer, Not(IsNil))
+	s.ins = proxylib.NewInstance("node1", accesslog.NewClient(s.logServer.Path))
+	c.Assert(s.ins, Not(IsNil))
+}
+
+func (s *MQTTSuite) checkAccessLogs(c *C, expPasses, expDrops int) {
+	passes, drops := s.logServer.Clear()
+	c.Check(passes, Equals, expPasses, Commentf("Unxpected number of passed access log messages"))
+	c.Check(drops, Equals, expDrops, Commentf("Unxpected number of denied access log messages"))
+}
+
+func (s *MQTTSuite) TearDownTest(c *C) {
+	s.logServer.Clear()
+}
+
+func (s *MQTTSuite) TearDownSuite(c *C) {
+	s.logServer.Close()
+}
+
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+func connectPkt(level byte, clientID string) []byte {
+	body := appendString(nil, "MQTT")
+	// Clean session and keep alive of 60 seconds
+	body = append(body, level, 0x02, 0, 60)
+	if level == version5 {
+		body = append(body, 0)
+	}
+	body = appendString(body, clientID)
+	return newPacket(packetConnect, 0, body)
+}
+
+func publishPkt(level, qos byte, topic string, packetID uint16, alias uint16, payload string) []byte {
+	body := appendString(nil, topic)
+	if qos > 0 {
+		body = append(body, byte(packetID>>8), byte(packetID))
+	}
+	if level == version5 {
+		if alias != 0 {
+			body = append(body, 3, propertyTopicAlias, byte(alias>>8), byte(alias))
+		} else {
+			body = append(body, 0)
+		}
+	}
+	body = append(body, payload...)
+	return newPacket(packetPublish, qos<<1, body)
+}
+
+func subscribePkt(level byte, packetID uint16, filters ...string) []byte {
+	body := []byte{byte(packetID >> 8), byte(packetID)}
+	if level == version5 {
+		body = append(body, 0)
+	}
+	for _, filter := range filters {
+		body = appendString(body, filter)
+		body = append(body, 1)
+	}
+	return newPacket(packetSubscribe, 0x02, body)
+}
+
+func pubrelPkt(packetID uint16) []byte {
+	body := make([]byte, 2)
+	binary.BigEndian.PutUint16(body, packetID)
+	return newPacket(packetPubrel, 0x02, body)
+}
+
+func concat(pkts ...[]byte) []byte {
+	var result []byte
+	for _, pkt := range pkts {
+		result = append(result, pkt...)
+	}
+	return result
+}
+
+const policySensors = `
+		name: "mqtt"
+		policy: 2
+		ingress_per_port_policies: <
+		  port: 80
+		  rules: <
+		    remote_policies: 1
+		    l7_proto: "mqtt"
+		    l7_rules: <
+		      l7_rules: <
+			rule: <
+			  key: "client_id"
+			  value: "^sensor-[0-9]+$"
+			>
+			rule: <
+			  key: "action"
+			  value: "publish"
+			>
+			rule: <
+			  key: "topic"
+			  value: "sensors/+/temperature"
+			>
+		      >
+		      l7_rules: <
+			rule: <
+			  key: "client_id"
+			  value: "^sensor-[0-9]+$"
+			>
+			rule: <
+			  key: "action"
+			  value: "subscribe"
+			>
+			rule: <
+			  key: "topic"
+			  value: "config/#"
+			>
+		      >
+		    >
+		  >
+		>
+		`
+
+func (s *MQTTSuite) TestMQTTPolicyParse(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policySensors})
+
+	for _, rule := range []string{
+		`key: "topic" value: "sensors/#/temperature"`,
+		`key: "action" value: "unsubscribe"`,
+		`key: "qos" value: "1"`,
+	} {
+		err := s.ins.InsertPolicyText("2", []string{`
+		name: "mqtt-invalid"
+		policy: 2
+		ingress_per_port_policies: <
+		  port: 80
+		  rules: <
+		    l7_proto: "mqtt"
+		    l7_rules: <
+		      l7_rules: <
+			rule: < ` + rule + ` >
+		      >
+		    >
+		  >
+		>
+		`}, "update")
+		c.Assert(err, Not(IsNil), Commentf("rule: %s", rule))
+	}
+}
+
+func (s *MQTTSuite) TestMQTTConnectDenied(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policySensors})
+
+	for _, level := range []byte{version311, version5} {
+		conn := s.ins.CheckNewConnectionOK(c, "mqtt", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "mqtt")
+		connect := connectPkt(level, "laptop")
+		publish := publishPkt(level, 0, "sensors/1/temperature", 0, 0, "20")
+		data := [][]byte{concat(connect, publish)}
+		conn.CheckOnDataOK(c, false, false, &data, connackDenied(level),
+			proxylib.DROP, len(connect),
+			proxylib.DROP, len(publish),
+			proxylib.MORE, 1)
+		s.checkAccessLogs(c, 0, 1)
+	}
+	c.Assert(connackDenied(version311), DeepEquals, []byte{0x20, 0x02, 0x00, 0x05})
+	c.Assert(connackDenied(version5), DeepEquals, []byte{0x20, 0x03, 0x00, 0x87, 0x00})
+
+	// Packets other than CONNECT are not allowed before CONNECT
+	conn := s.ins.CheckNewConnectionOK(c, "mqtt", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "mqtt")
+	data := [][]byte{publishPkt(version311, 0, "sensors/1/temperature", 0, 0, "20")}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE))
+}
+
+func (s *MQTTSuite) TestMQTTPublish(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policySensors})
+	conn := s.ins.CheckNewConnectionOK(c, "mqtt", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "mqtt")
+
+	// CONNECT split across data buffers
+	connect := connectPkt(version311, "sensor-1")
+	data := [][]byte{connect[:1]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.MORE, 1)
+	data = [][]byte{connect[:1], connect[1:5]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.MORE, len(connect)-5)
+	data = [][]byte{connect[:1], connect[1:5], connect[5:]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(connect),
+		proxylib.MORE, 2)
+	s.checkAccessLogs(c, 1, 0)
+
+	allowed := publishPkt(version311, 1, "sensors/1/temperature", 1, 0, "20")
+	qos0 := publishPkt(version311, 0, "sensors/1/humidity", 0, 0, "50")
+	qos1 := publishPkt(version311, 1, "sensors/1/humidity", 2, 0, "50")
+	qos2 := publishPkt(version311, 2, "sensors/1/humidity", 3, 0, "50")
+	data = [][]byte{concat(allowed, qos0, qos1, qos2, pubrelPkt(3), pubrelPkt(1))}
+	conn.CheckOnDataOK(c, false, false, &data,
+		concat([]byte{0x40, 0x02, 0x00, 0x02}, []byte{0x50, 0x02, 0x00, 0x03}, []byte{0x70, 0x02, 0x00, 0x03}),
+		proxylib.PASS, len(allowed),
+		proxylib.DROP, len(qos0),
+		proxylib.DROP, len(qos1),
+		proxylib.DROP, len(qos2),
+		proxylib.DROP, 4,
+		proxylib.PASS, 4,
+		proxylib.MORE, 2)
+	s.checkAccessLogs(c, 1, 3)
+
+	// The payload of a PUBLISH is passed before it has been received
+	large := publishPkt(version311, 0, "sensors/1/temperature", 0, 0, string(make([]byte, 1000)))
+	data = [][]byte{large[:100]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(large),
+		proxylib.MORE, 2)
+
+	// Truncated variable header
+	data = [][]byte{large[:5]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.MORE, 1)
+}
+
+func (s *MQTTSuite) TestMQTTPublishTopicAlias(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policySensors})
+	conn := s.ins.CheckNewConnectionOK(c, "mqtt", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "mqtt")
+
+	connect := connectPkt(version5, "sensor-1")
+	data := [][]byte{connect}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(connect),
+		proxylib.MORE, 2)
+
+	// The alias of an allowed topic may be used, the alias of a denied
+	// topic is unknown
+	setAllowed := publishPkt(version5, 1, "sensors/1/temperature", 1, 1, "20")
+	useAllowed := publishPkt(version5, 1, "", 2, 1, "21")
+	setDenied := publishPkt(version5, 1, "sensors/1/humidity", 3, 2, "50")
+	useDenied := publishPkt(version5, 2, "", 4, 2, "51")
+	data = [][]byte{concat(setAllowed, useAllowed, setDenied, useDenied)}
+	conn.CheckOnDataOK(c, false, false, &data,
+		concat([]byte{0x40, 0x03, 0x00, 0x03, 0x87}, []byte{0x50, 0x03, 0x00, 0x04, 0x87}),
+		proxylib.PASS, len(setAllowed),
+		proxylib.PASS, len(useAllowed),
+		proxylib.DROP, len(setDenied),
+		proxylib.DROP, len(useDenied),
+		proxylib.MORE, 2)
+	s.checkAccessLogs(c, 3, 2)
+}
+
+func (s *MQTTSuite) TestMQTTSubscribe(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policySensors})
+
+	for _, level := range []byte{version311, version5} {
+		conn := s.ins.CheckNewConnectionOK(c, "mqtt", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "mqtt")
+		connect := connectPkt(level, "sensor-2")
+		allowed := subscribePkt(level, 1, "config/sensor-2/#", "$share/sensors/config/+")
+		denied := subscribePkt(level, 2, "config/+", "sensors/#")
+		data := [][]byte{concat(connect, allowed, denied)}
+
+		code := byte(subackFailure)
+		suback := []byte{0x90, 0x04, 0x00, 0x02}
+		if level == version5 {
+			code = reasonNotAuthorized
+			suback = []byte{0x90, 0x05, 0x00, 0x02, 0x00}
+		}
+		conn.CheckOnDataOK(c, false, false, &data, append(suback, code, code),
+			proxylib.PASS, len(connect),
+			proxylib.PASS, len(allowed),
+			proxylib.DROP, len(denied),
+			proxylib.MORE, 2)
+		s.checkAccessLogs(c, 2, 1)
+	}
+}
+
+func (s *MQTTSuite) TestMQTTReplyIntact(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policySensors})
+	conn := s.ins.CheckNewConnectionOK(c, "mqtt", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "mqtt")
+
+	connect := connectPkt(version311, "sensor-1")
+	data := [][]byte{connect}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(connect),
+		proxylib.MORE, 2)
+
+	// The server starts sending a large PUBLISH
+	large := publishPkt(version311, 0, "config/sensor-1", 0, 0, string(make([]byte, 1000)))
+	data = [][]byte{large[:100]}
+	conn.CheckOnDataOK(c, true, false, &data, []byte{},
+		proxylib.PASS, len(large))
+
+	// The PUBACK is held back until the next packet of the server
+	denied := publishPkt(version311, 1, "sensors/1/humidity", 7, 0, "50")
+	data = [][]byte{denied}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.DROP, len(denied),
+		proxylib.MORE, 2)
+
+	pingresp := newPacket(packetPingresp, 0, nil)
+	data = [][]byte{pingresp}
+	conn.CheckOnDataOK(c, true, false, &data, []byte{0x40, 0x02, 0x00, 0x07},
+		proxylib.INJECT, 4,
+		proxylib.PASS, len(pingresp))
+}
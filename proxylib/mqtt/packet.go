@@ -0,0 +1,422 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Control packet types
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetPubrec      = 5
+	packetPubrel      = 6
+	packetPubcomp     = 7
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+	packetAuth        = 15
+)
+
+// Protocol levels of the CONNECT packet
+const (
+	version31  = 3
+	version311 = 4
+	version5   = 5
+)
+
+const (
+	// maxRemainingLength is the largest value the variable length
+	// encoding of the remaining length can represent
+	maxRemainingLength = 268435455
+
+	// propertyTopicAlias is the identifier of the MQTT 5 Topic Alias
+	// property of PUBLISH packets
+	propertyTopicAlias = 0x23
+)
+
+// Return and reason codes used in negative acknowledgements
+const (
+	// connackNotAuthorized is the CONNACK return code of MQTT 3.1 and
+	// 3.1.1 for a client which is not authorized to connect
+	connackNotAuthorized = 0x05
+	// subackFailure is the SUBACK return code of MQTT 3.1.1 for a
+	// rejected subscription
+	subackFailure = 0x80
+	// reasonNotAuthorized is the MQTT 5 reason code used in CONNACK,
+	// PUBACK, PUBREC and SUBACK
+	reasonNotAuthorized = 0x87
+)
+
+var (
+	errTruncated        = errors.New("truncated packet")
+	errInvalidLength    = errors.New("invalid remaining length")
+	errInvalidProtocol  = errors.New("invalid protocol name")
+	errInvalidQoS       = errors.New("invalid QoS")
+	errInvalidProperty  = errors.New("invalid property")
+	errMissingTopic     = errors.New("missing topic filter")
+	errUnsupportedLevel = errors.New("unsupported protocol level")
+)
+
+// parseHeader parses the fixed header at the start of data. It returns the
+// packet type, the flags, the length of the fixed header and the remaining
+// length of the packet. If the fixed header is not complete, the minimum
+// number of additional bytes needed is returned in more.
+func parseHeader(data []byte) (packetType, flags byte, hdrLen, remLen int, more int, err error) {
+	if len(data) < 2 {
+		return 0, 0, 0, 0, 2 - len(data), nil
+	}
+
+	multiplier := 1
+	for i := 1; ; i++ {
+		if i > 4 {
+			return 0, 0, 0, 0, 0, errInvalidLength
+		}
+		if i >= len(data) {
+			return 0, 0, 0, 0, 1, nil
+		}
+		remLen += int(data[i]&0x7f) * multiplier
+		if data[i]&0x80 == 0 {
+			hdrLen = i + 1
+			break
+		}
+		multiplier *= 128
+	}
+
+	return data[0] >> 4, data[0] & 0x0f, hdrLen, remLen, 0, nil
+}
+
+// encodeRemainingLength appends the variable length encoding of n to b
+func encodeRemainingLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// reader decodes the fields of the variable header and payload of a packet
+type reader struct {
+	data []byte
+}
+
+func (r *reader) byte() (byte, error) {
+	if len(r.data) < 1 {
+		return 0, errTruncated
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b, nil
+}
+
+func (r *reader) uint16() (uint16, error) {
+	if len(r.data) < 2 {
+		return 0, errTruncated
+	}
+	v := binary.BigEndian.Uint16(r.data)
+	r.data = r.data[2:]
+	return v, nil
+}
+
+func (r *reader) skip(n int) error {
+	if len(r.data) < n {
+		return errTruncated
+	}
+	r.data = r.data[n:]
+	return nil
+}
+
+func (r *reader) varInt() (int, error) {
+	value := 0
+	multiplier := 1
+	for i := 0; i < 4; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		value += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			return value, nil
+		}
+		multiplier *= 128
+	}
+	return 0, errInvalidLength
+}
+
+// bytes returns the length prefixed binary data or UTF-8 string
+func (r *reader) bytes() ([]byte, error) {
+	n, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.data) < int(n) {
+		return nil, errTruncated
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
+
+func (r *reader) string() (string, error) {
+	b, err := r.bytes()
+	return string(b), err
+}
+
+// properties returns the MQTT 5 properties
+func (r *reader) properties() ([]byte, error) {
+	n, err := r.varInt()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.data) < n {
+		return nil, errTruncated
+	}
+	props := r.data[:n]
+	r.data = r.data[n:]
+	return props, nil
+}
+
+// findProperty returns the value of the property with identifier id in
+// the MQTT 5 properties props
+func findProperty(props []byte, id byte) ([]byte, bool, error) {
+	r := &reader{data: props}
+	for len(r.data) > 0 {
+		propID, _ := r.byte()
+		value := r.data
+		var err error
+		switch propID {
+		case 0x01, 0x17, 0x19, 0x24, 0x25, 0x28, 0x29, 0x2a:
+			err = r.skip(1)
+		case 0x13, 0x21, 0x22, 0x23:
+			err = r.skip(2)
+		case 0x02, 0x11, 0x18, 0x27:
+			err = r.skip(4)
+		case 0x0b:
+			_, err = r.varInt()
+		case 0x03, 0x08, 0x09, 0x12, 0x15, 0x16, 0x1a, 0x1c, 0x1f:
+			_, err = r.bytes()
+		case 0x26:
+			if _, err = r.bytes(); err == nil {
+				_, err = r.bytes()
+			}
+		default:
+			return nil, false, errInvalidProperty
+		}
+		if err != nil {
+			return nil, false, errInvalidProperty
+		}
+		if propID == id {
+			return value[:len(value)-len(r.data)], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// connectPacket is the decoded CONNECT packet
+type connectPacket struct {
+	level    byte
+	clientID string
+}
+
+// parseConnect parses the variable header and the client identifier of the
+// CONNECT packet body
+func parseConnect(body []byte) (*connectPacket, error) {
+	r := &reader{data: body}
+	name, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+	level, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case level == version31 && name == "MQIsdp":
+	case (level == version311 || level == version5) && name == "MQTT":
+	case name != "MQTT" && name != "MQIsdp":
+		return nil, errInvalidProtocol
+	default:
+		return nil, errUnsupportedLevel
+	}
+
+	// Connect flags and keep alive
+	if err := r.skip(3); err != nil {
+		return nil, err
+	}
+	if level == version5 {
+		if _, err := r.properties(); err != nil {
+			return nil, err
+		}
+	}
+
+	clientID, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+	return &connectPacket{level: level, clientID: clientID}, nil
+}
+
+// publishPacket is the decoded variable header of a PUBLISH packet
+type publishPacket struct {
+	qos      byte
+	topic    string
+	packetID uint16
+	// alias is the MQTT 5 topic alias, 0 if not present
+	alias uint16
+}
+
+// parsePublish parses the variable header of a PUBLISH packet. body may
+// be truncated after the variable header.
+func parsePublish(flags byte, body []byte, level byte) (*publishPacket, error) {
+	pub := &publishPacket{qos: (flags >> 1) & 0x03}
+	if pub.qos > 2 {
+		return nil, errInvalidQoS
+	}
+
+	r := &reader{data: body}
+	var err error
+	if pub.topic, err = r.string(); err != nil {
+		return nil, err
+	}
+	if pub.qos > 0 {
+		if pub.packetID, err = r.uint16(); err != nil {
+			return nil, err
+		}
+	}
+	if level == version5 {
+		props, err := r.properties()
+		if err != nil {
+			return nil, err
+		}
+		value, ok, err := findProperty(props, propertyTopicAlias)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			pub.alias = binary.BigEndian.Uint16(value)
+		}
+	}
+	return pub, nil
+}
+
+// subscribePacket is the decoded SUBSCRIBE packet
+type subscribePacket struct {
+	packetID uint16
+	filters  []string
+}
+
+// parseSubscribe parses the SUBSCRIBE packet body
+func parseSubscribe(body []byte, level byte) (*subscribePacket, error) {
+	r := &reader{data: body}
+	sub := &subscribePacket{}
+	var err error
+	if sub.packetID, err = r.uint16(); err != nil {
+		return nil, err
+	}
+	if level == version5 {
+		if _, err := r.properties(); err != nil {
+			return nil, err
+		}
+	}
+	for len(r.data) > 0 {
+		filter, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		// Requested QoS or subscription options
+		if err := r.skip(1); err != nil {
+			return nil, err
+		}
+		sub.filters = append(sub.filters, filter)
+	}
+	if len(sub.filters) == 0 {
+		return nil, errMissingTopic
+	}
+	return sub, nil
+}
+
+// parsePacketID parses the packet identifier at the start of body
+func parsePacketID(body []byte) (uint16, error) {
+	r := &reader{data: body}
+	return r.uint16()
+}
+
+// newPacket returns a control packet with the given variable header and
+// payload
+func newPacket(packetType, flags byte, body []byte) []byte {
+	packet := []byte{packetType<<4 | flags}
+	packet = encodeRemainingLength(packet, len(body))
+	return append(packet, body...)
+}
+
+// connackDenied returns the CONNACK rejecting a client for the protocol
+// level
+func connackDenied(level byte) []byte {
+	if level == version5 {
+		// Session present, reason code and empty properties
+		return newPacket(packetConnack, 0, []byte{0, reasonNotAuthorized, 0})
+	}
+	return newPacket(packetConnack, 0, []byte{0, connackNotAuthorized})
+}
+
+// ackDenied returns the PUBACK or PUBREC rejecting a PUBLISH. MQTT 3.1.1
+// has no means to signal an error in these packets, the message is
+// acknowledged without being delivered.
+func ackDenied(packetType byte, packetID uint16, level byte) []byte {
+	body := make([]byte, 2, 3)
+	binary.BigEndian.PutUint16(body, packetID)
+	if level == version5 {
+		body = append(body, reasonNotAuthorized)
+	}
+	return newPacket(packetType, 0, body)
+}
+
+// pubcomp returns the PUBCOMP completing a QoS 2 flow
+func pubcomp(packetID uint16) []byte {
+	body := make([]byte, 2)
+	binary.BigEndian.PutUint16(body, packetID)
+	return newPacket(packetPubcomp, 0, body)
+}
+
+// subackDenied returns the SUBACK rejecting all n topic filters of a
+// SUBSCRIBE
+func subackDenied(packetID uint16, n int, level byte) []byte {
+	body := make([]byte, 2, 3+n)
+	binary.BigEndian.PutUint16(body, packetID)
+	code := byte(subackFailure)
+	if level == version5 {
+		// Empty properties
+		body = append(body, 0)
+		code = reasonNotAuthorized
+	}
+	for i := 0; i < n; i++ {
+		body = append(body, code)
+	}
+	return newPacket(packetSuback, 0, body)
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"strings"
+)
+
+const (
+	topicSeparator   = "/"
+	singleLevelMatch = "+"
+	multiLevelMatch  = "#"
+
+	// sharedPrefix is the prefix of MQTT 5 shared subscriptions,
+	// "$share/<group>/<filter>"
+	sharedPrefix = "$share/"
+)
+
+// validTopicFilter returns true if filter is a syntactically valid topic
+// filter. The multi-level wildcard must be the last level and both wildcards
+// must occupy an entire level.
+func validTopicFilter(filter string) bool {
+	if filter == "" {
+		return false
+	}
+	levels := strings.Split(filter, topicSeparator)
+	for i, level := range levels {
+		if strings.Contains(level, multiLevelMatch) && (level != multiLevelMatch || i != len(levels)-1) {
+			return false
+		}
+		if strings.Contains(level, singleLevelMatch) && level != singleLevelMatch {
+			return false
+		}
+	}
+	return true
+}
+
+// filterCovers returns true if every topic matched by sub is also matched
+// by filter. sub may be a topic name or a topic filter requested in a
+// SUBSCRIBE. Topics starting with '$' are not matched by wildcards in the
+// first level.
+func filterCovers(filter, sub string) bool {
+	filterLevels := strings.Split(filter, topicSeparator)
+	subLevels := strings.Split(sub, topicSeparator)
+
+	if strings.HasPrefix(sub, "$") && (filterLevels[0] == singleLevelMatch || filterLevels[0] == multiLevelMatch) {
+		return false
+	}
+
+	for i, level := range filterLevels {
+		if level == multiLevelMatch {
+			// Also matches the parent level
+			return true
+		}
+		if i >= len(subLevels) {
+			return false
+		}
+		switch subLevels[i] {
+		case multiLevelMatch:
+			return false
+		case level:
+			continue
+		}
+		if level != singleLevelMatch {
+			return false
+		}
+	}
+	return len(filterLevels) == len(subLevels)
+}
+
+// subscriptionFilter returns the topic filter of a subscription, stripping
+// the share name of MQTT 5 shared subscriptions
+func subscriptionFilter(filter string) string {
+	if !strings.HasPrefix(filter, sharedPrefix) {
+		return filter
+	}
+	rest := filter[len(sharedPrefix):]
+	if i := strings.Index(rest, topicSeparator); i >= 0 {
+		return rest[i+1:]
+	}
+	return filter
+}
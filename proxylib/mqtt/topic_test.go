@@ -0,0 +1,72 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package mqtt
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *MQTTSuite) TestValidTopicFilter(c *C) {
+	for _, filter := range []string{"a", "a/b", "+", "#", "a/+/c", "a/#", "+/+", "/", "$SYS/#"} {
+		c.Check(validTopicFilter(filter), Equals, true, Commentf("filter: %q", filter))
+	}
+	for _, filter := range []string{"", "a#", "a/#/c", "a+", "a/b+/c", "##"} {
+		c.Check(validTopicFilter(filter), Equals, false, Commentf("filter: %q", filter))
+	}
+}
+
+func (s *MQTTSuite) TestFilterCovers(c *C) {
+	for _, tc := range []struct {
+		filter   string
+		sub      string
+		expected bool
+	}{
+		// Topic names
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/b", "a/b/c", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a", false},
+		{"a/+", "a/b/c", false},
+		{"a/+/c", "a/b/c", true},
+		{"+/+", "/a", true},
+		{"a/#", "a", true},
+		{"a/#", "a/b/c", true},
+		{"a/#", "b/c", false},
+		{"#", "a/b", true},
+		{"#", "$SYS/uptime", false},
+		{"+/uptime", "$SYS/uptime", false},
+		{"$SYS/#", "$SYS/uptime", true},
+
+		// Topic filters
+		{"a/+", "a/+", true},
+		{"a/+", "a/#", false},
+		{"a/b", "a/+", false},
+		{"a/#", "a/+/c", true},
+		{"a/#", "a/#", true},
+		{"#", "#", true},
+		{"a/+/c", "a/+/+", false},
+	} {
+		c.Check(filterCovers(tc.filter, tc.sub), Equals, tc.expected, Commentf("filter: %q, sub: %q", tc.filter, tc.sub))
+	}
+}
+
+func (s *MQTTSuite) TestSubscriptionFilter(c *C) {
+	c.Assert(subscriptionFilter("a/b"), Equals, "a/b")
+	c.Assert(subscriptionFilter("$share/group/a/b"), Equals, "a/b")
+	c.Assert(subscriptionFilter("$share/group"), Equals, "$share/group")
+}
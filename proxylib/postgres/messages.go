@@ -0,0 +1,167 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// PostgreSQL frontend/backend protocol v3 framing, see
+// https://www.postgresql.org/docs/current/protocol-message-formats.html
+
+const (
+	// protocolVersion3 is the protocol version of a v3 StartupMessage
+	protocolVersion3 = 3 << 16
+
+	// sslRequestCode, gssEncRequestCode and cancelRequestCode take the
+	// place of the protocol version in the respective untyped messages
+	sslRequestCode    = 80877103
+	gssEncRequestCode = 80877104
+	cancelRequestCode = 80877102
+
+	// maxStartupLen is the maximum length of a startup packet
+	// (MAX_STARTUP_PACKET_LENGTH)
+	maxStartupLen = 10000
+
+	// maxMessageLen is the maximum length of a typed message
+	maxMessageLen = 1 << 30
+
+	startupHdrLen = 8
+	messageHdrLen = 5
+)
+
+const (
+	msgQuery        = 'Q'
+	msgParse        = 'P'
+	msgSync         = 'S'
+	msgFunctionCall = 'F'
+
+	msgReadyForQuery = 'Z'
+	msgErrorResponse = 'E'
+)
+
+var (
+	errInvalidLength   = errors.New("invalid message length")
+	errInvalidStartup  = errors.New("invalid startup message")
+	errMissingCStringZ = errors.New("missing string terminator")
+)
+
+// parseStartup parses the untyped message sent by the frontend at the start
+// of a connection. It returns the length of the message, the request code
+// or protocol version, and for a StartupMessage the parameters. If the
+// message is not complete, the minimum number of additional bytes needed is
+// returned in more.
+func parseStartup(data []byte) (length int, code uint32, params map[string]string, more int, err error) {
+	if len(data) < startupHdrLen {
+		return 0, 0, nil, startupHdrLen - len(data), nil
+	}
+
+	length = int(binary.BigEndian.Uint32(data[0:4]))
+	if length < startupHdrLen || length > maxStartupLen {
+		return 0, 0, nil, 0, errInvalidLength
+	}
+	if len(data) < length {
+		return 0, 0, nil, length - len(data), nil
+	}
+
+	code = binary.BigEndian.Uint32(data[4:8])
+	if code != protocolVersion3 {
+		return length, code, nil, 0, nil
+	}
+
+	params = map[string]string{}
+	rest := data[startupHdrLen:length]
+	for len(rest) > 0 && rest[0] != 0 {
+		var key, value string
+		if key, rest, err = readCString(rest); err != nil {
+			return 0, 0, nil, 0, errInvalidStartup
+		}
+		if value, rest, err = readCString(rest); err != nil {
+			return 0, 0, nil, 0, errInvalidStartup
+		}
+		params[key] = value
+	}
+
+	return length, code, params, 0, nil
+}
+
+// parseMessage parses the typed message at the start of data. It returns the
+// message type, the payload and the total length of the message. If the
+// message is not complete, the minimum number of additional bytes needed is
+// returned in more.
+func parseMessage(data []byte) (msgType byte, payload []byte, length int, more int, err error) {
+	if len(data) < messageHdrLen {
+		return 0, nil, 0, messageHdrLen - len(data), nil
+	}
+
+	// The length includes itself but not the message type
+	n := int(binary.BigEndian.Uint32(data[1:5]))
+	if n < 4 || n > maxMessageLen {
+		return 0, nil, 0, 0, errInvalidLength
+	}
+	length = n + 1
+	if len(data) < length {
+		return 0, nil, 0, length - len(data), nil
+	}
+
+	return data[0], data[messageHdrLen:length], length, 0, nil
+}
+
+// readCString returns the null terminated string at the start of b and the
+// remainder of b following the terminator
+func readCString(b []byte) (string, []byte, error) {
+	idx := bytes.IndexByte(b, 0)
+	if idx < 0 {
+		return "", nil, errMissingCStringZ
+	}
+	return string(b[:idx]), b[idx+1:], nil
+}
+
+// newMessage returns a typed message with the given payload
+func newMessage(msgType byte, payload []byte) []byte {
+	msg := make([]byte, messageHdrLen, messageHdrLen+len(payload))
+	msg[0] = msgType
+	binary.BigEndian.PutUint32(msg[1:5], uint32(4+len(payload)))
+	return append(msg, payload...)
+}
+
+// errorResponse returns an ErrorResponse message with the given severity,
+// SQLSTATE code and message
+func errorResponse(severity, code, message string) []byte {
+	var payload bytes.Buffer
+	for _, field := range []struct {
+		t     byte
+		value string
+	}{
+		{'S', severity},
+		{'V', severity},
+		{'C', code},
+		{'M', message},
+	} {
+		payload.WriteByte(field.t)
+		payload.WriteString(field.value)
+		payload.WriteByte(0)
+	}
+	payload.WriteByte(0)
+	return newMessage(msgErrorResponse, payload.Bytes())
+}
+
+// readyForQuery returns a ReadyForQuery message with the given transaction
+// status
+func readyForQuery(txStatus byte) []byte {
+	return newMessage(msgReadyForQuery, []byte{txStatus})
+}
@@ -0,0 +1,459 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/cilium/cilium/proxylib/proxylib"
+
+	"github.com/cilium/proxy/go/cilium/api"
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// PostgreSQL Parser
+//
+// Spec: https://www.postgresql.org/docs/current/protocol.html
+//
+
+// The PostgreSQL parser supports filtering on the database and user of the
+// startup message and on the class of the statements issued via the simple
+// and the extended query protocol. Statements are classified by their
+// leading keyword as one of "select", "insert", "update", "delete", "ddl",
+// "dcl", "transaction", "session", "program" or "other". COPY statements
+// reading from or writing to a server-side program are classified as
+// "program" as they execute a shell command on the database server.
+// Statements nesting data modifying statements, e.g. in WITH queries or in
+// the query of COPY (query) TO, have the classes of all nested statements.
+//
+// Policy Examples:
+// {database : "orders", user : "app"} - Allow user "app" to connect to database "orders" and issue any statement
+// {database : "orders", action : "select"} - Allow SELECT on database "orders"
+// {action : "transaction"} - Allow BEGIN/COMMIT/ROLLBACK on any database
+//
+// The startup message is allowed if any rule matches its database and user.
+// A query is allowed only if every class of every statement it contains is
+// allowed by a rule. A denied query is answered with an ErrorResponse, a denied startup
+// message with a FATAL ErrorResponse, after which all further data is
+// dropped. SSL and GSSAPI encryption requests are declined so that the
+// connection remains inspectable.
+//
+// In the extended query protocol, all messages following a denied Parse
+// message are dropped until the next Sync, and the ErrorResponse is sent
+// right before the ReadyForQuery reply of the server to that Sync.
+
+// Rule matches against PostgreSQL requests
+type Rule struct {
+	database string
+	user     string
+	action   string
+}
+
+type postgresRequest struct {
+	database string
+	user     string
+	// action is the statement class, empty for the startup message
+	action string
+}
+
+// Matches returns true if the Rule matches
+func (rule *Rule) Matches(data interface{}) bool {
+	req, ok := data.(postgresRequest)
+	if !ok {
+		log.Warning("Matches() called with type other than postgresRequest")
+		return false
+	}
+
+	if rule.database != "" && rule.database != req.database {
+		log.Debugf("PostgresRule: database mismatch %s, %s", rule.database, req.database)
+		return false
+	}
+	if rule.user != "" && rule.user != req.user {
+		log.Debugf("PostgresRule: user mismatch %s, %s", rule.user, req.user)
+		return false
+	}
+	if rule.action != "" && req.action != "" && rule.action != req.action {
+		log.Debugf("PostgresRule: action mismatch %s, %s", rule.action, req.action)
+		return false
+	}
+	return true
+}
+
+// L7RuleParser parses protobuf L7 rules to an array of Rule
+// May panic
+func L7RuleParser(rule *cilium.PortNetworkPolicyRule) []proxylib.L7NetworkPolicyRule {
+	var rules []proxylib.L7NetworkPolicyRule
+	l7Rules := rule.GetL7Rules()
+	if l7Rules == nil {
+		return rules
+	}
+	for _, l7Rule := range l7Rules.GetL7Rules() {
+		var pr Rule
+		for k, v := range l7Rule.Rule {
+			switch k {
+			case "database":
+				pr.database = v
+			case "user":
+				pr.user = v
+			case "action":
+				pr.action = strings.ToLower(v)
+				if _, ok := validActions[pr.action]; pr.action != "" && !ok {
+					proxylib.ParseError(fmt.Sprintf("Unable to parse L7 postgres rule with invalid action: '%s'", v), rule)
+				}
+			default:
+				proxylib.ParseError(fmt.Sprintf("Unsupported key: %s", k), rule)
+			}
+		}
+		log.Debugf("Parsed Rule: %v", pr)
+		rules = append(rules, &pr)
+	}
+	return rules
+}
+
+// ParserFactory implements proxylib.ParserFactory
+type ParserFactory struct{}
+
+// Create creates PostgreSQL parser
+func (f *ParserFactory) Create(connection *proxylib.Connection) proxylib.Parser {
+	log.Debugf("PostgresParserFactory: Create: %v", connection)
+	return &Parser{
+		connection: connection,
+		txStatus:   'I',
+		prepared:   map[string][]string{},
+	}
+}
+
+// compile time check for interface implementation
+var _ proxylib.ParserFactory = &ParserFactory{}
+
+const (
+	parserName = "postgres"
+)
+
+func init() {
+	log.Debug("init(): Registering postgresParserFactory")
+	proxylib.RegisterParserFactory(parserName, &ParserFactory{})
+	proxylib.RegisterL7RuleParser(parserName, L7RuleParser)
+}
+
+// Parser implements proxylib.Parser
+type Parser struct {
+	connection *proxylib.Connection
+
+	// started is set once the StartupMessage has been seen
+	started bool
+	// denied is set if the StartupMessage has been denied
+	denied bool
+
+	database string
+	user     string
+
+	// skipUntilSync is set after a denied Parse message, all extended
+	// query messages are dropped until the next Sync
+	skipUntilSync bool
+
+	// replyQueue holds an entry for each ReadyForQuery reply outstanding,
+	// in request order
+	replyQueue []*replyIntent
+
+	// txStatus is the transaction status of the last ReadyForQuery
+	txStatus byte
+
+	// prepared maps the names of statements prepared with PREPARE to
+	// their classes
+	prepared map[string][]string
+}
+
+type replyIntent struct {
+	// denied is set for a denied simple query, ErrorResponse and
+	// ReadyForQuery are injected in place of the reply of the server
+	denied bool
+	// injectError is set if an ErrorResponse must be injected before
+	// the ReadyForQuery of the server
+	injectError bool
+}
+
+var _ proxylib.Parser = &Parser{}
+
+var (
+	// DeniedMsg is sent if policy denies a query. Exported for tests
+	DeniedMsg = errorResponse("ERROR", "42501", "access denied by policy")
+
+	// StartupDeniedMsg is sent if policy denies the startup message.
+	// Exported for tests
+	StartupDeniedMsg = errorResponse("FATAL", "28000", "access denied by policy")
+
+	// sslDeniedMsg is sent in response to an SSLRequest or
+	// GSSENCRequest
+	sslDeniedMsg = []byte{'N'}
+)
+
+func (p *Parser) logEntry(msgType string, statements []statement) *cilium.LogEntry_GenericL7 {
+	actions := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		actions = append(actions, stmt.classes...)
+	}
+	return &cilium.LogEntry_GenericL7{
+		GenericL7: &cilium.L7LogEntry{
+			Proto: parserName,
+			Fields: map[string]string{
+				"type":     msgType,
+				"database": p.database,
+				"user":     p.user,
+				"action":   strings.Join(actions, ","),
+			},
+		},
+	}
+}
+
+// matches returns true if all statements are allowed
+func (p *Parser) matches(statements []statement) bool {
+	req := postgresRequest{database: p.database, user: p.user}
+	if len(statements) == 0 {
+		return p.connection.Matches(req)
+	}
+	for _, stmt := range statements {
+		for _, class := range stmt.classes {
+			req.action = class
+			if !p.connection.Matches(req) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// OnData parses PostgreSQL data
+func (p *Parser) OnData(reply, endStream bool, dataBuffers [][]byte) (proxylib.OpType, int) {
+	if reply {
+		return p.onReply(dataBuffers)
+	}
+
+	// TODO: don't copy data to new slices
+	data := bytes.Join(dataBuffers, []byte{})
+
+	if p.denied {
+		if len(data) == 0 {
+			return proxylib.MORE, 1
+		}
+		return proxylib.DROP, len(data)
+	}
+
+	if !p.started {
+		return p.onStartup(data)
+	}
+
+	msgType, payload, length, more, err := parseMessage(data)
+	if err != nil {
+		log.WithError(err).Error("Could not parse PostgreSQL message")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_LENGTH)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+
+	switch msgType {
+	case msgQuery:
+		query, _, err := readCString(payload)
+		if err != nil {
+			log.WithError(err).Error("Could not parse PostgreSQL query")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+		statements := classifyQuery(query, p.prepared)
+		if p.matches(statements) {
+			for _, stmt := range statements {
+				if stmt.prepareName != "" {
+					p.prepared[stmt.prepareName] = stmt.classes
+				}
+			}
+			p.replyQueue = append(p.replyQueue, &replyIntent{})
+			p.connection.Log(cilium.EntryType_Request, p.logEntry("query", statements))
+			return proxylib.PASS, length
+		}
+
+		// The denied reply must not overtake the replies to earlier
+		// requests
+		if len(p.replyQueue) > 0 {
+			p.replyQueue = append(p.replyQueue, &replyIntent{denied: true})
+		} else {
+			p.injectDenied()
+		}
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry("query", statements))
+		return proxylib.DROP, length
+
+	case msgParse:
+		if p.skipUntilSync {
+			return proxylib.DROP, length
+		}
+		_, rest, err := readCString(payload)
+		if err != nil {
+			log.WithError(err).Error("Could not parse PostgreSQL Parse message")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+		query, _, err := readCString(rest)
+		if err != nil {
+			log.WithError(err).Error("Could not parse PostgreSQL Parse message")
+			return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+		}
+		statements := classifyQuery(query, p.prepared)
+		if p.matches(statements) {
+			p.connection.Log(cilium.EntryType_Request, p.logEntry("parse", statements))
+			return proxylib.PASS, length
+		}
+		p.skipUntilSync = true
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry("parse", statements))
+		return proxylib.DROP, length
+
+	case msgSync:
+		p.replyQueue = append(p.replyQueue, &replyIntent{injectError: p.skipUntilSync})
+		p.skipUntilSync = false
+		return proxylib.PASS, length
+
+	case msgFunctionCall:
+		// The fast-path function call interface bypasses the statement
+		// classification
+		statements := []statement{{classes: []string{actionOther}}}
+		if p.matches(statements) {
+			p.replyQueue = append(p.replyQueue, &replyIntent{})
+			p.connection.Log(cilium.EntryType_Request, p.logEntry("function-call", statements))
+			return proxylib.PASS, length
+		}
+		if len(p.replyQueue) > 0 {
+			p.replyQueue = append(p.replyQueue, &replyIntent{denied: true})
+		} else {
+			p.injectDenied()
+		}
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry("function-call", statements))
+		return proxylib.DROP, length
+	}
+
+	if p.skipUntilSync {
+		return proxylib.DROP, length
+	}
+	return proxylib.PASS, length
+}
+
+// onStartup handles the untyped messages at the start of a connection
+func (p *Parser) onStartup(data []byte) (proxylib.OpType, int) {
+	length, code, params, more, err := parseStartup(data)
+	if err != nil {
+		log.WithError(err).Error("Could not parse PostgreSQL startup message")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_LENGTH)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+
+	switch code {
+	case sslRequestCode, gssEncRequestCode:
+		// Encrypted connections cannot be inspected, decline so that
+		// the client may continue unencrypted
+		p.connection.Inject(true, sslDeniedMsg)
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry("ssl-request", nil))
+		return proxylib.DROP, length
+
+	case cancelRequestCode:
+		return proxylib.PASS, length
+
+	case protocolVersion3:
+		p.started = true
+		p.user = params["user"]
+		p.database = params["database"]
+		if p.database == "" {
+			p.database = p.user
+		}
+
+		if p.matches(nil) {
+			p.connection.Log(cilium.EntryType_Request, p.logEntry("startup", nil))
+			return proxylib.PASS, length
+		}
+
+		p.denied = true
+		p.connection.Inject(true, StartupDeniedMsg)
+		p.connection.Log(cilium.EntryType_Denied, p.logEntry("startup", nil))
+		return proxylib.DROP, length
+	}
+
+	log.Errorf("Unsupported PostgreSQL protocol version %d", code)
+	return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+}
+
+func (p *Parser) onReply(dataBuffers [][]byte) (proxylib.OpType, int) {
+	if injected := p.injectFromQueue(); injected > 0 {
+		return proxylib.INJECT, injected
+	}
+
+	// TODO: don't copy data to new slices
+	data := bytes.Join(dataBuffers, []byte{})
+	if len(data) == 0 {
+		return proxylib.NOP, 0
+	}
+
+	msgType, payload, length, more, err := parseMessage(data)
+	if err != nil {
+		log.WithError(err).Error("Could not parse PostgreSQL reply")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_LENGTH)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+
+	if msgType == msgReadyForQuery {
+		if len(p.replyQueue) > 0 {
+			intent := p.replyQueue[0]
+			if intent.injectError {
+				intent.injectError = false
+				p.connection.Inject(true, DeniedMsg)
+				return proxylib.INJECT, len(DeniedMsg)
+			}
+			p.replyQueue = p.replyQueue[1:]
+		}
+		if len(payload) == 1 {
+			p.txStatus = payload[0]
+		}
+		p.connection.Log(cilium.EntryType_Response, p.logEntry("ready-for-query", nil))
+	}
+
+	return proxylib.PASS, length
+}
+
+// injectDenied injects the replies to a denied query
+func (p *Parser) injectDenied() int {
+	ready := readyForQuery(p.txStatus)
+	p.connection.Inject(true, DeniedMsg)
+	p.connection.Inject(true, ready)
+	return len(DeniedMsg) + len(ready)
+}
+
+// injectFromQueue injects the replies to the denied queries at the head of
+// the reply queue and returns the number of bytes injected
+func (p *Parser) injectFromQueue() int {
+	injected := 0
+	n := 0
+	for _, intent := range p.replyQueue {
+		if !intent.denied {
+			break
+		}
+		injected += p.injectDenied()
+		n++
+	}
+	if n > 0 {
+		p.replyQueue = p.replyQueue[n:]
+	}
+	return injected
+}
@@ -0,0 +1,336 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package postgres
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/cilium/cilium/proxylib/accesslog"
+	"github.com/cilium/cilium/proxylib/proxylib"
+	"github.com/cilium/cilium/proxylib/test"
+
+	. "gopkg.in/check.v1"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type PostgresSuite struct {
+	logServer *test.AccessLogServer
+	ins       *proxylib.Instance
+}
+
+var _ = Suite(&PostgresSuite{})
+
+// Set up access log server and Library instance for all the test cases
+func (s *PostgresSuite) SetUpSuite(c *C) {
+	s.logServer = test.StartAccessLogServer("access_log.sock", 10)
+	c.Assert(s.logServer, Not(IsNil))
+	s.ins = proxylib.NewInstance("node1", accesslog.NewClient(s.logServer.Path))
+	c.Assert(s.ins, Not(IsNil))
+}
+
+func (s *PostgresSuite) checkAccessLogs(c *C, expPasses, expDrops int) {
+	passes, drops := s.logServer.Clear()
+	c.Check(passes, Equals, expPasses, Commentf("Unxpected number of passed access log messages"))
+	c.Check(drops, Equals, expDrops, Commentf("Unxpected number of denied access log messages"))
+}
+
+func (s *PostgresSuite) TearDownTest(c *C) {
+	s.logServer.Clear()
+}
+
+func (s *PostgresSuite) TearDownSuite(c *C) {
+	s.logServer.Close()
+}
+
+func startupMsg(params ...string) []byte {
+	msg := make([]byte, startupHdrLen)
+	binary.BigEndian.PutUint32(msg[4:8], protocolVersion3)
+	for _, param := range params {
+		msg = append(msg, param...)
+		msg = append(msg, 0)
+	}
+	msg = append(msg, 0)
+	binary.BigEndian.PutUint32(msg[0:4], uint32(len(msg)))
+	return msg
+}
+
+func requestMsg(code uint32) []byte {
+	msg := make([]byte, startupHdrLen)
+	binary.BigEndian.PutUint32(msg[0:4], startupHdrLen)
+	binary.BigEndian.PutUint32(msg[4:8], code)
+	return msg
+}
+
+func queryMsg(query string) []byte {
+	return newMessage(msgQuery, append([]byte(query), 0))
+}
+
+func parseMsg(name, query string) []byte {
+	payload := append([]byte(name), 0)
+	payload = append(payload, query...)
+	payload = append(payload, 0, 0, 0)
+	return newMessage(msgParse, payload)
+}
+
+func syncMsg() []byte {
+	return newMessage(msgSync, nil)
+}
+
+func concat(msgs ...[]byte) []byte {
+	var result []byte
+	for _, msg := range msgs {
+		result = append(result, msg...)
+	}
+	return result
+}
+
+var (
+	authOK = newMessage('R', []byte{0, 0, 0, 0})
+	// CommandComplete for a SELECT
+	selectComplete = newMessage('C', []byte("SELECT 1\x00"))
+	parseComplete  = newMessage('1', nil)
+)
+
+const policyOrders = `
+		name: "pg"
+		policy: 2
+		ingress_per_port_policies: <
+		  port: 80
+		  rules: <
+		    remote_policies: 1
+		    l7_proto: "postgres"
+		    l7_rules: <
+		      l7_rules: <
+			rule: <
+			  key: "database"
+			  value: "orders"
+			>
+			rule: <
+			  key: "action"
+			  value: "select"
+			>
+		      >
+		      l7_rules: <
+			rule: <
+			  key: "database"
+			  value: "orders"
+			>
+			rule: <
+			  key: "action"
+			  value: "transaction"
+			>
+		      >
+		    >
+		  >
+		>
+		`
+
+func (s *PostgresSuite) TestPostgresPolicyParse(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policyOrders})
+
+	// Unknown actions are rejected
+	err := s.ins.InsertPolicyText("2", []string{`
+		name: "pg-invalid"
+		policy: 2
+		ingress_per_port_policies: <
+		  port: 80
+		  rules: <
+		    l7_proto: "postgres"
+		    l7_rules: <
+		      l7_rules: <
+			rule: <
+			  key: "action"
+			  value: "merge"
+			>
+		      >
+		    >
+		  >
+		>
+		`}, "update")
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *PostgresSuite) TestPostgresStartup(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policyOrders})
+	conn := s.ins.CheckNewConnectionOK(c, "postgres", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "pg")
+
+	// SSLRequest is declined
+	data := [][]byte{requestMsg(sslRequestCode)}
+	conn.CheckOnDataOK(c, false, false, &data, []byte("N"),
+		proxylib.DROP, startupHdrLen,
+		proxylib.MORE, startupHdrLen)
+
+	// Partial startup message
+	startup := startupMsg("user", "app", "database", "orders")
+	data = [][]byte{startup[:10]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.MORE, len(startup)-10)
+
+	data = [][]byte{startup[:10], startup[10:]}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(startup),
+		proxylib.MORE, messageHdrLen)
+
+	data = [][]byte{concat(authOK, readyForQuery('I'))}
+	conn.CheckOnDataOK(c, true, false, &data, []byte{},
+		proxylib.PASS, len(authOK),
+		proxylib.PASS, 6)
+	s.checkAccessLogs(c, 2, 1)
+}
+
+func (s *PostgresSuite) TestPostgresStartupDenied(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policyOrders})
+	conn := s.ins.CheckNewConnectionOK(c, "postgres", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "pg")
+
+	// The database defaults to the user name
+	startup := startupMsg("user", "app")
+	query := queryMsg("SELECT 1")
+	data := [][]byte{concat(startup, query)}
+	conn.CheckOnDataOK(c, false, false, &data, StartupDeniedMsg,
+		proxylib.DROP, len(startup),
+		proxylib.DROP, len(query),
+		proxylib.MORE, 1)
+	s.checkAccessLogs(c, 0, 1)
+}
+
+func (s *PostgresSuite) TestPostgresSimpleQuery(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policyOrders})
+	conn := s.ins.CheckNewConnectionOK(c, "postgres", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "pg")
+
+	startup := startupMsg("user", "app", "database", "orders")
+	data := [][]byte{startup}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(startup),
+		proxylib.MORE, messageHdrLen)
+	s.checkAccessLogs(c, 1, 0)
+
+	// Allowed query, and a denied query without outstanding replies
+	allowed := queryMsg("BEGIN; SELECT * FROM orders")
+	data = [][]byte{allowed}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(allowed),
+		proxylib.MORE, messageHdrLen)
+
+	reply := concat(selectComplete, readyForQuery('T'))
+	data = [][]byte{reply}
+	conn.CheckOnDataOK(c, true, false, &data, []byte{},
+		proxylib.PASS, len(selectComplete),
+		proxylib.PASS, 6)
+
+	// A single denied statement denies the whole query, the injected
+	// ReadyForQuery carries the last transaction status
+	denied := queryMsg("SELECT 1; DELETE FROM orders")
+	data = [][]byte{denied}
+	conn.CheckOnDataOK(c, false, false, &data, concat(DeniedMsg, readyForQuery('T')),
+		proxylib.DROP, len(denied),
+		proxylib.MORE, messageHdrLen)
+	s.checkAccessLogs(c, 2, 1)
+
+	// Pipelined queries, the denial waits for the reply to the
+	// preceding query
+	data = [][]byte{concat(allowed, denied, allowed)}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(allowed),
+		proxylib.DROP, len(denied),
+		proxylib.PASS, len(allowed),
+		proxylib.MORE, messageHdrLen)
+
+	data = [][]byte{concat(reply, reply)}
+	conn.CheckOnDataOK(c, true, false, &data, concat(DeniedMsg, readyForQuery('T')),
+		proxylib.PASS, len(selectComplete),
+		proxylib.PASS, 6,
+		proxylib.INJECT, len(DeniedMsg)+6,
+		proxylib.PASS, len(selectComplete),
+		proxylib.PASS, 6)
+}
+
+func (s *PostgresSuite) TestPostgresNestedStatements(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policyOrders})
+	conn := s.ins.CheckNewConnectionOK(c, "postgres", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "pg")
+
+	startup := startupMsg("user", "app", "database", "orders")
+	data := [][]byte{startup}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(startup),
+		proxylib.MORE, messageHdrLen)
+
+	// Data modifying statements nested in allowed statements are denied,
+	// all classes of a statement must be allowed
+	for _, query := range []string{
+		"COPY (DELETE FROM orders RETURNING *) TO STDOUT",
+		"WITH x AS (DELETE FROM orders RETURNING *) SELECT * FROM x",
+		"COPY (SELECT * FROM orders) TO PROGRAM 'sh'",
+	} {
+		denied := queryMsg(query)
+		data = [][]byte{denied}
+		conn.CheckOnDataOK(c, false, false, &data, concat(DeniedMsg, readyForQuery('I')),
+			proxylib.DROP, len(denied),
+			proxylib.MORE, messageHdrLen)
+	}
+
+	allowed := queryMsg("COPY (WITH x AS (SELECT 1) SELECT * FROM x) TO STDOUT")
+	data = [][]byte{allowed}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(allowed),
+		proxylib.MORE, messageHdrLen)
+	s.checkAccessLogs(c, 2, 3)
+}
+
+func (s *PostgresSuite) TestPostgresExtendedQuery(c *C) {
+	s.ins.CheckInsertPolicyText(c, "1", []string{policyOrders})
+	conn := s.ins.CheckNewConnectionOK(c, "postgres", true, 1, 2, "1.1.1.1:34567", "2.2.2.2:80", "pg")
+
+	startup := startupMsg("user", "app", "database", "orders")
+	data := [][]byte{startup}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(startup),
+		proxylib.MORE, messageHdrLen)
+
+	// Bind and Execute following a denied Parse are dropped
+	allowed := parseMsg("s1", "SELECT $1")
+	denied := parseMsg("", "UPDATE orders SET a = $1")
+	bind := newMessage('B', []byte{0, 0, 0, 0, 0, 0, 0, 0})
+	execute := newMessage('E', []byte{0, 0, 0, 0, 0})
+	data = [][]byte{concat(allowed, syncMsg(), denied, bind, execute, syncMsg(), allowed, syncMsg())}
+	conn.CheckOnDataOK(c, false, false, &data, []byte{},
+		proxylib.PASS, len(allowed),
+		proxylib.PASS, 5,
+		proxylib.DROP, len(denied),
+		proxylib.DROP, len(bind),
+		proxylib.DROP, len(execute),
+		proxylib.PASS, 5,
+		proxylib.PASS, len(allowed),
+		proxylib.PASS, 5,
+		proxylib.MORE, messageHdrLen)
+	s.checkAccessLogs(c, 3, 1)
+
+	// The error is injected before the ReadyForQuery in response to the
+	// Sync following the denied Parse
+	data = [][]byte{concat(parseComplete, readyForQuery('I'), readyForQuery('I'), parseComplete, readyForQuery('I'))}
+	conn.CheckOnDataOK(c, true, false, &data, DeniedMsg,
+		proxylib.PASS, len(parseComplete),
+		proxylib.PASS, 6,
+		proxylib.INJECT, len(DeniedMsg),
+		proxylib.PASS, 6,
+		proxylib.PASS, len(parseComplete),
+		proxylib.PASS, 6)
+}
@@ -0,0 +1,393 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"strings"
+)
+
+// Statement classes which can be used as "action" in policy rules
+const (
+	actionSelect      = "select"
+	actionInsert      = "insert"
+	actionUpdate      = "update"
+	actionDelete      = "delete"
+	actionDDL         = "ddl"
+	actionDCL         = "dcl"
+	actionTransaction = "transaction"
+	actionSession     = "session"
+	actionProgram     = "program"
+	actionOther       = "other"
+)
+
+// validActions is the set of statement classes accepted in policy rules
+var validActions = map[string]struct{}{
+	actionSelect:      {},
+	actionInsert:      {},
+	actionUpdate:      {},
+	actionDelete:      {},
+	actionDDL:         {},
+	actionDCL:         {},
+	actionTransaction: {},
+	actionSession:     {},
+	actionProgram:     {},
+	actionOther:       {},
+}
+
+// statementClasses maps the leading keyword of a statement to its class.
+// Statements not listed here are classified as "other".
+var statementClasses = map[string]string{
+	"SELECT": actionSelect,
+	"TABLE":  actionSelect,
+	"VALUES": actionSelect,
+	"FETCH":  actionSelect,
+	"MOVE":   actionSelect,
+
+	"INSERT": actionInsert,
+	"UPDATE": actionUpdate,
+	"DELETE": actionDelete,
+
+	"CREATE":   actionDDL,
+	"ALTER":    actionDDL,
+	"DROP":     actionDDL,
+	"TRUNCATE": actionDDL,
+	"COMMENT":  actionDDL,
+	"REINDEX":  actionDDL,
+	"CLUSTER":  actionDDL,
+	"SECURITY": actionDDL,
+	"IMPORT":   actionDDL,
+	"REFRESH":  actionDDL,
+
+	"GRANT":    actionDCL,
+	"REVOKE":   actionDCL,
+	"REASSIGN": actionDCL,
+
+	"BEGIN":     actionTransaction,
+	"START":     actionTransaction,
+	"COMMIT":    actionTransaction,
+	"END":       actionTransaction,
+	"ROLLBACK":  actionTransaction,
+	"ABORT":     actionTransaction,
+	"SAVEPOINT": actionTransaction,
+	"RELEASE":   actionTransaction,
+
+	"SET":        actionSession,
+	"RESET":      actionSession,
+	"SHOW":       actionSession,
+	"DISCARD":    actionSession,
+	"DEALLOCATE": actionSession,
+	"CLOSE":      actionSession,
+	"LISTEN":     actionSession,
+	"UNLISTEN":   actionSession,
+}
+
+// word is an unquoted keyword or identifier of a statement in upper case,
+// along with the parenthesis nesting depth it was found at
+type word struct {
+	text  string
+	depth int
+}
+
+// statement is a classified SQL statement
+type statement struct {
+	// classes are the statement classes, all of which must be allowed.
+	// Statements nesting data modifying statements, e.g. in WITH queries,
+	// may have more than one class.
+	classes []string
+
+	// prepareName is the name of the prepared statement created by a
+	// PREPARE statement
+	prepareName string
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
+
+// skipQuoted returns the offset following the string quoted by q starting
+// at offset i. A doubled quote is an escaped quote, backslash escapes are
+// honoured if escapes is true.
+func skipQuoted(query string, i int, q byte, escapes bool) int {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case q:
+			if i+1 < len(query) && query[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// skipDollarQuoted returns the offset following the dollar quoted string
+// starting at offset i, or -1 if no dollar quote starts at offset i
+func skipDollarQuoted(query string, i int) int {
+	j := i + 1
+	for j < len(query) && query[j] != '$' {
+		if !isIdentChar(query[j]) || (j == i+1 && !isIdentStart(query[j])) {
+			return -1
+		}
+		j++
+	}
+	if j >= len(query) {
+		return -1
+	}
+	tag := query[i : j+1]
+	end := strings.Index(query[j+1:], tag)
+	if end < 0 {
+		return len(query)
+	}
+	return j + 1 + end + len(tag)
+}
+
+// skipBlockComment returns the offset following the possibly nested block
+// comment starting at offset i
+func skipBlockComment(query string, i int) int {
+	depth := 0
+	for i < len(query)-1 {
+		switch {
+		case query[i] == '/' && query[i+1] == '*':
+			depth++
+			i += 2
+		case query[i] == '*' && query[i+1] == '/':
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(query)
+}
+
+// splitStatements splits query into statements separated by semicolons and
+// returns the words of each non-empty statement. String literals, quoted
+// identifiers and comments are skipped.
+func splitStatements(query string) [][]word {
+	var statements [][]word
+	var words []word
+	depth := 0
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ';':
+			if len(words) > 0 {
+				statements = append(statements, words)
+			}
+			words = nil
+			depth = 0
+			i++
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+			i++
+		case c == '\'':
+			i = skipQuoted(query, i, '\'', false)
+		case c == '"':
+			i = skipQuoted(query, i, '"', false)
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			i = skipBlockComment(query, i)
+		case c == '$':
+			if end := skipDollarQuoted(query, i); end >= 0 {
+				i = end
+			} else {
+				i++
+			}
+		case isIdentStart(c):
+			start := i
+			for i < len(query) && isIdentChar(query[i]) {
+				i++
+			}
+			text := strings.ToUpper(query[start:i])
+			if i < len(query) && query[i] == '\'' && (text == "E" || text == "B" || text == "X") {
+				// String constant with escapes or bit string
+				i = skipQuoted(query, i, '\'', text == "E")
+				continue
+			}
+			words = append(words, word{text: text, depth: depth})
+		default:
+			i++
+		}
+	}
+
+	if len(words) > 0 {
+		statements = append(statements, words)
+	}
+
+	return statements
+}
+
+// addClass returns classes with class appended unless already contained
+func addClass(classes []string, class string) []string {
+	for _, c := range classes {
+		if c == class {
+			return classes
+		}
+	}
+	return append(classes, class)
+}
+
+// subquery returns the words of the parenthesized subquery starting at
+// words[0], at the depth of the enclosing statement
+func subquery(words []word) []word {
+	var result []word
+	for _, w := range words {
+		if w.depth == 0 {
+			break
+		}
+		result = append(result, word{text: w.text, depth: w.depth - 1})
+	}
+	return result
+}
+
+// findWord returns the index of the first of the given words at depth 0
+// in words, or -1 if not found
+func findWord(words []word, texts ...string) int {
+	for i, w := range words {
+		if w.depth != 0 {
+			continue
+		}
+		for _, text := range texts {
+			if w.text == text {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// classifyStatement classifies the statement consisting of words. prepared
+// maps the names of statements prepared with PREPARE to their classes.
+func classifyStatement(words []word, prepared map[string][]string) statement {
+	if len(words) == 0 {
+		return statement{classes: []string{actionOther}}
+	}
+
+	switch words[0].text {
+	case "WITH":
+		// Data modifying statements may be used in WITH, both in the
+		// auxiliary statements and in the primary statement
+		var classes []string
+		for _, w := range words[1:] {
+			switch w.text {
+			case "INSERT", "UPDATE", "DELETE":
+				classes = addClass(classes, statementClasses[w.text])
+			case "MERGE":
+				classes = addClass(classes, actionOther)
+			}
+		}
+		if len(classes) == 0 {
+			classes = []string{actionSelect}
+		}
+		return statement{classes: classes}
+
+	case "SELECT":
+		// SELECT INTO creates a new table
+		if findWord(words, "INTO") >= 0 {
+			return statement{classes: []string{actionDDL}}
+		}
+		return statement{classes: []string{actionSelect}}
+
+	case "EXPLAIN":
+		// EXPLAIN ANALYZE executes the statement
+		i := 1
+		for i < len(words) && (words[i].depth > 0 || words[i].text == "ANALYZE" ||
+			words[i].text == "ANALYSE" || words[i].text == "VERBOSE") {
+			i++
+		}
+		return classifyStatement(words[i:], prepared)
+
+	case "PREPARE":
+		if len(words) > 1 && words[1].text == "TRANSACTION" {
+			return statement{classes: []string{actionTransaction}}
+		}
+		if i := findWord(words, "AS"); i > 1 {
+			stmt := classifyStatement(words[i+1:], prepared)
+			return statement{classes: stmt.classes, prepareName: words[1].text}
+		}
+		return statement{classes: []string{actionOther}}
+
+	case "EXECUTE":
+		if len(words) > 1 {
+			if classes, ok := prepared[words[1].text]; ok {
+				return statement{classes: classes}
+			}
+		}
+		return statement{classes: []string{actionOther}}
+
+	case "DECLARE":
+		if i := findWord(words, "FOR"); i > 0 {
+			return classifyStatement(words[i+1:], prepared)
+		}
+		return statement{classes: []string{actionOther}}
+
+	case "COPY":
+		var classes []string
+		if len(words) > 1 && words[1].depth > 0 {
+			// The query of COPY (query) TO may modify data with
+			// RETURNING
+			classes = classifyStatement(subquery(words[1:]), prepared).classes
+		}
+		if i := findWord(words[1:], "FROM", "TO") + 1; i > 0 {
+			// COPY FROM/TO PROGRAM runs a shell command on the server
+			if i+1 < len(words) && words[i+1].text == "PROGRAM" {
+				classes = addClass(classes, actionProgram)
+			} else if words[i].text == "FROM" {
+				classes = addClass(classes, actionInsert)
+			}
+		}
+		if len(classes) == 0 {
+			classes = []string{actionSelect}
+		}
+		return statement{classes: classes}
+	}
+
+	if class, ok := statementClasses[words[0].text]; ok {
+		return statement{classes: []string{class}}
+	}
+	return statement{classes: []string{actionOther}}
+}
+
+// classifyQuery classifies all statements of query
+func classifyQuery(query string, prepared map[string][]string) []statement {
+	var statements []statement
+	for _, words := range splitStatements(query) {
+		statements = append(statements, classifyStatement(words, prepared))
+	}
+	return statements
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package postgres
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func classes(statements []statement) []string {
+	result := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		result = append(result, strings.Join(stmt.classes, ","))
+	}
+	return result
+}
+
+func (s *PostgresSuite) TestClassifyQuery(c *C) {
+	for _, tc := range []struct {
+		query    string
+		expected []string
+	}{
+		{"SELECT * FROM t", []string{actionSelect}},
+		{"  select 1;", []string{actionSelect}},
+		{"TABLE t", []string{actionSelect}},
+		{"INSERT INTO t VALUES (1)", []string{actionInsert}},
+		{"update t set a = 1", []string{actionUpdate}},
+		{"DELETE FROM t", []string{actionDelete}},
+		{"CREATE TABLE t (a int)", []string{actionDDL}},
+		{"SELECT * INTO t2 FROM t", []string{actionDDL}},
+		{"SELECT (SELECT a INTO x FROM t)", []string{actionSelect}},
+		{"GRANT SELECT ON t TO u", []string{actionDCL}},
+		{"BEGIN; INSERT INTO t VALUES (1); COMMIT", []string{actionTransaction, actionInsert, actionTransaction}},
+		{"SET search_path TO s", []string{actionSession}},
+		{"VACUUM", []string{actionOther}},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", []string{actionSelect}},
+		{"WITH x AS (DELETE FROM t RETURNING *) SELECT * FROM x", []string{actionDelete}},
+		{"WITH a AS (INSERT INTO t VALUES (1) RETURNING *) DELETE FROM t", []string{actionInsert + "," + actionDelete}},
+		{"WITH a AS (UPDATE t SET a = 1 RETURNING *), b AS (UPDATE u SET b = 1 RETURNING *) SELECT 1", []string{actionUpdate}},
+		{"EXPLAIN ANALYZE UPDATE t SET a = 1", []string{actionUpdate}},
+		{"EXPLAIN (ANALYZE, FORMAT json) DELETE FROM t", []string{actionDelete}},
+		{"DECLARE c CURSOR FOR SELECT * FROM t", []string{actionSelect}},
+		{"COPY t FROM STDIN", []string{actionInsert}},
+		{"COPY (SELECT * FROM t) TO STDOUT", []string{actionSelect}},
+		{"COPY t FROM PROGRAM 'cat /etc/passwd'", []string{actionProgram}},
+		{"copy (SELECT * FROM t) to program 'sh'", []string{actionSelect + "," + actionProgram}},
+		{"COPY t (a, b) TO PROGRAM 'gzip > /tmp/t.gz'", []string{actionProgram}},
+		{"COPY program FROM STDIN", []string{actionInsert}},
+		{"COPY (DELETE FROM t RETURNING *) TO STDOUT", []string{actionDelete}},
+		{"COPY (WITH x AS (DELETE FROM t RETURNING *) SELECT * FROM x) TO STDOUT", []string{actionDelete}},
+		{"COPY (SELECT (SELECT 1)) TO STDOUT", []string{actionSelect}},
+		{"PREPARE TRANSACTION 'x'", []string{actionTransaction}},
+
+		// Quoted strings, identifiers and comments are not classified
+		{"SELECT 'a; DROP TABLE t'", []string{actionSelect}},
+		{"SELECT E'\\'; DROP TABLE t'", []string{actionSelect}},
+		{"SELECT \"a;b\" FROM t", []string{actionSelect}},
+		{"SELECT $$; DROP TABLE t$$", []string{actionSelect}},
+		{"SELECT $tag$ $$; DROP $$ $tag$", []string{actionSelect}},
+		{"/* DROP TABLE t; */ SELECT 1", []string{actionSelect}},
+		{"/* /* nested */ ; DROP */ SELECT 1", []string{actionSelect}},
+		{"-- DROP TABLE t;\nSELECT 1", []string{actionSelect}},
+		{"SELECT 1; -- trailing", []string{actionSelect}},
+
+		{"", []string{}},
+		{";;", []string{}},
+	} {
+		c.Check(classes(classifyQuery(tc.query, nil)), DeepEquals, tc.expected, Commentf("query: %q", tc.query))
+	}
+}
+
+func (s *PostgresSuite) TestClassifyPrepared(c *C) {
+	prepared := map[string][]string{}
+	statements := classifyQuery("PREPARE ins (int) AS INSERT INTO t VALUES ($1)", prepared)
+	c.Assert(statements, HasLen, 1)
+	c.Assert(statements[0], DeepEquals, statement{classes: []string{actionInsert}, prepareName: "INS"})
+	prepared[statements[0].prepareName] = statements[0].classes
+
+	c.Assert(classes(classifyQuery("EXECUTE ins(1)", prepared)), DeepEquals, []string{actionInsert})
+	c.Assert(classes(classifyQuery("EXECUTE unknown", prepared)), DeepEquals, []string{actionOther})
+}
@@ -23,9 +23,12 @@ import (
 	"github.com/cilium/cilium/proxylib/accesslog"
 	_ "github.com/cilium/cilium/proxylib/cassandra"
 	_ "github.com/cilium/cilium/proxylib/memcached"
+	_ "github.com/cilium/cilium/proxylib/mqtt"
 	"github.com/cilium/cilium/proxylib/npds"
+	_ "github.com/cilium/cilium/proxylib/postgres"
 	. "github.com/cilium/cilium/proxylib/proxylib"
 	_ "github.com/cilium/cilium/proxylib/r2d2"
+	_ "github.com/cilium/cilium/proxylib/redis"
 	_ "github.com/cilium/cilium/proxylib/testparsers"
 
 	"github.com/cilium/cilium/pkg/lock"
@@ -0,0 +1,299 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cilium/cilium/proxylib/proxylib"
+	"github.com/cilium/cilium/proxylib/redis"
+	"github.com/cilium/cilium/proxylib/test"
+
+	_ "gopkg.in/check.v1"
+)
+
+var getSessionRESP = []byte("*2\r\n$3\r\nGET\r\n$9\r\nsession:1\r\n")
+var getUserRESP = []byte("*2\r\n$3\r\nGET\r\n$6\r\nuser:1\r\n")
+var mgetSessionsRESP = []byte("*3\r\n$4\r\nMGET\r\n$9\r\nsession:1\r\n$9\r\nsession:2\r\n")
+var mgetMixedRESP = []byte("*3\r\n$4\r\nmget\r\n$9\r\nsession:1\r\n$6\r\nuser:1\r\n")
+var flushAllRESP = []byte("*1\r\n$8\r\nFLUSHALL\r\n")
+var configGetRESP = []byte("*3\r\n$6\r\nCONFIG\r\n$3\r\nGET\r\n$1\r\n*\r\n")
+var keysRESP = []byte("*2\r\n$4\r\nKEYS\r\n$1\r\n*\r\n")
+var subscribeRESP = []byte("*2\r\n$9\r\nSUBSCRIBE\r\n$4\r\nnews\r\n")
+var pingInline = []byte("PING\r\n")
+var sortStoreSessionRESP = []byte("*4\r\n$4\r\nSORT\r\n$9\r\nsession:1\r\n$5\r\nSTORE\r\n$9\r\nsession:2\r\n")
+var sortStoreUserRESP = []byte("*4\r\n$4\r\nSORT\r\n$9\r\nsession:1\r\n$5\r\nSTORE\r\n$6\r\nuser:1\r\n")
+
+var bulkReply = []byte("$5\r\nhello\r\n")
+var nullReply = []byte("$-1\r\n")
+var arrayReply = []byte("*2\r\n$5\r\nhello\r\n$-1\r\n")
+var pongReply = []byte("+PONG\r\n")
+var subscribeReply = []byte("*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n")
+var messageReply = []byte("*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n")
+var pushReply = []byte(">3\r\n+message\r\n+news\r\n+hello\r\n")
+
+// sessionPolicy allows GET and MGET on keys prefixed with "session:", PING
+// and SUBSCRIBE
+const sessionPolicy = `
+		    l7_rules: <
+		      rule: <
+		        key: "command"
+		        value: "GET"
+		      >
+		      rule: <
+		        key: "keyPrefix"
+		        value: "session:"
+		      >
+		    >
+		    l7_rules: <
+		      rule: <
+		        key: "command"
+		        value: "mget"
+		      >
+		      rule: <
+		        key: "keyPrefix"
+		        value: "session:"
+		      >
+		    >
+		    l7_rules: <
+		      rule: <
+		        key: "command"
+		        value: "PING"
+		      >
+		    >
+		    l7_rules: <
+		      rule: <
+		        key: "command"
+		        value: "SUBSCRIBE"
+		      >
+		    >
+`
+
+func TestRedis(t *testing.T) {
+	for _, tc := range redisTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logServer := test.StartAccessLogServer("access_log.sock", 10)
+			defer logServer.Close()
+
+			mod := OpenModule([][2]string{{"access-log-path", logServer.Path}}, false)
+			if mod == 0 {
+				t.Errorf("OpenModule() with access log path %s failed", logServer.Path)
+			} else {
+				defer CloseModule(mod)
+			}
+
+			insertPolicyText(t, mod, "1", []string{fmt.Sprintf(`
+		name: "rd1"
+		policy: 2
+		ingress_per_port_policies: <
+		  port: 6379
+		  rules: <
+		    remote_policies: 1
+		    l7_proto: "redis"
+		    l7_rules: <
+%s
+		    >
+		  >
+		>
+		`, tc.policy)})
+
+			buf := CheckOnNewConnection(t, mod, "redis", 1, true, 1, 2, "1.1.1.1:34567", "2.2.2.2:6379", "rd1",
+				80, proxylib.OK, 1)
+
+			tc.onDataChecks(t)
+
+			CheckClose(t, 1, buf, 1)
+		})
+	}
+}
+
+// sortSessionPolicy allows SORT on keys prefixed with "session:"
+const sortSessionPolicy = `
+		    l7_rules: <
+		      rule: <
+		        key: "command"
+		        value: "SORT"
+		      >
+		      rule: <
+		        key: "keyPrefix"
+		        value: "session:"
+		      >
+		    >
+`
+
+var redisTestCases = []testCase{
+	{
+		"sort store to other key drop",
+		sortSessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{sortStoreUserRESP}, []ExpFilterOp{
+				{proxylib.DROP, len(sortStoreUserRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, string(redis.DeniedMsg))
+
+			CheckOnData(t, 1, false, false, &[][]byte{sortStoreSessionRESP}, []ExpFilterOp{
+				{proxylib.PASS, len(sortStoreSessionRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+		},
+	},
+	{
+		"get session pass",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{getSessionRESP}, []ExpFilterOp{
+				{proxylib.PASS, len(getSessionRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{bulkReply}, []ExpFilterOp{
+				{proxylib.PASS, len(bulkReply)},
+			}, proxylib.OK, "")
+		},
+	},
+	{
+		"get other key drop",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{getUserRESP}, []ExpFilterOp{
+				{proxylib.DROP, len(getUserRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, string(redis.DeniedMsg))
+		},
+	},
+	{
+		"mget pass",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{mgetSessionsRESP}, []ExpFilterOp{
+				{proxylib.PASS, len(mgetSessionsRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{arrayReply}, []ExpFilterOp{
+				{proxylib.PASS, len(arrayReply)},
+			}, proxylib.OK, "")
+		},
+	},
+	{
+		"mget with other key drop",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{mgetMixedRESP}, []ExpFilterOp{
+				{proxylib.DROP, len(mgetMixedRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, string(redis.DeniedMsg))
+		},
+	},
+	{
+		"flushall drop",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{flushAllRESP}, []ExpFilterOp{
+				{proxylib.DROP, len(flushAllRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, string(redis.DeniedMsg))
+		},
+	},
+	{
+		"config and keys drop",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{configGetRESP, keysRESP}, []ExpFilterOp{
+				{proxylib.DROP, len(configGetRESP)}, {proxylib.DROP, len(keysRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, string(redis.DeniedMsg)+string(redis.DeniedMsg))
+		},
+	},
+	{
+		"inline ping pass",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{pingInline}, []ExpFilterOp{
+				{proxylib.PASS, len(pingInline)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{pongReply}, []ExpFilterOp{
+				{proxylib.PASS, len(pongReply)},
+			}, proxylib.OK, "")
+		},
+	},
+	{
+		"pipelined requests keep reply order",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{getSessionRESP, flushAllRESP, getSessionRESP}, []ExpFilterOp{
+				{proxylib.PASS, len(getSessionRESP)}, {proxylib.DROP, len(flushAllRESP)},
+				{proxylib.PASS, len(getSessionRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{bulkReply, nullReply}, []ExpFilterOp{
+				{proxylib.PASS, len(bulkReply)}, {proxylib.INJECT, len(redis.DeniedMsg)},
+				{proxylib.PASS, len(nullReply)},
+			}, proxylib.OK, string(redis.DeniedMsg))
+		},
+	},
+	{
+		"partial frames",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{getSessionRESP[:7]}, []ExpFilterOp{
+				{proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, false, false, &[][]byte{getSessionRESP[:15], getSessionRESP[15:]}, []ExpFilterOp{
+				{proxylib.PASS, len(getSessionRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{bulkReply[:7]}, []ExpFilterOp{
+				{proxylib.MORE, len(bulkReply) - 7},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{bulkReply[:7], bulkReply[7:]}, []ExpFilterOp{
+				{proxylib.PASS, len(bulkReply)},
+			}, proxylib.OK, "")
+		},
+	},
+	{
+		"subscribe and push messages pass",
+		sessionPolicy,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{subscribeRESP}, []ExpFilterOp{
+				{proxylib.PASS, len(subscribeRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, true, false, &[][]byte{subscribeReply, messageReply, pushReply}, []ExpFilterOp{
+				{proxylib.PASS, len(subscribeReply)}, {proxylib.PASS, len(messageReply)},
+				{proxylib.PASS, len(pushReply)},
+			}, proxylib.OK, "")
+
+			CheckOnData(t, 1, false, false, &[][]byte{flushAllRESP}, []ExpFilterOp{
+				{proxylib.DROP, len(flushAllRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, string(redis.DeniedMsg))
+		},
+	},
+	{
+		"allow all",
+		`
+		    l7_rules: <
+		      rule: <
+		        key: "command"
+		        value: ""
+		      >
+		    >
+`,
+		func(t *testing.T) {
+			CheckOnData(t, 1, false, false, &[][]byte{flushAllRESP, keysRESP}, []ExpFilterOp{
+				{proxylib.PASS, len(flushAllRESP)}, {proxylib.PASS, len(keysRESP)}, {proxylib.MORE, 1},
+			}, proxylib.OK, "")
+		},
+	},
+}
@@ -0,0 +1,205 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+)
+
+// keySpec describes the position of the keys in the arguments of a command,
+// following the first key, last key and step definitions of the Redis
+// COMMAND command. Argument positions are 1-based, the command name itself
+// is at position 0. A negative last position is relative to the end of the
+// arguments, -1 being the last argument.
+type keySpec struct {
+	first int
+	last  int
+	step  int
+}
+
+var (
+	firstKey       = keySpec{first: 1, last: 1, step: 1}
+	secondKey      = keySpec{first: 2, last: 2, step: 1}
+	firstTwoKeys   = keySpec{first: 1, last: 2, step: 1}
+	allKeys        = keySpec{first: 1, last: -1, step: 1}
+	allButLastKeys = keySpec{first: 1, last: -2, step: 1}
+	keyValuePairs  = keySpec{first: 1, last: -1, step: 2}
+)
+
+// commandKeys maps the upper case names of commands operating on keys to the
+// position of the keys. Commands not listed here are considered to have no
+// keys.
+var commandKeys = map[string]keySpec{}
+
+// keyFunc returns the keys of a request whose keys depend on keyword
+// arguments, args[0] being the command name
+type keyFunc func(args [][]byte) [][]byte
+
+// commandKeyFuncs maps the upper case names of commands taking keys as
+// keyword arguments to the function extracting the keys. Such commands are
+// not listed in commandKeys.
+var commandKeyFuncs = map[string]keyFunc{
+	"SORT":              sortKeys,
+	"SORT_RO":           sortKeys,
+	"GEORADIUS":         storeKeys(6),
+	"GEORADIUSBYMEMBER": storeKeys(5),
+}
+
+// sortKeys returns the keys of SORT key [BY pattern] [LIMIT offset count]
+// [GET pattern ...] [ASC|DESC] [ALPHA] [STORE destination]. The BY and GET
+// patterns are returned as keys as they read the keys matching them.
+func sortKeys(args [][]byte) [][]byte {
+	if len(args) < 2 {
+		return nil
+	}
+	keys := [][]byte{args[1]}
+	for i := 2; i < len(args)-1; i++ {
+		arg := args[i+1]
+		switch {
+		case bytes.EqualFold(args[i], []byte("LIMIT")):
+			i += 2
+		case bytes.EqualFold(args[i], []byte("BY")):
+			// A pattern without '*' skips sorting
+			if bytes.IndexByte(arg, '*') >= 0 {
+				keys = append(keys, arg)
+			}
+			i++
+		case bytes.EqualFold(args[i], []byte("GET")):
+			// '#' returns the element itself
+			if !bytes.Equal(arg, []byte("#")) {
+				keys = append(keys, arg)
+			}
+			i++
+		case bytes.EqualFold(args[i], []byte("STORE")):
+			keys = append(keys, arg)
+			i++
+		}
+	}
+	return keys
+}
+
+// storeKeys returns a function returning the keys of a command with the key
+// as first argument and the keyword arguments STORE and STOREDIST, each
+// followed by a destination key, starting at argument position first
+func storeKeys(first int) keyFunc {
+	return func(args [][]byte) [][]byte {
+		if len(args) < 2 {
+			return nil
+		}
+		keys := [][]byte{args[1]}
+		for i := first; i < len(args)-1; i++ {
+			if bytes.EqualFold(args[i], []byte("STORE")) || bytes.EqualFold(args[i], []byte("STOREDIST")) {
+				keys = append(keys, args[i+1])
+				i++
+			}
+		}
+		return keys
+	}
+}
+
+func registerCommands(spec keySpec, commands ...string) {
+	for _, cmd := range commands {
+		commandKeys[cmd] = spec
+	}
+}
+
+func init() {
+	// strings and generic key commands
+	registerCommands(firstKey,
+		"GET", "SET", "SETNX", "SETEX", "PSETEX", "GETSET", "GETDEL", "GETEX",
+		"APPEND", "STRLEN", "INCR", "DECR", "INCRBY", "DECRBY", "INCRBYFLOAT",
+		"GETRANGE", "SETRANGE", "SUBSTR", "SETBIT", "GETBIT", "BITCOUNT", "BITPOS",
+		"BITFIELD", "EXPIRE", "PEXPIRE", "EXPIREAT", "PEXPIREAT", "TTL", "PTTL",
+		"PERSIST", "TYPE", "DUMP", "RESTORE", "MOVE")
+	registerCommands(secondKey, "OBJECT")
+	registerCommands(allKeys, "MGET", "DEL", "UNLINK", "EXISTS", "TOUCH", "WATCH")
+	registerCommands(keyValuePairs, "MSET", "MSETNX")
+	registerCommands(firstTwoKeys, "RENAME", "RENAMENX", "COPY")
+
+	// hashes
+	registerCommands(firstKey,
+		"HGET", "HSET", "HSETNX", "HMSET", "HMGET", "HDEL", "HLEN", "HKEYS",
+		"HVALS", "HGETALL", "HEXISTS", "HINCRBY", "HINCRBYFLOAT", "HSTRLEN",
+		"HSCAN", "HRANDFIELD")
+
+	// lists
+	registerCommands(firstKey,
+		"LPUSH", "RPUSH", "LPUSHX", "RPUSHX", "LPOP", "RPOP", "LLEN", "LRANGE",
+		"LINDEX", "LSET", "LREM", "LTRIM", "LINSERT", "LPOS")
+	registerCommands(firstTwoKeys, "RPOPLPUSH", "LMOVE", "BRPOPLPUSH", "BLMOVE")
+	registerCommands(allButLastKeys, "BLPOP", "BRPOP", "BZPOPMIN", "BZPOPMAX")
+
+	// sets
+	registerCommands(firstKey,
+		"SADD", "SREM", "SMEMBERS", "SISMEMBER", "SMISMEMBER", "SCARD", "SPOP",
+		"SRANDMEMBER", "SSCAN")
+	registerCommands(firstTwoKeys, "SMOVE")
+	registerCommands(allKeys,
+		"SINTER", "SUNION", "SDIFF", "SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE")
+
+	// sorted sets
+	registerCommands(firstKey,
+		"ZADD", "ZREM", "ZRANGE", "ZRANGEBYSCORE", "ZREVRANGE", "ZREVRANGEBYSCORE",
+		"ZRANGEBYLEX", "ZREVRANGEBYLEX", "ZRANK", "ZREVRANK", "ZSCORE", "ZMSCORE",
+		"ZCARD", "ZCOUNT", "ZLEXCOUNT", "ZINCRBY", "ZSCAN", "ZPOPMIN", "ZPOPMAX",
+		"ZREMRANGEBYRANK", "ZREMRANGEBYSCORE", "ZREMRANGEBYLEX", "ZRANDMEMBER")
+
+	// hyperloglog, streams and geo
+	registerCommands(firstKey, "PFADD")
+	registerCommands(allKeys, "PFCOUNT", "PFMERGE")
+	registerCommands(firstKey,
+		"XADD", "XLEN", "XRANGE", "XREVRANGE", "XDEL", "XTRIM", "XACK",
+		"XCLAIM", "XAUTOCLAIM", "XPENDING", "XSETID")
+	registerCommands(secondKey, "XGROUP")
+	registerCommands(firstKey,
+		"GEOADD", "GEOPOS", "GEODIST", "GEOHASH", "GEOSEARCH")
+}
+
+// operatesOnKeys returns true if the upper case command name cmd is known to
+// take keys
+func operatesOnKeys(cmd string) bool {
+	if _, ok := commandKeyFuncs[cmd]; ok {
+		return true
+	}
+	_, ok := commandKeys[cmd]
+	return ok
+}
+
+// extractKeys returns the keys of the request with the upper case command
+// name cmd and the arguments args, args[0] being the command name
+func extractKeys(cmd string, args [][]byte) [][]byte {
+	if fn, ok := commandKeyFuncs[cmd]; ok {
+		return fn(args)
+	}
+
+	spec, ok := commandKeys[cmd]
+	if !ok {
+		return nil
+	}
+
+	last := spec.last
+	if last < 0 {
+		last += len(args)
+	}
+	if last >= len(args) {
+		last = len(args) - 1
+	}
+
+	var keys [][]byte
+	for i := spec.first; i <= last; i += spec.step {
+		keys = append(keys, args[i])
+	}
+	return keys
+}
@@ -0,0 +1,313 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cilium/cilium/proxylib/proxylib"
+
+	"github.com/cilium/proxy/go/cilium/api"
+	log "github.com/sirupsen/logrus"
+)
+
+//
+// Redis Parser
+//
+// Spec: https://redis.io/topics/protocol
+//
+
+// The Redis parser supports filtering on the command name and the keys of
+// requests in both RESP2 and RESP3. Requests are multi-bulk arrays or inline
+// commands, replies are parsed only to the extent needed to keep track of
+// which reply belongs to which request when requests are pipelined.
+//
+// Policy Examples:
+// {command : "GET", keyPrefix : "session:"} - Allow GET on keys starting with "session:"
+// {command : "MGET", keyPrefix : "session:"} - Allow MGET if all keys start with "session:"
+// {command : "PING"} - Allow PING
+// {} - Allow all commands
+//
+// Commands not allowed by any rule, e.g. FLUSHALL, CONFIG or KEYS, are denied
+// with a "-NOPERM" error reply injected in place of the server reply.
+//
+// Once a client subscribes to a channel, replies to further requests are
+// passed without correlating them to requests, as the server pushes messages
+// at any time. Denied requests are then answered immediately.
+
+// Rule matches against Redis requests
+type Rule struct {
+	// command is the upper case command name, empty matches all commands
+	command   string
+	keyExact  []byte
+	keyPrefix []byte
+	regex     *regexp.Regexp
+}
+
+type redisRequest struct {
+	command string
+	keys    [][]byte
+}
+
+func (rule *Rule) hasKeyConstraint() bool {
+	return len(rule.keyExact) > 0 || len(rule.keyPrefix) > 0 || rule.regex != nil
+}
+
+// Matches returns true if the Rule matches
+func (rule *Rule) Matches(data interface{}) bool {
+	req, ok := data.(redisRequest)
+	if !ok {
+		log.Warning("Matches() called with type other than redisRequest")
+		return false
+	}
+
+	if rule.command != "" && rule.command != req.command {
+		log.Debugf("RedisRule: command mismatch %s, %s", rule.command, req.command)
+		return false
+	}
+
+	if !rule.hasKeyConstraint() {
+		return true
+	}
+
+	// A rule restricting keys never matches a request without keys
+	if len(req.keys) == 0 {
+		return false
+	}
+
+	for _, key := range req.keys {
+		if len(rule.keyExact) > 0 && !bytes.Equal(rule.keyExact, key) {
+			log.Debugf("RedisRule: keyExact mismatch %s, %s", rule.keyExact, key)
+			return false
+		}
+		if len(rule.keyPrefix) > 0 && !bytes.HasPrefix(key, rule.keyPrefix) {
+			log.Debugf("RedisRule: keyPrefix mismatch %s, %s", rule.keyPrefix, key)
+			return false
+		}
+		if rule.regex != nil && !rule.regex.Match(key) {
+			log.Debugf("RedisRule: keyRegex mismatch %s, %s", rule.regex.String(), key)
+			return false
+		}
+	}
+
+	return true
+}
+
+// L7RuleParser parses protobuf L7 rules to an array of Rule
+// May panic
+func L7RuleParser(rule *cilium.PortNetworkPolicyRule) []proxylib.L7NetworkPolicyRule {
+	var rules []proxylib.L7NetworkPolicyRule
+	l7Rules := rule.GetL7Rules()
+	if l7Rules == nil {
+		return rules
+	}
+	for _, l7Rule := range l7Rules.GetL7Rules() {
+		var rr Rule
+		for k, v := range l7Rule.Rule {
+			switch k {
+			case "command":
+				rr.command = strings.ToUpper(v)
+			case "keyExact":
+				rr.keyExact = []byte(v)
+			case "keyPrefix":
+				rr.keyPrefix = []byte(v)
+			case "keyRegex":
+				if v != "" {
+					rr.regex = regexp.MustCompile(v)
+				}
+			default:
+				proxylib.ParseError(fmt.Sprintf("Unsupported key: %s", k), rule)
+			}
+		}
+		if rr.hasKeyConstraint() {
+			if rr.command == "" {
+				proxylib.ParseError("command not specified but key was provided", rule)
+			}
+			if !operatesOnKeys(rr.command) {
+				proxylib.ParseError(fmt.Sprintf("key provided for command '%s' which does not operate on keys", rr.command), rule)
+			}
+		}
+		log.Debugf("Parsed Rule: %v", rr)
+		rules = append(rules, &rr)
+	}
+	return rules
+}
+
+// ParserFactory implements proxylib.ParserFactory
+type ParserFactory struct{}
+
+// Create creates Redis parser
+func (f *ParserFactory) Create(connection *proxylib.Connection) proxylib.Parser {
+	log.Debugf("RedisParserFactory: Create: %v", connection)
+	return &Parser{connection: connection}
+}
+
+// compile time check for interface implementation
+var _ proxylib.ParserFactory = &ParserFactory{}
+
+const (
+	parserName = "redis"
+)
+
+func init() {
+	log.Debug("init(): Registering redisParserFactory")
+	proxylib.RegisterParserFactory(parserName, &ParserFactory{})
+	proxylib.RegisterL7RuleParser(parserName, L7RuleParser)
+}
+
+// Parser implements proxylib.Parser
+type Parser struct {
+	connection *proxylib.Connection
+
+	// replyQueue holds an entry for each request for which a reply is
+	// outstanding, in request order
+	replyQueue []*replyIntent
+
+	// subscribed is set when the client subscribed to a pub/sub channel,
+	// no further replies are queued from then on
+	subscribed bool
+}
+
+type replyIntent struct {
+	command string
+	denied  bool
+}
+
+var _ proxylib.Parser = &Parser{}
+
+// DeniedMsg is sent if policy denies the request. Exported for tests
+var DeniedMsg = []byte("-NOPERM access denied by policy\r\n")
+
+// OnData parses Redis data
+func (p *Parser) OnData(reply, endStream bool, dataBuffers [][]byte) (proxylib.OpType, int) {
+	if reply {
+		return p.onReply(dataBuffers)
+	}
+
+	// TODO: don't copy data to new slices
+	data := bytes.Join(dataBuffers, []byte{})
+
+	args, length, more, err := parseRequest(data)
+	if err != nil {
+		log.WithError(err).Error("Could not parse Redis request")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_LENGTH)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+	if len(args) == 0 {
+		// Empty requests are ignored by the server
+		return proxylib.PASS, length
+	}
+
+	req := redisRequest{
+		command: strings.ToUpper(string(args[0])),
+	}
+	req.keys = extractKeys(req.command, args)
+
+	logEntry := &cilium.LogEntry_GenericL7{
+		GenericL7: &cilium.L7LogEntry{
+			Proto: parserName,
+			Fields: map[string]string{
+				"command": req.command,
+				"keys":    string(bytes.Join(req.keys, []byte(", "))),
+			},
+		},
+	}
+
+	if p.connection.Matches(req) {
+		switch req.command {
+		case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE":
+			p.subscribed = true
+		}
+		if !p.subscribed {
+			p.replyQueue = append(p.replyQueue, &replyIntent{command: req.command})
+		}
+		p.connection.Log(cilium.EntryType_Request, logEntry)
+		return proxylib.PASS, length
+	}
+
+	// The denied reply must not overtake the replies to earlier requests
+	if len(p.replyQueue) > 0 {
+		p.replyQueue = append(p.replyQueue, &replyIntent{command: req.command, denied: true})
+	} else {
+		p.connection.Inject(true, DeniedMsg)
+	}
+	p.connection.Log(cilium.EntryType_Denied, logEntry)
+	log.Debugf("Policy mismatch, dropping %d bytes", length)
+	return proxylib.DROP, length
+}
+
+func (p *Parser) onReply(dataBuffers [][]byte) (proxylib.OpType, int) {
+	if injected := p.injectFromQueue(); injected > 0 {
+		return proxylib.INJECT, injected
+	}
+
+	// TODO: don't copy data to new slices
+	data := bytes.Join(dataBuffers, []byte{})
+	if len(data) == 0 {
+		return proxylib.NOP, 0
+	}
+
+	length, more, err := parseReply(data, 0, 0)
+	if err != nil {
+		log.WithError(err).Error("Could not parse Redis reply")
+		return proxylib.ERROR, int(proxylib.ERROR_INVALID_FRAME_TYPE)
+	}
+	if more > 0 {
+		return proxylib.MORE, more
+	}
+
+	// RESP3 push messages and pub/sub messages are not replies to a
+	// request. Replies to requests issued before the client subscribed
+	// are still correlated.
+	if data[0] == '>' || len(p.replyQueue) == 0 {
+		return proxylib.PASS, length
+	}
+
+	intent := p.replyQueue[0]
+	p.replyQueue = p.replyQueue[1:]
+	p.connection.Log(cilium.EntryType_Response,
+		&cilium.LogEntry_GenericL7{
+			GenericL7: &cilium.L7LogEntry{
+				Proto: parserName,
+				Fields: map[string]string{
+					"command": intent.command,
+				},
+			},
+		})
+
+	return proxylib.PASS, length
+}
+
+// injectFromQueue injects the denied replies at the head of the reply queue
+// and returns the number of bytes injected
+func (p *Parser) injectFromQueue() int {
+	injected := 0
+	for _, intent := range p.replyQueue {
+		if !intent.denied {
+			break
+		}
+		p.connection.Inject(true, DeniedMsg)
+		injected++
+	}
+	if injected > 0 {
+		p.replyQueue = p.replyQueue[injected:]
+	}
+	return injected * len(DeniedMsg)
+}
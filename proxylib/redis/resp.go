@@ -0,0 +1,204 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// RESP2/RESP3 framing, see https://redis.io/topics/protocol and
+// https://github.com/antirez/RESP3/blob/master/spec.md
+
+const (
+	// maxBulkLen is the maximum length of a bulk string, as enforced by
+	// the Redis server (proto-max-bulk-len)
+	maxBulkLen = 512 * 1024 * 1024
+
+	// maxArrayLen is the maximum number of elements in a multi-bulk
+	// request or an aggregate reply
+	maxArrayLen = 1024 * 1024 * 1024
+
+	// maxLineLen is the maximum length of an inline command or of a
+	// simple reply line (PROTO_INLINE_MAX_SIZE)
+	maxLineLen = 64 * 1024
+
+	// maxNesting is the maximum nesting depth of aggregate replies
+	maxNesting = 64
+)
+
+var (
+	errInvalidFrameType   = errors.New("invalid RESP frame type")
+	errInvalidFrameLength = errors.New("invalid RESP frame length")
+)
+
+// readLine returns the content of the CRLF terminated line starting at
+// offset and the offset following the line. If the line is not complete,
+// the minimum number of additional bytes needed is returned in more.
+func readLine(data []byte, offset int) (line []byte, next int, more int, err error) {
+	idx := bytes.Index(data[offset:], []byte("\r\n"))
+	if idx < 0 {
+		if len(data)-offset > maxLineLen {
+			return nil, 0, 0, errInvalidFrameLength
+		}
+		if len(data) > offset && data[len(data)-1] == '\r' {
+			return nil, 0, 1, nil
+		}
+		return nil, 0, 2, nil
+	}
+	return data[offset : offset+idx], offset + idx + 2, 0, nil
+}
+
+// readLength parses the length of a bulk string or aggregate. -1 denotes a
+// null value.
+func readLength(line []byte, max int64) (int, error) {
+	n, err := strconv.ParseInt(string(line), 10, 64)
+	if err != nil || n < -1 || n > max {
+		return 0, errInvalidFrameLength
+	}
+	return int(n), nil
+}
+
+// readBulk returns the payload of the bulk string of length n starting at
+// offset and the offset following the terminating CRLF
+func readBulk(data []byte, offset, n int) (payload []byte, next int, more int, err error) {
+	end := offset + n + 2
+	if len(data) < end {
+		return nil, 0, end - len(data), nil
+	}
+	if data[end-2] != '\r' || data[end-1] != '\n' {
+		return nil, 0, 0, errInvalidFrameLength
+	}
+	return data[offset : offset+n], end, 0, nil
+}
+
+// parseRequest parses a single request at the start of data. A request is
+// either a multi-bulk array of bulk strings or an inline command. It returns
+// the arguments and the length of the request. If the request is not
+// complete, the minimum number of additional bytes needed is returned in
+// more.
+func parseRequest(data []byte) (args [][]byte, length int, more int, err error) {
+	if len(data) == 0 {
+		return nil, 0, 1, nil
+	}
+
+	if data[0] != '*' {
+		// Inline commands may be terminated by a single LF
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			if len(data) > maxLineLen {
+				return nil, 0, 0, errInvalidFrameLength
+			}
+			return nil, 0, 1, nil
+		}
+		return bytes.Fields(data[:idx]), idx + 1, 0, nil
+	}
+
+	line, next, more, err := readLine(data, 1)
+	if more > 0 || err != nil {
+		return nil, 0, more, err
+	}
+	count, err := readLength(line, maxArrayLen)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for i := 0; i < count; i++ {
+		if next >= len(data) {
+			return nil, 0, 1, nil
+		}
+		if data[next] != '$' {
+			return nil, 0, 0, errInvalidFrameType
+		}
+		line, next, more, err = readLine(data, next+1)
+		if more > 0 || err != nil {
+			return nil, 0, more, err
+		}
+		n, err := readLength(line, maxBulkLen)
+		if err != nil || n < 0 {
+			return nil, 0, 0, errInvalidFrameLength
+		}
+		var arg []byte
+		arg, next, more, err = readBulk(data, next, n)
+		if more > 0 || err != nil {
+			return nil, 0, more, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, next, 0, nil
+}
+
+// parseReply parses the RESP2 or RESP3 value starting at offset and returns
+// the offset following the value. If the value is not complete, the minimum
+// number of additional bytes needed is returned in more.
+func parseReply(data []byte, offset, depth int) (next int, more int, err error) {
+	if depth > maxNesting {
+		return 0, 0, fmt.Errorf("reply nested deeper than %d levels", maxNesting)
+	}
+	if offset >= len(data) {
+		return 0, 1, nil
+	}
+
+	t := data[offset]
+	line, next, more, err := readLine(data, offset+1)
+	if more > 0 || err != nil {
+		return 0, more, err
+	}
+
+	switch t {
+	case '+', '-', ':', '_', ',', '#', '(':
+		// simple string, error, integer, null, double, boolean,
+		// big number
+		return next, 0, nil
+
+	case '$', '!', '=':
+		// bulk string, blob error, verbatim string
+		n, err := readLength(line, maxBulkLen)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n < 0 {
+			return next, 0, nil
+		}
+		_, next, more, err = readBulk(data, next, n)
+		return next, more, err
+
+	case '*', '~', '>', '%', '|':
+		// array, set, push, map, attribute
+		n, err := readLength(line, maxArrayLen)
+		if err != nil {
+			return 0, 0, err
+		}
+		if t == '%' || t == '|' {
+			n *= 2
+		}
+		for i := 0; i < n; i++ {
+			next, more, err = parseReply(data, next, depth+1)
+			if more > 0 || err != nil {
+				return 0, more, err
+			}
+		}
+		if t == '|' {
+			// Attributes are followed by the value they describe
+			return parseReply(data, next, depth+1)
+		}
+		return next, 0, nil
+	}
+
+	return 0, 0, errInvalidFrameType
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 Authors of Cilium
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !privileged_tests
+
+package redis
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type RedisSuite struct{}
+
+var _ = Suite(&RedisSuite{})
+
+func (s *RedisSuite) TestParseRequest(c *C) {
+	req := []byte("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n")
+	args, length, more, err := parseRequest(req)
+	c.Assert(err, IsNil)
+	c.Assert(more, Equals, 0)
+	c.Assert(length, Equals, len(req))
+	c.Assert(len(args), Equals, 3)
+	c.Assert(string(args[0]), Equals, "SET")
+	c.Assert(string(args[2]), Equals, "value")
+
+	// Pipelined requests are parsed one at a time
+	args, length, _, err = parseRequest(append(req, req...))
+	c.Assert(err, IsNil)
+	c.Assert(length, Equals, len(req))
+	c.Assert(len(args), Equals, 3)
+
+	// Partial requests need more data
+	for i := 0; i < len(req); i++ {
+		_, _, more, err = parseRequest(req[:i])
+		c.Assert(err, IsNil)
+		c.Assert(more > 0, Equals, true, Commentf("length %d", i))
+	}
+	_, _, more, _ = parseRequest(req[:28])
+	c.Assert(more, Equals, len(req)-28)
+
+	// Inline commands
+	args, length, _, err = parseRequest([]byte("get  key\r\nPING\r\n"))
+	c.Assert(err, IsNil)
+	c.Assert(length, Equals, 10)
+	c.Assert(len(args), Equals, 2)
+	c.Assert(string(args[1]), Equals, "key")
+
+	args, length, _, err = parseRequest([]byte("\r\n"))
+	c.Assert(err, IsNil)
+	c.Assert(length, Equals, 2)
+	c.Assert(len(args), Equals, 0)
+
+	// Invalid requests
+	for _, invalid := range []string{
+		"*1\r\n:1\r\n",
+		"*x\r\n",
+		"*1\r\n$-1\r\n",
+		"*1\r\n$3\r\nGETX\r\n",
+		"*1\r\n$1000000000\r\n",
+	} {
+		_, _, _, err = parseRequest([]byte(invalid))
+		c.Assert(err, Not(IsNil), Commentf("request %q", invalid))
+	}
+}
+
+func (s *RedisSuite) TestParseReply(c *C) {
+	for _, reply := range []string{
+		"+OK\r\n",
+		"-ERR unknown command\r\n",
+		":1000\r\n",
+		"$5\r\nhello\r\n",
+		"$-1\r\n",
+		"*-1\r\n",
+		"*0\r\n",
+		"*2\r\n$3\r\nfoo\r\n$-1\r\n",
+		"*2\r\n*1\r\n:1\r\n+two\r\n",
+		// RESP3
+		"_\r\n",
+		",3.14\r\n",
+		"#t\r\n",
+		"(3492890328409238509324850943850943825024385\r\n",
+		"!21\r\nSYNTAX invalid syntax\r\n",
+		"=15\r\ntxt:Some string\r\n",
+		"%2\r\n+first\r\n:1\r\n+second\r\n:2\r\n",
+		"~2\r\n+orange\r\n+apple\r\n",
+		">3\r\n+message\r\n+channel\r\n+hello\r\n",
+		"|1\r\n+key-popularity\r\n%1\r\n$1\r\na\r\n,0.19\r\n*1\r\n:2039123\r\n",
+	} {
+		data := []byte(reply + "+OK\r\n")
+		next, more, err := parseReply(data, 0, 0)
+		c.Assert(err, IsNil, Commentf("reply %q", reply))
+		c.Assert(more, Equals, 0, Commentf("reply %q", reply))
+		c.Assert(next, Equals, len(reply), Commentf("reply %q", reply))
+
+		for i := 0; i < len(reply); i++ {
+			_, more, err = parseReply([]byte(reply[:i]), 0, 0)
+			c.Assert(err, IsNil, Commentf("reply %q", reply[:i]))
+			c.Assert(more > 0, Equals, true, Commentf("reply %q", reply[:i]))
+		}
+	}
+
+	for _, invalid := range []string{
+		"?\r\n",
+		"$x\r\n",
+		"$3\r\nhello\r\n",
+		"*-2\r\n",
+	} {
+		_, _, err := parseReply([]byte(invalid), 0, 0)
+		c.Assert(err, Not(IsNil), Commentf("reply %q", invalid))
+	}
+}
+
+func (s *RedisSuite) TestExtractKeys(c *C) {
+	split := func(args ...string) [][]byte {
+		b := make([][]byte, 0, len(args))
+		for _, arg := range args {
+			b = append(b, []byte(arg))
+		}
+		return b
+	}
+	keys := func(cmd string, args ...string) []string {
+		k := []string{}
+		for _, key := range extractKeys(cmd, split(append([]string{cmd}, args...)...)) {
+			k = append(k, string(key))
+		}
+		return k
+	}
+
+	c.Assert(keys("GET", "a"), DeepEquals, []string{"a"})
+	c.Assert(keys("SET", "a", "1", "EX", "10"), DeepEquals, []string{"a"})
+	c.Assert(keys("MGET", "a", "b", "c"), DeepEquals, []string{"a", "b", "c"})
+	c.Assert(keys("MSET", "a", "1", "b", "2"), DeepEquals, []string{"a", "b"})
+	c.Assert(keys("BLPOP", "a", "b", "0"), DeepEquals, []string{"a", "b"})
+	c.Assert(keys("RENAME", "a", "b"), DeepEquals, []string{"a", "b"})
+	c.Assert(keys("GET"), DeepEquals, []string{})
+
+	// Subcommands precede the key
+	c.Assert(keys("OBJECT", "ENCODING", "a"), DeepEquals, []string{"a"})
+	c.Assert(keys("OBJECT", "HELP"), DeepEquals, []string{})
+	c.Assert(keys("XGROUP", "CREATE", "a", "g", "$"), DeepEquals, []string{"a"})
+	c.Assert(keys("XGROUP", "DESTROY", "a", "g"), DeepEquals, []string{"a"})
+
+	// Keys given as keyword arguments
+	c.Assert(keys("SORT", "a"), DeepEquals, []string{"a"})
+	c.Assert(keys("SORT", "a", "LIMIT", "0", "10", "ALPHA", "store", "b"), DeepEquals, []string{"a", "b"})
+	c.Assert(keys("SORT", "a", "BY", "w_*", "GET", "#", "GET", "o_*", "STORE", "b"), DeepEquals, []string{"a", "w_*", "o_*", "b"})
+	c.Assert(keys("SORT", "a", "BY", "nosort"), DeepEquals, []string{"a"})
+	c.Assert(keys("SORT", "a", "LIMIT", "STORE", "0", "DESC"), DeepEquals, []string{"a"})
+	c.Assert(keys("GEORADIUS", "a", "15", "37", "200", "km", "COUNT", "3", "STORE", "b", "STOREDIST", "c"), DeepEquals, []string{"a", "b", "c"})
+	c.Assert(keys("GEORADIUS", "a", "15", "37", "200", "km", "WITHDIST"), DeepEquals, []string{"a"})
+	c.Assert(keys("GEORADIUSBYMEMBER", "a", "STORE", "200", "km", "STORE", "b"), DeepEquals, []string{"a", "b"})
+	c.Assert(keys("GEORADIUSBYMEMBER", "a", "m", "200", "km", "storedist", "b"), DeepEquals, []string{"a", "b"})
+	c.Assert(keys("FLUSHALL"), DeepEquals, []string{})
+	c.Assert(keys("KEYS", "*"), DeepEquals, []string{})
+}